@@ -59,6 +59,7 @@ const envSchema = Joi.object({
   ML_BATCH_SIZE: Joi.number().default(10),
   ML_TIMEOUT: Joi.number().default(30000),
   ML_MAX_RETRIES: Joi.number().default(3),
+  ML_MAX_PAIR_BATCH_SIZE: Joi.number().default(100),
 });
 
 const { error, value: envVars } = envSchema.validate(process.env);
@@ -126,6 +127,7 @@ export const config = {
     batchSize: envVars.ML_BATCH_SIZE,
     timeout: envVars.ML_TIMEOUT,
     maxRetries: envVars.ML_MAX_RETRIES,
+    maxPairBatchSize: envVars.ML_MAX_PAIR_BATCH_SIZE,
   },
 } as const;
 
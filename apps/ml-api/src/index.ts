@@ -226,6 +226,8 @@ async function startServer() {
       logger.info('Available endpoints:');
       logger.info('  - POST /api/ml/score (single image processing)');
       logger.info('  - POST /api/ml/score/batch (batch image processing)');
+      logger.info('  - POST /api/ml/detect (face detection and photo checks)');
+      logger.info('  - POST /api/ml/score-pairs (batch discovery pair scoring)');
       logger.info('  - GET /api/ml/models/status (model status)');
       logger.info('  - GET /api/ml/health (service health)');
       logger.info('  - POST /api/ml/face-score (legacy compatibility)');
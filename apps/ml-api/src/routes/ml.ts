@@ -2,6 +2,7 @@ import { Router } from 'express';
 import multer from 'multer';
 import { config } from '../config';
 import { mlService } from '../services/mlService';
+import { getFaceScoringQueue } from '../services/queue';
 import { ApiResponse, LegacyApiResponse } from '@shared/types';
 import { ScoringResult } from '@shared/types';
 import {
@@ -272,12 +273,129 @@ router.get('/models/status', async (req, res) => {
   }
 });
 
+/**
+ * @description Face detector and photo inspection endpoint
+ * POST /api/ml/detect
+ * Accepts a multipart image or a base64 `image` field and returns dimensions,
+ * brightness, sharpness and detected face count for pre-scoring quality gates
+ */
+router.post('/detect', upload.single('image'), async (req, res) => {
+  try {
+    let imageBuffer: Buffer | undefined = req.file?.buffer;
+
+    if (!imageBuffer && typeof req.body.image === 'string') {
+      const base64 = req.body.image.replace(/^data:image\/\w+;base64,/, '');
+      imageBuffer = Buffer.from(base64, 'base64');
+    }
+
+    if (!imageBuffer || imageBuffer.length === 0) {
+      const error = new ValidationError('No image provided');
+      return res.status(400).json(formatErrorResponse(error));
+    }
+
+    const inspection = await mlService.inspectImage(imageBuffer);
+
+    const response: ApiResponse<typeof inspection> = {
+      status: 'success',
+      message: `Detected ${inspection.faceCount} face(s)`,
+      data: inspection,
+    };
+
+    res.json(response);
+  } catch (error) {
+    logger.error('Error in face detection endpoint:', error);
+
+    if (error instanceof ValidationError || error instanceof ProcessingError) {
+      const errorResponse = formatErrorResponse(error);
+      return res.status(error.statusCode).json(errorResponse);
+    }
+
+    const processingError = new ProcessingError('Face detection failed');
+    const errorResponse = formatErrorResponse(processingError);
+    return res.status(500).json(errorResponse);
+  }
+});
+
+/**
+ * @description Batch pair scoring for discovery ranking
+ * POST /api/ml/score-pairs
+ * Scores up to ML_MAX_PAIR_BATCH_SIZE viewer/candidate embedding pairs in a
+ * single request so the gateway can rank a discovery page in one round trip
+ */
+router.post('/score-pairs', async (req, res) => {
+  try {
+    const { pairs } = req.body;
+
+    if (!Array.isArray(pairs) || pairs.length === 0) {
+      const error = new ValidationError('pairs must be a non-empty array');
+      return res.status(400).json(formatErrorResponse(error));
+    }
+
+    if (pairs.length > config.ml.maxPairBatchSize) {
+      const error = new ValidationError(
+        `Maximum ${config.ml.maxPairBatchSize} pairs per request`
+      );
+      return res.status(400).json(formatErrorResponse(error));
+    }
+
+    const isEmbedding = (value: unknown): value is number[] =>
+      Array.isArray(value) &&
+      value.length > 0 &&
+      value.every(v => typeof v === 'number');
+
+    const invalid = pairs.find(
+      (pair: any) =>
+        typeof pair?.id !== 'string' ||
+        !isEmbedding(pair.viewer) ||
+        !isEmbedding(pair.candidate)
+    );
+    if (invalid) {
+      const error = new ValidationError(
+        'Each pair needs a string id and numeric viewer/candidate embeddings'
+      );
+      return res.status(400).json(formatErrorResponse(error));
+    }
+
+    const startTime = Date.now();
+    const scores = await mlService.scorePairs(pairs);
+
+    const response: ApiResponse<{
+      scores: typeof scores;
+      processingTime: number;
+    }> = {
+      status: 'success',
+      message: `Scored ${scores.length} pair(s)`,
+      data: {
+        scores,
+        processingTime: Date.now() - startTime,
+      },
+    };
+
+    res.json(response);
+  } catch (error) {
+    logger.error('Error in pair scoring endpoint:', error);
+
+    if (error instanceof ValidationError || error instanceof ProcessingError) {
+      const errorResponse = formatErrorResponse(error);
+      return res.status(error.statusCode).json(errorResponse);
+    }
+
+    const processingError = new ProcessingError('Pair scoring failed');
+    const errorResponse = formatErrorResponse(processingError);
+    return res.status(500).json(errorResponse);
+  }
+});
+
 /**
  * @description ML service health check endpoint
  * GET /api/ml/health
  */
 router.get('/health', async (req, res) => {
   try {
+    const modelInfo = mlService.modelInfo;
+    const queue = getFaceScoringQueue();
+    const jobCounts = queue ? await queue.getJobCounts() : null;
+
     const healthData = {
       status: mlService.isHealthy ? 'healthy' : 'unhealthy',
       service: 'advanced-ml-api',
@@ -286,8 +404,23 @@ router.get('/health', async (req, res) => {
       memory: process.memoryUsage(),
       models: {
         initialized: mlService.isHealthy,
-        count: mlService.modelInfo.length,
+        count: modelInfo.length,
+      },
+      details: {
+        faceDetection: modelInfo.some(m => m.name === 'face_detection'),
+        faceEmbedding: modelInfo.some(m => m.name === 'face_embedding'),
+        attractiveness: modelInfo.some(m => m.name === 'attractiveness'),
+        overall: mlService.isHealthy,
       },
+      // Scoring queue depth, so gateways can shed ML work under backlog
+      queue: jobCounts
+        ? {
+            active: jobCounts.active,
+            waiting: jobCounts.waiting,
+            completed: jobCounts.completed,
+            failed: jobCounts.failed,
+          }
+        : undefined,
       timestamp: new Date().toISOString(),
     };
 
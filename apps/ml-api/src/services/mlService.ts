@@ -3,9 +3,40 @@ import sharp from 'sharp';
 import path from 'path';
 import fs from 'fs/promises';
 import { config } from '../config';
-import { logger, ProcessingError } from '@shared/utils';
+import { logger, ProcessingError, ValidationError } from '@shared/utils';
 import { ScoringResult, ModelConfig } from '@shared/types';
 
+/**
+ * @description Result of a quick image inspection (no scoring)
+ */
+export interface ImageInspection {
+  width: number;
+  height: number;
+  format: string;
+  brightness: number;
+  sharpness: number;
+  faceCount: number;
+  faceBoxes: number[][];
+}
+
+/**
+ * @description A viewer/candidate embedding pair to score for discovery
+ */
+export interface EmbeddingPair {
+  id: string;
+  viewer: number[];
+  candidate: number[];
+}
+
+/**
+ * @description Compatibility of a scored embedding pair
+ */
+export interface PairScore {
+  id: string;
+  similarity: number;
+  compatibility: number;
+}
+
 /**
  * @description Advanced ML Service with real ONNX model processing
  * Migrated from nested API with full model inference capabilities
@@ -142,6 +173,118 @@ export class MLService {
     }
   }
 
+  /**
+   * @description Lightweight photo inspection used by the gateway quality gate
+   * Reports dimensions, brightness, sharpness and face count without scoring
+   */
+  async inspectImage(imageBuffer: Buffer): Promise<ImageInspection> {
+    if (!this.isInitialized) {
+      throw new ProcessingError('ML service not initialized');
+    }
+
+    try {
+      const metadata = await sharp(imageBuffer).metadata();
+
+      // Mean luminance of the greyscale image (0-255)
+      const { channels: luminance } = await sharp(imageBuffer)
+        .greyscale()
+        .stats();
+
+      // Variance of the Laplacian as a blur measure; offset keeps negatives
+      const { channels: edges } = await sharp(imageBuffer)
+        .greyscale()
+        .resize(512, 512, { fit: 'inside', withoutEnlargement: true })
+        .convolve({
+          width: 3,
+          height: 3,
+          kernel: [0, 1, 0, 1, -4, 1, 0, 1, 0],
+          offset: 128,
+        })
+        .stats();
+
+      const processedImage = await this.preprocessImage(imageBuffer);
+      const faceDetection = await this.detectFaces(processedImage);
+
+      return {
+        width: metadata.width || 0,
+        height: metadata.height || 0,
+        format: metadata.format || 'unknown',
+        brightness: luminance[0].mean,
+        sharpness: Math.pow(edges[0].stdev, 2),
+        faceCount: faceDetection.count,
+        faceBoxes: faceDetection.boxes,
+      };
+    } catch (error) {
+      logger.error('Error inspecting image:', error);
+      throw new ProcessingError('Image inspection failed', {
+        imageSize: imageBuffer.length,
+        originalError: error instanceof Error ? error.message : String(error),
+      });
+    }
+  }
+
+  /**
+   * @description Score viewer/candidate embedding pairs in one pass
+   * Compatibility (0-100) blends facial similarity with how close the two
+   * attractiveness scores are; each distinct embedding is scored once
+   */
+  async scorePairs(pairs: EmbeddingPair[]): Promise<PairScore[]> {
+    if (!this.isInitialized) {
+      throw new ProcessingError('ML service not initialized');
+    }
+
+    const attractiveness = new Map<string, Promise<number>>();
+    const scoreOf = (embedding: number[]): Promise<number> => {
+      const key = embedding.join(',');
+      let score = attractiveness.get(key);
+      if (!score) {
+        score = this.calculateAttractiveness(embedding).then(r => r.score);
+        attractiveness.set(key, score);
+      }
+      return score;
+    };
+
+    return Promise.all(
+      pairs.map(async pair => {
+        if (pair.viewer.length !== pair.candidate.length) {
+          throw new ValidationError(
+            `Embedding dimensions differ for pair ${pair.id}`
+          );
+        }
+
+        const [viewerScore, candidateScore] = await Promise.all([
+          scoreOf(pair.viewer),
+          scoreOf(pair.candidate),
+        ]);
+
+        const similarity = this.cosineSimilarity(pair.viewer, pair.candidate);
+        const closeness = 1 - Math.abs(viewerScore - candidateScore);
+        const compatibility = ((similarity + 1) / 2) * 0.6 + closeness * 0.4;
+
+        return {
+          id: pair.id,
+          similarity,
+          compatibility: Math.round(compatibility * 10000) / 100,
+        };
+      })
+    );
+  }
+
+  private cosineSimilarity(a: number[], b: number[]): number {
+    let dot = 0;
+    let normA = 0;
+    let normB = 0;
+    for (let i = 0; i < a.length; i++) {
+      dot += a[i] * b[i];
+      normA += a[i] * a[i];
+      normB += b[i] * b[i];
+    }
+    if (normA === 0 || normB === 0) {
+      return 0;
+    }
+    return dot / (Math.sqrt(normA) * Math.sqrt(normB));
+  }
+
   /**
    * @description Preprocess image using Sharp for ML model input
    * Resize to 224x224, normalize pixel values, convert to RGB
@@ -13,14 +13,16 @@ const createJestConfig = nextJest({
 // Add any custom config to be passed to Jest
 const customJestConfig = {
   setupFilesAfterEnv: ['<rootDir>/jest.setup.js'],
-  testEnvironment: 'jsdom',
+  // Unit tests cover server code; a component test can opt into jsdom
+  // with a @jest-environment docblock
+  testEnvironment: 'node',
   testMatch: [
     '<rootDir>/test/**/*.test.{ts,tsx}',
     '<rootDir>/src/**/__tests__/**/*.{ts,tsx}',
     '<rootDir>/src/**/*.{test,spec}.{ts,tsx}',
   ],
   moduleDirectories: ['node_modules', '<rootDir>/'],
-  moduleNameMapping: {
+  moduleNameMapper: {
     '^@/(.*)$': '<rootDir>/src/$1',
     '^@shared/types$': '<rootDir>/../../packages/shared-types/src',
     '^@shared/utils$': '<rootDir>/../../packages/shared-utils/src',
@@ -48,22 +50,6 @@ const customJestConfig = {
   testTimeout: 30000, // 30 seconds for integration tests
   verbose: true,
   testPathIgnorePatterns: ['/node_modules/', '/.next/', '/dist/', '/build/'],
-  // Environment variables for tests
-  setupFiles: ['<rootDir>/jest.env.js'],
-  // Integration test specific settings
-  projects: [
-    {
-      displayName: 'Unit Tests',
-      testMatch: ['<rootDir>/src/**/*.{test,spec}.{ts,tsx}'],
-      testEnvironment: 'jsdom',
-    },
-    {
-      displayName: 'Integration Tests',
-      testMatch: ['<rootDir>/test/integration/**/*.test.{ts,tsx}'],
-      testEnvironment: 'node',
-      testTimeout: 60000, // Longer timeout for integration tests
-    },
-  ],
 };
 
 // createJestConfig is exported this way to ensure that next/jest can load the Next.js config which is async
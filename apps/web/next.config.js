@@ -5,13 +5,12 @@ const nextConfig = {
   reactStrictMode: true,
   output: "standalone", // For Docker deployment, creates a standalone output
   // output: 'export', // Alternative for static export if needed, but not for full SSR
+  // Inlined at build time, so no secrets here: the server reads those at
+  // runtime, possibly from a secret store (see src/lib/secrets.ts)
   env: {
     NEXT_PUBLIC_WORLDCOIN_APP_ID: process.env.NEXT_PUBLIC_WORLDCOIN_APP_ID,
-    WORLDCOIN_APP_SECRET: process.env.WORLDCOIN_APP_SECRET,
     NEXT_PUBLIC_WALLET_CONNECT_PROJECT_ID:
       process.env.NEXT_PUBLIC_WALLET_CONNECT_PROJECT_ID,
-    ALCHEMY_API_KEY: process.env.ALCHEMY_API_KEY,
-    JWT_SECRET: process.env.JWT_SECRET,
     NFT_CONTRACT_ADDRESS: process.env.NFT_CONTRACT_ADDRESS,
     NEXT_PUBLIC_API_BASE_URL: API_BASE_URL,
     // Add other environment variables as needed
@@ -0,0 +1,8 @@
+-- AlterTable
+ALTER TABLE "Payment" ADD COLUMN "kind" TEXT NOT NULL DEFAULT 'purchase';
+
+-- DropIndex
+DROP INDEX "Payment_userId_idx";
+
+-- CreateIndex
+CREATE INDEX "Payment_userId_createdAt_idx" ON "Payment"("userId", "createdAt");
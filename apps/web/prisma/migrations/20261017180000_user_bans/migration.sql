@@ -0,0 +1,6 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "bannedUntil" DATETIME;
+ALTER TABLE "User" ADD COLUMN "banReason" TEXT;
+
+-- CreateIndex
+CREATE INDEX "User_status_idx" ON "User"("status");
@@ -0,0 +1,24 @@
+-- CreateTable
+CREATE TABLE "Report" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "reporterId" TEXT NOT NULL,
+    "reportedUserId" TEXT NOT NULL,
+    "reason" TEXT NOT NULL,
+    "details" TEXT,
+    "signalId" TEXT,
+    "photoUrl" TEXT,
+    "status" TEXT NOT NULL DEFAULT 'open',
+    "action" TEXT,
+    "resolvedBy" TEXT,
+    "resolutionNote" TEXT,
+    "resolvedAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "Report_reporterId_fkey" FOREIGN KEY ("reporterId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "Report_reportedUserId_fkey" FOREIGN KEY ("reportedUserId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "Report_status_createdAt_idx" ON "Report"("status", "createdAt");
+
+-- CreateIndex
+CREATE INDEX "Report_reportedUserId_idx" ON "Report"("reportedUserId");
@@ -0,0 +1,5 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "shadowbanned" BOOLEAN NOT NULL DEFAULT false;
+
+-- AlterTable
+ALTER TABLE "Signal" ADD COLUMN "suppressed" BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,19 @@
+-- CreateTable
+CREATE TABLE "AnalyticsDaily" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "date" TEXT NOT NULL,
+    "metric" TEXT NOT NULL,
+    "dimension" TEXT NOT NULL DEFAULT '',
+    "value" INTEGER NOT NULL,
+    "updatedAt" DATETIME NOT NULL
+);
+
+-- CreateTable
+CREATE TABLE "AnalyticsCheckpoint" (
+    "name" TEXT NOT NULL PRIMARY KEY,
+    "position" TEXT NOT NULL,
+    "updatedAt" DATETIME NOT NULL
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "AnalyticsDaily_date_metric_dimension_key" ON "AnalyticsDaily"("date", "metric", "dimension");
@@ -0,0 +1,19 @@
+-- AlterTable
+ALTER TABLE "AuditLog" ADD COLUMN "ipAddress" TEXT;
+
+-- CreateIndex
+CREATE INDEX "AuditLog_actorType_actorId_idx" ON "AuditLog"("actorType", "actorId");
+
+-- CreateIndex
+CREATE INDEX "AuditLog_createdAt_idx" ON "AuditLog"("createdAt");
+
+-- Append-only: reject any change to existing entries
+CREATE TRIGGER "AuditLog_no_update" BEFORE UPDATE ON "AuditLog"
+BEGIN
+    SELECT RAISE(ABORT, 'AuditLog is append-only');
+END;
+
+CREATE TRIGGER "AuditLog_no_delete" BEFORE DELETE ON "AuditLog"
+BEGIN
+    SELECT RAISE(ABORT, 'AuditLog is append-only');
+END;
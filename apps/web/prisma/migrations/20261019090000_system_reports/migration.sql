@@ -0,0 +1,27 @@
+-- RedefineTables
+PRAGMA defer_foreign_keys=ON;
+PRAGMA foreign_keys=OFF;
+CREATE TABLE "new_Report" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "reporterId" TEXT,
+    "reportedUserId" TEXT NOT NULL,
+    "reason" TEXT NOT NULL,
+    "details" TEXT,
+    "signalId" TEXT,
+    "photoUrl" TEXT,
+    "status" TEXT NOT NULL DEFAULT 'open',
+    "action" TEXT,
+    "resolvedBy" TEXT,
+    "resolutionNote" TEXT,
+    "resolvedAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "Report_reporterId_fkey" FOREIGN KEY ("reporterId") REFERENCES "User" ("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "Report_reportedUserId_fkey" FOREIGN KEY ("reportedUserId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+INSERT INTO "new_Report" ("action", "createdAt", "details", "id", "photoUrl", "reason", "reportedUserId", "reporterId", "resolutionNote", "resolvedAt", "resolvedBy", "signalId", "status") SELECT "action", "createdAt", "details", "id", "photoUrl", "reason", "reportedUserId", "reporterId", "resolutionNote", "resolvedAt", "resolvedBy", "signalId", "status" FROM "Report";
+DROP TABLE "Report";
+ALTER TABLE "new_Report" RENAME TO "Report";
+CREATE INDEX "Report_status_createdAt_idx" ON "Report"("status", "createdAt");
+CREATE INDEX "Report_reportedUserId_idx" ON "Report"("reportedUserId");
+PRAGMA foreign_keys=ON;
+PRAGMA defer_foreign_keys=OFF;
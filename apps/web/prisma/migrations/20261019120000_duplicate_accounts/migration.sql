@@ -0,0 +1,42 @@
+-- CreateTable
+CREATE TABLE "IdentitySignal" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "kind" TEXT NOT NULL,
+    "value" TEXT NOT NULL,
+    "firstSeenAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "lastSeenAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "IdentitySignal_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateTable
+CREATE TABLE "DuplicateFlag" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "user1Id" TEXT NOT NULL,
+    "user2Id" TEXT NOT NULL,
+    "score" REAL NOT NULL,
+    "evidence" JSONB NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'open',
+    "reviewedBy" TEXT,
+    "reviewNote" TEXT,
+    "reviewedAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "DuplicateFlag_user1Id_fkey" FOREIGN KEY ("user1Id") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "DuplicateFlag_user2Id_fkey" FOREIGN KEY ("user2Id") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "IdentitySignal_userId_kind_value_key" ON "IdentitySignal"("userId", "kind", "value");
+
+-- CreateIndex
+CREATE INDEX "IdentitySignal_kind_value_idx" ON "IdentitySignal"("kind", "value");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "DuplicateFlag_user1Id_user2Id_key" ON "DuplicateFlag"("user1Id", "user2Id");
+
+-- CreateIndex
+CREATE INDEX "DuplicateFlag_status_createdAt_idx" ON "DuplicateFlag"("status", "createdAt");
+
+-- CreateIndex
+CREATE INDEX "DuplicateFlag_user2Id_idx" ON "DuplicateFlag"("user2Id");
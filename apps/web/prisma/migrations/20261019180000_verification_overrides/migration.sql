@@ -0,0 +1,4 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "photoVerified" BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE "User" ADD COLUMN "nftOverride" BOOLEAN;
+ALTER TABLE "User" ADD COLUMN "photoOverride" BOOLEAN;
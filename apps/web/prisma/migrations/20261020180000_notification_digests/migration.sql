@@ -0,0 +1,5 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "timezone" TEXT;
+ALTER TABLE "User" ADD COLUMN "quietHoursStart" INTEGER;
+ALTER TABLE "User" ADD COLUMN "quietHoursEnd" INTEGER;
+ALTER TABLE "User" ADD COLUMN "lastDigestAt" DATETIME;
@@ -0,0 +1,16 @@
+-- CreateTable
+CREATE TABLE "ChatLink" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "provider" TEXT NOT NULL,
+    "externalId" TEXT NOT NULL,
+    "linkedAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "disabledAt" DATETIME,
+    CONSTRAINT "ChatLink_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "ChatLink_userId_provider_key" ON "ChatLink"("userId", "provider");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "ChatLink_provider_externalId_key" ON "ChatLink"("provider", "externalId");
@@ -0,0 +1,12 @@
+-- CreateTable
+CREATE TABLE "UserLocation" (
+    "userId" TEXT NOT NULL PRIMARY KEY,
+    "geohash" TEXT NOT NULL,
+    "precision" INTEGER NOT NULL,
+    "source" TEXT NOT NULL,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "UserLocation_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "UserLocation_geohash_idx" ON "UserLocation"("geohash");
@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "hideDistance" BOOLEAN NOT NULL DEFAULT false;
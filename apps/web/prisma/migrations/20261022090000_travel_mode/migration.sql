@@ -0,0 +1,12 @@
+-- CreateTable
+CREATE TABLE "TravelLocation" (
+    "userId" TEXT NOT NULL PRIMARY KEY,
+    "city" TEXT NOT NULL,
+    "geohash" TEXT NOT NULL,
+    "startedAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "expiresAt" DATETIME NOT NULL,
+    CONSTRAINT "TravelLocation_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "TravelLocation_geohash_idx" ON "TravelLocation"("geohash");
@@ -0,0 +1,65 @@
+-- CreateTable
+CREATE TABLE "Place" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "kind" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "nameTh" TEXT,
+    "country" TEXT NOT NULL,
+    "cityId" TEXT,
+    "lat" REAL NOT NULL,
+    "lng" REAL NOT NULL,
+    "active" BOOLEAN NOT NULL DEFAULT true,
+    "sortOrder" INTEGER NOT NULL DEFAULT 0,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "Place_cityId_fkey" FOREIGN KEY ("cityId") REFERENCES "Place" ("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "Place_kind_active_idx" ON "Place"("kind", "active");
+
+-- Seed the directory with the cities and campuses the app launched with
+INSERT INTO "Place" ("id", "kind", "name", "nameTh", "country", "cityId", "lat", "lng", "sortOrder", "updatedAt") VALUES
+    ('bangkok', 'city', 'Bangkok', 'กรุงเทพมหานคร', 'TH', NULL, 13.7563, 100.5018, 0, CURRENT_TIMESTAMP),
+    ('chiang_mai', 'city', 'Chiang Mai', 'เชียงใหม่', 'TH', NULL, 18.7883, 98.9853, 1, CURRENT_TIMESTAMP),
+    ('phuket', 'city', 'Phuket', 'ภูเก็ต', 'TH', NULL, 7.8804, 98.3923, 2, CURRENT_TIMESTAMP),
+    ('pattaya', 'city', 'Pattaya', 'พัทยา', 'TH', NULL, 12.9236, 100.8825, 3, CURRENT_TIMESTAMP),
+    ('khon_kaen', 'city', 'Khon Kaen', 'ขอนแก่น', 'TH', NULL, 16.4419, 102.836, 4, CURRENT_TIMESTAMP),
+    ('hat_yai', 'city', 'Hat Yai', 'หาดใหญ่', 'TH', NULL, 7.0086, 100.4747, 5, CURRENT_TIMESTAMP),
+    ('singapore', 'city', 'Singapore', 'สิงคโปร์', 'SG', NULL, 1.3521, 103.8198, 6, CURRENT_TIMESTAMP),
+    ('kuala_lumpur', 'city', 'Kuala Lumpur', 'กัวลาลัมเปอร์', 'MY', NULL, 3.139, 101.6869, 7, CURRENT_TIMESTAMP),
+    ('hong_kong', 'city', 'Hong Kong', 'ฮ่องกง', 'HK', NULL, 22.3193, 114.1694, 8, CURRENT_TIMESTAMP),
+    ('taipei', 'city', 'Taipei', 'ไทเป', 'TW', NULL, 25.033, 121.5654, 9, CURRENT_TIMESTAMP),
+    ('tokyo', 'city', 'Tokyo', 'โตเกียว', 'JP', NULL, 35.6762, 139.6503, 10, CURRENT_TIMESTAMP),
+    ('seoul', 'city', 'Seoul', 'โซล', 'KR', NULL, 37.5665, 126.978, 11, CURRENT_TIMESTAMP),
+    ('bu', 'campus', 'Bangkok University', 'มหาวิทยาลัยกรุงเทพ', 'TH', 'bangkok', 14.0395, 100.6136, 0, CURRENT_TIMESTAMP),
+    ('cu', 'campus', 'Chulalongkorn University', 'จุฬาลงกรณ์มหาวิทยาลัย', 'TH', 'bangkok', 13.7384, 100.532, 1, CURRENT_TIMESTAMP),
+    ('tu', 'campus', 'Thammasat University', 'มหาวิทยาลัยธรรมศาสตร์', 'TH', 'bangkok', 13.757, 100.4905, 2, CURRENT_TIMESTAMP),
+    ('ku', 'campus', 'Kasetsart University', 'มหาวิทยาลัยเกษตรศาสตร์', 'TH', 'bangkok', 13.8476, 100.5696, 3, CURRENT_TIMESTAMP),
+    ('mu', 'campus', 'Mahidol University', 'มหาวิทยาลัยมหิดล', 'TH', 'bangkok', 13.7947, 100.3233, 4, CURRENT_TIMESTAMP),
+    ('kmutt', 'campus', 'KMUTT', 'มหาวิทยาลัยเทคโนโลยีพระจอมเกล้าธนบุรี', 'TH', 'bangkok', 13.6512, 100.4938, 5, CURRENT_TIMESTAMP);
+
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "cityId" TEXT REFERENCES "Place" ("id") ON DELETE SET NULL ON UPDATE CASCADE;
+ALTER TABLE "User" ADD COLUMN "campusId" TEXT REFERENCES "Place" ("id") ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- Carry over free-text values that name a directory place
+UPDATE "User" SET "cityId" = (
+    SELECT "id" FROM "Place"
+    WHERE "kind" = 'city'
+      AND (lower("Place"."name") = lower(trim("User"."city")) OR "Place"."id" = lower(trim("User"."city")))
+) WHERE "city" IS NOT NULL;
+UPDATE "User" SET "campusId" = (
+    SELECT "id" FROM "Place"
+    WHERE "kind" = 'campus'
+      AND ("Place"."id" = json_extract("User"."tags", '$.university') OR lower("Place"."name") = lower(json_extract("User"."tags", '$.university')))
+) WHERE json_extract("tags", '$.university') IS NOT NULL;
+
+-- AlterTable
+ALTER TABLE "User" DROP COLUMN "city";
+
+-- CreateIndex
+CREATE INDEX "User_cityId_idx" ON "User"("cityId");
+
+-- CreateIndex
+CREATE INDEX "User_campusId_idx" ON "User"("campusId");
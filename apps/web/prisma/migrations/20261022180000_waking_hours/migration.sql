@@ -0,0 +1,15 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "detectedTimezone" TEXT;
+
+-- AlterTable
+ALTER TABLE "Place" ADD COLUMN "timezone" TEXT;
+
+UPDATE "Place" SET "timezone" = CASE "country"
+    WHEN 'TH' THEN 'Asia/Bangkok'
+    WHEN 'SG' THEN 'Asia/Singapore'
+    WHEN 'MY' THEN 'Asia/Kuala_Lumpur'
+    WHEN 'HK' THEN 'Asia/Hong_Kong'
+    WHEN 'TW' THEN 'Asia/Taipei'
+    WHEN 'JP' THEN 'Asia/Tokyo'
+    WHEN 'KR' THEN 'Asia/Seoul'
+END;
@@ -0,0 +1,5 @@
+-- CreateTable
+CREATE TABLE "ReplicationHeartbeat" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "beatAt" DATETIME NOT NULL
+);
@@ -0,0 +1,17 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "deletedAt" DATETIME;
+
+-- AlterTable
+ALTER TABLE "Signal" ADD COLUMN "deletedAt" DATETIME;
+
+-- AlterTable
+ALTER TABLE "Match" ADD COLUMN "deletedAt" DATETIME;
+
+-- CreateIndex
+CREATE INDEX "User_deletedAt_idx" ON "User"("deletedAt");
+
+-- CreateIndex
+CREATE INDEX "Signal_deletedAt_idx" ON "Signal"("deletedAt");
+
+-- CreateIndex
+CREATE INDEX "Match_deletedAt_idx" ON "Match"("deletedAt");
@@ -0,0 +1,19 @@
+-- CreateTable
+CREATE TABLE "DeepLink" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "slug" TEXT NOT NULL,
+    "targetType" TEXT NOT NULL,
+    "targetId" TEXT NOT NULL,
+    "createdBy" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "DeepLink_createdBy_fkey" FOREIGN KEY ("createdBy") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "DeepLink_slug_key" ON "DeepLink"("slug");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "DeepLink_createdBy_targetType_targetId_key" ON "DeepLink"("createdBy", "targetType", "targetId");
+
+-- CreateIndex
+CREATE INDEX "DeepLink_targetType_targetId_idx" ON "DeepLink"("targetType", "targetId");
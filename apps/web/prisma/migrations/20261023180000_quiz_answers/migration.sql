@@ -0,0 +1,14 @@
+-- CreateTable
+CREATE TABLE "QuizAnswer" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "questionId" TEXT NOT NULL,
+    "answer" TEXT NOT NULL,
+    "acceptable" JSONB NOT NULL,
+    "importance" TEXT NOT NULL,
+    "answeredAt" DATETIME NOT NULL,
+    CONSTRAINT "QuizAnswer_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "QuizAnswer_userId_questionId_key" ON "QuizAnswer"("userId", "questionId");
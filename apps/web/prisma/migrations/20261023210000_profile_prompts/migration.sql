@@ -0,0 +1,15 @@
+-- CreateTable
+CREATE TABLE "ProfilePrompt" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "promptId" TEXT NOT NULL,
+    "answer" TEXT NOT NULL,
+    "position" INTEGER NOT NULL,
+    "removedAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "ProfilePrompt_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "ProfilePrompt_userId_promptId_key" ON "ProfilePrompt"("userId", "promptId");
@@ -0,0 +1,17 @@
+-- CreateTable
+CREATE TABLE "VoiceIntro" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'pending_upload',
+    "uploadKey" TEXT NOT NULL,
+    "mediaKey" TEXT,
+    "durationMs" INTEGER,
+    "transcript" TEXT,
+    "rejectionReason" TEXT,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "VoiceIntro_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "VoiceIntro_userId_status_idx" ON "VoiceIntro"("userId", "status");
@@ -0,0 +1,5 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "photoRevealMode" BOOLEAN NOT NULL DEFAULT false;
+
+-- AlterTable
+ALTER TABLE "Match" ADD COLUMN "messageCount" INTEGER NOT NULL DEFAULT 0;
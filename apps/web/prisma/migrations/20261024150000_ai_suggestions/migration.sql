@@ -0,0 +1,3 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "aiSuggestions" BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE "User" ADD COLUMN "aiMessageAccess" BOOLEAN NOT NULL DEFAULT true;
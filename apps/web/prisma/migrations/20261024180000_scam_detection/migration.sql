@@ -0,0 +1,3 @@
+-- AlterTable
+ALTER TABLE "Signal" ADD COLUMN "scamScore" REAL;
+ALTER TABLE "Signal" ADD COLUMN "scamFlags" JSONB;
@@ -0,0 +1,4 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "birthDate" DATETIME;
+ALTER TABLE "User" ADD COLUMN "ageVerifiedAt" DATETIME;
+ALTER TABLE "User" ADD COLUMN "ageVerifyMethod" TEXT;
@@ -0,0 +1,31 @@
+-- CreateTable
+CREATE TABLE "PolicyVersion" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "kind" TEXT NOT NULL,
+    "version" TEXT NOT NULL,
+    "url" TEXT NOT NULL,
+    "summary" TEXT,
+    "effectiveAt" DATETIME NOT NULL,
+    "createdBy" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateTable
+CREATE TABLE "PolicyAcceptance" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "policyVersionId" TEXT NOT NULL,
+    "acceptedAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "ipAddress" TEXT,
+    CONSTRAINT "PolicyAcceptance_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "PolicyAcceptance_policyVersionId_fkey" FOREIGN KEY ("policyVersionId") REFERENCES "PolicyVersion" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "PolicyVersion_kind_version_key" ON "PolicyVersion"("kind", "version");
+
+-- CreateIndex
+CREATE INDEX "PolicyVersion_kind_effectiveAt_idx" ON "PolicyVersion"("kind", "effectiveAt");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "PolicyAcceptance_userId_policyVersionId_key" ON "PolicyAcceptance"("userId", "policyVersionId");
@@ -0,0 +1,3 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "trustScore" REAL NOT NULL DEFAULT 0.5;
+ALTER TABLE "User" ADD COLUMN "trustScoredAt" DATETIME;
@@ -0,0 +1,28 @@
+-- CreateTable
+CREATE TABLE "SafetyCheckIn" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "matchId" TEXT NOT NULL,
+    "place" TEXT,
+    "dateAt" DATETIME NOT NULL,
+    "checkInAt" DATETIME NOT NULL,
+    "contactName" TEXT NOT NULL,
+    "contactEmail" TEXT,
+    "shareToken" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'scheduled',
+    "remindedAt" DATETIME,
+    "escalatedAt" DATETIME,
+    "checkedInAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "SafetyCheckIn_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "SafetyCheckIn_matchId_fkey" FOREIGN KEY ("matchId") REFERENCES "Match" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "SafetyCheckIn_shareToken_key" ON "SafetyCheckIn"("shareToken");
+
+-- CreateIndex
+CREATE INDEX "SafetyCheckIn_status_checkInAt_idx" ON "SafetyCheckIn"("status", "checkInAt");
+
+-- CreateIndex
+CREATE INDEX "SafetyCheckIn_userId_idx" ON "SafetyCheckIn"("userId");
@@ -0,0 +1,17 @@
+-- CreateTable
+CREATE TABLE "ContactShare" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "matchId" TEXT NOT NULL,
+    "userId" TEXT NOT NULL,
+    "encrypted" TEXT NOT NULL,
+    "consentedAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "releasedAt" DATETIME,
+    CONSTRAINT "ContactShare_matchId_fkey" FOREIGN KEY ("matchId") REFERENCES "Match" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "ContactShare_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "ContactShare_matchId_userId_key" ON "ContactShare"("matchId", "userId");
+
+-- CreateIndex
+CREATE INDEX "ContactShare_userId_idx" ON "ContactShare"("userId");
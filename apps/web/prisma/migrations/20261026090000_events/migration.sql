@@ -0,0 +1,42 @@
+-- CreateTable
+CREATE TABLE "Event" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "title" TEXT NOT NULL,
+    "description" TEXT,
+    "venue" TEXT NOT NULL,
+    "cityId" TEXT NOT NULL,
+    "campusId" TEXT,
+    "lat" REAL NOT NULL,
+    "lng" REAL NOT NULL,
+    "geohash" TEXT NOT NULL,
+    "startsAt" DATETIME NOT NULL,
+    "endsAt" DATETIME NOT NULL,
+    "capacity" INTEGER,
+    "status" TEXT NOT NULL DEFAULT 'published',
+    "createdBy" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL
+);
+
+-- CreateTable
+CREATE TABLE "EventRsvp" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "eventId" TEXT NOT NULL,
+    "userId" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "attendedAt" DATETIME,
+    CONSTRAINT "EventRsvp_eventId_fkey" FOREIGN KEY ("eventId") REFERENCES "Event" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "EventRsvp_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "Event_status_endsAt_idx" ON "Event"("status", "endsAt");
+
+-- CreateIndex
+CREATE INDEX "Event_geohash_idx" ON "Event"("geohash");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "EventRsvp_eventId_userId_key" ON "EventRsvp"("eventId", "userId");
+
+-- CreateIndex
+CREATE INDEX "EventRsvp_userId_idx" ON "EventRsvp"("userId");
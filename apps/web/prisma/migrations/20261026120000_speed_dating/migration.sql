@@ -0,0 +1,38 @@
+-- CreateTable
+CREATE TABLE "SpeedDatingSession" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "title" TEXT NOT NULL,
+    "cityId" TEXT,
+    "startsAt" DATETIME NOT NULL,
+    "endsAt" DATETIME NOT NULL,
+    "roundSeconds" INTEGER NOT NULL DEFAULT 300,
+    "status" TEXT NOT NULL DEFAULT 'scheduled',
+    "createdBy" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateTable
+CREATE TABLE "SpeedDate" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "sessionId" TEXT NOT NULL,
+    "user1Id" TEXT NOT NULL,
+    "user2Id" TEXT NOT NULL,
+    "startedAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "endsAt" DATETIME NOT NULL,
+    "vote1" TEXT,
+    "vote2" TEXT,
+    "matchId" TEXT,
+    CONSTRAINT "SpeedDate_sessionId_fkey" FOREIGN KEY ("sessionId") REFERENCES "SpeedDatingSession" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "SpeedDatingSession_status_endsAt_idx" ON "SpeedDatingSession"("status", "endsAt");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "SpeedDate_sessionId_user1Id_user2Id_key" ON "SpeedDate"("sessionId", "user1Id", "user2Id");
+
+-- CreateIndex
+CREATE INDEX "SpeedDate_user1Id_idx" ON "SpeedDate"("user1Id");
+
+-- CreateIndex
+CREATE INDEX "SpeedDate_user2Id_idx" ON "SpeedDate"("user2Id");
@@ -0,0 +1,40 @@
+-- CreateTable
+CREATE TABLE "Circle" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "slug" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+    "description" TEXT,
+    "kind" TEXT NOT NULL,
+    "campusId" TEXT,
+    "joinPolicy" TEXT NOT NULL DEFAULT 'open',
+    "createdBy" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateTable
+CREATE TABLE "CircleMember" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "circleId" TEXT NOT NULL,
+    "userId" TEXT NOT NULL,
+    "role" TEXT NOT NULL DEFAULT 'member',
+    "status" TEXT NOT NULL DEFAULT 'active',
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "approvedAt" DATETIME,
+    CONSTRAINT "CircleMember_circleId_fkey" FOREIGN KEY ("circleId") REFERENCES "Circle" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "CircleMember_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Circle_slug_key" ON "Circle"("slug");
+
+-- CreateIndex
+CREATE INDEX "Circle_campusId_idx" ON "Circle"("campusId");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "CircleMember_circleId_userId_key" ON "CircleMember"("circleId", "userId");
+
+-- CreateIndex
+CREATE INDEX "CircleMember_userId_idx" ON "CircleMember"("userId");
+
+-- CreateIndex
+CREATE INDEX "CircleMember_circleId_status_idx" ON "CircleMember"("circleId", "status");
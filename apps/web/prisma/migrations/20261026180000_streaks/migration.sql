@@ -0,0 +1,23 @@
+-- CreateTable
+CREATE TABLE "Streak" (
+    "userId" TEXT NOT NULL PRIMARY KEY,
+    "current" INTEGER NOT NULL DEFAULT 0,
+    "longest" INTEGER NOT NULL DEFAULT 0,
+    "lastDay" TEXT,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "Streak_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateTable
+CREATE TABLE "StreakClaim" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "day" TEXT NOT NULL,
+    "streak" INTEGER NOT NULL,
+    "timezone" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "StreakClaim_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "StreakClaim_userId_day_key" ON "StreakClaim"("userId", "day");
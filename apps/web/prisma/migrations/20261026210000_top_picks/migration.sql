@@ -0,0 +1,11 @@
+-- CreateTable
+CREATE TABLE "TopPicks" (
+    "userId" TEXT NOT NULL PRIMARY KEY,
+    "userIds" JSONB NOT NULL,
+    "ranking" TEXT NOT NULL,
+    "generatedAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "TopPicks_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "TopPicks_generatedAt_idx" ON "TopPicks"("generatedAt");
@@ -0,0 +1,16 @@
+-- CreateTable
+CREATE TABLE "SocialEdge" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "kind" TEXT NOT NULL,
+    "key" TEXT NOT NULL,
+    "label" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "SocialEdge_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "SocialEdge_userId_kind_key_key" ON "SocialEdge"("userId", "kind", "key");
+
+-- CreateIndex
+CREATE INDEX "SocialEdge_kind_key_idx" ON "SocialEdge"("kind", "key");
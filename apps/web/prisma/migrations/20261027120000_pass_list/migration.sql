@@ -0,0 +1,34 @@
+-- CreateTable
+CREATE TABLE "Pass" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "passedUserId" TEXT NOT NULL,
+    "passedAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "expiresAt" DATETIME NOT NULL,
+    CONSTRAINT "Pass_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "Pass_passedUserId_fkey" FOREIGN KEY ("passedUserId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Pass_userId_passedUserId_key" ON "Pass"("userId", "passedUserId");
+
+-- CreateIndex
+CREATE INDEX "Pass_userId_expiresAt_idx" ON "Pass"("userId", "expiresAt");
+
+-- CreateIndex
+CREATE INDEX "Pass_passedUserId_idx" ON "Pass"("passedUserId");
+
+-- Move existing pass swipes out of Signal, keeping the latest per pair
+-- with the default 30-day window
+INSERT INTO "Pass" ("id", "userId", "passedUserId", "passedAt", "expiresAt")
+SELECT
+    'c' || lower(hex(randomblob(12))),
+    "fromUserId",
+    "toUserId",
+    MAX("sentAt"),
+    MAX("sentAt") + 30 * 86400000
+FROM "Signal"
+WHERE "type" = 'pass' AND "deletedAt" IS NULL
+GROUP BY "fromUserId", "toUserId";
+
+DELETE FROM "Signal" WHERE "type" = 'pass';
@@ -0,0 +1,8 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "responseRate" REAL;
+ALTER TABLE "User" ADD COLUMN "firstMessageMs" INTEGER;
+ALTER TABLE "User" ADD COLUMN "responseStatsAt" DATETIME;
+
+-- AlterTable
+ALTER TABLE "Match" ADD COLUMN "user1FirstMessageAt" DATETIME;
+ALTER TABLE "Match" ADD COLUMN "user2FirstMessageAt" DATETIME;
@@ -0,0 +1,7 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "cohort" TEXT NOT NULL DEFAULT 'full';
+ALTER TABLE "User" ADD COLUMN "waitlistedAt" DATETIME;
+ALTER TABLE "User" ADD COLUMN "admittedAt" DATETIME;
+
+-- CreateIndex
+CREATE INDEX "User_cohort_waitlistedAt_idx" ON "User"("cohort", "waitlistedAt");
@@ -0,0 +1,21 @@
+-- CreateTable
+CREATE TABLE "WaitlistEntry" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "worldId" TEXT NOT NULL,
+    "verificationLevel" TEXT,
+    "campusId" TEXT NOT NULL,
+    "email" TEXT,
+    "status" TEXT NOT NULL DEFAULT 'waiting',
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "admittedAt" DATETIME,
+    CONSTRAINT "WaitlistEntry_campusId_fkey" FOREIGN KEY ("campusId") REFERENCES "Place" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "WaitlistEntry_worldId_key" ON "WaitlistEntry"("worldId");
+
+-- CreateIndex
+CREATE INDEX "WaitlistEntry_status_createdAt_idx" ON "WaitlistEntry"("status", "createdAt");
+
+-- CreateIndex
+CREATE INDEX "WaitlistEntry_campusId_idx" ON "WaitlistEntry"("campusId");
@@ -0,0 +1,28 @@
+-- CreateTable
+CREATE TABLE "Tenant" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "name" TEXT NOT NULL,
+    "campusId" TEXT NOT NULL,
+    "host" TEXT,
+    "gateContractAddress" TEXT,
+    "gateCollectionName" TEXT,
+    "discoveryDeckSize" INTEGER,
+    "freeDailySuperInterests" INTEGER,
+    "freeDailyTopPicks" INTEGER,
+    "active" BOOLEAN NOT NULL DEFAULT true,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "Tenant_campusId_fkey" FOREIGN KEY ("campusId") REFERENCES "Place" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Tenant_campusId_key" ON "Tenant"("campusId");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Tenant_host_key" ON "Tenant"("host");
+
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "tenantId" TEXT REFERENCES "Tenant" ("id") ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- CreateIndex
+CREATE INDEX "User_tenantId_idx" ON "User"("tenantId");
@@ -0,0 +1,14 @@
+-- CreateTable
+CREATE TABLE "SigningKey" (
+    "kid" TEXT NOT NULL PRIMARY KEY,
+    "algorithm" TEXT NOT NULL DEFAULT 'ES256',
+    "publicJwk" JSONB NOT NULL,
+    "privateKey" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "activatedAt" DATETIME,
+    "retiredAt" DATETIME
+);
+
+-- CreateIndex
+CREATE INDEX "SigningKey_status_idx" ON "SigningKey"("status");
@@ -0,0 +1,10 @@
+-- AlterTable: birth dates become text so they can be stored encrypted.
+-- Existing dates are carried over as plaintext YYYY-MM-DD until the PII
+-- rewrap (npm run pii:rewrap) encrypts them.
+ALTER TABLE "User" RENAME COLUMN "birthDate" TO "birthDate_old";
+ALTER TABLE "User" ADD COLUMN "birthDate" TEXT;
+UPDATE "User" SET "birthDate" = CASE
+    WHEN typeof("birthDate_old") IN ('integer', 'real') THEN strftime('%Y-%m-%d', "birthDate_old" / 1000, 'unixepoch')
+    ELSE substr("birthDate_old", 1, 10)
+END WHERE "birthDate_old" IS NOT NULL;
+ALTER TABLE "User" DROP COLUMN "birthDate_old";
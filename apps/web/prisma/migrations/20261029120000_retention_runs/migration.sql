@@ -0,0 +1,16 @@
+-- CreateTable
+CREATE TABLE "RetentionRun" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "dataClass" TEXT NOT NULL,
+    "cutoff" DATETIME NOT NULL,
+    "purged" INTEGER NOT NULL DEFAULT 0,
+    "anonymized" INTEGER NOT NULL DEFAULT 0,
+    "details" JSONB,
+    "ranAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateIndex
+CREATE INDEX "RetentionRun_dataClass_ranAt_idx" ON "RetentionRun"("dataClass", "ranAt");
+
+-- CreateIndex
+CREATE INDEX "RetentionRun_ranAt_idx" ON "RetentionRun"("ranAt");
@@ -0,0 +1,23 @@
+-- CreateTable
+CREATE TABLE "BackfillJob" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "kind" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'running',
+    "ratePerSecond" REAL NOT NULL,
+    "total" INTEGER NOT NULL DEFAULT 0,
+    "processed" INTEGER NOT NULL DEFAULT 0,
+    "failed" INTEGER NOT NULL DEFAULT 0,
+    "cursor" TEXT,
+    "lastError" TEXT,
+    "runId" TEXT,
+    "createdBy" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    "finishedAt" DATETIME
+);
+
+-- CreateIndex
+CREATE INDEX "BackfillJob_status_idx" ON "BackfillJob"("status");
+
+-- CreateIndex
+CREATE INDEX "BackfillJob_createdAt_idx" ON "BackfillJob"("createdAt");
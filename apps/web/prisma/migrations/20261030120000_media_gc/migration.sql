@@ -0,0 +1,29 @@
+-- CreateTable
+CREATE TABLE "MediaObject" (
+    "key" TEXT NOT NULL PRIMARY KEY,
+    "kind" TEXT NOT NULL,
+    "ownerId" TEXT NOT NULL,
+    "sizeBytes" INTEGER,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateTable
+CREATE TABLE "MediaGcRun" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "dryRun" BOOLEAN NOT NULL,
+    "scanned" INTEGER NOT NULL DEFAULT 0,
+    "orphaned" INTEGER NOT NULL DEFAULT 0,
+    "deleted" INTEGER NOT NULL DEFAULT 0,
+    "bytes" INTEGER NOT NULL DEFAULT 0,
+    "details" JSONB,
+    "ranAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateIndex
+CREATE INDEX "MediaObject_kind_createdAt_idx" ON "MediaObject"("kind", "createdAt");
+
+-- CreateIndex
+CREATE INDEX "MediaObject_ownerId_idx" ON "MediaObject"("ownerId");
+
+-- CreateIndex
+CREATE INDEX "MediaGcRun_ranAt_idx" ON "MediaGcRun"("ranAt");
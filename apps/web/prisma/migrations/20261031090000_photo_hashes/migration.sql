@@ -0,0 +1,28 @@
+-- CreateTable
+CREATE TABLE "PhotoHash" (
+    "userId" TEXT NOT NULL PRIMARY KEY,
+    "hash" TEXT NOT NULL,
+    "band0" TEXT NOT NULL,
+    "band1" TEXT NOT NULL,
+    "band2" TEXT NOT NULL,
+    "band3" TEXT NOT NULL,
+    "matchedAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "PhotoHash_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "PhotoHash_band0_idx" ON "PhotoHash"("band0");
+
+-- CreateIndex
+CREATE INDEX "PhotoHash_band1_idx" ON "PhotoHash"("band1");
+
+-- CreateIndex
+CREATE INDEX "PhotoHash_band2_idx" ON "PhotoHash"("band2");
+
+-- CreateIndex
+CREATE INDEX "PhotoHash_band3_idx" ON "PhotoHash"("band3");
+
+-- CreateIndex
+CREATE INDEX "PhotoHash_matchedAt_idx" ON "PhotoHash"("matchedAt");
@@ -0,0 +1,23 @@
+-- CreateTable
+CREATE TABLE "QuarantinedUpload" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "source" TEXT NOT NULL,
+    "key" TEXT NOT NULL,
+    "scanner" TEXT NOT NULL,
+    "signature" TEXT NOT NULL,
+    "sizeBytes" INTEGER NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'held',
+    "purgedBy" TEXT,
+    "purgedAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "QuarantinedUpload_key_key" ON "QuarantinedUpload"("key");
+
+-- CreateIndex
+CREATE INDEX "QuarantinedUpload_status_createdAt_idx" ON "QuarantinedUpload"("status", "createdAt");
+
+-- CreateIndex
+CREATE INDEX "QuarantinedUpload_userId_idx" ON "QuarantinedUpload"("userId");
@@ -0,0 +1,17 @@
+-- CreateTable
+CREATE TABLE "ConversationExport" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "matchId" TEXT NOT NULL,
+    "userId" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "transcript" TEXT,
+    "expiresAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "ConversationExport_matchId_userId_key" ON "ConversationExport"("matchId", "userId");
+
+-- CreateIndex
+CREATE INDEX "ConversationExport_userId_idx" ON "ConversationExport"("userId");
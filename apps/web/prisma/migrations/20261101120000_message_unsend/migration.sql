@@ -0,0 +1,22 @@
+-- AlterTable
+ALTER TABLE "Signal" ADD COLUMN "messageDeletedAt" DATETIME;
+
+-- CreateTable
+CREATE TABLE "DeletedMessage" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "signalId" TEXT NOT NULL,
+    "senderId" TEXT NOT NULL,
+    "recipientId" TEXT NOT NULL,
+    "body" TEXT NOT NULL,
+    "sentAt" DATETIME NOT NULL,
+    "deletedAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "DeletedMessage_signalId_key" ON "DeletedMessage"("signalId");
+
+-- CreateIndex
+CREATE INDEX "DeletedMessage_senderId_recipientId_idx" ON "DeletedMessage"("senderId", "recipientId");
+
+-- CreateIndex
+CREATE INDEX "DeletedMessage_deletedAt_idx" ON "DeletedMessage"("deletedAt");
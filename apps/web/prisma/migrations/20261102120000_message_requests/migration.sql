@@ -0,0 +1,26 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "messageRequests" BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE "User" ADD COLUMN "declineRate" REAL;
+
+-- CreateTable
+CREATE TABLE "MessageRequest" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "matchId" TEXT NOT NULL,
+    "senderId" TEXT NOT NULL,
+    "recipientId" TEXT NOT NULL,
+    "message" TEXT,
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "respondedAt" DATETIME,
+    CONSTRAINT "MessageRequest_senderId_fkey" FOREIGN KEY ("senderId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "MessageRequest_recipientId_fkey" FOREIGN KEY ("recipientId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "MessageRequest_matchId_key" ON "MessageRequest"("matchId");
+
+-- CreateIndex
+CREATE INDEX "MessageRequest_recipientId_status_idx" ON "MessageRequest"("recipientId", "status");
+
+-- CreateIndex
+CREATE INDEX "MessageRequest_senderId_respondedAt_idx" ON "MessageRequest"("senderId", "respondedAt");
@@ -0,0 +1,36 @@
+-- CreateTable
+CREATE TABLE "ScheduledMessage" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "matchId" TEXT NOT NULL,
+    "senderId" TEXT NOT NULL,
+    "recipientId" TEXT NOT NULL,
+    "kind" TEXT NOT NULL,
+    "body" TEXT,
+    "sendAt" DATETIME NOT NULL,
+    "deliverAt" DATETIME NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'scheduled',
+    "failureReason" TEXT,
+    "sentAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateTable
+CREATE TABLE "ConversationMute" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "matchId" TEXT NOT NULL,
+    "userId" TEXT NOT NULL,
+    "until" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateIndex
+CREATE INDEX "ScheduledMessage_status_deliverAt_idx" ON "ScheduledMessage"("status", "deliverAt");
+
+-- CreateIndex
+CREATE INDEX "ScheduledMessage_matchId_senderId_idx" ON "ScheduledMessage"("matchId", "senderId");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "ConversationMute_matchId_userId_key" ON "ConversationMute"("matchId", "userId");
+
+-- CreateIndex
+CREATE INDEX "ConversationMute_userId_idx" ON "ConversationMute"("userId");
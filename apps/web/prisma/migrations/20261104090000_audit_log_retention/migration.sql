@@ -0,0 +1,13 @@
+-- Let retention purge entries once they're a year old; anything newer
+-- stays append-only. Prisma stores DateTime as epoch milliseconds, rows
+-- filled by the column default as text, so both are compared.
+DROP TRIGGER "AuditLog_no_delete";
+
+CREATE TRIGGER "AuditLog_no_delete" BEFORE DELETE ON "AuditLog"
+WHEN (typeof(OLD."createdAt") = 'integer'
+        AND OLD."createdAt" >= CAST(strftime('%s', 'now', '-365 days') AS INTEGER) * 1000)
+    OR (typeof(OLD."createdAt") <> 'integer'
+        AND OLD."createdAt" >= datetime('now', '-365 days'))
+BEGIN
+    SELECT RAISE(ABORT, 'AuditLog entries are append-only for a year');
+END;
@@ -0,0 +1,25 @@
+-- RedefineTables
+PRAGMA defer_foreign_keys=ON;
+PRAGMA foreign_keys=OFF;
+CREATE TABLE "new_PrivacyRequest" (
+    "id" TEXT NOT NULL PRIMARY KEY,
+    "userId" TEXT NOT NULL,
+    "type" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "dueAt" DATETIME NOT NULL,
+    "exportData" TEXT,
+    "exportExpiresAt" DATETIME,
+    "handledBy" TEXT,
+    "note" TEXT,
+    "completedAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "PrivacyRequest_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+INSERT INTO "new_PrivacyRequest" ("completedAt", "createdAt", "dueAt", "exportData", "exportExpiresAt", "handledBy", "id", "note", "status", "type", "updatedAt", "userId") SELECT "completedAt", "createdAt", "dueAt", "exportData", "exportExpiresAt", "handledBy", "id", "note", "status", "type", "updatedAt", "userId" FROM "PrivacyRequest";
+DROP TABLE "PrivacyRequest";
+ALTER TABLE "new_PrivacyRequest" RENAME TO "PrivacyRequest";
+CREATE INDEX "PrivacyRequest_status_dueAt_idx" ON "PrivacyRequest"("status", "dueAt");
+CREATE INDEX "PrivacyRequest_userId_createdAt_idx" ON "PrivacyRequest"("userId", "createdAt");
+PRAGMA foreign_keys=ON;
+PRAGMA defer_foreign_keys=OFF;
@@ -0,0 +1,10 @@
+-- Account emails are encrypted (see lib/field-encryption); the keyed hash
+-- takes over the lookup and uniqueness. Existing rows are hashed and
+-- encrypted by `npm run pii:rewrap`.
+DROP INDEX "User_email_key";
+
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "emailHash" TEXT;
+
+-- CreateIndex
+CREATE UNIQUE INDEX "User_emailHash_key" ON "User"("emailHash");
@@ -0,0 +1,48 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { Announcements } from '@/lib/announcements';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+/**
+ * Cancel an announcement that hasn't been sent yet
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+
+    const canceled = await Announcements.cancel(id, adminId);
+    if (!canceled) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Announcement not found or already sent',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Announcement canceled',
+    });
+  } catch (error) {
+    console.error('💥 Cancel announcement error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to cancel announcement',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
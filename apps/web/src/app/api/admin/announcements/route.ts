@@ -0,0 +1,134 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Announcements, ANNOUNCEMENT_KINDS } from '@/lib/announcements';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const listSchema = z.object({
+  status: z.enum(['scheduled', 'sending', 'sent', 'canceled']).optional(),
+  limit: z.coerce.number().int().min(1).max(100).default(25),
+  cursor: z.string().optional(),
+});
+
+const announcementSchema = z.object({
+  kind: z.enum(ANNOUNCEMENT_KINDS),
+  title: z.string().min(1).max(100),
+  body: z.string().min(1).max(500),
+  // Mini app path opened from the notification
+  path: z.string().startsWith('/').max(200).optional(),
+  audience: z
+    .object({
+      verified: z.boolean().optional(),
+      cities: z.array(z.string().min(1)).max(50).optional(),
+      plans: z.array(z.enum(['free', 'premium'])).optional(),
+    })
+    .default({}),
+  push: z.boolean().default(false),
+  sendAt: z.coerce
+    .date()
+    .refine(date => date > new Date(), 'sendAt must be in the future')
+    .optional(),
+});
+
+/**
+ * Announcements, most recently scheduled first
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const announcements = await Announcements.list(
+      query.limit,
+      query.status,
+      query.cursor
+    );
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        announcements,
+        nextCursor:
+          announcements.length === query.limit
+            ? announcements[announcements.length - 1].id
+            : null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch announcements error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch announcements',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Create an announcement, sent now or at `sendAt`
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = announcementSchema.parse(body);
+
+    const announcement = await Announcements.create(validatedData, adminId);
+
+    return NextResponse.json(
+      {
+        success: true,
+        message: 'Announcement scheduled',
+        data: announcement,
+      },
+      { status: 201 }
+    );
+  } catch (error) {
+    console.error('💥 Create announcement error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid announcement data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to create announcement',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
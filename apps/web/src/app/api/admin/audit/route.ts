@@ -0,0 +1,82 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const auditQuerySchema = z.object({
+  actorType: z.enum(['system', 'user', 'admin', 'provider']).optional(),
+  actorId: z.string().min(1).optional(),
+  targetType: z.string().min(1).optional(),
+  targetId: z.string().min(1).optional(),
+  // Exact action, or a prefix ending in "." (e.g. "billing.")
+  action: z.string().min(1).optional(),
+  from: z.coerce.date().optional(),
+  to: z.coerce.date().optional(),
+  limit: z.coerce.number().int().min(1).max(200).default(50),
+  cursor: z.string().optional(),
+});
+
+/**
+ * Search the audit trail by actor, target, action and time range
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = auditQuerySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const entries = await AuditLog.query(query);
+
+    // Investigations are themselves audited
+    await AuditLog.record({
+      action: 'admin.audit_queried',
+      actorType: 'admin',
+      actorId: await getAdminId(request),
+      details: {
+        ...query,
+        from: query.from?.toISOString(),
+        to: query.to?.toISOString(),
+      },
+      ipAddress: ClientIp.of(request),
+    });
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        entries,
+        nextCursor:
+          entries.length === query.limit
+            ? entries[entries.length - 1].id
+            : null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Audit query error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to query audit log',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,147 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Backfills } from '@/lib/backfills';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const updateSchema = z
+  .object({
+    action: z.enum(['pause', 'resume', 'cancel']).optional(),
+    ratePerSecond: z.number().positive().max(1000).optional(),
+  })
+  .refine(
+    update => update.action !== undefined || update.ratePerSecond !== undefined,
+    'Nothing to change'
+  );
+
+/**
+ * A backfill job and its progress
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const job = await Backfills.get(id);
+
+    if (!job) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Backfill not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({ success: true, data: job });
+  } catch (error) {
+    console.error('💥 Fetch backfill error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch backfill',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Pause, resume or cancel a backfill, or change its rate
+ */
+export async function PATCH(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = updateSchema.parse(body);
+
+    const result = await Backfills.update(id, adminId, validatedData);
+
+    switch (result.status) {
+      case 'ok':
+        return NextResponse.json({
+          success: true,
+          message: 'Backfill updated',
+          data: result.job,
+        });
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Backfill not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'invalid_transition':
+        return NextResponse.json(
+          {
+            success: false,
+            message:
+              `Can't ${validatedData.action} a ` +
+              `${result.current} backfill`,
+            error_type: 'invalid_transition',
+          },
+          { status: 409 }
+        );
+      case 'unavailable':
+        return NextResponse.json(
+          {
+            success: false,
+            message: result.reason,
+            error_type: 'unavailable',
+          },
+          { status: 409 }
+        );
+      case 'upstream_busy':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Another backfill is running against the same upstream',
+            error_type: 'upstream_busy',
+            runningId: result.runningId,
+          },
+          { status: 409 }
+        );
+    }
+  } catch (error) {
+    console.error('💥 Update backfill error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update backfill',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,137 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { BACKFILL_KINDS, Backfills } from '@/lib/backfills';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const listQuerySchema = z.object({
+  limit: z.coerce.number().int().min(1).max(200).default(50),
+});
+
+// Rates above the kind's upstream cap are lowered to it
+const startSchema = z.object({
+  kind: z.enum(BACKFILL_KINDS),
+  ratePerSecond: z.number().positive().max(1000).optional(),
+});
+
+/**
+ * Recent backfill jobs with their progress, and the kinds that can be run
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listQuerySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const jobs = await Backfills.list(query.limit);
+
+    return NextResponse.json({
+      success: true,
+      data: { kinds: Backfills.kinds(), jobs },
+    });
+  } catch (error) {
+    console.error('💥 Fetch backfills error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch backfills',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Launch a backfill. One job per upstream runs at a time.
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = startSchema.parse(body);
+
+    const result = await Backfills.start(
+      validatedData.kind,
+      adminId,
+      validatedData.ratePerSecond
+    );
+
+    if (result.status === 'unavailable') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: result.reason,
+          error_type: 'unavailable',
+        },
+        { status: 409 }
+      );
+    }
+    if (result.status === 'upstream_busy') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Another backfill is running against the same upstream',
+          error_type: 'upstream_busy',
+          runningId: result.runningId,
+        },
+        { status: 409 }
+      );
+    }
+    if (result.status !== 'ok') {
+      throw new Error(`Unexpected backfill result: ${result.status}`);
+    }
+
+    return NextResponse.json(
+      {
+        success: true,
+        message: 'Backfill started',
+        data: result.job,
+      },
+      { status: 201 }
+    );
+  } catch (error) {
+    console.error('💥 Start backfill error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to start backfill',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
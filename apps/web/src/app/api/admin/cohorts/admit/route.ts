@@ -0,0 +1,65 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Cohorts, MAX_ADMISSION_BATCH } from '@/lib/cohorts';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const admitSchema = z
+  .object({
+    count: z.number().int().min(1).max(MAX_ADMISSION_BATCH).optional(),
+    campusId: z.string().min(1).optional(),
+    userIds: z
+      .array(z.string().min(1))
+      .min(1)
+      .max(MAX_ADMISSION_BATCH)
+      .optional(),
+  })
+  .refine(data => Boolean(data.count) !== Boolean(data.userIds), {
+    message: 'Give either a count or a list of user IDs',
+  });
+
+/**
+ * Admit a batch off the waitlist: the next `count` in line (optionally
+ * from one campus), or specific users
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = admitSchema.parse(body);
+
+    const admitted = await Cohorts.admit(validatedData, adminId);
+
+    return NextResponse.json({
+      success: true,
+      message: `Admitted ${admitted.length} from the waitlist`,
+      data: { admitted },
+    });
+  } catch (error) {
+    console.error('💥 Admit cohort error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid admission data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to admit cohort',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
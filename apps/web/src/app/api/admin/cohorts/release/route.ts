@@ -0,0 +1,34 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { Cohorts } from '@/lib/cohorts';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+/**
+ * Give everyone invited so far full access
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const released = await Cohorts.release(adminId);
+
+    return NextResponse.json({
+      success: true,
+      message: `Released ${released} to full access`,
+      data: { released },
+    });
+  } catch (error) {
+    console.error('💥 Release cohort error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to release cohort',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
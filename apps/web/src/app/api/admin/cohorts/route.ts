@@ -0,0 +1,35 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { Cohorts } from '@/lib/cohorts';
+import { requireAdmin } from '@/middleware/admin';
+
+/**
+ * Soft-launch cohort sizes and whether the gate is on
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const [cohorts, gateEnabled] = await Promise.all([
+      Cohorts.summary(),
+      Cohorts.isGateEnabled(),
+    ]);
+
+    return NextResponse.json({
+      success: true,
+      data: { gateEnabled, cohorts },
+    });
+  } catch (error) {
+    console.error('💥 Fetch cohorts error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch cohorts',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,77 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import {
+  DuplicateAccounts,
+  DUPLICATE_REVIEW_DECISIONS,
+} from '@/lib/duplicate-accounts';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const reviewSchema = z.object({
+  decision: z.enum(DUPLICATE_REVIEW_DECISIONS),
+  note: z.string().max(500).optional(),
+});
+
+/**
+ * Confirm or dismiss a duplicate account flag
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = reviewSchema.parse(body);
+
+    const flag = await DuplicateAccounts.review(
+      id,
+      validatedData.decision,
+      adminId,
+      validatedData.note
+    );
+    if (!flag) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Duplicate flag not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Duplicate flag reviewed',
+      data: flag,
+    });
+  } catch (error) {
+    console.error('💥 Review duplicate flag error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid review data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to review duplicate flag',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,55 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { PhotoHashes } from '@/lib/photo-hashes';
+import { requireAdmin } from '@/middleware/admin';
+
+const listSchema = z.object({
+  limit: z.coerce.number().int().min(1).max(100).default(25),
+  cursor: z.string().optional(),
+});
+
+/**
+ * Profile photos that were already in use on another account when they
+ * were uploaded, with the accounts they collide with, newest first
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const page = await PhotoHashes.listCollisions(query.limit, query.cursor);
+
+    return NextResponse.json({
+      success: true,
+      data: page,
+    });
+  } catch (error) {
+    console.error('💥 Fetch photo collisions error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch photo collisions',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,63 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { DuplicateAccounts } from '@/lib/duplicate-accounts';
+import { requireAdmin } from '@/middleware/admin';
+
+const listSchema = z.object({
+  status: z.enum(['open', 'confirmed', 'dismissed']).default('open'),
+  limit: z.coerce.number().int().min(1).max(100).default(25),
+  cursor: z.string().optional(),
+});
+
+/**
+ * Likely duplicate accounts flagged for review, newest first
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const flags = await DuplicateAccounts.listFlags(
+      query.status,
+      query.limit,
+      query.cursor
+    );
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        flags,
+        nextCursor:
+          flags.length === query.limit ? flags[flags.length - 1].id : null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch duplicate flags error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch duplicate flags',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,143 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Events } from '@/lib/events';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const updateSchema = z.object({
+  title: z.string().trim().min(1).max(120).optional(),
+  description: z.string().trim().max(2000).nullable().optional(),
+  venue: z.string().trim().min(1).max(200).optional(),
+  cityId: z.string().min(1).optional(),
+  campusId: z.string().min(1).nullable().optional(),
+  lat: z.number().min(-90).max(90).optional(),
+  lng: z.number().min(-180).max(180).optional(),
+  startsAt: z.coerce.date().optional(),
+  endsAt: z.coerce.date().optional(),
+  capacity: z.number().int().positive().nullable().optional(),
+});
+
+/**
+ * Edit an event
+ */
+export async function PATCH(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = updateSchema.parse(body);
+
+    const result = await Events.update(id, validatedData, adminId);
+
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Event not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'invalid_time':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'An event must end after it starts, within 48 hours',
+            error_type: 'invalid_time',
+          },
+          { status: 400 }
+        );
+      case 'invalid_place':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Unknown city or campus',
+            error_type: 'invalid_place',
+          },
+          { status: 400 }
+        );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Event updated',
+      data: result.event,
+    });
+  } catch (error) {
+    console.error('💥 Update event error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update event',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Cancel an event. Everyone who RSVP'd is notified.
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+
+    const result = await Events.cancel(id, adminId);
+    if (result.status !== 'saved') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Event not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Event canceled',
+      data: result.event,
+    });
+  } catch (error) {
+    console.error('💥 Cancel event error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to cancel event',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
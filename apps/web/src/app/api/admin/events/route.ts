@@ -0,0 +1,132 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Events, EVENT_STATUSES } from '@/lib/events';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const listSchema = z.object({
+  status: z.enum(EVENT_STATUSES).optional(),
+});
+
+const createSchema = z.object({
+  title: z.string().trim().min(1).max(120),
+  description: z.string().trim().max(2000).nullable().optional(),
+  venue: z.string().trim().min(1).max(200),
+  cityId: z.string().min(1),
+  campusId: z.string().min(1).nullable().optional(),
+  lat: z.number().min(-90).max(90),
+  lng: z.number().min(-180).max(180),
+  startsAt: z.coerce.date(),
+  endsAt: z.coerce.date(),
+  capacity: z.number().int().positive().nullable().optional(),
+});
+
+/**
+ * All events, past and canceled ones included
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+    const events = await Events.listAll(query.status);
+
+    return NextResponse.json({
+      success: true,
+      data: events,
+    });
+  } catch (error) {
+    console.error('💥 Fetch events error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch events',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Publish an event
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = createSchema.parse(body);
+
+    const result = await Events.create(validatedData, adminId);
+
+    if (result.status === 'invalid_time') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'An event must end after it starts, within 48 hours',
+          error_type: 'invalid_time',
+        },
+        { status: 400 }
+      );
+    }
+    if (result.status !== 'saved') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Unknown city or campus',
+          error_type: 'invalid_place',
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Event created',
+      data: result.event,
+    });
+  } catch (error) {
+    console.error('💥 Create event error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to create event',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,98 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Places } from '@/lib/places';
+import { isValidTimezone } from '@/lib/waking-hours';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const updateSchema = z.object({
+  name: z.string().min(1).max(100).optional(),
+  nameTh: z.string().min(1).max(100).nullable().optional(),
+  country: z
+    .string()
+    .regex(/^[A-Z]{2}$/)
+    .optional(),
+  // Moves a campus to another city
+  cityId: z.string().optional(),
+  lat: z.number().min(-90).max(90).optional(),
+  lng: z.number().min(-180).max(180).optional(),
+  timezone: z
+    .string()
+    .refine(isValidTimezone, 'Unknown timezone')
+    .nullable()
+    .optional(),
+  // false retires the place: existing profiles keep it, nobody new can
+  // pick it
+  active: z.boolean().optional(),
+  sortOrder: z.number().int().optional(),
+});
+
+/**
+ * Edit or retire a place. IDs never change since profiles store them.
+ */
+export async function PATCH(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = updateSchema.parse(body);
+
+    const result = await Places.update(id, validatedData, adminId);
+
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Place not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (result.status !== 'saved') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Unknown city',
+          error_type: 'invalid_city',
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Place updated',
+      data: result.place,
+    });
+  } catch (error) {
+    console.error('💥 Update place error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update place',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,145 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Places, PLACE_KINDS } from '@/lib/places';
+import { isValidTimezone } from '@/lib/waking-hours';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const listSchema = z.object({
+  kind: z.enum(PLACE_KINDS).optional(),
+});
+
+const createSchema = z
+  .object({
+    id: z
+      .string()
+      .regex(/^[a-z0-9_]{2,40}$/, 'Use lowercase letters, digits and _'),
+    kind: z.enum(PLACE_KINDS),
+    name: z.string().min(1).max(100),
+    nameTh: z.string().min(1).max(100).nullable().optional(),
+    country: z.string().regex(/^[A-Z]{2}$/),
+    cityId: z.string().nullable().optional(),
+    lat: z.number().min(-90).max(90),
+    lng: z.number().min(-180).max(180),
+    timezone: z
+      .string()
+      .refine(isValidTimezone, 'Unknown timezone')
+      .nullable()
+      .optional(),
+    active: z.boolean().optional(),
+    sortOrder: z.number().int().optional(),
+  })
+  .refine(data => (data.kind === 'campus') === Boolean(data.cityId), {
+    message: 'Campuses need a city; cities have none',
+    path: ['cityId'],
+  });
+
+/**
+ * The whole directory, including retired places
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+    const places = await Places.list(query.kind, true);
+
+    return NextResponse.json({
+      success: true,
+      data: places,
+    });
+  } catch (error) {
+    console.error('💥 Fetch places error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch places',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Add a city or campus to the directory
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = createSchema.parse(body);
+
+    const result = await Places.create(validatedData, adminId);
+
+    if (result.status === 'already_exists') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'A place with this ID already exists',
+          error_type: 'already_exists',
+        },
+        { status: 409 }
+      );
+    }
+    if (result.status !== 'saved') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Unknown city',
+          error_type: 'invalid_city',
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Place created',
+      data: result.place,
+    });
+  } catch (error) {
+    console.error('💥 Create place error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to create place',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,123 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { AuditLog } from '@/lib/audit-log';
+import { MediaGc, mediaGc } from '@/lib/media-gc';
+import { Scheduler } from '@/lib/scheduler';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const reportQuerySchema = z.object({
+  limit: z.coerce.number().int().min(1).max(200).default(20),
+});
+
+// A dry run unless asked otherwise, so a run can be reviewed first
+const runSchema = z.object({
+  dryRun: z.boolean().default(true),
+  scanBucket: z.boolean().default(false),
+});
+
+/**
+ * What's tracked in media storage, and what recent GC runs found and
+ * deleted
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = reportQuerySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const report = await MediaGc.report(query.limit);
+
+    return NextResponse.json({ success: true, data: report });
+  } catch (error) {
+    console.error('💥 Media GC report error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to load media GC report',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Start a GC run now. It runs on the scheduler worker; its result shows up
+ * in the report.
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json().catch(() => ({}));
+    const validatedData = runSchema.parse(body);
+
+    const jobId = await Scheduler.scheduleAt(
+      mediaGc.name,
+      validatedData,
+      new Date(),
+      `media-gc-${Date.now()}`
+    );
+
+    await AuditLog.record({
+      action: 'admin.media_gc_started',
+      actorType: 'admin',
+      actorId: adminId,
+      details: validatedData,
+    });
+
+    return NextResponse.json(
+      {
+        success: true,
+        message: validatedData.dryRun
+          ? 'Media GC dry run started'
+          : 'Media GC started',
+        data: { jobId, ...validatedData },
+      },
+      { status: 202 }
+    );
+  } catch (error) {
+    console.error('💥 Start media GC error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to start media GC',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
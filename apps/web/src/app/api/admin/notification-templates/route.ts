@@ -0,0 +1,199 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import {
+  eventPlaceholders,
+  hasTemplate,
+  NotificationTemplates,
+  placeholders,
+  TEMPLATE_CHANNELS,
+  TEMPLATE_EVENTS,
+  TEMPLATE_LOCALES,
+} from '@/lib/notification-templates';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const listSchema = z.object({
+  event: z.string().refine(event => TEMPLATE_EVENTS.includes(event)).optional(),
+});
+
+const keySchema = z
+  .object({
+    event: z.string(),
+    channel: z.enum(TEMPLATE_CHANNELS),
+    locale: z.enum(TEMPLATE_LOCALES),
+  })
+  .refine(
+    key => hasTemplate(key.event, key.channel),
+    'This event has no template on this channel'
+  );
+
+const overrideSchema = z
+  .object({
+    event: z.string(),
+    channel: z.enum(TEMPLATE_CHANNELS),
+    locale: z.enum(TEMPLATE_LOCALES),
+    title: z.string().min(1).max(200),
+    body: z.string().min(1).max(2000),
+  })
+  .refine(
+    data => hasTemplate(data.event, data.channel),
+    'This event has no template on this channel'
+  )
+  .refine(
+    data => {
+      // Only variables the event actually provides can be used
+      const allowed = eventPlaceholders(data.event);
+      return placeholders(data.title + data.body).every(name =>
+        allowed.includes(name)
+      );
+    },
+    { message: 'Unknown placeholder', path: ['body'] }
+  );
+
+/**
+ * Every notification template: default copy, placeholders and overrides
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+    const templates = await NotificationTemplates.list(query.event);
+
+    return NextResponse.json({
+      success: true,
+      data: { templates },
+    });
+  } catch (error) {
+    console.error('💥 Fetch notification templates error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch notification templates',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Replace the copy for one event, channel and locale
+ */
+export async function PUT(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const { title, body: text, ...key } = overrideSchema.parse(body);
+
+    const template = await NotificationTemplates.setOverride(
+      key,
+      { title, body: text },
+      adminId
+    );
+
+    return NextResponse.json({
+      success: true,
+      message: 'Template updated',
+      data: { template },
+    });
+  } catch (error) {
+    console.error('💥 Update notification template error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update notification template',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Go back to the default copy
+ */
+export async function DELETE(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const key = keySchema.parse(body);
+
+    const cleared = await NotificationTemplates.clearOverride(key, adminId);
+    if (!cleared) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This template has no override',
+          error_type: 'override_not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Template reset to default',
+    });
+  } catch (error) {
+    console.error('💥 Reset notification template error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to reset notification template',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,118 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Policies, POLICY_KINDS } from '@/lib/policies';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const listSchema = z.object({
+  kind: z.enum(POLICY_KINDS).optional(),
+});
+
+const publishSchema = z.object({
+  kind: z.enum(POLICY_KINDS),
+  version: z.string().min(1).max(40),
+  url: z.string().url(),
+  summary: z.string().max(1000).optional(),
+  effectiveAt: z.coerce.date().optional(),
+});
+
+/**
+ * Every published policy version, including upcoming ones
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+    const versions = await Policies.list(query.kind);
+
+    return NextResponse.json({
+      success: true,
+      data: versions,
+    });
+  } catch (error) {
+    console.error('💥 Fetch policy versions error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch policy versions',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Publish a new terms or privacy policy version. From `effectiveAt` on,
+ * users have to accept it before they can keep using the app.
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = publishSchema.parse(body);
+
+    const result = await Policies.publish(validatedData, adminId);
+
+    if (result.status === 'already_exists') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This policy version already exists',
+          error_type: 'already_exists',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Policy version published',
+      data: result.policy,
+    });
+  } catch (error) {
+    console.error('💥 Publish policy version error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to publish policy version',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
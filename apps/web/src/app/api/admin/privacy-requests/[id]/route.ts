@@ -0,0 +1,107 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { PrivacyRequests } from '@/lib/privacy-requests';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const updateSchema = z.discriminatedUnion('action', [
+  z.object({ action: z.literal('start') }),
+  z.object({
+    action: z.literal('fulfill'),
+    note: z.string().max(500).optional(),
+  }),
+  z.object({
+    action: z.literal('reject'),
+    // Shown to the user
+    note: z.string().min(1, 'A reason is required').max(500),
+  }),
+]);
+
+/**
+ * Move a data subject request along: start work on it, fulfill it (runs
+ * the export or erasure), or reject it
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = updateSchema.parse(body);
+
+    let privacyRequest;
+    switch (validatedData.action) {
+      case 'start':
+        privacyRequest = await PrivacyRequests.start(id, adminId);
+        break;
+      case 'fulfill':
+        privacyRequest = await PrivacyRequests.fulfill(
+          id,
+          adminId,
+          validatedData.note
+        );
+        break;
+      case 'reject':
+        privacyRequest = await PrivacyRequests.reject(
+          id,
+          adminId,
+          validatedData.note
+        );
+        break;
+    }
+
+    if (!privacyRequest) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Request not found or not in a state for this action',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Privacy request updated',
+      data: {
+        id: privacyRequest.id,
+        userId: privacyRequest.userId,
+        type: privacyRequest.type,
+        status: privacyRequest.status,
+        dueAt: privacyRequest.dueAt,
+        handledBy: privacyRequest.handledBy,
+        note: privacyRequest.note,
+        completedAt: privacyRequest.completedAt,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Update privacy request error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update privacy request',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
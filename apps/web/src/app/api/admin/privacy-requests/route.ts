@@ -0,0 +1,70 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import {
+  PrivacyRequests,
+  PRIVACY_REQUEST_STATUSES,
+  PRIVACY_REQUEST_TYPES,
+} from '@/lib/privacy-requests';
+import { requireAdmin } from '@/middleware/admin';
+
+const listSchema = z.object({
+  status: z.enum(PRIVACY_REQUEST_STATUSES).optional(),
+  type: z.enum(PRIVACY_REQUEST_TYPES).optional(),
+  overdue: z
+    .enum(['true', 'false'])
+    .transform(value => value === 'true')
+    .optional(),
+  limit: z.coerce.number().int().min(1).max(100).default(25),
+  cursor: z.string().optional(),
+});
+
+/**
+ * Data subject requests, nearest deadline first
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const requests = await PrivacyRequests.list(query);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        requests,
+        nextCursor:
+          requests.length === query.limit
+            ? requests[requests.length - 1].id
+            : null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch privacy request queue error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch privacy requests',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
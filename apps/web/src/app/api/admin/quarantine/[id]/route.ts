@@ -0,0 +1,60 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { Quarantine } from '@/lib/quarantine';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+/**
+ * Delete a quarantined upload's file; the record is kept
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+
+    const result = await Quarantine.purge(id, adminId);
+
+    switch (result.status) {
+      case 'purged':
+        return NextResponse.json({
+          success: true,
+          message: 'Quarantined upload purged',
+          data: result.upload,
+        });
+      case 'already_purged':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Upload was already purged',
+            error_type: 'already_purged',
+          },
+          { status: 409 }
+        );
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Quarantined upload not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+    }
+  } catch (error) {
+    console.error('💥 Purge quarantined upload error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to purge quarantined upload',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
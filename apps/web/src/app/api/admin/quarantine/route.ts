@@ -0,0 +1,65 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Quarantine } from '@/lib/quarantine';
+import { requireAdmin } from '@/middleware/admin';
+
+const listSchema = z.object({
+  status: z.enum(['held', 'purged']).default('held'),
+  limit: z.coerce.number().int().min(1).max(100).default(25),
+  cursor: z.string().optional(),
+});
+
+/**
+ * Uploads that failed the virus scan, newest first
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const uploads = await Quarantine.list(
+      query.status,
+      query.limit,
+      query.cursor
+    );
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        uploads,
+        nextCursor:
+          uploads.length === query.limit
+            ? uploads[uploads.length - 1].id
+            : null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch quarantined uploads error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch quarantined uploads',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
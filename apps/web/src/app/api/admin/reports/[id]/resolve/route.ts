@@ -0,0 +1,71 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Reports, MODERATION_ACTIONS } from '@/lib/reports';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const resolveSchema = z.object({
+  action: z.enum(MODERATION_ACTIONS),
+  // temp_ban only
+  durationHours: z.number().positive().max(8760).optional(),
+  note: z.string().max(500).optional(),
+});
+
+/**
+ * Resolve an open report with a moderation action
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = resolveSchema.parse(body);
+
+    const report = await Reports.resolve(id, validatedData, adminId);
+    if (!report) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Report not found or already resolved',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Report resolved',
+      data: report,
+    });
+  } catch (error) {
+    console.error('💥 Resolve report error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid resolution data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to resolve report',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,57 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { Reports } from '@/lib/reports';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+/**
+ * A report with its evidence: reported messages, photos and prior history
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const report = await Reports.getEvidence(id);
+    if (!report) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Report not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    await AuditLog.recordSafely({
+      action: 'admin.report_viewed',
+      actorType: 'admin',
+      actorId: await getAdminId(request),
+      targetType: 'report',
+      targetId: id,
+      ipAddress: ClientIp.of(request),
+    });
+
+    return NextResponse.json({
+      success: true,
+      data: report,
+    });
+  } catch (error) {
+    console.error('💥 Fetch report error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch report',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
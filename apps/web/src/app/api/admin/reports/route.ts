@@ -0,0 +1,60 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Reports } from '@/lib/reports';
+import { requireAdmin } from '@/middleware/admin';
+
+const queueSchema = z.object({
+  limit: z.coerce.number().int().min(1).max(100).default(25),
+  cursor: z.string().optional(),
+});
+
+/**
+ * Open reports awaiting review, oldest first
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = queueSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const reports = await Reports.listOpen(query.limit, query.cursor);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        reports,
+        nextCursor:
+          reports.length === query.limit
+            ? reports[reports.length - 1].id
+            : null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch report queue error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch reports',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,53 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { DataRetention } from '@/lib/data-retention';
+import { requireAdmin } from '@/middleware/admin';
+
+const reportQuerySchema = z.object({
+  days: z.coerce.number().int().min(1).max(365).default(30),
+  limit: z.coerce.number().int().min(1).max(500).default(100),
+});
+
+/**
+ * Retention policies, and what their enforcement purged and anonymized
+ * over the last `days` days
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = reportQuerySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const since = new Date(Date.now() - query.days * 24 * 60 * 60 * 1000);
+    const report = await DataRetention.report(since, query.limit);
+
+    return NextResponse.json({ success: true, data: report });
+  } catch (error) {
+    console.error('💥 Retention report error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to load retention report',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
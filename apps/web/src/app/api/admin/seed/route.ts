@@ -0,0 +1,89 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { JwtKeys } from '@/lib/jwt-keys';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import { DevSeed, devSeedEnabled, MAX_SEED_USERS } from '@/lib/dev-seed';
+import { SESSION_COOKIE } from '@/middleware/auth';
+
+const seedSchema = z.object({
+  users: z.number().int().min(2).max(MAX_SEED_USERS).default(50),
+  seed: z.number().int().default(1),
+});
+
+/**
+ * Fill a development database with fake users, signals and matches, and
+ * sign in as the first seed user. Only exists when DEV_SEED_ENABLED is on
+ * outside production, so it needs no admin session (there's no World ID
+ * to get one with in local development).
+ */
+export async function POST(request: NextRequest) {
+  if (!devSeedEnabled()) {
+    return NextResponse.json(
+      { success: false, message: 'Not found' },
+      { status: 404 }
+    );
+  }
+
+  try {
+    const body = await request.json().catch(() => ({}));
+    const validatedData = seedSchema.parse(body);
+
+    const result = await DevSeed.run(validatedData);
+
+    const viewer = await prisma.user.findUniqueOrThrow({
+      where: { id: result.userIds[0] },
+    });
+    const token = await JwtKeys.sign(
+      {
+        worldId: viewer.worldId,
+        walletAddress: viewer.walletAddress,
+        profileId: viewer.id,
+        profileCompleted: true,
+        nftVerified: viewer.nftVerified,
+      },
+      '7d'
+    );
+
+    const response = NextResponse.json({
+      success: true,
+      message: `Seeded ${result.userIds.length} users`,
+      data: {
+        users: result.userIds.length,
+        signals: result.signals,
+        passes: result.passes,
+        matches: result.matches,
+        signedInAs: { id: viewer.id, handle: viewer.handle },
+      },
+    });
+    response.cookies.set(SESSION_COOKIE, token, {
+      httpOnly: true,
+      secure: false,
+      sameSite: 'strict',
+      maxAge: 7 * 24 * 60 * 60,
+      path: '/',
+    });
+    return response;
+  } catch (error) {
+    console.error('💥 Dev seed error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid seed options',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to seed database',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,49 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { SpeedDating } from '@/lib/speed-dating';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+/**
+ * Cancel a session. Queued users are dropped; running dates finish.
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+
+    const session = await SpeedDating.cancel(id, adminId);
+    if (!session) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Session not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Session canceled',
+      data: session,
+    });
+  } catch (error) {
+    console.error('💥 Cancel speed-dating session error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to cancel session',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
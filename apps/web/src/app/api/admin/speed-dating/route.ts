@@ -0,0 +1,102 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { SpeedDating } from '@/lib/speed-dating';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const createSchema = z
+  .object({
+    title: z.string().trim().min(1).max(120),
+    cityId: z.string().min(1).nullable().optional(),
+    startsAt: z.coerce.date(),
+    endsAt: z.coerce.date(),
+    roundSeconds: z.number().int().min(60).max(30 * 60).optional(),
+  })
+  .refine(data => data.endsAt > data.startsAt, {
+    message: 'A session must end after it starts',
+    path: ['endsAt'],
+  });
+
+/**
+ * Speed-dating sessions, newest first
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const sessions = await SpeedDating.list();
+
+    return NextResponse.json({
+      success: true,
+      data: sessions,
+    });
+  } catch (error) {
+    console.error('💥 Fetch speed-dating sessions error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch sessions',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Schedule a speed-dating session
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = createSchema.parse(body);
+
+    const result = await SpeedDating.create(validatedData, adminId);
+    if (result.status !== 'saved') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Unknown city',
+          error_type: 'invalid_city',
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Session scheduled',
+      data: result.session,
+    });
+  } catch (error) {
+    console.error('💥 Create speed-dating session error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to create session',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
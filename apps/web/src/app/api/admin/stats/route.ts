@@ -0,0 +1,133 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import { analyticsDay } from '@/lib/analytics';
+import { ResponseStats } from '@/lib/response-stats';
+import { requireAdmin } from '@/middleware/admin';
+
+const WINDOW_DAYS = { '1d': 1, '7d': 7, '30d': 30, '90d': 90 } as const;
+
+const statsQuerySchema = z.object({
+  window: z.enum(['1d', '7d', '30d', '90d']).default('7d'),
+});
+
+// Signal types that can produce a match
+const LIKE_TYPES = ['like', 'super_like'];
+
+/**
+ * Product stats over a window, read from the daily analytics rollups
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = statsQuerySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const days = WINDOW_DAYS[query.window];
+    const to = analyticsDay(new Date());
+    const from = analyticsDay(
+      new Date(Date.now() - (days - 1) * 24 * 60 * 60 * 1000)
+    );
+
+    const [rows, responsiveness] = await Promise.all([
+      prisma.analyticsDaily.findMany({
+        where: { date: { gte: from, lte: to } },
+        orderBy: { date: 'asc' },
+      }),
+      ResponseStats.summary(),
+    ]);
+
+    const sum = (metric: string) =>
+      rows
+        .filter(row => row.metric === metric)
+        .reduce((total, row) => total + row.value, 0);
+    const byDimension = (metric: string) =>
+      rows
+        .filter(row => row.metric === metric)
+        .reduce<Record<string, number>>((totals, row) => {
+          totals[row.dimension] = (totals[row.dimension] || 0) + row.value;
+          return totals;
+        }, {});
+    const daily = (metric: string) =>
+      Object.fromEntries(
+        rows
+          .filter(row => row.metric === metric)
+          .map(row => [row.date, row.value])
+      );
+
+    const dau = daily('dau');
+    const wau = daily('wau');
+    const signals = byDimension('signals');
+    const likes = LIKE_TYPES.reduce((n, type) => n + (signals[type] || 0), 0);
+    const matches = sum('matches');
+    const lastRollup = rows.reduce<Date | null>(
+      (latest, row) =>
+        !latest || row.updatedAt > latest ? row.updatedAt : latest,
+      null
+    );
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        window: query.window,
+        from,
+        to,
+        activeUsers: {
+          dau: dau[to] ?? 0,
+          wau: wau[to] ?? 0,
+          daily: Object.keys(dau).map(date => ({
+            date,
+            dau: dau[date],
+            wau: wau[date] ?? 0,
+          })),
+        },
+        signups: {
+          total: sum('signups'),
+          byAuthMethod: byDimension('signups'),
+        },
+        signals: {
+          total: sum('signals'),
+          byType: signals,
+        },
+        matches,
+        // Share of likes that turned into a match
+        matchRate: likes > 0 ? matches / likes : null,
+        messages: sum('messages'),
+        // Current, across all users; never per user
+        responsiveness,
+        screenViews: {
+          total: sum('screen_views'),
+          byScreen: byDimension('screen_views'),
+        },
+        updatedAt: lastRollup,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Admin stats error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch stats',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,103 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Tenants } from '@/lib/tenants';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+// Null quotas and gate go back to the defaults
+const updateSchema = z.object({
+  name: z.string().min(1).max(100).optional(),
+  host: z
+    .string()
+    .regex(/^[a-z0-9.-]+$/, 'Use a bare lowercase hostname')
+    .nullable()
+    .optional(),
+  gateContractAddress: z
+    .string()
+    .regex(/^0x[a-fA-F0-9]{40}$/, 'Invalid contract address')
+    .nullable()
+    .optional(),
+  gateCollectionName: z.string().min(1).max(100).nullable().optional(),
+  discoveryDeckSize: z.number().int().min(1).max(50).nullable().optional(),
+  freeDailySuperInterests: z
+    .number()
+    .int()
+    .min(0)
+    .max(50)
+    .nullable()
+    .optional(),
+  freeDailyTopPicks: z.number().int().min(0).max(20).nullable().optional(),
+  // false suspends the instance: its members fall back to the default
+  // host, gate and quotas but stay partitioned
+  active: z.boolean().optional(),
+});
+
+/**
+ * Change a campus instance's settings. The campus and ID are fixed.
+ */
+export async function PATCH(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = updateSchema.parse(body);
+
+    const result = await Tenants.update(id, validatedData, adminId);
+
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Tenant not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (result.status !== 'saved') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Another tenant is served from this host',
+          error_type: 'host_taken',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Tenant updated',
+      data: result.tenant,
+    });
+  } catch (error) {
+    console.error('💥 Update tenant error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update tenant',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
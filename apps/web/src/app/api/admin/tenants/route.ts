@@ -0,0 +1,141 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Tenants } from '@/lib/tenants';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+// Null quotas and gate fall back to the defaults
+const createSchema = z.object({
+  id: z
+    .string()
+    .regex(/^[a-z0-9_]{2,40}$/, 'Use lowercase letters, digits and _'),
+  // One tenant per campus
+  campusId: z.string().min(1),
+  name: z.string().min(1).max(100),
+  host: z
+    .string()
+    .regex(/^[a-z0-9.-]+$/, 'Use a bare lowercase hostname')
+    .nullable()
+    .optional(),
+  gateContractAddress: z
+    .string()
+    .regex(/^0x[a-fA-F0-9]{40}$/, 'Invalid contract address')
+    .nullable()
+    .optional(),
+  gateCollectionName: z.string().min(1).max(100).nullable().optional(),
+  discoveryDeckSize: z.number().int().min(1).max(50).nullable().optional(),
+  freeDailySuperInterests: z
+    .number()
+    .int()
+    .min(0)
+    .max(50)
+    .nullable()
+    .optional(),
+  freeDailyTopPicks: z.number().int().min(0).max(20).nullable().optional(),
+  active: z.boolean().optional(),
+});
+
+/**
+ * Every campus instance, including inactive ones
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const tenants = await Tenants.list();
+
+    return NextResponse.json({
+      success: true,
+      data: tenants,
+    });
+  } catch (error) {
+    console.error('💥 Fetch tenants error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch tenants',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Launch a campus instance. The campus's existing students join it.
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = createSchema.parse(body);
+
+    const result = await Tenants.create(validatedData, adminId);
+
+    if (result.status === 'already_exists') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'A tenant with this ID or campus already exists',
+          error_type: 'already_exists',
+        },
+        { status: 409 }
+      );
+    }
+    if (result.status === 'host_taken') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Another tenant is served from this host',
+          error_type: 'host_taken',
+        },
+        { status: 409 }
+      );
+    }
+    if (result.status !== 'saved') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Unknown campus',
+          error_type: 'invalid_campus',
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Tenant created',
+      data: result.tenant,
+    });
+  } catch (error) {
+    console.error('💥 Create tenant error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to create tenant',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
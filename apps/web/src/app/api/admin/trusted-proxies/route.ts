@@ -0,0 +1,122 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { ClientIp } from '@/lib/client-ip';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const updateSchema = z.object({
+  // Addresses or CIDR ranges, e.g. Cloudflare's published ranges plus
+  // the nginx network. An empty list trusts nobody: every request is taken
+  // to come straight from the client.
+  cidrs: z
+    .array(
+      z.string().trim().refine(ClientIp.isValidCidr, 'Invalid address range')
+    )
+    .max(500),
+});
+
+/**
+ * The proxies whose forwarded client IPs are believed, and the address
+ * this request resolves to with them
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const proxies = await ClientIp.trustedProxies();
+
+    return NextResponse.json({
+      success: true,
+      data: { ...proxies, clientIp: ClientIp.of(request) },
+    });
+  } catch (error) {
+    console.error('💥 Fetch trusted proxies error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch trusted proxies',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Replace the trusted proxies without a restart
+ */
+export async function PUT(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const { cidrs } = updateSchema.parse(body);
+
+    const proxies = await ClientIp.setTrustedProxies(cidrs, adminId);
+
+    return NextResponse.json({
+      success: true,
+      message: 'Trusted proxies updated',
+      data: proxies,
+    });
+  } catch (error) {
+    console.error('💥 Update trusted proxies error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update trusted proxies',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Go back to the TRUSTED_PROXIES configuration
+ */
+export async function DELETE(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const proxies = await ClientIp.resetTrustedProxies(adminId);
+
+    return NextResponse.json({
+      success: true,
+      message: 'Trusted proxies reset to configuration',
+      data: proxies,
+    });
+  } catch (error) {
+    console.error('💥 Reset trusted proxies error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to reset trusted proxies',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
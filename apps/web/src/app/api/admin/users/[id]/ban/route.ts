@@ -0,0 +1,77 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import { Bans, BAN_REASONS } from '@/lib/bans';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const banSchema = z.object({
+  reason: z.enum(BAN_REASONS),
+  // Omit for a permanent ban
+  durationHours: z.number().positive().max(8760).optional(),
+  note: z.string().max(500).optional(),
+});
+
+/**
+ * Ban a user, temporarily or permanently
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = banSchema.parse(body);
+
+    const user = await prisma.user.findUnique({
+      where: { id },
+      select: { id: true },
+    });
+    if (!user) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const ban = await Bans.ban(id, validatedData, adminId);
+
+    return NextResponse.json({
+      success: true,
+      message: 'User banned',
+      data: { userId: id, ...ban },
+    });
+  } catch (error) {
+    console.error('💥 Ban user error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid ban data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to ban user',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,85 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import { DuplicateAccounts } from '@/lib/duplicate-accounts';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const graphSchema = z.object({
+  depth: z.coerce.number().int().min(1).max(3).default(2),
+});
+
+/**
+ * Evidence graph of the accounts linked to a user: shared World ID
+ * nullifiers, wallets, devices, matching faces and reused photos
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const query = graphSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const user = await prisma.user.findUnique({
+      where: { id },
+      select: { id: true },
+    });
+    if (!user) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const graph = await DuplicateAccounts.getEvidenceGraph(id, query.depth);
+
+    await AuditLog.recordSafely({
+      action: 'admin.duplicates_viewed',
+      actorType: 'admin',
+      actorId: await getAdminId(request),
+      targetType: 'user',
+      targetId: id,
+      ipAddress: ClientIp.of(request),
+    });
+
+    return NextResponse.json({
+      success: true,
+      data: graph,
+    });
+  } catch (error) {
+    console.error('💥 Fetch duplicate graph error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch duplicate graph',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
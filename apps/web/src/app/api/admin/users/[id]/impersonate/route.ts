@@ -0,0 +1,104 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import {
+  mintImpersonationToken,
+  IMPERSONATION_SCOPES,
+  MAX_IMPERSONATION_MINUTES,
+} from '@/lib/impersonation';
+import { SESSION_COOKIE } from '@/middleware/auth';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const impersonateSchema = z.object({
+  // Why support needs to see the app as this user (e.g. a ticket reference)
+  reason: z.string().min(3).max(500),
+  scope: z.enum(IMPERSONATION_SCOPES).default('read'),
+  ttlMinutes: z
+    .number()
+    .int()
+    .positive()
+    .max(MAX_IMPERSONATION_MINUTES)
+    .default(15),
+});
+
+/**
+ * Mint a time-limited impersonation session token for a user
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = impersonateSchema.parse(body);
+
+    const user = await prisma.user.findUnique({
+      where: { id },
+      select: {
+        id: true,
+        worldId: true,
+        walletAddress: true,
+        nftVerified: true,
+        tenantId: true,
+      },
+    });
+    if (!user) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const impersonation = await mintImpersonationToken(
+      user,
+      adminId,
+      validatedData
+    );
+
+    return NextResponse.json({
+      success: true,
+      message: 'Impersonation session created',
+      data: {
+        impersonationId: impersonation.impersonationId,
+        // Use as the session cookie in a separate browser profile
+        cookie: SESSION_COOKIE,
+        token: impersonation.token,
+        scope: validatedData.scope,
+        expiresAt: impersonation.expiresAt,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Impersonation error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid impersonation request',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to start impersonation',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
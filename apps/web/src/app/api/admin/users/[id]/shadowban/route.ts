@@ -0,0 +1,80 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import { Shadowbans } from '@/lib/shadowbans';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const shadowbanSchema = z.object({
+  enabled: z.boolean(),
+  note: z.string().max(500).optional(),
+});
+
+/**
+ * Turn a user's shadowban on or off
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = shadowbanSchema.parse(body);
+
+    const user = await prisma.user.findUnique({
+      where: { id },
+      select: { id: true },
+    });
+    if (!user) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    await Shadowbans.set(
+      id,
+      validatedData.enabled,
+      adminId,
+      validatedData.note
+    );
+
+    return NextResponse.json({
+      success: true,
+      message: validatedData.enabled ? 'User shadowbanned' : 'Shadowban lifted',
+      data: { userId: id, shadowbanned: validatedData.enabled },
+    });
+  } catch (error) {
+    console.error('💥 Shadowban error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid shadowban data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update shadowban',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
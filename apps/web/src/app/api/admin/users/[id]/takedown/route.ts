@@ -0,0 +1,93 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import {
+  Takedowns,
+  TAKEDOWN_FIELDS,
+  CONTENT_POLICIES,
+  ContentPolicy,
+} from '@/lib/takedowns';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const takedownSchema = z.object({
+  field: z.enum(TAKEDOWN_FIELDS),
+  policy: z.enum(
+    Object.keys(CONTENT_POLICIES) as [ContentPolicy, ...ContentPolicy[]]
+  ),
+  note: z.string().max(500).optional(),
+  // Required for field "prompt"
+  promptId: z.string().optional(),
+});
+
+const FIELD_LABELS = { photo: 'Photo', bio: 'Bio', prompt: 'Prompt answer' };
+
+/**
+ * Remove a user's photo, bio or a prompt answer for breaking a content
+ * policy
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = takedownSchema.parse(body);
+
+    const removed = await Takedowns.remove(
+      id,
+      validatedData.field,
+      validatedData.policy,
+      adminId,
+      { note: validatedData.note, promptId: validatedData.promptId }
+    );
+    if (!removed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found or nothing to remove',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: `${FIELD_LABELS[validatedData.field]} removed`,
+      data: {
+        userId: id,
+        field: validatedData.field,
+        policy: validatedData.policy,
+        ...(validatedData.promptId && { promptId: validatedData.promptId }),
+      },
+    });
+  } catch (error) {
+    console.error('💥 Content takedown error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid takedown data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to remove content',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,84 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import { Bans } from '@/lib/bans';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const unbanSchema = z.object({
+  note: z.string().max(500).optional(),
+});
+
+/**
+ * Lift a user's ban
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json().catch(() => ({}));
+    const validatedData = unbanSchema.parse(body);
+
+    const user = await prisma.user.findUnique({
+      where: { id },
+      select: { status: true },
+    });
+    if (!user) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (user.status !== 'banned') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User is not banned',
+          error_type: 'not_banned',
+        },
+        { status: 409 }
+      );
+    }
+
+    await Bans.unban(id, adminId, validatedData.note);
+
+    return NextResponse.json({
+      success: true,
+      message: 'User unbanned',
+      data: { userId: id },
+    });
+  } catch (error) {
+    console.error('💥 Unban user error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid unban data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to unban user',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
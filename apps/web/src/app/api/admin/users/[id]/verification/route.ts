@@ -0,0 +1,83 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import { Verification, VERIFICATION_BADGES } from '@/lib/verification';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const overrideSchema = z.object({
+  badge: z.enum(VERIFICATION_BADGES),
+  // true grants, false revokes, null hands back to the automated check
+  granted: z.boolean().nullable(),
+  reason: z.string().min(1, 'Reason is required').max(500),
+});
+
+/**
+ * Manually grant or revoke a user's NFT or photo verification badge
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = overrideSchema.parse(body);
+
+    const user = await prisma.user.findUnique({
+      where: { id },
+      select: { id: true },
+    });
+    if (!user) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const status = await Verification.setOverride(
+      id,
+      validatedData.badge,
+      validatedData.granted,
+      adminId,
+      validatedData.reason
+    );
+
+    return NextResponse.json({
+      success: true,
+      message: 'Verification updated',
+      data: { userId: id, ...status },
+    });
+  } catch (error) {
+    console.error('💥 Verification override error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid verification data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update verification',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
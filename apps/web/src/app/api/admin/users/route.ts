@@ -0,0 +1,116 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Prisma } from '@prisma/client';
+import prisma from '@/lib/prisma';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { COHORTS } from '@/lib/cohorts';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const searchSchema = z.object({
+  handle: z.string().min(1).optional(),
+  wallet: z.string().min(1).optional(),
+  worldId: z.string().min(1).optional(),
+  status: z.enum(['active', 'banned']).optional(),
+  shadowbanned: z.enum(['true', 'false']).optional(),
+  cohort: z.enum(COHORTS).optional(),
+  limit: z.coerce.number().int().min(1).max(100).default(25),
+  cursor: z.string().optional(),
+});
+
+/**
+ * Search users by handle, wallet, World ID, ban status and cohort
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = searchSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const now = new Date();
+    const activeBan: Prisma.UserWhereInput = {
+      status: 'banned',
+      OR: [{ bannedUntil: null }, { bannedUntil: { gt: now } }],
+    };
+
+    const where: Prisma.UserWhereInput = {
+      ...(query.handle && { handle: { contains: query.handle } }),
+      ...(query.wallet && {
+        walletAddress: { in: [query.wallet, query.wallet.toLowerCase()] },
+      }),
+      ...(query.worldId && { worldId: query.worldId }),
+      ...(query.status === 'banned' && activeBan),
+      ...(query.status === 'active' && { NOT: activeBan }),
+      ...(query.shadowbanned && {
+        shadowbanned: query.shadowbanned === 'true',
+      }),
+      ...(query.cohort && { cohort: query.cohort }),
+    };
+
+    const users = await prisma.user.findMany({
+      where,
+      select: {
+        id: true,
+        handle: true,
+        displayName: true,
+        worldId: true,
+        walletAddress: true,
+        status: true,
+        bannedUntil: true,
+        banReason: true,
+        shadowbanned: true,
+        cohort: true,
+        billingFlaggedAt: true,
+        createdAt: true,
+        lastSeen: true,
+      },
+      orderBy: { id: 'asc' },
+      take: query.limit,
+      ...(query.cursor && { cursor: { id: query.cursor }, skip: 1 }),
+    });
+
+    await AuditLog.recordSafely({
+      action: 'admin.users_searched',
+      actorType: 'admin',
+      actorId: await getAdminId(request),
+      details: { ...query },
+      ipAddress: ClientIp.of(request),
+    });
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        users,
+        nextCursor:
+          users.length === query.limit ? users[users.length - 1].id : null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Admin user search error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to search users',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,62 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { MAX_ADMISSION_BATCH } from '@/lib/cohorts';
+import { Waitlist } from '@/lib/waitlist';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const admitSchema = z.object({
+  count: z.number().int().min(1).max(MAX_ADMISSION_BATCH),
+  campusId: z.string().min(1).optional(),
+});
+
+/**
+ * Admit the next `count` waitlist entries (optionally from one campus)
+ * into the soft launch. They sign up invited, and any who already have an
+ * account are let in now.
+ */
+export async function POST(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const adminId = (await getAdminId(request))!;
+    const body = await request.json();
+    const validatedData = admitSchema.parse(body);
+
+    const admission = await Waitlist.admit(
+      validatedData.count,
+      adminId,
+      validatedData.campusId
+    );
+
+    return NextResponse.json({
+      success: true,
+      message: `Admitted ${admission.admitted} waitlist entries`,
+      data: admission,
+    });
+  } catch (error) {
+    console.error('💥 Admit waitlist error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid admission data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to admit waitlist entries',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
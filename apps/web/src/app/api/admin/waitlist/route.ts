@@ -0,0 +1,101 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { Waitlist, WAITLIST_STATUSES } from '@/lib/waitlist';
+import { getAdminId, requireAdmin } from '@/middleware/admin';
+
+const exportQuerySchema = z.object({
+  status: z.enum(WAITLIST_STATUSES).optional(),
+  campusId: z.string().min(1).optional(),
+  format: z.enum(['json', 'csv']).default('json'),
+  limit: z.coerce.number().int().min(1).max(5000).default(500),
+  cursor: z.string().optional(),
+});
+
+const CSV_COLUMNS = [
+  'id',
+  'worldId',
+  'verificationLevel',
+  'campusId',
+  'email',
+  'status',
+  'createdAt',
+  'admittedAt',
+] as const;
+
+function csvField(value: unknown): string {
+  const text =
+    value instanceof Date ? value.toISOString() : String(value ?? '');
+  return /[",\n]/.test(text) ? `"${text.replace(/"/g, '""')}"` : text;
+}
+
+/**
+ * Export waitlist entries in queue order, as JSON pages or CSV
+ */
+export async function GET(request: NextRequest) {
+  const adminResponse = await requireAdmin(request);
+  if (adminResponse) {
+    return adminResponse;
+  }
+
+  try {
+    const query = exportQuerySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const entries = await Waitlist.list(query);
+    const nextCursor =
+      entries.length === query.limit ? entries[entries.length - 1].id : null;
+
+    // Entries hold contact details, so exports are audited
+    await AuditLog.recordSafely({
+      action: 'admin.waitlist_exported',
+      actorType: 'admin',
+      actorId: await getAdminId(request),
+      details: { ...query, count: entries.length },
+      ipAddress: ClientIp.of(request),
+    });
+
+    if (query.format === 'csv') {
+      const rows = entries.map(entry =>
+        CSV_COLUMNS.map(column => csvField(entry[column])).join(',')
+      );
+      return new NextResponse([CSV_COLUMNS.join(','), ...rows].join('\n'), {
+        headers: {
+          'Content-Type': 'text/csv; charset=utf-8',
+          'Content-Disposition': 'attachment; filename="waitlist.csv"',
+          // Pass back as `cursor` for the next page
+          ...(nextCursor && { 'X-Next-Cursor': nextCursor }),
+        },
+      });
+    }
+
+    return NextResponse.json({
+      success: true,
+      data: { entries, nextCursor },
+    });
+  } catch (error) {
+    console.error('💥 Waitlist export error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to export waitlist',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,47 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { AgeVerification, DocumentCheckResult } from '@/lib/age-verification';
+
+/**
+ * Document check provider callback. The raw body is needed for signature
+ * checks.
+ */
+export async function POST(request: NextRequest) {
+  const payload = await request.text();
+
+  let result: DocumentCheckResult;
+  try {
+    result = AgeVerification.verifyDocumentWebhook(
+      payload,
+      request.headers.get('x-signature')
+    );
+  } catch (error) {
+    console.warn('⚠️ Rejected age verification webhook:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Invalid webhook signature',
+        error_type: 'invalid_signature',
+      },
+      { status: 400 }
+    );
+  }
+
+  try {
+    const outcome = await AgeVerification.handleDocumentResult(result);
+    return NextResponse.json({
+      success: true,
+      data: { sessionId: result.sessionId, outcome: outcome.status },
+    });
+  } catch (error) {
+    // A 5xx makes the provider retry the delivery
+    console.error('💥 Age verification webhook error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to process webhook',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,67 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { rateLimitMiddleware } from '@/middleware/rateLimit';
+import { ClientEvents, clientEventBatchSchema } from '@/lib/client-events';
+
+/**
+ * Batched product analytics events from the miniapp
+ */
+export async function POST(request: NextRequest) {
+  const rateLimitResponse = await rateLimitMiddleware(request);
+  if (rateLimitResponse) {
+    return rateLimitResponse;
+  }
+
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    // Admins acting as a user shouldn't skew product metrics
+    if (session.impersonation) {
+      return NextResponse.json(
+        { success: true, message: 'Events ignored', data: { accepted: 0 } },
+        { status: 202 }
+      );
+    }
+
+    const body = await request.json();
+    const validatedData = clientEventBatchSchema.parse(body);
+
+    const accepted = await ClientEvents.ingest(validatedData.events, {
+      userId: session.profileId!,
+      sessionId: validatedData.sessionId,
+      appVersion: request.headers.get('x-app-version'),
+    });
+
+    return NextResponse.json(
+      { success: true, message: 'Events accepted', data: { accepted } },
+      { status: 202 }
+    );
+  } catch (error) {
+    console.error('💥 Client events error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to record events',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
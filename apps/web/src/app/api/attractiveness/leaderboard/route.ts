@@ -1,5 +1,6 @@
 import { NextRequest, NextResponse } from 'next/server'
 import { attractivenessEngine } from '@/lib/attractiveness-engine'
+import { singleflight } from '@/lib/singleflight'
 
 export async function GET(request: NextRequest) {
   try {
@@ -7,8 +8,11 @@ export async function GET(request: NextRequest) {
     const limitParam = searchParams.get('limit')
     const limit = limitParam ? Math.min(100, Math.max(1, parseInt(limitParam))) : 50
 
-    // Get leaderboard data
-    const leaderboard = await attractivenessEngine.getLeaderboard(limit)
+    // Get leaderboard data. It scans every stored embedding, so concurrent
+    // requests for the same size share one scan.
+    const leaderboard = await singleflight('leaderboard', String(limit), () =>
+      attractivenessEngine.getLeaderboard(limit)
+    )
 
     return NextResponse.json({
       success: true,
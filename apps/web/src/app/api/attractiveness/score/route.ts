@@ -14,6 +14,10 @@ import { ScoringRequest } from '@/lib/attractiveness-engine';
 import { mlServiceClient } from '@/lib/ml-service-client';
 import { rateLimitMiddleware } from '@/middleware/rateLimit';
 import { RedisCache } from '@/lib/redis-cache';
+import { checkPhotoQuality } from '@/lib/photo-quality';
+import { selectModelVersion } from '@/lib/ml-model-routing';
+import { ScoreEvents } from '@/lib/score-events';
+import { getSession } from '@/middleware/auth';
 
 export async function POST(request: NextRequest) {
   // Apply rate limiting
@@ -85,6 +89,23 @@ export async function POST(request: NextRequest) {
       );
     }
 
+    // Reject unusable photos before they reach the ML API
+    const quality = await checkPhotoQuality(image);
+    if (!quality.passed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: quality.issues[0].message,
+          error_type: 'photo_quality_insufficient',
+          issues: quality.issues,
+        },
+        { status: 400 }
+      );
+    }
+
+    // Pick the ML model version (header pin or feature-flag assignment)
+    const modelSelection = await selectModelVersion(request, userId);
+
     // Create scoring request
     const scoringRequest: ScoringRequest = {
       userId,
@@ -93,6 +114,7 @@ export async function POST(request: NextRequest) {
         nftVerified: nftVerified || false,
         wldVerified: wldVerified || false,
         timestamp: new Date().toISOString(),
+        modelVersion: modelSelection.version,
       },
     };
 
@@ -131,6 +153,14 @@ export async function POST(request: NextRequest) {
     // Cache the facial score
     await RedisCache.cacheFacialScore(userId, result.score);
 
+    // Emit events for any score thresholds crossed since the last scoring;
+    // only when users score themselves, so no one can push to someone else
+    const session = await getSession(request);
+    const scoreEvents =
+      session?.profileId === userId
+        ? await ScoreEvents.recordScore(userId, 'facial', result.score)
+        : [];
+
     const message = fallbackUsed
       ? `Scored successfully (fallback mode)! Rank #${result.metadata.userRank} out of ${result.metadata.totalUsers} users`
       : `Scored successfully! Rank #${result.metadata.userRank} out of ${result.metadata.totalUsers} users`;
@@ -144,6 +174,7 @@ export async function POST(request: NextRequest) {
         timestamp: result.timestamp,
         metadata: result.metadata,
         distribution: result.distribution,
+        scoreEvents,
       },
       message,
       debug: {
@@ -153,6 +184,8 @@ export async function POST(request: NextRequest) {
             : 'simulated_detection -> simulated_embedding -> vector_comparison -> percentile_calculation -> vibe_clustering',
         mlMode: actualMLMode,
         fallbackUsed,
+        modelVersion: modelSelection.version,
+        modelVersionSource: modelSelection.source,
         confidence: result.metadata.confidence,
         faceMetrics: {
           quality: result.metadata.faceQuality,
@@ -0,0 +1,28 @@
+import { NextResponse } from 'next/server';
+import { JwtKeys, JWKS_MAX_AGE_SECONDS } from '@/lib/jwt-keys';
+
+/**
+ * Public keys for verifying session tokens, by kid. New keys appear here
+ * a full cache lifetime before anything is signed with them.
+ */
+export async function GET() {
+  try {
+    const jwks = await JwtKeys.jwks();
+
+    return NextResponse.json(jwks, {
+      headers: {
+        'Cache-Control': `public, max-age=${JWKS_MAX_AGE_SECONDS}`,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch JWKS error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch signing keys',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
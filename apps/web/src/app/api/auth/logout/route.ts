@@ -1,9 +1,22 @@
 import { NextRequest, NextResponse } from 'next/server'
+import { getSession } from '@/middleware/auth'
+import { AuditLog } from '@/lib/audit-log'
+import { ClientIp } from '@/lib/client-ip'
 
 export async function POST(request: NextRequest) {
   try {
     console.log('🚪 User logging out')
 
+    const session = await getSession(request)
+    if (session) {
+      await AuditLog.recordSafely({
+        action: 'auth.logout',
+        actorType: 'user',
+        actorId: session.worldId,
+        ipAddress: ClientIp.of(request),
+      })
+    }
+
     const responseObj = NextResponse.json({
       success: true,
       message: 'Logged out successfully'
@@ -1,52 +1,18 @@
 import { NextRequest, NextResponse } from 'next/server'
-import { jwtVerify, SignJWT } from 'jose'
+import { JwtKeys } from '@/lib/jwt-keys'
 import { z } from 'zod'
-import { createPublicClient, http } from 'viem'
-import { mainnet } from 'viem/chains'
-
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!)
-
-const publicClient = createPublicClient({
-  chain: mainnet,
-  transport: http(process.env.ALCHEMY_URL), // Assumes ALCHEMY_URL is in .env
-})
-
-const erc721Abi = [
-  {
-    name: 'balanceOf',
-    type: 'function',
-    inputs: [{ name: 'owner', type: 'address' }],
-    outputs: [{ name: '', type: 'uint256' }],
-    stateMutability: 'view',
-  },
-] as const
+import { AuditLog } from '@/lib/audit-log'
+import { ClientIp } from '@/lib/client-ip'
+import { Verification } from '@/lib/verification'
+import { EventBus } from '@/lib/event-bus'
+import { Tenants } from '@/lib/tenants'
+import { EligibleNft, NftOwnership } from '@/lib/nft-ownership'
 
 const nftVerifySchema = z.object({
   walletAddress: z.string().regex(/^0x[a-fA-F0-9]{40}$/, 'Invalid Ethereum address'),
   collections: z.array(z.string())
 })
 
-const ELIGIBLE_NFTS = [
-  {
-    name: "Bangkok University Student ID",
-    contractAddress: "0x1234567890123456789012345678901234567890", // Replace with actual address
-    description: "Official Bangkok University NFT Student ID",
-    requiredAmount: 1
-  },
-  {
-    name: "Chulalongkorn University Pass",
-    contractAddress: "0x2345678901234567890123456789012345678901", // Replace with actual address
-    description: "Chulalongkorn University Alumni/Student NFT",
-    requiredAmount: 1
-  },
-  {
-    name: "Thammasat Gold Member",
-    contractAddress: "0x3456789012345678901234567890123456789012", // Replace with actual address
-    description: "Thammasat University Premium Member NFT",
-    requiredAmount: 1
-  }
-]
-
 export async function POST(request: NextRequest) {
   try {
     // Verify session
@@ -58,7 +24,7 @@ export async function POST(request: NextRequest) {
       )
     }
 
-    const { payload } = await jwtVerify(sessionCookie.value, secret)
+    const { payload } = await JwtKeys.verify(sessionCookie.value)
     if (!payload.walletAddress) {
       return NextResponse.json(
         { success: false, message: 'Wallet connection required' },
@@ -66,6 +32,19 @@ export async function POST(request: NextRequest) {
       )
     }
 
+    // Impersonation sessions are short-lived and admin-minted; they're
+    // never reissued
+    if (payload.impersonation) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This action is disabled while impersonating',
+          error_type: 'impersonation_restricted',
+        },
+        { status: 403 }
+      )
+    }
+
     const body = await request.json()
     const validatedData = nftVerifySchema.parse(body)
 
@@ -74,43 +53,63 @@ export async function POST(request: NextRequest) {
       collections: validatedData.collections.length
     })
 
-    // Check NFT holdings for each eligible collection
-    let hasAccess = false
-    let accessGrantedBy: typeof ELIGIBLE_NFTS[0] | undefined;
-
-    for (const nft of ELIGIBLE_NFTS) {
-      try {
-        const balance = await publicClient.readContract({
-          address: nft.contractAddress as `0x${string}`,
-          abi: erc721Abi,
-          functionName: 'balanceOf',
-          args: [validatedData.walletAddress as `0x${string}`],
-        })
-
-        if (balance >= nft.requiredAmount) {
-          hasAccess = true
-          accessGrantedBy = nft
-          break // Exit loop once access is confirmed
-        }
-      } catch (err) {
-        console.warn(`Could not check balance for ${nft.name}:`, err)
-        // Continue to the next NFT collection
-      }
+    // A campus instance can gate on its own collection instead
+    const tenant = payload.profileId
+      ? await Tenants.get(await Tenants.ofUser(payload.profileId as string))
+      : await Tenants.resolve(request)
+
+    // Check NFT holdings, reusing a recent answer for this wallet
+    let accessGrantedBy: EligibleNft | undefined
+    try {
+      accessGrantedBy =
+        (await NftOwnership.check(validatedData.walletAddress, tenant)) ??
+        undefined
+    } catch (err) {
+      console.warn('Could not check NFT holdings:', err)
     }
+    const hasAccess = Boolean(accessGrantedBy)
     console.log('🎯 NFT Access:', hasAccess ? 'GRANTED' : 'DENIED')
 
-    if (hasAccess) {
-      // Update session with NFT verification
-      const updatedToken = await new SignJWT({
-        ...payload,
-        nftVerified: true,
-        nftVerifiedAt: new Date().toISOString(),
-        eligibleNFT: accessGrantedBy?.name,
+    await AuditLog.recordSafely({
+      action: 'auth.nft_verification',
+      actorType: 'user',
+      actorId: payload.worldId as string,
+      details: {
+        walletAddress: validatedData.walletAddress,
+        granted: hasAccess,
+        collection: accessGrantedBy?.name ?? null,
+      },
+      ipAddress: ClientIp.of(request),
+    })
+
+    // Failed lookups may just be an RPC outage, so only a pass is persisted
+    if (hasAccess && payload.profileId) {
+      await Verification.setAutomated(payload.profileId as string, 'nft', true)
+      // Feeds shared-collection hints (see lib/social-graph)
+      await EventBus.publish('user.collection_verified', {
+        userId: payload.profileId as string,
+        contractAddress: accessGrantedBy!.contractAddress,
+        name: accessGrantedBy!.name,
       })
-        .setProtectedHeader({ alg: 'HS256' })
-        .setIssuedAt()
-        .setExpirationTime('24h')
-        .sign(secret)
+    }
+
+    // An admin override (e.g. granted during an RPC outage) wins either way
+    const status = payload.profileId
+      ? await Verification.getStatus(payload.profileId as string)
+      : null
+    const verified = status?.overrides.nft ?? hasAccess
+
+    if (verified) {
+      // Update session with NFT verification
+      const updatedToken = await JwtKeys.reissue(
+        payload,
+        {
+          nftVerified: true,
+          nftVerifiedAt: new Date().toISOString(),
+          eligibleNFT: accessGrantedBy?.name,
+        },
+        24 * 60 * 60
+      )
 
       const responseObj = NextResponse.json({
         success: true,
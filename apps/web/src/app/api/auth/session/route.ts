@@ -1,7 +1,7 @@
 import { NextRequest, NextResponse } from 'next/server'
-import { jwtVerify } from 'jose'
-
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!)
+import { JwtKeys } from '@/lib/jwt-keys'
+import { Verification } from '@/lib/verification'
+import { Badges } from '@/lib/badges'
 
 export async function GET(request: NextRequest) {
   try {
@@ -15,7 +15,15 @@ export async function GET(request: NextRequest) {
     }
 
     // Verify the session token
-    const { payload } = await jwtVerify(sessionCookie.value, secret)
+    const { payload } = await JwtKeys.verify(sessionCookie.value)
+
+    // Stored badges (including admin overrides) win over the token's claim
+    const verification = payload.profileId
+      ? await Verification.getStatus(payload.profileId as string)
+      : null
+    const badges = payload.profileId
+      ? await Badges.forUsers([payload.profileId as string])
+      : null
     
     const sessionData = {
       worldId: payload.worldId,
@@ -23,7 +31,9 @@ export async function GET(request: NextRequest) {
       verifiedAt: payload.verifiedAt,
       walletAddress: payload.walletAddress || null,
       walletConnectedAt: payload.walletConnectedAt || null,
-      nftVerified: payload.nftVerified || false,
+      nftVerified: verification?.nft ?? (payload.nftVerified || false),
+      photoVerified: verification?.photo ?? false,
+      badges: badges?.get(payload.profileId as string) ?? [],
       profileCompleted: payload.profileCompleted || false
     }
 
@@ -1,8 +1,12 @@
 import { NextRequest, NextResponse } from 'next/server'
-import { jwtVerify } from 'jose'
+import { JwtKeys } from '@/lib/jwt-keys'
 import { z } from 'zod'
-
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!)
+import { AuditLog } from '@/lib/audit-log'
+import { ClientIp } from '@/lib/client-ip'
+import {
+  DuplicateAccounts,
+  DEVICE_FINGERPRINT_HEADER,
+} from '@/lib/duplicate-accounts'
 
 const walletConnectionSchema = z.object({
   address: z.string().regex(/^0x[a-fA-F0-9]{40}$/, 'Invalid Ethereum address'),
@@ -21,7 +25,7 @@ export async function POST(request: NextRequest) {
     }
 
     // Verify the session token
-    const { payload } = await jwtVerify(sessionCookie.value, secret)
+    const { payload } = await JwtKeys.verify(sessionCookie.value)
     if (!payload.worldId) {
       return NextResponse.json(
         { success: false, message: 'Invalid World ID session' },
@@ -29,6 +33,19 @@ export async function POST(request: NextRequest) {
       )
     }
 
+    // Impersonation sessions are short-lived and admin-minted; they're
+    // never reissued
+    if (payload.impersonation) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This action is disabled while impersonating',
+          error_type: 'impersonation_restricted',
+        },
+        { status: 403 }
+      )
+    }
+
     const body = await request.json()
     const validatedData = walletConnectionSchema.parse(body)
 
@@ -38,20 +55,40 @@ export async function POST(request: NextRequest) {
       hasSignature: !!validatedData.signature
     })
 
+    await AuditLog.recordSafely({
+      action: 'auth.wallet_connected',
+      actorType: 'user',
+      actorId: payload.worldId as string,
+      details: { walletAddress: validatedData.address },
+      ipAddress: ClientIp.of(request),
+    })
+
+    if (payload.profileId) {
+      const profileId = payload.profileId as string
+      await DuplicateAccounts.recordSignal(
+        profileId,
+        'wallet',
+        validatedData.address
+      )
+      await DuplicateAccounts.recordSignal(
+        profileId,
+        'device',
+        request.headers.get(DEVICE_FINGERPRINT_HEADER)
+      )
+    }
+
     // TODO: Store wallet connection in database
     // For now, we'll just update the session token
 
     // Create updated session token with wallet info
-    const { SignJWT } = await import('jose')
-    const updatedToken = await new SignJWT({
-      ...payload,
-      walletAddress: validatedData.address,
-      walletConnectedAt: new Date().toISOString()
-    })
-      .setProtectedHeader({ alg: 'HS256' })
-      .setIssuedAt()
-      .setExpirationTime('24h')
-      .sign(secret)
+    const updatedToken = await JwtKeys.reissue(
+      payload,
+      {
+        walletAddress: validatedData.address,
+        walletConnectedAt: new Date().toISOString()
+      },
+      24 * 60 * 60
+    )
 
     const responseObj = NextResponse.json({
       success: true,
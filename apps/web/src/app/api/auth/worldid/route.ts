@@ -1,8 +1,14 @@
 import { NextRequest, NextResponse } from 'next/server'
 import { worldIdProofSchema } from '@/lib/validations'
-import { SignJWT } from 'jose'
-
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!)
+import { JwtKeys } from '@/lib/jwt-keys'
+import { AuditLog } from '@/lib/audit-log'
+import { ClientIp } from '@/lib/client-ip'
+import prisma from '@/lib/prisma'
+import { upstreamFetch } from '@/lib/http-client'
+import {
+  DuplicateAccounts,
+  DEVICE_FINGERPRINT_HEADER,
+} from '@/lib/duplicate-accounts'
 
 export async function POST(request: NextRequest) {
   try {
@@ -26,7 +32,7 @@ export async function POST(request: NextRequest) {
       action: 'verify-human'
     }
 
-    const response = await fetch(`https://developer.worldcoin.org/api/v1/verify/${process.env.NEXT_PUBLIC_WORLDCOIN_APP_ID}`, {
+    const response = await upstreamFetch('worldcoin', `https://developer.worldcoin.org/api/v1/verify/${process.env.NEXT_PUBLIC_WORLDCOIN_APP_ID}`, {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
@@ -43,6 +49,13 @@ export async function POST(request: NextRequest) {
     })
 
     if (!response.ok || !verificationResult.success) {
+      await AuditLog.recordSafely({
+        action: 'auth.worldid_failed',
+        actorType: 'user',
+        actorId: validatedData.nullifier_hash,
+        details: { code: verificationResult.code || null },
+        ipAddress: ClientIp.of(request),
+      })
       return NextResponse.json(
         { 
           success: false, 
@@ -53,17 +66,37 @@ export async function POST(request: NextRequest) {
       )
     }
 
-    // Create a session token for the verified user
-    const sessionToken = await new SignJWT({ 
-      worldId: validatedData.nullifier_hash,
-      verificationLevel: validatedData.verification_level,
-      verifiedAt: new Date().toISOString(),
-      action: 'verify-human'
+    await AuditLog.recordSafely({
+      action: 'auth.worldid_verified',
+      actorType: 'user',
+      actorId: validatedData.nullifier_hash,
+      details: { verificationLevel: validatedData.verification_level },
+      ipAddress: ClientIp.of(request),
     })
-      .setProtectedHeader({ alg: 'HS256' })
-      .setIssuedAt()
-      .setExpirationTime('24h')
-      .sign(secret)
+
+    // Returning users: note the device they signed in from
+    const existingUser = await prisma.user.findUnique({
+      where: { worldId: validatedData.nullifier_hash },
+      select: { id: true },
+    })
+    if (existingUser) {
+      await DuplicateAccounts.recordSignal(
+        existingUser.id,
+        'device',
+        request.headers.get(DEVICE_FINGERPRINT_HEADER)
+      )
+    }
+
+    // Create a session token for the verified user
+    const sessionToken = await JwtKeys.sign(
+      {
+        worldId: validatedData.nullifier_hash,
+        verificationLevel: validatedData.verification_level,
+        verifiedAt: new Date().toISOString(),
+        action: 'verify-human'
+      },
+      '24h'
+    )
 
     // Set secure cookie
     const responseObj = NextResponse.json({ 
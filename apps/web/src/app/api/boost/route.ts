@@ -0,0 +1,90 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Boosts } from '@/lib/boosts';
+
+/**
+ * Current boost status and remaining boost credits
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const status = await Boosts.getStatus(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: status,
+    });
+  } catch (error) {
+    console.error('💥 Fetch boost status error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch boost status',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Spend a boost credit to raise the user's discovery exposure
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const activation = await Boosts.activate(session.profileId!);
+
+    if (activation.status === 'already_active') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'A boost is already active',
+          error_type: 'boost_active',
+          data: { expiresAt: activation.expiresAt },
+        },
+        { status: 409 }
+      );
+    }
+
+    if (activation.status === 'no_credits') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'No boosts available',
+          error_type: 'insufficient_credits',
+        },
+        { status: 402 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Boost activated',
+      data: {
+        source: activation.source,
+        expiresAt: activation.expiresAt,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Boost activation error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to activate boost',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,48 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { ChatBridge, verifyLineWebhook } from '@/lib/chat-bridge';
+
+/**
+ * LINE Messaging API events. The raw body is needed for signature checks.
+ */
+export async function POST(request: NextRequest) {
+  const payload = await request.text();
+
+  if (!verifyLineWebhook(payload, request.headers.get('x-line-signature'))) {
+    console.warn('⚠️ Rejected LINE webhook');
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Invalid webhook signature',
+        error_type: 'invalid_signature',
+      },
+      { status: 401 }
+    );
+  }
+
+  try {
+    const { events = [] } = JSON.parse(payload);
+    for (const event of events) {
+      const lineUserId = event.source?.userId;
+      if (!lineUserId) {
+        continue;
+      }
+      if (event.type === 'message' && event.message?.type === 'text') {
+        await ChatBridge.handleIncoming('line', lineUserId, event.message.text);
+      } else if (event.type === 'unfollow') {
+        await ChatBridge.disable('line', lineUserId);
+      }
+    }
+
+    return NextResponse.json({ success: true });
+  } catch (error) {
+    console.error('💥 LINE webhook error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to process webhook',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,55 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { ChatBridge, verifyTelegramWebhook } from '@/lib/chat-bridge';
+
+/**
+ * Telegram bot updates. Only private text messages matter: they may carry
+ * a link code.
+ */
+export async function POST(request: NextRequest) {
+  if (
+    !verifyTelegramWebhook(
+      request.headers.get('x-telegram-bot-api-secret-token')
+    )
+  ) {
+    console.warn('⚠️ Rejected Telegram webhook');
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Invalid webhook secret',
+        error_type: 'invalid_signature',
+      },
+      { status: 401 }
+    );
+  }
+
+  try {
+    const update = await request.json();
+    const message = update.message;
+    if (message?.chat?.type === 'private' && typeof message.text === 'string') {
+      await ChatBridge.handleIncoming(
+        'telegram',
+        String(message.chat.id),
+        message.text
+      );
+    } else if (update.my_chat_member?.new_chat_member?.status === 'kicked') {
+      // The user blocked the bot
+      await ChatBridge.disable(
+        'telegram',
+        String(update.my_chat_member.chat.id)
+      );
+    }
+
+    return NextResponse.json({ success: true });
+  } catch (error) {
+    // A 5xx makes Telegram retry the update
+    console.error('💥 Telegram webhook error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to process webhook',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,32 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Calls } from '@/lib/calls';
+
+/**
+ * STUN/TURN servers for RTCPeerConnection, with short-lived TURN
+ * credentials. Fetch before each call.
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    return NextResponse.json({
+      success: true,
+      data: Calls.iceServers(session.profileId!),
+    });
+  } catch (error) {
+    console.error('💥 Fetch ICE servers error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch ICE servers',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
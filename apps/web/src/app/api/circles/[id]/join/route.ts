@@ -0,0 +1,109 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { Circles } from '@/lib/circles';
+
+/**
+ * Join an open circle, or ask the owner of an approval circle to let the
+ * signed-in user in
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const result = await Circles.join(id, session.profileId!);
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Circle not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message:
+        result.status === 'active'
+          ? "You're in"
+          : 'Request sent. The owner will review it.',
+      data: await Circles.get(id, session.profileId!),
+    });
+  } catch (error) {
+    console.error('💥 Join circle error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to join circle',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Leave a circle, or take back a request to join. Owners can't leave.
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const outcome = await Circles.leave(id, session.profileId!);
+    if (outcome === 'not_member') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'You are not in this circle',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (outcome === 'owner') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Owners cannot leave their circle',
+          error_type: 'circle_owner',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Left circle',
+    });
+  } catch (error) {
+    console.error('💥 Leave circle error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to leave circle',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
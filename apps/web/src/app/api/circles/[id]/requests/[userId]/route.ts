@@ -0,0 +1,97 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { Circles } from '@/lib/circles';
+
+const decisionSchema = z.object({
+  approve: z.boolean(),
+});
+
+/**
+ * The owner approves or declines a request to join their circle
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string; userId: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id, userId } = await params;
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const { approve } = decisionSchema.parse(body);
+
+    const result = await Circles.decide(
+      id,
+      session.profileId!,
+      userId,
+      approve
+    );
+    switch (result.status) {
+      case 'not_allowed':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Only the circle owner can decide on requests',
+            error_type: 'not_owner',
+          },
+          { status: 403 }
+        );
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Request not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+    }
+
+    await AuditLog.recordSafely({
+      action: `circle.request_${result.status}`,
+      actorType: 'user',
+      actorId: session.profileId!,
+      targetType: 'circle',
+      targetId: id,
+      details: { userId },
+      ipAddress: ClientIp.of(request),
+    });
+
+    return NextResponse.json({
+      success: true,
+      message:
+        result.status === 'approved' ? 'Request approved' : 'Request declined',
+    });
+  } catch (error) {
+    console.error('💥 Decide circle request error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid decision',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to decide on request',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
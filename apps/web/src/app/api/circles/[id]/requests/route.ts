@@ -0,0 +1,45 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Circles } from '@/lib/circles';
+
+/**
+ * Pending requests to join, for the circle's owner
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const requests = await Circles.requests(id, session.profileId!);
+    if (!requests) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Only the circle owner can see requests',
+          error_type: 'not_owner',
+        },
+        { status: 403 }
+      );
+    }
+
+    return NextResponse.json({ success: true, data: { requests } });
+  } catch (error) {
+    console.error('💥 Fetch circle requests error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch requests',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,45 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Circles } from '@/lib/circles';
+
+/**
+ * One circle, by ID or slug, with the signed-in user's membership
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const circle = await Circles.get(id, session.profileId!);
+    if (!circle) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Circle not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({ success: true, data: circle });
+  } catch (error) {
+    console.error('💥 Fetch circle error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch circle',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,167 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { CIRCLE_JOIN_POLICIES, CIRCLE_KINDS, Circles } from '@/lib/circles';
+
+// Directory filters (campus IDs from /api/meta/locations), or just the
+// circles the user is in
+const querySchema = z.object({
+  kind: z.enum(CIRCLE_KINDS).optional(),
+  campus: z.string().optional(),
+  q: z.string().trim().min(1).max(100).optional(),
+  mine: z
+    .enum(['true', 'false'])
+    .transform(value => value === 'true')
+    .optional(),
+});
+
+const circleSchema = z.object({
+  slug: z
+    .string()
+    .regex(/^[a-z0-9]+(?:-[a-z0-9]+)*$/)
+    .min(3)
+    .max(40),
+  name: z.string().trim().min(1).max(80),
+  description: z.string().trim().max(500).optional(),
+  kind: z.enum(CIRCLE_KINDS),
+  campusId: z.string().optional(),
+  joinPolicy: z.enum(CIRCLE_JOIN_POLICIES).default('open'),
+});
+
+/**
+ * Circles to browse, biggest first, with the user's membership in each
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const query = querySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const circles = query.mine
+      ? await Circles.mine(session.profileId!)
+      : await Circles.list(session.profileId!, {
+          kind: query.kind,
+          campusId: query.campus,
+          search: query.q,
+        });
+
+    return NextResponse.json({ success: true, data: circles });
+  } catch (error) {
+    console.error('💥 Fetch circles error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch circles',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Start a circle; the signed-in user becomes its owner
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = circleSchema.parse(body);
+
+    const result = await Circles.create(session.profileId!, validatedData);
+    switch (result.status) {
+      case 'invalid_campus':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Unknown campus',
+            error_type: 'invalid_place',
+          },
+          { status: 400 }
+        );
+      case 'already_exists':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'That circle name is taken',
+            error_type: 'slug_taken',
+          },
+          { status: 409 }
+        );
+      case 'limit_reached':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'You own too many circles already',
+            error_type: 'limit_reached',
+          },
+          { status: 409 }
+        );
+    }
+
+    await AuditLog.recordSafely({
+      action: 'circle.created',
+      actorType: 'user',
+      actorId: session.profileId!,
+      targetType: 'circle',
+      targetId: result.circle.id,
+      details: { slug: result.circle.slug, kind: result.circle.kind },
+      ipAddress: ClientIp.of(request),
+    });
+
+    return NextResponse.json({
+      success: true,
+      message: 'Circle created',
+      data: await Circles.get(result.circle.id, session.profileId!),
+    });
+  } catch (error) {
+    console.error('💥 Create circle error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid circle',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to create circle',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -1,84 +1,160 @@
 import { NextRequest, NextResponse } from 'next/server'
-import { jwtVerify } from 'jose'
 import { z } from 'zod'
 import prisma from '@/lib/prisma'
-
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!)
+import { Entitlements } from '@/lib/entitlements'
+import { Inventory } from '@/lib/inventory'
+import { Shadowbans } from '@/lib/shadowbans'
+import { AbuseDetection } from '@/lib/abuse-detection'
+import { Notifications } from '@/lib/notifications'
+import { Locations } from '@/lib/locations'
+import { Swipes, SWIPE_ACTIONS } from '@/lib/swipes'
+import { discoveryQuotas } from '@/lib/client-config'
+import { Tenants } from '@/lib/tenants'
+import { getSession } from '@/middleware/auth'
+import { authorize } from '@/middleware/policy'
 
 const swipeActionSchema = z.object({
   profileId: z.string().min(1, 'Profile ID is required'),
-  action: z.enum(['like', 'pass', 'super_like'], {
+  action: z.enum(SWIPE_ACTIONS, {
     errorMap: () => ({ message: 'Action must be like, pass, or super_like' })
   })
 })
 
 export async function POST(request: NextRequest) {
   try {
-    // Verify session
-    const sessionCookie = request.cookies.get('worldid-session')
-    if (!sessionCookie) {
-      return NextResponse.json(
-        { success: false, message: 'Session required' },
-        { status: 401 }
-      )
-    }
-
-    const { payload } = await jwtVerify(sessionCookie.value, secret)
-    if (!payload.profileCompleted) {
-      return NextResponse.json(
-        { success: false, message: 'Profile setup required' },
-        { status: 400 }
-      )
+    // Verify the session, account status and route policy
+    const authResponse = await authorize(request)
+    if (authResponse) {
+      return authResponse
     }
+    const session = (await getSession(request))!
+    const userId = session.profileId!
 
     const body = await request.json()
     const validatedData = swipeActionSchema.parse(body)
 
     console.log('🎯 Recording swipe action:', {
-      userId: (payload.worldId as string).substring(0, 10) + '...',
+      userId: session.worldId.substring(0, 10) + '...',
       profileId: validatedData.profileId,
       action: validatedData.action
     })
 
-    // Store swipe action in the database
-    const swipe = await prisma.signal.create({
-      data: {
-        fromUserId: payload.profileId as string,
-        toUserId: validatedData.profileId,
-        type: validatedData.action,
-      },
-    });
+    // Campus instances are closed: no swiping across tenants
+    const sameTenant = await Tenants.sameTenant(userId, validatedData.profileId)
+    if (!sameTenant) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Profile not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      )
+    }
 
-    let isMatch = false;
-    // Check for a mutual match if the action is 'like' or 'super_like'
-    if (validatedData.action === 'like' || validatedData.action === 'super_like') {
-      const mutualLike = await prisma.signal.findFirst({
-        where: {
-          fromUserId: validatedData.profileId,
-          toUserId: payload.profileId as string,
-          type: {
-            in: ['like', 'super_like'],
+    if (validatedData.action !== 'pass') {
+      const verdict = await AbuseDetection.recordActivity(userId, 'signal')
+      if (!verdict.allowed) {
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Too many signals, please slow down',
+            error_type: 'rate_clamped',
           },
-        },
-      });
+          { status: 429 }
+        )
+      }
+    }
+
+    // Super-likes beyond the daily allowance spend a purchased super-interest
+    let spentSuperInterest = false
+    if (validatedData.action === 'super_like') {
+      const startOfDay = new Date()
+      startOfDay.setUTCHours(0, 0, 0, 0)
 
-      if (mutualLike) {
-        isMatch = true;
-        // Create a match record
-        await prisma.match.create({
-          data: {
-            user1Id: payload.profileId as string,
-            user2Id: validatedData.profileId,
+      const [sentToday, extra, quotas] = await Promise.all([
+        prisma.signal.count({
+          where: {
+            fromUserId: userId,
+            type: 'super_like',
+            sentAt: { gte: startOfDay },
           },
-        });
+        }),
+        Entitlements.getLimit(userId, 'extra_super_interests'),
+        Tenants.ofUser(userId).then(discoveryQuotas),
+      ])
+
+      if (sentToday >= quotas.freeDailySuperInterests + extra) {
+        spentSuperInterest = await Inventory.consume(userId, 'super_interest')
+        if (!spentSuperInterest) {
+          return NextResponse.json(
+            {
+              success: false,
+              message: 'Daily super-like limit reached',
+              error_type: 'entitlement_required',
+              feature: 'extra_super_interests',
+            },
+            { status: 403 }
+          )
+        }
       }
     }
 
+    // Shadowbanned users' swipes are stored but never reach anyone
+    const suppressed = await Shadowbans.isShadowbanned(userId)
+
+    // Store the swipe, and the match if it's mutual, in one transaction
+    let swipe
+    try {
+      swipe = await Swipes.record({
+        fromUserId: userId,
+        toUserId: validatedData.profileId,
+        action: validatedData.action,
+        suppressed,
+      })
+    } catch (error) {
+      if (spentSuperInterest) {
+        await Inventory.refund(userId, 'super_interest')
+      }
+      throw error
+    }
+
+    const { match } = swipe;
+    let distance: string | null = null;
+    if (match) {
+      await Promise.all(
+        [match.user1Id, match.user2Id].map(userId =>
+          Notifications.notify(userId, {
+            type: 'match',
+            path: `/matches/${match.id}`,
+            data: { matchId: match.id },
+            push: true,
+          })
+        )
+      );
+      const distances = await Locations.distancesFrom(userId, [
+        validatedData.profileId,
+      ]);
+      distance = distances.get(validatedData.profileId) ?? null;
+    } else if (!suppressed && validatedData.action !== 'pass') {
+      // Likes stay anonymous until they're mutual
+      await Notifications.notify(validatedData.profileId, {
+        type: 'new_like',
+        template:
+          validatedData.action === 'super_like'
+            ? 'new_super_like'
+            : 'new_like',
+        path: '/discover',
+        push: true,
+      });
+    }
+
     return NextResponse.json({
       success: true,
       message: 'Swipe action recorded',
       data: {
-        isMatch,
+        isMatch: match !== null,
+        distance,
       },
     });
 
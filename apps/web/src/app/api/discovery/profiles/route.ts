@@ -1,44 +1,143 @@
 import { NextRequest, NextResponse } from 'next/server'
-import { jwtVerify } from 'jose'
-import prisma from '@/lib/prisma'
+import { z } from 'zod'
+import {
+  rankDiscoveryProfiles,
+  toPublicProfile,
+} from '@/lib/discovery-ranking'
+import { Locations } from '@/lib/locations'
+import { Presence } from '@/lib/presence'
+import { discoveryQuotas } from '@/lib/client-config'
+import { Tenants } from '@/lib/tenants'
+import { CompatibilityQuiz } from '@/lib/compatibility-quiz'
+import { ProfilePrompts } from '@/lib/profile-prompts'
+import { VoiceIntros } from '@/lib/voice-intros'
+import { PhotoReveal } from '@/lib/photo-reveal'
+import { Badges } from '@/lib/badges'
+import { Events } from '@/lib/events'
+import { Circles } from '@/lib/circles'
+import { SocialGraph } from '@/lib/social-graph'
+import { getSession } from '@/middleware/auth'
+import { authorize } from '@/middleware/policy'
 
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!)
+// Optional directory filters (IDs from /api/meta/locations), an event's
+// attendees while it's on, or the members of one of the user's circles
+const querySchema = z.object({
+  city: z.string().optional(),
+  campus: z.string().optional(),
+  event: z.string().optional(),
+  circle: z.string().optional(),
+})
 
 export async function GET(request: NextRequest) {
   try {
-    // Verify session
-    const sessionCookie = request.cookies.get('worldid-session')
-    if (!sessionCookie) {
-      return NextResponse.json(
-        { success: false, message: 'Session required' },
-        { status: 401 }
-      )
+    // Verify the session, account status and route policy
+    const authResponse = await authorize(request)
+    if (authResponse) {
+      return authResponse
     }
+    const session = (await getSession(request))!
+    const userId = session.profileId!
 
-    const { payload } = await jwtVerify(sessionCookie.value, secret)
-    if (!payload.profileCompleted) {
-      return NextResponse.json(
-        { success: false, message: 'Profile setup required' },
-        { status: 400 }
-      )
+    const query = querySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    )
+
+    let deckIds: string[] | undefined
+    if (query.event) {
+      const ids = await Events.attendeeIds(query.event, userId)
+      if (!ids) {
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Event decks are for attendees while the event is on',
+            error_type: 'not_attending',
+          },
+          { status: 403 }
+        )
+      }
+      deckIds = ids
+    } else if (query.circle) {
+      const ids = await Circles.memberIds(query.circle, userId)
+      if (!ids) {
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Circle decks are for members of the circle',
+            error_type: 'not_a_member',
+          },
+          { status: 403 }
+        )
+      }
+      deckIds = ids
     }
 
-    // Fetch profiles from the database
-    const users = await prisma.user.findMany({
-      where: {
-        id: {
-          not: payload.profileId as string,
-        },
-      },
-      take: 10, // Limit to 10 profiles for now
-    })
+    // Fetch profiles, ML-ranked when the ML API is healthy
+    const { deckSize } = await discoveryQuotas(await Tenants.ofUser(userId))
+    const { users, ranking, scores } = await rankDiscoveryProfiles(
+      userId,
+      deckSize,
+      { cityId: query.city, campusId: query.campus, userIds: deckIds }
+    )
+
+    // Distance and presence only where neither side hides them
+    const userIds = users.map(user => user.id)
+    const [
+      distances,
+      presence,
+      compatibility,
+      prompts,
+      voiceIntros,
+      reveals,
+      badges,
+      circles,
+      sharedContext,
+    ] = await Promise.all([
+      Locations.distancesFrom(userId, userIds),
+      Presence.lookup(userId, userIds),
+      CompatibilityQuiz.scores(userId, userIds, scores),
+      ProfilePrompts.visibleFor(userIds),
+      VoiceIntros.readyFor(userId, userIds),
+      PhotoReveal.statesFor(userId, users),
+      Badges.forUsers(userIds),
+      Circles.forUsers(userIds),
+      SocialGraph.hintsFor(userId, userIds),
+    ])
 
     return NextResponse.json({
       success: true,
-      data: users,
+      data: users.map(user => ({
+        ...toPublicProfile(user),
+        profileImage: PhotoReveal.photoUrl(
+          user.id,
+          user.profileImage,
+          reveals.get(user.id)
+        ),
+        photoReveal: reveals.get(user.id) ?? null,
+        prompts: prompts.get(user.id) ?? [],
+        voiceIntro: voiceIntros.get(user.id) ?? null,
+        badges: badges.get(user.id) ?? [],
+        circles: circles.get(user.id) ?? [],
+        sharedContext: sharedContext.get(user.id) ?? [],
+        distance: distances.get(user.id) ?? null,
+        presence: presence.get(user.id) ?? null,
+        compatibility: compatibility.get(user.id) ?? null,
+      })),
+      ranking,
     })
   } catch (error) {
     console.error('💥 Fetch profiles error:', error)
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      )
+    }
+
     return NextResponse.json(
       {
         success: false,
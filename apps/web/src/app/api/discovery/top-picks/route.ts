@@ -0,0 +1,62 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { toPublicProfile } from '@/lib/discovery-ranking';
+import { TopPicks } from '@/lib/top-picks';
+import { PhotoReveal } from '@/lib/photo-reveal';
+import { Badges } from '@/lib/badges';
+import { Circles } from '@/lib/circles';
+
+/**
+ * Today's Top Picks for the signed-in user, as far as their allowance
+ * goes
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const viewerId = session.profileId!;
+    const picks = await TopPicks.forViewer(viewerId);
+
+    const userIds = picks.users.map(user => user.id);
+    const [reveals, badges, circles] = await Promise.all([
+      PhotoReveal.statesFor(viewerId, picks.users),
+      Badges.forUsers(userIds),
+      Circles.forUsers(userIds),
+    ]);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        picks: picks.users.map(user => ({
+          ...toPublicProfile(user),
+          profileImage: PhotoReveal.photoUrl(
+            user.id,
+            user.profileImage,
+            reveals.get(user.id)
+          ),
+          photoReveal: reveals.get(user.id) ?? null,
+          badges: badges.get(user.id) ?? [],
+          circles: circles.get(user.id) ?? [],
+        })),
+        ranking: picks.ranking,
+        locked: picks.locked,
+        refreshesAt: picks.refreshesAt,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch top picks error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch top picks',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,78 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { Events } from '@/lib/events';
+
+/**
+ * Check in at an event the signed-in user RSVP'd to, while it's on. Their
+ * saved location must be around the venue.
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const result = await Events.checkIn(id, session.profileId!);
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Event not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'not_going':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'RSVP to this event first',
+            error_type: 'not_going',
+          },
+          { status: 403 }
+        );
+      case 'not_live':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Check-in is only open while the event is on',
+            error_type: 'event_not_live',
+          },
+          { status: 409 }
+        );
+      case 'not_nearby':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Update your location at the venue to check in',
+            error_type: 'not_nearby',
+          },
+          { status: 403 }
+        );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Checked in',
+    });
+  } catch (error) {
+    console.error('💥 Event check-in error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to check in',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
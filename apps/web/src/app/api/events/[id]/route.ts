@@ -0,0 +1,45 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Events } from '@/lib/events';
+
+/**
+ * One event, with whether the signed-in user is going
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const event = await Events.get(id, session.profileId!);
+    if (!event) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Event not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({ success: true, data: event });
+  } catch (error) {
+    console.error('💥 Fetch event error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch event',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,114 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { Events } from '@/lib/events';
+
+/**
+ * RSVP to an event, while there's room
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const result = await Events.rsvp(id, session.profileId!);
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Event not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'ended':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'This event has ended',
+            error_type: 'event_ended',
+          },
+          { status: 409 }
+        );
+      case 'full':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'This event is full',
+            error_type: 'event_full',
+          },
+          { status: 409 }
+        );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: "You're going",
+      data: await Events.get(id, session.profileId!),
+    });
+  } catch (error) {
+    console.error('💥 Event RSVP error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to RSVP',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Give up the signed-in user's RSVP and free the spot
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const removed = await Events.cancelRsvp(id, session.profileId!);
+    if (!removed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'No RSVP to cancel',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'RSVP canceled',
+    });
+  } catch (error) {
+    console.error('💥 Cancel event RSVP error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to cancel RSVP',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
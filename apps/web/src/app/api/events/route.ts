@@ -0,0 +1,62 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Events } from '@/lib/events';
+
+// Directory filters (IDs from /api/meta/locations), or events near the
+// user's saved location
+const querySchema = z.object({
+  city: z.string().optional(),
+  campus: z.string().optional(),
+  nearby: z
+    .enum(['true', 'false'])
+    .transform(value => value === 'true')
+    .optional(),
+});
+
+/**
+ * Upcoming and ongoing events, soonest first
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const query = querySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const events = await Events.list(session.profileId!, {
+      cityId: query.city,
+      campusId: query.campus,
+      nearby: query.nearby,
+    });
+
+    return NextResponse.json({ success: true, data: events });
+  } catch (error) {
+    console.error('💥 Fetch events error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch events',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,82 @@
+import { NextResponse } from 'next/server';
+import prisma from '@/lib/prisma';
+import redis from '@/lib/redis';
+import { MLHealthMonitor } from '@/lib/ml-health';
+
+// A dependency slower than this to answer counts as down
+const CHECK_TIMEOUT_MS = parseInt(
+  process.env.HEALTH_CHECK_TIMEOUT_MS || '2000'
+);
+
+interface CheckResult {
+  healthy: boolean;
+  latencyMs: number;
+}
+
+async function check(fn: () => Promise<unknown>): Promise<CheckResult> {
+  const start = Date.now();
+  let timer: NodeJS.Timeout | undefined;
+  try {
+    await Promise.race([
+      fn(),
+      new Promise((_, reject) => {
+        timer = setTimeout(
+          () => reject(new Error(`Timed out after ${CHECK_TIMEOUT_MS}ms`)),
+          CHECK_TIMEOUT_MS
+        );
+      }),
+    ]);
+    return { healthy: true, latencyMs: Date.now() - start };
+  } catch (error) {
+    console.error('Readiness check failed:', error);
+    return { healthy: false, latencyMs: Date.now() - start };
+  } finally {
+    clearTimeout(timer);
+  }
+}
+
+const describe = (result: CheckResult) => ({
+  status: result.healthy ? 'healthy' : 'unhealthy',
+  latencyMs: result.latencyMs,
+});
+
+/**
+ * Readiness probe. Database and Redis are hard dependencies; an unhealthy ML
+ * API is reported but doesn't fail readiness since discovery degrades to
+ * non-ML ranking and scoring returns its own errors.
+ */
+export async function GET() {
+  const [database, cache, ml] = await Promise.all([
+    check(() => prisma.$queryRaw`SELECT 1`),
+    check(() => redis.ping()),
+    MLHealthMonitor.getHealth(),
+  ]);
+
+  const ready = database.healthy && cache.healthy;
+  let status = 'unhealthy';
+  if (ready) {
+    status = ml.status === 'healthy' ? 'healthy' : 'degraded';
+  }
+
+  return NextResponse.json(
+    {
+      success: ready,
+      message: ready
+        ? 'Aurum Circle API is ready'
+        : 'Aurum Circle API is not ready',
+      timestamp: new Date().toISOString(),
+      status,
+      services: {
+        database: describe(database),
+        redis: describe(cache),
+        ml_api: {
+          status: ml.status,
+          latencyMs: ml.latencyMs,
+          queue: ml.queue,
+          checkedAt: ml.checkedAt,
+        },
+      },
+    },
+    { status: ready ? 200 : 503 }
+  );
+}
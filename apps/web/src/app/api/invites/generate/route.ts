@@ -1,25 +1,23 @@
 import { NextRequest, NextResponse } from 'next/server'
-import { jwtVerify } from 'jose'
 import prisma from '@/lib/prisma'
-import { customAlphabet } from 'nanoid'
-
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!)
-const nanoid = customAlphabet('0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ', 6)
+import { Invites } from '@/lib/invites'
+import { getSession } from '@/middleware/auth'
+import { authorize } from '@/middleware/policy'
 
 export async function POST(request: NextRequest) {
   try {
-    // 1. Verify session
-    const sessionCookie = request.cookies.get('worldid-session')
-    if (!sessionCookie) {
-      return NextResponse.json({ success: false, message: 'Session required' }, { status: 401 })
+    // 1. Verify the session, account status and route policy
+    const authResponse = await authorize(request)
+    if (authResponse) {
+      return authResponse
     }
 
-    const { payload } = await jwtVerify(sessionCookie.value, secret)
-    if (!payload.profileCompleted || !payload.profileId) {
+    const session = (await getSession(request))!
+    if (!session.profileCompleted || !session.profileId) {
       return NextResponse.json({ success: false, message: 'Profile setup required' }, { status: 400 })
     }
 
-    const userId = payload.profileId as string
+    const userId = session.profileId
 
     // 2. Check if the user has reached their maximum number of active invites
     const userInvites = await prisma.invite.count({
@@ -38,23 +36,10 @@ export async function POST(request: NextRequest) {
       )
     }
 
-    // 3. Generate a new unique invite code
-    let newCode: string
-    let existingCode = true
-    do {
-      newCode = `AURUM-${nanoid()}`
-      existingCode = !!(await prisma.invite.findUnique({ where: { code: newCode } }))
-    } while (existingCode)
-
-    // 4. Save the invite code to the database
-    const invite = await prisma.invite.create({
-      data: {
-        code: newCode,
-        userId: userId,
-      },
-    })
+    // 3. Generate a new unique invite code and save it
+    const invite = await Invites.generate(userId)
 
-    // 5. Return the new invite code to the user
+    // 4. Return the new invite code to the user
     return NextResponse.json({
       success: true,
       message: 'Invite code generated successfully',
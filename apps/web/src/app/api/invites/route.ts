@@ -1,23 +1,22 @@
 import { NextRequest, NextResponse } from 'next/server'
-import { jwtVerify } from 'jose'
 import prisma from '@/lib/prisma'
-
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!)
+import { getSession } from '@/middleware/auth'
+import { authorize } from '@/middleware/policy'
 
 export async function GET(request: NextRequest) {
   try {
-    // 1. Verify session
-    const sessionCookie = request.cookies.get('worldid-session')
-    if (!sessionCookie) {
-      return NextResponse.json({ success: false, message: 'Session required' }, { status: 401 })
+    // 1. Verify the session, account status and route policy
+    const authResponse = await authorize(request)
+    if (authResponse) {
+      return authResponse
     }
 
-    const { payload } = await jwtVerify(sessionCookie.value, secret)
-    if (!payload.profileCompleted || !payload.profileId) {
+    const session = (await getSession(request))!
+    if (!session.profileCompleted || !session.profileId) {
       return NextResponse.json({ success: false, message: 'Profile setup required' }, { status: 400 })
     }
 
-    const userId = payload.profileId as string
+    const userId = session.profileId
 
     // 2. Fetch user's invites from the database
     const invites = await prisma.invite.findMany({
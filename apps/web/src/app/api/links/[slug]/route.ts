@@ -0,0 +1,55 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { DeepLinks } from '@/lib/deep-links';
+
+/**
+ * Where a short link points, for the client router. Public: the person
+ * opening a shared link usually isn't signed in.
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ slug: string }> }
+) {
+  try {
+    const { slug } = await params;
+    const result = await DeepLinks.resolve(slug);
+
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Link not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (result.status === 'target_unavailable') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This link is no longer available',
+          error_type: 'target_unavailable',
+        },
+        { status: 410 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: true,
+        data: { slug: result.slug, target: result.target, ...result.urls },
+      },
+      { headers: { 'Cache-Control': 'public, max-age=60' } }
+    );
+  } catch (error) {
+    console.error('💥 Resolve link error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to resolve link',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,79 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { DeepLinks, LINK_TARGET_TYPES } from '@/lib/deep-links';
+
+const linkSchema = z.object({
+  targetType: z.enum(LINK_TARGET_TYPES),
+  targetId: z.string().min(1),
+});
+
+/**
+ * Create (or fetch) the signed-in user's short link to a profile or invite
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = linkSchema.parse(body);
+
+    const result = await DeepLinks.create(
+      session.profileId!,
+      validatedData.targetType,
+      validatedData.targetId
+    );
+    if (result.status === 'target_not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Link target not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (result.status === 'not_allowed') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'You can only share your own invites',
+          error_type: 'not_allowed',
+        },
+        { status: 403 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Link created',
+      data: { slug: result.slug, ...result.urls },
+    });
+  } catch (error) {
+    console.error('💥 Create link error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid link data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to create link',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,84 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { REPORT_REASONS } from '@/lib/reports';
+import { ConversationSafety } from '@/lib/conversation-safety';
+
+const blockSchema = z.object({
+  // Report them at the same time
+  report: z
+    .object({
+      reason: z.enum(REPORT_REASONS),
+      details: z.string().max(1000).optional(),
+    })
+    .optional(),
+});
+
+/**
+ * Block the other person in a match and unmatch, optionally reporting them
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+    const body = await request.json().catch(() => ({}));
+    const validatedData = blockSchema.parse(body);
+
+    const result = await ConversationSafety.blockAndUnmatch(
+      id,
+      session.profileId!,
+      validatedData.report
+    );
+
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Match not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'blocked':
+        return NextResponse.json({
+          success: true,
+          message: result.report
+            ? 'Blocked, unmatched and reported'
+            : 'Blocked and unmatched',
+          data: { reportId: result.report?.id ?? null },
+        });
+    }
+  } catch (error) {
+    console.error('💥 Block match error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid block data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to block user',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,160 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { CALL_MEDIA, CALL_SIGNAL_TYPES, Calls } from '@/lib/calls';
+
+// Generous for an SDP blob, small enough to keep Redis mailboxes cheap
+const MAX_PAYLOAD_BYTES = 16 * 1024;
+
+const signalSchema = z
+  .object({
+    type: z.enum(CALL_SIGNAL_TYPES),
+    callId: z.string().uuid().optional(),
+    media: z.enum(CALL_MEDIA).optional(),
+    payload: z
+      .record(z.unknown())
+      .refine(
+        payload => JSON.stringify(payload).length <= MAX_PAYLOAD_BYTES,
+        'Payload is too large'
+      )
+      .optional(),
+  })
+  .refine(
+    signal => signal.type === 'offer' || signal.callId,
+    'callId is required except when starting a call'
+  );
+
+/**
+ * The match's current call and any signals waiting for the signed-in user.
+ * Clients poll this every second or so while a call is ringing or
+ * connecting.
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const userId = (await getSession(request))!.profileId!;
+
+    if (!(await Calls.otherParticipant(id, userId))) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Match not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const result = await Calls.collect(id, userId);
+    return NextResponse.json({ success: true, data: result });
+  } catch (error) {
+    console.error('💥 Fetch call signals error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch call signals',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Send a call signal (offer, answer, ICE candidate, hangup or decline) to
+ * the other side of the match
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = signalSchema.parse(body);
+
+    const userId = session.profileId!;
+    const otherUserId = await Calls.otherParticipant(id, userId);
+    if (!otherUserId) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Match not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const result = await Calls.signal(id, userId, otherUserId, validatedData);
+    switch (result.status) {
+      case 'busy':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'There is already a call in progress',
+            error_type: 'call_in_progress',
+          },
+          { status: 409 }
+        );
+      case 'no_call':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'This call has ended',
+            error_type: 'call_not_found',
+          },
+          { status: 404 }
+        );
+      case 'not_allowed':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Only the person being called can answer',
+            error_type: 'not_allowed',
+          },
+          { status: 403 }
+        );
+    }
+
+    return NextResponse.json({ success: true, data: { call: result.call } });
+  } catch (error) {
+    console.error('💥 Send call signal error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid call signal',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to send call signal',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,223 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { ContactExchange } from '@/lib/contact-exchange';
+
+const consentSchema = z
+  .object({
+    telegram: z
+      .string()
+      .trim()
+      .regex(/^@?[A-Za-z0-9_]{5,32}$/, 'Invalid Telegram username')
+      .transform(handle => handle.replace(/^@/, ''))
+      .optional(),
+    phone: z
+      .string()
+      .trim()
+      .regex(/^\+[1-9]\d{6,14}$/, 'Phone number must be in +E.164 format')
+      .optional(),
+  })
+  .refine(details => details.telegram || details.phone, {
+    message: 'Share a Telegram username or a phone number',
+  });
+
+/**
+ * Where the contact exchange with a match stands, with their details once
+ * both sides have consented
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const status = await ContactExchange.status(id, session.profileId!);
+    if (!status) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Match not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json(
+      { success: true, data: status },
+      { headers: { 'Cache-Control': 'no-store' } }
+    );
+  } catch (error) {
+    console.error('💥 Fetch contact exchange error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch contact exchange',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Consent to swapping contacts with a match, with the details to share.
+ * They're only revealed once the match consents too.
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = consentSchema.parse(body);
+
+    const result = await ContactExchange.consent(
+      id,
+      session.profileId!,
+      validatedData
+    );
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Match not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'unavailable':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Contact exchange is not available right now',
+            error_type: 'contact_exchange_unavailable',
+          },
+          { status: 503 }
+        );
+      case 'already_released':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Contacts were already exchanged',
+            error_type: 'already_released',
+          },
+          { status: 409 }
+        );
+    }
+
+    await AuditLog.recordSafely({
+      action: 'user.contact_exchange_consented',
+      actorType: 'user',
+      actorId: session.profileId!,
+      targetType: 'match',
+      targetId: id,
+      details: { released: result.released },
+      ipAddress: ClientIp.of(request),
+    });
+
+    return NextResponse.json({
+      success: true,
+      message: result.released
+        ? 'Contacts exchanged'
+        : 'Waiting for your match to agree',
+      data: await ContactExchange.status(id, session.profileId!),
+    });
+  } catch (error) {
+    console.error('💥 Contact exchange consent error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid contact details',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to save contact exchange',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Withdraw consent before the exchange happens
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const result = await ContactExchange.withdraw(id, session.profileId!);
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'You have not agreed to an exchange with this match',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (result.status === 'already_released') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Contacts were already exchanged',
+          error_type: 'already_released',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Consent withdrawn',
+    });
+  } catch (error) {
+    console.error('💥 Withdraw contact exchange error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to withdraw consent',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,65 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { ConversationExports } from '@/lib/conversation-exports';
+
+/**
+ * A transcript of the conversation with a match. The first request queues
+ * it (202); once it's ready, the next request downloads it as a text file,
+ * and it's deleted.
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const result = await ConversationExports.fetch(id, session.profileId!);
+
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Match not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'pending':
+        return NextResponse.json(
+          {
+            success: true,
+            message: 'Your transcript is being prepared',
+            data: { status: 'pending', requestedAt: result.requestedAt },
+          },
+          { status: 202, headers: { 'Cache-Control': 'no-store' } }
+        );
+      case 'ready':
+        return new NextResponse(result.transcript, {
+          headers: {
+            'Content-Type': 'text/plain; charset=utf-8',
+            'Content-Disposition': `attachment; filename="${result.filename}"`,
+            'Cache-Control': 'no-store',
+          },
+        });
+    }
+  } catch (error) {
+    console.error('💥 Export conversation error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to export conversation',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,112 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AbuseDetection } from '@/lib/abuse-detection';
+import { MatchMessages } from '@/lib/match-messages';
+
+const messageSchema = z.object({
+  text: z.string().trim().min(1).max(1000),
+});
+
+/**
+ * Send a message to a match. The first message to someone who takes
+ * message requests waits as a request until they accept it.
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+    const userId = session.profileId!;
+
+    const body = await request.json();
+    const validatedData = messageSchema.parse(body);
+
+    const verdict = await AbuseDetection.recordActivity(
+      userId,
+      'message',
+      validatedData.text
+    );
+    if (!verdict.allowed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Too many messages, please slow down',
+          error_type: 'rate_clamped',
+        },
+        { status: 429 }
+      );
+    }
+
+    const result = await MatchMessages.send(id, userId, validatedData.text);
+    switch (result.status) {
+      case 'pending':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Your message request has not been answered yet',
+            error_type: 'request_pending',
+          },
+          { status: 409 }
+        );
+      case 'declined':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Your message request was declined',
+            error_type: 'request_declined',
+          },
+          { status: 403 }
+        );
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Match not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: result.held ? 'Message request sent' : 'Message sent',
+      data: {
+        messageId: result.messageId,
+        held: result.held,
+        sentAt: result.sentAt,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Send message error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid message',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to send message',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
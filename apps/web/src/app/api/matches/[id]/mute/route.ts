@@ -0,0 +1,116 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { ConversationMutes } from '@/lib/conversation-mutes';
+
+const muteSchema = z.object({
+  // Omitted or null mutes until unmuted
+  until: z.coerce.date().nullable().optional(),
+});
+
+/**
+ * Mute the conversation with a match: no pushes about it
+ */
+export async function PUT(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+    const body = await request.json().catch(() => ({}));
+    const { until } = muteSchema.parse(body);
+
+    const result = await ConversationMutes.mute(
+      id,
+      session.profileId!,
+      until ?? null
+    );
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Match not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Conversation muted',
+      data: { until: result.mute.until },
+    });
+  } catch (error) {
+    console.error('💥 Mute conversation error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to mute conversation',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const unmuted = await ConversationMutes.unmute(id, session.profileId!);
+    if (!unmuted) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Conversation is not muted',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Conversation unmuted',
+    });
+  } catch (error) {
+    console.error('💥 Unmute conversation error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to unmute conversation',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,77 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { REPORT_REASONS } from '@/lib/reports';
+import { ConversationSafety } from '@/lib/conversation-safety';
+
+const reportSchema = z.object({
+  reason: z.enum(REPORT_REASONS),
+  details: z.string().max(1000).optional(),
+});
+
+/**
+ * Report the other person in a match, attaching the conversation
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = reportSchema.parse(body);
+
+    const result = await ConversationSafety.report(
+      id,
+      session.profileId!,
+      validatedData
+    );
+
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Match not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'reported':
+        return NextResponse.json({
+          success: true,
+          message: 'Report submitted',
+          data: { reportId: result.report.id },
+        });
+    }
+  } catch (error) {
+    console.error('💥 Report conversation error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid report data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to submit report',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
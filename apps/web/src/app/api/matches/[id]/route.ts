@@ -0,0 +1,119 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import prisma from '@/lib/prisma';
+import { toPublicProfile } from '@/lib/discovery-ranking';
+import { Locations } from '@/lib/locations';
+import { Presence } from '@/lib/presence';
+import { MLHealthMonitor } from '@/lib/ml-health';
+import { scoreCandidatePairs } from '@/lib/pair-scoring';
+import { CompatibilityQuiz } from '@/lib/compatibility-quiz';
+import { ProfilePrompts } from '@/lib/profile-prompts';
+import { VoiceIntros } from '@/lib/voice-intros';
+import { PhotoReveal } from '@/lib/photo-reveal';
+import { Badges } from '@/lib/badges';
+import { Circles } from '@/lib/circles';
+
+/**
+ * One of the signed-in user's matches, with the other person's profile
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const viewerId = (await getSession(request))!.profileId!;
+
+    const match = await prisma.match.findFirst({
+      where: {
+        id,
+        deletedAt: null,
+        OR: [{ user1Id: viewerId }, { user2Id: viewerId }],
+      },
+      include: { user1: true, user2: true },
+    });
+    if (!match) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Match not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const other = match.user1Id === viewerId ? match.user2 : match.user1;
+    const mlScores = new Map<string, number>();
+    if (await MLHealthMonitor.isAvailable()) {
+      try {
+        const [pair] = await scoreCandidatePairs(viewerId, [other.id]);
+        if (pair) {
+          mlScores.set(other.id, pair.compatibility);
+        }
+      } catch (error) {
+        console.error('Pair scoring failed, using quiz only:', error);
+      }
+    }
+
+    const [
+      distances,
+      presence,
+      compatibility,
+      prompts,
+      voiceIntros,
+      reveals,
+      badges,
+      circles,
+    ] = await Promise.all([
+      Locations.distancesFrom(viewerId, [other.id]),
+      Presence.lookup(viewerId, [other.id]),
+      CompatibilityQuiz.scores(viewerId, [other.id], mlScores),
+      ProfilePrompts.visibleFor([other.id]),
+      VoiceIntros.readyFor(viewerId, [other.id]),
+      PhotoReveal.statesFor(viewerId, [other]),
+      Badges.forUsers([other.id]),
+      Circles.forUsers([other.id]),
+    ]);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        id: match.id,
+        matchedAt: match.matchedAt,
+        status: match.status,
+        user: {
+          ...toPublicProfile(other),
+          profileImage: PhotoReveal.photoUrl(
+            other.id,
+            other.profileImage,
+            reveals.get(other.id)
+          ),
+          photoReveal: reveals.get(other.id) ?? null,
+          prompts: prompts.get(other.id) ?? [],
+          voiceIntro: voiceIntros.get(other.id) ?? null,
+          badges: badges.get(other.id) ?? [],
+          circles: circles.get(other.id) ?? [],
+          distance: distances.get(other.id) ?? null,
+          presence: presence.get(other.id) ?? null,
+        },
+        compatibility: compatibility.get(other.id) ?? null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch match error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch match',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,53 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { ScheduledMessages } from '@/lib/scheduled-messages';
+
+/**
+ * Cancel a scheduled message or reminder before it goes out
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string; scheduledId: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id, scheduledId } = await params;
+    const session = (await getSession(request))!;
+
+    const canceled = await ScheduledMessages.cancel(
+      scheduledId,
+      id,
+      session.profileId!
+    );
+    if (!canceled) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Scheduled message not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Scheduled message canceled',
+    });
+  } catch (error) {
+    console.error('💥 Cancel scheduled message error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to cancel scheduled message',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,144 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import {
+  ScheduledMessages,
+  SCHEDULED_KINDS,
+  MAX_SCHEDULE_DAYS,
+} from '@/lib/scheduled-messages';
+
+const scheduleSchema = z
+  .object({
+    kind: z.enum(SCHEDULED_KINDS),
+    // The message to send; reminders have none
+    text: z.string().trim().min(1).max(1000).optional(),
+    sendAt: z.coerce.date(),
+  })
+  .refine(
+    data => (data.kind === 'message') === (data.text !== undefined),
+    'Messages need text, and reminders take none'
+  );
+
+/**
+ * What the signed-in user has scheduled in the conversation with a match
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+    const scheduled = await ScheduledMessages.list(id, session.profileId!);
+
+    return NextResponse.json({ success: true, data: { scheduled } });
+  } catch (error) {
+    console.error('💥 Fetch scheduled messages error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch scheduled messages',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Schedule a message to a match, or a reminder to reply to them. Either
+ * waits for the quiet hours of whoever it reaches to end.
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = scheduleSchema.parse(body);
+
+    const result = await ScheduledMessages.schedule(
+      id,
+      session.profileId!,
+      validatedData
+    );
+
+    switch (result.status) {
+      case 'invalid_time':
+        return NextResponse.json(
+          {
+            success: false,
+            message: `Pick a time within the next ${MAX_SCHEDULE_DAYS} days`,
+            error_type: 'validation_error',
+          },
+          { status: 400 }
+        );
+      case 'too_many':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Too many scheduled messages for this match',
+            error_type: 'too_many',
+          },
+          { status: 409 }
+        );
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Match not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message:
+        validatedData.kind === 'message'
+          ? 'Message scheduled'
+          : 'Reminder scheduled',
+      data: {
+        id: result.scheduled.id,
+        kind: result.scheduled.kind,
+        sendAt: result.scheduled.sendAt,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Schedule message error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid scheduled message',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to schedule message',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
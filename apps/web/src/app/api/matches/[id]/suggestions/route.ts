@@ -0,0 +1,110 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { MLHealthMonitor } from '@/lib/ml-health';
+import {
+  ConversationSuggestions,
+  SUGGESTIONS_PER_HOUR,
+} from '@/lib/conversation-suggestions';
+
+const querySchema = z.object({
+  kind: z.enum(['icebreaker', 'reply']).optional(),
+});
+
+/**
+ * AI conversation suggestions for one of the signed-in user's matches.
+ * Needs the aiSuggestions privacy setting; limited per user per hour.
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const query = querySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    if (!(await MLHealthMonitor.isAvailable())) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Suggestions are not available right now',
+          error_type: 'suggestions_unavailable',
+        },
+        { status: 503 }
+      );
+    }
+
+    const result = await ConversationSuggestions.generate(
+      id,
+      session.profileId!,
+      query.kind
+    );
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Match not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'consent_required':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Turn on AI suggestions in privacy settings first',
+            error_type: 'consent_required',
+          },
+          { status: 403 }
+        );
+      case 'rate_limited':
+        return NextResponse.json(
+          {
+            success: false,
+            message: `Up to ${SUGGESTIONS_PER_HOUR} suggestion requests per hour`,
+            error_type: 'rate_limit_exceeded',
+            retryAfterSeconds: result.retryAfterSeconds,
+          },
+          { status: 429 }
+        );
+    }
+
+    return NextResponse.json({
+      success: true,
+      data: { kind: result.kind, suggestions: result.suggestions },
+    });
+  } catch (error) {
+    console.error('💥 Fetch suggestions error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch suggestions',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,98 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { MediaStorage } from '@/lib/media-storage';
+import { SignedMedia } from '@/lib/signed-media';
+
+// Storage headers worth passing on to the client
+const PASSED_HEADERS = [
+  'content-type',
+  'content-length',
+  'content-range',
+  'accept-ranges',
+  'etag',
+  'last-modified',
+];
+
+/**
+ * Stream a stored object to the viewer its signed link was made for (see
+ * lib/signed-media)
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ key: string[] }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { key: segments } = await params;
+    const key = segments.join('/');
+    const viewerId = (await getSession(request))!.profileId ?? '';
+    const searchParams = request.nextUrl.searchParams;
+    const expiresAt = Number(searchParams.get('exp'));
+
+    const access = SignedMedia.verify(
+      key,
+      expiresAt,
+      searchParams.get('sig') ?? '',
+      viewerId
+    );
+    if (access.status !== 'ok') {
+      return NextResponse.json(
+        {
+          success: false,
+          message:
+            access.status === 'expired'
+              ? 'This link has expired'
+              : 'Invalid media link',
+          error_type:
+            access.status === 'expired' ? 'link_expired' : 'invalid_signature',
+        },
+        { status: 403 }
+      );
+    }
+
+    const object = await MediaStorage.stream(
+      key,
+      request.headers.get('range') ?? undefined
+    );
+    if (!object) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Media not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const headers = new Headers({
+      // Only for this viewer, and no longer than the link lasts
+      'Cache-Control': `private, max-age=${Math.max(
+        0,
+        expiresAt - Math.floor(Date.now() / 1000)
+      )}`,
+      'X-Content-Type-Options': 'nosniff',
+    });
+    for (const name of PASSED_HEADERS) {
+      const value = object.headers.get(name);
+      if (value) {
+        headers.set(name, value);
+      }
+    }
+    return new NextResponse(object.body, { status: object.status, headers });
+  } catch (error) {
+    console.error('💥 Fetch media error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch media',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
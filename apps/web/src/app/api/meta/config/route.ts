@@ -0,0 +1,48 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { ClientConfig } from '@/lib/client-config';
+import { APP_VERSION_HEADER } from '@/lib/app-version';
+import { Tenants } from '@/lib/tenants';
+import { singleflight } from '@/lib/singleflight';
+
+/**
+ * Everything the miniapp needs to configure itself on startup. Works
+ * signed out; signed in, flags are bucketed for the user. Quotas are the
+ * tenant's, from the session or else the host. Outdated builds can still
+ * reach this to learn they need to upgrade. Every app launch calls this,
+ * so identical signed-out builds in flight at once are coalesced.
+ */
+export async function GET(request: NextRequest) {
+  try {
+    const session = await getSession(request);
+    const appVersion = request.headers.get(APP_VERSION_HEADER);
+    const tenant = await Tenants.resolve(request, session?.tenantId);
+    const config = session?.profileId
+      ? await ClientConfig.build(session.profileId, appVersion, tenant)
+      : await singleflight(
+          'client-config',
+          `${tenant?.id ?? ''}:${appVersion ?? ''}`,
+          () => ClientConfig.build(undefined, appVersion, tenant)
+        );
+
+    return NextResponse.json(
+      { success: true, data: config },
+      {
+        headers: {
+          'Cache-Control': 'private, max-age=60',
+          Vary: 'X-App-Version, Host',
+        },
+      }
+    );
+  } catch (error) {
+    console.error('💥 Fetch client config error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch config',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,70 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Place } from '@prisma/client';
+import { Places } from '@/lib/places';
+
+const querySchema = z.object({
+  // Only the campuses in this city
+  city: z.string().optional(),
+});
+
+const toEntry = (place: Place) => ({
+  id: place.id,
+  name: place.name,
+  nameTh: place.nameTh,
+  country: place.country,
+  timezone: place.timezone,
+  ...(place.kind === 'campus' && { cityId: place.cityId }),
+});
+
+/**
+ * Cities and campuses users can pick. Their IDs are what profiles,
+ * discovery filters and travel mode take.
+ */
+export async function GET(request: NextRequest) {
+  try {
+    const query = querySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const [cities, campuses] = await Promise.all([
+      Places.list('city'),
+      Places.list('campus'),
+    ]);
+
+    return NextResponse.json(
+      {
+        success: true,
+        data: {
+          cities: cities.map(toEntry),
+          campuses: campuses
+            .filter(campus => !query.city || campus.cityId === query.city)
+            .map(toEntry),
+        },
+      },
+      { headers: { 'Cache-Control': 'public, max-age=300' } }
+    );
+  } catch (error) {
+    console.error('💥 Fetch locations error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch locations',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,27 @@
+import { NextResponse } from 'next/server';
+import { Policies, toPolicySummary } from '@/lib/policies';
+
+/**
+ * The terms of service and privacy policy versions in effect. Works
+ * signed out, so they can be shown before registration.
+ */
+export async function GET() {
+  try {
+    const policies = await Policies.current();
+
+    return NextResponse.json({
+      success: true,
+      data: policies.map(toPolicySummary),
+    });
+  } catch (error) {
+    console.error('💥 Fetch policies error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch policies',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
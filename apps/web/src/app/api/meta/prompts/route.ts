@@ -0,0 +1,23 @@
+import { NextResponse } from 'next/server';
+import {
+  MAX_PROFILE_PROMPTS,
+  MAX_PROMPT_ANSWER_LENGTH,
+  PROMPT_BANK,
+} from '@/lib/profile-prompts';
+
+/**
+ * The prompts users can answer on their profiles
+ */
+export async function GET() {
+  return NextResponse.json(
+    {
+      success: true,
+      data: {
+        prompts: PROMPT_BANK,
+        maxPrompts: MAX_PROFILE_PROMPTS,
+        maxAnswerLength: MAX_PROMPT_ANSWER_LENGTH,
+      },
+    },
+    { headers: { 'Cache-Control': 'public, max-age=3600' } }
+  );
+}
@@ -0,0 +1,27 @@
+import { NextResponse } from 'next/server';
+import { renderMetrics } from '@/lib/metrics';
+import { MLHealthMonitor } from '@/lib/ml-health';
+import prisma from '@/lib/prisma';
+
+export const dynamic = 'force-dynamic';
+
+/**
+ * Prometheus scrape endpoint
+ */
+export async function GET() {
+  // Refresh ML API gauges so a scrape never reports a long-stale probe
+  await MLHealthMonitor.getHealth();
+
+  // Prisma's own pool metrics (open/busy/idle connections, wait times)
+  const poolMetrics = await prisma.$metrics.prometheus().catch(error => {
+    console.error('Failed to read database pool metrics:', error);
+    return '';
+  });
+
+  return new NextResponse(renderMetrics() + poolMetrics, {
+    headers: {
+      'Content-Type': 'text/plain; version=0.0.4; charset=utf-8',
+      'Cache-Control': 'no-store',
+    },
+  });
+}
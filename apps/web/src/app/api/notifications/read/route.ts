@@ -0,0 +1,58 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Notifications } from '@/lib/notifications';
+
+const readSchema = z.union([
+  z.object({ ids: z.array(z.string().min(1)).min(1).max(100) }),
+  z.object({ all: z.literal(true) }),
+]);
+
+/**
+ * Mark notifications read, by ID or all at once
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = readSchema.parse(body);
+
+    const updated = await Notifications.markRead(
+      session.profileId!,
+      'ids' in validatedData ? validatedData.ids : undefined
+    );
+    const unreadCount = await Notifications.unreadCount(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: { updated, unreadCount },
+    });
+  } catch (error) {
+    console.error('💥 Mark notifications read error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Provide notification ids or all: true',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to mark notifications read',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,65 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Notifications } from '@/lib/notifications';
+
+const listSchema = z.object({
+  limit: z.coerce.number().int().min(1).max(100).default(20),
+  cursor: z.string().optional(),
+});
+
+/**
+ * The signed-in user's notifications, newest first, with the unread count
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const query = listSchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const [notifications, unreadCount] = await Promise.all([
+      Notifications.list(session.profileId!, query.limit, query.cursor),
+      Notifications.unreadCount(session.profileId!),
+    ]);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        notifications,
+        unreadCount,
+        nextCursor:
+          notifications.length === query.limit
+            ? notifications[notifications.length - 1].id
+            : null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch notifications error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch notifications',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,61 @@
+import { NextRequest, NextResponse } from 'next/server';
+import prisma from '@/lib/prisma';
+import { authMiddleware, getSession } from '@/middleware/auth';
+
+/**
+ * Status of one of the signed-in user's payments
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ reference: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { reference } = await params;
+    const session = (await getSession(request))!;
+
+    const payment = await prisma.payment.findUnique({
+      where: { reference },
+    });
+    if (!payment || payment.userId !== session.profileId) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Payment not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: `Payment is ${payment.status}`,
+      data: {
+        reference: payment.reference,
+        provider: payment.provider,
+        product: payment.product,
+        token: payment.token,
+        amount: payment.amount,
+        status: payment.status,
+        transactionId: payment.transactionId,
+        createdAt: payment.createdAt,
+        confirmedAt: payment.confirmedAt,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Payment status error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch payment',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,91 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { PRODUCTS } from '@/lib/payments';
+import {
+  createOnchainIntent,
+  CONFIRMATION_DEPTH,
+} from '@/lib/onchain-payments';
+
+const intentSchema = z.object({
+  product: z.string().refine(id => id in PRODUCTS, 'Unknown product'),
+  token: z.enum(['WLD', 'USDCE']),
+});
+
+/**
+ * Create an on-chain payment intent. The client must transfer exactly
+ * `amount` base units of `token` to `to`; the payment is granted
+ * automatically once the transfer is confirmed.
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = intentSchema.parse(body);
+
+    const product = PRODUCTS[validatedData.product];
+    if (!product.prices[validatedData.token]) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: `${product.name} can't be paid with ${validatedData.token}`,
+          error_type: 'validation_error',
+        },
+        { status: 400 }
+      );
+    }
+
+    const intent = await createOnchainIntent(
+      session.profileId!,
+      validatedData.product,
+      validatedData.token
+    );
+
+    return NextResponse.json(
+      {
+        success: true,
+        message: 'Payment intent created',
+        data: {
+          reference: intent.payment.reference,
+          product: intent.payment.product,
+          description: product.name,
+          to: intent.to,
+          token: intent.payment.token,
+          tokenAddress: intent.tokenAddress,
+          amount: intent.payment.amount,
+          confirmations: CONFIRMATION_DEPTH,
+          expiresAt: intent.expiresAt.toISOString(),
+          statusUrl: `/api/payments/${intent.payment.reference}`,
+        },
+      },
+      { status: 201 }
+    );
+  } catch (error) {
+    console.error('💥 Payment intent error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid payment data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to create payment intent',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
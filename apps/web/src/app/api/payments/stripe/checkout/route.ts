@@ -0,0 +1,77 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { PRODUCTS } from '@/lib/payments';
+import { createCheckoutSession } from '@/lib/stripe';
+
+const checkoutSchema = z.object({
+  product: z.string().refine(id => id in PRODUCTS, 'Unknown product'),
+});
+
+/**
+ * Start a Stripe Checkout session for a card subscription
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = checkoutSchema.parse(body);
+
+    const product = PRODUCTS[validatedData.product];
+    if (!product.stripePriceId) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: `${product.name} is not available for card payment`,
+          error_type: 'validation_error',
+        },
+        { status: 400 }
+      );
+    }
+
+    const baseUrl = process.env.NEXT_PUBLIC_BASE_URL || request.nextUrl.origin;
+    const checkout = await createCheckoutSession({
+      userId: session.profileId!,
+      productId: validatedData.product,
+      priceId: product.stripePriceId,
+      successUrl: `${baseUrl}/discover?checkout=success`,
+      cancelUrl: `${baseUrl}/discover?checkout=canceled`,
+    });
+
+    return NextResponse.json({
+      success: true,
+      message: 'Checkout session created',
+      data: {
+        sessionId: checkout.id,
+        url: checkout.url,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Stripe checkout error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid checkout data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to start checkout',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
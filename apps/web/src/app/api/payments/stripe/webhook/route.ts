@@ -0,0 +1,47 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { verifyWebhookEvent, StripeEvent } from '@/lib/stripe';
+import { handleStripeEvent } from '@/lib/stripe-billing';
+
+/**
+ * Stripe webhook receiver. The raw body is needed for signature checks.
+ */
+export async function POST(request: NextRequest) {
+  const payload = await request.text();
+
+  let event: StripeEvent;
+  try {
+    event = verifyWebhookEvent(
+      payload,
+      request.headers.get('stripe-signature')
+    );
+  } catch (error) {
+    console.warn('⚠️ Rejected Stripe webhook:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Invalid webhook signature',
+        error_type: 'invalid_signature',
+      },
+      { status: 400 }
+    );
+  }
+
+  try {
+    const outcome = await handleStripeEvent(event);
+    return NextResponse.json({
+      success: true,
+      data: { eventId: event.id, type: event.type, outcome },
+    });
+  } catch (error) {
+    // A 5xx makes Stripe retry the delivery
+    console.error(`💥 Stripe webhook ${event.type} error:`, error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to process webhook',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
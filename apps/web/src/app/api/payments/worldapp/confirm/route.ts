@@ -0,0 +1,171 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { Payment } from '@prisma/client';
+import prisma from '@/lib/prisma';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Payments } from '@/lib/payments';
+import {
+  fetchWorldAppTransaction,
+  WorldAppTransaction,
+} from '@/lib/worldapp-payments';
+
+// Shape of MiniKit's pay command `finalPayload`
+const confirmSchema = z.object({
+  reference: z.string().min(1, 'Reference is required'),
+  transaction_id: z.string().min(1, 'Transaction ID is required'),
+  status: z.literal('success'),
+});
+
+/**
+ * Verify a MiniKit pay transaction and grant the purchased product.
+ * Safe to call repeatedly: once confirmed, later calls return the same
+ * result without granting again.
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = confirmSchema.parse(body);
+
+    const payment = await prisma.payment.findUnique({
+      where: { reference: validatedData.reference },
+    });
+    if (
+      !payment ||
+      payment.userId !== session.profileId ||
+      payment.provider !== 'worldapp'
+    ) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Payment not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    // Duplicate confirmation of an already-settled payment
+    if (payment.status !== 'pending') {
+      if (payment.transactionId !== validatedData.transaction_id) {
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Payment was settled by a different transaction',
+            error_type: 'payment_conflict',
+          },
+          { status: 409 }
+        );
+      }
+      return paymentResponse(payment.status, payment.reference, false);
+    }
+
+    const transaction = await fetchWorldAppTransaction(
+      validatedData.transaction_id
+    );
+
+    const mismatch = findMismatch(transaction, payment);
+    if (mismatch) {
+      console.warn('⚠️ World App transaction mismatch:', {
+        reference: payment.reference,
+        transactionId: validatedData.transaction_id,
+        mismatch,
+      });
+      return NextResponse.json(
+        {
+          success: false,
+          message: `Transaction ${mismatch} does not match the payment`,
+          error_type: 'payment_verification_failed',
+        },
+        { status: 400 }
+      );
+    }
+
+    if (transaction.status === 'failed') {
+      await Payments.fail(payment, validatedData.transaction_id);
+      return paymentResponse('failed', payment.reference, false);
+    }
+
+    if (transaction.status !== 'mined') {
+      // Not final yet; the client retries confirmation
+      return paymentResponse('pending', payment.reference, false);
+    }
+
+    const fulfilled = await Payments.fulfill(
+      payment,
+      validatedData.transaction_id
+    );
+    return paymentResponse('confirmed', payment.reference, fulfilled);
+  } catch (error) {
+    console.error('💥 Payment confirmation error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid payment confirmation',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to confirm payment',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * First field where the on-chain transaction disagrees with the payment
+ */
+function findMismatch(
+  transaction: WorldAppTransaction,
+  payment: Payment
+): string | null {
+  if (transaction.reference !== payment.reference) {
+    return 'reference';
+  }
+  const recipient = process.env.WORLD_APP_PAYMENT_ADDRESS?.toLowerCase();
+  if (!recipient || transaction.to?.toLowerCase() !== recipient) {
+    return 'recipient';
+  }
+  if (transaction.token !== payment.token) {
+    return 'token';
+  }
+  if (BigInt(transaction.tokenAmount || '0') < BigInt(payment.amount)) {
+    return 'amount';
+  }
+  return null;
+}
+
+function paymentResponse(
+  status: string,
+  reference: string,
+  granted: boolean
+) {
+  const messages: Record<string, string> = {
+    confirmed: 'Payment confirmed',
+    pending: 'Payment is still pending confirmation',
+    failed: 'Payment failed',
+  };
+
+  return NextResponse.json(
+    {
+      success: status !== 'failed',
+      message: messages[status] || `Payment is ${status}`,
+      data: { reference, status, granted },
+    },
+    { status: status === 'pending' ? 202 : status === 'failed' ? 402 : 200 }
+  );
+}
@@ -0,0 +1,83 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Payments, PRODUCTS } from '@/lib/payments';
+
+const initiateSchema = z.object({
+  product: z.string().refine(id => id in PRODUCTS, 'Unknown product'),
+  token: z.enum(['WLD', 'USDCE']).default('WLD'),
+});
+
+/**
+ * Create a payment reference for MiniKit's pay command
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = initiateSchema.parse(body);
+
+    const product = PRODUCTS[validatedData.product];
+    if (!product.prices[validatedData.token]) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: `${product.name} can't be paid with ${validatedData.token}`,
+          error_type: 'validation_error',
+        },
+        { status: 400 }
+      );
+    }
+
+    if (!process.env.WORLD_APP_PAYMENT_ADDRESS) {
+      throw new Error('WORLD_APP_PAYMENT_ADDRESS is not configured');
+    }
+
+    const payment = await Payments.createIntent(
+      session.profileId!,
+      'worldapp',
+      validatedData.product,
+      validatedData.token
+    );
+
+    return NextResponse.json({
+      success: true,
+      message: 'Payment initiated',
+      data: {
+        reference: payment.reference,
+        product: payment.product,
+        description: product.name,
+        to: process.env.WORLD_APP_PAYMENT_ADDRESS,
+        token: payment.token,
+        amount: payment.amount,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Payment initiation error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid payment data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to initiate payment',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
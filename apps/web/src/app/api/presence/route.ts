@@ -0,0 +1,61 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Presence } from '@/lib/presence';
+
+const querySchema = z.object({
+  // Comma-separated profile IDs
+  userIds: z
+    .string()
+    .transform(ids => Array.from(new Set(ids.split(',').filter(Boolean))))
+    .pipe(z.array(z.string()).min(1).max(100)),
+});
+
+/**
+ * Online status and last seen for up to 100 users. Users who hide their
+ * last seen come back as null.
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const { userIds } = querySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const presence = await Presence.lookup(session.profileId!, userIds);
+
+    return NextResponse.json({
+      success: true,
+      data: Object.fromEntries(
+        userIds.map(userId => [userId, presence.get(userId) ?? null])
+      ),
+    });
+  } catch (error) {
+    console.error('💥 Fetch presence error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch presence',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -1,18 +1,41 @@
 import { NextRequest, NextResponse } from 'next/server';
-import { jwtVerify, SignJWT } from 'jose';
+import { JwtKeys } from '@/lib/jwt-keys';
 import { z } from 'zod';
 import prisma from '@/lib/prisma';
-
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!);
+import { EventBus } from '@/lib/event-bus';
+import { Places } from '@/lib/places';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { Policies } from '@/lib/policies';
+import { Cohorts } from '@/lib/cohorts';
+import { Tenants } from '@/lib/tenants';
+import { toStoredDate } from '@/lib/field-encryption';
+import {
+  AgeVerification,
+  MINIMUM_AGE,
+  parseBirthDate,
+} from '@/lib/age-verification';
+import {
+  DuplicateAccounts,
+  DEVICE_FINGERPRINT_HEADER,
+} from '@/lib/duplicate-accounts';
 
 const profileCreateSchema = z.object({
   name: z.string().min(1, 'Name is required').max(50, 'Name too long'),
+  // Directory IDs from GET /api/meta/locations
   university: z.string().min(1, 'University is required'),
+  city: z.string().optional(),
   year: z.string().optional(),
   faculty: z.string().optional(),
   primaryVibe: z.string().min(1, 'Primary vibe is required'),
   secondaryVibes: z.array(z.string()).max(2, 'Maximum 2 secondary vibes'),
   bio: z.string().max(300, 'Bio too long').optional(),
+  // YYYY-MM-DD
+  birthDate: z
+    .string()
+    .refine(value => parseBirthDate(value) !== null, 'Invalid birth date'),
+  // Policy versions (GET /api/meta/policies) accepted on the signup form
+  acceptedPolicies: z.array(z.string().min(1)).max(10).optional(),
 });
 
 export async function POST(request: NextRequest) {
@@ -26,7 +49,7 @@ export async function POST(request: NextRequest) {
       );
     }
 
-    const { payload } = await jwtVerify(sessionCookie.value, secret);
+    const { payload } = await JwtKeys.verify(sessionCookie.value);
     if (!payload.walletAddress) {
       return NextResponse.json(
         { success: false, message: 'Wallet connection required' },
@@ -34,9 +57,68 @@ export async function POST(request: NextRequest) {
       );
     }
 
+    // Impersonation sessions are short-lived and admin-minted; they're
+    // never reissued
+    if (payload.impersonation) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This action is disabled while impersonating',
+          error_type: 'impersonation_restricted',
+        },
+        { status: 403 }
+      );
+    }
+
     const body = await request.json();
     const validatedData = profileCreateSchema.parse(body);
 
+    const birthDate = parseBirthDate(validatedData.birthDate)!;
+    const gate = await AgeVerification.checkRegistration(
+      payload.worldId as string,
+      birthDate
+    );
+    if (gate === 'underage') {
+      await AuditLog.recordSafely({
+        action: 'user.registration_underage',
+        actorType: 'user',
+        actorId: payload.worldId as string,
+        ipAddress: ClientIp.of(request),
+      });
+      return NextResponse.json(
+        {
+          success: false,
+          message: `You must be at least ${MINIMUM_AGE} to join`,
+          error_type: 'underage',
+        },
+        { status: 403 }
+      );
+    }
+
+    // A campus instance's host only takes its own students; elsewhere
+    // students of a campus with an instance join it
+    const campus = await Places.get(validatedData.university);
+    const hostTenant = await Tenants.resolve(request);
+    if (
+      !campus ||
+      campus.kind !== 'campus' ||
+      !campus.active ||
+      (hostTenant && hostTenant.campusId !== campus.id) ||
+      (validatedData.city &&
+        !(await Places.isSelectable(validatedData.city, 'city')))
+    ) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Unknown university or city',
+          error_type: 'unknown_location',
+        },
+        { status: 400 }
+      );
+    }
+
+    const tenant = hostTenant ?? (await Tenants.forCampus(campus.id));
+
     console.log('👤 Creating profile:', {
       worldId: (payload.worldId as string).substring(0, 10) + '...',
       name: validatedData.name,
@@ -55,29 +137,63 @@ export async function POST(request: NextRequest) {
           crypto.randomUUID().slice(0, 4),
         displayName: validatedData.name,
         bio: validatedData.bio,
+        birthDate: toStoredDate(birthDate),
         vibe: validatedData.primaryVibe,
+        // Students live in their campus's city unless they say otherwise
+        cityId: validatedData.city ?? campus.cityId,
+        campusId: campus.id,
+        tenantId: tenant?.id ?? null,
         tags: {
-          university: validatedData.university,
           year: validatedData.year,
           faculty: validatedData.faculty,
           secondaryVibes: validatedData.secondaryVibes,
         },
         nftVerified: false, // This will be updated later
         status: 'active',
+        ...(await Cohorts.signupFields(payload.worldId as string)),
       },
     });
 
+    await AuditLog.recordSafely({
+      action: 'user.profile_created',
+      actorType: 'user',
+      actorId: payload.worldId as string,
+      targetType: 'user',
+      targetId: user.id,
+      ipAddress: ClientIp.of(request),
+    });
+    if (validatedData.acceptedPolicies?.length) {
+      await Policies.accept(
+        user.id,
+        validatedData.acceptedPolicies,
+        ClientIp.of(request)
+      );
+    }
+    await DuplicateAccounts.recordSignal(user.id, 'world_id', user.worldId);
+    await DuplicateAccounts.recordSignal(user.id, 'wallet', user.walletAddress);
+    await DuplicateAccounts.recordSignal(
+      user.id,
+      'device',
+      request.headers.get(DEVICE_FINGERPRINT_HEADER)
+    );
+    await EventBus.publish('user.signed_up', {
+      userId: user.id,
+      // World ID verification level ("orb" or "device")
+      authMethod: (payload.verificationLevel as string) || 'unknown',
+    });
+
     // Update session with profile completion
-    const updatedToken = await new SignJWT({
-      ...payload,
-      profileCompleted: true,
-      profileId: user.id,
-      profileCreatedAt: user.createdAt,
-    })
-      .setProtectedHeader({ alg: 'HS256' })
-      .setIssuedAt()
-      .setExpirationTime('7d') // Extend session for active users
-      .sign(secret);
+    // Extended for active users, though never past the current session
+    const updatedToken = await JwtKeys.reissue(
+      payload,
+      {
+        profileCompleted: true,
+        profileId: user.id,
+        profileCreatedAt: user.createdAt,
+        ...(tenant && { tenantId: tenant.id }),
+      },
+      7 * 24 * 60 * 60
+    );
 
     const responseObj = NextResponse.json({
       success: true,
@@ -87,6 +203,7 @@ export async function POST(request: NextRequest) {
         name: validatedData.name,
         university: validatedData.university,
         primaryVibe: validatedData.primaryVibe,
+        cohort: user.cohort,
       },
     });
 
@@ -0,0 +1,101 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { rateLimitMiddleware } from '@/middleware/rateLimit';
+import { DeepLinks } from '@/lib/deep-links';
+import {
+  defaultBranding,
+  MAX_QR_SIZE,
+  MIN_QR_SIZE,
+  QR_FORMATS,
+  QrCodes,
+} from '@/lib/qr-codes';
+
+const hexColor = z.string().regex(/^[0-9a-fA-F]{6}$/, 'Use a hex color');
+
+const querySchema = z.object({
+  // Short link slug (from POST /api/links)
+  target: z.string().min(1, 'Target is required'),
+  format: z.enum(QR_FORMATS).default('png'),
+  size: z.coerce
+    .number()
+    .int()
+    .min(MIN_QR_SIZE)
+    .max(MAX_QR_SIZE)
+    .default(512),
+  // Overrides for the configured brand colors, without the "#"
+  fg: hexColor.optional(),
+  bg: hexColor.optional(),
+  logo: z
+    .enum(['true', 'false'])
+    .default('true')
+    .transform(value => value === 'true'),
+});
+
+/**
+ * A QR code for a profile or invite short link. Public, so it can be used
+ * directly as an image source.
+ */
+export async function GET(request: NextRequest) {
+  const rateLimitResponse = await rateLimitMiddleware(request);
+  if (rateLimitResponse) {
+    return rateLimitResponse;
+  }
+
+  try {
+    const query = querySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const link = await DeepLinks.resolve(query.target);
+    if (link.status !== 'resolved') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Link not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const branding = defaultBranding();
+    const qr = await QrCodes.render(link.urls.shortUrl, {
+      format: query.format,
+      size: query.size,
+      branding: {
+        foreground: query.fg ? `#${query.fg}` : branding.foreground,
+        background: query.bg ? `#${query.bg}` : branding.background,
+        logoUrl: query.logo ? branding.logoUrl : null,
+      },
+    });
+
+    return new NextResponse(qr.body, {
+      headers: {
+        'Content-Type': qr.contentType,
+        'Cache-Control': 'public, max-age=86400',
+      },
+    });
+  } catch (error) {
+    console.error('💥 Render QR code error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to render QR code',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,62 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import {
+  CompatibilityQuiz,
+  QUIZ_QUESTIONS,
+  quizAnswerSchema,
+} from '@/lib/compatibility-quiz';
+
+const answersSchema = z.object({
+  answers: z.array(quizAnswerSchema).min(1).max(QUIZ_QUESTIONS.length),
+});
+
+/**
+ * Answer (or re-answer) quiz questions
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = answersSchema.parse(body);
+    const answers = await CompatibilityQuiz.saveAnswers(
+      session.profileId!,
+      validatedData.answers
+    );
+
+    return NextResponse.json({
+      success: true,
+      message: 'Answers saved',
+      data: answers,
+    });
+  } catch (error) {
+    console.error('💥 Save quiz answers error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid answers',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to save answers',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
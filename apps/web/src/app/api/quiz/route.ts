@@ -0,0 +1,41 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import {
+  CompatibilityQuiz,
+  QUIZ_IMPORTANCE,
+  QUIZ_QUESTIONS,
+} from '@/lib/compatibility-quiz';
+
+/**
+ * The quiz questions, with the signed-in user's answers so far
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const answers = await CompatibilityQuiz.getAnswers(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        questions: QUIZ_QUESTIONS,
+        importance: QUIZ_IMPORTANCE,
+        answers,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch quiz error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch quiz',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,79 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Reports, REPORT_REASONS } from '@/lib/reports';
+
+const reportSchema = z.object({
+  reportedUserId: z.string().min(1),
+  reason: z.enum(REPORT_REASONS),
+  details: z.string().max(1000).optional(),
+  // The message (signal) being reported, if any
+  signalId: z.string().optional(),
+});
+
+/**
+ * Report another user to the moderation team
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = reportSchema.parse(body);
+
+    if (validatedData.reportedUserId === session.profileId) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'You cannot report yourself',
+          error_type: 'validation_error',
+        },
+        { status: 400 }
+      );
+    }
+
+    const report = await Reports.create(session.profileId!, validatedData);
+    if (!report) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Report submitted',
+      data: { reportId: report.id },
+    });
+  } catch (error) {
+    console.error('💥 Submit report error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid report data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to submit report',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,60 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { SafetyCheckIns } from '@/lib/safety-check-ins';
+
+/**
+ * Check in as safe. Also clears a missed check-in for the trusted contact.
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const result = await SafetyCheckIns.checkIn(session.profileId!, id);
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Check-in not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (result.status === 'closed') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This check-in is already closed',
+          error_type: 'check_in_closed',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Checked in',
+      data: result.checkIn,
+    });
+  } catch (error) {
+    console.error('💥 Safety check-in error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to check in',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,60 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { SafetyCheckIns } from '@/lib/safety-check-ins';
+
+/**
+ * Cancel one of the signed-in user's open check-ins
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const result = await SafetyCheckIns.cancel(session.profileId!, id);
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Check-in not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (result.status === 'closed') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This check-in is already closed',
+          error_type: 'check_in_closed',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Check-in canceled',
+      data: result.checkIn,
+    });
+  } catch (error) {
+    console.error('💥 Cancel safety check-in error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to cancel check-in',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
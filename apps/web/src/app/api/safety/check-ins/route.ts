@@ -0,0 +1,138 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { SafetyCheckIns } from '@/lib/safety-check-ins';
+
+const checkInSchema = z.object({
+  matchId: z.string().min(1),
+  place: z.string().trim().min(1).max(200).optional(),
+  dateAt: z.coerce.date(),
+  checkInAt: z.coerce.date(),
+  contactName: z.string().trim().min(1).max(100),
+  contactEmail: z.string().email().max(254).optional(),
+});
+
+/**
+ * The signed-in user's date check-ins
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const checkIns = await SafetyCheckIns.list(session.profileId!);
+
+    return NextResponse.json({ success: true, data: { checkIns } });
+  } catch (error) {
+    console.error('💥 Fetch safety check-ins error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch check-ins',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Schedule a check-in for a date with a match. The response includes the
+ * link to share with the trusted contact.
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = checkInSchema.parse(body);
+
+    const result = await SafetyCheckIns.create(
+      session.profileId!,
+      validatedData
+    );
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Match not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'invalid_time':
+        return NextResponse.json(
+          {
+            success: false,
+            message:
+              'The check-in must be in the future, after the date starts and within a day of it',
+            error_type: 'invalid_time',
+          },
+          { status: 400 }
+        );
+      case 'too_many':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'You have too many check-ins open already',
+            error_type: 'limit_reached',
+          },
+          { status: 409 }
+        );
+    }
+
+    await AuditLog.recordSafely({
+      action: 'safety.check_in_scheduled',
+      actorType: 'user',
+      actorId: session.profileId!,
+      targetType: 'safety_check_in',
+      targetId: result.checkIn.id,
+      details: {
+        matchId: result.checkIn.matchId,
+        checkInAt: result.checkIn.checkInAt,
+        contactEmailed: Boolean(result.checkIn.contactEmail),
+      },
+      ipAddress: ClientIp.of(request),
+    });
+
+    return NextResponse.json({
+      success: true,
+      message: 'Check-in scheduled',
+      data: result.checkIn,
+    });
+  } catch (error) {
+    console.error('💥 Schedule safety check-in error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid check-in',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to schedule check-in',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,41 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { SafetyCheckIns } from '@/lib/safety-check-ins';
+
+/**
+ * A date check-in as its trusted contact sees it. Public: the token in
+ * the share link is the only credential.
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ token: string }> }
+) {
+  try {
+    const { token } = await params;
+    const shared = await SafetyCheckIns.shared(token);
+    if (!shared) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This link has expired or does not exist',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json(
+      { success: true, data: shared },
+      { headers: { 'Cache-Control': 'no-store' } }
+    );
+  } catch (error) {
+    console.error('💥 Fetch shared check-in error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch check-in',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
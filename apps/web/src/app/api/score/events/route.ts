@@ -0,0 +1,45 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { ScoreEvents } from '@/lib/score-events';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+
+/**
+ * Recent score threshold events for the signed-in user, newest first
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    if (!session.profileId) {
+      return NextResponse.json(
+        { success: false, message: 'Profile setup required' },
+        { status: 400 }
+      );
+    }
+
+    const limit = Math.min(
+      parseInt(request.nextUrl.searchParams.get('limit') || '20') || 20,
+      20
+    );
+    const events = await ScoreEvents.getRecent(session.profileId, limit);
+
+    return NextResponse.json({
+      success: true,
+      data: { events },
+    });
+  } catch (error) {
+    console.error('💥 Fetch score events error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch score events',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
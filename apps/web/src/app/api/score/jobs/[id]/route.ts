@@ -0,0 +1,47 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getScoringJobStatus } from '@/lib/scoring-jobs';
+import { authMiddleware, getSession } from '@/middleware/auth';
+
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    // Other users' jobs are reported as missing
+    const status = await getScoringJobStatus(id);
+    if (!status || status.userId !== session.profileId) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Scoring job not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: `Scoring job is ${status.state}`,
+      data: status,
+    });
+  } catch (error) {
+    console.error('Scoring job status error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to retrieve scoring job',
+        error_type: 'processing_error',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,102 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { rateLimitMiddleware } from '@/middleware/rateLimit';
+import { enqueueScoringJob, MAX_PHOTOS_PER_JOB } from '@/lib/scoring-jobs';
+import { selectModelVersion } from '@/lib/ml-model-routing';
+import { ImageSanitizer } from '@/lib/image-sanitizer';
+import { Verification } from '@/lib/verification';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+
+// Jobs score the signed-in user's own photos
+const scoringJobSchema = z.object({
+  images: z
+    .array(
+      z
+        .string()
+        .regex(/^[A-Za-z0-9+/]*={0,2}$/, 'Invalid image format (must be base64)')
+        .min(5000, 'Image too small for analysis')
+        .max(3000000, 'Image too large (max 3MB)')
+    )
+    .min(1, 'At least one image is required')
+    .max(MAX_PHOTOS_PER_JOB, `Maximum ${MAX_PHOTOS_PER_JOB} images per job`),
+});
+
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  // Apply rate limiting
+  const rateLimitResponse = await rateLimitMiddleware(request);
+  if (rateLimitResponse) {
+    return rateLimitResponse;
+  }
+
+  try {
+    const userId = (await getSession(request))!.profileId!;
+    const body = await request.json();
+    const validatedData = scoringJobSchema.parse(body);
+
+    const modelSelection = await selectModelVersion(request, userId);
+
+    // Queued photos sit in Redis until scored, so strip location and
+    // device metadata first
+    const images = await Promise.all(
+      validatedData.images.map(image => ImageSanitizer.sanitizeBase64(image))
+    );
+
+    const submittedAt = new Date().toISOString();
+    const jobId = await enqueueScoringJob({
+      userId,
+      images,
+      metadata: {
+        nftVerified: await Verification.isVerified(userId, 'nft'),
+        // Accounts only exist behind World ID sign-in
+        wldVerified: true,
+        submittedAt,
+        modelVersion: modelSelection.version,
+        submittedBy: userId,
+      },
+    });
+
+    return NextResponse.json(
+      {
+        success: true,
+        message: 'Scoring job queued',
+        data: {
+          jobId,
+          state: 'queued',
+          submittedAt,
+          modelVersion: modelSelection.version,
+          statusUrl: `/api/score/jobs/${jobId}`,
+        },
+      },
+      { status: 202 }
+    );
+  } catch (error) {
+    console.error('Scoring job submission error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid scoring job request',
+          error_type: 'validation_error',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to queue scoring job',
+        error_type: 'processing_error',
+      },
+      { status: 500 }
+    );
+  }
+}
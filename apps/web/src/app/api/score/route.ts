@@ -1,6 +1,10 @@
 import { NextRequest, NextResponse } from 'next/server'
 import { calculateFinalScore, UserProfile } from '@/lib/final-score-calculator'
 import { RedisCache } from '@/lib/redis-cache'
+import { ScorePercentiles } from '@/lib/score-percentiles'
+import { ScoreEvents } from '@/lib/score-events'
+import { getSession } from '@/middleware/auth'
+import { authorize } from '@/middleware/policy'
 
 // University Score Map (Bangkok only)
 const universityScoreMap: Record<string, number> = {
@@ -30,7 +34,13 @@ const nftScoreMap: Record<string, number> = {
 }
 
 export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request)
+  if (authResponse) {
+    return authResponse
+  }
+
   try {
+    const userId = (await getSession(request))!.profileId!
     const body = await request.json()
     
     // Validate request body
@@ -42,23 +52,49 @@ export async function POST(request: NextRequest) {
       }, { status: 400 })
     }
     
-    // Calculate final score
-    const result = calculateFinalScore(body as UserProfile)
+    // Calculate the caller's own final score, from the facial score we
+    // computed for them rather than one they sent
+    const facialScore = await RedisCache.getFacialScore(userId)
+    if (facialScore === null) {
+      return NextResponse.json({
+        success: false,
+        message: 'Score a photo before calculating the final score',
+        error_type: 'facial_score_required'
+      }, { status: 409 })
+    }
+    const result = calculateFinalScore({
+      ...(body as UserProfile),
+      userId,
+      facialScore
+    })
     
     // Get component scores
-    const facialScore = result.facialScore || 0
     const universityScore = universityScoreMap[result.university] || 0
     const nftScore = result.gender === "male" ? (nftScoreMap[result.nftTier || "none"] || 0) : 0
     
-    // Cache the final score
-    await RedisCache.cacheFacialScore(result.userId, result.finalScore || 0)
+    // Rank the score within the user's cohort distribution
+    const rank = await ScorePercentiles.record(
+      'final',
+      ScorePercentiles.cohortFor(result),
+      result.finalScore || 0
+    )
+    
+    // Emit events for any score thresholds crossed since the last scoring
+    const scoreEvents = await ScoreEvents.recordScore(
+      userId,
+      'final',
+      result.finalScore || 0
+    )
     
     return NextResponse.json({
       success: true,
       data: {
         ...result,
         score: result.finalScore,
-        percentile: result.finalScore ? Math.round((result.finalScore / 100) * 100) / 100 : 0,
+        percentile: rank?.percentile ?? null,
+        topPercent: rank?.topPercent ?? null,
+        cohort: rank ? { name: rank.cohort, size: rank.cohortSize } : null,
+        scoreEvents,
         components: {
           facial: facialScore,
           university: universityScore,
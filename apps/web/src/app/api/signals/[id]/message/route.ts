@@ -0,0 +1,76 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import {
+  MessageDeletion,
+  UNSEND_WINDOW_MINUTES,
+} from '@/lib/message-deletion';
+
+/**
+ * Unsend the message sent with one of the signed-in user's signals. The
+ * signal stays, marked as having had its message deleted.
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const result = await MessageDeletion.unsend(id, session.profileId!);
+
+    switch (result.status) {
+      case 'deleted':
+        return NextResponse.json({
+          success: true,
+          message: 'Message deleted',
+          data: { signalId: id, deletedAt: result.deletedAt },
+        });
+      case 'already_deleted':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Message was already deleted',
+            error_type: 'already_deleted',
+          },
+          { status: 409 }
+        );
+      case 'window_closed':
+        return NextResponse.json(
+          {
+            success: false,
+            message:
+              `Messages can only be deleted within ${UNSEND_WINDOW_MINUTES} ` +
+              'minutes of sending',
+            error_type: 'unsend_window_closed',
+          },
+          { status: 409 }
+        );
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Message not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+    }
+  } catch (error) {
+    console.error('💥 Unsend message error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to delete message',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
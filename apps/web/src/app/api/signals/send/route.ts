@@ -1,8 +1,11 @@
 import { NextRequest, NextResponse } from 'next/server'
-import { jwtVerify, SignJWT } from 'jose'
 import { z } from 'zod'
-
-const secret = new TextEncoder().encode(process.env.JWT_SECRET!)
+import { Shadowbans } from '@/lib/shadowbans'
+import { EventBus } from '@/lib/event-bus'
+import { AbuseDetection } from '@/lib/abuse-detection'
+import { Notifications } from '@/lib/notifications'
+import { getSession } from '@/middleware/auth'
+import { authorize } from '@/middleware/policy'
 
 const signalSchema = z.object({
   profileId: z.string().min(1, 'Profile ID is required'),
@@ -20,32 +23,35 @@ const SIGNAL_COSTS = {
 
 export async function POST(request: NextRequest) {
   try {
-    // Verify session
-    const sessionCookie = request.cookies.get('worldid-session')
-    if (!sessionCookie) {
-      return NextResponse.json(
-        { success: false, message: 'Session required' },
-        { status: 401 }
-      )
-    }
-
-    const { payload } = await jwtVerify(sessionCookie.value, secret)
-    if (!payload.profileCompleted) {
-      return NextResponse.json(
-        { success: false, message: 'Profile setup required' },
-        { status: 400 }
-      )
+    // Verify the session, account status and route policy
+    const authResponse = await authorize(request)
+    if (authResponse) {
+      return authResponse
     }
+    const session = (await getSession(request))!
+    const userId = session.profileId!
 
     const body = await request.json()
     const validatedData = signalSchema.parse(body)
 
     console.log('🌟 Sending secret signal:', {
-      from: (payload.worldId as string).substring(0, 10) + '...',
+      from: session.worldId.substring(0, 10) + '...',
       to: validatedData.profileId,
       type: validatedData.signalType
     })
 
+    const verdict = await AbuseDetection.recordActivity(userId, 'signal')
+    if (!verdict.allowed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Too many signals, please slow down',
+          error_type: 'rate_clamped',
+        },
+        { status: 429 }
+      )
+    }
+
     // TODO: Check user's signal balance and deduct cost
     const signalCost = SIGNAL_COSTS[validatedData.signalType]
     console.log(`💎 Signal cost: ${signalCost} (${validatedData.signalType})`)
@@ -53,17 +59,34 @@ export async function POST(request: NextRequest) {
     // TODO: Store signal in database and check for mutual signals
     const signalRecord = {
       id: crypto.randomUUID(),
-      from: payload.worldId,
+      from: session.worldId,
       to: validatedData.profileId,
       signalType: validatedData.signalType,
       timestamp: new Date().toISOString(),
       mutual: false // Check if recipient has also sent a signal
     }
 
-    // Mock mutual signal detection (15% chance for demo)
-    const isMutual = Math.random() > 0.85
+    // Mock mutual signal detection (15% chance for demo); signals from
+    // shadowbanned users are never delivered, so never mutual
+    const suppressed = await Shadowbans.isShadowbanned(userId)
+    const isMutual = !suppressed && Math.random() > 0.85
     signalRecord.mutual = isMutual
 
+    if (!suppressed) {
+      await EventBus.publish('signal.sent', {
+        fromUserId: userId,
+        toUserId: validatedData.profileId,
+        type: validatedData.signalType,
+      })
+      await Notifications.notify(validatedData.profileId, {
+        type: 'signal',
+        template: isMutual ? 'signal_mutual' : 'signal',
+        path: '/signals',
+        data: { signalType: validatedData.signalType, mutual: isMutual },
+        push: true,
+      })
+    }
+
     if (isMutual) {
       console.log('🔥 Mutual signal detected! Profile revealed.')
     }
@@ -0,0 +1,80 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { SpeedDating } from '@/lib/speed-dating';
+
+const messageSchema = z.object({
+  text: z.string().trim().min(1).max(1000),
+});
+
+/**
+ * Send a chat message to the other person while the date is running
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = messageSchema.parse(body);
+
+    const result = await SpeedDating.message(
+      id,
+      session.profileId!,
+      validatedData.text
+    );
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Date not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (result.status === 'ended') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This date has ended',
+          error_type: 'date_ended',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({ success: true, message: 'Message sent' });
+  } catch (error) {
+    console.error('💥 Speed-dating message error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid message',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to send message',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
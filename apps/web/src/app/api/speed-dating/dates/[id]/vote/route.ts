@@ -0,0 +1,85 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { SPEED_DATE_VOTES, SpeedDating } from '@/lib/speed-dating';
+
+const voteSchema = z.object({
+  vote: z.enum(SPEED_DATE_VOTES),
+});
+
+/**
+ * Keep or pass on the person from a date. Voting while the date is
+ * running ends it; two keeps make a regular match.
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = voteSchema.parse(body);
+
+    const result = await SpeedDating.vote(
+      id,
+      session.profileId!,
+      validatedData.vote
+    );
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Date not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+    if (result.status === 'closed') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Voting on this date is closed',
+          error_type: 'voting_closed',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: result.matchId ? "It's a match!" : 'Vote recorded',
+      data: { matchId: result.matchId },
+    });
+  } catch (error) {
+    console.error('💥 Speed-dating vote error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid vote',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to record vote',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
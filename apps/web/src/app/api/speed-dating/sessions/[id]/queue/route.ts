@@ -0,0 +1,160 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { SpeedDating } from '@/lib/speed-dating';
+
+/**
+ * Whether the signed-in user is queued in the session, the date they're
+ * in, and any updates (pairings, messages, votes) waiting for them.
+ * Clients without a socket connection poll this every second or two.
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const userId = (await getSession(request))!.profileId!;
+
+    const state = await SpeedDating.state(id, userId);
+    return NextResponse.json({ success: true, data: state });
+  } catch (error) {
+    console.error('💥 Fetch speed-dating state error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch speed-dating state',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Join a live session's queue. Pairs right away if someone suitable is
+ * waiting; otherwise the pairing arrives as an update.
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const result = await SpeedDating.join(id, session.profileId!);
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Session not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'not_live':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'This session is not running right now',
+            error_type: 'session_not_live',
+          },
+          { status: 409 }
+        );
+      case 'not_eligible':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'This session is for another city',
+            error_type: 'not_eligible',
+          },
+          { status: 403 }
+        );
+      case 'in_date':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Finish your current date first',
+            error_type: 'in_date',
+          },
+          { status: 409 }
+        );
+      case 'waiting':
+        return NextResponse.json({
+          success: true,
+          message: 'Waiting for a match',
+          data: { status: 'waiting', date: null },
+        });
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Paired',
+      data: {
+        status: 'paired',
+        date: {
+          id: result.date.id,
+          withUserId:
+            result.date.user1Id === session.profileId
+              ? result.date.user2Id
+              : result.date.user1Id,
+          startedAt: result.date.startedAt,
+          endsAt: result.date.endsAt,
+        },
+      },
+    });
+  } catch (error) {
+    console.error('💥 Join speed-dating queue error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to join the queue',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Leave the session's queue
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const userId = (await getSession(request))!.profileId!;
+
+    await SpeedDating.leave(id, userId);
+    return NextResponse.json({ success: true, message: 'Left the queue' });
+  } catch (error) {
+    console.error('💥 Leave speed-dating queue error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to leave the queue',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,35 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import prisma from '@/lib/prisma';
+import { SpeedDating } from '@/lib/speed-dating';
+
+/**
+ * Speed-dating sessions the signed-in user can join, soonest first
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const user = await prisma.user.findUnique({
+      where: { id: session.profileId! },
+      select: { cityId: true },
+    });
+    const sessions = await SpeedDating.upcoming(user?.cityId ?? null);
+
+    return NextResponse.json({ success: true, data: sessions });
+  } catch (error) {
+    console.error('💥 Fetch speed-dating sessions error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch sessions',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,74 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import prisma from '@/lib/prisma';
+import { PhotoReveal } from '@/lib/photo-reveal';
+
+/**
+ * A user's photo as the signed-in viewer may see it: blurred to their
+ * reveal stage if the user has photo reveal on, otherwise the original
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const viewerId = (await getSession(request))!.profileId!;
+
+    const owner = await prisma.user.findFirst({
+      where: { id, status: 'active' },
+      select: { id: true, profileImage: true, photoRevealMode: true },
+    });
+    if (!owner?.profileImage) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Photo not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    const state = (await PhotoReveal.statesFor(viewerId, [owner])).get(id);
+    if (!state?.blurred) {
+      // Streamed rather than redirected, so the stored URL stays private
+      const original = await PhotoReveal.original(owner.profileImage);
+      return new NextResponse(original.body, {
+        headers: {
+          'Content-Type': original.contentType,
+          'Cache-Control': 'private, max-age=300',
+          'X-Content-Type-Options': 'nosniff',
+        },
+      });
+    }
+
+    const variant = await PhotoReveal.variant(
+      id,
+      owner.profileImage,
+      state.stage
+    );
+    return new NextResponse(variant, {
+      headers: {
+        'Content-Type': 'image/jpeg',
+        // Private: another viewer may be at a different stage
+        'Cache-Control': 'private, max-age=300',
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch photo error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch photo',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
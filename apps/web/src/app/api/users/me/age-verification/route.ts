@@ -0,0 +1,238 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { worldIdProofSchema } from '@/lib/validations';
+import {
+  AgeVerification,
+  MINIMUM_AGE,
+  parseBirthDate,
+} from '@/lib/age-verification';
+
+const verifySchema = z.discriminatedUnion('method', [
+  z.object({ method: z.literal('world_id'), proof: worldIdProofSchema }),
+  z.object({ method: z.literal('document') }),
+]);
+
+const birthDateSchema = z.object({
+  // YYYY-MM-DD
+  birthDate: z
+    .string()
+    .refine(value => parseBirthDate(value) !== null, 'Invalid birth date'),
+});
+
+/**
+ * The signed-in user's date of birth, age range and verification status
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const data = await AgeVerification.getOwn(session.profileId!);
+
+    return NextResponse.json({ success: true, data });
+  } catch (error) {
+    console.error('💥 Fetch age verification error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch age verification',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Verify the signed-in user's age: a World ID document credential proof
+ * verifies immediately, a document check returns a URL to complete it at
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = verifySchema.parse(body);
+
+    const result = await AgeVerification.start(
+      session.profileId!,
+      validatedData
+    );
+    await AuditLog.recordSafely({
+      action: 'user.age_verification_started',
+      actorType: 'user',
+      actorId: session.profileId!,
+      targetType: 'user',
+      targetId: session.profileId!,
+      details: { method: validatedData.method, outcome: result.status },
+      ipAddress: ClientIp.of(request),
+    });
+
+    switch (result.status) {
+      case 'unavailable':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'This verification method is not available right now',
+            error_type: 'age_verification_unavailable',
+          },
+          { status: 503 }
+        );
+      case 'already_verified':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Your age is already verified',
+            error_type: 'already_verified',
+          },
+          { status: 409 }
+        );
+      case 'failed':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Age verification failed',
+            error_type: 'verification_failed',
+            reason: result.reason,
+          },
+          { status: 400 }
+        );
+      case 'underage':
+        return NextResponse.json(
+          {
+            success: false,
+            message: `You must be at least ${MINIMUM_AGE} to join`,
+            error_type: 'underage',
+          },
+          { status: 403 }
+        );
+      case 'pending':
+        return NextResponse.json({
+          success: true,
+          message: 'Continue at the verification URL',
+          data: { status: 'pending', url: result.url },
+        });
+      default:
+        return NextResponse.json({
+          success: true,
+          message: 'Age verified',
+          data: { status: 'verified', method: result.method },
+        });
+    }
+  } catch (error) {
+    console.error('💥 Age verification error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid age verification request',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to verify age',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Set a date of birth on an account registered before it was asked for.
+ * Only works once.
+ */
+export async function PUT(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = birthDateSchema.parse(body);
+
+    const outcome = await AgeVerification.declareBirthDate(
+      session.profileId!,
+      parseBirthDate(validatedData.birthDate)!
+    );
+    if (outcome === 'already_set') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Your birth date is already set',
+          error_type: 'already_set',
+        },
+        { status: 409 }
+      );
+    }
+
+    await AuditLog.recordSafely({
+      action: 'user.birth_date_declared',
+      actorType: 'user',
+      actorId: session.profileId!,
+      targetType: 'user',
+      targetId: session.profileId!,
+      details: { underage: outcome === 'underage' },
+      ipAddress: ClientIp.of(request),
+    });
+    if (outcome === 'underage') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: `You must be at least ${MINIMUM_AGE} to join`,
+          error_type: 'underage',
+        },
+        { status: 403 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Birth date saved',
+      data: await AgeVerification.getOwn(session.profileId!),
+    });
+  } catch (error) {
+    console.error('💥 Set birth date error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid birth date',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to save birth date',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,61 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Payments } from '@/lib/payments';
+
+const historyQuerySchema = z.object({
+  limit: z.coerce.number().int().min(1).max(100).default(20),
+  before: z.coerce.date().optional(),
+});
+
+/**
+ * The signed-in user's billing history across payment providers
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const query = historyQuerySchema.parse(
+      Object.fromEntries(request.nextUrl.searchParams)
+    );
+
+    const records = await Payments.getHistory(session.profileId!, query);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        records,
+        nextBefore:
+          records.length === query.limit
+            ? records[records.length - 1].createdAt
+            : null,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch billing history error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid query parameters',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch billing history',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,161 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import {
+  ChatBridge,
+  CHAT_PROVIDERS,
+  chatProviderEnabled,
+} from '@/lib/chat-bridge';
+
+const providerSchema = z.object({
+  provider: z.enum(CHAT_PROVIDERS),
+});
+
+/**
+ * Linked Telegram/LINE chats, and which bots are available
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const links = await ChatBridge.listLinks(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        links,
+        available: CHAT_PROVIDERS.filter(chatProviderEnabled),
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch chat links error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch chat links',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Start linking a chat: returns a code to send the bot, and a deep link
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = providerSchema.parse(body);
+
+    if (!chatProviderEnabled(validatedData.provider)) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This messenger is not available',
+          error_type: 'provider_unavailable',
+        },
+        { status: 503 }
+      );
+    }
+
+    const link = await ChatBridge.createLinkCode(
+      session.profileId!,
+      validatedData.provider
+    );
+
+    return NextResponse.json({
+      success: true,
+      message: 'Send the code to the bot to finish linking',
+      data: link,
+    });
+  } catch (error) {
+    console.error('💥 Create chat link error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to create chat link',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+export async function DELETE(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = providerSchema.parse(body);
+
+    const removed = await ChatBridge.unlink(
+      session.profileId!,
+      validatedData.provider
+    );
+    if (!removed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'No linked chat for this messenger',
+          error_type: 'link_not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Chat unlinked',
+    });
+  } catch (error) {
+    console.error('💥 Remove chat link error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to remove chat link',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,41 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Cohorts } from '@/lib/cohorts';
+
+/**
+ * The signed-in user's soft-launch cohort and place on the waitlist.
+ * Reachable while waitlisted.
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const status = await Cohorts.status(session.profileId!);
+    if (!status) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({ success: true, data: status });
+  } catch (error) {
+    console.error('💥 Fetch cohort error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch cohort',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,125 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { Devices, DEVICE_PLATFORMS } from '@/lib/devices';
+
+const registerSchema = z.object({
+  token: z.string().min(1).max(4096),
+  platform: z.enum(DEVICE_PLATFORMS),
+  locale: z.string().max(35).optional(),
+  appVersion: z.string().max(32).optional(),
+});
+
+const unregisterSchema = z.object({
+  token: z.string().min(1).max(4096),
+});
+
+/**
+ * Register this device's push token, or refresh it on app start
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = registerSchema.parse(body);
+
+    const device = await Devices.register(session.profileId!, validatedData);
+
+    return NextResponse.json({
+      success: true,
+      message: 'Device registered',
+      data: {
+        deviceId: device.id,
+        platform: device.platform,
+        locale: device.locale,
+        appVersion: device.appVersion,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Register device error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to register device',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Stop push notifications to a device, e.g. on sign-out
+ */
+export async function DELETE(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = unregisterSchema.parse(body);
+
+    const removed = await Devices.unregister(
+      session.profileId!,
+      validatedData.token
+    );
+    if (!removed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Device not found',
+          error_type: 'device_not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Device unregistered',
+    });
+  } catch (error) {
+    console.error('💥 Unregister device error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to unregister device',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,125 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { Email } from '@/lib/email';
+
+const emailSchema = z.object({
+  email: z.string().email().max(254),
+});
+
+/**
+ * The account's email address and whether it's verified
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const address = await Email.getAddress(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        email: address?.email ?? null,
+        verified: Boolean(address?.emailVerifiedAt),
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch email error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch email',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Add or change the account's email. A code is sent to the new address;
+ * it takes effect once confirmed at /api/users/me/email/verify.
+ */
+export async function PUT(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = emailSchema.parse(body);
+
+    const result = await Email.startVerification(
+      session.profileId!,
+      validatedData.email
+    );
+
+    if (result.status === 'email_taken') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This email is used by another account',
+          error_type: 'email_taken',
+        },
+        { status: 409 }
+      );
+    }
+
+    if (result.status === 'rate_limited') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Too many codes requested. Try again later.',
+          error_type: 'rate_limited',
+        },
+        { status: 429 }
+      );
+    }
+
+    if (result.status === 'unavailable') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Email is not available right now',
+          error_type: 'email_unavailable',
+        },
+        { status: 503 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Verification code sent',
+      data: { expiresAt: result.expiresAt },
+    });
+  } catch (error) {
+    console.error('💥 Update email error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update email',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,90 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Email } from '@/lib/email';
+
+const verifySchema = z.object({
+  code: z.string().regex(/^\d{6}$/),
+});
+
+/**
+ * Confirm the code emailed to a new address
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = verifySchema.parse(body);
+
+    const result = await Email.confirmVerification(
+      session.profileId!,
+      validatedData.code
+    );
+
+    if (result.status === 'invalid_code') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Incorrect code',
+          error_type: 'invalid_code',
+        },
+        { status: 400 }
+      );
+    }
+
+    if (result.status === 'expired') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'The code has expired. Request a new one.',
+          error_type: 'code_expired',
+        },
+        { status: 410 }
+      );
+    }
+
+    if (result.status === 'email_taken') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This email is used by another account',
+          error_type: 'email_taken',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Email verified',
+      data: { email: result.email, verified: true },
+    });
+  } catch (error) {
+    console.error('💥 Verify email error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to verify email',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,45 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Entitlements, PLANS } from '@/lib/entitlements';
+
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const userId = session.profileId!;
+
+    const [subscription, entitlements] = await Promise.all([
+      Entitlements.getSubscription(userId),
+      Entitlements.getActive(userId),
+    ]);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        plan: {
+          id: subscription.plan,
+          name: PLANS[subscription.plan].name,
+          status: subscription.status,
+          startedAt: subscription.startedAt,
+          expiresAt: subscription.expiresAt,
+          graceEndsAt: subscription.graceEndsAt,
+        },
+        entitlements,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch entitlements error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch entitlements',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,30 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Inventory } from '@/lib/inventory';
+
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const inventory = await Inventory.getBalances(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: { inventory },
+    });
+  } catch (error) {
+    console.error('💥 Fetch inventory error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch inventory',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,86 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { ReadReplicas } from '@/lib/read-replicas';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { Locations } from '@/lib/locations';
+import { Presence } from '@/lib/presence';
+import { PhotoReveal } from '@/lib/photo-reveal';
+
+/**
+ * Profiles that liked the signed-in user (premium: see-who-liked-me)
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const db = await ReadReplicas.client(session.profileId!);
+    const likes = await db.signal.findMany({
+      where: {
+        toUserId: session.profileId!,
+        type: { in: ['like', 'super_like'] },
+        suppressed: false,
+        deletedAt: null,
+      },
+      include: {
+        fromUser: {
+          select: {
+            id: true,
+            handle: true,
+            displayName: true,
+            profileImage: true,
+            vibe: true,
+            photoRevealMode: true,
+          },
+        },
+      },
+      orderBy: { sentAt: 'desc' },
+      take: 50,
+    });
+
+    const userIds = likes.map(like => like.fromUserId);
+    const [distances, presence, reveals] = await Promise.all([
+      Locations.distancesFrom(session.profileId!, userIds),
+      Presence.lookup(session.profileId!, userIds),
+      PhotoReveal.statesFor(
+        session.profileId!,
+        likes.map(like => like.fromUser)
+      ),
+    ]);
+
+    return NextResponse.json({
+      success: true,
+      data: likes.map(like => ({
+        user: {
+          id: like.fromUser.id,
+          handle: like.fromUser.handle,
+          displayName: like.fromUser.displayName,
+          profileImage: PhotoReveal.photoUrl(
+            like.fromUser.id,
+            like.fromUser.profileImage,
+            reveals.get(like.fromUser.id)
+          ),
+          vibe: like.fromUser.vibe,
+        },
+        distance: distances.get(like.fromUserId) ?? null,
+        presence: presence.get(like.fromUserId) ?? null,
+        type: like.type,
+        likedAt: like.sentAt,
+      })),
+    });
+  } catch (error) {
+    console.error('💥 Fetch likes error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch likes',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
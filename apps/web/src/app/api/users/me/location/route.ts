@@ -0,0 +1,137 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import {
+  Locations,
+  MAX_LOCATION_PRECISION,
+  MIN_LOCATION_PRECISION,
+} from '@/lib/locations';
+import { GEOHASH_PATTERN } from '@/lib/geohash';
+
+const locationSchema = z.union([
+  z.object({
+    lat: z.number().min(-90).max(90),
+    lng: z.number().min(-180).max(180),
+    // Geohash length to store; coarser is more private
+    precision: z
+      .number()
+      .int()
+      .min(MIN_LOCATION_PRECISION)
+      .max(MAX_LOCATION_PRECISION)
+      .optional(),
+  }),
+  // For clients that won't send coordinates; finer hashes are truncated
+  z.object({
+    geohash: z
+      .string()
+      .toLowerCase()
+      .regex(GEOHASH_PATTERN)
+      .min(MIN_LOCATION_PRECISION),
+  }),
+]);
+
+/**
+ * The location we have stored for the user, as a geohash cell
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const location = await Locations.get(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: location,
+    });
+  } catch (error) {
+    console.error('💥 Fetch location error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch location',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Update the user's location from coordinates or a geohash
+ */
+export async function PUT(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = locationSchema.parse(body);
+
+    const location = await Locations.update(session.profileId!, validatedData);
+
+    return NextResponse.json({
+      success: true,
+      message: 'Location updated',
+      data: location,
+    });
+  } catch (error) {
+    console.error('💥 Update location error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update location',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Forget the user's location; discovery stops using it
+ */
+export async function DELETE(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const removed = await Locations.clear(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      message: removed ? 'Location removed' : 'No location stored',
+    });
+  } catch (error) {
+    console.error('💥 Remove location error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to remove location',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
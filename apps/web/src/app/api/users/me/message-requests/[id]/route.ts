@@ -0,0 +1,87 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { MessageRequests } from '@/lib/message-requests';
+
+const decisionSchema = z.object({
+  accept: z.boolean(),
+});
+
+/**
+ * Accept a message request, opening the conversation, or decline it
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const { accept } = decisionSchema.parse(body);
+
+    const result = await MessageRequests.respond(
+      id,
+      session.profileId!,
+      accept
+    );
+    switch (result.status) {
+      case 'not_found':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Message request not found',
+            error_type: 'not_found',
+          },
+          { status: 404 }
+        );
+      case 'already_answered':
+        return NextResponse.json(
+          {
+            success: false,
+            message: 'Message request already answered',
+            error_type: 'already_answered',
+          },
+          { status: 409 }
+        );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message:
+        result.status === 'accepted'
+          ? 'Message request accepted'
+          : 'Message request declined',
+      data: { status: result.status, matchId: result.matchId },
+    });
+  } catch (error) {
+    console.error('💥 Answer message request error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to answer message request',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
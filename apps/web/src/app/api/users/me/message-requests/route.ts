@@ -0,0 +1,35 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { MessageRequests } from '@/lib/message-requests';
+
+/**
+ * First messages from new matches waiting for the signed-in user to accept
+ * them
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const requests = await MessageRequests.list(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: { requests },
+    });
+  } catch (error) {
+    console.error('💥 Fetch message requests error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch message requests',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
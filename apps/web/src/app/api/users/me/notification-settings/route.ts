@@ -0,0 +1,113 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { isValidTimezone } from '@/lib/waking-hours';
+
+const settingsFields = {
+  locale: true,
+  timezone: true,
+  quietHoursStart: true,
+  quietHoursEnd: true,
+} as const;
+
+const hour = z.number().int().min(0).max(23);
+
+const settingsSchema = z
+  .object({
+    // BCP 47 tag, e.g. "th-TH"; picks the language of emails
+    locale: z
+      .string()
+      .regex(/^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$/)
+      .optional(),
+    timezone: z.string().refine(isValidTimezone, 'Unknown timezone'),
+    // Both or neither; null uses the default (22-8), equal hours turn
+    // quiet hours off
+    quietHoursStart: hour.nullable(),
+    quietHoursEnd: hour.nullable(),
+  })
+  .refine(
+    data => (data.quietHoursStart === null) === (data.quietHoursEnd === null),
+    'Set both quiet hours or neither'
+  );
+
+/**
+ * Language, timezone and quiet hours, which decide how and when
+ * notifications reach the user
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const settings = await prisma.user.findUnique({
+      where: { id: session.profileId! },
+      select: settingsFields,
+    });
+
+    return NextResponse.json({
+      success: true,
+      data: settings,
+    });
+  } catch (error) {
+    console.error('💥 Fetch notification settings error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch notification settings',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+export async function PUT(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = settingsSchema.parse(body);
+
+    const settings = await prisma.user.update({
+      where: { id: session.profileId! },
+      data: validatedData,
+      select: settingsFields,
+    });
+
+    return NextResponse.json({
+      success: true,
+      message: 'Notification settings updated',
+      data: settings,
+    });
+  } catch (error) {
+    console.error('💥 Update notification settings error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update notification settings',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,49 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { Passes } from '@/lib/passes';
+
+/**
+ * Take one person off the pass list
+ */
+export async function DELETE(
+  request: NextRequest,
+  { params }: { params: Promise<{ userId: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { userId } = await params;
+    const session = (await getSession(request))!;
+
+    const removed = await Passes.remove(session.profileId!, userId);
+    if (!removed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Not on your pass list',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Removed from pass list',
+    });
+  } catch (error) {
+    console.error('💥 Remove pass error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to remove pass',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
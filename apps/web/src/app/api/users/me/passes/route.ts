@@ -0,0 +1,67 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { Passes, PASS_RESHOW_DAYS } from '@/lib/passes';
+
+/**
+ * The people the signed-in user passed on who are still hidden from
+ * their discovery
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const passes = await Passes.list(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: { passes, reshowDays: PASS_RESHOW_DAYS },
+    });
+  } catch (error) {
+    console.error('💥 Fetch passes error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch passes',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Clear the pass list so everyone on it can show up in discovery again
+ */
+export async function DELETE(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const cleared = await Passes.clear(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      message: 'Pass list cleared',
+      data: { cleared },
+    });
+  } catch (error) {
+    console.error('💥 Clear passes error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to clear passes',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
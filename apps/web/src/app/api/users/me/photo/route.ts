@@ -0,0 +1,199 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AbuseDetection } from '@/lib/abuse-detection';
+import { mediaStorageEnabled } from '@/lib/media-storage';
+import { PhotoReveal } from '@/lib/photo-reveal';
+import {
+  PROFILE_PHOTO_MAX_UPLOAD_BYTES,
+  ProfilePhotos,
+} from '@/lib/profile-photos';
+
+const uploadSchema = z.object({
+  // Base64 of a JPEG, PNG, WebP, HEIF or AVIF photo
+  image: z
+    .string()
+    .regex(/^[A-Za-z0-9+/]*={0,2}$/, 'Invalid image format (must be base64)')
+    .max(Math.ceil((PROFILE_PHOTO_MAX_UPLOAD_BYTES * 4) / 3) + 4),
+});
+
+/**
+ * Set the signed-in user's profile photo. Location, camera and other
+ * metadata are stripped and the photo is turned upright before it's
+ * stored.
+ */
+export async function PUT(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    if (!mediaStorageEnabled()) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Photo uploads are not available right now',
+          error_type: 'media_unavailable',
+        },
+        { status: 503 }
+      );
+    }
+
+    const body = await request.json();
+    const validatedData = uploadSchema.parse(body);
+
+    const verdict = await AbuseDetection.recordActivity(
+      session.profileId!,
+      'profile_edit'
+    );
+    if (!verdict.allowed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Too many profile edits, please slow down',
+          error_type: 'rate_clamped',
+        },
+        { status: 429 }
+      );
+    }
+
+    const result = await ProfilePhotos.replace(
+      session.profileId!,
+      Buffer.from(validatedData.image, 'base64')
+    );
+    if (result.status === 'too_large') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Photo is too large',
+          error_type: 'file_too_large',
+          maxUploadBytes: PROFILE_PHOTO_MAX_UPLOAD_BYTES,
+        },
+        { status: 400 }
+      );
+    }
+    if (result.status === 'unreadable') {
+      return NextResponse.json(
+        {
+          success: false,
+          message:
+            'We could not read this photo. Please upload a JPEG, PNG, ' +
+            'or WebP image.',
+          error_type: 'unreadable_image',
+        },
+        { status: 400 }
+      );
+    }
+
+    if (result.status === 'infected') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This file failed our security scan',
+          error_type: 'upload_rejected',
+        },
+        { status: 422 }
+      );
+    }
+    if (result.status === 'scan_unavailable') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Photo uploads are not available right now',
+          error_type: 'media_unavailable',
+        },
+        { status: 503 }
+      );
+    }
+    if (result.status === 'duplicate') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This photo is already used by another account',
+          error_type: 'duplicate_photo',
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Profile photo updated',
+      data: {
+        profileImage: PhotoReveal.photoUrl(
+          session.profileId!,
+          result.profileImage,
+          undefined
+        ),
+        width: result.width,
+        height: result.height,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Upload profile photo error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid photo upload',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to upload profile photo',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Remove the signed-in user's profile photo
+ */
+export async function DELETE(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const removed = await ProfilePhotos.remove(session.profileId!);
+    if (!removed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'No profile photo to remove',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Profile photo removed',
+    });
+  } catch (error) {
+    console.error('💥 Remove profile photo error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to remove profile photo',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,105 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { ClientIp } from '@/lib/client-ip';
+import { Policies, toPolicySummary } from '@/lib/policies';
+
+const acceptSchema = z.object({
+  policyVersionIds: z.array(z.string().min(1)).min(1).max(10),
+});
+
+/**
+ * Policy versions the signed-in user still has to accept, and the ones
+ * they have
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const [pending, accepted] = await Promise.all([
+      Policies.pendingFor(session.profileId!),
+      Policies.history(session.profileId!),
+    ]);
+
+    return NextResponse.json({
+      success: true,
+      data: { pending: pending.map(toPolicySummary), accepted },
+    });
+  } catch (error) {
+    console.error('💥 Fetch policy acceptances error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch policies',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Accept the current terms and/or privacy policy
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = acceptSchema.parse(body);
+
+    const result = await Policies.accept(
+      session.profileId!,
+      validatedData.policyVersionIds,
+      ClientIp.of(request)
+    );
+    if (result.status === 'not_current') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Only the current policy versions can be accepted',
+          error_type: 'policy_not_current',
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Policies accepted',
+      data: { pending: result.pending.map(toPolicySummary) },
+    });
+  } catch (error) {
+    console.error('💥 Accept policies error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to accept policies',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,65 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { ONLINE_WINDOW_SECONDS } from '@/lib/presence';
+import { WakingHours } from '@/lib/waking-hours';
+
+const heartbeatSchema = z.object({
+  // The device's IANA timezone, used until the user picks one
+  timezone: z.string().max(64).optional(),
+});
+
+/**
+ * Heartbeat from an open app. Authentication already records the
+ * activity; this also keeps the device's timezone current and tells the
+ * client how often to call.
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json().catch(() => ({}));
+    const validatedData = heartbeatSchema.parse(body);
+
+    if (validatedData.timezone) {
+      await WakingHours.recordDetectedTimezone(
+        session.profileId!,
+        validatedData.timezone
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        // Heartbeat at least this often to stay online
+        intervalSeconds: ONLINE_WINDOW_SECONDS / 2,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Presence heartbeat error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to record heartbeat',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,52 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { PrivacyRequests } from '@/lib/privacy-requests';
+
+/**
+ * Download the data export from a completed access request
+ */
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const { id } = await params;
+    const session = (await getSession(request))!;
+
+    const data = await PrivacyRequests.getExport(session.profileId!, id);
+    if (!data) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Export not found or expired',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return new NextResponse(JSON.stringify(data, null, 2), {
+      headers: {
+        'Content-Type': 'application/json',
+        'Content-Disposition': `attachment; filename="aurum-data-${id}.json"`,
+        'Cache-Control': 'no-store',
+      },
+    });
+  } catch (error) {
+    console.error('💥 Download data export error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to download data export',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,110 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import {
+  PrivacyRequests,
+  PRIVACY_REQUEST_TYPES,
+} from '@/lib/privacy-requests';
+
+const requestSchema = z.object({
+  type: z.enum(PRIVACY_REQUEST_TYPES),
+});
+
+/**
+ * The signed-in user's data access and erasure requests
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const requests = await PrivacyRequests.listForUser(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: { requests },
+    });
+  } catch (error) {
+    console.error('💥 Fetch privacy requests error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch privacy requests',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Ask for a copy of your data ("access") or for it to be erased
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = requestSchema.parse(body);
+
+    const { request: privacyRequest, created } = await PrivacyRequests.file(
+      session.profileId!,
+      validatedData.type
+    );
+    if (!created) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'A request of this type is already in progress',
+          error_type: 'request_pending',
+          data: { requestId: privacyRequest.id, dueAt: privacyRequest.dueAt },
+        },
+        { status: 409 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: true,
+        message: 'Request received',
+        data: {
+          requestId: privacyRequest.id,
+          type: privacyRequest.type,
+          status: privacyRequest.status,
+          dueAt: privacyRequest.dueAt,
+        },
+      },
+      { status: 201 }
+    );
+  } catch (error) {
+    console.error('💥 File privacy request error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to file privacy request',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
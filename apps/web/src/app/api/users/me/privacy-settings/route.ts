@@ -0,0 +1,108 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import prisma from '@/lib/prisma';
+import { authMiddleware, getSession } from '@/middleware/auth';
+
+const settingsFields = {
+  hideDistance: true,
+  hideLastSeen: true,
+  photoRevealMode: true,
+  aiSuggestions: true,
+  aiMessageAccess: true,
+  messageRequests: true,
+} as const;
+
+const settingsSchema = z.object({
+  // Hides the user's distance from others, and others' from them
+  hideDistance: z.boolean().optional(),
+  // Hides the user's online status and last seen, and others' from them
+  hideLastSeen: z.boolean().optional(),
+  // Photo starts blurred for others and clears up as a match chats
+  photoRevealMode: z.boolean().optional(),
+  // Opts in to AI conversation suggestions for the user's matches
+  aiSuggestions: z.boolean().optional(),
+  // Lets matches' AI suggestions read the user's messages
+  aiMessageAccess: z.boolean().optional(),
+  // Holds first messages from new matches as requests to accept
+  messageRequests: z.boolean().optional(),
+});
+
+/**
+ * What other users get to see about the user
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const settings = await prisma.user.findUnique({
+      where: { id: session.profileId! },
+      select: settingsFields,
+    });
+
+    return NextResponse.json({
+      success: true,
+      data: settings,
+    });
+  } catch (error) {
+    console.error('💥 Fetch privacy settings error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch privacy settings',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+export async function PUT(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = settingsSchema.parse(body);
+
+    const settings = await prisma.user.update({
+      where: { id: session.profileId! },
+      data: validatedData,
+      select: settingsFields,
+    });
+
+    return NextResponse.json({
+      success: true,
+      message: 'Privacy settings updated',
+      data: settings,
+    });
+  } catch (error) {
+    console.error('💥 Update privacy settings error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to update privacy settings',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,133 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AbuseDetection } from '@/lib/abuse-detection';
+import {
+  MAX_PROFILE_PROMPTS,
+  MAX_PROMPT_ANSWER_LENGTH,
+  PROMPT_BANK,
+  ProfilePrompts,
+} from '@/lib/profile-prompts';
+
+const promptIds = PROMPT_BANK.map(prompt => prompt.id);
+
+const promptsSchema = z.object({
+  prompts: z
+    .array(
+      z.object({
+        promptId: z
+          .string()
+          .refine(id => promptIds.includes(id), 'Unknown prompt'),
+        answer: z.string().trim().min(1).max(MAX_PROMPT_ANSWER_LENGTH),
+      })
+    )
+    .max(MAX_PROFILE_PROMPTS)
+    .refine(
+      prompts => new Set(prompts.map(p => p.promptId)).size === prompts.length,
+      'Each prompt can only be answered once'
+    ),
+});
+
+/**
+ * The signed-in user's prompt answers, including any moderators removed
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const prompts = await ProfilePrompts.getOwn(session.profileId!);
+
+    return NextResponse.json({ success: true, data: prompts });
+  } catch (error) {
+    console.error('💥 Fetch prompts error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch prompts',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Replace the signed-in user's prompt answers (in display order)
+ */
+export async function PUT(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const body = await request.json();
+    const validatedData = promptsSchema.parse(body);
+
+    const verdict = await AbuseDetection.recordActivity(
+      session.profileId!,
+      'profile_edit'
+    );
+    if (!verdict.allowed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Too many profile edits, please slow down',
+          error_type: 'rate_clamped',
+        },
+        { status: 429 }
+      );
+    }
+
+    const result = await ProfilePrompts.set(
+      session.profileId!,
+      validatedData.prompts
+    );
+    if (result.status === 'contact_details') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Links and contact details are not allowed in prompts',
+          error_type: 'contact_details_not_allowed',
+          promptId: result.promptId,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Prompts saved',
+      data: result.prompts,
+    });
+  } catch (error) {
+    console.error('💥 Save prompts error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid prompts',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to save prompts',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,54 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { AccountDeletion } from '@/lib/account-deletion';
+
+/**
+ * Undo the signed-in user's account deletion, within the retention window
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const result = await AccountDeletion.restore(session.profileId!);
+
+    if (result.status === 'not_deleted') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Account is not deleted',
+          error_type: 'not_deleted',
+        },
+        { status: 409 }
+      );
+    }
+    if (result.status === 'expired') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'This account can no longer be restored',
+          error_type: 'restore_expired',
+        },
+        { status: 410 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Account restored',
+    });
+  } catch (error) {
+    console.error('💥 Restore account error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to restore account',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,47 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AccountDeletion } from '@/lib/account-deletion';
+
+/**
+ * Delete the signed-in user's account. It can be restored until the
+ * retention window runs out, then it's erased.
+ */
+export async function DELETE(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const result = await AccountDeletion.delete(session.profileId!);
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'User not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Account deleted',
+      data: { restorableUntil: result.restorableUntil },
+    });
+  } catch (error) {
+    console.error('💥 Delete account error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to delete account',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,71 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { Streaks } from '@/lib/streaks';
+
+/**
+ * The signed-in user's daily streak and the next milestone reward
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const streak = await Streaks.status(session.profileId!);
+
+    return NextResponse.json({ success: true, data: streak });
+  } catch (error) {
+    console.error('💥 Fetch streak error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch streak',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Claim today for the streak. Idempotent per local day, so the app can
+ * call this on every open.
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const result = await Streaks.claim(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      message:
+        result.status === 'claimed'
+          ? 'Streak extended'
+          : 'Already claimed today',
+      data: {
+        claimed: result.status === 'claimed',
+        reward: result.status === 'claimed' ? result.reward : null,
+        ...(await Streaks.status(session.profileId!)),
+      },
+    });
+  } catch (error) {
+    console.error('💥 Claim streak error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to claim streak',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
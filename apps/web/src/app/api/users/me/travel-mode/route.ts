@@ -0,0 +1,143 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { MAX_TRAVEL_DAYS, TravelMode } from '@/lib/travel-mode';
+
+const travelSchema = z.object({
+  city: z.string().min(1),
+  days: z.number().int().min(1).max(MAX_TRAVEL_DAYS),
+});
+
+/**
+ * The user's current trip. The cities they can travel to are those in
+ * GET /api/meta/locations.
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const travel = await TravelMode.get(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        travel: travel && {
+          city: travel.city,
+          startedAt: travel.startedAt,
+          expiresAt: travel.expiresAt,
+        },
+        maxDays: MAX_TRAVEL_DAYS,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch travel mode error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch travel mode',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Start travel mode in a city, or move an ongoing trip (premium)
+ */
+export async function PUT(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const body = await request.json();
+    const validatedData = travelSchema.parse(body);
+
+    const result = await TravelMode.start(
+      session.profileId!,
+      validatedData.city,
+      validatedData.days
+    );
+
+    if (result.status === 'unknown_city') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Unknown city',
+          error_type: 'unknown_city',
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Travel mode on',
+      data: {
+        city: result.travel.city,
+        startedAt: result.travel.startedAt,
+        expiresAt: result.travel.expiresAt,
+      },
+    });
+  } catch (error) {
+    console.error('💥 Start travel mode error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid request data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to start travel mode',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * End travel mode early
+ */
+export async function DELETE(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const stopped = await TravelMode.stop(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      message: stopped ? 'Travel mode off' : 'Travel mode was not on',
+    });
+  } catch (error) {
+    console.error('💥 Stop travel mode error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to stop travel mode',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
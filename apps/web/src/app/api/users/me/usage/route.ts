@@ -0,0 +1,30 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { Usage } from '@/lib/usage';
+
+/**
+ * The signed-in user's quotas, boosts and rate-limit status in one call
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const usage = await Usage.forUser(session.profileId!);
+
+    return NextResponse.json({ success: true, data: usage });
+  } catch (error) {
+    console.error('💥 Fetch usage error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch usage',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,51 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { VoiceIntros } from '@/lib/voice-intros';
+
+/**
+ * Mark a voice intro's recording as uploaded and queue it for processing.
+ * Poll GET /api/users/me/voice-intro for the outcome.
+ */
+export async function POST(
+  request: NextRequest,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const { id } = await params;
+    const result = await VoiceIntros.completeUpload(session.profileId!, id);
+    if (result.status === 'not_found') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Voice intro upload not found',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Voice intro is being processed',
+      data: result.intro,
+    });
+  } catch (error) {
+    console.error('💥 Complete voice intro upload error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to complete voice intro upload',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
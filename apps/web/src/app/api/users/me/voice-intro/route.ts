@@ -0,0 +1,182 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { authMiddleware, getSession } from '@/middleware/auth';
+import { authorize } from '@/middleware/policy';
+import { AbuseDetection } from '@/lib/abuse-detection';
+import { mediaStorageEnabled } from '@/lib/media-storage';
+import {
+  VOICE_INTRO_CONTENT_TYPES,
+  VOICE_INTRO_MAX_SECONDS,
+  VOICE_INTRO_MAX_UPLOAD_BYTES,
+  VOICE_INTRO_MIN_SECONDS,
+  VoiceIntros,
+} from '@/lib/voice-intros';
+
+const uploadSchema = z.object({
+  contentType: z.enum(VOICE_INTRO_CONTENT_TYPES),
+  sizeBytes: z.number().int().positive(),
+});
+
+/**
+ * The signed-in user's live voice intro and latest submission
+ */
+export async function GET(request: NextRequest) {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    const intro = await VoiceIntros.getOwn(session.profileId!);
+
+    return NextResponse.json({
+      success: true,
+      data: {
+        ...intro,
+        limits: {
+          minSeconds: VOICE_INTRO_MIN_SECONDS,
+          maxSeconds: VOICE_INTRO_MAX_SECONDS,
+          maxUploadBytes: VOICE_INTRO_MAX_UPLOAD_BYTES,
+          contentTypes: VOICE_INTRO_CONTENT_TYPES,
+        },
+      },
+    });
+  } catch (error) {
+    console.error('💥 Fetch voice intro error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch voice intro',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Start a voice intro upload: returns a pre-signed URL to PUT the
+ * recording to, then call .../[id]/complete
+ */
+export async function POST(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+    if (!mediaStorageEnabled()) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Voice intros are not available right now',
+          error_type: 'media_unavailable',
+        },
+        { status: 503 }
+      );
+    }
+
+    const body = await request.json();
+    const validatedData = uploadSchema.parse(body);
+
+    const verdict = await AbuseDetection.recordActivity(
+      session.profileId!,
+      'profile_edit'
+    );
+    if (!verdict.allowed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Too many profile edits, please slow down',
+          error_type: 'rate_clamped',
+        },
+        { status: 429 }
+      );
+    }
+
+    const result = await VoiceIntros.startUpload(
+      session.profileId!,
+      validatedData.contentType,
+      validatedData.sizeBytes
+    );
+    if (result.status === 'too_large') {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Voice intro is too large',
+          error_type: 'file_too_large',
+          maxUploadBytes: VOICE_INTRO_MAX_UPLOAD_BYTES,
+        },
+        { status: 400 }
+      );
+    }
+
+    const { status: _status, ...upload } = result;
+    return NextResponse.json({ success: true, data: upload });
+  } catch (error) {
+    console.error('💥 Start voice intro upload error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid voice intro upload',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to start voice intro upload',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Remove the signed-in user's voice intro from their profile
+ */
+export async function DELETE(request: NextRequest) {
+  const authResponse = await authorize(request);
+  if (authResponse) {
+    return authResponse;
+  }
+
+  try {
+    const session = (await getSession(request))!;
+
+    const removed = await VoiceIntros.remove(session.profileId!);
+    if (!removed) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'No voice intro to remove',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({
+      success: true,
+      message: 'Voice intro removed',
+    });
+  } catch (error) {
+    console.error('💥 Remove voice intro error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to remove voice intro',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,137 @@
+import { NextRequest, NextResponse } from 'next/server';
+import { z } from 'zod';
+import { getSession } from '@/middleware/auth';
+import { rateLimitMiddleware } from '@/middleware/rateLimit';
+import { Places } from '@/lib/places';
+import { Waitlist } from '@/lib/waitlist';
+
+const waitlistSchema = z.object({
+  // Campus ID from GET /api/meta/locations
+  university: z.string().min(1, 'University is required'),
+  email: z.string().email().max(254).optional(),
+});
+
+/**
+ * Where the World ID-verified caller is on the waitlist
+ */
+export async function GET(request: NextRequest) {
+  const session = await getSession(request);
+  if (!session) {
+    return NextResponse.json(
+      { success: false, message: 'Session required' },
+      { status: 401 }
+    );
+  }
+
+  try {
+    const place = await Waitlist.place(session.worldId);
+    if (!place) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Not on the waitlist',
+          error_type: 'not_found',
+        },
+        { status: 404 }
+      );
+    }
+
+    return NextResponse.json({ success: true, data: place });
+  } catch (error) {
+    console.error('💥 Fetch waitlist place error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to fetch waitlist place',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * Join the waitlist with a verified World ID (from /api/auth/worldid),
+ * before making an account. Joining again keeps the caller's place and
+ * updates their university and email.
+ */
+export async function POST(request: NextRequest) {
+  const rateLimitResponse = await rateLimitMiddleware(request);
+  if (rateLimitResponse) {
+    return rateLimitResponse;
+  }
+
+  const session = await getSession(request);
+  if (!session) {
+    return NextResponse.json(
+      { success: false, message: 'Session required' },
+      { status: 401 }
+    );
+  }
+
+  try {
+    if (session.profileId) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'You already have an account',
+          error_type: 'already_registered',
+        },
+        { status: 409 }
+      );
+    }
+
+    const body = await request.json();
+    const validatedData = waitlistSchema.parse(body);
+
+    if (!(await Places.isSelectable(validatedData.university, 'campus'))) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Unknown university',
+          error_type: 'unknown_location',
+        },
+        { status: 400 }
+      );
+    }
+
+    const { created, place } = await Waitlist.join(session.worldId, {
+      campusId: validatedData.university,
+      email: validatedData.email,
+      verificationLevel: session.verificationLevel,
+    });
+
+    return NextResponse.json(
+      {
+        success: true,
+        message: created
+          ? "You're on the waitlist"
+          : 'Your waitlist details were updated',
+        data: place,
+      },
+      { status: created ? 201 : 200 }
+    );
+  } catch (error) {
+    console.error('💥 Join waitlist error:', error);
+
+    if (error instanceof z.ZodError) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Invalid waitlist data',
+          errors: error.errors,
+        },
+        { status: 400 }
+      );
+    }
+
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to join waitlist',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+}
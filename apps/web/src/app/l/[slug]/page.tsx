@@ -0,0 +1,16 @@
+import { notFound, redirect } from 'next/navigation';
+import { DeepLinks } from '@/lib/deep-links';
+
+// Short links land here and go straight to the screen they point to
+export default async function ShortLinkPage({
+  params,
+}: {
+  params: Promise<{ slug: string }>;
+}) {
+  const { slug } = await params;
+  const result = await DeepLinks.resolve(slug);
+  if (result.status !== 'resolved') {
+    notFound();
+  }
+  redirect(result.target.path);
+}
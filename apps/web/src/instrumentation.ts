@@ -0,0 +1,17 @@
+/**
+ * Instrumentation
+ * Runs once when a server instance starts, before it handles requests:
+ * loads secrets from the configured secret store (see lib/secrets) and
+ * keeps them fresh
+ */
+
+export async function register() {
+  // The edge runtime can't reach the secret store, and doesn't need to
+  if (process.env.NEXT_RUNTIME !== 'nodejs') {
+    return;
+  }
+
+  const { Secrets } = await import('./lib/secrets');
+  await Secrets.load();
+  Secrets.startRotation();
+}
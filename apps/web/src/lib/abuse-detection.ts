@@ -0,0 +1,248 @@
+/**
+ * Abuse Detection
+ * Velocity heuristics for spam: bursts of signals or messages, the same
+ * message sent over and over, rapid profile edits, and message request
+ * after message request being declined (see lib/message-requests).
+ * Tripping a rule puts the account under a temporary rate clamp and files a
+ * moderation report. Velocity limits scale with the account's trust score.
+ */
+
+import { createHash } from 'crypto';
+import redis from './redis';
+import { Reports } from './reports';
+import { AuditLog } from './audit-log';
+import { counter } from './metrics';
+import { TrustScore } from './trust-score';
+
+export type AbuseActivity = 'signal' | 'message' | 'profile_edit';
+
+interface VelocityRule {
+  limit: number;
+  windowMs: number;
+  // Allowance per window while the account is clamped
+  clampedLimit: number;
+}
+
+const MINUTE = 60 * 1000;
+
+const RULES: Record<AbuseActivity, VelocityRule> = {
+  signal: {
+    limit: parseInt(process.env.ABUSE_MAX_SIGNALS_PER_10_MIN || '60'),
+    windowMs: 10 * MINUTE,
+    clampedLimit: 5,
+  },
+  message: {
+    limit: parseInt(process.env.ABUSE_MAX_MESSAGES_PER_10_MIN || '40'),
+    windowMs: 10 * MINUTE,
+    clampedLimit: 5,
+  },
+  profile_edit: {
+    limit: parseInt(process.env.ABUSE_MAX_PROFILE_EDITS_PER_HOUR || '10'),
+    windowMs: 60 * MINUTE,
+    clampedLimit: 1,
+  },
+};
+
+// The same message text this many times within an hour looks like spam
+const DUPLICATE_MESSAGE_LIMIT = parseInt(
+  process.env.ABUSE_DUPLICATE_MESSAGE_LIMIT || '5'
+);
+
+// This many of the user's message requests declined within a day looks
+// like unwanted mass messaging
+const DECLINED_REQUEST_LIMIT = parseInt(
+  process.env.ABUSE_MAX_DECLINED_REQUESTS_PER_DAY || '5'
+);
+
+const CLAMP_SECONDS =
+  parseInt(process.env.ABUSE_CLAMP_MINUTES || '60') * 60;
+
+const clampKey = (userId: string) => `abuse:clamp:${userId}`;
+
+const clampCounter = counter(
+  'aurum_abuse_clamps_total',
+  'Rate clamps applied by abuse heuristics'
+);
+
+export interface ActivityVerdict {
+  allowed: boolean;
+  clamped: boolean;
+}
+
+export interface ActivityAllowance {
+  // The current limit per window: the clamped allowance while clamped
+  limit: number;
+  remaining: number;
+  windowSeconds: number;
+}
+
+export interface RateLimitStatus {
+  clamped: boolean;
+  clampExpiresAt: string | null;
+  activities: Record<AbuseActivity, ActivityAllowance>;
+}
+
+/**
+ * Record one event in a sliding window and return the window's size
+ */
+async function slidingWindowCount(
+  key: string,
+  windowMs: number
+): Promise<number> {
+  const now = Date.now();
+  const results = await redis
+    .multi()
+    .zremrangebyscore(key, '-inf', now - windowMs)
+    .zadd(key, now, `${now}:${Math.random()}`)
+    .zcard(key)
+    .pexpire(key, windowMs)
+    .exec();
+  return Number(results?.[2]?.[1] ?? 0);
+}
+
+async function applyClamp(
+  userId: string,
+  rule: string,
+  details: Record<string, unknown>
+) {
+  // NX: one clamp (and one report) per episode
+  const applied = await redis.set(
+    clampKey(userId),
+    rule,
+    'EX',
+    CLAMP_SECONDS,
+    'NX'
+  );
+  if (!applied) {
+    return;
+  }
+
+  clampCounter.inc({ rule });
+  await AuditLog.record({
+    action: 'moderation.rate_clamp_applied',
+    actorType: 'system',
+    targetType: 'user',
+    targetId: userId,
+    details: { rule, clampMinutes: CLAMP_SECONDS / 60, ...details },
+  });
+  await Reports.createSystemReport(userId, 'spam', {
+    rule,
+    ...details,
+  });
+}
+
+export class AbuseDetection {
+  /**
+   * Whether the account is under a rate clamp
+   */
+  static async isClamped(userId: string): Promise<boolean> {
+    return (await redis.exists(clampKey(userId))) === 1;
+  }
+
+  /**
+   * Where the user stands against each velocity limit, without recording
+   * anything
+   */
+  static async status(userId: string): Promise<RateLimitStatus> {
+    const now = Date.now();
+    const [clampMs, factor] = await Promise.all([
+      redis.pttl(clampKey(userId)),
+      TrustScore.rateLimitFactor(userId),
+    ]);
+    const clamped = clampMs > 0;
+
+    const activities = await Promise.all(
+      (Object.keys(RULES) as AbuseActivity[]).map(async activity => {
+        const rule = RULES[activity];
+        const limit = clamped
+          ? rule.clampedLimit
+          : Math.max(1, Math.round(rule.limit * factor));
+        const key = clamped
+          ? `abuse:clamped:${activity}:${userId}`
+          : `abuse:velocity:${activity}:${userId}`;
+        const used = await redis.zcount(key, now - rule.windowMs, '+inf');
+        const allowance: ActivityAllowance = {
+          limit,
+          remaining: Math.max(limit - used, 0),
+          windowSeconds: rule.windowMs / 1000,
+        };
+        return [activity, allowance] as const;
+      })
+    );
+
+    return {
+      clamped,
+      clampExpiresAt: clamped ? new Date(now + clampMs).toISOString() : null,
+      activities: Object.fromEntries(activities) as Record<
+        AbuseActivity,
+        ActivityAllowance
+      >,
+    };
+  }
+
+  /**
+   * Record an activity and decide whether it may go ahead. Clamped accounts
+   * keep a small allowance so normal use still works.
+   */
+  static async recordActivity(
+    userId: string,
+    activity: AbuseActivity,
+    content?: string
+  ): Promise<ActivityVerdict> {
+    const rule = RULES[activity];
+    const [count, factor] = await Promise.all([
+      slidingWindowCount(`abuse:velocity:${activity}:${userId}`, rule.windowMs),
+      TrustScore.rateLimitFactor(userId),
+    ]);
+    const limit = Math.max(1, Math.round(rule.limit * factor));
+
+    if (count > limit) {
+      await applyClamp(userId, `${activity}_velocity`, {
+        count,
+        windowMinutes: rule.windowMs / MINUTE,
+      });
+    }
+
+    if (activity === 'message' && content) {
+      const hash = createHash('sha256')
+        .update(content.trim().toLowerCase())
+        .digest('hex')
+        .slice(0, 16);
+      const repeats = await slidingWindowCount(
+        `abuse:duplicate:${userId}:${hash}`,
+        60 * MINUTE
+      );
+      if (repeats >= DUPLICATE_MESSAGE_LIMIT) {
+        await applyClamp(userId, 'duplicate_messages', {
+          repeats,
+          sample: content.slice(0, 200),
+        });
+      }
+    }
+
+    const clamped = await AbuseDetection.isClamped(userId);
+    if (!clamped) {
+      return { allowed: true, clamped: false };
+    }
+
+    const clampedCount = await slidingWindowCount(
+      `abuse:clamped:${activity}:${userId}`,
+      rule.windowMs
+    );
+    return { allowed: clampedCount <= rule.clampedLimit, clamped: true };
+  }
+
+  /**
+   * Count one of the user's message requests being declined, clamping the
+   * account when too many are declined within a day
+   */
+  static async recordDeclinedRequest(userId: string): Promise<void> {
+    const declines = await slidingWindowCount(
+      `abuse:declined_requests:${userId}`,
+      24 * 60 * MINUTE
+    );
+    if (declines >= DECLINED_REQUEST_LIMIT) {
+      await applyClamp(userId, 'declined_requests', { declines });
+    }
+  }
+}
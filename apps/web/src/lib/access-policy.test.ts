@@ -0,0 +1,170 @@
+/**
+ * @description Unit tests for route access policies
+ */
+
+import { describe, it, expect, jest, beforeEach } from '@jest/globals';
+import { AccessPolicy, Subject } from '@/lib/access-policy';
+
+const mockHas = jest.fn(async (_userId: string, _feature: string) => false);
+const mockIsVerified = jest.fn(
+  async (_userId: string, _badge: string) => false
+);
+
+jest.mock('@/lib/entitlements', () => ({
+  Entitlements: {
+    has: (userId: string, feature: string) => mockHas(userId, feature),
+  },
+}));
+
+jest.mock('@/lib/verification', () => ({
+  Verification: {
+    isVerified: (userId: string, badge: string) =>
+      mockIsVerified(userId, badge),
+  },
+}));
+
+const subject = (overrides: Partial<Subject> = {}): Subject => ({
+  worldId: 'world-1',
+  userId: 'user-1',
+  verificationLevel: 'device',
+  tenantId: null,
+  impersonating: false,
+  ...overrides,
+});
+
+async function allowed(
+  method: string,
+  path: string,
+  caller: Subject = subject()
+): Promise<boolean> {
+  const decision = await AccessPolicy.evaluate(
+    caller,
+    AccessPolicy.forRoute(method, path)
+  );
+  return decision.allowed;
+}
+
+describe('AccessPolicy', () => {
+  beforeEach(() => {
+    mockHas.mockClear().mockResolvedValue(false);
+    mockIsVerified.mockClear().mockResolvedValue(true);
+    process.env.ADMIN_WORLD_IDS = 'world-admin';
+  });
+
+  it('only needs a session for routes without a policy', async () => {
+    expect(AccessPolicy.forRoute('GET', '/api/users/me')).toEqual([]);
+    expect(await allowed('GET', '/api/users/me')).toBe(true);
+  });
+
+  it('matches one path segment per parameter', () => {
+    expect(
+      AccessPolicy.forRoute('POST', '/api/matches/m1/report')
+    ).not.toEqual([]);
+    expect(
+      AccessPolicy.forRoute('POST', '/api/matches/m1/extra/report')
+    ).toEqual([]);
+    expect(AccessPolicy.forRoute('post', '/api/matches/m1/report/')).toEqual(
+      AccessPolicy.forRoute('POST', '/api/matches/m1/report')
+    );
+  });
+
+  it('blocks acting in the user name while impersonating', async () => {
+    const impersonated = subject({ impersonating: true });
+
+    expect(await allowed('POST', '/api/signals/send')).toBe(true);
+    expect(await allowed('POST', '/api/signals/send', impersonated)).toBe(
+      false
+    );
+    expect(
+      await allowed(
+        'GET',
+        '/api/users/me/privacy-requests/r1/export',
+        impersonated
+      )
+    ).toBe(false);
+    expect(await allowed('PUT', '/api/users/me/email', impersonated)).toBe(
+      false
+    );
+  });
+
+  it('gates the deck on the NFT badge', async () => {
+    mockIsVerified.mockResolvedValue(false);
+
+    const decision = await AccessPolicy.evaluate(
+      subject(),
+      AccessPolicy.forRoute('GET', '/api/discovery/profiles')
+    );
+
+    expect(decision).toEqual({
+      allowed: false,
+      denial: expect.objectContaining({
+        status: 403,
+        errorType: 'verification_required',
+        details: { badge: 'nft' },
+      }),
+    });
+    expect(mockIsVerified).toHaveBeenCalledWith('user-1', 'nft');
+  });
+
+  it('requires the entitlement a premium route names', async () => {
+    expect(await allowed('GET', '/api/users/me/likes')).toBe(false);
+
+    mockHas.mockResolvedValue(true);
+    expect(await allowed('GET', '/api/users/me/likes')).toBe(true);
+    expect(mockHas).toHaveBeenCalledWith('user-1', 'see_who_liked_me');
+  });
+
+  it('asks for a profile before checking badges or entitlements', async () => {
+    const decision = await AccessPolicy.evaluate(subject({ userId: null }), [
+      { entitlement: 'boosts' },
+    ]);
+
+    expect(decision).toEqual({
+      allowed: false,
+      denial: expect.objectContaining({ errorType: 'profile_required' }),
+    });
+    expect(mockHas).not.toHaveBeenCalled();
+  });
+
+  it('ranks World ID verification levels', async () => {
+    const rules = [{ verificationLevel: 'orb' as const }];
+
+    expect((await AccessPolicy.evaluate(subject(), rules)).allowed).toBe(
+      false
+    );
+    expect(
+      (
+        await AccessPolicy.evaluate(
+          subject({ verificationLevel: 'orb' }),
+          rules
+        )
+      ).allowed
+    ).toBe(true);
+  });
+
+  it('treats an impersonating admin as the user', async () => {
+    const rules = [{ role: 'admin' as const }];
+    const admin = subject({ worldId: 'world-admin' });
+
+    expect((await AccessPolicy.evaluate(admin, rules)).allowed).toBe(true);
+    expect(
+      (
+        await AccessPolicy.evaluate({ ...admin, impersonating: true }, rules)
+      ).allowed
+    ).toBe(false);
+  });
+
+  it('allows when any one alternative holds', async () => {
+    const rules = [
+      { anyOf: [{ role: 'admin' as const }, { tenant: ['campus-1'] }] },
+    ];
+
+    expect((await AccessPolicy.evaluate(subject(), rules)).allowed).toBe(
+      false
+    );
+    expect(
+      (await AccessPolicy.evaluate(subject({ tenantId: 'campus-1' }), rules))
+        .allowed
+    ).toBe(true);
+  });
+});
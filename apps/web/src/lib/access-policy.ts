@@ -0,0 +1,296 @@
+/**
+ * Access Policy
+ * Declarative authorization for API routes. A route's policy is a list of
+ * rules over the caller's attributes (role, World ID verification level,
+ * verification badges, entitlements, tenant, and whether an admin is
+ * impersonating them), and a request is allowed only if every rule holds.
+ * Policies live in one table, keyed by method and route, instead of checks
+ * spread across handlers; a route without an entry only needs a valid
+ * session. Attributes that cost a query (badges, entitlements) are only
+ * looked up when a rule asks for them.
+ */
+
+import { Entitlements, Feature } from './entitlements';
+import { Verification, VerificationBadge } from './verification';
+
+export type Role = 'user' | 'admin';
+
+// World ID verification levels, weakest first
+const VERIFICATION_LEVELS = ['device', 'orb'] as const;
+
+export type VerificationLevel = (typeof VERIFICATION_LEVELS)[number];
+
+export type Rule =
+  | { role: Role }
+  // At least this World ID verification level
+  | { verificationLevel: VerificationLevel }
+  | { badge: VerificationBadge }
+  | { entitlement: Feature }
+  // Members of these tenants only; null is the shared instance
+  | { tenant: Array<string | null> }
+  | { impersonation: 'deny' }
+  // Any one of the rules holding is enough
+  | { anyOf: Rule[] };
+
+// What the session token says about the caller
+export interface Subject {
+  worldId: string;
+  userId: string | null;
+  verificationLevel?: string;
+  tenantId: string | null;
+  impersonating: boolean;
+}
+
+export interface Denial {
+  status: number;
+  message: string;
+  errorType: string;
+  details?: Record<string, unknown>;
+}
+
+export type Decision = { allowed: true } | { allowed: false; denial: Denial };
+
+// Signaling, messaging and other actions taken in the user's name
+const NO_IMPERSONATION: Rule = { impersonation: 'deny' };
+
+// The NFT access gate in front of the deck and everything done from it;
+// admins can grant the badge when the automated check can't run
+const NFT_VERIFIED: Rule = { badge: 'nft' };
+
+/**
+ * Policies by "METHOD /api/path" ("*" for any method). [param] segments
+ * match any one segment.
+ */
+export const ROUTE_POLICIES: Record<string, Rule[]> = {
+  'POST /api/circles': [NO_IMPERSONATION],
+  'POST /api/circles/[id]/join': [NO_IMPERSONATION],
+  'DELETE /api/circles/[id]/join': [NO_IMPERSONATION],
+  'POST /api/circles/[id]/requests/[userId]': [NO_IMPERSONATION],
+  'POST /api/discovery/action': [NO_IMPERSONATION, NFT_VERIFIED],
+  'GET /api/discovery/profiles': [NFT_VERIFIED],
+  'GET /api/discovery/top-picks': [NFT_VERIFIED],
+  'POST /api/events/[id]/check-in': [NO_IMPERSONATION],
+  'POST /api/events/[id]/rsvp': [NO_IMPERSONATION],
+  'DELETE /api/events/[id]/rsvp': [NO_IMPERSONATION],
+  // Claiming rewards the inviter
+  'POST /api/invites/claim': [NO_IMPERSONATION],
+  'POST /api/invites/generate': [NO_IMPERSONATION],
+  'POST /api/matches/[id]/block': [NO_IMPERSONATION],
+  'POST /api/matches/[id]/call': [NO_IMPERSONATION],
+  // Includes reading the other person's released details
+  '* /api/matches/[id]/contact-exchange': [NO_IMPERSONATION],
+  // Transcripts hold the conversation
+  'GET /api/matches/[id]/export': [NO_IMPERSONATION],
+  'POST /api/matches/[id]/messages': [NO_IMPERSONATION],
+  '* /api/matches/[id]/mute': [NO_IMPERSONATION],
+  // Scheduled messages hold message text
+  '* /api/matches/[id]/scheduled-messages': [NO_IMPERSONATION],
+  'DELETE /api/matches/[id]/scheduled-messages/[scheduledId]': [
+    NO_IMPERSONATION,
+  ],
+  // Suggestions read the conversation
+  'GET /api/matches/[id]/suggestions': [NO_IMPERSONATION],
+  'POST /api/matches/[id]/report': [NO_IMPERSONATION],
+  'POST /api/quiz/answers': [NO_IMPERSONATION],
+  'POST /api/safety/check-ins': [NO_IMPERSONATION],
+  'DELETE /api/safety/check-ins/[id]': [NO_IMPERSONATION],
+  'POST /api/safety/check-ins/[id]/check-in': [NO_IMPERSONATION],
+  // Scoring sets the user's cached score
+  'POST /api/score/jobs': [NO_IMPERSONATION],
+  'POST /api/signals/send': [NO_IMPERSONATION, NFT_VERIFIED],
+  'DELETE /api/signals/[id]/message': [NO_IMPERSONATION],
+  'POST /api/speed-dating/dates/[id]/messages': [NO_IMPERSONATION],
+  'POST /api/speed-dating/dates/[id]/vote': [NO_IMPERSONATION],
+  'POST /api/speed-dating/sessions/[id]/queue': [NO_IMPERSONATION],
+  'DELETE /api/users/me': [NO_IMPERSONATION],
+  'POST /api/users/me/age-verification': [NO_IMPERSONATION],
+  'PUT /api/users/me/age-verification': [NO_IMPERSONATION],
+  // A linked chat account or push token would outlive the impersonation
+  'POST /api/users/me/chat-links': [NO_IMPERSONATION],
+  'POST /api/users/me/devices': [NO_IMPERSONATION],
+  'PUT /api/users/me/email': [NO_IMPERSONATION],
+  'GET /api/users/me/likes': [{ entitlement: 'see_who_liked_me' }],
+  // Requests hold the first message
+  'GET /api/users/me/message-requests': [NO_IMPERSONATION],
+  'POST /api/users/me/message-requests/[id]': [NO_IMPERSONATION],
+  'DELETE /api/users/me/passes': [NO_IMPERSONATION],
+  'DELETE /api/users/me/passes/[userId]': [NO_IMPERSONATION],
+  'PUT /api/users/me/photo': [NO_IMPERSONATION],
+  'DELETE /api/users/me/photo': [NO_IMPERSONATION],
+  'POST /api/users/me/policies': [NO_IMPERSONATION],
+  'POST /api/users/me/privacy-requests': [NO_IMPERSONATION],
+  // The full data export
+  'GET /api/users/me/privacy-requests/[id]/export': [NO_IMPERSONATION],
+  'PUT /api/users/me/prompts': [NO_IMPERSONATION],
+  'POST /api/users/me/streak': [NO_IMPERSONATION],
+  'PUT /api/users/me/travel-mode': [{ entitlement: 'travel_mode' }],
+  'POST /api/users/me/voice-intro': [NO_IMPERSONATION],
+  'DELETE /api/users/me/voice-intro': [NO_IMPERSONATION],
+  'POST /api/users/me/voice-intro/[id]/complete': [NO_IMPERSONATION],
+};
+
+interface CompiledPolicy {
+  method: string;
+  segments: string[];
+  rules: Rule[];
+}
+
+const compiled: CompiledPolicy[] = Object.entries(ROUTE_POLICIES).map(
+  ([key, rules]) => {
+    const [method, path] = key.split(' ');
+    return { method, segments: path.split('/'), rules };
+  }
+);
+
+function matches(policy: CompiledPolicy, method: string, segments: string[]) {
+  return (
+    (policy.method === '*' || policy.method === method) &&
+    policy.segments.length === segments.length &&
+    policy.segments.every(
+      (segment, i) =>
+        (segment.startsWith('[') && segment.endsWith(']')) ||
+        segment === segments[i]
+    )
+  );
+}
+
+function adminWorldIds(): Set<string> {
+  return new Set(
+    (process.env.ADMIN_WORLD_IDS || '')
+      .split(',')
+      .map(id => id.trim())
+      .filter(Boolean)
+  );
+}
+
+function roleOf(subject: Subject): Role {
+  // An impersonating admin acts as the user
+  return !subject.impersonating && adminWorldIds().has(subject.worldId)
+    ? 'admin'
+    : 'user';
+}
+
+function levelRank(level: string | undefined): number {
+  return VERIFICATION_LEVELS.indexOf(level as VerificationLevel);
+}
+
+const PROFILE_REQUIRED: Denial = {
+  status: 400,
+  message: 'Profile setup required',
+  errorType: 'profile_required',
+};
+
+async function check(subject: Subject, rule: Rule): Promise<Denial | null> {
+  if ('anyOf' in rule) {
+    let first: Denial | null = null;
+    for (const alternative of rule.anyOf) {
+      const denial = await check(subject, alternative);
+      if (!denial) {
+        return null;
+      }
+      first = first ?? denial;
+    }
+    return first;
+  }
+
+  if ('role' in rule) {
+    return rule.role === 'user' || roleOf(subject) === rule.role
+      ? null
+      : {
+          status: 403,
+          message: 'Admin access required',
+          errorType: 'admin_required',
+        };
+  }
+
+  if ('verificationLevel' in rule) {
+    return levelRank(subject.verificationLevel) >=
+      levelRank(rule.verificationLevel)
+      ? null
+      : {
+          status: 403,
+          message:
+            `This feature requires ${rule.verificationLevel} ` +
+            'verification',
+          errorType: 'verification_level_required',
+          details: { level: rule.verificationLevel },
+        };
+  }
+
+  if ('badge' in rule) {
+    if (!subject.userId) {
+      return PROFILE_REQUIRED;
+    }
+    return (await Verification.isVerified(subject.userId, rule.badge))
+      ? null
+      : {
+          status: 403,
+          message: `This feature requires ${rule.badge} verification`,
+          errorType: 'verification_required',
+          details: { badge: rule.badge },
+        };
+  }
+
+  if ('entitlement' in rule) {
+    if (!subject.userId) {
+      return PROFILE_REQUIRED;
+    }
+    return (await Entitlements.has(subject.userId, rule.entitlement))
+      ? null
+      : {
+          status: 403,
+          message: 'This feature requires a premium subscription',
+          errorType: 'entitlement_required',
+          details: { feature: rule.entitlement },
+        };
+  }
+
+  if ('tenant' in rule) {
+    return rule.tenant.includes(subject.tenantId)
+      ? null
+      : {
+          status: 403,
+          message: "This feature isn't available on your campus",
+          errorType: 'tenant_restricted',
+        };
+  }
+
+  return subject.impersonating
+    ? {
+        status: 403,
+        message: 'This action is disabled while impersonating',
+        errorType: 'impersonation_restricted',
+      }
+    : null;
+}
+
+export class AccessPolicy {
+  /**
+   * The rules for a request to `pathname`, or none if the route has no
+   * policy
+   */
+  static forRoute(method: string, pathname: string): Rule[] {
+    const segments = pathname.replace(/\/$/, '').split('/');
+    return compiled
+      .filter(policy => matches(policy, method.toUpperCase(), segments))
+      .flatMap(policy => policy.rules);
+  }
+
+  static roleOf(subject: Subject): Role {
+    return roleOf(subject);
+  }
+
+  /**
+   * Whether `subject` satisfies every rule, and if not, the first rule
+   * that failed
+   */
+  static async evaluate(subject: Subject, rules: Rule[]): Promise<Decision> {
+    for (const rule of rules) {
+      const denial = await check(subject, rule);
+      if (denial) {
+        return { allowed: false, denial };
+      }
+    }
+    return { allowed: true };
+  }
+}
@@ -0,0 +1,464 @@
+/**
+ * Account Data
+ * Exports everything we hold about a user and erases it on request.
+ * Erasure anonymizes the account in place: billing records and the audit
+ * trail are kept (we're required to), everything identifying goes.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { faceVectorStore } from './vector-store';
+import { RedisCache } from './redis-cache';
+import { EventBus } from './event-bus';
+import { Presence } from './presence';
+import { MediaStorage } from './media-storage';
+import { ContactExchange } from './contact-exchange';
+
+export interface AccountExport {
+  generatedAt: string;
+  profile: Record<string, unknown>;
+  [section: string]: unknown;
+}
+
+export class AccountData {
+  /**
+   * Whether the account has been erased
+   */
+  static async isErased(userId: string): Promise<boolean> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { status: true },
+    });
+    return user?.status === 'deleted';
+  }
+
+  /**
+   * Everything we hold about a user, or null if the user doesn't exist
+   */
+  static async export(userId: string): Promise<AccountExport | null> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: {
+        id: true,
+        worldId: true,
+        walletAddress: true,
+        handle: true,
+        displayName: true,
+        bio: true,
+        profileImage: true,
+        vibe: true,
+        cityId: true,
+        campusId: true,
+        tags: true,
+        email: true,
+        emailVerifiedAt: true,
+        locale: true,
+        timezone: true,
+        detectedTimezone: true,
+        quietHoursStart: true,
+        quietHoursEnd: true,
+        hideDistance: true,
+        hideLastSeen: true,
+        photoRevealMode: true,
+        aiSuggestions: true,
+        aiMessageAccess: true,
+        messageRequests: true,
+        birthDate: true,
+        ageVerifiedAt: true,
+        ageVerifyMethod: true,
+        nftVerified: true,
+        photoVerified: true,
+        status: true,
+        bannedUntil: true,
+        banReason: true,
+        deletedAt: true,
+        lastSeen: true,
+        createdAt: true,
+        cohort: true,
+        waitlistedAt: true,
+        admittedAt: true,
+        tenantId: true,
+      },
+    });
+    if (!user) {
+      return null;
+    }
+
+    const [
+      subscription,
+      entitlements,
+      payments,
+      inventory,
+      boosts,
+      signalsSent,
+      signalsReceived,
+      passes,
+      blocks,
+      matches,
+      reportsFiled,
+      notifications,
+      devices,
+      pushDevices,
+      location,
+      travelLocation,
+      quizAnswers,
+      prompts,
+      voiceIntros,
+      policyAcceptances,
+      badges,
+      safetyCheckIns,
+      contactShares,
+      eventRsvps,
+      speedDates,
+      circles,
+      streakClaims,
+      socialEdges,
+      waitlistEntry,
+      embedding,
+    ] = await Promise.all([
+      prisma.subscription.findUnique({ where: { userId } }),
+      prisma.entitlement.findMany({ where: { userId } }),
+      prisma.payment.findMany({
+        where: { userId },
+        orderBy: { createdAt: 'asc' },
+      }),
+      prisma.inventoryItem.findMany({ where: { userId } }),
+      prisma.boost.findMany({ where: { userId } }),
+      prisma.signal.findMany({
+        where: { fromUserId: userId },
+        select: {
+          toUserId: true,
+          type: true,
+          message: true,
+          sentAt: true,
+          deletedAt: true,
+        },
+        orderBy: { sentAt: 'asc' },
+      }),
+      // Other users' messages are their data; only the fact of a signal is ours
+      prisma.signal.count({ where: { toUserId: userId, suppressed: false } }),
+      prisma.pass.findMany({
+        where: { userId },
+        select: { passedUserId: true, passedAt: true, expiresAt: true },
+        orderBy: { passedAt: 'asc' },
+      }),
+      prisma.block.findMany({
+        where: { blockerId: userId },
+        select: { blockedId: true, createdAt: true },
+        orderBy: { createdAt: 'asc' },
+      }),
+      prisma.match.findMany({
+        where: { OR: [{ user1Id: userId }, { user2Id: userId }] },
+        orderBy: { matchedAt: 'asc' },
+      }),
+      prisma.report.findMany({
+        where: { reporterId: userId },
+        select: { reportedUserId: true, reason: true, createdAt: true },
+      }),
+      prisma.notification.findMany({
+        where: { userId },
+        orderBy: { createdAt: 'asc' },
+      }),
+      prisma.identitySignal.findMany({
+        where: { userId, kind: 'device' },
+        select: { value: true, firstSeenAt: true, lastSeenAt: true },
+      }),
+      prisma.device.findMany({
+        where: { userId },
+        select: {
+          platform: true,
+          locale: true,
+          appVersion: true,
+          createdAt: true,
+          lastSeenAt: true,
+        },
+      }),
+      prisma.userLocation.findUnique({
+        where: { userId },
+        select: { geohash: true, precision: true, updatedAt: true },
+      }),
+      prisma.travelLocation.findUnique({
+        where: { userId },
+        select: { city: true, startedAt: true, expiresAt: true },
+      }),
+      prisma.quizAnswer.findMany({
+        where: { userId },
+        select: {
+          questionId: true,
+          answer: true,
+          acceptable: true,
+          importance: true,
+          answeredAt: true,
+        },
+      }),
+      prisma.profilePrompt.findMany({
+        where: { userId },
+        select: {
+          promptId: true,
+          answer: true,
+          removedAt: true,
+          updatedAt: true,
+        },
+        orderBy: { position: 'asc' },
+      }),
+      prisma.voiceIntro.findMany({
+        where: { userId },
+        select: {
+          status: true,
+          durationMs: true,
+          transcript: true,
+          rejectionReason: true,
+          createdAt: true,
+        },
+        orderBy: { createdAt: 'asc' },
+      }),
+      prisma.policyAcceptance.findMany({
+        where: { userId },
+        select: {
+          policyVersion: { select: { kind: true, version: true } },
+          acceptedAt: true,
+          ipAddress: true,
+        },
+        orderBy: { acceptedAt: 'asc' },
+      }),
+      prisma.userBadge.findMany({
+        where: { userId },
+        select: { badge: true, grantedAt: true },
+      }),
+      prisma.safetyCheckIn.findMany({
+        where: { userId },
+        select: {
+          matchId: true,
+          place: true,
+          dateAt: true,
+          checkInAt: true,
+          contactName: true,
+          contactEmail: true,
+          status: true,
+          checkedInAt: true,
+          escalatedAt: true,
+          createdAt: true,
+        },
+        orderBy: { createdAt: 'asc' },
+      }),
+      ContactExchange.exportFor(userId),
+      prisma.eventRsvp.findMany({
+        where: { userId },
+        select: {
+          eventId: true,
+          createdAt: true,
+          attendedAt: true,
+        },
+        orderBy: { createdAt: 'asc' },
+      }),
+      prisma.speedDate.findMany({
+        where: { OR: [{ user1Id: userId }, { user2Id: userId }] },
+        orderBy: { startedAt: 'asc' },
+      }),
+      prisma.circleMember.findMany({
+        where: { userId },
+        select: {
+          circleId: true,
+          role: true,
+          status: true,
+          createdAt: true,
+          approvedAt: true,
+        },
+        orderBy: { createdAt: 'asc' },
+      }),
+      prisma.streakClaim.findMany({
+        where: { userId },
+        select: { day: true, streak: true, timezone: true, createdAt: true },
+        orderBy: { day: 'asc' },
+      }),
+      prisma.socialEdge.findMany({
+        where: { userId },
+        select: { kind: true, key: true, label: true, createdAt: true },
+        orderBy: { createdAt: 'asc' },
+      }),
+      prisma.waitlistEntry.findUnique({
+        where: { worldId: user.worldId },
+        select: {
+          campusId: true,
+          email: true,
+          status: true,
+          createdAt: true,
+          admittedAt: true,
+        },
+      }),
+      faceVectorStore.getUserEmbedding(userId),
+    ]);
+
+    return {
+      generatedAt: new Date().toISOString(),
+      profile: user,
+      subscription,
+      entitlements,
+      payments,
+      inventory,
+      boosts,
+      signalsSent,
+      signalsReceivedCount: signalsReceived,
+      passes,
+      blocks,
+      matches: matches.map(match => ({
+        matchId: match.id,
+        with: match.user1Id === userId ? match.user2Id : match.user1Id,
+        matchedAt: match.matchedAt,
+        status: match.status,
+        deletedAt: match.deletedAt,
+      })),
+      reportsFiled,
+      notifications,
+      devices,
+      pushDevices,
+      location,
+      travelLocation,
+      quizAnswers,
+      prompts,
+      voiceIntros,
+      policyAcceptances: policyAcceptances.map(acceptance => ({
+        kind: acceptance.policyVersion.kind,
+        version: acceptance.policyVersion.version,
+        acceptedAt: acceptance.acceptedAt,
+        ipAddress: acceptance.ipAddress,
+      })),
+      badges,
+      safetyCheckIns,
+      contactShares,
+      eventRsvps,
+      speedDates: speedDates.map(date => {
+        const isUser1 = date.user1Id === userId;
+        return {
+          sessionId: date.sessionId,
+          with: isUser1 ? date.user2Id : date.user1Id,
+          startedAt: date.startedAt,
+          vote: isUser1 ? date.vote1 : date.vote2,
+          matchId: date.matchId,
+        };
+      }),
+      circles,
+      streakClaims,
+      socialEdges,
+      waitlistEntry,
+      faceScore: embedding
+        ? { score: embedding.score ?? null, metadata: embedding.metadata }
+        : null,
+    };
+  }
+
+  /**
+   * Anonymize an account and delete its personal data. Idempotent.
+   */
+  static async erase(userId: string): Promise<void> {
+    const tombstone = `deleted:${userId}`;
+    const account = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { worldId: true },
+    });
+    const voiceIntros = await prisma.voiceIntro.findMany({
+      where: { userId },
+      select: { uploadKey: true, mediaKey: true },
+    });
+
+    await prisma.$transaction([
+      prisma.user.update({
+        where: { id: userId },
+        data: {
+          // Frees the nullifier and wallet; nothing links back to the person
+          worldId: tombstone,
+          walletAddress: tombstone,
+          handle: `deleted_${userId}`,
+          displayName: 'Deleted user',
+          bio: null,
+          profileImage: null,
+          blurredImage: null,
+          vibe: null,
+          cityId: null,
+          campusId: null,
+          tags: Prisma.DbNull,
+          birthDate: null,
+          ageVerifiedAt: null,
+          ageVerifyMethod: null,
+          email: null,
+          emailVerifiedAt: null,
+          status: 'deleted',
+        },
+      }),
+      prisma.signal.updateMany({
+        where: { fromUserId: userId },
+        data: { message: null },
+      }),
+      prisma.notification.deleteMany({ where: { userId } }),
+      prisma.identitySignal.deleteMany({ where: { userId } }),
+      prisma.emailVerification.deleteMany({ where: { userId } }),
+      prisma.emailMessage.deleteMany({ where: { userId } }),
+      prisma.chatLink.deleteMany({ where: { userId } }),
+      prisma.quizAnswer.deleteMany({ where: { userId } }),
+      prisma.profilePrompt.deleteMany({ where: { userId } }),
+      prisma.voiceIntro.deleteMany({ where: { userId } }),
+      prisma.photoHash.deleteMany({ where: { userId } }),
+      prisma.conversationExport.deleteMany({ where: { userId } }),
+      prisma.deletedMessage.deleteMany({ where: { senderId: userId } }),
+      prisma.userBadge.deleteMany({ where: { userId } }),
+      prisma.safetyCheckIn.deleteMany({ where: { userId } }),
+      prisma.contactShare.deleteMany({ where: { userId } }),
+      prisma.eventRsvp.deleteMany({ where: { userId } }),
+      prisma.circleMember.deleteMany({ where: { userId } }),
+      prisma.streakClaim.deleteMany({ where: { userId } }),
+      prisma.streak.deleteMany({ where: { userId } }),
+      prisma.topPicks.deleteMany({ where: { userId } }),
+      prisma.socialEdge.deleteMany({ where: { userId } }),
+      prisma.waitlistEntry.deleteMany({
+        where: { worldId: account?.worldId ?? tombstone },
+      }),
+      prisma.pass.deleteMany({
+        where: { OR: [{ userId }, { passedUserId: userId }] },
+      }),
+      prisma.scheduledMessage.deleteMany({
+        where: { OR: [{ senderId: userId }, { recipientId: userId }] },
+      }),
+      prisma.conversationMute.deleteMany({ where: { userId } }),
+      prisma.messageRequest.deleteMany({
+        where: { OR: [{ senderId: userId }, { recipientId: userId }] },
+      }),
+      prisma.block.deleteMany({
+        where: { OR: [{ blockerId: userId }, { blockedId: userId }] },
+      }),
+      prisma.deepLink.deleteMany({
+        where: {
+          OR: [
+            { createdBy: userId },
+            { targetType: 'profile', targetId: userId },
+          ],
+        },
+      }),
+      prisma.userLocation.deleteMany({ where: { userId } }),
+      prisma.travelLocation.deleteMany({ where: { userId } }),
+      prisma.pushDelivery.deleteMany({ where: { device: { userId } } }),
+      prisma.device.deleteMany({ where: { userId } }),
+      // Kept as a record of consent, without the IP address
+      prisma.policyAcceptance.updateMany({
+        where: { userId },
+        data: { ipAddress: null },
+      }),
+      prisma.privacyRequest.updateMany({
+        where: { userId },
+        data: { exportData: null },
+      }),
+    ]);
+
+    // Face data and media live outside the database
+    await faceVectorStore.removeEmbedding(userId);
+    for (const intro of voiceIntros) {
+      await MediaStorage.remove(intro.uploadKey);
+      if (intro.mediaKey) {
+        await MediaStorage.remove(intro.mediaKey);
+      }
+    }
+    await RedisCache.invalidateFacialScore(userId);
+    await Presence.forget(userId);
+
+    await EventBus.publish('user.erased', { userId });
+  }
+}
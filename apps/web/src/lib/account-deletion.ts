@@ -0,0 +1,181 @@
+/**
+ * Account Deletion
+ * Users delete their own accounts softly: the account, and the signals and
+ * matches it took part in, are stamped with deletedAt and disappear from
+ * everyone's view, but can be restored for a retention window. After that
+ * the purge job erases the account (see AccountData.erase) and drops the
+ * hidden signals and matches for good. Until then exports still include
+ * everything, flagged with deletedAt.
+ */
+
+import prisma from './prisma';
+import { AccountData } from './account-data';
+import { AuditLog } from './audit-log';
+import { Presence } from './presence';
+import { TravelMode } from './travel-mode';
+import { ScheduledTask } from './scheduler';
+
+export const DELETION_RETENTION_DAYS = parseInt(
+  process.env.ACCOUNT_DELETION_RETENTION_DAYS || '30'
+);
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// Accounts erased per purge run
+const PURGE_BATCH_SIZE = 100;
+
+export type DeletionResult =
+  | { status: 'deleted'; restorableUntil: Date }
+  | { status: 'already_deleted'; restorableUntil: Date }
+  | { status: 'not_found' };
+
+export type RestoreResult =
+  | { status: 'restored' }
+  | { status: 'not_deleted' }
+  | { status: 'expired' };
+
+export function restorableUntil(deletedAt: Date): Date {
+  return new Date(deletedAt.getTime() + DELETION_RETENTION_DAYS * DAY_MS);
+}
+
+const involving = (userId: string) => ({
+  signals: { OR: [{ fromUserId: userId }, { toUserId: userId }] },
+  matches: { OR: [{ user1Id: userId }, { user2Id: userId }] },
+});
+
+export class AccountDeletion {
+  /**
+   * When the user deleted their account, or null if they haven't (or it
+   * has already been erased)
+   */
+  static async deletedAt(userId: string): Promise<Date | null> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { deletedAt: true, status: true },
+    });
+    return user?.status === 'deleted' ? null : (user?.deletedAt ?? null);
+  }
+
+  /**
+   * Hide the account and everything it took part in
+   */
+  static async delete(userId: string): Promise<DeletionResult> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { deletedAt: true, status: true },
+    });
+    if (!user || user.status === 'deleted') {
+      return { status: 'not_found' };
+    }
+    if (user.deletedAt) {
+      return {
+        status: 'already_deleted',
+        restorableUntil: restorableUntil(user.deletedAt),
+      };
+    }
+
+    // The shared timestamp marks what this deletion hid, for restoring
+    const deletedAt = new Date();
+    const { signals, matches } = involving(userId);
+    await prisma.$transaction([
+      prisma.user.update({ where: { id: userId }, data: { deletedAt } }),
+      prisma.signal.updateMany({
+        where: { ...signals, deletedAt: null },
+        data: { deletedAt },
+      }),
+      prisma.match.updateMany({
+        where: { ...matches, deletedAt: null },
+        data: { deletedAt },
+      }),
+    ]);
+    await TravelMode.stop(userId);
+    await Presence.forget(userId);
+
+    await AuditLog.record({
+      action: 'user.account_deleted',
+      actorType: 'user',
+      actorId: userId,
+      targetType: 'user',
+      targetId: userId,
+    });
+    return { status: 'deleted', restorableUntil: restorableUntil(deletedAt) };
+  }
+
+  /**
+   * Bring back a deleted account within the retention window, with the
+   * signals and matches its deletion hid (but not ones hidden by the other
+   * user's own deletion)
+   */
+  static async restore(userId: string): Promise<RestoreResult> {
+    const deletedAt = await AccountDeletion.deletedAt(userId);
+    if (!deletedAt) {
+      return { status: 'not_deleted' };
+    }
+    if (restorableUntil(deletedAt) <= new Date()) {
+      return { status: 'expired' };
+    }
+
+    const { signals, matches } = involving(userId);
+    await prisma.$transaction([
+      prisma.user.update({
+        where: { id: userId },
+        data: { deletedAt: null },
+      }),
+      prisma.signal.updateMany({
+        where: { ...signals, deletedAt },
+        data: { deletedAt: null },
+      }),
+      prisma.match.updateMany({
+        where: { ...matches, deletedAt },
+        data: { deletedAt: null },
+      }),
+    ]);
+
+    await AuditLog.record({
+      action: 'user.account_restored',
+      actorType: 'user',
+      actorId: userId,
+      targetType: 'user',
+      targetId: userId,
+    });
+    return { status: 'restored' };
+  }
+
+  /**
+   * Erase accounts past the retention window and drop the signals and
+   * matches hidden for as long
+   */
+  static async purge(): Promise<{ erased: number; removed: number }> {
+    const cutoff = new Date(Date.now() - DELETION_RETENTION_DAYS * DAY_MS);
+
+    const expired = await prisma.user.findMany({
+      where: { deletedAt: { lte: cutoff }, status: { not: 'deleted' } },
+      select: { id: true },
+      take: PURGE_BATCH_SIZE,
+    });
+    for (const { id } of expired) {
+      await AccountData.erase(id);
+      await AuditLog.record({
+        action: 'user.account_purged',
+        actorType: 'system',
+        targetType: 'user',
+        targetId: id,
+      });
+    }
+
+    const [signals, matches] = await prisma.$transaction([
+      prisma.signal.deleteMany({ where: { deletedAt: { lte: cutoff } } }),
+      prisma.match.deleteMany({ where: { deletedAt: { lte: cutoff } } }),
+    ]);
+    return {
+      erased: expired.length,
+      removed: signals.count + matches.count,
+    };
+  }
+}
+
+export const accountDeletionPurge: ScheduledTask = {
+  name: 'account-deletion-purge',
+  everyMs: 60 * 60 * 1000,
+  run: () => AccountDeletion.purge(),
+};
@@ -0,0 +1,439 @@
+/**
+ * Age Verification
+ * Users give a date of birth at registration and anyone under 18 is turned
+ * away (and kept away, so retrying with another date doesn't work). Adults
+ * can then verify their age with a pluggable provider: a World ID document
+ * credential, which proves adulthood without revealing the date, or a
+ * hosted document check that reports the date of birth back through a
+ * signed webhook. Birth dates are never shown to other users; profiles
+ * carry an age range instead.
+ */
+
+import { createHmac, timingSafeEqual } from 'crypto';
+import prisma from './prisma';
+import redis from './redis';
+import { AuditLog } from './audit-log';
+import { upstreamFetch } from './http-client';
+import { Reports } from './reports';
+import { DuplicateAccounts } from './duplicate-accounts';
+import { counter } from './metrics';
+import { toStoredDate } from './field-encryption';
+
+export const MINIMUM_AGE = 18;
+
+export const AGE_VERIFICATION_METHODS = ['world_id', 'document'] as const;
+
+export type AgeVerificationMethod = (typeof AGE_VERIFICATION_METHODS)[number];
+
+// Lower bounds of the ranges shown on profiles; the last one is open-ended
+const AGE_RANGE_BOUNDS = [18, 21, 25, 30, 35];
+
+// How long a World ID stays locked out after an underage registration
+const UNDERAGE_LOCK_SECONDS = 365 * 24 * 60 * 60;
+
+// World ID verification levels backed by an identity document
+const WORLD_ID_DOCUMENT_LEVELS = ['document', 'secure_document'];
+
+const WORLD_ID_AGE_ACTION =
+  process.env.AGE_VERIFICATION_WORLD_ACTION || 'verify-age';
+
+const underageKey = (worldId: string) => `age-gate:underage:${worldId}`;
+
+const verificationCounter = counter(
+  'aurum_age_verifications_total',
+  'Age verification attempts by method and outcome'
+);
+
+export interface WorldIdProof {
+  merkle_root: string;
+  nullifier_hash: string;
+  proof: string;
+  verification_level: string;
+}
+
+export type AgeVerificationInput =
+  | { method: 'world_id'; proof: WorldIdProof }
+  | { method: 'document' };
+
+type ProviderResult =
+  | { status: 'verified'; reference: string; birthDate: Date | null }
+  | { status: 'pending'; reference: string; url: string }
+  | { status: 'failed'; reason: string };
+
+export interface AgeVerificationProvider {
+  enabled(): boolean;
+  start(userId: string, input: AgeVerificationInput): Promise<ProviderResult>;
+}
+
+export type AgeVerificationResult =
+  | { status: 'verified'; method: AgeVerificationMethod }
+  | { status: 'pending'; url: string }
+  | { status: 'failed'; reason: string }
+  | { status: 'underage' }
+  | { status: 'already_verified' }
+  | { status: 'unavailable' };
+
+export interface DocumentCheckResult {
+  sessionId: string;
+  // The user ID we started the session with
+  reference: string;
+  status: 'approved' | 'declined';
+  // YYYY-MM-DD, on approval
+  dateOfBirth?: string | null;
+  reason?: string | null;
+}
+
+/**
+ * A YYYY-MM-DD date of birth, or null if it isn't a real date
+ */
+export function parseBirthDate(value: string): Date | null {
+  const match = value.match(/^(\d{4})-(\d{2})-(\d{2})$/);
+  if (!match) {
+    return null;
+  }
+  const [year, month, day] = match.slice(1).map(Number);
+  const date = new Date(Date.UTC(year, month - 1, day));
+  if (
+    date.getUTCFullYear() !== year ||
+    date.getUTCMonth() !== month - 1 ||
+    date.getUTCDate() !== day ||
+    date.getTime() > Date.now()
+  ) {
+    return null;
+  }
+  return date;
+}
+
+/**
+ * Whole years since `birthDate`
+ */
+export function ageFrom(birthDate: Date, now: Date = new Date()): number {
+  const age = now.getUTCFullYear() - birthDate.getUTCFullYear();
+  const beforeBirthday =
+    now.getUTCMonth() < birthDate.getUTCMonth() ||
+    (now.getUTCMonth() === birthDate.getUTCMonth() &&
+      now.getUTCDate() < birthDate.getUTCDate());
+  return beforeBirthday ? age - 1 : age;
+}
+
+/**
+ * The age range other users see, e.g. "21-24" or "35+"
+ */
+export function ageRange(birthDate: Date | null): string | null {
+  if (!birthDate) {
+    return null;
+  }
+  const age = ageFrom(birthDate);
+  const lower = AGE_RANGE_BOUNDS.filter(bound => age >= bound).pop();
+  if (lower === undefined) {
+    return null;
+  }
+  const next = AGE_RANGE_BOUNDS.find(bound => bound > lower);
+  return next ? `${lower}-${next - 1}` : `${lower}+`;
+}
+
+const worldIdProvider: AgeVerificationProvider = {
+  enabled: () => Boolean(process.env.NEXT_PUBLIC_WORLDCOIN_APP_ID),
+
+  async start(_userId, input) {
+    if (input.method !== 'world_id') {
+      return { status: 'failed', reason: 'invalid_input' };
+    }
+    // Only document-backed proofs say anything about age; the action's
+    // adults-only credential requirement is set in the Developer Portal
+    if (!WORLD_ID_DOCUMENT_LEVELS.includes(input.proof.verification_level)) {
+      return { status: 'failed', reason: 'document_credential_required' };
+    }
+
+    const response = await upstreamFetch(
+      'worldcoin',
+      `https://developer.worldcoin.org/api/v1/verify/${process.env.NEXT_PUBLIC_WORLDCOIN_APP_ID}`,
+      {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ ...input.proof, action: WORLD_ID_AGE_ACTION }),
+      }
+    );
+    const result = await response.json();
+    if (!response.ok || !result.success) {
+      return { status: 'failed', reason: result.code || 'invalid_proof' };
+    }
+    return {
+      status: 'verified',
+      reference: input.proof.nullifier_hash,
+      birthDate: null,
+    };
+  },
+};
+
+const documentProvider: AgeVerificationProvider = {
+  enabled: () =>
+    Boolean(
+      process.env.AGE_VERIFICATION_DOCUMENT_URL &&
+        process.env.AGE_VERIFICATION_WEBHOOK_SECRET
+    ),
+
+  async start(userId) {
+    const baseUrl = process.env.NEXT_PUBLIC_BASE_URL || '';
+    const response = await upstreamFetch(
+      'age-verification',
+      `${process.env.AGE_VERIFICATION_DOCUMENT_URL}/sessions`,
+      {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          Authorization: `Bearer ${process.env.AGE_VERIFICATION_DOCUMENT_API_KEY}`,
+        },
+        body: JSON.stringify({
+          reference: userId,
+          checks: ['document', 'liveness'],
+          callbackUrl: `${baseUrl}/api/age-verification/webhook`,
+          returnUrl: `${baseUrl}/settings/age-verification`,
+        }),
+      }
+    );
+    if (!response.ok) {
+      throw new Error(`Document check session failed: ${response.status}`);
+    }
+    const session = (await response.json()) as { id: string; url: string };
+    return { status: 'pending', reference: session.id, url: session.url };
+  },
+};
+
+const PROVIDERS: Record<AgeVerificationMethod, AgeVerificationProvider> = {
+  world_id: worldIdProvider,
+  document: documentProvider,
+};
+
+export class AgeVerification {
+  /**
+   * Methods users can verify with right now
+   */
+  static availableMethods(): AgeVerificationMethod[] {
+    return AGE_VERIFICATION_METHODS.filter(method =>
+      PROVIDERS[method].enabled()
+    );
+  }
+
+  /**
+   * Registration gate: whether this World ID may sign up with `birthDate`.
+   * An underage attempt locks the World ID out so the date can't simply
+   * be changed and resubmitted.
+   */
+  static async checkRegistration(
+    worldId: string,
+    birthDate: Date
+  ): Promise<'allowed' | 'underage'> {
+    if (await redis.exists(underageKey(worldId))) {
+      return 'underage';
+    }
+    if (ageFrom(birthDate) >= MINIMUM_AGE) {
+      return 'allowed';
+    }
+    await redis.set(underageKey(worldId), '1', 'EX', UNDERAGE_LOCK_SECONDS);
+    verificationCounter.inc({ method: 'registration', outcome: 'underage' });
+    return 'underage';
+  }
+
+  /**
+   * Own age details, for the user themself only
+   */
+  static async getOwn(userId: string) {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { birthDate: true, ageVerifiedAt: true, ageVerifyMethod: true },
+    });
+    const birthDate = user?.birthDate ?? null;
+    return {
+      birthDate: birthDate?.toISOString().slice(0, 10) ?? null,
+      age: birthDate ? ageFrom(birthDate) : null,
+      ageRange: ageRange(birthDate),
+      verified: Boolean(user?.ageVerifiedAt),
+      verifiedAt: user?.ageVerifiedAt ?? null,
+      method: user?.ageVerifyMethod ?? null,
+      availableMethods: AgeVerification.availableMethods(),
+    };
+  }
+
+  /**
+   * Record a date of birth for an account created before registration
+   * asked for one. It can only be set once; after that, only a document
+   * check can change it. A minor's account goes to moderation.
+   */
+  static async declareBirthDate(
+    userId: string,
+    birthDate: Date
+  ): Promise<'saved' | 'already_set' | 'underage'> {
+    const { count } = await prisma.user.updateMany({
+      where: { id: userId, birthDate: null },
+      data: { birthDate: toStoredDate(birthDate) },
+    });
+    if (count === 0) {
+      return 'already_set';
+    }
+    if (ageFrom(birthDate) >= MINIMUM_AGE) {
+      return 'saved';
+    }
+    verificationCounter.inc({ method: 'declared', outcome: 'underage' });
+    await Reports.createSystemReport(userId, 'underage', {
+      source: 'declared_birth_date',
+    });
+    return 'underage';
+  }
+
+  /**
+   * Start verifying the user's age with the method they picked
+   */
+  static async start(
+    userId: string,
+    input: AgeVerificationInput
+  ): Promise<AgeVerificationResult> {
+    const provider = PROVIDERS[input.method];
+    if (!provider.enabled()) {
+      return { status: 'unavailable' };
+    }
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { ageVerifiedAt: true },
+    });
+    if (user?.ageVerifiedAt) {
+      return { status: 'already_verified' };
+    }
+
+    const result = await provider.start(userId, input);
+    if (result.status === 'failed') {
+      verificationCounter.inc({ method: input.method, outcome: 'failed' });
+      return result;
+    }
+    if (result.status === 'pending') {
+      return { status: 'pending', url: result.url };
+    }
+    if (input.method === 'world_id') {
+      // One credential verifying several accounts points at one person
+      await DuplicateAccounts.recordSignal(
+        userId,
+        'age_credential',
+        result.reference
+      );
+    }
+    return AgeVerification.complete(
+      userId,
+      input.method,
+      result.reference,
+      result.birthDate
+    );
+  }
+
+  /**
+   * Record a successful check. A verified date of birth replaces the
+   * declared one; if it shows the user is a minor, the account isn't
+   * verified and goes to moderation instead.
+   */
+  static async complete(
+    userId: string,
+    method: AgeVerificationMethod,
+    reference: string,
+    birthDate: Date | null
+  ): Promise<AgeVerificationResult> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { birthDate: true },
+    });
+    if (!user) {
+      return { status: 'failed', reason: 'unknown_user' };
+    }
+
+    if (birthDate && ageFrom(birthDate) < MINIMUM_AGE) {
+      verificationCounter.inc({ method, outcome: 'underage' });
+      await Reports.createSystemReport(userId, 'underage', {
+        source: 'age_verification',
+        method,
+        reference,
+      });
+      await AuditLog.recordSafely({
+        action: 'user.age_verification_underage',
+        actorType: 'provider',
+        actorId: method,
+        targetType: 'user',
+        targetId: userId,
+        details: { reference },
+      });
+      return { status: 'underage' };
+    }
+
+    await prisma.user.update({
+      where: { id: userId },
+      data: {
+        ageVerifiedAt: new Date(),
+        ageVerifyMethod: method,
+        ...(birthDate && { birthDate: toStoredDate(birthDate) }),
+      },
+    });
+    verificationCounter.inc({ method, outcome: 'verified' });
+    await AuditLog.recordSafely({
+      action: 'user.age_verified',
+      actorType: 'provider',
+      actorId: method,
+      targetType: 'user',
+      targetId: userId,
+      details: {
+        reference,
+        birthDateCorrected: Boolean(
+          birthDate &&
+            user.birthDate &&
+            toStoredDate(birthDate) !== toStoredDate(user.birthDate)
+        ),
+      },
+    });
+    return { status: 'verified', method };
+  }
+
+  /**
+   * Check a document provider webhook's HMAC-SHA256 signature (hex, over
+   * the raw body) and parse it
+   */
+  static verifyDocumentWebhook(
+    payload: string,
+    signature: string | null
+  ): DocumentCheckResult {
+    const secret = process.env.AGE_VERIFICATION_WEBHOOK_SECRET;
+    if (!secret || !signature) {
+      throw new Error('Missing age verification webhook signature');
+    }
+    const expected = Buffer.from(
+      createHmac('sha256', secret).update(payload).digest('hex')
+    );
+    const candidate = Buffer.from(signature);
+    if (
+      candidate.length !== expected.length ||
+      !timingSafeEqual(candidate, expected)
+    ) {
+      throw new Error('Invalid age verification webhook signature');
+    }
+    return JSON.parse(payload) as DocumentCheckResult;
+  }
+
+  /**
+   * Apply a document check's outcome
+   */
+  static async handleDocumentResult(
+    result: DocumentCheckResult
+  ): Promise<AgeVerificationResult> {
+    if (result.status !== 'approved') {
+      verificationCounter.inc({ method: 'document', outcome: 'failed' });
+      return { status: 'failed', reason: result.reason || 'declined' };
+    }
+    const birthDate = result.dateOfBirth
+      ? parseBirthDate(result.dateOfBirth)
+      : null;
+    if (!birthDate) {
+      return { status: 'failed', reason: 'missing_date_of_birth' };
+    }
+    return AgeVerification.complete(
+      result.reference,
+      'document',
+      result.sessionId,
+      birthDate
+    );
+  }
+}
@@ -0,0 +1,168 @@
+/**
+ * Analytics
+ * Product metrics rolled up from the domain event stream into daily
+ * warehouse rows, so stats never scan the OLTP tables. Active users are
+ * counted with per-day HyperLogLogs.
+ */
+
+import prisma from './prisma';
+import redis from './redis';
+import { EVENT_STREAM_KEY, DomainEvent } from './event-bus';
+import { ScheduledTask } from './scheduler';
+
+export type AnalyticsMetric =
+  | 'dau'
+  | 'wau'
+  | 'signups'
+  | 'signals'
+  | 'matches'
+  | 'messages'
+  | 'screen_views';
+
+// Event type -> metric, and which payload field (if any) is the dimension
+const EVENT_METRICS: Record<
+  string,
+  { metric: AnalyticsMetric; dimension?: string }
+> = {
+  'user.signed_up': { metric: 'signups', dimension: 'authMethod' },
+  'signal.sent': { metric: 'signals', dimension: 'type' },
+  'match.created': { metric: 'matches' },
+  'message.sent': { metric: 'messages' },
+  'client.screen_view': { metric: 'screen_views', dimension: 'screen' },
+};
+
+const CHECKPOINT_NAME = 'event-stream';
+const STREAM_BATCH_SIZE = 1000;
+
+// Active-user sets must outlive the 7-day WAU window
+const ACTIVE_SET_TTL = 35 * 24 * 60 * 60;
+
+/**
+ * UTC day (YYYY-MM-DD) for a date
+ */
+export function analyticsDay(date: Date): string {
+  return date.toISOString().slice(0, 10);
+}
+
+const activeKey = (day: string) => `analytics:active:${day}`;
+
+function previousDays(day: string, count: number): string[] {
+  const end = Date.parse(`${day}T00:00:00Z`);
+  return Array.from({ length: count }, (_, i) =>
+    analyticsDay(new Date(end - i * 24 * 60 * 60 * 1000))
+  );
+}
+
+async function setDailyValue(
+  date: string,
+  metric: AnalyticsMetric,
+  value: number
+) {
+  await prisma.analyticsDaily.upsert({
+    where: { date_metric_dimension: { date, metric, dimension: '' } },
+    create: { date, metric, dimension: '', value },
+    update: { value },
+  });
+}
+
+export class Analytics {
+  /**
+   * Count a user as active today. Never throws.
+   */
+  static async recordActive(userId: string): Promise<void> {
+    try {
+      const key = activeKey(analyticsDay(new Date()));
+      await redis.pfadd(key, userId);
+      await redis.expire(key, ACTIVE_SET_TTL);
+    } catch (error) {
+      console.error('Error recording active user:', error);
+    }
+  }
+
+  /**
+   * Fold new stream events into daily rows and refresh active-user counts
+   */
+  static async rollup(): Promise<{ events: number }> {
+    const checkpoint = await prisma.analyticsCheckpoint.findUnique({
+      where: { name: CHECKPOINT_NAME },
+    });
+    let position = checkpoint?.position || '0';
+    let processed = 0;
+
+    for (;;) {
+      const entries = await redis.xrange(
+        EVENT_STREAM_KEY,
+        `(${position}`,
+        '+',
+        'COUNT',
+        STREAM_BATCH_SIZE
+      );
+      if (entries.length === 0) {
+        break;
+      }
+
+      const counts = new Map<string, number>();
+      entries.forEach(([, fields]) => {
+        const body = fields[fields.indexOf('event') + 1];
+        const event = JSON.parse(body) as DomainEvent<Record<string, unknown>>;
+        const mapping = EVENT_METRICS[event.type];
+        if (!mapping) {
+          return;
+        }
+        const dimension = mapping.dimension
+          ? String(event.payload[mapping.dimension] ?? 'unknown')
+          : '';
+        const key = [
+          analyticsDay(new Date(event.occurredAt)),
+          mapping.metric,
+          dimension,
+        ].join('|');
+        counts.set(key, (counts.get(key) || 0) + 1);
+      });
+
+      position = entries[entries.length - 1][0];
+      // Counts and checkpoint commit together so no batch is counted twice
+      await prisma.$transaction([
+        ...Array.from(counts.entries()).map(([key, value]) => {
+          const [date, metric, dimension] = key.split('|');
+          return prisma.analyticsDaily.upsert({
+            where: { date_metric_dimension: { date, metric, dimension } },
+            create: { date, metric, dimension, value },
+            update: { value: { increment: value } },
+          });
+        }),
+        prisma.analyticsCheckpoint.upsert({
+          where: { name: CHECKPOINT_NAME },
+          create: { name: CHECKPOINT_NAME, position },
+          update: { position },
+        }),
+      ]);
+      processed += entries.length;
+
+      if (entries.length < STREAM_BATCH_SIZE) {
+        break;
+      }
+    }
+
+    // Yesterday is refreshed too so late activity before midnight lands
+    const today = analyticsDay(new Date());
+    for (const day of previousDays(today, 2)) {
+      const [dau, wau] = await Promise.all([
+        redis.pfcount(activeKey(day)),
+        redis.pfcount(...previousDays(day, 7).map(activeKey)),
+      ]);
+      await Promise.all([
+        setDailyValue(day, 'dau', dau),
+        setDailyValue(day, 'wau', wau),
+      ]);
+    }
+
+    return { events: processed };
+  }
+}
+
+export const analyticsRollup: ScheduledTask = {
+  name: 'analytics-rollup',
+  everyMs: parseInt(process.env.ANALYTICS_ROLLUP_INTERVAL_MS || '300000'),
+  run: () => Analytics.rollup(),
+};
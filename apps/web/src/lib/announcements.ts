@@ -0,0 +1,267 @@
+/**
+ * Announcements
+ * Admin broadcasts (maintenance notices, event invites) delivered to the
+ * notification center, and optionally by push, at a scheduled time. The
+ * audience can be narrowed by verification, city and plan.
+ */
+
+import { Announcement, Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { Plan, PLANS } from './entitlements';
+import { Notifications } from './notifications';
+import { sendPushNotification } from './push-notifications';
+import { Devices } from './devices';
+import { Scheduler, ScheduledTask } from './scheduler';
+import { AuditLog } from './audit-log';
+import { EventBus } from './event-bus';
+import { verifiedWhere } from './verification';
+
+export const ANNOUNCEMENT_KINDS = ['maintenance', 'event', 'general'] as const;
+
+export type AnnouncementKind = (typeof ANNOUNCEMENT_KINDS)[number];
+
+export interface AnnouncementAudience {
+  // NFT-verified users only (or unverified only when false)
+  verified?: boolean;
+  // Directory city IDs
+  cities?: string[];
+  plans?: Plan[];
+}
+
+export interface AnnouncementInput {
+  kind: AnnouncementKind;
+  title: string;
+  body: string;
+  path?: string;
+  audience: AnnouncementAudience;
+  push: boolean;
+  // Omit to send right away
+  sendAt?: Date;
+}
+
+interface AnnouncementJob {
+  announcementId: string;
+}
+
+const PAID_PLANS = (Object.keys(PLANS) as Plan[]).filter(
+  plan => plan !== 'free'
+);
+
+// Recipients are loaded and notified this many at a time
+const DELIVERY_BATCH_SIZE = 500;
+
+const deliveryJobId = (announcementId: string) =>
+  `announcement-${announcementId}`;
+
+/**
+ * Users with a paid plan that is currently in effect (including grace)
+ */
+function paidPlanWhere(plans: Plan[]): Prisma.UserWhereInput {
+  const now = new Date();
+  return {
+    subscription: {
+      plan: { in: plans },
+      status: { in: ['active', 'past_due'] },
+      OR: [
+        { expiresAt: null },
+        { expiresAt: { gt: now } },
+        { status: 'past_due', graceEndsAt: { gt: now } },
+      ],
+    },
+  };
+}
+
+function audienceWhere(audience: AnnouncementAudience): Prisma.UserWhereInput {
+  const filters: Prisma.UserWhereInput[] = [
+    { status: 'active', deletedAt: null },
+  ];
+
+  if (audience.verified !== undefined) {
+    filters.push(verifiedWhere('nft', audience.verified));
+  }
+  if (audience.cities?.length) {
+    filters.push({ cityId: { in: audience.cities } });
+  }
+  if (audience.plans?.length) {
+    const paid = audience.plans.filter(plan => plan !== 'free');
+    const includesFree = audience.plans.includes('free');
+    if (!includesFree) {
+      filters.push(paidPlanWhere(paid));
+    } else if (paid.length === 0) {
+      filters.push({ NOT: paidPlanWhere(PAID_PLANS) });
+    } else {
+      // Free users plus the listed paid plans
+      filters.push({
+        OR: [paidPlanWhere(paid), { NOT: paidPlanWhere(PAID_PLANS) }],
+      });
+    }
+  }
+
+  return { AND: filters };
+}
+
+export class Announcements {
+  /**
+   * Create an announcement and schedule its delivery
+   */
+  static async create(
+    input: AnnouncementInput,
+    adminId: string
+  ): Promise<Announcement> {
+    const announcement = await prisma.announcement.create({
+      data: {
+        kind: input.kind,
+        title: input.title,
+        body: input.body,
+        path: input.path,
+        audience: input.audience as Prisma.InputJsonValue,
+        push: input.push,
+        sendAt: input.sendAt || new Date(),
+        createdBy: adminId,
+      },
+    });
+
+    await Scheduler.scheduleAt(
+      announcementDelivery.name,
+      { announcementId: announcement.id },
+      announcement.sendAt,
+      deliveryJobId(announcement.id)
+    );
+
+    await AuditLog.record({
+      action: 'admin.announcement_created',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'announcement',
+      targetId: announcement.id,
+      details: {
+        kind: input.kind,
+        title: input.title,
+        audience: input.audience,
+        push: input.push,
+        sendAt: announcement.sendAt.toISOString(),
+      },
+    });
+    return announcement;
+  }
+
+  /**
+   * Cancel an announcement that hasn't gone out yet. Returns false if it
+   * doesn't exist or is already being delivered.
+   */
+  static async cancel(
+    announcementId: string,
+    adminId: string
+  ): Promise<boolean> {
+    const canceled = await prisma.announcement.updateMany({
+      where: { id: announcementId, status: 'scheduled' },
+      data: { status: 'canceled' },
+    });
+    if (canceled.count === 0) {
+      return false;
+    }
+
+    await Scheduler.cancel(deliveryJobId(announcementId));
+    await AuditLog.record({
+      action: 'admin.announcement_canceled',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'announcement',
+      targetId: announcementId,
+    });
+    return true;
+  }
+
+  /**
+   * Announcements, most recently scheduled first
+   */
+  static async list(limit: number, status?: string, cursor?: string) {
+    return prisma.announcement.findMany({
+      where: status ? { status } : {},
+      orderBy: [{ sendAt: 'desc' }, { id: 'desc' }],
+      take: limit,
+      ...(cursor && { cursor: { id: cursor }, skip: 1 }),
+    });
+  }
+
+  /**
+   * Deliver a scheduled announcement to everyone in its audience
+   */
+  static async deliver(
+    announcementId: string
+  ): Promise<{ recipients: number } | { skipped: string }> {
+    // Claim it so a retried job can't deliver twice
+    const claimed = await prisma.announcement.updateMany({
+      where: { id: announcementId, status: 'scheduled' },
+      data: { status: 'sending' },
+    });
+    if (claimed.count === 0) {
+      return { skipped: 'not_scheduled' };
+    }
+
+    const announcement = (await prisma.announcement.findUnique({
+      where: { id: announcementId },
+    }))!;
+    const where = audienceWhere(
+      announcement.audience as unknown as AnnouncementAudience
+    );
+
+    let recipients = 0;
+    let cursor: string | undefined;
+    for (;;) {
+      const users = await prisma.user.findMany({
+        where,
+        select: { id: true, walletAddress: true },
+        orderBy: { id: 'asc' },
+        take: DELIVERY_BATCH_SIZE,
+        ...(cursor && { cursor: { id: cursor }, skip: 1 }),
+      });
+      if (users.length === 0) {
+        break;
+      }
+
+      await Notifications.createMany(users.map(user => user.id), {
+        type: 'announcement',
+        title: announcement.title,
+        body: announcement.body,
+        path: announcement.path,
+        data: { announcementId, kind: announcement.kind },
+      });
+      if (announcement.push) {
+        await sendPushNotification(users.map(user => user.walletAddress), {
+          title: announcement.title,
+          message: announcement.body,
+          path: announcement.path || undefined,
+        });
+        await Devices.push(users.map(user => user.id), {
+          type: 'announcement',
+          title: announcement.title,
+          message: announcement.body,
+          path: announcement.path || undefined,
+        });
+      }
+
+      recipients += users.length;
+      cursor = users[users.length - 1].id;
+      if (users.length < DELIVERY_BATCH_SIZE) {
+        break;
+      }
+    }
+
+    await prisma.announcement.update({
+      where: { id: announcementId },
+      data: { status: 'sent', sentAt: new Date(), recipientCount: recipients },
+    });
+    await EventBus.publish('announcement.sent', {
+      announcementId,
+      kind: announcement.kind,
+      recipients,
+    });
+    return { recipients };
+  }
+}
+
+export const announcementDelivery: ScheduledTask<AnnouncementJob> = {
+  name: 'announcement-delivery',
+  run: ({ announcementId }) => Announcements.deliver(announcementId),
+};
@@ -0,0 +1,72 @@
+/**
+ * API Client
+ * Typed fetch client for the miniapp, generated from openapi.yaml (run
+ * `make sdk` after changing the spec). Paths, params, bodies and responses
+ * are checked at compile time, so a route change that isn't reflected in
+ * the spec and its callers fails the build.
+ */
+
+import createClient, { Middleware } from 'openapi-fetch';
+import type { components, paths } from './schema';
+import { APP_VERSION_HEADER } from '../app-version';
+
+export type ApiSchemas = components['schemas'];
+export type ApiError = ApiSchemas['Error'];
+export type UpgradeRequired = ApiSchemas['UpgradeRequired'];
+
+export interface ApiClientOptions {
+  // Defaults to the page's origin
+  baseUrl?: string;
+  // Sent as X-App-Version so the API can fence off outdated builds
+  appVersion?: string;
+  // The session cookie is missing, expired or revoked
+  onSessionExpired?: () => void;
+  // This build is below the minimum supported version
+  onUpgradeRequired?: (details: UpgradeRequired) => void;
+}
+
+/**
+ * Session and version handling shared by every request: the session is an
+ * httpOnly cookie, so requests just carry credentials, and 401/426
+ * responses are surfaced to the app through the callbacks
+ */
+function sessionMiddleware(options: ApiClientOptions): Middleware {
+  return {
+    onRequest({ request }) {
+      if (options.appVersion) {
+        request.headers.set(APP_VERSION_HEADER, options.appVersion);
+      }
+      return request;
+    },
+    async onResponse({ response }) {
+      if (response.status === 401) {
+        options.onSessionExpired?.();
+      } else if (response.status === 426 && options.onUpgradeRequired) {
+        options.onUpgradeRequired(await response.clone().json());
+      }
+      return response;
+    },
+  };
+}
+
+export function createApiClient(options: ApiClientOptions = {}) {
+  const client = createClient<paths>({
+    baseUrl: options.baseUrl ?? '',
+    credentials: 'include',
+  });
+  client.use(sessionMiddleware(options));
+  return client;
+}
+
+export type ApiClient = ReturnType<typeof createApiClient>;
+
+/**
+ * Whether a failed response body carries the given error_type
+ */
+export function hasErrorType(error: unknown, errorType: string): boolean {
+  return (
+    typeof error === 'object' &&
+    error !== null &&
+    (error as ApiError).error_type === errorType
+  );
+}
@@ -0,0 +1,74 @@
+/**
+ * App Version
+ * Server-side fencing of old miniapp builds. Clients send their version in
+ * the X-App-Version header; builds below APP_MIN_VERSION are refused with a
+ * 426 so they can prompt a forced upgrade, and builds below
+ * APP_RECOMMENDED_VERSION get a soft upgrade nudge via /api/meta/config.
+ * Requests without the header (webhooks, the admin dashboard, builds that
+ * predate it) are let through. Kept free of Node-only imports so the edge
+ * middleware can use it.
+ */
+
+export const APP_VERSION_HEADER = 'x-app-version';
+
+export type AppVersionStatus =
+  | 'current'
+  | 'upgrade_recommended'
+  | 'upgrade_required';
+
+/**
+ * "1.4.2" -> [1, 4, 2]; missing parts count as 0, and a pre-release or
+ * build suffix ("1.4.2-beta.1") is ignored. Null if it isn't a version.
+ */
+export function parseVersion(version: string): number[] | null {
+  const match = version.trim().match(/^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?/);
+  if (!match) {
+    return null;
+  }
+  return [match[1], match[2], match[3]].map(part => parseInt(part || '0'));
+}
+
+/**
+ * Negative if a is older than b, positive if newer, 0 if the same
+ */
+export function compareVersions(a: number[], b: number[]): number {
+  for (let index = 0; index < 3; index++) {
+    if (a[index] !== b[index]) {
+      return a[index] - b[index];
+    }
+  }
+  return 0;
+}
+
+export function minimumVersion(): string | null {
+  return process.env.APP_MIN_VERSION || null;
+}
+
+export function recommendedVersion(): string | null {
+  return process.env.APP_RECOMMENDED_VERSION || null;
+}
+
+export class AppVersion {
+  /**
+   * Where a client version stands against the configured minimum and
+   * recommended versions. Unknown or unparseable versions count as current.
+   */
+  static status(version: string | null | undefined): AppVersionStatus {
+    const parsed = version ? parseVersion(version) : null;
+    if (!parsed) {
+      return 'current';
+    }
+
+    const isBelow = (configured: string | null) => {
+      const target = configured ? parseVersion(configured) : null;
+      return target !== null && compareVersions(parsed, target) < 0;
+    };
+    if (isBelow(minimumVersion())) {
+      return 'upgrade_required';
+    }
+    if (isBelow(recommendedVersion())) {
+      return 'upgrade_recommended';
+    }
+    return 'current';
+  }
+}
@@ -13,6 +13,7 @@ import {
 import { VibeClusterer } from './vibe-clustering';
 import { MLProcessingResult } from './ml-models/model-integration';
 import { mlServiceClient } from './ml-service-client';
+import { getMLClientForVersion } from './ml-model-routing';
 
 export interface ScoringRequest {
   userId: string;
@@ -21,6 +22,7 @@ export interface ScoringRequest {
     nftVerified?: boolean;
     wldVerified?: boolean;
     timestamp?: string;
+    modelVersion?: string; // ML API model version to route to
   };
 }
 
@@ -37,6 +39,7 @@ export interface ScoringResult {
     totalUsers: number;
     userRank: number;
     confidence: number;
+    modelVersion?: string;
   };
   distribution?: ScoreDistribution;
 }
@@ -129,7 +132,8 @@ export class AttractivenessEngineV2 {
         try {
           // Use standalone ML service for processing
           console.log('Processing image with standalone ML service...');
-          const mlResult = await mlServiceClient.processImage(
+          const mlClient = getMLClientForVersion(request.metadata?.modelVersion);
+          const mlResult = await mlClient.processImage(
             request.imageBase64
           );
 
@@ -140,7 +144,7 @@ export class AttractivenessEngineV2 {
           }
 
           // Validate the ML result quality
-          const validation = mlServiceClient.validateResult(mlResult);
+          const validation = mlClient.validateResult(mlResult);
           if (!validation.isValid) {
             throw new Error(
               `ML processing validation failed: ${validation.reason}`
@@ -200,6 +204,7 @@ export class AttractivenessEngineV2 {
           frontality: processedFace.frontality,
           symmetry: processedFace.symmetry,
           resolution: processedFace.resolution,
+          modelVersion: request.metadata?.modelVersion,
         },
       };
 
@@ -246,6 +251,7 @@ export class AttractivenessEngineV2 {
           totalUsers,
           userRank,
           confidence,
+          modelVersion: request.metadata?.modelVersion,
         },
         distribution,
       };
@@ -292,6 +298,7 @@ export class AttractivenessEngineV2 {
             },
             totalUsers
           ),
+          modelVersion: userEmbedding.metadata.modelVersion,
         },
         distribution,
       };
@@ -0,0 +1,96 @@
+/**
+ * Audit Log
+ * Append-only trail of sensitive operations. Entries are only ever
+ * inserted; database triggers reject updates, and deletes of entries less
+ * than a year old, which leaves older ones to retention (see
+ * lib/data-retention).
+ */
+
+import { AuditLog as AuditLogEntry, Prisma } from '@prisma/client';
+import prisma from './prisma';
+
+export type AuditActorType = 'system' | 'user' | 'admin' | 'provider';
+
+export interface AuditEntry {
+  action: string;
+  actorType: AuditActorType;
+  actorId?: string | null;
+  targetType?: string;
+  targetId?: string;
+  details?: Record<string, unknown>;
+  ipAddress?: string | null;
+}
+
+export interface AuditQuery {
+  actorType?: AuditActorType;
+  actorId?: string;
+  targetType?: string;
+  targetId?: string;
+  // Exact action, or a prefix ending in "." (e.g. "billing.")
+  action?: string;
+  from?: Date;
+  to?: Date;
+  limit: number;
+  cursor?: string;
+}
+
+export class AuditLog {
+  /**
+   * Append an entry
+   */
+  static async record(entry: AuditEntry): Promise<void> {
+    await prisma.auditLog.create({
+      data: {
+        action: entry.action,
+        actorType: entry.actorType,
+        actorId: entry.actorId ?? null,
+        targetType: entry.targetType ?? null,
+        targetId: entry.targetId ?? null,
+        details: entry.details as Prisma.InputJsonValue | undefined,
+        ipAddress: entry.ipAddress ?? null,
+      },
+    });
+  }
+
+  /**
+   * Append an entry without letting a failure break the caller. For
+   * operations whose outcome shouldn't depend on the audit write.
+   */
+  static async recordSafely(entry: AuditEntry): Promise<void> {
+    try {
+      await AuditLog.record(entry);
+    } catch (error) {
+      console.error(`Error recording ${entry.action} audit entry:`, error);
+    }
+  }
+
+  /**
+   * Filtered entries, newest first
+   */
+  static async query(filters: AuditQuery): Promise<AuditLogEntry[]> {
+    const where: Prisma.AuditLogWhereInput = {
+      ...(filters.actorType && { actorType: filters.actorType }),
+      ...(filters.actorId && { actorId: filters.actorId }),
+      ...(filters.targetType && { targetType: filters.targetType }),
+      ...(filters.targetId && { targetId: filters.targetId }),
+      ...(filters.action && {
+        action: filters.action.endsWith('.')
+          ? { startsWith: filters.action }
+          : filters.action,
+      }),
+      ...((filters.from || filters.to) && {
+        createdAt: {
+          ...(filters.from && { gte: filters.from }),
+          ...(filters.to && { lte: filters.to }),
+        },
+      }),
+    };
+
+    return prisma.auditLog.findMany({
+      where,
+      orderBy: [{ createdAt: 'desc' }, { id: 'desc' }],
+      take: filters.limit,
+      ...(filters.cursor && { cursor: { id: filters.cursor }, skip: 1 }),
+    });
+  }
+}
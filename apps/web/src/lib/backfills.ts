@@ -0,0 +1,467 @@
+/**
+ * Backfills
+ * Long-running jobs an admin launches to bring existing data up to date:
+ * recomputing trust scores, regenerating photo reveal variants, and
+ * re-verifying NFT holdings. A job walks its users in ID order, a slice of
+ * a minute or so at a time on the scheduler queue, saving its cursor and
+ * progress as it goes, so it can be paused, resumed and survive restarts.
+ * Items are paced to the job's rate, which is capped per upstream the
+ * kind calls (chain RPC, media storage, the database), and only one job
+ * per upstream runs at a time, so a backfill can't eat the quota live
+ * traffic needs. An item that fails is counted and skipped.
+ */
+
+import { randomUUID } from 'crypto';
+import { BackfillJob, Prisma } from '@prisma/client';
+import { Job } from 'bullmq';
+import prisma from './prisma';
+import redis from './redis';
+import { AuditLog } from './audit-log';
+import { ScheduledTask, Scheduler } from './scheduler';
+import { TrustScore } from './trust-score';
+import { PhotoReveal, REVEAL_STAGES } from './photo-reveal';
+import { mediaStorageEnabled } from './media-storage';
+import { NftOwnership } from './nft-ownership';
+import { Tenants } from './tenants';
+import { Verification } from './verification';
+
+export const BACKFILL_KINDS = [
+  'trust_scores',
+  'photo_variants',
+  'nft_reverify',
+] as const;
+
+export type BackfillKind = (typeof BACKFILL_KINDS)[number];
+
+export type BackfillAction = 'pause' | 'resume' | 'cancel';
+
+type Upstream = 'database' | 'media' | 'rpc';
+
+// Highest rate (items per second) any job may run at against each upstream
+const MAX_RATES: Record<Upstream, number> = {
+  database: parseFloat(process.env.BACKFILL_MAX_RATE_DATABASE || '50'),
+  media: parseFloat(process.env.BACKFILL_MAX_RATE_MEDIA || '5'),
+  rpc: parseFloat(process.env.BACKFILL_MAX_RATE_RPC || '5'),
+};
+
+const AUDIT_ACTIONS: Record<BackfillAction, string> = {
+  pause: 'admin.backfill_paused',
+  resume: 'admin.backfill_resumed',
+  cancel: 'admin.backfill_canceled',
+};
+
+const TASK_NAME = 'backfill-slice';
+
+// How long one slice runs before handing over to the next
+const SLICE_MS = 60 * 1000;
+// Items fetched per query; progress is saved after each batch
+const BATCH_SIZE = 25;
+// Longer than a slice plus a slow batch
+const LOCK_SECONDS = 10 * 60;
+// A slice that finds the previous one still finishing tries again after
+const LOCK_RETRY_MS = 5 * 1000;
+
+interface BackfillDefinition {
+  description: string;
+  upstream: Upstream;
+  // Why the kind can't run here, if it can't
+  unavailable(): string | null;
+  // IDs of the next items after `cursor`, in ID order
+  next(cursor: string | null, take: number): Promise<string[]>;
+  count(): Promise<number>;
+  process(id: string): Promise<void>;
+}
+
+interface SliceData {
+  backfillId: string;
+  runId: string;
+  slice: number;
+}
+
+export interface BackfillView extends BackfillJob {
+  description: string;
+  upstream: Upstream;
+  // 0-100; an estimate, as users come and go while it runs
+  percent: number;
+}
+
+export type BackfillResult =
+  | { status: 'ok'; job: BackfillView }
+  | { status: 'not_found' }
+  | { status: 'unavailable'; reason: string }
+  | { status: 'upstream_busy'; runningId: string }
+  | { status: 'invalid_transition'; current: string };
+
+function idsAfter(cursor: string | null) {
+  return cursor ? { gt: cursor } : undefined;
+}
+
+const ACTIVE_USERS = { status: 'active', deletedAt: null };
+
+const PHOTO_REVEAL_USERS = {
+  ...ACTIVE_USERS,
+  photoRevealMode: true,
+  profileImage: { not: null },
+};
+
+const NFT_VERIFIED_USERS = { ...ACTIVE_USERS, nftVerified: true };
+
+const BACKFILLS: Record<BackfillKind, BackfillDefinition> = {
+  trust_scores: {
+    description: "Recompute every active user's trust score",
+    upstream: 'database',
+    unavailable: () => null,
+    next: async (cursor, take) =>
+      (
+        await prisma.user.findMany({
+          where: { ...ACTIVE_USERS, id: idsAfter(cursor) },
+          select: { id: true },
+          orderBy: { id: 'asc' },
+          take,
+        })
+      ).map(user => user.id),
+    count: () => prisma.user.count({ where: ACTIVE_USERS }),
+    process: async id => {
+      await TrustScore.refresh(id);
+    },
+  },
+  photo_variants: {
+    description:
+      'Regenerate the stored blurred photo variants of photo reveal users',
+    upstream: 'media',
+    unavailable: () =>
+      mediaStorageEnabled() ? null : 'Media storage is not configured',
+    next: async (cursor, take) =>
+      (
+        await prisma.user.findMany({
+          where: { ...PHOTO_REVEAL_USERS, id: idsAfter(cursor) },
+          select: { id: true },
+          orderBy: { id: 'asc' },
+          take,
+        })
+      ).map(user => user.id),
+    count: () => prisma.user.count({ where: PHOTO_REVEAL_USERS }),
+    process: async id => {
+      const user = await prisma.user.findUnique({
+        where: { id },
+        select: { profileImage: true },
+      });
+      if (!user?.profileImage) {
+        return;
+      }
+      // Unblurred stages serve the photo itself
+      for (let stage = 0; stage < REVEAL_STAGES.length; stage++) {
+        if (REVEAL_STAGES[stage].blurSigma > 0) {
+          await PhotoReveal.variant(id, user.profileImage, stage, true);
+        }
+      }
+    },
+  },
+  nft_reverify: {
+    description:
+      'Check NFT-verified users still hold an eligible NFT, revoking ' +
+      "the badge of those who don't",
+    upstream: 'rpc',
+    unavailable: () =>
+      process.env.ALCHEMY_URL ? null : 'ALCHEMY_URL is not configured',
+    next: async (cursor, take) =>
+      (
+        await prisma.user.findMany({
+          where: { ...NFT_VERIFIED_USERS, id: idsAfter(cursor) },
+          select: { id: true },
+          orderBy: { id: 'asc' },
+          take,
+        })
+      ).map(user => user.id),
+    count: () => prisma.user.count({ where: NFT_VERIFIED_USERS }),
+    process: async id => {
+      const user = await prisma.user.findUnique({
+        where: { id },
+        select: { walletAddress: true, tenantId: true },
+      });
+      if (!user) {
+        return;
+      }
+      // Throws if the chain can't be read, leaving the badge as it is
+      const nft = await NftOwnership.check(
+        user.walletAddress,
+        await Tenants.get(user.tenantId),
+        { fresh: true }
+      );
+      await Verification.setAutomated(id, 'nft', Boolean(nft));
+    },
+  },
+};
+
+function maxRate(kind: BackfillKind): number {
+  return MAX_RATES[BACKFILLS[kind].upstream];
+}
+
+function toView(job: BackfillJob): BackfillView {
+  const definition = BACKFILLS[job.kind as BackfillKind];
+  return {
+    ...job,
+    description: definition.description,
+    upstream: definition.upstream,
+    percent:
+      job.status === 'completed'
+        ? 100
+        : job.total > 0
+          ? Math.min(99, Math.floor((job.processed / job.total) * 100))
+          : 0,
+  };
+}
+
+function sleep(ms: number): Promise<void> {
+  return new Promise(resolve => setTimeout(resolve, ms));
+}
+
+/**
+ * The running job on the same upstream as `kind`, if any
+ */
+async function runningOn(
+  kind: BackfillKind,
+  exceptId?: string
+): Promise<BackfillJob | null> {
+  const sameUpstream = BACKFILL_KINDS.filter(
+    other => BACKFILLS[other].upstream === BACKFILLS[kind].upstream
+  );
+  return prisma.backfillJob.findFirst({
+    where: {
+      status: 'running',
+      kind: { in: [...sameUpstream] },
+      id: exceptId ? { not: exceptId } : undefined,
+    },
+  });
+}
+
+function scheduleSlice(data: SliceData, delayMs = 0) {
+  return Scheduler.scheduleAt(
+    TASK_NAME,
+    data,
+    new Date(Date.now() + delayMs),
+    `backfill-${data.runId}-${data.slice}`
+  );
+}
+
+/**
+ * Work through a job for up to a slice, then hand over to the next slice
+ */
+async function runSlice(data: SliceData): Promise<void> {
+  const lockKey = `backfill:lock:${data.backfillId}`;
+  const locked = await redis.set(lockKey, '1', 'EX', LOCK_SECONDS, 'NX');
+  if (!locked) {
+    // The slice before a pause is still finishing its batch
+    await scheduleSlice({ ...data, slice: data.slice + 1 }, LOCK_RETRY_MS);
+    return;
+  }
+
+  try {
+    let job = await prisma.backfillJob.findUnique({
+      where: { id: data.backfillId },
+    });
+    if (!job || job.status !== 'running' || job.runId !== data.runId) {
+      return;
+    }
+
+    const definition = BACKFILLS[job.kind as BackfillKind];
+    const started = Date.now();
+    let { cursor, processed, failed, lastError } = job;
+
+    while (Date.now() - started < SLICE_MS) {
+      const ids = await definition.next(cursor, BATCH_SIZE);
+      if (ids.length === 0) {
+        await prisma.backfillJob.updateMany({
+          where: { id: job.id, runId: data.runId, status: 'running' },
+          data: { status: 'completed', finishedAt: new Date() },
+        });
+        console.log(`🔁 Backfill ${job.id} (${job.kind}) completed:`, {
+          processed,
+          failed,
+        });
+        return;
+      }
+
+      const intervalMs = 1000 / job.ratePerSecond;
+      for (const id of ids) {
+        const itemStarted = Date.now();
+        try {
+          await definition.process(id);
+        } catch (error) {
+          failed++;
+          lastError = `${id}: ${
+            error instanceof Error ? error.message : String(error)
+          }`;
+        }
+        processed++;
+        cursor = id;
+        await sleep(Math.max(0, intervalMs - (Date.now() - itemStarted)));
+      }
+
+      // Also picks up a pause, cancel or rate change made meanwhile
+      job = await prisma.backfillJob.update({
+        where: { id: job.id },
+        data: { cursor, processed, failed, lastError },
+      });
+      if (job.status !== 'running' || job.runId !== data.runId) {
+        return;
+      }
+    }
+
+    await scheduleSlice({ ...data, slice: data.slice + 1 });
+  } finally {
+    await redis.del(lockKey);
+  }
+}
+
+export class Backfills {
+  static kinds() {
+    return BACKFILL_KINDS.map(kind => ({
+      kind,
+      description: BACKFILLS[kind].description,
+      upstream: BACKFILLS[kind].upstream,
+      maxRatePerSecond: maxRate(kind),
+      unavailable: BACKFILLS[kind].unavailable(),
+    }));
+  }
+
+  static async list(limit = 50): Promise<BackfillView[]> {
+    const jobs = await prisma.backfillJob.findMany({
+      orderBy: { createdAt: 'desc' },
+      take: limit,
+    });
+    return jobs.map(toView);
+  }
+
+  static async get(id: string): Promise<BackfillView | null> {
+    const job = await prisma.backfillJob.findUnique({ where: { id } });
+    return job ? toView(job) : null;
+  }
+
+  /**
+   * Launch a backfill at up to `ratePerSecond` items a second (the
+   * upstream's cap by default)
+   */
+  static async start(
+    kind: BackfillKind,
+    adminId: string,
+    ratePerSecond?: number
+  ): Promise<BackfillResult> {
+    const reason = BACKFILLS[kind].unavailable();
+    if (reason) {
+      return { status: 'unavailable', reason };
+    }
+    const running = await runningOn(kind);
+    if (running) {
+      return { status: 'upstream_busy', runningId: running.id };
+    }
+
+    const runId = randomUUID();
+    const job = await prisma.backfillJob.create({
+      data: {
+        kind,
+        ratePerSecond: Math.min(ratePerSecond ?? Infinity, maxRate(kind)),
+        total: await BACKFILLS[kind].count(),
+        runId,
+        createdBy: adminId,
+      },
+    });
+    await scheduleSlice({ backfillId: job.id, runId, slice: 0 });
+
+    await AuditLog.record({
+      action: 'admin.backfill_started',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'backfill',
+      targetId: job.id,
+      details: { kind, ratePerSecond: job.ratePerSecond, total: job.total },
+    });
+    return { status: 'ok', job: toView(job) };
+  }
+
+  /**
+   * Pause, resume or cancel a job, and/or change its rate. A paused job
+   * stops after the batch in hand and resumes where it stopped.
+   */
+  static async update(
+    id: string,
+    adminId: string,
+    change: { action?: BackfillAction; ratePerSecond?: number }
+  ): Promise<BackfillResult> {
+    const job = await prisma.backfillJob.findUnique({ where: { id } });
+    if (!job) {
+      return { status: 'not_found' };
+    }
+    const kind = job.kind as BackfillKind;
+
+    const data: Prisma.BackfillJobUpdateInput = {};
+    if (change.ratePerSecond !== undefined) {
+      data.ratePerSecond = Math.min(change.ratePerSecond, maxRate(kind));
+    }
+
+    let runId: string | null = null;
+    if (change.action === 'pause') {
+      if (job.status !== 'running') {
+        return { status: 'invalid_transition', current: job.status };
+      }
+      data.status = 'paused';
+    } else if (change.action === 'resume') {
+      if (job.status !== 'paused' && job.status !== 'failed') {
+        return { status: 'invalid_transition', current: job.status };
+      }
+      const reason = BACKFILLS[kind].unavailable();
+      if (reason) {
+        return { status: 'unavailable', reason };
+      }
+      const running = await runningOn(kind, id);
+      if (running) {
+        return { status: 'upstream_busy', runningId: running.id };
+      }
+      runId = randomUUID();
+      data.status = 'running';
+      data.runId = runId;
+      data.lastError = null;
+    } else if (change.action === 'cancel') {
+      if (job.status !== 'running' && job.status !== 'paused') {
+        return { status: 'invalid_transition', current: job.status };
+      }
+      data.status = 'canceled';
+      data.finishedAt = new Date();
+    }
+
+    const updated = await prisma.backfillJob.update({ where: { id }, data });
+    if (runId) {
+      await scheduleSlice({ backfillId: id, runId, slice: 0 });
+    }
+
+    await AuditLog.record({
+      action: change.action
+        ? AUDIT_ACTIONS[change.action]
+        : 'admin.backfill_updated',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'backfill',
+      targetId: id,
+      details: { kind, ratePerSecond: updated.ratePerSecond },
+    });
+    return { status: 'ok', job: toView(updated) };
+  }
+}
+
+export const backfillSlice: ScheduledTask<SliceData> = {
+  name: TASK_NAME,
+  run: async (data: SliceData, job: Job<SliceData>) => {
+    try {
+      await runSlice(data);
+    } catch (error) {
+      // Out of retries: stop the job where it is, to be resumed by hand
+      if (job.attemptsMade + 1 >= (job.opts.attempts ?? 1)) {
+        await prisma.backfillJob.updateMany({
+          where: { id: data.backfillId, runId: data.runId, status: 'running' },
+          data: { status: 'failed', lastError: String(error) },
+        });
+      }
+      throw error;
+    }
+  },
+};
@@ -0,0 +1,226 @@
+/**
+ * Badges
+ * Achievements shown on profiles. The server grants them (users can't
+ * claim one) by evaluating grant rules against the domain event stream,
+ * the same way the analytics rollup reads it, from its own checkpoint.
+ * Granting is idempotent, so replaying events is harmless. Only the
+ * verified badge is ever taken back, when verification is.
+ */
+
+import prisma from './prisma';
+import redis from './redis';
+import { EVENT_STREAM_KEY, DomainEvent } from './event-bus';
+import { ScheduledTask } from './scheduler';
+import { Notifications } from './notifications';
+import { Verification } from './verification';
+import { counter } from './metrics';
+
+export const BADGES = [
+  'early_adopter',
+  'event_attendee',
+  'verified',
+  'ten_matches',
+] as const;
+
+export type Badge = (typeof BADGES)[number];
+
+// The first this many users to sign up are early adopters
+const EARLY_ADOPTER_LIMIT = parseInt(
+  process.env.BADGE_EARLY_ADOPTER_LIMIT || '1000'
+);
+
+const MATCH_MILESTONE = 10;
+
+const CHECKPOINT_NAME = 'badges';
+const STREAM_BATCH_SIZE = 500;
+
+const grantCounter = counter(
+  'aurum_badges_granted_total',
+  'Badges granted, by badge'
+);
+
+interface BadgeChange {
+  userId: string;
+  badge: Badge;
+  granted: boolean;
+}
+
+type GrantRule = (
+  event: DomainEvent<Record<string, unknown>>
+) => Promise<BadgeChange[]>;
+
+// Event type -> the rule deciding which badges it grants
+const GRANT_RULES: Record<string, GrantRule> = {
+  'user.signed_up': async event => {
+    const userId = event.payload.userId as string;
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { createdAt: true },
+    });
+    if (!user) {
+      return [];
+    }
+    const position = await prisma.user.count({
+      where: { createdAt: { lte: user.createdAt } },
+    });
+    return position <= EARLY_ADOPTER_LIMIT
+      ? [{ userId, badge: 'early_adopter', granted: true }]
+      : [];
+  },
+
+  // Held while photo verification is
+  'user.verification_changed': async event => {
+    const userId = event.payload.userId as string;
+    const verified = await Verification.isVerified(userId, 'photo');
+    return [{ userId, badge: 'verified', granted: verified }];
+  },
+
+  'match.created': async event => {
+    const userIds = [
+      event.payload.user1Id as string,
+      event.payload.user2Id as string,
+    ];
+    const counts = await Promise.all(
+      userIds.map(userId =>
+        prisma.match.count({
+          where: {
+            deletedAt: null,
+            OR: [{ user1Id: userId }, { user2Id: userId }],
+          },
+        })
+      )
+    );
+    return userIds
+      .filter((_, index) => counts[index] >= MATCH_MILESTONE)
+      .map((userId): BadgeChange => ({
+        userId,
+        badge: 'ten_matches',
+        granted: true,
+      }));
+  },
+
+  'event.attended': async event => [
+    {
+      userId: event.payload.userId as string,
+      badge: 'event_attendee',
+      granted: true,
+    },
+  ],
+};
+
+/**
+ * Apply one change; true if it granted a badge the user didn't have
+ */
+async function applyChange(change: BadgeChange): Promise<boolean> {
+  if (!change.granted) {
+    await prisma.userBadge.deleteMany({
+      where: { userId: change.userId, badge: change.badge },
+    });
+    return false;
+  }
+  const existing = await prisma.userBadge.findUnique({
+    where: {
+      userId_badge: { userId: change.userId, badge: change.badge },
+    },
+  });
+  if (existing) {
+    return false;
+  }
+  await prisma.userBadge.create({
+    data: { userId: change.userId, badge: change.badge },
+  });
+  return true;
+}
+
+export class Badges {
+  /**
+   * Badges held by each user, in BADGES order
+   */
+  static async forUsers(userIds: string[]): Promise<Map<string, Badge[]>> {
+    const badges = new Map<string, Badge[]>();
+    if (userIds.length === 0) {
+      return badges;
+    }
+    const rows = await prisma.userBadge.findMany({
+      where: { userId: { in: userIds } },
+      select: { userId: true, badge: true },
+    });
+    for (const userId of userIds) {
+      const held = rows
+        .filter(row => row.userId === userId)
+        .map(row => row.badge);
+      if (held.length > 0) {
+        badges.set(userId, BADGES.filter(badge => held.includes(badge)));
+      }
+    }
+    return badges;
+  }
+
+  /**
+   * Run new stream events through the grant rules
+   */
+  static async evaluate(): Promise<{ events: number; granted: number }> {
+    const checkpoint = await prisma.analyticsCheckpoint.findUnique({
+      where: { name: CHECKPOINT_NAME },
+    });
+    let position = checkpoint?.position || '0';
+    let processed = 0;
+    let granted = 0;
+
+    for (;;) {
+      const entries = await redis.xrange(
+        EVENT_STREAM_KEY,
+        `(${position}`,
+        '+',
+        'COUNT',
+        STREAM_BATCH_SIZE
+      );
+      if (entries.length === 0) {
+        break;
+      }
+
+      for (const [, fields] of entries) {
+        const body = fields[fields.indexOf('event') + 1];
+        const event = JSON.parse(body) as DomainEvent<Record<string, unknown>>;
+        const rule = GRANT_RULES[event.type];
+        if (!rule) {
+          continue;
+        }
+        for (const change of await rule(event)) {
+          if (!(await applyChange(change))) {
+            continue;
+          }
+          granted++;
+          grantCounter.inc({ badge: change.badge });
+          await Notifications.notify(change.userId, {
+            type: 'badge_granted',
+            template: `badge_${change.badge}`,
+            path: '/profile',
+            data: { badge: change.badge },
+            push: false,
+          });
+        }
+      }
+
+      position = entries[entries.length - 1][0];
+      await prisma.analyticsCheckpoint.upsert({
+        where: { name: CHECKPOINT_NAME },
+        create: { name: CHECKPOINT_NAME, position },
+        update: { position },
+      });
+      processed += entries.length;
+
+      if (entries.length < STREAM_BATCH_SIZE) {
+        break;
+      }
+    }
+
+    return { events: processed, granted };
+  }
+}
+
+export const badgeGrants: ScheduledTask = {
+  name: 'badge-grants',
+  everyMs: parseInt(process.env.BADGE_GRANT_INTERVAL_MS || '60000'),
+  run: () => Badges.evaluate(),
+};
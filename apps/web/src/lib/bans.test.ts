@@ -0,0 +1,100 @@
+/**
+ * @description Unit tests for account bans
+ */
+
+import { describe, it, expect, jest, beforeEach } from '@jest/globals';
+import { Bans } from '@/lib/bans';
+
+interface MockUser {
+  status: string;
+  bannedUntil: Date | null;
+  banReason: string | null;
+}
+
+let mockUser: MockUser | null = null;
+const mockPublish = jest.fn(
+  async (_type: string, _payload: Record<string, unknown>) => {}
+);
+
+jest.mock('@/lib/prisma', () => ({
+  __esModule: true,
+  default: {
+    user: {
+      findUnique: async () => mockUser,
+      update: async ({ data }: { data: Partial<MockUser> }) => {
+        mockUser = { ...mockUser!, ...data };
+        return mockUser;
+      },
+    },
+  },
+}));
+
+jest.mock('@/lib/audit-log', () => ({
+  AuditLog: { record: async () => {} },
+}));
+
+jest.mock('@/lib/event-bus', () => ({
+  EventBus: {
+    publish: (type: string, payload: Record<string, unknown>) =>
+      mockPublish(type, payload),
+  },
+}));
+
+const HOUR_MS = 60 * 60 * 1000;
+
+describe('Bans', () => {
+  beforeEach(() => {
+    mockUser = { status: 'active', bannedUntil: null, banReason: null };
+    mockPublish.mockClear();
+  });
+
+  it('finds no ban on an active account', async () => {
+    expect(await Bans.getActiveBan('user-1')).toBeNull();
+  });
+
+  it('bans permanently when no duration is given', async () => {
+    const ban = await Bans.ban('user-1', { reason: 'scam' }, 'admin-1');
+
+    expect(ban).toEqual({ reason: 'scam', until: null });
+    expect(await Bans.getActiveBan('user-1')).toEqual(ban);
+    expect(mockPublish).toHaveBeenCalledWith('user.banned', {
+      userId: 'user-1',
+      ...ban,
+    });
+  });
+
+  it('holds a temporary ban until it runs out', async () => {
+    const ban = await Bans.ban(
+      'user-1',
+      { reason: 'spam', durationHours: 24 },
+      'admin-1'
+    );
+
+    expect(new Date(ban.until!).getTime()).toBeGreaterThan(
+      Date.now() + 23 * HOUR_MS
+    );
+    expect(await Bans.getActiveBan('user-1')).toEqual(ban);
+  });
+
+  it('lifts a temporary ban on its own once it has passed', async () => {
+    mockUser = {
+      status: 'banned',
+      bannedUntil: new Date(Date.now() - HOUR_MS),
+      banReason: 'spam',
+    };
+
+    expect(await Bans.getActiveBan('user-1')).toBeNull();
+  });
+
+  it('lifts a ban when an admin unbans', async () => {
+    await Bans.ban('user-1', { reason: 'harassment' }, 'admin-1');
+    await Bans.unban('user-1', 'admin-1', 'appeal upheld');
+
+    expect(await Bans.getActiveBan('user-1')).toBeNull();
+    expect(mockUser).toEqual({
+      status: 'active',
+      bannedUntil: null,
+      banReason: null,
+    });
+  });
+});
@@ -0,0 +1,115 @@
+/**
+ * Bans
+ * Temporary and permanent account bans. Enforced by `authMiddleware`;
+ * temporary bans lift on their own once `bannedUntil` passes.
+ */
+
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { EventBus } from './event-bus';
+
+export const BAN_REASONS = [
+  'spam',
+  'harassment',
+  'fake_profile',
+  'underage',
+  'scam',
+  'inappropriate_content',
+  'billing_abuse',
+  'other',
+] as const;
+
+export type BanReason = (typeof BAN_REASONS)[number];
+
+export interface ActiveBan {
+  reason: string | null;
+  // null for permanent bans
+  until: string | null;
+}
+
+export interface BanOptions {
+  reason: BanReason;
+  // Omit for a permanent ban
+  durationHours?: number;
+  note?: string;
+}
+
+export class Bans {
+  /**
+   * The user's ban if one is in effect
+   */
+  static async getActiveBan(userId: string): Promise<ActiveBan | null> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { status: true, bannedUntil: true, banReason: true },
+    });
+    if (
+      user?.status !== 'banned' ||
+      (user.bannedUntil && user.bannedUntil <= new Date())
+    ) {
+      return null;
+    }
+
+    return {
+      reason: user.banReason,
+      until: user.bannedUntil?.toISOString() || null,
+    };
+  }
+
+  /**
+   * Ban a user. Banning an already-banned user replaces the ban.
+   */
+  static async ban(
+    userId: string,
+    options: BanOptions,
+    adminId: string
+  ): Promise<ActiveBan> {
+    const bannedUntil = options.durationHours
+      ? new Date(Date.now() + options.durationHours * 60 * 60 * 1000)
+      : null;
+
+    await prisma.user.update({
+      where: { id: userId },
+      data: { status: 'banned', bannedUntil, banReason: options.reason },
+    });
+
+    const ban = {
+      reason: options.reason,
+      until: bannedUntil?.toISOString() || null,
+    };
+    await AuditLog.record({
+      action: 'admin.user_banned',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'user',
+      targetId: userId,
+      details: { ...ban, note: options.note ?? null },
+    });
+    await EventBus.publish('user.banned', { userId, ...ban });
+    return ban;
+  }
+
+  /**
+   * Lift a ban
+   */
+  static async unban(
+    userId: string,
+    adminId: string,
+    note?: string
+  ): Promise<void> {
+    await prisma.user.update({
+      where: { id: userId },
+      data: { status: 'active', bannedUntil: null, banReason: null },
+    });
+
+    await AuditLog.record({
+      action: 'admin.user_unbanned',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'user',
+      targetId: userId,
+      details: { note: note ?? null },
+    });
+    await EventBus.publish('user.unbanned', { userId });
+  }
+}
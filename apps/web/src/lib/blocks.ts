@@ -0,0 +1,38 @@
+/**
+ * Blocks
+ * A block is permanent until the blocker's account is erased: the two
+ * users never see each other in discovery again, in either direction, and
+ * can't start anything new with each other. Blocking from a conversation
+ * also ends the match (see lib/conversation-safety).
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+
+/**
+ * Users who haven't blocked the viewer and whom the viewer hasn't blocked
+ */
+export function notBlockedWhere(viewerId: string): Prisma.UserWhereInput {
+  return {
+    blocks: { none: { blockedId: viewerId } },
+    blockedBy: { none: { blockerId: viewerId } },
+  };
+}
+
+export class Blocks {
+  /**
+   * Whether either user has blocked the other
+   */
+  static async between(userId: string, otherUserId: string): Promise<boolean> {
+    const block = await prisma.block.findFirst({
+      where: {
+        OR: [
+          { blockerId: userId, blockedId: otherUserId },
+          { blockerId: otherUserId, blockedId: userId },
+        ],
+      },
+      select: { id: true },
+    });
+    return Boolean(block);
+  }
+}
@@ -0,0 +1,142 @@
+/**
+ * Boosts
+ * Time-boxed exposure boosts. An active boost multiplies the user's weight
+ * in other users' discovery decks. Boosts are paid for from the plan's
+ * allowance first, then from purchased inventory.
+ */
+
+import prisma from './prisma';
+import redis from './redis';
+import { Entitlements } from './entitlements';
+import { Inventory } from './inventory';
+import { EventBus } from './event-bus';
+
+const BOOST_DURATION_MS =
+  parseInt(process.env.BOOST_DURATION_MINUTES || '30') * 60 * 1000;
+
+export const BOOST_EXPOSURE_MULTIPLIER = parseFloat(
+  process.env.BOOST_EXPOSURE_MULTIPLIER || '2'
+);
+
+// Sorted set of boosted user IDs scored by expiry (ms)
+const ACTIVE_BOOSTS_KEY = 'boosts:active';
+
+const boostKey = (userId: string) => `boost:${userId}`;
+
+export type BoostSource = 'plan' | 'inventory';
+
+export interface BoostStatus {
+  active: boolean;
+  expiresAt: string | null;
+  planBoostsRemaining: number | null; // null when unlimited
+  inventoryBoosts: number;
+}
+
+export type BoostActivation =
+  | { status: 'activated'; source: BoostSource; expiresAt: string }
+  | { status: 'already_active'; expiresAt: string }
+  | { status: 'no_credits' };
+
+async function planBoostsRemaining(userId: string): Promise<number> {
+  const [limit, subscription] = await Promise.all([
+    Entitlements.getLimit(userId, 'boosts'),
+    Entitlements.getSubscription(userId),
+  ]);
+  if (limit === 0 || !subscription.startedAt) {
+    return 0;
+  }
+
+  // The allowance resets with each plan period
+  const used = await prisma.boost.count({
+    where: {
+      userId,
+      source: 'plan',
+      startedAt: { gte: new Date(subscription.startedAt) },
+    },
+  });
+  return Math.max(limit - used, 0);
+}
+
+export class Boosts {
+  /**
+   * Whether the user has a running boost, and what they can still spend
+   */
+  static async getStatus(userId: string): Promise<BoostStatus> {
+    const [expiresAt, remaining, balances] = await Promise.all([
+      redis.get(boostKey(userId)),
+      planBoostsRemaining(userId),
+      Inventory.getBalances(userId),
+    ]);
+
+    return {
+      active: expiresAt !== null,
+      expiresAt,
+      planBoostsRemaining: Number.isFinite(remaining) ? remaining : null,
+      inventoryBoosts: balances.boost,
+    };
+  }
+
+  /**
+   * Start a boost. The Redis key doubles as a lock, so concurrent requests
+   * can't start (or pay for) two boosts at once.
+   */
+  static async activate(userId: string): Promise<BoostActivation> {
+    const expiresAt = new Date(Date.now() + BOOST_DURATION_MS);
+    const key = boostKey(userId);
+
+    const claimed = await redis.set(
+      key,
+      expiresAt.toISOString(),
+      'PX',
+      BOOST_DURATION_MS,
+      'NX'
+    );
+    if (!claimed) {
+      const current = await redis.get(key);
+      return {
+        status: 'already_active',
+        expiresAt: current || expiresAt.toISOString(),
+      };
+    }
+
+    let source: BoostSource | null = null;
+    try {
+      if ((await planBoostsRemaining(userId)) > 0) {
+        source = 'plan';
+      } else if (await Inventory.consume(userId, 'boost')) {
+        source = 'inventory';
+      }
+
+      if (!source) {
+        await redis.del(key);
+        return { status: 'no_credits' };
+      }
+
+      await prisma.boost.create({ data: { userId, source, expiresAt } });
+      await redis.zadd(ACTIVE_BOOSTS_KEY, expiresAt.getTime(), userId);
+    } catch (error) {
+      if (source === 'inventory') {
+        await Inventory.refund(userId, 'boost');
+      }
+      await redis.del(key);
+      throw error;
+    }
+
+    await EventBus.publish('boost.activated', {
+      userId,
+      source,
+      expiresAt: expiresAt.toISOString(),
+    });
+
+    return { status: 'activated', source, expiresAt: expiresAt.toISOString() };
+  }
+
+  /**
+   * IDs of all currently boosted users
+   */
+  static async getActiveUserIds(): Promise<string[]> {
+    const now = Date.now();
+    await redis.zremrangebyscore(ACTIVE_BOOSTS_KEY, '-inf', now);
+    return redis.zrangebyscore(ACTIVE_BOOSTS_KEY, now, '+inf');
+  }
+}
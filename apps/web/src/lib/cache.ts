@@ -0,0 +1,176 @@
+/**
+ * Cache
+ * Two-tier read-through cache for hot per-user lookups: a small in-process
+ * LRU in front of Redis. Invalidating a key deletes it from Redis and
+ * broadcasts it over pub/sub so every app instance drops its local copy.
+ * Concurrent misses for a key share one Redis read and load (see
+ * singleflight). Redis errors fall through to the loader; caching is never
+ * required for correctness.
+ */
+
+import Redis from 'ioredis';
+import redis from './redis';
+import { counter } from './metrics';
+import { singleflight } from './singleflight';
+
+const INVALIDATION_CHANNEL = 'cache:invalidate';
+
+// Local copies expire sooner than Redis ones, bounding staleness if an
+// invalidation message is missed
+const LOCAL_TTL_MS = parseInt(process.env.CACHE_LOCAL_TTL_MS || '15000');
+
+const lookupCounter = counter(
+  'aurum_cache_lookups_total',
+  'Cache lookups by cache and the tier that answered'
+);
+
+export interface Cache<T> {
+  /**
+   * The cached value for `key`, loading (and caching) it on a miss
+   */
+  getOrLoad(key: string, load: () => Promise<T>): Promise<T>;
+  /**
+   * Drop `key` from every tier on every instance
+   */
+  invalidate(key: string): Promise<void>;
+}
+
+export interface CacheOptions {
+  // Redis TTL
+  ttlSeconds: number;
+  // Local LRU capacity
+  maxEntries?: number;
+}
+
+interface LocalEntry {
+  value: unknown;
+  expiresAt: number;
+}
+
+/**
+ * Least-recently-used map; Map keeps insertion order, so re-inserting on
+ * read moves an entry to the back and the front is the eviction candidate
+ */
+class LocalLru {
+  private entries = new Map<string, LocalEntry>();
+
+  constructor(private maxEntries: number) {}
+
+  get(key: string): LocalEntry | undefined {
+    const entry = this.entries.get(key);
+    if (!entry) {
+      return undefined;
+    }
+    this.entries.delete(key);
+    if (entry.expiresAt <= Date.now()) {
+      return undefined;
+    }
+    this.entries.set(key, entry);
+    return entry;
+  }
+
+  set(key: string, value: unknown, ttlMs: number) {
+    this.entries.delete(key);
+    this.entries.set(key, { value, expiresAt: Date.now() + ttlMs });
+    if (this.entries.size > this.maxEntries) {
+      this.entries.delete(this.entries.keys().next().value!);
+    }
+  }
+
+  delete(key: string) {
+    this.entries.delete(key);
+  }
+}
+
+declare global {
+  var cacheLocals: undefined | Map<string, LocalLru>;
+  var cacheSubscriber: undefined | Redis;
+}
+
+// Shared across route bundles so one invalidation reaches every copy
+const locals: Map<string, LocalLru> = globalThis.cacheLocals ?? new Map();
+globalThis.cacheLocals = locals;
+
+/**
+ * Listen for invalidations from other instances (once per process)
+ */
+function subscribe() {
+  if (globalThis.cacheSubscriber) {
+    return;
+  }
+  // Subscribed connections can't run other commands, so this needs its own
+  const subscriber = redis.duplicate();
+  globalThis.cacheSubscriber = subscriber;
+  subscriber.subscribe(INVALIDATION_CHANNEL).catch(error => {
+    console.error('Error subscribing to cache invalidations:', error);
+  });
+  subscriber.on('message', (_channel, message: string) => {
+    const separator = message.indexOf(':');
+    locals
+      .get(message.slice(0, separator))
+      ?.delete(message.slice(separator + 1));
+  });
+}
+
+/**
+ * A cache whose keys live under `name` (e.g. "entitlements"). Values must
+ * survive a JSON round trip.
+ */
+export function createCache<T>(name: string, options: CacheOptions): Cache<T> {
+  const local = locals.get(name) ?? new LocalLru(options.maxEntries ?? 1000);
+  locals.set(name, local);
+  const redisKey = (key: string) => `cache:${name}:${key}`;
+  const localTtlMs = Math.min(LOCAL_TTL_MS, options.ttlSeconds * 1000);
+
+  return {
+    async getOrLoad(key, load) {
+      subscribe();
+
+      const cached = local.get(key);
+      if (cached) {
+        lookupCounter.inc({ cache: name, tier: 'local' });
+        return cached.value as T;
+      }
+
+      return singleflight(`cache:${name}`, key, async () => {
+        try {
+          const data = await redis.get(redisKey(key));
+          if (data !== null) {
+            // Wrapped so a cached null is told apart from a miss
+            const { value } = JSON.parse(data) as { value: T };
+            local.set(key, value, localTtlMs);
+            lookupCounter.inc({ cache: name, tier: 'redis' });
+            return value;
+          }
+        } catch (error) {
+          console.error(`Error reading ${name} cache:`, error);
+        }
+
+        lookupCounter.inc({ cache: name, tier: 'miss' });
+        const value = await load();
+        local.set(key, value, localTtlMs);
+        try {
+          await redis.set(
+            redisKey(key),
+            JSON.stringify({ value }),
+            'EX',
+            options.ttlSeconds
+          );
+        } catch (error) {
+          console.error(`Error writing ${name} cache:`, error);
+        }
+        return value;
+      });
+    },
+
+    async invalidate(key) {
+      local.delete(key);
+      try {
+        await redis.del(redisKey(key));
+        await redis.publish(INVALIDATION_CHANNEL, `${name}:${key}`);
+      } catch (error) {
+        console.error(`Error invalidating ${name} cache:`, error);
+      }
+    },
+  };
+}
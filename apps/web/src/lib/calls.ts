@@ -0,0 +1,253 @@
+/**
+ * Calls
+ * Signaling for in-app WebRTC voice and video calls between matched users,
+ * so they can talk without swapping phone numbers. Each match has at most
+ * one call at a time, tracked in Redis. SDP offers/answers and ICE
+ * candidates are relayed through a short-lived per-recipient mailbox that
+ * clients poll while a call is ringing or connecting, and are also
+ * published on the recipient's channel for a socket server to push.
+ * Media never touches our servers: peers connect directly, or through TURN
+ * with the time-limited credentials vended here.
+ */
+
+import { createHmac, randomUUID } from 'crypto';
+import prisma from './prisma';
+import redis from './redis';
+import { Notifications } from './notifications';
+
+export const CALL_MEDIA = ['audio', 'video'] as const;
+
+export type CallMedia = (typeof CALL_MEDIA)[number];
+
+export const CALL_SIGNAL_TYPES = [
+  'offer',
+  'answer',
+  'ice_candidate',
+  'hangup',
+  'decline',
+] as const;
+
+export type CallSignalType = (typeof CALL_SIGNAL_TYPES)[number];
+
+// An unanswered call stops ringing after this long
+const RING_TIMEOUT_SECONDS = 45;
+// Upper bound on a connected call's state lingering in Redis
+const MAX_CALL_SECONDS = 2 * 60 * 60;
+// Signals the recipient hasn't collected by then are useless anyway
+const MAILBOX_TTL_SECONDS = 60;
+const MAX_MAILBOX_SIGNALS = 100;
+
+const DEFAULT_STUN_URLS = 'stun:stun.l.google.com:19302';
+
+const TURN_CREDENTIAL_TTL_SECONDS = parseInt(
+  process.env.TURN_CREDENTIAL_TTL_SECONDS || '3600'
+);
+
+const callKey = (matchId: string) => `calls:active:${matchId}`;
+const mailboxKey = (matchId: string, userId: string) =>
+  `calls:signals:${matchId}:${userId}`;
+export const callChannel = (userId: string) => `calls:user:${userId}`;
+
+export interface ActiveCall {
+  callId: string;
+  callerId: string;
+  calleeId: string;
+  media: CallMedia;
+  startedAt: string;
+  answeredAt: string | null;
+}
+
+export interface CallSignal {
+  matchId: string;
+  callId: string;
+  type: CallSignalType;
+  fromUserId: string;
+  // SDP or ICE candidate, passed through untouched
+  payload: Record<string, unknown> | null;
+  sentAt: string;
+}
+
+export interface SignalInput {
+  type: CallSignalType;
+  // Required for everything but a new offer
+  callId?: string;
+  media?: CallMedia;
+  payload?: Record<string, unknown>;
+}
+
+export type SignalResult =
+  | { status: 'sent'; call: ActiveCall | null }
+  | { status: 'busy' }
+  | { status: 'no_call' }
+  | { status: 'not_allowed' };
+
+export interface IceServer {
+  urls: string[];
+  username?: string;
+  credential?: string;
+}
+
+async function getCall(matchId: string): Promise<ActiveCall | null> {
+  const data = await redis.get(callKey(matchId));
+  return data ? (JSON.parse(data) as ActiveCall) : null;
+}
+
+async function relay(toUserId: string, signal: CallSignal) {
+  const key = mailboxKey(signal.matchId, toUserId);
+  const data = JSON.stringify(signal);
+  await redis
+    .multi()
+    .rpush(key, data)
+    .ltrim(key, -MAX_MAILBOX_SIGNALS, -1)
+    .expire(key, MAILBOX_TTL_SECONDS)
+    .publish(callChannel(toUserId), data)
+    .exec();
+}
+
+export class Calls {
+  /**
+   * The other user in `matchId`, if `userId` is in that (live) match
+   */
+  static async otherParticipant(
+    matchId: string,
+    userId: string
+  ): Promise<string | null> {
+    const match = await prisma.match.findFirst({
+      where: {
+        id: matchId,
+        deletedAt: null,
+        OR: [{ user1Id: userId }, { user2Id: userId }],
+      },
+      select: { user1Id: true, user2Id: true },
+    });
+    if (!match) {
+      return null;
+    }
+    return match.user1Id === userId ? match.user2Id : match.user1Id;
+  }
+
+  /**
+   * Send a signal to the other side of the match's call. An offer without
+   * a callId starts a new call; with one, it renegotiates the current call.
+   */
+  static async signal(
+    matchId: string,
+    fromUserId: string,
+    toUserId: string,
+    input: SignalInput
+  ): Promise<SignalResult> {
+    let call = await getCall(matchId);
+
+    if (input.type === 'offer' && !input.callId) {
+      if (call) {
+        return { status: 'busy' };
+      }
+      call = {
+        callId: randomUUID(),
+        callerId: fromUserId,
+        calleeId: toUserId,
+        media: input.media ?? 'audio',
+        startedAt: new Date().toISOString(),
+        answeredAt: null,
+      };
+      const created = await redis.set(
+        callKey(matchId),
+        JSON.stringify(call),
+        'EX',
+        RING_TIMEOUT_SECONDS,
+        'NX'
+      );
+      if (!created) {
+        return { status: 'busy' };
+      }
+      await Notifications.notify(toUserId, {
+        type: 'incoming_call',
+        path: `/matches/${matchId}/call`,
+        data: { matchId, callId: call.callId, media: call.media },
+        push: true,
+      });
+    } else if (!call || call.callId !== input.callId) {
+      return { status: 'no_call' };
+    } else if (input.type === 'answer' && !call.answeredAt) {
+      if (fromUserId !== call.calleeId) {
+        return { status: 'not_allowed' };
+      }
+      call = { ...call, answeredAt: new Date().toISOString() };
+      await redis.set(
+        callKey(matchId),
+        JSON.stringify(call),
+        'EX',
+        MAX_CALL_SECONDS
+      );
+    } else if (input.type === 'hangup' || input.type === 'decline') {
+      await redis.del(callKey(matchId));
+      call = null;
+    }
+
+    await relay(toUserId, {
+      matchId,
+      callId: call?.callId ?? input.callId!,
+      type: input.type,
+      fromUserId,
+      payload: input.payload ?? null,
+      sentAt: new Date().toISOString(),
+    });
+    return { status: 'sent', call };
+  }
+
+  /**
+   * The match's current call, and the signals waiting for `userId` (which
+   * are removed as they're returned)
+   */
+  static async collect(
+    matchId: string,
+    userId: string
+  ): Promise<{ call: ActiveCall | null; signals: CallSignal[] }> {
+    const key = mailboxKey(matchId, userId);
+    const [call, results] = await Promise.all([
+      getCall(matchId),
+      redis.multi().lrange(key, 0, -1).del(key).exec(),
+    ]);
+    const pending = (results?.[0]?.[1] as string[] | undefined) ?? [];
+    return {
+      call,
+      signals: pending.map(data => JSON.parse(data) as CallSignal),
+    };
+  }
+
+  /**
+   * STUN and TURN servers for a call. TURN credentials use the TURN REST
+   * API scheme (coturn's use-auth-secret): the username carries its expiry
+   * and the password is an HMAC of it, so nothing is stored.
+   */
+  static iceServers(userId: string): {
+    iceServers: IceServer[];
+    expiresAt: string;
+  } {
+    const expiresAt =
+      Math.floor(Date.now() / 1000) + TURN_CREDENTIAL_TTL_SECONDS;
+    const list = (value: string) =>
+      value
+        .split(',')
+        .map(url => url.trim())
+        .filter(Boolean);
+
+    const iceServers: IceServer[] = [];
+    const stun = list(process.env.STUN_URLS ?? DEFAULT_STUN_URLS);
+    if (stun.length > 0) {
+      iceServers.push({ urls: stun });
+    }
+    const turn = list(process.env.TURN_URLS || '');
+    if (turn.length > 0 && process.env.TURN_SECRET) {
+      const username = `${expiresAt}:${userId}`;
+      iceServers.push({
+        urls: turn,
+        username,
+        credential: createHmac('sha1', process.env.TURN_SECRET)
+          .update(username)
+          .digest('base64'),
+      });
+    }
+    return { iceServers, expiresAt: new Date(expiresAt * 1000).toISOString() };
+  }
+}
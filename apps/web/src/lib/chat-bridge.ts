@@ -0,0 +1,287 @@
+/**
+ * Chat Bridge
+ * Delivers match and signal notifications through a Telegram or LINE bot,
+ * for users who keep push turned off in World App. Users link an account
+ * by sending the bot a one-time code from the app.
+ */
+
+import { createHmac, randomBytes, timingSafeEqual } from 'crypto';
+import { ChatLink } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { PushNotification } from './push-notifications';
+import { AuditLog } from './audit-log';
+import { upstreamFetch } from './http-client';
+import { NotificationTemplates } from './notification-templates';
+
+export const CHAT_PROVIDERS = ['telegram', 'line'] as const;
+
+export type ChatProvider = (typeof CHAT_PROVIDERS)[number];
+
+// Notification types forwarded to linked chats
+export const BRIDGED_TYPES = ['match', 'signal'];
+
+const LINK_CODE_TTL_SECONDS = 10 * 60;
+
+const linkCodeKey = (code: string) => `chat_link_code:${code}`;
+
+const TELEGRAM_API_URL = 'https://api.telegram.org';
+const LINE_API_URL = 'https://api.line.me/v2/bot';
+
+interface PendingLink {
+  userId: string;
+  provider: ChatProvider;
+}
+
+/**
+ * Whether a bot is configured for `provider` in this deployment
+ */
+export function chatProviderEnabled(provider: ChatProvider): boolean {
+  return provider === 'telegram'
+    ? Boolean(
+        process.env.TELEGRAM_BOT_TOKEN && process.env.TELEGRAM_BOT_USERNAME
+      )
+    : Boolean(
+        process.env.LINE_CHANNEL_ACCESS_TOKEN &&
+          process.env.LINE_CHANNEL_SECRET
+      );
+}
+
+/**
+ * Telegram echoes the secret set with setWebhook in a header
+ */
+export function verifyTelegramWebhook(secret: string | null): boolean {
+  const expected = process.env.TELEGRAM_WEBHOOK_SECRET;
+  if (!expected || !secret || secret.length !== expected.length) {
+    return false;
+  }
+  return timingSafeEqual(Buffer.from(secret), Buffer.from(expected));
+}
+
+/**
+ * LINE signs the raw body with the channel secret (HMAC-SHA256, base64)
+ */
+export function verifyLineWebhook(
+  payload: string,
+  signature: string | null
+): boolean {
+  if (!signature || !process.env.LINE_CHANNEL_SECRET) {
+    return false;
+  }
+  const expected = createHmac('sha256', process.env.LINE_CHANNEL_SECRET)
+    .update(payload)
+    .digest('base64');
+  return (
+    signature.length === expected.length &&
+    timingSafeEqual(Buffer.from(signature), Buffer.from(expected))
+  );
+}
+
+/**
+ * Send a text message. Returns false if the chat can no longer be reached
+ * (bot blocked, account gone); throws on other failures.
+ */
+async function sendMessage(
+  provider: ChatProvider,
+  externalId: string,
+  text: string
+): Promise<boolean> {
+  const response =
+    provider === 'telegram'
+      ? await upstreamFetch(
+          'telegram',
+          `${TELEGRAM_API_URL}/bot${process.env.TELEGRAM_BOT_TOKEN}/sendMessage`,
+          {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ chat_id: externalId, text }),
+          },
+          // The bot token is in the path
+          { timeoutMs: 5000, logPath: false }
+        )
+      : await upstreamFetch(
+          'line',
+          `${LINE_API_URL}/message/push`,
+          {
+            method: 'POST',
+            headers: {
+              'Content-Type': 'application/json',
+              Authorization: `Bearer ${process.env.LINE_CHANNEL_ACCESS_TOKEN}`,
+            },
+            body: JSON.stringify({
+              to: externalId,
+              messages: [{ type: 'text', text }],
+            }),
+          },
+          { timeoutMs: 5000 }
+        );
+
+  if (response.ok) {
+    return true;
+  }
+  // Telegram: 403 when the user blocked the bot. LINE: 400 for a user who
+  // unfollowed or never added it.
+  if (response.status === 403 || response.status === 400) {
+    return false;
+  }
+  throw new Error(`${provider} send failed: ${response.status}`);
+}
+
+/**
+ * Answer a message to the bot. Failures are logged, not thrown.
+ */
+async function reply(
+  provider: ChatProvider,
+  externalId: string,
+  template: string,
+  locale: string | null | undefined
+): Promise<void> {
+  try {
+    const copy = await NotificationTemplates.render(template, 'chat', locale);
+    await sendMessage(provider, externalId, copy.body);
+  } catch (error) {
+    console.error('Error replying to chat:', error);
+  }
+}
+
+export class ChatBridge {
+  /**
+   * A one-time code the user sends the bot to link their account, with a
+   * deep link that does it in one tap where the platform allows
+   */
+  static async createLinkCode(
+    userId: string,
+    provider: ChatProvider
+  ): Promise<{ code: string; url: string | null; expiresAt: Date }> {
+    const code = randomBytes(6).toString('hex').toUpperCase();
+    const pending: PendingLink = { userId, provider };
+    await redis.set(
+      linkCodeKey(code),
+      JSON.stringify(pending),
+      'EX',
+      LINK_CODE_TTL_SECONDS
+    );
+
+    const url =
+      provider === 'telegram'
+        ? `https://t.me/${process.env.TELEGRAM_BOT_USERNAME}?start=${code}`
+        : process.env.LINE_BOT_ID
+          ? `https://line.me/R/oaMessage/${encodeURIComponent(process.env.LINE_BOT_ID)}/?${code}`
+          : null;
+    return {
+      code,
+      url,
+      expiresAt: new Date(Date.now() + LINK_CODE_TTL_SECONDS * 1000),
+    };
+  }
+
+  /**
+   * Handle a message sent to the bot. If it carries a valid link code for
+   * this provider, link the sender's chat to the user. Replies either way.
+   */
+  static async handleIncoming(
+    provider: ChatProvider,
+    externalId: string,
+    text: string
+  ): Promise<ChatLink | null> {
+    const code = text
+      .replace(/^\/start\s*/, '')
+      .trim()
+      .toUpperCase();
+    const stored = code ? await redis.getdel(linkCodeKey(code)) : null;
+    const pending: PendingLink | null = stored ? JSON.parse(stored) : null;
+
+    if (!pending || pending.provider !== provider) {
+      await reply(provider, externalId, 'chat_link_prompt', null);
+      return null;
+    }
+
+    // A chat can only be linked to one account
+    await prisma.chatLink.deleteMany({
+      where: { provider, externalId, NOT: { userId: pending.userId } },
+    });
+    const link = await prisma.chatLink.upsert({
+      where: { userId_provider: { userId: pending.userId, provider } },
+      create: { userId: pending.userId, provider, externalId },
+      update: { externalId, linkedAt: new Date(), disabledAt: null },
+    });
+
+    await AuditLog.recordSafely({
+      action: 'user.chat_linked',
+      actorType: 'user',
+      actorId: pending.userId,
+      targetType: 'user',
+      targetId: pending.userId,
+      details: { provider },
+    });
+    const user = await prisma.user.findUnique({
+      where: { id: pending.userId },
+      select: { locale: true },
+    });
+    await reply(provider, externalId, 'chat_linked', user?.locale);
+    return link;
+  }
+
+  /**
+   * A user's linked chats (the external IDs stay server-side)
+   */
+  static async listLinks(userId: string) {
+    return prisma.chatLink.findMany({
+      where: { userId },
+      select: { provider: true, linkedAt: true, disabledAt: true },
+    });
+  }
+
+  static async unlink(userId: string, provider: ChatProvider) {
+    const removed = await prisma.chatLink.deleteMany({
+      where: { userId, provider },
+    });
+    return removed.count > 0;
+  }
+
+  /**
+   * Stop forwarding to a chat whose owner left the bot
+   */
+  static async disable(provider: ChatProvider, externalId: string) {
+    await prisma.chatLink.updateMany({
+      where: { provider, externalId, disabledAt: null },
+      data: { disabledAt: new Date() },
+    });
+  }
+
+  /**
+   * Forward a notification to every chat the user has linked. Chats that
+   * can't be reached any more are disabled. Never throws.
+   */
+  static async send(
+    userId: string,
+    notification: PushNotification
+  ): Promise<void> {
+    try {
+      const links = await prisma.chatLink.findMany({
+        where: { userId, disabledAt: null },
+      });
+
+      for (const link of links) {
+        const provider = link.provider as ChatProvider;
+        if (!chatProviderEnabled(provider)) {
+          continue;
+        }
+        const text = `${notification.title}\n${notification.message}`;
+        try {
+          const reachable = await sendMessage(provider, link.externalId, text);
+          if (!reachable) {
+            await prisma.chatLink.update({
+              where: { id: link.id },
+              data: { disabledAt: new Date() },
+            });
+          }
+        } catch (error) {
+          console.error(`Error sending ${provider} notification:`, error);
+        }
+      }
+    } catch (error) {
+      console.error('Error forwarding notification to chats:', error);
+    }
+  }
+}
@@ -0,0 +1,361 @@
+/**
+ * Circles
+ * Named groups users belong to: university clubs and interest groups.
+ * Anyone can start one and becomes its owner. Open circles take members
+ * straight away; for approval circles the owner approves each request.
+ * Members can switch discovery to a deck of just the circle, and the
+ * circles a user is in show on their profile.
+ */
+
+import { Circle, CircleMember, Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { Places } from './places';
+import { Notifications } from './notifications';
+
+export const CIRCLE_KINDS = ['club', 'interest'] as const;
+
+export type CircleKind = (typeof CIRCLE_KINDS)[number];
+
+export const CIRCLE_JOIN_POLICIES = ['open', 'approval'] as const;
+
+export type CircleJoinPolicy = (typeof CIRCLE_JOIN_POLICIES)[number];
+
+// Circles one user can own, to keep the directory from being spammed
+const MAX_OWNED_CIRCLES = 5;
+// Circles shown per profile
+const MAX_PROFILE_CIRCLES = 5;
+const LIST_LIMIT = 50;
+// Circle decks are filtered to this many members at most
+const MAX_DECK_MEMBERS = 1000;
+
+export interface CircleInput {
+  slug: string;
+  name: string;
+  description?: string | null;
+  kind: CircleKind;
+  campusId?: string | null;
+  joinPolicy?: CircleJoinPolicy;
+}
+
+export interface CircleFilters {
+  kind?: CircleKind;
+  campusId?: string;
+  // Matched against the name
+  search?: string;
+}
+
+export type CreateCircleResult =
+  | { status: 'created'; circle: Circle }
+  | { status: 'already_exists' }
+  | { status: 'invalid_campus' }
+  | { status: 'limit_reached' };
+
+export type JoinResult =
+  | { status: 'active' | 'pending' }
+  | { status: 'not_found' };
+
+export type DecisionResult =
+  | { status: 'approved' | 'declined' }
+  | { status: 'not_found' }
+  | { status: 'not_allowed' };
+
+export interface ProfileCircle {
+  id: string;
+  slug: string;
+  name: string;
+}
+
+type CircleWithCount = Circle & { _count: { members: number } };
+
+/**
+ * What users are shown of a circle
+ */
+export function toCircleSummary(
+  circle: CircleWithCount,
+  membership: CircleMember | null
+) {
+  return {
+    id: circle.id,
+    slug: circle.slug,
+    name: circle.name,
+    description: circle.description,
+    kind: circle.kind,
+    campusId: circle.campusId,
+    joinPolicy: circle.joinPolicy,
+    members: circle._count.members,
+    membership: membership
+      ? { role: membership.role, status: membership.status }
+      : null,
+  };
+}
+
+const activeCount = {
+  _count: { select: { members: { where: { status: 'active' } } } },
+} as const;
+
+async function withViewer(circles: CircleWithCount[], viewerId: string) {
+  const memberships = await prisma.circleMember.findMany({
+    where: { userId: viewerId, circleId: { in: circles.map(c => c.id) } },
+  });
+  return circles.map(circle =>
+    toCircleSummary(
+      circle,
+      memberships.find(m => m.circleId === circle.id) ?? null
+    )
+  );
+}
+
+async function isOwner(circleId: string, userId: string): Promise<boolean> {
+  const membership = await prisma.circleMember.findUnique({
+    where: { circleId_userId: { circleId, userId } },
+  });
+  return membership?.role === 'owner';
+}
+
+export class Circles {
+  /**
+   * Circles matching the filters, biggest first
+   */
+  static async list(viewerId: string, filters: CircleFilters = {}) {
+    const where: Prisma.CircleWhereInput = {
+      ...(filters.kind && { kind: filters.kind }),
+      ...(filters.campusId && { campusId: filters.campusId }),
+      ...(filters.search && { name: { contains: filters.search } }),
+    };
+    const circles = await prisma.circle.findMany({
+      where,
+      include: activeCount,
+      orderBy: { members: { _count: 'desc' } },
+      take: LIST_LIMIT,
+    });
+    return withViewer(circles, viewerId);
+  }
+
+  /**
+   * The circles the user is in or has asked to join
+   */
+  static async mine(userId: string) {
+    const memberships = await prisma.circleMember.findMany({
+      where: { userId },
+      include: { circle: { include: activeCount } },
+      orderBy: { createdAt: 'asc' },
+    });
+    return memberships.map(m => toCircleSummary(m.circle, m));
+  }
+
+  static async get(idOrSlug: string, viewerId: string) {
+    const circle = await prisma.circle.findFirst({
+      where: { OR: [{ id: idOrSlug }, { slug: idOrSlug }] },
+      include: activeCount,
+    });
+    if (!circle) {
+      return null;
+    }
+    const [summary] = await withViewer([circle], viewerId);
+    return summary;
+  }
+
+  /**
+   * Start a circle, owned by its creator
+   */
+  static async create(
+    userId: string,
+    input: CircleInput
+  ): Promise<CreateCircleResult> {
+    if (
+      input.campusId &&
+      !(await Places.isSelectable(input.campusId, 'campus'))
+    ) {
+      return { status: 'invalid_campus' };
+    }
+    const owned = await prisma.circleMember.count({
+      where: { userId, role: 'owner' },
+    });
+    if (owned >= MAX_OWNED_CIRCLES) {
+      return { status: 'limit_reached' };
+    }
+    if (await prisma.circle.findUnique({ where: { slug: input.slug } })) {
+      return { status: 'already_exists' };
+    }
+
+    const circle = await prisma.circle.create({
+      data: {
+        ...input,
+        createdBy: userId,
+        members: {
+          create: { userId, role: 'owner', approvedAt: new Date() },
+        },
+      },
+    });
+    return { status: 'created', circle };
+  }
+
+  /**
+   * Join an open circle, or ask to join one that needs approval. Asking
+   * again doesn't repeat the request.
+   */
+  static async join(circleId: string, userId: string): Promise<JoinResult> {
+    const circle = await prisma.circle.findUnique({ where: { id: circleId } });
+    if (!circle) {
+      return { status: 'not_found' };
+    }
+    const existing = await prisma.circleMember.findUnique({
+      where: { circleId_userId: { circleId, userId } },
+    });
+    if (existing) {
+      return { status: existing.status as 'active' | 'pending' };
+    }
+
+    const open = circle.joinPolicy === 'open';
+    await prisma.circleMember.create({
+      data: {
+        circleId,
+        userId,
+        status: open ? 'active' : 'pending',
+        approvedAt: open ? new Date() : null,
+      },
+    });
+    if (!open) {
+      const owners = await prisma.circleMember.findMany({
+        where: { circleId, role: 'owner' },
+        select: { userId: true },
+      });
+      for (const owner of owners) {
+        await Notifications.notify(owner.userId, {
+          type: 'circle_join_requested',
+          variables: { circle: circle.name },
+          path: `/circles/${circle.slug}/requests`,
+          data: { circleId, userId },
+        });
+      }
+    }
+    return { status: open ? 'active' : 'pending' };
+  }
+
+  /**
+   * Leave a circle or take back a request. Owners can't leave.
+   */
+  static async leave(
+    circleId: string,
+    userId: string
+  ): Promise<'left' | 'not_member' | 'owner'> {
+    const membership = await prisma.circleMember.findUnique({
+      where: { circleId_userId: { circleId, userId } },
+    });
+    if (!membership) {
+      return 'not_member';
+    }
+    if (membership.role === 'owner') {
+      return 'owner';
+    }
+    await prisma.circleMember.delete({ where: { id: membership.id } });
+    return 'left';
+  }
+
+  /**
+   * Pending requests, oldest first, for the circle's owner
+   */
+  static async requests(circleId: string, ownerId: string) {
+    if (!(await isOwner(circleId, ownerId))) {
+      return null;
+    }
+    const pending = await prisma.circleMember.findMany({
+      where: { circleId, status: 'pending' },
+      include: {
+        user: { select: { id: true, handle: true, displayName: true } },
+      },
+      orderBy: { createdAt: 'asc' },
+    });
+    return pending.map(request => ({
+      userId: request.userId,
+      handle: request.user.handle,
+      displayName: request.user.displayName,
+      requestedAt: request.createdAt,
+    }));
+  }
+
+  /**
+   * The owner approves or declines a request to join
+   */
+  static async decide(
+    circleId: string,
+    ownerId: string,
+    userId: string,
+    approve: boolean
+  ): Promise<DecisionResult> {
+    if (!(await isOwner(circleId, ownerId))) {
+      return { status: 'not_allowed' };
+    }
+    const request = await prisma.circleMember.findUnique({
+      where: { circleId_userId: { circleId, userId } },
+      include: { circle: { select: { name: true, slug: true } } },
+    });
+    if (!request || request.status !== 'pending') {
+      return { status: 'not_found' };
+    }
+
+    if (!approve) {
+      await prisma.circleMember.delete({ where: { id: request.id } });
+      return { status: 'declined' };
+    }
+    await prisma.circleMember.update({
+      where: { id: request.id },
+      data: { status: 'active', approvedAt: new Date() },
+    });
+    await Notifications.notify(userId, {
+      type: 'circle_join_approved',
+      variables: { circle: request.circle.name },
+      path: `/circles/${request.circle.slug}`,
+      data: { circleId },
+      push: true,
+    });
+    return { status: 'approved' };
+  }
+
+  /**
+   * The other active members for the viewer's circle deck, or null unless
+   * the viewer is an active member
+   */
+  static async memberIds(
+    circleId: string,
+    viewerId: string
+  ): Promise<string[] | null> {
+    const membership = await prisma.circleMember.findUnique({
+      where: { circleId_userId: { circleId, userId: viewerId } },
+    });
+    if (membership?.status !== 'active') {
+      return null;
+    }
+    const members = await prisma.circleMember.findMany({
+      where: { circleId, status: 'active', userId: { not: viewerId } },
+      select: { userId: true },
+      take: MAX_DECK_MEMBERS,
+    });
+    return members.map(member => member.userId);
+  }
+
+  /**
+   * The circles each user is an active member of, for their profiles
+   */
+  static async forUsers(
+    userIds: string[]
+  ): Promise<Map<string, ProfileCircle[]>> {
+    const circles = new Map<string, ProfileCircle[]>();
+    if (userIds.length === 0) {
+      return circles;
+    }
+    const memberships = await prisma.circleMember.findMany({
+      where: { userId: { in: userIds }, status: 'active' },
+      include: { circle: { select: { id: true, slug: true, name: true } } },
+      orderBy: { approvedAt: 'asc' },
+    });
+    for (const membership of memberships) {
+      const list = circles.get(membership.userId) ?? [];
+      if (list.length < MAX_PROFILE_CIRCLES) {
+        list.push(membership.circle);
+        circles.set(membership.userId, list);
+      }
+    }
+    return circles;
+  }
+}
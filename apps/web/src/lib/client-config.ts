@@ -0,0 +1,120 @@
+/**
+ * Client Config
+ * Settings the miniapp fetches on startup instead of hardcoding: feature
+ * flags as they apply to the user, whether the app should upgrade, discovery
+ * quotas and where static assets and media are served from. Server code
+ * reads the same constants so the two can't drift. Campus instances can
+ * override the quotas (see Tenants).
+ */
+
+import { Tenant } from '@prisma/client';
+import { FeatureFlags, FLAG_DEFINITIONS } from './feature-flags';
+import { AppVersion, minimumVersion, recommendedVersion } from './app-version';
+import { Tenants } from './tenants';
+
+// Profiles per discovery deck
+export const DISCOVERY_DECK_SIZE = parseInt(
+  process.env.DISCOVERY_DECK_SIZE || '10'
+);
+
+// Top Picks every user gets per day before extra top picks apply
+export const FREE_DAILY_TOP_PICKS = parseInt(
+  process.env.FREE_DAILY_TOP_PICKS || '4'
+);
+
+// Super-likes every user gets per day before extra super-interests apply
+export const FREE_DAILY_SUPER_INTERESTS = parseInt(
+  process.env.FREE_DAILY_SUPER_INTERESTS || '1'
+);
+
+export interface DiscoveryQuotas {
+  deckSize: number;
+  freeDailySuperInterests: number;
+  freeDailyTopPicks: number;
+}
+
+/**
+ * The quotas in a tenant, or the defaults outside any
+ */
+export async function discoveryQuotas(
+  tenantId: string | null
+): Promise<DiscoveryQuotas> {
+  const tenant = await Tenants.get(tenantId);
+  return {
+    deckSize: tenant?.discoveryDeckSize ?? DISCOVERY_DECK_SIZE,
+    freeDailySuperInterests:
+      tenant?.freeDailySuperInterests ?? FREE_DAILY_SUPER_INTERESTS,
+    freeDailyTopPicks: tenant?.freeDailyTopPicks ?? FREE_DAILY_TOP_PICKS,
+  };
+}
+
+export interface FlagState {
+  enabled: boolean;
+  variant: string | null;
+}
+
+export interface ClientConfigPayload {
+  flags: Record<string, FlagState>;
+  app: {
+    minimumVersion: string | null;
+    recommendedVersion: string | null;
+    // Below the minimum: block the app until it's updated
+    forceUpgrade: boolean;
+    // Below the recommended version: suggest updating
+    softUpgrade: boolean;
+    upgradeUrl: string | null;
+  };
+  // The campus instance, or null for the default one
+  tenant: { id: string; name: string; campusId: string } | null;
+  discovery: DiscoveryQuotas;
+  assets: {
+    // Static app assets (icons, illustrations)
+    cdnBaseUrl: string | null;
+    // User-uploaded media (profile photos)
+    mediaBaseUrl: string | null;
+  };
+}
+
+export class ClientConfig {
+  /**
+   * The config for a user, or for a signed-out client, running the given
+   * app version in a tenant
+   */
+  static async build(
+    userId?: string,
+    appVersion?: string | null,
+    tenant: Tenant | null = null
+  ): Promise<ClientConfigPayload> {
+    const flags = await Promise.all(
+      Object.keys(FLAG_DEFINITIONS).map(async key => {
+        const [enabled, variant] = await Promise.all([
+          FeatureFlags.isEnabled(key, { userId }),
+          FeatureFlags.getVariant(key, { userId }),
+        ]);
+        return [key, { enabled, variant }] as const;
+      })
+    );
+
+    const versionStatus = AppVersion.status(appVersion);
+    return {
+      flags: Object.fromEntries(flags),
+      app: {
+        minimumVersion: minimumVersion(),
+        recommendedVersion: recommendedVersion(),
+        forceUpgrade: versionStatus === 'upgrade_required',
+        softUpgrade: versionStatus === 'upgrade_recommended',
+        upgradeUrl: process.env.APP_UPGRADE_URL || null,
+      },
+      tenant: tenant && {
+        id: tenant.id,
+        name: tenant.name,
+        campusId: tenant.campusId,
+      },
+      discovery: await discoveryQuotas(tenant?.id ?? null),
+      assets: {
+        cdnBaseUrl: process.env.ASSET_CDN_BASE_URL || null,
+        mediaBaseUrl: process.env.MEDIA_CDN_BASE_URL || null,
+      },
+    };
+  }
+}
@@ -0,0 +1,133 @@
+/**
+ * Client Events
+ * Product analytics events reported by the miniapp itself (screen views,
+ * swipe gestures), so we don't need a third-party SDK in the World App
+ * webview. Each event goes onto the event bus as `client.<type>`, where
+ * the analytics rollup counts it, and batches are also forwarded to an
+ * external warehouse when ANALYTICS_WAREHOUSE_URL is set.
+ */
+
+import { z } from 'zod';
+import { EventBus } from './event-bus';
+import { upstreamFetch } from './http-client';
+import { counter } from './metrics';
+import { SWIPE_ACTIONS } from './swipes';
+
+export const CLIENT_EVENT_TYPES = ['screen_view', 'swipe_action'] as const;
+
+export type ClientEventType = (typeof CLIENT_EVENT_TYPES)[number];
+
+export const MAX_CLIENT_EVENTS_PER_BATCH = 50;
+
+// Events older than this (e.g. from a long-offline client) are refused
+const MAX_EVENT_AGE_MS = 7 * 24 * 60 * 60 * 1000;
+// Tolerated client clock skew
+const MAX_CLOCK_SKEW_MS = 5 * 60 * 1000;
+
+const WAREHOUSE_TIMEOUT_MS = 5000;
+
+const occurredAt = z
+  .string()
+  .datetime()
+  .refine(value => {
+    const age = Date.now() - Date.parse(value);
+    return age <= MAX_EVENT_AGE_MS && age >= -MAX_CLOCK_SKEW_MS;
+  }, 'Event time is out of range');
+
+const common = {
+  // Client-generated, so consumers can drop retried duplicates
+  id: z.string().uuid(),
+  occurredAt,
+};
+
+export const clientEventSchema = z.discriminatedUnion('type', [
+  z.object({
+    ...common,
+    type: z.literal('screen_view'),
+    screen: z.string().min(1).max(64),
+    previousScreen: z.string().max(64).optional(),
+  }),
+  z.object({
+    ...common,
+    type: z.literal('swipe_action'),
+    profileId: z.string().min(1),
+    action: z.enum(SWIPE_ACTIONS),
+    // Position of the card in the deck, and how long it was shown
+    position: z.number().int().min(0).optional(),
+    dwellMs: z.number().int().min(0).optional(),
+  }),
+]);
+
+export type ClientEvent = z.infer<typeof clientEventSchema>;
+
+export const clientEventBatchSchema = z.object({
+  sessionId: z.string().max(64).optional(),
+  events: z.array(clientEventSchema).min(1).max(MAX_CLIENT_EVENTS_PER_BATCH),
+});
+
+export interface ClientContext {
+  userId: string;
+  sessionId?: string;
+  appVersion: string | null;
+}
+
+const ingestedCounter = counter(
+  'aurum_client_events_total',
+  'Client analytics events accepted, by type'
+);
+
+async function forwardToWarehouse(rows: object[]): Promise<void> {
+  const headers: Record<string, string> = {
+    'Content-Type': 'application/x-ndjson',
+  };
+  if (process.env.ANALYTICS_WAREHOUSE_TOKEN) {
+    headers.Authorization = `Bearer ${process.env.ANALYTICS_WAREHOUSE_TOKEN}`;
+  }
+
+  const response = await upstreamFetch(
+    'warehouse',
+    process.env.ANALYTICS_WAREHOUSE_URL!,
+    {
+      method: 'POST',
+      headers,
+      body: rows.map(row => JSON.stringify(row)).join('\n'),
+    },
+    { timeoutMs: WAREHOUSE_TIMEOUT_MS }
+  );
+  if (!response.ok) {
+    throw new Error(`Warehouse responded with HTTP ${response.status}`);
+  }
+}
+
+export class ClientEvents {
+  /**
+   * Accept a validated batch from a signed-in client
+   */
+  static async ingest(
+    events: ClientEvent[],
+    context: ClientContext
+  ): Promise<number> {
+    const rows = events.map(({ id, type, occurredAt, ...properties }) => ({
+      clientEventId: id,
+      type,
+      clientOccurredAt: occurredAt,
+      userId: context.userId,
+      sessionId: context.sessionId ?? null,
+      appVersion: context.appVersion,
+      ...properties,
+    }));
+
+    for (const row of rows) {
+      await EventBus.publish(`client.${row.type}`, row);
+      ingestedCounter.inc({ type: row.type });
+    }
+
+    // Fire-and-forget; the event stream already has everything
+    if (process.env.ANALYTICS_WAREHOUSE_URL) {
+      forwardToWarehouse(rows).catch(error => {
+        console.error('Error forwarding client events to warehouse:', error);
+      });
+    }
+    return rows.length;
+  }
+}
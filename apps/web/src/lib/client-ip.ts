@@ -0,0 +1,207 @@
+/**
+ * Client IP
+ * The address a request really came from, for rate limiting and audit
+ * logs. The app only sits behind proxies (nginx, and Cloudflare in front
+ * of it), which each append the address they got the request from to
+ * X-Forwarded-For, so the client is the last address in the chain that
+ * isn't one of our trusted proxies; anything further left was supplied by
+ * the client and can't be believed. X-Real-IP is only used when there's
+ * no X-Forwarded-For. Route handlers can't see the connection's own
+ * address, so the app must only be reachable through the proxies. Trusted
+ * proxies default to TRUSTED_PROXIES (or loopback and private networks),
+ * and admins can replace the list at runtime, e.g. when Cloudflare
+ * publishes new ranges, with no restart.
+ */
+
+import { BlockList, isIP } from 'net';
+import redis from './redis';
+import { AuditLog } from './audit-log';
+
+const OVERRIDE_KEY = 'trusted_proxies';
+
+// How long instances keep using the list they have before re-reading it
+const REFRESH_MS = 30 * 1000;
+
+const DEFAULT_TRUSTED_PROXIES = (
+  process.env.TRUSTED_PROXIES ||
+  '127.0.0.0/8,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,::1/128,fc00::/7'
+)
+  .split(',')
+  .map(cidr => cidr.trim())
+  .filter(Boolean);
+
+export interface TrustedProxies {
+  cidrs: string[];
+  // Whether an admin has replaced the configured list
+  overridden: boolean;
+}
+
+/**
+ * A bare address: no IPv4-mapped IPv6 prefix, port or brackets
+ */
+function normalizeIp(value: string): string | null {
+  let ip = value.trim();
+  if (ip.startsWith('[')) {
+    ip = ip.slice(1, ip.indexOf(']'));
+  } else if (ip.includes('.') && ip.includes(':') && !ip.includes('::')) {
+    ip = ip.slice(0, ip.lastIndexOf(':')); // 1.2.3.4:5678
+  }
+  if (ip.toLowerCase().startsWith('::ffff:') && isIP(ip.slice(7)) === 4) {
+    ip = ip.slice(7);
+  }
+  return isIP(ip) ? ip : null;
+}
+
+function parseCidr(cidr: string): [string, number, 'ipv4' | 'ipv6'] | null {
+  const [address, prefix, ...rest] = cidr.trim().split('/');
+  const version = isIP(address);
+  if (!version || rest.length > 0) {
+    return null;
+  }
+  const maxPrefix = version === 4 ? 32 : 128;
+  const bits = prefix === undefined ? maxPrefix : Number(prefix);
+  if (!Number.isInteger(bits) || bits < 0 || bits > maxPrefix) {
+    return null;
+  }
+  return [address, bits, version === 4 ? 'ipv4' : 'ipv6'];
+}
+
+function toBlockList(cidrs: string[]): BlockList {
+  const blockList = new BlockList();
+  for (const cidr of cidrs) {
+    const parsed = parseCidr(cidr);
+    if (parsed) {
+      blockList.addSubnet(...parsed);
+    }
+  }
+  return blockList;
+}
+
+// The configured list until the first refresh comes back
+let current = {
+  proxies: { cidrs: DEFAULT_TRUSTED_PROXIES, overridden: false },
+  blockList: toBlockList(DEFAULT_TRUSTED_PROXIES),
+};
+let loadedAt = 0;
+let refreshing = false;
+
+function use(proxies: TrustedProxies) {
+  current = { proxies, blockList: toBlockList(proxies.cidrs) };
+  loadedAt = Date.now();
+}
+
+async function load(): Promise<TrustedProxies> {
+  const data = await redis.get(OVERRIDE_KEY);
+  return data
+    ? { cidrs: JSON.parse(data) as string[], overridden: true }
+    : { cidrs: DEFAULT_TRUSTED_PROXIES, overridden: false };
+}
+
+/**
+ * Re-read the list in the background; until it's back (and if Redis is
+ * down), the previous list, or the configured one, stays in use
+ */
+function refreshInBackground() {
+  if (refreshing || Date.now() - loadedAt < REFRESH_MS) {
+    return;
+  }
+  refreshing = true;
+  load()
+    .then(use)
+    .catch(error => {
+      console.error('Error loading trusted proxies:', error);
+      loadedAt = Date.now();
+    })
+    .finally(() => {
+      refreshing = false;
+    });
+}
+
+export class ClientIp {
+  static isValidCidr(cidr: string): boolean {
+    return parseCidr(cidr) !== null;
+  }
+
+  /**
+   * Whether an address belongs to one of our proxies
+   */
+  static isTrustedProxy(ip: string): boolean {
+    refreshInBackground();
+    const address = normalizeIp(ip);
+    if (!address) {
+      return false;
+    }
+    return current.blockList.check(
+      address,
+      isIP(address) === 4 ? 'ipv4' : 'ipv6'
+    );
+  }
+
+  /**
+   * The client's address, or null if the request carries none
+   */
+  static of(request: Request): string | null {
+    const forwarded = request.headers.get('x-forwarded-for');
+    const chain = (forwarded || request.headers.get('x-real-ip') || '')
+      .split(',')
+      .map(normalizeIp)
+      .filter((ip): ip is string => ip !== null);
+    if (chain.length === 0) {
+      return null;
+    }
+
+    // Walk back from the hop nearest us until one isn't ours
+    for (let i = chain.length - 1; i > 0; i--) {
+      if (!ClientIp.isTrustedProxy(chain[i])) {
+        return chain[i];
+      }
+    }
+    return chain[0];
+  }
+
+  /**
+   * The trusted proxy list in effect
+   */
+  static async trustedProxies(): Promise<TrustedProxies> {
+    const proxies = await load();
+    use(proxies);
+    return proxies;
+  }
+
+  /**
+   * Replace the trusted proxy list. Other instances pick it up on their
+   * next refresh.
+   */
+  static async setTrustedProxies(
+    cidrs: string[],
+    adminId: string
+  ): Promise<TrustedProxies> {
+    await redis.set(OVERRIDE_KEY, JSON.stringify(cidrs));
+    const proxies = { cidrs, overridden: true };
+    use(proxies);
+
+    await AuditLog.record({
+      action: 'admin.trusted_proxies_updated',
+      actorType: 'admin',
+      actorId: adminId,
+      details: { cidrs },
+    });
+    return proxies;
+  }
+
+  /**
+   * Go back to the configured list
+   */
+  static async resetTrustedProxies(adminId: string): Promise<TrustedProxies> {
+    await redis.del(OVERRIDE_KEY);
+    const proxies = { cidrs: DEFAULT_TRUSTED_PROXIES, overridden: false };
+    use(proxies);
+
+    await AuditLog.record({
+      action: 'admin.trusted_proxies_reset',
+      actorType: 'admin',
+      actorId: adminId,
+    });
+    return proxies;
+  }
+}
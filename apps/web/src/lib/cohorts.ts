@@ -0,0 +1,224 @@
+/**
+ * Cohorts
+ * Soft-launch gating. While the soft_launch_gate flag is on, new accounts
+ * start on the waitlist and can't use the app beyond their own account
+ * until an admin admits them, oldest first and in batches. Admitted users
+ * are invited: they're in, as part of the launch cohort, until the cohort
+ * is released to full access. Accounts created while the gate is off, or
+ * before it existed, have full access. People who signed up to the
+ * waitlist before making an account (see Waitlist) keep their place in
+ * line, or are invited straight away if their entry was admitted.
+ * Enforced by `authMiddleware`; turning the flag off lets everyone in.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { FeatureFlags } from './feature-flags';
+import { Notifications } from './notifications';
+
+export const COHORTS = ['waitlist', 'invited', 'full'] as const;
+
+export type Cohort = (typeof COHORTS)[number];
+
+export const SOFT_LAUNCH_GATE_FLAG = 'soft_launch_gate';
+
+// Most users one admission can let in
+export const MAX_ADMISSION_BATCH = 1000;
+
+export interface CohortStatus {
+  cohort: Cohort;
+  // Whether the gate is currently holding the user back
+  gated: boolean;
+  // 1-based place in the queue while on the waitlist
+  position: number | null;
+  waitlistSize: number;
+  waitlistedAt: string | null;
+  admittedAt: string | null;
+}
+
+export interface AdmissionOptions {
+  // Admit this many from the front of the queue...
+  count?: number;
+  // ...optionally only from one campus
+  campusId?: string;
+  // ...or these users, wherever they are in it
+  userIds?: string[];
+}
+
+// Waitlisted accounts still in the running for admission
+const queuedWhere: Prisma.UserWhereInput = {
+  cohort: 'waitlist',
+  status: 'active',
+  deletedAt: null,
+};
+
+export class Cohorts {
+  static async isGateEnabled(): Promise<boolean> {
+    return FeatureFlags.isEnabled(SOFT_LAUNCH_GATE_FLAG);
+  }
+
+  /**
+   * Cohort fields for an account being created now for a World ID
+   */
+  static async signupFields(worldId: string): Promise<{
+    cohort: Cohort;
+    waitlistedAt: Date | null;
+    admittedAt: Date | null;
+  }> {
+    if (!(await Cohorts.isGateEnabled())) {
+      return { cohort: 'full', waitlistedAt: null, admittedAt: null };
+    }
+
+    const entry = await prisma.waitlistEntry.findUnique({
+      where: { worldId },
+    });
+    if (entry?.status === 'admitted') {
+      return {
+        cohort: 'invited',
+        waitlistedAt: entry.createdAt,
+        admittedAt: entry.admittedAt,
+      };
+    }
+    return {
+      cohort: 'waitlist',
+      waitlistedAt: entry?.createdAt ?? new Date(),
+      admittedAt: null,
+    };
+  }
+
+  /**
+   * Whether the gate is holding the user on the waitlist
+   */
+  static async isWaitlisted(userId: string): Promise<boolean> {
+    if (!(await Cohorts.isGateEnabled())) {
+      return false;
+    }
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { cohort: true },
+    });
+    return user?.cohort === 'waitlist';
+  }
+
+  /**
+   * The user's cohort and, while waitlisted, where they are in the queue
+   */
+  static async status(userId: string): Promise<CohortStatus | null> {
+    const [user, gateEnabled, waitlistSize] = await Promise.all([
+      prisma.user.findUnique({
+        where: { id: userId },
+        select: { cohort: true, waitlistedAt: true, admittedAt: true },
+      }),
+      Cohorts.isGateEnabled(),
+      prisma.user.count({ where: queuedWhere }),
+    ]);
+    if (!user) {
+      return null;
+    }
+
+    const waitlisted = user.cohort === 'waitlist';
+    const ahead =
+      waitlisted && user.waitlistedAt
+        ? await prisma.user.count({
+            where: { ...queuedWhere, waitlistedAt: { lt: user.waitlistedAt } },
+          })
+        : null;
+
+    return {
+      cohort: user.cohort as Cohort,
+      gated: waitlisted && gateEnabled,
+      position: ahead === null ? null : ahead + 1,
+      waitlistSize,
+      waitlistedAt: user.waitlistedAt?.toISOString() ?? null,
+      admittedAt: user.admittedAt?.toISOString() ?? null,
+    };
+  }
+
+  /**
+   * How many accounts are in each cohort
+   */
+  static async summary(): Promise<Record<Cohort, number>> {
+    const groups = await prisma.user.groupBy({
+      by: ['cohort'],
+      where: { status: { not: 'deleted' }, deletedAt: null },
+      _count: { _all: true },
+    });
+    const counts = { waitlist: 0, invited: 0, full: 0 };
+    for (const group of groups) {
+      counts[group.cohort as Cohort] = group._count._all;
+    }
+    return counts;
+  }
+
+  /**
+   * Let a batch in off the waitlist and tell them. Returns who was
+   * admitted.
+   */
+  static async admit(
+    options: AdmissionOptions,
+    adminId: string
+  ): Promise<string[]> {
+    const queued = await prisma.user.findMany({
+      where: {
+        ...queuedWhere,
+        ...(options.campusId && { campusId: options.campusId }),
+        ...(options.userIds && { id: { in: options.userIds } }),
+      },
+      select: { id: true },
+      orderBy: { waitlistedAt: 'asc' },
+      take: Math.min(
+        options.userIds?.length ?? options.count ?? 0,
+        MAX_ADMISSION_BATCH
+      ),
+    });
+    const userIds = queued.map(user => user.id);
+    if (userIds.length === 0) {
+      return [];
+    }
+
+    // Scoped to the waitlist again in case anyone was admitted meanwhile
+    await prisma.user.updateMany({
+      where: { id: { in: userIds }, cohort: 'waitlist' },
+      data: { cohort: 'invited', admittedAt: new Date() },
+    });
+
+    await AuditLog.record({
+      action: 'admin.cohort_admitted',
+      actorType: 'admin',
+      actorId: adminId,
+      details: {
+        count: userIds.length,
+        campusId: options.campusId ?? null,
+        userIds,
+      },
+    });
+
+    for (const userId of userIds) {
+      await Notifications.notify(userId, {
+        type: 'cohort_admitted',
+        path: '/discover',
+        push: true,
+      });
+    }
+    return userIds;
+  }
+
+  /**
+   * Move everyone invited so far to full access; returns how many moved
+   */
+  static async release(adminId: string): Promise<number> {
+    const released = await prisma.user.updateMany({
+      where: { cohort: 'invited' },
+      data: { cohort: 'full' },
+    });
+
+    await AuditLog.record({
+      action: 'admin.cohort_released',
+      actorType: 'admin',
+      actorId: adminId,
+      details: { count: released.count },
+    });
+    return released.count;
+  }
+}
@@ -0,0 +1,312 @@
+/**
+ * Compatibility Quiz
+ * Short multiple-choice questions users answer about themselves, marking
+ * which answers they'd accept from a match and how much the question
+ * matters to them. Two users' compatibility is how well each satisfies the
+ * other's weighted preferences (the geometric mean of both sides), over
+ * the questions both answered. Where the ML API scored the pair, the quiz
+ * score can be blended with it.
+ */
+
+import { QuizAnswer } from '@prisma/client';
+import { z } from 'zod';
+import prisma from './prisma';
+
+export interface QuizQuestion {
+  id: string;
+  prompt: string;
+  options: { id: string; label: string }[];
+}
+
+// Add new questions at the end; never change an existing question's
+// meaning or option IDs, since stored answers refer to them
+export const QUIZ_QUESTIONS: QuizQuestion[] = [
+  {
+    id: 'weekend',
+    prompt: 'Your ideal weekend?',
+    options: [
+      { id: 'out', label: 'Out with friends' },
+      { id: 'adventure', label: 'Somewhere new' },
+      { id: 'home', label: 'At home, recharging' },
+      { id: 'work', label: 'Catching up on work' },
+    ],
+  },
+  {
+    id: 'sleep',
+    prompt: 'Early bird or night owl?',
+    options: [
+      { id: 'early', label: 'Early bird' },
+      { id: 'night', label: 'Night owl' },
+      { id: 'depends', label: 'Depends on the day' },
+    ],
+  },
+  {
+    id: 'texting',
+    prompt: 'How often do you like to text someone you are seeing?',
+    options: [
+      { id: 'all_day', label: 'Throughout the day' },
+      { id: 'daily', label: 'Once or twice a day' },
+      { id: 'rarely', label: 'Only to make plans' },
+    ],
+  },
+  {
+    id: 'looking_for',
+    prompt: 'What are you looking for?',
+    options: [
+      { id: 'relationship', label: 'A relationship' },
+      { id: 'dating', label: 'Dating, seeing where it goes' },
+      { id: 'friends', label: 'New friends' },
+    ],
+  },
+  {
+    id: 'study',
+    prompt: 'Study style?',
+    options: [
+      { id: 'library', label: 'Quiet library' },
+      { id: 'cafe', label: 'Busy cafe' },
+      { id: 'group', label: 'Study group' },
+      { id: 'last_minute', label: 'Night before the exam' },
+    ],
+  },
+  {
+    id: 'drinking',
+    prompt: 'Do you drink?',
+    options: [
+      { id: 'often', label: 'Often' },
+      { id: 'socially', label: 'Socially' },
+      { id: 'never', label: 'Never' },
+    ],
+  },
+  {
+    id: 'splitting',
+    prompt: 'On a date, the bill is...',
+    options: [
+      { id: 'split', label: 'Split' },
+      { id: 'alternate', label: 'Taken in turns' },
+      { id: 'inviter', label: 'On whoever asked' },
+    ],
+  },
+  {
+    id: 'plans',
+    prompt: 'After graduation?',
+    options: [
+      { id: 'stay', label: 'Stay in the city' },
+      { id: 'abroad', label: 'Move abroad' },
+      { id: 'home', label: 'Go back home' },
+      { id: 'unsure', label: 'No idea yet' },
+    ],
+  },
+];
+
+export const QUIZ_IMPORTANCE = [
+  'irrelevant',
+  'a_little',
+  'somewhat',
+  'very',
+] as const;
+
+export type QuizImportance = (typeof QUIZ_IMPORTANCE)[number];
+
+// Steep on purpose: one "very important" mismatch should outweigh several
+// minor ones
+const IMPORTANCE_WEIGHTS: Record<QuizImportance, number> = {
+  irrelevant: 0,
+  a_little: 1,
+  somewhat: 10,
+  very: 50,
+};
+
+// Fewer shared answers than this say too little to show a score
+export const MIN_COMMON_ANSWERS = 3;
+
+// Share of the ML pair score in the blended score (0 disables blending)
+export const QUIZ_ML_BLEND_WEIGHT = parseFloat(
+  process.env.QUIZ_ML_BLEND_WEIGHT || '0.3'
+);
+
+const questionsById = new Map(
+  QUIZ_QUESTIONS.map(question => [question.id, question])
+);
+
+export const quizAnswerSchema = z
+  .object({
+    questionId: z.string(),
+    answer: z.string(),
+    // Answers the user would accept from a match (their own is implied)
+    acceptable: z.array(z.string()).min(1),
+    importance: z.enum(QUIZ_IMPORTANCE),
+  })
+  .superRefine((value, ctx) => {
+    const question = questionsById.get(value.questionId);
+    if (!question) {
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        path: ['questionId'],
+        message: 'Unknown question',
+      });
+      return;
+    }
+    const optionIds = new Set(question.options.map(option => option.id));
+    if (!optionIds.has(value.answer)) {
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        path: ['answer'],
+        message: 'Not an option for this question',
+      });
+    }
+    if (value.acceptable.some(id => !optionIds.has(id))) {
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        path: ['acceptable'],
+        message: 'Not an option for this question',
+      });
+    }
+  });
+
+export type QuizAnswerInput = z.infer<typeof quizAnswerSchema>;
+
+export interface StoredQuizAnswer {
+  questionId: string;
+  answer: string;
+  acceptable: string[];
+  importance: QuizImportance;
+  answeredAt: Date;
+}
+
+function toStored(answer: QuizAnswer): StoredQuizAnswer {
+  return {
+    questionId: answer.questionId,
+    answer: answer.answer,
+    acceptable: answer.acceptable as string[],
+    importance: answer.importance as QuizImportance,
+    answeredAt: answer.answeredAt,
+  };
+}
+
+/**
+ * How well `other` satisfies `own`'s preferences on the shared questions,
+ * 0-1 (1 when none of them matter to `own`)
+ */
+function satisfaction(
+  own: Map<string, StoredQuizAnswer>,
+  other: Map<string, StoredQuizAnswer>,
+  shared: string[]
+): number {
+  let possible = 0;
+  let earned = 0;
+  for (const questionId of shared) {
+    const mine = own.get(questionId)!;
+    const weight = IMPORTANCE_WEIGHTS[mine.importance];
+    possible += weight;
+    if (mine.acceptable.includes(other.get(questionId)!.answer)) {
+      earned += weight;
+    }
+  }
+  return possible === 0 ? 1 : earned / possible;
+}
+
+/**
+ * Quiz compatibility between two users' answers, 0-1, or null if they
+ * share too few answered questions
+ */
+export function quizCompatibility(
+  a: Map<string, StoredQuizAnswer>,
+  b: Map<string, StoredQuizAnswer>
+): number | null {
+  const shared = Array.from(a.keys()).filter(id => b.has(id));
+  if (shared.length < MIN_COMMON_ANSWERS) {
+    return null;
+  }
+  return Math.sqrt(satisfaction(a, b, shared) * satisfaction(b, a, shared));
+}
+
+async function loadAnswers(
+  userIds: string[]
+): Promise<Map<string, Map<string, StoredQuizAnswer>>> {
+  const answers = await prisma.quizAnswer.findMany({
+    where: { userId: { in: userIds } },
+  });
+  const byUser = new Map<string, Map<string, StoredQuizAnswer>>();
+  for (const answer of answers) {
+    const own = byUser.get(answer.userId) ?? new Map();
+    own.set(answer.questionId, toStored(answer));
+    byUser.set(answer.userId, own);
+  }
+  return byUser;
+}
+
+export class CompatibilityQuiz {
+  static async getAnswers(userId: string): Promise<StoredQuizAnswer[]> {
+    const answers = await prisma.quizAnswer.findMany({
+      where: { userId },
+      orderBy: { answeredAt: 'asc' },
+    });
+    return answers.map(toStored);
+  }
+
+  /**
+   * Save (or change) the user's answers
+   */
+  static async saveAnswers(
+    userId: string,
+    answers: QuizAnswerInput[]
+  ): Promise<StoredQuizAnswer[]> {
+    const saved = await prisma.$transaction(
+      answers.map(answer => {
+        // A user always accepts their own answer
+        const acceptable = new Set([answer.answer, ...answer.acceptable]);
+        const data = {
+          answer: answer.answer,
+          acceptable: Array.from(acceptable),
+          importance: answer.importance,
+        };
+        return prisma.quizAnswer.upsert({
+          where: {
+            userId_questionId: { userId, questionId: answer.questionId },
+          },
+          create: { userId, questionId: answer.questionId, ...data },
+          update: data,
+        });
+      })
+    );
+    return saved.map(toStored);
+  }
+
+  /**
+   * The viewer's compatibility with each of `userIds` as a percentage,
+   * blended with the ML pair score where `mlScores` has one (0-1). Users
+   * with too few shared answers are left out.
+   */
+  static async scores(
+    viewerId: string,
+    userIds: string[],
+    mlScores: Map<string, number> = new Map()
+  ): Promise<Map<string, number>> {
+    const scores = new Map<string, number>();
+    if (userIds.length === 0) {
+      return scores;
+    }
+
+    const answers = await loadAnswers([viewerId, ...userIds]);
+    const viewer = answers.get(viewerId);
+    if (!viewer) {
+      return scores;
+    }
+
+    for (const userId of userIds) {
+      const other = answers.get(userId);
+      const quiz = other ? quizCompatibility(viewer, other) : null;
+      if (quiz === null) {
+        continue;
+      }
+      const ml = mlScores.get(userId);
+      const blended =
+        ml === undefined
+          ? quiz
+          : quiz * (1 - QUIZ_ML_BLEND_WEIGHT) +
+            Math.min(Math.max(ml, 0), 1) * QUIZ_ML_BLEND_WEIGHT;
+      scores.set(userId, Math.round(blended * 100));
+    }
+    return scores;
+  }
+}
@@ -0,0 +1,220 @@
+/**
+ * Contact Exchange
+ * Lets two matched users swap a Telegram handle and/or phone number once
+ * both of them want to. Each side consents on their own by submitting
+ * their details, which are stored encrypted (AES-256-GCM under
+ * CONTACT_ENCRYPTION_KEY). Nothing is shown to the other person until
+ * the second consent, which releases both sides in one transaction.
+ * Consent can be withdrawn until then; a release can't be undone.
+ */
+
+import { createCipheriv, createDecipheriv, randomBytes } from 'crypto';
+import prisma from './prisma';
+import { Notifications } from './notifications';
+
+export interface ContactDetails {
+  telegram?: string;
+  // E.164
+  phone?: string;
+}
+
+export type ConsentResult =
+  | { status: 'consented'; released: boolean }
+  | { status: 'not_found' }
+  | { status: 'already_released' }
+  | { status: 'unavailable' };
+
+export type WithdrawResult =
+  | { status: 'withdrawn' }
+  | { status: 'not_found' }
+  | { status: 'already_released' };
+
+const CIPHER = 'aes-256-gcm';
+const IV_BYTES = 12;
+const TAG_BYTES = 16;
+
+function encryptionKey(): Buffer | null {
+  const key = process.env.CONTACT_ENCRYPTION_KEY;
+  if (!key) {
+    return null;
+  }
+  const bytes = Buffer.from(key, 'base64');
+  return bytes.length === 32 ? bytes : null;
+}
+
+/**
+ * Whether a valid encryption key is configured in this deployment
+ */
+export function contactExchangeEnabled(): boolean {
+  return encryptionKey() !== null;
+}
+
+function seal(details: ContactDetails, key: Buffer): string {
+  const iv = randomBytes(IV_BYTES);
+  const cipher = createCipheriv(CIPHER, key, iv);
+  const ciphertext = Buffer.concat([
+    cipher.update(JSON.stringify(details), 'utf8'),
+    cipher.final(),
+  ]);
+  return Buffer.concat([iv, cipher.getAuthTag(), ciphertext]).toString(
+    'base64'
+  );
+}
+
+function unseal(sealed: string, key: Buffer): ContactDetails {
+  const bytes = Buffer.from(sealed, 'base64');
+  const decipher = createDecipheriv(CIPHER, key, bytes.subarray(0, IV_BYTES));
+  decipher.setAuthTag(bytes.subarray(IV_BYTES, IV_BYTES + TAG_BYTES));
+  const plaintext = Buffer.concat([
+    decipher.update(bytes.subarray(IV_BYTES + TAG_BYTES)),
+    decipher.final(),
+  ]);
+  return JSON.parse(plaintext.toString('utf8'));
+}
+
+async function liveMatch(matchId: string, userId: string) {
+  return prisma.match.findFirst({
+    where: {
+      id: matchId,
+      deletedAt: null,
+      OR: [{ user1Id: userId }, { user2Id: userId }],
+    },
+    select: { id: true, user1Id: true, user2Id: true },
+  });
+}
+
+export class ContactExchange {
+  /**
+   * Where the exchange stands for the user, with the other person's
+   * details once released. Null if the match isn't theirs.
+   */
+  static async status(matchId: string, userId: string) {
+    const match = await liveMatch(matchId, userId);
+    if (!match) {
+      return null;
+    }
+    const otherId = match.user1Id === userId ? match.user2Id : match.user1Id;
+    const shares = await prisma.contactShare.findMany({
+      where: { matchId },
+    });
+    const own = shares.find(share => share.userId === userId);
+    const theirs = shares.find(share => share.userId === otherId);
+    const released = Boolean(own?.releasedAt);
+    const key = encryptionKey();
+
+    return {
+      enabled: key !== null,
+      consented: Boolean(own),
+      // The other person has consented and is waiting on the user
+      requested: Boolean(theirs) && !released,
+      released,
+      releasedAt: own?.releasedAt ?? null,
+      own: own && key ? unseal(own.encrypted, key) : null,
+      theirs: released && theirs && key ? unseal(theirs.encrypted, key) : null,
+    };
+  }
+
+  /**
+   * Consent to the exchange with the user's details (replacing any given
+   * before). Releases both sides if the other person already consented.
+   */
+  static async consent(
+    matchId: string,
+    userId: string,
+    details: ContactDetails
+  ): Promise<ConsentResult> {
+    const key = encryptionKey();
+    if (!key) {
+      return { status: 'unavailable' };
+    }
+    const match = await liveMatch(matchId, userId);
+    if (!match) {
+      return { status: 'not_found' };
+    }
+    const otherId = match.user1Id === userId ? match.user2Id : match.user1Id;
+    const encrypted = seal(details, key);
+
+    const outcome = await prisma.$transaction(async tx => {
+      const existing = await tx.contactShare.findUnique({
+        where: { matchId_userId: { matchId, userId } },
+      });
+      if (existing?.releasedAt) {
+        return 'already_released' as const;
+      }
+      await tx.contactShare.upsert({
+        where: { matchId_userId: { matchId, userId } },
+        create: { matchId, userId, encrypted },
+        update: { encrypted, consentedAt: new Date() },
+      });
+
+      const theirs = await tx.contactShare.findUnique({
+        where: { matchId_userId: { matchId, userId: otherId } },
+      });
+      if (!theirs) {
+        return 'waiting' as const;
+      }
+      await tx.contactShare.updateMany({
+        where: { matchId, releasedAt: null },
+        data: { releasedAt: new Date() },
+      });
+      return 'released' as const;
+    });
+    if (outcome === 'already_released') {
+      return { status: 'already_released' };
+    }
+
+    const released = outcome === 'released';
+    await Notifications.notify(otherId, {
+      type: released
+        ? 'contact_exchange_released'
+        : 'contact_exchange_requested',
+      path: `/matches/${matchId}`,
+      data: { matchId },
+      push: true,
+    });
+    return { status: 'consented', released };
+  }
+
+  /**
+   * Take back consent (and the details with it) before the exchange is
+   * released
+   */
+  static async withdraw(
+    matchId: string,
+    userId: string
+  ): Promise<WithdrawResult> {
+    const share = await prisma.contactShare.findUnique({
+      where: { matchId_userId: { matchId, userId } },
+    });
+    if (!share) {
+      return { status: 'not_found' };
+    }
+    if (share.releasedAt) {
+      return { status: 'already_released' };
+    }
+    // Guarded on releasedAt in case the other side just released it
+    const removed = await prisma.contactShare.deleteMany({
+      where: { id: share.id, releasedAt: null },
+    });
+    return removed.count > 0
+      ? { status: 'withdrawn' }
+      : { status: 'already_released' };
+  }
+
+  /**
+   * The details the user has shared, per match, for their data export
+   */
+  static async exportFor(userId: string) {
+    const key = encryptionKey();
+    const shares = await prisma.contactShare.findMany({
+      where: { userId },
+      orderBy: { consentedAt: 'asc' },
+    });
+    return shares.map(share => ({
+      matchId: share.matchId,
+      consentedAt: share.consentedAt,
+      releasedAt: share.releasedAt,
+      details: key ? unseal(share.encrypted, key) : null,
+    }));
+  }
+}
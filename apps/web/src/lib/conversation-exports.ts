@@ -0,0 +1,228 @@
+/**
+ * Conversation Exports
+ * Lets either person in a match download a transcript of their
+ * conversation: the messages the app holds for the pair (sent with their
+ * signals), as plain text. Transcripts are put together by the scheduler
+ * worker, so asking for one only queues it; asking again once it's ready
+ * downloads it. A transcript is deleted as soon as it's downloaded, and
+ * after CONVERSATION_EXPORT_TTL_HOURS if it never is. Only the person who
+ * asked can download it, and it holds only what they could already see.
+ */
+
+import { ConversationExport } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { Scheduler, ScheduledTask } from './scheduler';
+
+const EXPORT_TTL_MS =
+  parseInt(process.env.CONVERSATION_EXPORT_TTL_HOURS || '24') * 60 * 60 * 1000;
+
+interface ExportJob {
+  exportId: string;
+}
+
+export type ExportResult =
+  | { status: 'pending'; requestedAt: Date }
+  | { status: 'ready'; filename: string; transcript: string }
+  | { status: 'not_found' };
+
+// Per request, as a failed export is queued again under the same ID
+const buildJobId = (conversationExport: ConversationExport) =>
+  `conversation-export-${conversationExport.id}-` +
+  conversationExport.createdAt.getTime();
+const expiryJobId = (exportId: string) =>
+  `conversation-export-expiry-${exportId}`;
+
+async function findMatch(matchId: string, userId: string) {
+  return prisma.match.findFirst({
+    where: {
+      id: matchId,
+      deletedAt: null,
+      OR: [{ user1Id: userId }, { user2Id: userId }],
+    },
+    include: {
+      user1: { select: { id: true, displayName: true } },
+      user2: { select: { id: true, displayName: true } },
+    },
+  });
+}
+
+async function queue(conversationExport: ConversationExport) {
+  await Scheduler.scheduleAt(
+    conversationExportBuild.name,
+    { exportId: conversationExport.id },
+    new Date(),
+    buildJobId(conversationExport)
+  );
+}
+
+/**
+ * The transcript, as the requester sees the conversation
+ */
+async function transcribe(
+  matchId: string,
+  userId: string
+): Promise<string | null> {
+  const match = await findMatch(matchId, userId);
+  if (!match) {
+    return null;
+  }
+  const other = match.user1Id === userId ? match.user2 : match.user1;
+
+  const messages = await prisma.signal.findMany({
+    where: {
+      OR: [
+        { fromUserId: userId, toUserId: other.id },
+        // Suppressed messages were never delivered to the requester
+        { fromUserId: other.id, toUserId: userId, suppressed: false },
+      ],
+      // Unsent messages stay in as tombstones
+      NOT: { message: null, messageDeletedAt: null },
+      deletedAt: null,
+    },
+    orderBy: { sentAt: 'asc' },
+  });
+
+  const lines = [
+    `Conversation with ${other.displayName}`,
+    `Matched ${match.matchedAt.toISOString()}`,
+    `Exported ${new Date().toISOString()}`,
+    '',
+    ...messages.map(
+      message =>
+        `[${message.sentAt.toISOString()}] ` +
+        `${message.fromUserId === userId ? 'You' : other.displayName}: ` +
+        (message.messageDeletedAt ? '(message deleted)' : message.message)
+    ),
+  ];
+  if (messages.length === 0) {
+    lines.push('No messages yet.');
+  }
+  return lines.join('\n') + '\n';
+}
+
+export class ConversationExports {
+  /**
+   * The user's transcript of the match, once it's ready; until then, queue
+   * one (or report the one queued). A ready transcript is deleted as it's
+   * handed over.
+   */
+  static async fetch(matchId: string, userId: string): Promise<ExportResult> {
+    if (!(await findMatch(matchId, userId))) {
+      return { status: 'not_found' };
+    }
+
+    const existing = await prisma.conversationExport.findUnique({
+      where: { matchId_userId: { matchId, userId } },
+    });
+
+    if (existing?.status === 'ready' && existing.transcript !== null) {
+      // Only one request gets to download it
+      const { count } = await prisma.conversationExport.deleteMany({
+        where: { id: existing.id, status: 'ready' },
+      });
+      if (count === 1) {
+        await Scheduler.cancel(expiryJobId(existing.id));
+        await AuditLog.record({
+          action: 'privacy.conversation_exported',
+          actorType: 'user',
+          actorId: userId,
+          targetType: 'match',
+          targetId: matchId,
+        });
+        return {
+          status: 'ready',
+          filename: `conversation-${matchId}.txt`,
+          transcript: existing.transcript,
+        };
+      }
+    }
+
+    if (existing?.status === 'pending') {
+      return { status: 'pending', requestedAt: existing.createdAt };
+    }
+
+    // New, or the last attempt failed
+    const conversationExport = await prisma.conversationExport.upsert({
+      where: { matchId_userId: { matchId, userId } },
+      create: { matchId, userId },
+      update: { status: 'pending', transcript: null, createdAt: new Date() },
+    });
+    await queue(conversationExport);
+    return { status: 'pending', requestedAt: conversationExport.createdAt };
+  }
+
+  /**
+   * Put the transcript together. A match deleted meanwhile drops the
+   * export.
+   */
+  static async build(
+    exportId: string,
+    finalAttempt: boolean
+  ): Promise<{ built: string } | { skipped: string }> {
+    const conversationExport = await prisma.conversationExport.findUnique({
+      where: { id: exportId },
+    });
+    if (conversationExport?.status !== 'pending') {
+      return { skipped: 'not_pending' };
+    }
+
+    let transcript: string | null;
+    try {
+      transcript = await transcribe(
+        conversationExport.matchId,
+        conversationExport.userId
+      );
+    } catch (error) {
+      // Out of retries: the next request starts over
+      if (finalAttempt) {
+        await prisma.conversationExport.update({
+          where: { id: exportId },
+          data: { status: 'failed' },
+        });
+      }
+      throw error;
+    }
+    if (transcript === null) {
+      await prisma.conversationExport.delete({ where: { id: exportId } });
+      return { skipped: 'match_gone' };
+    }
+
+    const expiresAt = new Date(Date.now() + EXPORT_TTL_MS);
+    await prisma.conversationExport.update({
+      where: { id: exportId },
+      data: { status: 'ready', transcript, expiresAt },
+    });
+    await Scheduler.scheduleAt(
+      conversationExportExpiry.name,
+      { exportId },
+      expiresAt,
+      expiryJobId(exportId)
+    );
+    return { built: exportId };
+  }
+
+  /**
+   * Delete a transcript nobody downloaded in time
+   */
+  static async expire(exportId: string): Promise<{ expired: number }> {
+    const { count } = await prisma.conversationExport.deleteMany({
+      where: { id: exportId, expiresAt: { lte: new Date() } },
+    });
+    return { expired: count };
+  }
+}
+
+export const conversationExportBuild: ScheduledTask<ExportJob> = {
+  name: 'conversation-export-build',
+  run: ({ exportId }, job) =>
+    ConversationExports.build(
+      exportId,
+      job.attemptsMade + 1 >= (job.opts.attempts ?? 1)
+    ),
+};
+
+export const conversationExportExpiry: ScheduledTask<ExportJob> = {
+  name: 'conversation-export-expiry',
+  run: ({ exportId }) => ConversationExports.expire(exportId),
+};
@@ -0,0 +1,66 @@
+/**
+ * Conversation Mutes
+ * A user can mute a conversation, for good or until a given time. Muting
+ * only silences it: messages still arrive, but nothing about the
+ * conversation is pushed to the user's phone. The chat service checks it
+ * before pushing a new message, as do scheduled messages and reminders
+ * (see lib/scheduled-messages).
+ */
+
+import { ConversationMute } from '@prisma/client';
+import prisma from './prisma';
+
+export type MuteResult =
+  | { status: 'muted'; mute: ConversationMute }
+  | { status: 'not_found' };
+
+export class ConversationMutes {
+  /**
+   * Mute the match's conversation for the user, until `until` or until
+   * they unmute it
+   */
+  static async mute(
+    matchId: string,
+    userId: string,
+    until: Date | null
+  ): Promise<MuteResult> {
+    const match = await prisma.match.findFirst({
+      where: {
+        id: matchId,
+        deletedAt: null,
+        OR: [{ user1Id: userId }, { user2Id: userId }],
+      },
+      select: { id: true },
+    });
+    if (!match) {
+      return { status: 'not_found' };
+    }
+
+    const mute = await prisma.conversationMute.upsert({
+      where: { matchId_userId: { matchId, userId } },
+      create: { matchId, userId, until },
+      update: { until },
+    });
+    return { status: 'muted', mute };
+  }
+
+  /**
+   * Unmute; returns false if it wasn't muted
+   */
+  static async unmute(matchId: string, userId: string): Promise<boolean> {
+    const removed = await prisma.conversationMute.deleteMany({
+      where: { matchId, userId },
+    });
+    return removed.count > 0;
+  }
+
+  /**
+   * Whether the user has the match's conversation muted right now
+   */
+  static async isMuted(matchId: string, userId: string): Promise<boolean> {
+    const mute = await prisma.conversationMute.findUnique({
+      where: { matchId_userId: { matchId, userId } },
+    });
+    return Boolean(mute && (!mute.until || mute.until > new Date()));
+  }
+}
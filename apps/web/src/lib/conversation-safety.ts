@@ -0,0 +1,204 @@
+/**
+ * Conversation Safety
+ * Safety tools from inside a conversation. Reporting a conversation files
+ * a report against the other person with the whole conversation attached
+ * as evidence (see Reports.getEvidence), including messages they unsent.
+ * Blocking ends the match and blocks the other person in one step, and can
+ * file a report at the same time. When scam or harassment detection flags
+ * a message, the recipient gets a safety notice in the conversation,
+ * published on their message channel at most once per sender and kind
+ * every SAFETY_NOTICE_COOLDOWN_HOURS.
+ */
+
+import { Report } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { AuditLog } from './audit-log';
+import { EventBus } from './event-bus';
+import { messageChannel } from './message-deletion';
+import { Reports, ReportReason } from './reports';
+import { counter } from './metrics';
+
+const NOTICE_COOLDOWN_SECONDS =
+  parseInt(process.env.SAFETY_NOTICE_COOLDOWN_HOURS || '24') * 60 * 60;
+
+export const SAFETY_NOTICE_KINDS = ['scam', 'harassment'] as const;
+
+export type SafetyNoticeKind = (typeof SAFETY_NOTICE_KINDS)[number];
+
+const noticeKey = (
+  recipientId: string,
+  senderId: string,
+  kind: SafetyNoticeKind
+) => `safety_notice:${recipientId}:${senderId}:${kind}`;
+
+export interface ConversationReport {
+  reason: ReportReason;
+  details?: string;
+}
+
+export type ReportConversationResult =
+  | { status: 'reported'; report: Report }
+  | { status: 'not_found' };
+
+export type BlockResult =
+  | { status: 'blocked'; report: Report | null }
+  | { status: 'not_found' };
+
+export interface SafetyNoticeEvent {
+  type: 'safety_notice';
+  kind: SafetyNoticeKind;
+  // The person the notice is about
+  fromUserId: string;
+  toUserId: string;
+  signalId: string | null;
+}
+
+export interface MatchRemovedEvent {
+  type: 'match_removed';
+  matchId: string;
+}
+
+const noticeCounter = counter(
+  'aurum_safety_notices_total',
+  'Safety notices shown in conversations, by kind'
+);
+
+async function findMatch(matchId: string, userId: string) {
+  return prisma.match.findFirst({
+    where: {
+      id: matchId,
+      deletedAt: null,
+      OR: [{ user1Id: userId }, { user2Id: userId }],
+    },
+  });
+}
+
+export class ConversationSafety {
+  /**
+   * Report the other person in a match, with the conversation as evidence
+   */
+  static async report(
+    matchId: string,
+    userId: string,
+    input: ConversationReport
+  ): Promise<ReportConversationResult> {
+    const match = await findMatch(matchId, userId);
+    if (!match) {
+      return { status: 'not_found' };
+    }
+    const otherUserId =
+      match.user1Id === userId ? match.user2Id : match.user1Id;
+
+    const report = await Reports.create(userId, {
+      reportedUserId: otherUserId,
+      reason: input.reason,
+      details: input.details,
+      matchId,
+    });
+    return report ? { status: 'reported', report } : { status: 'not_found' };
+  }
+
+  /**
+   * Block the other person in a match and unmatch, reporting them first if
+   * asked. The match is soft-deleted like the rest of an erased account's
+   * matches, so it disappears for both people.
+   */
+  static async blockAndUnmatch(
+    matchId: string,
+    userId: string,
+    input?: ConversationReport
+  ): Promise<BlockResult> {
+    const match = await findMatch(matchId, userId);
+    if (!match) {
+      return { status: 'not_found' };
+    }
+    const otherUserId =
+      match.user1Id === userId ? match.user2Id : match.user1Id;
+
+    // Filed while the match still exists, so the report can point at it
+    const report = input
+      ? await Reports.create(userId, {
+          reportedUserId: otherUserId,
+          reason: input.reason,
+          details: input.details,
+          matchId,
+        })
+      : null;
+
+    const [unmatched] = await prisma.$transaction([
+      prisma.match.updateMany({
+        where: { id: matchId, deletedAt: null },
+        data: { status: 'unmatched', deletedAt: new Date() },
+      }),
+      prisma.block.upsert({
+        where: {
+          blockerId_blockedId: { blockerId: userId, blockedId: otherUserId },
+        },
+        create: { blockerId: userId, blockedId: otherUserId, matchId },
+        update: {},
+      }),
+    ]);
+
+    if (unmatched.count > 0) {
+      const event: MatchRemovedEvent = { type: 'match_removed', matchId };
+      const data = JSON.stringify(event);
+      await redis
+        .multi()
+        .publish(messageChannel(userId), data)
+        .publish(messageChannel(otherUserId), data)
+        .exec();
+    }
+    await AuditLog.record({
+      action: 'user.blocked',
+      actorType: 'user',
+      actorId: userId,
+      targetType: 'user',
+      targetId: otherUserId,
+      details: { matchId, reportId: report?.id ?? null },
+    });
+    await EventBus.publish('match.unmatched', {
+      matchId,
+      userId,
+      otherUserId,
+      blocked: true,
+    });
+    return { status: 'blocked', report };
+  }
+
+  /**
+   * Warn the recipient of a flagged message, unless they were warned about
+   * the same sender for the same thing recently
+   */
+  static async notice(message: {
+    kind: SafetyNoticeKind;
+    senderId: string;
+    recipientId: string;
+    signalId?: string;
+  }): Promise<boolean> {
+    const first = await redis.set(
+      noticeKey(message.recipientId, message.senderId, message.kind),
+      '1',
+      'EX',
+      NOTICE_COOLDOWN_SECONDS,
+      'NX'
+    );
+    if (!first) {
+      return false;
+    }
+
+    const event: SafetyNoticeEvent = {
+      type: 'safety_notice',
+      kind: message.kind,
+      fromUserId: message.senderId,
+      toUserId: message.recipientId,
+      signalId: message.signalId ?? null,
+    };
+    await redis.publish(
+      messageChannel(message.recipientId),
+      JSON.stringify(event)
+    );
+    noticeCounter.inc({ kind: message.kind });
+    return true;
+  }
+}
@@ -0,0 +1,163 @@
+/**
+ * Conversation Suggestions
+ * Icebreakers and reply ideas for a match, generated by the ML API's LLM
+ * endpoint. Users opt in (aiSuggestions) before using it. The model only
+ * sees what the viewer can already see of the match: bio, vibe, tags and
+ * prompt answers, never names, handles, photos or location. It also sees
+ * the pair's messages, minus the match's if they opted out
+ * (aiMessageAccess). Links and contact details are redacted from
+ * everything sent.
+ */
+
+import { User } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import {
+  mlServiceClient,
+  MLSuggestionProfile,
+  MLSuggestionsRequest,
+} from './ml-service-client';
+import { ProfilePrompts, redactContactDetails } from './profile-prompts';
+
+export const SUGGESTIONS_PER_HOUR = parseInt(
+  process.env.AI_SUGGESTIONS_PER_HOUR || '10'
+);
+
+const ALLOWANCE_WINDOW_SECONDS = 60 * 60;
+const SUGGESTION_COUNT = 3;
+const MAX_MESSAGES = 20;
+
+export type SuggestionKind = MLSuggestionsRequest['kind'];
+
+export type SuggestionResult =
+  | { status: 'generated'; kind: SuggestionKind; suggestions: string[] }
+  | { status: 'consent_required' }
+  | { status: 'rate_limited'; retryAfterSeconds: number }
+  | { status: 'not_found' };
+
+/**
+ * Count a request against the user's hourly allowance
+ */
+async function takeAllowance(
+  userId: string
+): Promise<{ allowed: boolean; retryAfterSeconds: number }> {
+  const key = `suggestions:${userId}`;
+  const count = await redis.incr(key);
+  if (count === 1) {
+    await redis.expire(key, ALLOWANCE_WINDOW_SECONDS);
+  }
+  if (count <= SUGGESTIONS_PER_HOUR) {
+    return { allowed: true, retryAfterSeconds: 0 };
+  }
+  const ttl = await redis.ttl(key);
+  return {
+    allowed: false,
+    retryAfterSeconds: ttl > 0 ? ttl : ALLOWANCE_WINDOW_SECONDS,
+  };
+}
+
+function toSuggestionProfile(
+  user: Pick<User, 'bio' | 'vibe' | 'tags'>,
+  prompts: { prompt: string; answer: string }[]
+): MLSuggestionProfile {
+  return {
+    bio: user.bio && redactContactDetails(user.bio),
+    vibe: user.vibe,
+    tags: Array.isArray(user.tags) ? (user.tags as string[]) : [],
+    prompts: prompts.map(({ prompt, answer }) => ({
+      prompt,
+      answer: redactContactDetails(answer),
+    })),
+  };
+}
+
+export class ConversationSuggestions {
+  /**
+   * Suggestions for the viewer in `matchId`: icebreakers before anyone
+   * has written, replies after (unless `kind` says otherwise)
+   */
+  static async generate(
+    matchId: string,
+    viewerId: string,
+    kind?: SuggestionKind
+  ): Promise<SuggestionResult> {
+    const match = await prisma.match.findFirst({
+      where: {
+        id: matchId,
+        deletedAt: null,
+        OR: [{ user1Id: viewerId }, { user2Id: viewerId }],
+      },
+      include: { user1: true, user2: true },
+    });
+    if (!match) {
+      return { status: 'not_found' };
+    }
+    const viewer = match.user1Id === viewerId ? match.user1 : match.user2;
+    const other = match.user1Id === viewerId ? match.user2 : match.user1;
+    if (!viewer.aiSuggestions) {
+      return { status: 'consent_required' };
+    }
+
+    const allowance = await takeAllowance(viewerId);
+    if (!allowance.allowed) {
+      return {
+        status: 'rate_limited',
+        retryAfterSeconds: allowance.retryAfterSeconds,
+      };
+    }
+
+    const [prompts, signals] = await Promise.all([
+      ProfilePrompts.visibleFor([viewer.id, other.id]),
+      prisma.signal.findMany({
+        where: {
+          OR: [
+            { fromUserId: viewer.id, toUserId: other.id },
+            {
+              fromUserId: other.id,
+              toUserId: viewer.id,
+              suppressed: false,
+            },
+          ],
+          message: { not: null },
+          deletedAt: null,
+        },
+        orderBy: { sentAt: 'desc' },
+        take: MAX_MESSAGES,
+      }),
+    ]);
+    const messages = signals
+      .reverse()
+      .filter(
+        signal => signal.fromUserId === viewer.id || other.aiMessageAccess
+      )
+      .map(signal => ({
+        from:
+          signal.fromUserId === viewer.id
+            ? ('viewer' as const)
+            : ('match' as const),
+        text: redactContactDetails(signal.message!),
+      }));
+
+    const resolvedKind =
+      kind ?? (messages.length > 0 ? 'reply' : 'icebreaker');
+    const { suggestions } = await mlServiceClient.generateSuggestions({
+      kind: resolvedKind,
+      locale: viewer.locale || 'en',
+      count: SUGGESTION_COUNT,
+      profiles: {
+        viewer: toSuggestionProfile(viewer, prompts.get(viewer.id) ?? []),
+        match: toSuggestionProfile(other, prompts.get(other.id) ?? []),
+      },
+      messages,
+    });
+
+    return {
+      status: 'generated',
+      kind: resolvedKind,
+      // The model can echo contact details back too
+      suggestions: suggestions
+        .slice(0, SUGGESTION_COUNT)
+        .map(redactContactDetails),
+    };
+  }
+}
@@ -0,0 +1,345 @@
+/**
+ * Data Retention
+ * How long each class of data is kept, and the scheduled job that enforces
+ * it. Past its window, data is purged, or anonymized where the record
+ * itself still matters (a signal behind a match keeps existing without
+ * its message). Each run is recorded per data class, so admins can see
+ * what was removed and when. Windows are set in days per class; 0 keeps
+ * that class forever. Accounts deleted by their owners are handled by
+ * their own purge (see lib/account-deletion).
+ */
+
+import { Prisma, RetentionRun } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { EVENT_STREAM_KEY } from './event-bus';
+import { ScheduledTask } from './scheduler';
+
+export const DATA_CLASSES = [
+  'messages',
+  'deleted_messages',
+  'audit_logs',
+  'analytics_events',
+  'location_history',
+] as const;
+
+export type DataClass = (typeof DATA_CLASSES)[number];
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// Records removed per query, so a first run on a big table doesn't hold
+// the database for long
+const BATCH_SIZE = 1000;
+
+// The database refuses to delete audit entries younger than this (see
+// lib/audit-log), so a shorter window is raised to it
+const AUDIT_LOG_MIN_DAYS = 365;
+
+function auditLogDays(): number {
+  const days = parseInt(process.env.RETENTION_AUDIT_LOGS_DAYS || '730');
+  return days > 0 ? Math.max(days, AUDIT_LOG_MIN_DAYS) : days;
+}
+
+const RETENTION_DAYS: Record<DataClass, number> = {
+  messages: parseInt(process.env.RETENTION_MESSAGES_DAYS || '365'),
+  deleted_messages: parseInt(
+    process.env.RETENTION_DELETED_MESSAGES_DAYS || '30'
+  ),
+  audit_logs: auditLogDays(),
+  analytics_events: parseInt(
+    process.env.RETENTION_ANALYTICS_EVENTS_DAYS || '90'
+  ),
+  location_history: parseInt(
+    process.env.RETENTION_LOCATION_HISTORY_DAYS || '30'
+  ),
+};
+
+// What each class covers, for the report
+const DESCRIPTIONS: Record<DataClass, string> = {
+  messages:
+    'Signal messages are cleared; notifications and email logs are deleted',
+  deleted_messages: "Moderators' copies of unsent messages are deleted",
+  audit_logs: 'Audit log entries are deleted',
+  analytics_events: 'Raw domain events are trimmed from the event stream',
+  location_history:
+    'Locations not updated since, ended travel locations and finished ' +
+    'safety check-in places are removed',
+};
+
+export interface RetentionPolicy {
+  dataClass: DataClass;
+  // null when the class is kept forever
+  retentionDays: number | null;
+  description: string;
+}
+
+export interface EnforcementResult {
+  dataClass: DataClass;
+  cutoff: Date;
+  purged: number;
+  anonymized: number;
+  details: Record<string, number>;
+}
+
+export interface RetentionReport {
+  policies: RetentionPolicy[];
+  since: Date;
+  totals: Record<
+    DataClass,
+    { purged: number; anonymized: number; lastRunAt: Date | null }
+  >;
+  runs: RetentionRun[];
+}
+
+/**
+ * Delete `find`'s records batch by batch until none are left
+ */
+async function deleteInBatches(
+  find: () => Promise<Array<{ id: string }>>,
+  remove: (ids: string[]) => Promise<{ count: number }>
+): Promise<number> {
+  let total = 0;
+  for (;;) {
+    const batch = await find();
+    if (batch.length === 0) {
+      return total;
+    }
+    const { count } = await remove(batch.map(record => record.id));
+    total += count;
+    if (batch.length < BATCH_SIZE) {
+      return total;
+    }
+  }
+}
+
+async function enforceMessages(cutoff: Date) {
+  // Signals stay (matches depend on them); their text goes
+  const signals = await prisma.signal.updateMany({
+    where: { sentAt: { lt: cutoff }, message: { not: null } },
+    data: { message: null, scamFlags: Prisma.DbNull },
+  });
+  const notifications = await deleteInBatches(
+    () =>
+      prisma.notification.findMany({
+        where: { createdAt: { lt: cutoff } },
+        select: { id: true },
+        take: BATCH_SIZE,
+      }),
+    ids => prisma.notification.deleteMany({ where: { id: { in: ids } } })
+  );
+  const emails = await deleteInBatches(
+    () =>
+      prisma.emailMessage.findMany({
+        where: { createdAt: { lt: cutoff } },
+        select: { id: true },
+        take: BATCH_SIZE,
+      }),
+    ids => prisma.emailMessage.deleteMany({ where: { id: { in: ids } } })
+  );
+  return {
+    purged: notifications + emails,
+    anonymized: signals.count,
+    details: {
+      signalMessages: signals.count,
+      notifications,
+      emailMessages: emails,
+    },
+  };
+}
+
+async function enforceDeletedMessages(cutoff: Date) {
+  const copies = await deleteInBatches(
+    () =>
+      prisma.deletedMessage.findMany({
+        where: { deletedAt: { lt: cutoff } },
+        select: { id: true },
+        take: BATCH_SIZE,
+      }),
+    ids => prisma.deletedMessage.deleteMany({ where: { id: { in: ids } } })
+  );
+  return {
+    purged: copies,
+    anonymized: 0,
+    details: { deletedMessages: copies },
+  };
+}
+
+async function enforceAuditLogs(cutoff: Date) {
+  const entries = await deleteInBatches(
+    () =>
+      prisma.auditLog.findMany({
+        where: { createdAt: { lt: cutoff } },
+        select: { id: true },
+        take: BATCH_SIZE,
+      }),
+    ids => prisma.auditLog.deleteMany({ where: { id: { in: ids } } })
+  );
+  return {
+    purged: entries,
+    anonymized: 0,
+    details: { auditLogs: entries },
+  };
+}
+
+async function enforceAnalyticsEvents(cutoff: Date) {
+  // Stream IDs start with the time they were added; the rollups made
+  // from the events are aggregates and stay
+  const trimmed = await redis.xtrim(
+    EVENT_STREAM_KEY,
+    'MINID',
+    '~',
+    `${cutoff.getTime()}-0`
+  );
+  return {
+    purged: trimmed,
+    anonymized: 0,
+    details: { eventStream: trimmed },
+  };
+}
+
+async function enforceLocationHistory(cutoff: Date) {
+  const [locations, travel, checkIns] = await prisma.$transaction([
+    prisma.userLocation.deleteMany({ where: { updatedAt: { lt: cutoff } } }),
+    prisma.travelLocation.deleteMany({ where: { expiresAt: { lt: cutoff } } }),
+    prisma.safetyCheckIn.updateMany({
+      where: {
+        dateAt: { lt: cutoff },
+        status: { in: ['checked_in', 'escalated', 'canceled'] },
+        place: { not: null },
+      },
+      data: { place: null },
+    }),
+  ]);
+  return {
+    purged: locations.count + travel.count,
+    anonymized: checkIns.count,
+    details: {
+      userLocations: locations.count,
+      travelLocations: travel.count,
+      checkInPlaces: checkIns.count,
+    },
+  };
+}
+
+const ENFORCERS: Record<
+  DataClass,
+  (cutoff: Date) => Promise<Omit<EnforcementResult, 'dataClass' | 'cutoff'>>
+> = {
+  messages: enforceMessages,
+  deleted_messages: enforceDeletedMessages,
+  audit_logs: enforceAuditLogs,
+  analytics_events: enforceAnalyticsEvents,
+  location_history: enforceLocationHistory,
+};
+
+export class DataRetention {
+  /**
+   * The retention window of every data class
+   */
+  static policies(): RetentionPolicy[] {
+    return DATA_CLASSES.map(dataClass => ({
+      dataClass,
+      retentionDays:
+        RETENTION_DAYS[dataClass] > 0 ? RETENTION_DAYS[dataClass] : null,
+      description: DESCRIPTIONS[dataClass],
+    }));
+  }
+
+  /**
+   * Purge or anonymize one class's data past its window, and record the
+   * run. Null if the class is kept forever.
+   */
+  static async enforce(
+    dataClass: DataClass
+  ): Promise<EnforcementResult | null> {
+    const days = RETENTION_DAYS[dataClass];
+    if (!(days > 0)) {
+      return null;
+    }
+
+    const cutoff = new Date(Date.now() - days * DAY_MS);
+    const result = await ENFORCERS[dataClass](cutoff);
+    await prisma.retentionRun.create({
+      data: { dataClass, cutoff, ...result },
+    });
+
+    if (result.purged || result.anonymized) {
+      console.log(`🧹 Enforced ${dataClass} retention:`, result.details);
+    }
+    return { dataClass, cutoff, ...result };
+  }
+
+  /**
+   * Enforce every class. One class failing doesn't stop the others; the
+   * run fails afterwards so the scheduler retries it.
+   */
+  static async enforceAll(): Promise<EnforcementResult[]> {
+    const results: EnforcementResult[] = [];
+    let failed = false;
+    for (const dataClass of DATA_CLASSES) {
+      try {
+        const result = await DataRetention.enforce(dataClass);
+        if (result) {
+          results.push(result);
+        }
+      } catch (error) {
+        console.error(`Error enforcing ${dataClass} retention:`, error);
+        failed = true;
+      }
+    }
+    if (failed) {
+      throw new Error('Data retention enforcement failed for some classes');
+    }
+    return results;
+  }
+
+  /**
+   * What was purged and anonymized since `since`, by class, with the runs
+   */
+  static async report(since: Date, limit = 100): Promise<RetentionReport> {
+    const [grouped, lastRuns, runs] = await Promise.all([
+      prisma.retentionRun.groupBy({
+        by: ['dataClass'],
+        where: { ranAt: { gte: since } },
+        _sum: { purged: true, anonymized: true },
+      }),
+      prisma.retentionRun.groupBy({
+        by: ['dataClass'],
+        _max: { ranAt: true },
+      }),
+      prisma.retentionRun.findMany({
+        where: { ranAt: { gte: since } },
+        orderBy: { ranAt: 'desc' },
+        take: limit,
+      }),
+    ]);
+
+    const totals = Object.fromEntries(
+      DATA_CLASSES.map(dataClass => {
+        const sums = grouped.find(row => row.dataClass === dataClass)?._sum;
+        const last = lastRuns.find(row => row.dataClass === dataClass)?._max;
+        return [
+          dataClass,
+          {
+            purged: sums?.purged ?? 0,
+            anonymized: sums?.anonymized ?? 0,
+            lastRunAt: last?.ranAt ?? null,
+          },
+        ];
+      })
+    ) as RetentionReport['totals'];
+
+    return {
+      policies: DataRetention.policies(),
+      since,
+      totals,
+      runs,
+    };
+  }
+}
+
+export const dataRetention: ScheduledTask = {
+  name: 'data-retention',
+  everyMs: parseInt(process.env.RETENTION_INTERVAL_MS || String(DAY_MS)),
+  run: () => DataRetention.enforceAll(),
+};
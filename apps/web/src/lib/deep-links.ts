@@ -0,0 +1,222 @@
+/**
+ * Deep Links
+ * Short shareable links (/l/<slug>) to a profile, an invite or an event.
+ * Resolving a slug tells the client router where to go and what to
+ * preview, and gives the URLs that open the same screen in a browser or
+ * inside World App, so a link behaves the same wherever it's tapped.
+ * Resolving needs no session: links are mostly opened by people who
+ * aren't signed in yet.
+ */
+
+import { DeepLink } from '@prisma/client';
+import { customAlphabet } from 'nanoid';
+import prisma from './prisma';
+import { Bans } from './bans';
+import { counter } from './metrics';
+
+export const LINK_TARGET_TYPES = ['profile', 'invite', 'event'] as const;
+
+export type LinkTargetType = (typeof LINK_TARGET_TYPES)[number];
+
+// Lowercase and without look-alikes (0/o, 1/l), for links typed by hand
+const nanoid = customAlphabet('23456789abcdefghijkmnpqrstuvwxyz', 8);
+
+const resolutionCounter = counter(
+  'aurum_deep_link_resolutions_total',
+  'Deep link lookups by target type and outcome'
+);
+
+export interface LinkTarget {
+  type: LinkTargetType;
+  id: string;
+  // Client route to open
+  path: string;
+  // Preview for link cards and the landing screen
+  title: string;
+  imageUrl: string | null;
+  // Invites only: whether it can still be claimed
+  claimable?: boolean;
+}
+
+export interface LinkUrls {
+  // The short link itself
+  shortUrl: string;
+  // Opens the target in a browser
+  webUrl: string;
+  // Opens the target inside World App (or prompts to install it)
+  worldAppUrl: string | null;
+}
+
+export type LinkCreation =
+  | { status: 'created'; slug: string; urls: LinkUrls }
+  | { status: 'target_not_found' }
+  | { status: 'not_allowed' };
+
+export type LinkResolution =
+  | { status: 'resolved'; slug: string; target: LinkTarget; urls: LinkUrls }
+  | { status: 'not_found' }
+  | { status: 'target_unavailable' };
+
+function baseUrl(): string {
+  return (process.env.APP_PUBLIC_URL || '').replace(/\/$/, '');
+}
+
+function linkUrls(slug: string, path: string): LinkUrls {
+  const appId = process.env.NEXT_PUBLIC_WORLDCOIN_APP_ID;
+  return {
+    shortUrl: `${baseUrl()}/l/${slug}`,
+    webUrl: `${baseUrl()}${path}`,
+    worldAppUrl: appId
+      ? `https://world.org/mini-app?app_id=${appId}&path=${encodeURIComponent(path)}`
+      : null,
+  };
+}
+
+/**
+ * The target as the client should open it, or null if it no longer exists
+ * or can't be shown (deleted or banned profile)
+ */
+async function loadTarget(
+  type: LinkTargetType,
+  id: string
+): Promise<LinkTarget | null> {
+  if (type === 'profile') {
+    const user = await prisma.user.findUnique({
+      where: { id },
+      select: {
+        displayName: true,
+        blurredImage: true,
+        status: true,
+        deletedAt: true,
+      },
+    });
+    if (
+      !user ||
+      user.status === 'deleted' ||
+      user.deletedAt ||
+      (await Bans.getActiveBan(id))
+    ) {
+      return null;
+    }
+    return {
+      type,
+      id,
+      path: `/profiles/${id}`,
+      title: user.displayName,
+      // Only the blurred photo is public; the full one needs a session
+      imageUrl: user.blurredImage,
+    };
+  }
+
+  if (type === 'event') {
+    const event = await prisma.event.findUnique({
+      where: { id },
+      select: { title: true, status: true, endsAt: true },
+    });
+    if (!event || event.status !== 'published' || event.endsAt <= new Date()) {
+      return null;
+    }
+    return {
+      type,
+      id,
+      path: `/events/${id}`,
+      title: event.title,
+      imageUrl: null,
+    };
+  }
+
+  const invite = await prisma.invite.findUnique({
+    where: { id },
+    include: { user: { select: { displayName: true } } },
+  });
+  if (!invite) {
+    return null;
+  }
+  return {
+    type,
+    id,
+    path: `/invites?code=${invite.code}`,
+    title: `${invite.user.displayName} invited you to Aurum Circle`,
+    imageUrl: null,
+    claimable: !invite.claimedAt,
+  };
+}
+
+export class DeepLinks {
+  /**
+   * A link to the target for the user to share. Users can link any visible
+   * profile but only their own invites; asking again returns the same link.
+   */
+  static async create(
+    userId: string,
+    targetType: LinkTargetType,
+    targetId: string
+  ): Promise<LinkCreation> {
+    const target = await loadTarget(targetType, targetId);
+    if (!target) {
+      return { status: 'target_not_found' };
+    }
+    if (targetType === 'invite') {
+      const invite = await prisma.invite.findUnique({
+        where: { id: targetId },
+        select: { userId: true },
+      });
+      if (invite?.userId !== userId) {
+        return { status: 'not_allowed' };
+      }
+    }
+
+    const existing = await prisma.deepLink.findUnique({
+      where: {
+        createdBy_targetType_targetId: {
+          createdBy: userId,
+          targetType,
+          targetId,
+        },
+      },
+    });
+    let link: DeepLink;
+    if (existing) {
+      link = existing;
+    } else {
+      let slug: string;
+      do {
+        slug = nanoid();
+      } while (await prisma.deepLink.findUnique({ where: { slug } }));
+      link = await prisma.deepLink.create({
+        data: { slug, targetType, targetId, createdBy: userId },
+      });
+    }
+
+    return {
+      status: 'created',
+      slug: link.slug,
+      urls: linkUrls(link.slug, target.path),
+    };
+  }
+
+  static async resolve(slug: string): Promise<LinkResolution> {
+    const link = await prisma.deepLink.findUnique({
+      where: { slug: slug.toLowerCase() },
+    });
+    if (!link) {
+      resolutionCounter.inc({ type: 'unknown', outcome: 'not_found' });
+      return { status: 'not_found' };
+    }
+
+    const type = link.targetType as LinkTargetType;
+    const target = await loadTarget(type, link.targetId);
+    if (!target) {
+      resolutionCounter.inc({ type, outcome: 'target_unavailable' });
+      return { status: 'target_unavailable' };
+    }
+
+    resolutionCounter.inc({ type, outcome: 'resolved' });
+    return {
+      status: 'resolved',
+      slug: link.slug,
+      target,
+      urls: linkUrls(link.slug, target.path),
+    };
+  }
+}
@@ -0,0 +1,245 @@
+/**
+ * Dev Seed
+ * Fills a development database with fake but plausible users, likes,
+ * passes and matches so the frontend can be built without production
+ * data. Seed users are recognizable by their "seed:" World IDs; reseeding
+ * updates them in place and redraws their signals and matches. The same
+ * seed number always produces the same data.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { Places } from './places';
+import { toStoredDate } from './field-encryption';
+import { encodeGeohash } from './geohash';
+import { passExpiry } from './passes';
+
+export const MAX_SEED_USERS = 500;
+
+const SEED_WORLD_ID_PREFIX = 'seed:';
+
+const FIRST_NAMES = [
+  'Ploy',
+  'Nat',
+  'Beam',
+  'Mint',
+  'Fah',
+  'Bank',
+  'Pim',
+  'Ton',
+  'Fern',
+  'Kim',
+  'Earth',
+  'Praew',
+  'Boss',
+  'Nune',
+  'Arm',
+  'Jane',
+  'Tae',
+  'May',
+  'Win',
+  'Ice',
+];
+
+const VIBES = [
+  'academic',
+  'creative',
+  'adventurous',
+  'social',
+  'athletic',
+  'entrepreneur',
+  'chill',
+  'mystery',
+];
+
+const FACULTIES = [
+  'Engineering',
+  'Medicine',
+  'Arts',
+  'Economics',
+  'Architecture',
+  'Science',
+  'Law',
+  'Communication Arts',
+];
+
+const BIOS = [
+  'Coffee first, then everything else.',
+  'Looking for someone to try new food spots with.',
+  'Weekend hiker, weekday overthinker.',
+  'Will trade playlist recommendations for yours.',
+  'Probably at the library. Or the night market.',
+  null,
+];
+
+/**
+ * Whether seeding is allowed: never in production, and only when asked for
+ */
+export function devSeedEnabled(): boolean {
+  return (
+    process.env.NODE_ENV !== 'production' &&
+    process.env.DEV_SEED_ENABLED === 'true'
+  );
+}
+
+// Small deterministic PRNG (mulberry32) so a seed number is reproducible
+function random(seed: number): () => number {
+  let state = seed >>> 0;
+  return () => {
+    state = (state + 0x6d2b79f5) >>> 0;
+    let t = state;
+    t = Math.imul(t ^ (t >>> 15), t | 1);
+    t ^= t + Math.imul(t ^ (t >>> 7), t | 61);
+    return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+  };
+}
+
+export interface SeedOptions {
+  users: number;
+  seed: number;
+}
+
+export interface SeedResult {
+  userIds: string[];
+  signals: number;
+  matches: number;
+}
+
+export class DevSeed {
+  static async run(options: SeedOptions): Promise<SeedResult> {
+    const next = random(options.seed);
+    const pick = <T>(items: readonly T[]): T =>
+      items[Math.floor(next() * items.length)];
+
+    const campuses = await Places.list('campus');
+    if (campuses.length === 0) {
+      throw new Error('No campuses in the directory; run migrations first');
+    }
+
+    const userIds: string[] = [];
+    for (let index = 0; index < options.users; index++) {
+      const number = String(index + 1).padStart(3, '0');
+      const name = pick(FIRST_NAMES);
+      const campus = pick(campuses);
+      const profile = {
+        handle: `seed_${name.toLowerCase()}_${number}`,
+        displayName: name,
+        bio: pick(BIOS),
+        vibe: pick(VIBES),
+        cityId: campus.cityId,
+        campusId: campus.id,
+        tags: {
+          year: String(1 + Math.floor(next() * 4)),
+          faculty: pick(FACULTIES),
+          secondaryVibes: [pick(VIBES), pick(VIBES)],
+        },
+        nftVerified: next() < 0.3,
+        photoVerified: next() < 0.5,
+        status: 'active',
+        deletedAt: null,
+        lastSeen: new Date(Date.now() - next() * 7 * 24 * 60 * 60 * 1000),
+        // 18 to 28 years old
+        birthDate: toStoredDate(
+          new Date(
+            Date.now() - (18 + next() * 10) * 365.25 * 24 * 60 * 60 * 1000
+          )
+        ),
+      };
+
+      const worldId = `${SEED_WORLD_ID_PREFIX}${number}`;
+      const user = await prisma.user.upsert({
+        where: { worldId },
+        create: {
+          ...profile,
+          worldId,
+          walletAddress: `0x5eed${number.padStart(36, '0')}`,
+        },
+        update: profile,
+      });
+      userIds.push(user.id);
+
+      // Scattered within a few km of campus
+      const geohash = encodeGeohash(
+        campus.lat + (next() - 0.5) * 0.05,
+        campus.lng + (next() - 0.5) * 0.05,
+        5
+      );
+      await prisma.userLocation.upsert({
+        where: { userId: user.id },
+        create: { userId: user.id, geohash, precision: 5, source: 'geohash' },
+        update: { geohash, precision: 5, source: 'geohash' },
+      });
+    }
+
+    // Redraw every seed user's signals and matches
+    const seedUsers = await prisma.user.findMany({
+      where: { worldId: { startsWith: SEED_WORLD_ID_PREFIX } },
+      select: { id: true },
+    });
+    const seedIds = seedUsers.map(user => user.id);
+    await prisma.$transaction([
+      prisma.signal.deleteMany({
+        where: {
+          OR: [{ fromUserId: { in: seedIds } }, { toUserId: { in: seedIds } }],
+        },
+      }),
+      prisma.pass.deleteMany({
+        where: {
+          OR: [{ userId: { in: seedIds } }, { passedUserId: { in: seedIds } }],
+        },
+      }),
+      prisma.match.deleteMany({
+        where: {
+          OR: [{ user1Id: { in: seedIds } }, { user2Id: { in: seedIds } }],
+        },
+      }),
+    ]);
+
+    const signals: Prisma.SignalCreateManyInput[] = [];
+    const passes: Prisma.PassCreateManyInput[] = [];
+    const likes = new Set<string>();
+    for (const fromUserId of userIds) {
+      for (const toUserId of userIds) {
+        const roll = next();
+        if (fromUserId === toUserId || roll > 0.35) {
+          continue;
+        }
+        const sentAt = new Date(Date.now() - next() * 14 * 24 * 60 * 60 * 1000);
+        if (roll >= 0.2) {
+          passes.push({
+            userId: fromUserId,
+            passedUserId: toUserId,
+            passedAt: sentAt,
+            expiresAt: passExpiry(sentAt),
+          });
+          continue;
+        }
+        const type = roll < 0.03 ? 'super_like' : 'like';
+        signals.push({ fromUserId, toUserId, type, sentAt });
+        likes.add(`${fromUserId}:${toUserId}`);
+      }
+    }
+
+    const matches: Prisma.MatchCreateManyInput[] = [];
+    userIds.forEach((user1Id, index) => {
+      userIds.slice(index + 1).forEach(user2Id => {
+        if (
+          likes.has(`${user1Id}:${user2Id}`) &&
+          likes.has(`${user2Id}:${user1Id}`)
+        ) {
+          matches.push({ user1Id, user2Id });
+        }
+      });
+    });
+
+    await prisma.signal.createMany({ data: signals });
+    await prisma.pass.createMany({ data: passes });
+    await prisma.match.createMany({ data: matches });
+    return {
+      userIds,
+      signals: signals.length,
+      passes: passes.length,
+      matches: matches.length,
+    };
+  }
+}
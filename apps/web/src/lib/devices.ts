@@ -0,0 +1,246 @@
+/**
+ * Devices
+ * Push tokens registered by the native apps, delivered to through Firebase
+ * Cloud Messaging (which relays to APNs for iOS). Tokens FCM rejects are
+ * disabled automatically, and every send is recorded per device.
+ */
+
+import { SignJWT, importPKCS8 } from 'jose';
+import { Device } from '@prisma/client';
+import prisma from './prisma';
+import { upstreamFetch } from './http-client';
+import { PushNotification } from './push-notifications';
+import { counter } from './metrics';
+
+export const DEVICE_PLATFORMS = ['ios', 'android', 'web'] as const;
+
+export type DevicePlatform = (typeof DEVICE_PLATFORMS)[number];
+
+export interface DeviceRegistration {
+  token: string;
+  platform: DevicePlatform;
+  locale?: string;
+  appVersion?: string;
+}
+
+export interface DevicePushNotification extends PushNotification {
+  // Notification type, recorded with each delivery
+  type: string;
+}
+
+const FCM_SCOPE = 'https://www.googleapis.com/auth/firebase.messaging';
+const GOOGLE_TOKEN_URL = 'https://oauth2.googleapis.com/token';
+
+// FCM error codes meaning the token will never work again
+const REJECTED_TOKEN_CODES = ['UNREGISTERED', 'INVALID_ARGUMENT'];
+
+const pushDeliveries = counter(
+  'device_push_deliveries_total',
+  'Push sends to registered devices, by platform and status'
+);
+
+let accessToken: { value: string; expiresAt: number } | null = null;
+
+/**
+ * Whether FCM credentials are configured for this deployment
+ */
+export function devicePushEnabled(): boolean {
+  return Boolean(
+    process.env.FCM_PROJECT_ID &&
+      process.env.FCM_CLIENT_EMAIL &&
+      process.env.FCM_PRIVATE_KEY
+  );
+}
+
+/**
+ * An OAuth access token for the FCM service account, cached until shortly
+ * before it expires
+ */
+async function getAccessToken(): Promise<string> {
+  if (accessToken && accessToken.expiresAt > Date.now() + 60_000) {
+    return accessToken.value;
+  }
+
+  const key = await importPKCS8(
+    process.env.FCM_PRIVATE_KEY!.replace(/\\n/g, '\n'),
+    'RS256'
+  );
+  const assertion = await new SignJWT({ scope: FCM_SCOPE })
+    .setProtectedHeader({ alg: 'RS256' })
+    .setIssuer(process.env.FCM_CLIENT_EMAIL!)
+    .setAudience(GOOGLE_TOKEN_URL)
+    .setIssuedAt()
+    .setExpirationTime('1h')
+    .sign(key);
+
+  const response = await upstreamFetch(
+    'google-oauth',
+    GOOGLE_TOKEN_URL,
+    {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+      body: new URLSearchParams({
+        grant_type: 'urn:ietf:params:oauth:grant-type:jwt-bearer',
+        assertion,
+      }),
+    },
+    { retries: 1, timeoutMs: 5000 }
+  );
+  if (!response.ok) {
+    throw new Error(`FCM auth failed: ${response.status}`);
+  }
+
+  const { access_token, expires_in } = await response.json();
+  accessToken = {
+    value: access_token,
+    expiresAt: Date.now() + expires_in * 1000,
+  };
+  return access_token;
+}
+
+/**
+ * Send one message. Returns the FCM error code on failure, or null.
+ */
+async function sendToDevice(
+  device: Device,
+  notification: DevicePushNotification
+): Promise<string | null> {
+  const response = await upstreamFetch(
+    'fcm',
+    `https://fcm.googleapis.com/v1/projects/${process.env.FCM_PROJECT_ID}/messages:send`,
+    {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        Authorization: `Bearer ${await getAccessToken()}`,
+      },
+      body: JSON.stringify({
+        message: {
+          token: device.token,
+          notification: {
+            title: notification.title,
+            body: notification.message,
+          },
+          data: { type: notification.type, path: notification.path || '/' },
+        },
+      }),
+    },
+    { timeoutMs: 5000 }
+  );
+  if (response.ok) {
+    return null;
+  }
+
+  const body = await response.json().catch(() => null);
+  const details: { errorCode?: string }[] = body?.error?.details || [];
+  return (
+    details.find(detail => detail.errorCode)?.errorCode ||
+    body?.error?.status ||
+    `HTTP_${response.status}`
+  );
+}
+
+export class Devices {
+  /**
+   * Register a push token, or refresh it if already known. A token that
+   * moves to another account (shared phone) follows the new sign-in.
+   */
+  static async register(
+    userId: string,
+    registration: DeviceRegistration
+  ): Promise<Device> {
+    const fields = {
+      userId,
+      platform: registration.platform,
+      locale: registration.locale ?? null,
+      appVersion: registration.appVersion ?? null,
+      disabledAt: null,
+      disabledReason: null,
+      lastSeenAt: new Date(),
+    };
+
+    return prisma.device.upsert({
+      where: { token: registration.token },
+      create: { token: registration.token, ...fields },
+      update: fields,
+    });
+  }
+
+  /**
+   * Stop pushing to a token (sign-out, notifications turned off). Returns
+   * false if the user has no such device.
+   */
+  static async unregister(userId: string, token: string): Promise<boolean> {
+    const disabled = await prisma.device.updateMany({
+      where: { userId, token, disabledAt: null },
+      data: { disabledAt: new Date(), disabledReason: 'unregistered' },
+    });
+    return disabled.count > 0;
+  }
+
+  /**
+   * Push to every active device of the given users. Never throws; returns
+   * how many sends succeeded.
+   */
+  static async push(
+    userIds: string[],
+    notification: DevicePushNotification
+  ): Promise<number> {
+    if (!devicePushEnabled() || userIds.length === 0) {
+      return 0;
+    }
+
+    try {
+      const devices = await prisma.device.findMany({
+        where: { userId: { in: userIds }, disabledAt: null },
+      });
+
+      let sent = 0;
+      for (const device of devices) {
+        let error: string | null;
+        try {
+          error = await sendToDevice(device, notification);
+        } catch (sendError) {
+          console.error('Error sending device push:', sendError);
+          error = 'NETWORK_ERROR';
+        }
+
+        const status = !error
+          ? 'sent'
+          : REJECTED_TOKEN_CODES.includes(error)
+            ? 'token_rejected'
+            : 'failed';
+        await Devices.recordDelivery(device, notification.type, status, error);
+        if (status === 'sent') {
+          sent++;
+        }
+      }
+      return sent;
+    } catch (error) {
+      console.error('Error pushing to devices:', error);
+      return 0;
+    }
+  }
+
+  /**
+   * Record a send, disabling the device if the push service rejected its
+   * token
+   */
+  private static async recordDelivery(
+    device: Device,
+    type: string,
+    status: 'sent' | 'failed' | 'token_rejected',
+    error: string | null
+  ): Promise<void> {
+    await prisma.pushDelivery.create({
+      data: { deviceId: device.id, type, status, error },
+    });
+    if (status === 'token_rejected') {
+      await prisma.device.update({
+        where: { id: device.id },
+        data: { disabledAt: new Date(), disabledReason: error },
+      });
+    }
+    pushDeliveries.inc({ platform: device.platform, status });
+  }
+}
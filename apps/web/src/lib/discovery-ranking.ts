@@ -0,0 +1,268 @@
+/**
+ * Discovery Ranking
+ * Orders discovery candidates by ML pair compatibility with the viewer,
+ * falling back to recency ordering whenever the ML API is unhealthy.
+ * Boosted users are always in the pool and weighted up; shadowbanned users
+ * never are. Trust scores, response rates and how often people decline
+ * someone's message requests scale everyone's weight, and the least
+ * trusted and least responsive are left out, as is anyone the viewer
+ * passed on within the re-show window and anyone either of them blocked.
+ * When the viewer shared a location, people in the surrounding geohash
+ * cells come first and the rest of the pool tops them up. Nobody is shown
+ * outside the viewer's tenant. Candidates are read from a replica when one
+ * is healthy.
+ */
+
+import { Prisma, User } from '@prisma/client';
+import { scoreCandidatePairs } from './pair-scoring';
+import { MLHealthMonitor } from './ml-health';
+import { Boosts, BOOST_EXPOSURE_MULTIPLIER } from './boosts';
+import { counter } from './metrics';
+import { Locations } from './locations';
+import { ReadReplicas } from './read-replicas';
+import { ageRange } from './age-verification';
+import { TrustScore, TRUST_DISCOVERY_MIN } from './trust-score';
+import { notPassedWhere } from './passes';
+import { notBlockedWhere } from './blocks';
+import { MessageRequests } from './message-requests';
+import { ResponseStats, responsiveWhere } from './response-stats';
+import { Tenants } from './tenants';
+
+export type RankingMode = 'ml' | 'recency';
+
+export interface RankedProfiles {
+  users: User[];
+  ranking: RankingMode;
+  // ML pair compatibility by user ID (empty under recency ranking)
+  scores: Map<string, number>;
+}
+
+// What other users may see of a profile
+export interface PublicProfile {
+  id: string;
+  handle: string;
+  displayName: string;
+  bio: string | null;
+  profileImage: string | null;
+  vibe: string | null;
+  cityId: string | null;
+  campusId: string | null;
+  tags: User['tags'];
+  // Never the birth date itself
+  ageRange: string | null;
+  ageVerified: boolean;
+  nftVerified: boolean;
+  photoVerified: boolean;
+}
+
+export function toPublicProfile(user: User): PublicProfile {
+  return {
+    id: user.id,
+    handle: user.handle,
+    displayName: user.displayName,
+    bio: user.bio,
+    profileImage: user.profileImage,
+    vibe: user.vibe,
+    cityId: user.cityId,
+    campusId: user.campusId,
+    tags: user.tags,
+    ageRange: ageRange(user.birthDate),
+    ageVerified: Boolean(user.ageVerifiedAt),
+    nftVerified: user.nftVerified,
+    photoVerified: user.photoVerified,
+  };
+}
+
+// Narrow the deck to a directory city or campus, or to a set of users
+// (e.g. an event's attendees)
+export interface DiscoveryFilters {
+  cityId?: string;
+  campusId?: string;
+  userIds?: string[];
+}
+
+// Candidates pulled from the database before ML re-ranking
+const CANDIDATE_POOL_SIZE = 50;
+
+const rankingCounter = counter(
+  'aurum_discovery_rankings_total',
+  'Discovery ranking requests by ranking mode'
+);
+
+/**
+ * The most recently active candidates, nearby ones first
+ */
+async function recentCandidates(
+  viewerId: string,
+  tenantId: string | null,
+  take: number,
+  filters: DiscoveryFilters
+): Promise<User[]> {
+  const where: Prisma.UserWhereInput = {
+    id: {
+      not: viewerId,
+    },
+    tenantId,
+    shadowbanned: false,
+    trustScore: { gte: TRUST_DISCOVERY_MIN },
+    status: { not: 'deleted' },
+    deletedAt: null,
+    ...notPassedWhere(viewerId),
+    ...notBlockedWhere(viewerId),
+    ...(filters.cityId && { cityId: filters.cityId }),
+    ...(filters.campusId && { campusId: filters.campusId }),
+    AND: [
+      responsiveWhere(),
+      ...(filters.userIds ? [{ id: { in: filters.userIds } }] : []),
+    ],
+  };
+
+  const db = await ReadReplicas.client(viewerId);
+  const cells = await Locations.discoveryCells(viewerId).catch(error => {
+    console.error('Failed to load viewer location:', error);
+    return null;
+  });
+  if (!cells) {
+    return db.user.findMany({
+      where,
+      orderBy: { lastSeen: 'desc' },
+      take,
+    });
+  }
+
+  const nearby = await db.user.findMany({
+    where: {
+      ...where,
+      // Travelers count as being in their travel city
+      OR: cells.flatMap(cell => [
+        { location: { geohash: { startsWith: cell } } },
+        {
+          travelLocation: {
+            geohash: { startsWith: cell },
+            expiresAt: { gt: new Date() },
+          },
+        },
+      ]),
+    },
+    orderBy: { lastSeen: 'desc' },
+    take,
+  });
+  if (nearby.length >= take) {
+    return nearby;
+  }
+
+  const others = await db.user.findMany({
+    where: {
+      ...where,
+      id: { notIn: [viewerId, ...nearby.map(user => user.id)] },
+    },
+    orderBy: { lastSeen: 'desc' },
+    take: take - nearby.length,
+  });
+  return [...nearby, ...others];
+}
+
+/**
+ * Fetch and rank discovery profiles for a viewer
+ */
+export async function rankDiscoveryProfiles(
+  viewerId: string,
+  limit = 10,
+  filters: DiscoveryFilters = {}
+): Promise<RankedProfiles> {
+  const [useML, boostedIds, db, tenantId] = await Promise.all([
+    MLHealthMonitor.isAvailable(),
+    Boosts.getActiveUserIds().catch(error => {
+      console.error('Failed to load active boosts:', error);
+      return [] as string[];
+    }),
+    ReadReplicas.client(viewerId),
+    Tenants.ofUser(viewerId),
+  ]);
+  const boosted = new Set(
+    boostedIds.filter(
+      id =>
+        id !== viewerId && (!filters.userIds || filters.userIds.includes(id))
+    )
+  );
+
+  const [recent, boostedUsers] = await Promise.all([
+    recentCandidates(
+      viewerId,
+      tenantId,
+      useML ? CANDIDATE_POOL_SIZE : limit,
+      filters
+    ),
+    boosted.size > 0
+      ? db.user.findMany({
+          where: {
+            id: { in: Array.from(boosted) },
+            tenantId,
+            shadowbanned: false,
+            trustScore: { gte: TRUST_DISCOVERY_MIN },
+            deletedAt: null,
+            ...notPassedWhere(viewerId),
+            ...notBlockedWhere(viewerId),
+            ...responsiveWhere(),
+            ...(filters.cityId && { cityId: filters.cityId }),
+            ...(filters.campusId && { campusId: filters.campusId }),
+          },
+        })
+      : Promise.resolve([]),
+  ]);
+
+  const candidates = [
+    ...boostedUsers,
+    ...recent.filter(user => !boosted.has(user.id)),
+  ];
+
+  if (!useML) {
+    rankingCounter.inc({ mode: 'recency' });
+    return {
+      users: candidates.slice(0, limit),
+      ranking: 'recency',
+      scores: new Map(),
+    };
+  }
+
+  let scores: Map<string, number>;
+  try {
+    const pairScores = await scoreCandidatePairs(
+      viewerId,
+      candidates.map(user => user.id)
+    );
+    scores = new Map(pairScores.map(p => [p.candidateId, p.compatibility]));
+  } catch (error) {
+    console.error('Pair scoring failed, using recency ranking:', error);
+    scores = new Map();
+  }
+
+  if (scores.size === 0) {
+    // Nothing scorable (e.g. the viewer has no face embedding yet)
+    rankingCounter.inc({ mode: 'recency' });
+    return {
+      users: candidates.slice(0, limit),
+      ranking: 'recency',
+      scores: new Map(),
+    };
+  }
+
+  // Most compatible first; unscored candidates keep recency order at the end
+  const ranked = candidates
+    .map((user, index) => ({
+      user,
+      index,
+      compatibility:
+        (scores.get(user.id) ?? Number.NEGATIVE_INFINITY) *
+        (boosted.has(user.id) ? BOOST_EXPOSURE_MULTIPLIER : 1) *
+        TrustScore.exposure(user.trustScore) *
+        ResponseStats.exposure(user.responseRate) *
+        MessageRequests.exposure(user.declineRate),
+    }))
+    .sort((a, b) => b.compatibility - a.compatibility || a.index - b.index)
+    .slice(0, limit)
+    .map(entry => entry.user);
+
+  rankingCounter.inc({ mode: 'ml' });
+  return { users: ranked, ranking: 'ml', scores };
+}
@@ -0,0 +1,180 @@
+/**
+ * Dunning
+ * Grace period after a failed renewal. Plan entitlements stay active for
+ * the grace window while the user is reminded to fix their payment method;
+ * if the subscription is still past due when the window closes, the user is
+ * downgraded to the free plan.
+ */
+
+import prisma from './prisma';
+import { Entitlements } from './entitlements';
+import { EventBus } from './event-bus';
+import { Scheduler, ScheduledTask } from './scheduler';
+import { NotificationPush } from './notification-push';
+import { WakingHours, nextWakingTime } from './waking-hours';
+
+const GRACE_DAYS = parseFloat(process.env.BILLING_GRACE_PERIOD_DAYS || '7');
+const GRACE_PERIOD_MS = GRACE_DAYS * 24 * 60 * 60 * 1000;
+
+// Hours after the first failure at which a reminder is sent
+const REMINDER_HOURS = (process.env.BILLING_DUNNING_REMINDER_HOURS || '24,96')
+  .split(',')
+  .map(hours => parseFloat(hours.trim()))
+  .filter(hours => !isNaN(hours));
+
+interface DunningJob {
+  userId: string;
+  // Identifies the grace window a job belongs to
+  graceEndsAt: string;
+}
+
+// Job IDs are derived from the grace window so they can be cancelled later
+const reminderJobId = (job: DunningJob, index: number) =>
+  `dunning-reminder-${job.userId}-${Date.parse(job.graceEndsAt)}-${index}`;
+const downgradeJobId = (job: DunningJob) =>
+  `dunning-downgrade-${job.userId}-${Date.parse(job.graceEndsAt)}`;
+
+// Whether the grace window a job was scheduled for is still open
+async function isCurrentWindow(job: DunningJob): Promise<boolean> {
+  const subscription = await prisma.subscription.findUnique({
+    where: { userId: job.userId },
+    select: { status: true, graceEndsAt: true },
+  });
+  return (
+    subscription?.status === 'past_due' &&
+    subscription.graceEndsAt?.toISOString() === job.graceEndsAt
+  );
+}
+
+export class Dunning {
+  /**
+   * Open a grace window after a failed renewal. Repeated failures within an
+   * open window (provider retries) don't extend it.
+   */
+  static async startGracePeriod(userId: string): Promise<Date | null> {
+    const subscription = await prisma.subscription.findUnique({
+      where: { userId },
+    });
+    if (!subscription || subscription.plan === 'free') {
+      return null;
+    }
+    if (subscription.graceEndsAt) {
+      return subscription.graceEndsAt;
+    }
+
+    const now = Date.now();
+    const graceEndsAt = new Date(now + GRACE_PERIOD_MS);
+
+    await prisma.$transaction([
+      prisma.subscription.update({
+        where: { userId },
+        data: { status: 'past_due', graceEndsAt },
+      }),
+      // Keep plan entitlements alive until the window closes
+      prisma.entitlement.updateMany({
+        where: { userId, source: { startsWith: 'plan:' } },
+        data: { expiresAt: graceEndsAt },
+      }),
+    ]);
+    await Entitlements.invalidate(userId);
+
+    const job: DunningJob = { userId, graceEndsAt: graceEndsAt.toISOString() };
+    // Reminders wait for the morning rather than buzz at night
+    const preferences = await WakingHours.preferences(userId);
+    await Promise.all([
+      ...REMINDER_HOURS.map((hours, index) => {
+        const runAt = nextWakingTime(
+          new Date(now + hours * 60 * 60 * 1000),
+          preferences
+        );
+        return runAt < graceEndsAt
+          ? Scheduler.scheduleAt(
+              dunningReminder.name,
+              job,
+              runAt,
+              reminderJobId(job, index)
+            )
+          : undefined;
+      }),
+      Scheduler.scheduleAt(
+        dunningDowngrade.name,
+        job,
+        graceEndsAt,
+        downgradeJobId(job)
+      ),
+    ]);
+
+    await EventBus.publish('billing.grace_period_started', {
+      userId,
+      graceEndsAt: job.graceEndsAt,
+    });
+    return graceEndsAt;
+  }
+
+  /**
+   * Close an open grace window (payment recovered or subscription ended)
+   * and cancel its pending reminders and downgrade
+   */
+  static async resolve(userId: string): Promise<void> {
+    const subscription = await prisma.subscription.findUnique({
+      where: { userId },
+      select: { graceEndsAt: true },
+    });
+    if (!subscription?.graceEndsAt) {
+      return;
+    }
+
+    const job: DunningJob = {
+      userId,
+      graceEndsAt: subscription.graceEndsAt.toISOString(),
+    };
+    await prisma.subscription.update({
+      where: { userId },
+      data: { graceEndsAt: null },
+    });
+    await Promise.all([
+      ...REMINDER_HOURS.map((_, index) =>
+        Scheduler.cancel(reminderJobId(job, index))
+      ),
+      Scheduler.cancel(downgradeJobId(job)),
+    ]);
+  }
+}
+
+export const dunningReminder: ScheduledTask<DunningJob> = {
+  name: 'dunning-reminder',
+  run: async job => {
+    if (!(await isCurrentWindow(job))) {
+      return { skipped: 'resolved' };
+    }
+
+    await EventBus.publish('billing.dunning_reminder', job);
+    await NotificationPush.send(job.userId, {
+      type: 'dunning_reminder',
+      path: '/settings/billing',
+    });
+    return { reminded: true };
+  },
+};
+
+export const dunningDowngrade: ScheduledTask<DunningJob> = {
+  name: 'dunning-downgrade',
+  run: async job => {
+    if (!(await isCurrentWindow(job))) {
+      return { skipped: 'resolved' };
+    }
+
+    await Entitlements.setPlan(job.userId, 'free');
+    await prisma.subscription.update({
+      where: { userId: job.userId },
+      data: { status: 'expired', graceEndsAt: null },
+    });
+
+    await EventBus.publish('billing.subscription_downgraded', job);
+    await NotificationPush.send(job.userId, {
+      type: 'dunning_downgraded',
+      path: '/settings/billing',
+    });
+    return { downgraded: true };
+  },
+};
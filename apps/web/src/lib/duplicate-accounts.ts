@@ -0,0 +1,440 @@
+/**
+ * Duplicate Accounts
+ * Correlates the identifiers seen on each account (World ID nullifiers,
+ * wallets, device fingerprints), face embeddings from the ML API and
+ * reused profile photos (see lib/photo-hashes) to flag accounts that
+ * likely belong to the same person, or one impersonating another, for
+ * admin review.
+ */
+
+import { DuplicateFlag, Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { faceVectorStore } from './vector-store';
+import { AuditLog } from './audit-log';
+import { EventBus } from './event-bus';
+
+export type IdentityKind =
+  | 'world_id'
+  | 'wallet'
+  | 'device'
+  // Nullifier from a World ID age credential proof
+  | 'age_credential';
+
+// "photo" is a similar face; "photo_hash" the same photo
+export type EvidenceKind = IdentityKind | 'photo' | 'photo_hash';
+
+export interface DuplicateEvidence {
+  kind: EvidenceKind;
+  value?: string;
+  // photo only
+  similarity?: number;
+  // photo_hash only: differing bits between the two photos' hashes
+  distance?: number;
+}
+
+const PHOTO_EVIDENCE: EvidenceKind[] = ['photo', 'photo_hash'];
+
+export const DUPLICATE_REVIEW_DECISIONS = ['confirmed', 'dismissed'] as const;
+
+export type DuplicateReviewDecision =
+  (typeof DUPLICATE_REVIEW_DECISIONS)[number];
+
+// How strongly each kind of shared evidence suggests the same person
+const EVIDENCE_WEIGHTS: Record<EvidenceKind, number> = {
+  world_id: 1,
+  age_credential: 1,
+  wallet: 0.9,
+  // Enough to flag on its own: reusing a photo is how catfishing starts
+  photo_hash: 0.8,
+  photo: 0.6,
+  device: 0.5,
+};
+
+const FLAG_THRESHOLD = parseFloat(
+  process.env.DUPLICATE_FLAG_THRESHOLD || '0.8'
+);
+const PHOTO_SIMILARITY_THRESHOLD = parseFloat(
+  process.env.DUPLICATE_PHOTO_SIMILARITY || '0.92'
+);
+
+const MAX_GRAPH_NODES = 50;
+
+// Client-supplied device fingerprint header
+export const DEVICE_FINGERPRINT_HEADER = 'x-device-fingerprint';
+
+const userSummary = {
+  id: true,
+  handle: true,
+  displayName: true,
+  profileImage: true,
+  status: true,
+  shadowbanned: true,
+  createdAt: true,
+} as const;
+
+/**
+ * Combined likelihood that two accounts are the same person; independent
+ * evidence compounds without ever exceeding 1
+ */
+function duplicateScore(evidence: DuplicateEvidence[]): number {
+  const unlikely = evidence.reduce(
+    (product, item) => product * (1 - EVIDENCE_WEIGHTS[item.kind]),
+    1
+  );
+  return Math.round((1 - unlikely) * 1000) / 1000;
+}
+
+function orderPair(a: string, b: string): [string, string] {
+  return a < b ? [a, b] : [b, a];
+}
+
+/**
+ * Identifiers two accounts have in common
+ */
+async function sharedSignals(
+  userId: string,
+  otherUserId: string
+): Promise<DuplicateEvidence[]> {
+  const signals = await prisma.identitySignal.findMany({
+    where: { userId: { in: [userId, otherUserId] } },
+    select: { userId: true, kind: true, value: true },
+  });
+  const mine = new Set(
+    signals
+      .filter(s => s.userId === userId)
+      .map(s => `${s.kind}|${s.value}`)
+  );
+  return signals
+    .filter(s => s.userId === otherUserId && mine.has(`${s.kind}|${s.value}`))
+    .map(s => ({ kind: s.kind as IdentityKind, value: s.value }));
+}
+
+/**
+ * Re-score a pair of accounts, flagging it once the evidence is strong
+ * enough. Reviewed flags keep their decision but get fresh evidence.
+ */
+async function evaluatePair(
+  userId: string,
+  otherUserId: string,
+  photo?: DuplicateEvidence
+): Promise<DuplicateFlag | null> {
+  const [user1Id, user2Id] = orderPair(userId, otherUserId);
+  const existing = await prisma.duplicateFlag.findUnique({
+    where: { user1Id_user2Id: { user1Id, user2Id } },
+  });
+
+  // Photo matches found earlier still count
+  const earlierPhotos = (
+    (existing?.evidence as unknown as DuplicateEvidence[]) || []
+  ).filter(
+    item => PHOTO_EVIDENCE.includes(item.kind) && item.kind !== photo?.kind
+  );
+  const evidence = await sharedSignals(user1Id, user2Id);
+  evidence.push(...earlierPhotos);
+  if (photo) {
+    evidence.push(photo);
+  }
+
+  const score = duplicateScore(evidence);
+  if (!existing && score < FLAG_THRESHOLD) {
+    return null;
+  }
+
+  const flag = await prisma.duplicateFlag.upsert({
+    where: { user1Id_user2Id: { user1Id, user2Id } },
+    create: {
+      user1Id,
+      user2Id,
+      score,
+      evidence: evidence as unknown as Prisma.InputJsonValue,
+    },
+    update: {
+      score,
+      evidence: evidence as unknown as Prisma.InputJsonValue,
+    },
+  });
+
+  if (!existing) {
+    await AuditLog.record({
+      action: 'moderation.duplicate_flagged',
+      actorType: 'system',
+      targetType: 'user',
+      targetId: user1Id,
+      details: {
+        flagId: flag.id,
+        duplicateUserId: user2Id,
+        score,
+        evidence: evidence.map(item => item.kind),
+      },
+    });
+    await EventBus.publish('moderation.duplicate_flagged', {
+      flagId: flag.id,
+      user1Id,
+      user2Id,
+      score,
+    });
+  }
+  return flag;
+}
+
+export class DuplicateAccounts {
+  /**
+   * Record an identifier seen on an account and re-score every other
+   * account that shares it. Never throws.
+   */
+  static async recordSignal(
+    userId: string,
+    kind: IdentityKind,
+    value: string | null | undefined
+  ): Promise<void> {
+    if (!value) {
+      return;
+    }
+
+    try {
+      const normalized = kind === 'wallet' ? value.toLowerCase() : value;
+      await prisma.identitySignal.upsert({
+        where: {
+          userId_kind_value: { userId, kind, value: normalized },
+        },
+        create: { userId, kind, value: normalized },
+        update: { lastSeenAt: new Date() },
+      });
+
+      const others = await prisma.identitySignal.findMany({
+        where: { kind, value: normalized, NOT: { userId } },
+        select: { userId: true },
+      });
+      for (const other of others) {
+        await evaluatePair(userId, other.userId);
+      }
+    } catch (error) {
+      console.error(`Error recording ${kind} identity signal:`, error);
+    }
+  }
+
+  /**
+   * Compare an account's face embedding against everyone else's. Called
+   * once the ML API has embedded a new photo. Never throws.
+   */
+  static async checkPhoto(userId: string): Promise<void> {
+    try {
+      const embedding = await faceVectorStore.getUserEmbedding(userId);
+      if (!embedding) {
+        return;
+      }
+
+      const similar = await faceVectorStore.findSimilar(
+        embedding.embedding,
+        5,
+        userId
+      );
+      for (const match of similar) {
+        if (match.similarity < PHOTO_SIMILARITY_THRESHOLD) {
+          continue;
+        }
+        await evaluatePair(userId, match.userId, {
+          kind: 'photo',
+          similarity: Math.round(match.similarity * 1000) / 1000,
+        });
+      }
+    } catch (error) {
+      console.error('Error checking photo for duplicates:', error);
+    }
+  }
+
+  /**
+   * Record that two accounts use the same profile photo. Never throws.
+   */
+  static async recordPhotoMatch(
+    userId: string,
+    otherUserId: string,
+    distance: number
+  ): Promise<void> {
+    try {
+      await evaluatePair(userId, otherUserId, {
+        kind: 'photo_hash',
+        distance,
+      });
+    } catch (error) {
+      console.error('Error recording photo match:', error);
+    }
+  }
+
+  /**
+   * Flags awaiting review (or with the given status), newest first
+   */
+  static async listFlags(status: string, limit: number, cursor?: string) {
+    return prisma.duplicateFlag.findMany({
+      where: { status },
+      include: {
+        user1: { select: userSummary },
+        user2: { select: userSummary },
+      },
+      orderBy: [{ createdAt: 'desc' }, { id: 'desc' }],
+      take: limit,
+      ...(cursor && { cursor: { id: cursor }, skip: 1 }),
+    });
+  }
+
+  /**
+   * Accounts linked to `userId` through shared identifiers or flags, as a
+   * graph of users (nodes) and the evidence connecting them (edges)
+   */
+  static async getEvidenceGraph(userId: string, depth: number) {
+    const visited = new Set([userId]);
+    const edges: Array<{
+      source: string;
+      target: string;
+      kind: EvidenceKind;
+      value?: string;
+      similarity?: number;
+      distance?: number;
+    }> = [];
+    let frontier = [userId];
+
+    for (let level = 0; level < depth && frontier.length > 0; level++) {
+      const signals = await prisma.identitySignal.findMany({
+        where: { userId: { in: frontier } },
+        select: { userId: true, kind: true, value: true },
+      });
+      const shared =
+        signals.length > 0
+          ? await prisma.identitySignal.findMany({
+              where: {
+                OR: signals.map(s => ({ kind: s.kind, value: s.value })),
+                NOT: { userId: { in: frontier } },
+              },
+              select: { userId: true, kind: true, value: true },
+            })
+          : [];
+      const flags = await prisma.duplicateFlag.findMany({
+        where: {
+          OR: [{ user1Id: { in: frontier } }, { user2Id: { in: frontier } }],
+        },
+      });
+
+      const next = new Set<string>();
+      // Whether `target` is (now) in the graph; stops growing at the cap
+      const link = (target: string) => {
+        if (
+          !visited.has(target) &&
+          visited.size + next.size < MAX_GRAPH_NODES
+        ) {
+          next.add(target);
+        }
+        return visited.has(target) || next.has(target);
+      };
+
+      for (const signal of signals) {
+        for (const other of shared) {
+          if (
+            other.kind === signal.kind &&
+            other.value === signal.value &&
+            link(other.userId)
+          ) {
+            edges.push({
+              source: signal.userId,
+              target: other.userId,
+              kind: signal.kind as IdentityKind,
+              value: signal.value,
+            });
+          }
+        }
+      }
+      for (const flag of flags) {
+        const photos = (flag.evidence as unknown as DuplicateEvidence[]).filter(
+          item => PHOTO_EVIDENCE.includes(item.kind)
+        );
+        const [source, target] = frontier.includes(flag.user1Id)
+          ? [flag.user1Id, flag.user2Id]
+          : [flag.user2Id, flag.user1Id];
+        if (photos.length > 0 && link(target)) {
+          for (const photo of photos) {
+            edges.push({
+              source,
+              target,
+              kind: photo.kind,
+              similarity: photo.similarity,
+              distance: photo.distance,
+            });
+          }
+        }
+      }
+
+      next.forEach(id => visited.add(id));
+      frontier = Array.from(next);
+    }
+
+    const ids = Array.from(visited);
+    const [users, flags] = await Promise.all([
+      prisma.user.findMany({
+        where: { id: { in: ids } },
+        select: userSummary,
+      }),
+      prisma.duplicateFlag.findMany({
+        where: { user1Id: { in: ids }, user2Id: { in: ids } },
+      }),
+    ]);
+
+    // Two accounts sharing several identifiers get one edge per identifier;
+    // drop the mirror images found from the other side
+    const seen = new Set<string>();
+    const uniqueEdges = edges.filter(edge => {
+      const key = [
+        ...orderPair(edge.source, edge.target),
+        edge.kind,
+        edge.value ?? '',
+      ].join('|');
+      if (seen.has(key)) {
+        return false;
+      }
+      seen.add(key);
+      return true;
+    });
+
+    return { root: userId, nodes: users, edges: uniqueEdges, flags };
+  }
+
+  /**
+   * Record an admin's decision on a flag. Returns null if it doesn't exist.
+   */
+  static async review(
+    flagId: string,
+    decision: DuplicateReviewDecision,
+    adminId: string,
+    note?: string
+  ): Promise<DuplicateFlag | null> {
+    const flag = await prisma.duplicateFlag.findUnique({
+      where: { id: flagId },
+    });
+    if (!flag) {
+      return null;
+    }
+
+    const updated = await prisma.duplicateFlag.update({
+      where: { id: flagId },
+      data: {
+        status: decision,
+        reviewedBy: adminId,
+        reviewNote: note,
+        reviewedAt: new Date(),
+      },
+    });
+
+    await AuditLog.record({
+      action: `moderation.duplicate_${decision}`,
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'user',
+      targetId: flag.user1Id,
+      details: {
+        flagId,
+        duplicateUserId: flag.user2Id,
+        score: flag.score,
+        note: note ?? null,
+      },
+    });
+    return updated;
+  }
+}
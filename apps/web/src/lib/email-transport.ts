@@ -0,0 +1,199 @@
+/**
+ * Email Transport
+ * Delivers a rendered email through Amazon SES (API v2, SigV4-signed) or
+ * any SMTP server over implicit TLS, chosen by EMAIL_PROVIDER
+ */
+
+import { randomBytes } from 'crypto';
+import { connect, TLSSocket } from 'tls';
+import { signHeaders } from './aws-sigv4';
+import { upstreamFetch } from './http-client';
+
+export interface OutgoingEmail {
+  to: string;
+  subject: string;
+  text: string;
+}
+
+const SEND_TIMEOUT_MS = 10_000;
+
+/**
+ * Whether an email provider is configured for this deployment
+ */
+export function emailEnabled(): boolean {
+  if (!process.env.EMAIL_FROM) {
+    return false;
+  }
+  switch (process.env.EMAIL_PROVIDER) {
+    case 'ses':
+      return Boolean(
+        process.env.AWS_REGION &&
+          process.env.AWS_ACCESS_KEY_ID &&
+          process.env.AWS_SECRET_ACCESS_KEY
+      );
+    case 'smtp':
+      return Boolean(process.env.SMTP_HOST);
+    default:
+      return false;
+  }
+}
+
+async function sendWithSes(email: OutgoingEmail): Promise<void> {
+  const url = `https://email.${process.env.AWS_REGION}.amazonaws.com/v2/email/outbound-emails`;
+  const body = JSON.stringify({
+    FromEmailAddress: process.env.EMAIL_FROM,
+    Destination: { ToAddresses: [email.to] },
+    Content: {
+      Simple: {
+        Subject: { Data: email.subject, Charset: 'UTF-8' },
+        Body: { Text: { Data: email.text, Charset: 'UTF-8' } },
+      },
+    },
+  });
+
+  const response = await upstreamFetch(
+    'ses',
+    url,
+    {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        ...signHeaders({ method: 'POST', url, service: 'ses', body }),
+      },
+      body,
+    },
+    { timeoutMs: SEND_TIMEOUT_MS }
+  );
+  if (!response.ok) {
+    throw new Error(`SES rejected email: ${response.status}`);
+  }
+}
+
+/**
+ * RFC 2047 encoding for header values that aren't plain ASCII
+ */
+function encodeHeader(value: string): string {
+  return /^[\x20-\x7e]*$/.test(value)
+    ? value
+    : `=?UTF-8?B?${Buffer.from(value).toString('base64')}?=`;
+}
+
+function buildMessage(email: OutgoingEmail): string {
+  const from = process.env.EMAIL_FROM!;
+  const domain = from.split('@').pop()!.replace(/>$/, '');
+  const body = Buffer.from(email.text)
+    .toString('base64')
+    .replace(/.{76}/g, '$&\r\n');
+
+  return [
+    `From: ${from}`,
+    `To: ${email.to}`,
+    `Subject: ${encodeHeader(email.subject)}`,
+    `Date: ${new Date().toUTCString()}`,
+    `Message-ID: <${randomBytes(16).toString('hex')}@${domain}>`,
+    'MIME-Version: 1.0',
+    'Content-Type: text/plain; charset=UTF-8',
+    'Content-Transfer-Encoding: base64',
+    '',
+    body,
+  ].join('\r\n');
+}
+
+/**
+ * Minimal SMTP conversation: one message, AUTH LOGIN, implicit TLS
+ */
+async function sendWithSmtp(email: OutgoingEmail): Promise<void> {
+  const socket: TLSSocket = connect({
+    host: process.env.SMTP_HOST,
+    port: parseInt(process.env.SMTP_PORT || '465'),
+    servername: process.env.SMTP_HOST,
+  });
+  socket.setTimeout(SEND_TIMEOUT_MS, () =>
+    socket.destroy(new Error('SMTP timeout'))
+  );
+
+  let buffer = '';
+  let pending: {
+    resolve: (reply: string) => void;
+    reject: (error: Error) => void;
+  } | null = null;
+  let failed: Error | null = null;
+
+  // A reply is complete once its last line has a space after the code
+  const takeReply = () => {
+    const lines = buffer.split('\r\n');
+    const last = lines[lines.length - 2];
+    if (!pending || !last || !/^\d{3} /.test(last)) {
+      return;
+    }
+    const { resolve } = pending;
+    const reply = buffer;
+    buffer = '';
+    pending = null;
+    resolve(reply);
+  };
+
+  socket.on('data', chunk => {
+    buffer += chunk.toString();
+    takeReply();
+  });
+  socket.on('error', error => {
+    failed = error;
+    pending?.reject(error);
+    pending = null;
+  });
+
+  const command = async (line: string | null, expected: string) => {
+    const reply = new Promise<string>((resolve, reject) => {
+      if (failed) {
+        return reject(failed);
+      }
+      pending = { resolve, reject };
+      takeReply();
+    });
+    if (line !== null) {
+      socket.write(`${line}\r\n`);
+    }
+    const response = await reply;
+    if (!response.startsWith(expected)) {
+      throw new Error(`SMTP error: ${response.trim()}`);
+    }
+  };
+
+  try {
+    await command(null, '220');
+    await command(`EHLO ${process.env.SMTP_HELO || 'localhost'}`, '250');
+    if (process.env.SMTP_USER) {
+      await command('AUTH LOGIN', '334');
+      await command(
+        Buffer.from(process.env.SMTP_USER).toString('base64'),
+        '334'
+      );
+      await command(
+        Buffer.from(process.env.SMTP_PASSWORD || '').toString('base64'),
+        '235'
+      );
+    }
+    const from = process.env.EMAIL_FROM!.match(/<([^>]+)>/)?.[1];
+    await command(`MAIL FROM:<${from || process.env.EMAIL_FROM}>`, '250');
+    await command(`RCPT TO:<${email.to}>`, '250');
+    await command('DATA', '354');
+    // Dot-stuffing: lines starting with "." get another one
+    const message = buildMessage(email).replace(/^\./gm, '..');
+    await command(`${message}\r\n.`, '250');
+    socket.write('QUIT\r\n');
+  } finally {
+    socket.end();
+  }
+}
+
+/**
+ * Send one email through the configured provider. Throws on failure.
+ */
+export async function sendEmail(email: OutgoingEmail): Promise<void> {
+  if (process.env.EMAIL_PROVIDER === 'ses') {
+    await sendWithSes(email);
+  } else {
+    await sendWithSmtp(email);
+  }
+}
@@ -0,0 +1,332 @@
+/**
+ * Email
+ * Account-level emails (data exports, security alerts, receipts), rendered
+ * in the user's locale. Nothing is sent to an address until the user has
+ * confirmed it with a code.
+ */
+
+import { createHash, randomInt } from 'crypto';
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { emailEnabled, sendEmail } from './email-transport';
+import {
+  NotificationTemplates,
+  resolveLocale,
+  TemplateLocale,
+} from './notification-templates';
+import { AuditLog } from './audit-log';
+import { blindIndex } from './field-encryption';
+
+const CODE_TTL_MINUTES = 30;
+const MAX_CODE_ATTEMPTS = 5;
+// Codes a user can request per hour
+const MAX_CODES_PER_HOUR = 5;
+
+const hashCode = (code: string) =>
+  createHash('sha256').update(code).digest('hex');
+
+export type VerificationStart =
+  | { status: 'sent'; expiresAt: Date }
+  | { status: 'email_taken' }
+  | { status: 'rate_limited' }
+  | { status: 'unavailable' };
+
+export type VerificationResult =
+  | { status: 'verified'; email: string }
+  | { status: 'invalid_code' }
+  | { status: 'expired' }
+  | { status: 'email_taken' };
+
+interface Delivery {
+  userId: string;
+  to: string;
+  locale: TemplateLocale;
+  template: string;
+  variables: Record<string, string | number>;
+  dedupeKey?: string;
+}
+
+/**
+ * Render, send and log one email. Returns whether it was sent.
+ */
+async function deliver(delivery: Delivery): Promise<boolean> {
+  let error: string | null = null;
+  try {
+    const copy = await NotificationTemplates.render(
+      delivery.template,
+      'email',
+      delivery.locale,
+      delivery.variables
+    );
+    await sendEmail({ to: delivery.to, subject: copy.title, text: copy.body });
+  } catch (sendError) {
+    console.error(`Error sending ${delivery.template} email:`, sendError);
+    error = sendError instanceof Error ? sendError.message : 'send_failed';
+  }
+
+  try {
+    await prisma.emailMessage.create({
+      data: {
+        userId: delivery.userId,
+        template: delivery.template,
+        toAddress: delivery.to,
+        locale: delivery.locale,
+        dedupeKey: delivery.dedupeKey,
+        status: error ? 'failed' : 'sent',
+        error,
+      },
+    });
+  } catch (logError) {
+    console.error('Error logging email:', logError);
+  }
+  return !error;
+}
+
+export class Email {
+  /**
+   * Send a template to the user's verified address. Does nothing if they
+   * have none, or if `dedupeKey` was already sent. Never throws.
+   */
+  static async sendToUser(
+    userId: string,
+    template: string,
+    variables: Record<string, string | number>,
+    dedupeKey?: string
+  ): Promise<boolean> {
+    if (!emailEnabled()) {
+      return false;
+    }
+
+    try {
+      const user = await prisma.user.findUnique({
+        where: { id: userId },
+        select: { email: true, emailVerifiedAt: true, locale: true },
+      });
+      if (!user?.email || !user.emailVerifiedAt) {
+        return false;
+      }
+      if (
+        dedupeKey &&
+        (await prisma.emailMessage.findFirst({
+          where: { dedupeKey, status: 'sent' },
+        }))
+      ) {
+        return false;
+      }
+      // A failed attempt gives up its key so a retry can send again
+      if (dedupeKey) {
+        await prisma.emailMessage.updateMany({
+          where: { dedupeKey, status: 'failed' },
+          data: { dedupeKey: null },
+        });
+      }
+
+      return await deliver({
+        userId,
+        to: user.email,
+        locale: resolveLocale(user.locale),
+        template,
+        variables,
+        dedupeKey,
+      });
+    } catch (error) {
+      console.error(`Error sending ${template} email:`, error);
+      return false;
+    }
+  }
+
+  /**
+   * Send a template to someone other than the user, on their behalf (e.g.
+   * a trusted contact), in the user's locale. Logged under the user. Does
+   * nothing if `dedupeKey` was already sent. Never throws.
+   */
+  static async sendToContact(
+    userId: string,
+    to: string,
+    template: string,
+    variables: Record<string, string | number>,
+    dedupeKey?: string
+  ): Promise<boolean> {
+    if (!emailEnabled()) {
+      return false;
+    }
+
+    try {
+      if (
+        dedupeKey &&
+        (await prisma.emailMessage.findFirst({
+          where: { dedupeKey, status: 'sent' },
+        }))
+      ) {
+        return false;
+      }
+      if (dedupeKey) {
+        await prisma.emailMessage.updateMany({
+          where: { dedupeKey, status: 'failed' },
+          data: { dedupeKey: null },
+        });
+      }
+      const user = await prisma.user.findUnique({
+        where: { id: userId },
+        select: { locale: true },
+      });
+
+      return await deliver({
+        userId,
+        to,
+        locale: resolveLocale(user?.locale),
+        template,
+        variables,
+        dedupeKey,
+      });
+    } catch (error) {
+      console.error(`Error sending ${template} email:`, error);
+      return false;
+    }
+  }
+
+  /**
+   * The user's address and whether it's verified
+   */
+  static async getAddress(userId: string) {
+    return prisma.user.findUnique({
+      where: { id: userId },
+      select: { email: true, emailVerifiedAt: true },
+    });
+  }
+
+  /**
+   * Email a confirmation code to an address the user wants to use. The
+   * address isn't saved on the account until the code is confirmed.
+   */
+  static async startVerification(
+    userId: string,
+    email: string
+  ): Promise<VerificationStart> {
+    if (!emailEnabled()) {
+      return { status: 'unavailable' };
+    }
+
+    const normalized = email.trim().toLowerCase();
+    // Emails are encrypted, so they're looked up by their blind index
+    const taken = await prisma.user.findFirst({
+      where: {
+        emailHash: blindIndex('User', 'email', normalized),
+        NOT: { id: userId },
+      },
+      select: { id: true },
+    });
+    if (taken) {
+      return { status: 'email_taken' };
+    }
+
+    const recent = await prisma.emailVerification.count({
+      where: {
+        userId,
+        createdAt: { gt: new Date(Date.now() - 60 * 60 * 1000) },
+      },
+    });
+    if (recent >= MAX_CODES_PER_HOUR) {
+      return { status: 'rate_limited' };
+    }
+
+    const code = randomInt(0, 1_000_000).toString().padStart(6, '0');
+    const expiresAt = new Date(Date.now() + CODE_TTL_MINUTES * 60 * 1000);
+    await prisma.emailVerification.create({
+      data: { userId, email: normalized, codeHash: hashCode(code), expiresAt },
+    });
+
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { locale: true },
+    });
+    const sent = await deliver({
+      userId,
+      to: normalized,
+      locale: resolveLocale(user?.locale),
+      template: 'verify_email',
+      variables: { code, minutes: CODE_TTL_MINUTES },
+    });
+    return sent ? { status: 'sent', expiresAt } : { status: 'unavailable' };
+  }
+
+  /**
+   * Check a code against the user's latest pending verification and, if it
+   * matches, make that address the account's email. The previous address
+   * is told about the change.
+   */
+  static async confirmVerification(
+    userId: string,
+    code: string
+  ): Promise<VerificationResult> {
+    const pending = await prisma.emailVerification.findFirst({
+      where: { userId, usedAt: null },
+      orderBy: { createdAt: 'desc' },
+    });
+    if (
+      !pending ||
+      pending.expiresAt < new Date() ||
+      pending.attempts >= MAX_CODE_ATTEMPTS
+    ) {
+      return { status: 'expired' };
+    }
+
+    if (hashCode(code) !== pending.codeHash) {
+      await prisma.emailVerification.update({
+        where: { id: pending.id },
+        data: { attempts: { increment: 1 } },
+      });
+      return { status: 'invalid_code' };
+    }
+
+    const previous = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { email: true, emailVerifiedAt: true, locale: true },
+    });
+    try {
+      await prisma.$transaction([
+        prisma.emailVerification.update({
+          where: { id: pending.id },
+          data: { usedAt: new Date() },
+        }),
+        prisma.user.update({
+          where: { id: userId },
+          data: { email: pending.email, emailVerifiedAt: new Date() },
+        }),
+      ]);
+    } catch (error) {
+      // Someone else verified the address in the meantime
+      if (
+        error instanceof Prisma.PrismaClientKnownRequestError &&
+        error.code === 'P2002'
+      ) {
+        return { status: 'email_taken' };
+      }
+      throw error;
+    }
+
+    await AuditLog.record({
+      action: 'user.email_verified',
+      actorType: 'user',
+      actorId: userId,
+      targetType: 'user',
+      targetId: userId,
+      details: { changed: Boolean(previous?.emailVerifiedAt) },
+    });
+    if (
+      previous?.email &&
+      previous.emailVerifiedAt &&
+      previous.email !== pending.email &&
+      emailEnabled()
+    ) {
+      await deliver({
+        userId,
+        to: previous.email,
+        locale: resolveLocale(previous.locale),
+        template: 'security_email_changed',
+        variables: { newEmail: pending.email },
+      });
+    }
+    return { status: 'verified', email: pending.email };
+  }
+}
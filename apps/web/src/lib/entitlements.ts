@@ -0,0 +1,331 @@
+/**
+ * Subscriptions and Entitlements
+ * Plans grant a set of feature entitlements; purchases and manual grants add
+ * more. Other modules consult `Entitlements` rather than checking plans.
+ * Active entitlements are cached per user; anything that writes them
+ * outside this module must call `Entitlements.invalidate`.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { createCache } from './cache';
+
+export const FEATURES = [
+  'see_who_liked_me',
+  'extra_super_interests',
+  'boosts',
+  'travel_mode',
+  'extra_top_picks',
+] as const;
+
+export type Feature = (typeof FEATURES)[number];
+
+export type Plan = 'free' | 'premium';
+
+// Recurring billing providers' subscription reference (null to clear it)
+export interface PlanBilling {
+  provider?: string | null;
+  providerSubscriptionId?: string | null;
+}
+
+export interface PlanDefinition {
+  name: string;
+  // Feature -> allowance (null for unlimited)
+  features: Partial<Record<Feature, number | null>>;
+}
+
+export const PLANS: Record<Plan, PlanDefinition> = {
+  free: {
+    name: 'Free',
+    features: {},
+  },
+  premium: {
+    name: 'Premium',
+    features: {
+      see_who_liked_me: null,
+      extra_super_interests: 5, // Extra super-interests per day
+      boosts: 1, // Boosts per plan period
+      travel_mode: null,
+      extra_top_picks: 6, // Extra Top Picks per day
+    },
+  },
+};
+
+export interface ActiveEntitlement {
+  feature: Feature;
+  limit: number | null;
+  sources: string[];
+  expiresAt: string | null;
+}
+
+export interface SubscriptionSummary {
+  plan: Plan;
+  status: string;
+  startedAt: string | null;
+  expiresAt: string | null;
+  graceEndsAt: string | null;
+}
+
+const PLAN_SOURCE_PREFIX = 'plan:';
+
+const activeCache = createCache<ActiveEntitlement[]>('entitlements', {
+  ttlSeconds: 60,
+});
+
+function activeWhere(userId: string) {
+  return {
+    userId,
+    OR: [{ expiresAt: null }, { expiresAt: { gt: new Date() } }],
+  };
+}
+
+/**
+ * Unexpired entitlements merged per feature. Allowances from multiple
+ * sources add up; any unlimited source makes the feature unlimited.
+ */
+async function loadActive(userId: string): Promise<ActiveEntitlement[]> {
+  const rows = await prisma.entitlement.findMany({
+    where: activeWhere(userId),
+    orderBy: { createdAt: 'asc' },
+  });
+
+  const merged = new Map<string, ActiveEntitlement>();
+  rows.forEach(row => {
+    const existing = merged.get(row.feature);
+    const expiresAt = row.expiresAt?.toISOString() || null;
+
+    if (!existing) {
+      merged.set(row.feature, {
+        feature: row.feature as Feature,
+        limit: row.limit,
+        sources: [row.source],
+        expiresAt,
+      });
+      return;
+    }
+
+    existing.limit =
+      existing.limit === null || row.limit === null
+        ? null
+        : existing.limit + row.limit;
+    existing.sources.push(row.source);
+    // Report the latest expiry; null means at least one never expires
+    if (existing.expiresAt !== null) {
+      existing.expiresAt =
+        expiresAt === null || expiresAt > existing.expiresAt
+          ? expiresAt
+          : existing.expiresAt;
+    }
+  });
+
+  return Array.from(merged.values());
+}
+
+export class Entitlements {
+  /**
+   * The user's current plan (free when none is active)
+   */
+  static async getSubscription(userId: string): Promise<SubscriptionSummary> {
+    const subscription = await prisma.subscription.findUnique({
+      where: { userId },
+    });
+
+    // Past-due subscriptions keep their plan until the grace window ends
+    const endsAt =
+      subscription?.status === 'past_due' && subscription.graceEndsAt
+        ? subscription.graceEndsAt
+        : subscription?.expiresAt;
+    const active =
+      subscription &&
+      ['active', 'past_due'].includes(subscription.status) &&
+      (!endsAt || endsAt > new Date());
+
+    if (!subscription || !active) {
+      return {
+        plan: 'free',
+        status: subscription?.status || 'active',
+        startedAt: null,
+        expiresAt: null,
+        graceEndsAt: null,
+      };
+    }
+
+    return {
+      plan: subscription.plan as Plan,
+      status: subscription.status,
+      startedAt: subscription.startedAt.toISOString(),
+      expiresAt: subscription.expiresAt?.toISOString() || null,
+      graceEndsAt: subscription.graceEndsAt?.toISOString() || null,
+    };
+  }
+
+  /**
+   * Unexpired entitlements merged per feature (see loadActive)
+   */
+  static async getActive(userId: string): Promise<ActiveEntitlement[]> {
+    const active = await activeCache.getOrLoad(userId, () =>
+      loadActive(userId)
+    );
+    // Drop any that ran out while cached
+    const now = new Date().toISOString();
+    return active.filter(
+      entitlement =>
+        entitlement.expiresAt === null || entitlement.expiresAt > now
+    );
+  }
+
+  /**
+   * Whether the user currently holds a feature
+   */
+  static async has(userId: string, feature: Feature): Promise<boolean> {
+    const active = await Entitlements.getActive(userId);
+    return active.some(entitlement => entitlement.feature === feature);
+  }
+
+  /**
+   * Allowance for a metered feature: 0 without the entitlement, Infinity if
+   * any source is unlimited
+   */
+  static async getLimit(userId: string, feature: Feature): Promise<number> {
+    const active = await Entitlements.getActive(userId);
+    const entitlement = active.find(item => item.feature === feature);
+    if (!entitlement) {
+      return 0;
+    }
+    return entitlement.limit ?? Infinity;
+  }
+
+  /**
+   * Forget the user's cached entitlements after changing them
+   */
+  static async invalidate(userId: string): Promise<void> {
+    await activeCache.invalidate(userId);
+  }
+
+  /**
+   * Grant a single entitlement (purchases, promotions, support grants)
+   */
+  static async grant(
+    userId: string,
+    feature: Feature,
+    options: { source: string; limit?: number | null; expiresAt?: Date | null }
+  ): Promise<void> {
+    await prisma.entitlement.create({
+      data: {
+        userId,
+        feature,
+        source: options.source,
+        limit: options.limit ?? null,
+        expiresAt: options.expiresAt ?? null,
+      },
+    });
+    await Entitlements.invalidate(userId);
+  }
+
+  /**
+   * Revoke every entitlement granted by a source
+   */
+  static async revokeSource(userId: string, source: string): Promise<number> {
+    const result = await prisma.entitlement.deleteMany({
+      where: { userId, source },
+    });
+    await Entitlements.invalidate(userId);
+    return result.count;
+  }
+
+  /**
+   * Update a subscription's billing status without changing entitlements
+   */
+  static async setSubscriptionStatus(
+    userId: string,
+    status: string
+  ): Promise<void> {
+    await prisma.subscription.updateMany({
+      where: { userId },
+      data: { status },
+    });
+  }
+
+  /**
+   * Move a user onto a plan, replacing any previous plan entitlements.
+   * Recurring billing providers pass their subscription reference.
+   */
+  static async setPlan(
+    userId: string,
+    plan: Plan,
+    expiresAt: Date | null = null,
+    billing: PlanBilling = {}
+  ): Promise<void> {
+    await prisma.$transaction(
+      Entitlements.planWrites(userId, plan, expiresAt, billing)
+    );
+    await Entitlements.planChanged(userId, plan, expiresAt, billing);
+  }
+
+  /**
+   * The writes behind `setPlan`, for callers that must commit them together
+   * with their own. Call `planChanged` once they're committed.
+   */
+  static planWrites(
+    userId: string,
+    plan: Plan,
+    expiresAt: Date | null = null,
+    billing: PlanBilling = {}
+  ): Prisma.PrismaPromise<unknown>[] {
+    const features = Object.entries(PLANS[plan].features) as [
+      Feature,
+      number | null,
+    ][];
+
+    return [
+      prisma.subscription.upsert({
+        where: { userId },
+        create: { userId, plan, status: 'active', expiresAt, ...billing },
+        update: {
+          plan,
+          status: 'active',
+          startedAt: new Date(),
+          expiresAt,
+          ...billing,
+        },
+      }),
+      prisma.entitlement.deleteMany({
+        where: { userId, source: { startsWith: PLAN_SOURCE_PREFIX } },
+      }),
+      prisma.entitlement.createMany({
+        data: features.map(([feature, limit]) => ({
+          userId,
+          feature,
+          limit,
+          source: `${PLAN_SOURCE_PREFIX}${plan}`,
+          expiresAt,
+        })),
+      }),
+    ];
+  }
+
+  /**
+   * Drop cached entitlements and audit a committed plan change
+   */
+  static async planChanged(
+    userId: string,
+    plan: Plan,
+    expiresAt: Date | null = null,
+    billing: PlanBilling = {}
+  ): Promise<void> {
+    await Entitlements.invalidate(userId);
+
+    await AuditLog.recordSafely({
+      action: 'billing.plan_changed',
+      actorType: 'system',
+      targetType: 'user',
+      targetId: userId,
+      details: {
+        plan,
+        expiresAt: expiresAt?.toISOString() || null,
+        provider: billing.provider ?? null,
+      },
+    });
+  }
+}
@@ -0,0 +1,172 @@
+/**
+ * Event Bus
+ * Publishes domain events to in-process subscribers, a capped Redis stream
+ * (for out-of-process consumers) and any configured outbound webhooks
+ */
+
+import { createHmac } from 'crypto';
+import redis from './redis';
+import { upstreamFetch } from './http-client';
+
+export interface DomainEvent<T = Record<string, unknown>> {
+  id: string;
+  type: string;
+  occurredAt: string;
+  payload: T;
+}
+
+export type EventHandler<T = Record<string, unknown>> = (
+  event: DomainEvent<T>
+) => Promise<void> | void;
+
+export const EVENT_STREAM_KEY = 'events';
+
+// Approximate cap on the Redis event stream
+const STREAM_MAX_LENGTH = 10000;
+
+// Comma-separated webhook endpoints that receive every event
+const WEBHOOK_URLS = (process.env.EVENT_WEBHOOK_URLS || '')
+  .split(',')
+  .map(url => url.trim())
+  .filter(Boolean);
+
+const WEBHOOK_TIMEOUT_MS = 5000;
+
+declare global {
+  var eventHandlers: undefined | Map<string, Set<EventHandler<any>>>;
+}
+
+// Shared across route bundles so subscriptions registered anywhere see
+// events published anywhere in the process
+const handlers: Map<string, Set<EventHandler<any>>> =
+  globalThis.eventHandlers ?? new Map();
+globalThis.eventHandlers = handlers;
+
+export class EventBus {
+  /**
+   * Subscribe to an event type ('*' receives every event). Returns an
+   * unsubscribe function.
+   */
+  static subscribe<T = Record<string, unknown>>(
+    type: string,
+    handler: EventHandler<T>
+  ): () => void {
+    let set = handlers.get(type);
+    if (!set) {
+      set = new Set();
+      handlers.set(type, set);
+    }
+    set.add(handler);
+    return () => {
+      set!.delete(handler);
+    };
+  }
+
+  /**
+   * Publish an event. Delivery failures are logged, never thrown, so
+   * publishing can't break the request that triggered it.
+   */
+  static async publish<T extends object>(
+    type: string,
+    payload: T
+  ): Promise<DomainEvent<T>> {
+    const event: DomainEvent<T> = {
+      id: crypto.randomUUID(),
+      type,
+      occurredAt: new Date().toISOString(),
+      payload,
+    };
+
+    try {
+      await appendToStream(event);
+    } catch (error) {
+      console.error(`Error appending ${type} event to stream:`, error);
+    }
+    await fanOut(event);
+    return event;
+  }
+
+  /**
+   * Deliver an event built earlier (e.g. relayed from the outbox). Throws
+   * if it can't be appended to the stream, before anyone else sees it, so
+   * the caller can retry.
+   */
+  static async deliver(event: DomainEvent<object>): Promise<void> {
+    await appendToStream(event);
+    await fanOut(event);
+  }
+}
+
+async function appendToStream(event: DomainEvent<object>): Promise<void> {
+  await redis.xadd(
+    EVENT_STREAM_KEY,
+    'MAXLEN',
+    '~',
+    STREAM_MAX_LENGTH,
+    '*',
+    'type',
+    event.type,
+    'event',
+    JSON.stringify(event)
+  );
+}
+
+/**
+ * In-process subscribers and webhooks. Failures are logged.
+ */
+async function fanOut(event: DomainEvent<object>): Promise<void> {
+  const { type } = event;
+  const subscribers = [
+    ...(handlers.get(type) || []),
+    ...(handlers.get('*') || []),
+  ];
+  await Promise.all(
+    subscribers.map(async handler => {
+      try {
+        await handler(event);
+      } catch (error) {
+        console.error(`Error in ${type} event handler:`, error);
+      }
+    })
+  );
+
+  // Webhooks are fire-and-forget; slow receivers shouldn't add latency
+  const body = JSON.stringify(event);
+  WEBHOOK_URLS.forEach(url => {
+    deliverWebhook(url, type, body).catch(error => {
+      console.error(`Error delivering ${type} event to ${url}:`, error);
+    });
+  });
+}
+
+async function deliverWebhook(
+  url: string,
+  type: string,
+  body: string
+): Promise<void> {
+  const headers: Record<string, string> = {
+    'Content-Type': 'application/json',
+    'X-Aurum-Event': type,
+  };
+
+  // Receivers verify HMAC-SHA256(secret, body) to authenticate deliveries
+  if (process.env.EVENT_WEBHOOK_SECRET) {
+    headers['X-Aurum-Signature'] = createHmac(
+      'sha256',
+      process.env.EVENT_WEBHOOK_SECRET
+    )
+      .update(body)
+      .digest('hex');
+  }
+
+  const response = await upstreamFetch(
+    'event-webhook',
+    url,
+    { method: 'POST', headers, body },
+    { timeoutMs: WEBHOOK_TIMEOUT_MS }
+  );
+
+  if (!response.ok) {
+    throw new Error(`Webhook responded with HTTP ${response.status}`);
+  }
+}
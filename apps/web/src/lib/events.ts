@@ -0,0 +1,420 @@
+/**
+ * Events
+ * Ecosystem events, e.g. campus parties, published by admins. Users find
+ * upcoming ones by directory place or near them, RSVP (up to the event's
+ * capacity) and check in at the venue while it's on, which publishes
+ * event.attended. During the event window, users who RSVP'd can switch
+ * discovery to a deck of just the other attendees.
+ */
+
+import { Event, Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { Places } from './places';
+import { Locations } from './locations';
+import { encodeGeohash } from './geohash';
+import { EventBus } from './event-bus';
+import { Notifications } from './notifications';
+import { AuditLog } from './audit-log';
+
+export const EVENT_STATUSES = ['published', 'canceled'] as const;
+
+export type EventStatus = (typeof EVENT_STATUSES)[number];
+
+// Venue cells are stored this fine (~1.2 km) and matched by prefix
+const VENUE_PRECISION = 6;
+const HOUR_MS = 60 * 60 * 1000;
+// Longest an event can run
+const MAX_EVENT_LENGTH_MS = 48 * HOUR_MS;
+const LIST_LIMIT = 50;
+// Attendee decks are small; no need to page
+const MAX_DECK_ATTENDEES = 500;
+
+export interface EventInput {
+  title: string;
+  description?: string | null;
+  venue: string;
+  cityId: string;
+  campusId?: string | null;
+  lat: number;
+  lng: number;
+  startsAt: Date;
+  endsAt: Date;
+  capacity?: number | null;
+}
+
+export type EventUpdate = Partial<EventInput>;
+
+export interface EventFilters {
+  cityId?: string;
+  campusId?: string;
+  // Only events around the viewer's location
+  nearby?: boolean;
+}
+
+export type EventResult =
+  | { status: 'saved'; event: Event }
+  | { status: 'not_found' }
+  | { status: 'invalid_place' }
+  | { status: 'invalid_time' };
+
+export type RsvpResult =
+  | { status: 'going' }
+  | { status: 'not_found' }
+  | { status: 'full' }
+  | { status: 'ended' };
+
+export type EventCheckInResult =
+  | { status: 'attended' }
+  | { status: 'not_found' }
+  | { status: 'not_going' }
+  | { status: 'not_live' }
+  | { status: 'not_nearby' };
+
+type EventWithCount = Event & { _count: { rsvps: number } };
+
+/**
+ * What users are shown of an event
+ */
+export function toEventSummary(event: EventWithCount, going: boolean) {
+  return {
+    id: event.id,
+    title: event.title,
+    description: event.description,
+    venue: event.venue,
+    cityId: event.cityId,
+    campusId: event.campusId,
+    lat: event.lat,
+    lng: event.lng,
+    startsAt: event.startsAt,
+    endsAt: event.endsAt,
+    capacity: event.capacity,
+    attendees: event._count.rsvps,
+    spotsLeft:
+      event.capacity === null
+        ? null
+        : Math.max(0, event.capacity - event._count.rsvps),
+    status: event.status,
+    going,
+  };
+}
+
+function isLive(event: Event, now = new Date()): boolean {
+  return (
+    event.status === 'published' &&
+    event.startsAt <= now &&
+    event.endsAt > now
+  );
+}
+
+function validTimes(startsAt: Date, endsAt: Date): boolean {
+  return (
+    endsAt > startsAt &&
+    endsAt.getTime() - startsAt.getTime() <= MAX_EVENT_LENGTH_MS
+  );
+}
+
+/**
+ * The campus, if any, must be in the city
+ */
+async function validPlaces(
+  cityId: string,
+  campusId: string | null | undefined
+): Promise<boolean> {
+  if (!(await Places.isSelectable(cityId, 'city'))) {
+    return false;
+  }
+  if (!campusId) {
+    return true;
+  }
+  const campus = await Places.get(campusId);
+  return Boolean(
+    campus?.kind === 'campus' && campus.active && campus.cityId === cityId
+  );
+}
+
+async function withViewer(events: EventWithCount[], viewerId: string) {
+  const rsvps = await prisma.eventRsvp.findMany({
+    where: { userId: viewerId, eventId: { in: events.map(e => e.id) } },
+    select: { eventId: true },
+  });
+  const going = new Set(rsvps.map(rsvp => rsvp.eventId));
+  return events.map(event => toEventSummary(event, going.has(event.id)));
+}
+
+export class Events {
+  /**
+   * Upcoming and ongoing events, soonest first
+   */
+  static async list(viewerId: string, filters: EventFilters = {}) {
+    const where: Prisma.EventWhereInput = {
+      status: 'published',
+      endsAt: { gt: new Date() },
+      ...(filters.cityId && { cityId: filters.cityId }),
+      ...(filters.campusId && { campusId: filters.campusId }),
+    };
+    if (filters.nearby) {
+      const cells = await Locations.discoveryCells(viewerId);
+      if (!cells) {
+        return [];
+      }
+      where.OR = cells.map(cell => ({ geohash: { startsWith: cell } }));
+    }
+
+    const events = await prisma.event.findMany({
+      where,
+      include: { _count: { select: { rsvps: true } } },
+      orderBy: { startsAt: 'asc' },
+      take: LIST_LIMIT,
+    });
+    return withViewer(events, viewerId);
+  }
+
+  /**
+   * One event as the viewer sees it; canceled events are still shown so
+   * people who RSVP'd can see what happened
+   */
+  static async get(id: string, viewerId: string) {
+    const event = await prisma.event.findUnique({
+      where: { id },
+      include: { _count: { select: { rsvps: true } } },
+    });
+    if (!event) {
+      return null;
+    }
+    const [summary] = await withViewer([event], viewerId);
+    return summary;
+  }
+
+  /**
+   * Every event, newest first, for admins
+   */
+  static async listAll(status?: EventStatus) {
+    const events = await prisma.event.findMany({
+      where: status ? { status } : undefined,
+      include: { _count: { select: { rsvps: true } } },
+      orderBy: { startsAt: 'desc' },
+      take: 200,
+    });
+    return events.map(event => toEventSummary(event, false));
+  }
+
+  static async create(
+    input: EventInput,
+    adminId: string
+  ): Promise<EventResult> {
+    if (!validTimes(input.startsAt, input.endsAt)) {
+      return { status: 'invalid_time' };
+    }
+    if (!(await validPlaces(input.cityId, input.campusId))) {
+      return { status: 'invalid_place' };
+    }
+
+    const event = await prisma.event.create({
+      data: {
+        ...input,
+        geohash: encodeGeohash(input.lat, input.lng, VENUE_PRECISION),
+        createdBy: adminId,
+      },
+    });
+    await AuditLog.record({
+      action: 'admin.event_created',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'event',
+      targetId: event.id,
+      details: { title: event.title, startsAt: event.startsAt },
+    });
+    return { status: 'saved', event };
+  }
+
+  /**
+   * Edit an event. Lowering the capacity below the RSVPs already taken
+   * keeps them; it just stops new ones.
+   */
+  static async update(
+    id: string,
+    update: EventUpdate,
+    adminId: string
+  ): Promise<EventResult> {
+    const existing = await prisma.event.findUnique({ where: { id } });
+    if (!existing) {
+      return { status: 'not_found' };
+    }
+    if (
+      !validTimes(
+        update.startsAt ?? existing.startsAt,
+        update.endsAt ?? existing.endsAt
+      )
+    ) {
+      return { status: 'invalid_time' };
+    }
+    if (
+      (update.cityId !== undefined || update.campusId !== undefined) &&
+      !(await validPlaces(
+        update.cityId ?? existing.cityId,
+        update.campusId !== undefined ? update.campusId : existing.campusId
+      ))
+    ) {
+      return { status: 'invalid_place' };
+    }
+
+    const lat = update.lat ?? existing.lat;
+    const lng = update.lng ?? existing.lng;
+    const event = await prisma.event.update({
+      where: { id },
+      data: { ...update, geohash: encodeGeohash(lat, lng, VENUE_PRECISION) },
+    });
+    await AuditLog.record({
+      action: 'admin.event_updated',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'event',
+      targetId: id,
+      details: { changes: Object.keys(update) },
+    });
+    return { status: 'saved', event };
+  }
+
+  /**
+   * Call an event off and let everyone who RSVP'd know
+   */
+  static async cancel(id: string, adminId: string): Promise<EventResult> {
+    const existing = await prisma.event.findUnique({ where: { id } });
+    if (!existing) {
+      return { status: 'not_found' };
+    }
+    if (existing.status === 'canceled') {
+      return { status: 'saved', event: existing };
+    }
+
+    const event = await prisma.event.update({
+      where: { id },
+      data: { status: 'canceled' },
+    });
+    await AuditLog.record({
+      action: 'admin.event_canceled',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'event',
+      targetId: id,
+    });
+
+    const rsvps = await prisma.eventRsvp.findMany({
+      where: { eventId: id },
+      select: { userId: true },
+    });
+    for (const rsvp of rsvps) {
+      await Notifications.notify(rsvp.userId, {
+        type: 'event_canceled',
+        variables: { title: event.title },
+        path: `/events/${id}`,
+        data: { eventId: id },
+        push: true,
+      });
+    }
+    return { status: 'saved', event };
+  }
+
+  /**
+   * RSVP to an event that hasn't ended, if there's room. RSVPing again is
+   * a no-op.
+   */
+  static async rsvp(eventId: string, userId: string): Promise<RsvpResult> {
+    return prisma.$transaction(async (tx): Promise<RsvpResult> => {
+      const event = await tx.event.findUnique({ where: { id: eventId } });
+      if (!event || event.status !== 'published') {
+        return { status: 'not_found' };
+      }
+      if (event.endsAt <= new Date()) {
+        return { status: 'ended' };
+      }
+      const existing = await tx.eventRsvp.findUnique({
+        where: { eventId_userId: { eventId, userId } },
+      });
+      if (existing) {
+        return { status: 'going' };
+      }
+      if (event.capacity !== null) {
+        const taken = await tx.eventRsvp.count({ where: { eventId } });
+        if (taken >= event.capacity) {
+          return { status: 'full' };
+        }
+      }
+      await tx.eventRsvp.create({ data: { eventId, userId } });
+      return { status: 'going' };
+    });
+  }
+
+  /**
+   * Give up an RSVP (and the spot) before the event ends
+   */
+  static async cancelRsvp(eventId: string, userId: string): Promise<boolean> {
+    const removed = await prisma.eventRsvp.deleteMany({
+      where: {
+        eventId,
+        userId,
+        attendedAt: null,
+        event: { endsAt: { gt: new Date() } },
+      },
+    });
+    return removed.count > 0;
+  }
+
+  /**
+   * Check in at the venue while the event is on. The user's stored
+   * location has to be around the venue.
+   */
+  static async checkIn(
+    eventId: string,
+    userId: string
+  ): Promise<EventCheckInResult> {
+    const rsvp = await prisma.eventRsvp.findUnique({
+      where: { eventId_userId: { eventId, userId } },
+      include: { event: true },
+    });
+    if (!rsvp) {
+      const event = await prisma.event.findUnique({ where: { id: eventId } });
+      return { status: event ? 'not_going' : 'not_found' };
+    }
+    if (!isLive(rsvp.event)) {
+      return { status: 'not_live' };
+    }
+    if (rsvp.attendedAt) {
+      return { status: 'attended' };
+    }
+    const cells = await Locations.discoveryCells(userId);
+    if (!cells?.some(cell => rsvp.event.geohash.startsWith(cell))) {
+      return { status: 'not_nearby' };
+    }
+
+    await prisma.eventRsvp.update({
+      where: { id: rsvp.id },
+      data: { attendedAt: new Date() },
+    });
+    await EventBus.publish('event.attended', { eventId, userId });
+    return { status: 'attended' };
+  }
+
+  /**
+   * The other attendees for the viewer's event deck, or null unless the
+   * event is on now and the viewer RSVP'd
+   */
+  static async attendeeIds(
+    eventId: string,
+    viewerId: string
+  ): Promise<string[] | null> {
+    const rsvp = await prisma.eventRsvp.findUnique({
+      where: { eventId_userId: { eventId, userId: viewerId } },
+      include: { event: true },
+    });
+    if (!rsvp || !isLive(rsvp.event)) {
+      return null;
+    }
+    const rsvps = await prisma.eventRsvp.findMany({
+      where: { eventId, userId: { not: viewerId } },
+      select: { userId: true },
+      take: MAX_DECK_ATTENDEES,
+    });
+    return rsvps.map(other => other.userId);
+  }
+}
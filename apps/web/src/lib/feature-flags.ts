@@ -0,0 +1,192 @@
+/**
+ * Feature Flags
+ * Runtime-configurable flags with percentage rollouts and weighted variants.
+ * Definitions live in code; overrides are stored in Redis so operators can
+ * flip or re-weight a flag without a deploy.
+ */
+
+import { createHash } from 'crypto';
+import redis from './redis';
+import { singleflight } from './singleflight';
+
+export interface FlagVariant {
+  value: string;
+  weight: number; // Relative weight; variants don't need to sum to 100
+}
+
+export interface FlagDefinition {
+  key: string;
+  description: string;
+  enabled: boolean;
+  // Share of users (0-100) that see the flag as enabled
+  rolloutPercent?: number;
+  // Weighted variants for A/B tests; first variant is the control
+  variants?: FlagVariant[];
+}
+
+export interface FlagContext {
+  userId?: string;
+}
+
+// In-process cache of Redis overrides to keep flag checks off the hot path
+const OVERRIDE_CACHE_TTL_MS = 30 * 1000;
+const overrideCache = new Map<
+  string,
+  { value: Partial<FlagDefinition> | null; expiresAt: number }
+>();
+
+/**
+ * Flag registry. Add new flags here with safe defaults.
+ */
+export const FLAG_DEFINITIONS: Record<string, FlagDefinition> = {
+  ml_model_version: {
+    key: 'ml_model_version',
+    description: 'ML API model version used for scoring requests',
+    enabled: true,
+    variants: [
+      { value: process.env.ML_DEFAULT_MODEL_VERSION || 'v2', weight: 100 },
+    ],
+  },
+  soft_launch_gate: {
+    key: 'soft_launch_gate',
+    description: 'Hold new accounts on the waitlist until admitted',
+    enabled: process.env.SOFT_LAUNCH_GATE === 'true',
+  },
+};
+
+export class FeatureFlags {
+  /**
+   * Whether a flag is enabled for the given context
+   */
+  static async isEnabled(
+    key: string,
+    context: FlagContext = {}
+  ): Promise<boolean> {
+    const flag = await this.resolve(key);
+    if (!flag || !flag.enabled) {
+      return false;
+    }
+
+    if (flag.rolloutPercent === undefined || flag.rolloutPercent >= 100) {
+      return true;
+    }
+
+    // Without a user to bucket, only fully rolled out flags are on
+    if (!context.userId) {
+      return false;
+    }
+
+    return this.bucket(key, context.userId) < flag.rolloutPercent / 100;
+  }
+
+  /**
+   * Variant assigned to the given context, or null if the flag is off
+   */
+  static async getVariant(
+    key: string,
+    context: FlagContext = {}
+  ): Promise<string | null> {
+    const flag = await this.resolve(key);
+    if (!flag || !flag.enabled || !flag.variants?.length) {
+      return null;
+    }
+
+    const totalWeight = flag.variants.reduce((sum, v) => sum + v.weight, 0);
+    if (totalWeight <= 0) {
+      return flag.variants[0].value;
+    }
+
+    // Anonymous requests always get the control variant
+    if (!context.userId) {
+      return flag.variants[0].value;
+    }
+
+    const point = this.bucket(`${key}:variant`, context.userId) * totalWeight;
+    let cumulative = 0;
+    for (const variant of flag.variants) {
+      cumulative += variant.weight;
+      if (point < cumulative) {
+        return variant.value;
+      }
+    }
+
+    return flag.variants[flag.variants.length - 1].value;
+  }
+
+  /**
+   * Current effective definition of every flag (defaults merged with overrides)
+   */
+  static async list(): Promise<FlagDefinition[]> {
+    const flags = await Promise.all(
+      Object.keys(FLAG_DEFINITIONS).map(key => this.resolve(key))
+    );
+    return flags.filter((flag): flag is FlagDefinition => flag !== null);
+  }
+
+  /**
+   * Store an override for a flag in Redis
+   */
+  static async setOverride(
+    key: string,
+    override: Partial<Omit<FlagDefinition, 'key' | 'description'>>
+  ): Promise<void> {
+    if (!FLAG_DEFINITIONS[key]) {
+      throw new Error(`Unknown feature flag: ${key}`);
+    }
+    await redis.set(`feature_flag:${key}`, JSON.stringify(override));
+    overrideCache.delete(key);
+  }
+
+  /**
+   * Remove a flag override, reverting to the code default
+   */
+  static async clearOverride(key: string): Promise<void> {
+    await redis.del(`feature_flag:${key}`);
+    overrideCache.delete(key);
+  }
+
+  private static async resolve(key: string): Promise<FlagDefinition | null> {
+    const definition = FLAG_DEFINITIONS[key];
+    if (!definition) {
+      return null;
+    }
+
+    const override = await this.loadOverride(key);
+    return override ? { ...definition, ...override, key } : definition;
+  }
+
+  private static async loadOverride(
+    key: string
+  ): Promise<Partial<FlagDefinition> | null> {
+    const cached = overrideCache.get(key);
+    if (cached && cached.expiresAt > Date.now()) {
+      return cached.value;
+    }
+
+    // Every request checks flags, so they all miss together on expiry
+    return singleflight('feature-flags', key, async () => {
+      let value: Partial<FlagDefinition> | null = null;
+      try {
+        const data = await redis.get(`feature_flag:${key}`);
+        value = data ? JSON.parse(data) : null;
+      } catch (error) {
+        // Fall back to code defaults if Redis is unavailable
+        console.error(`Error loading feature flag override for ${key}:`, error);
+      }
+
+      overrideCache.set(key, {
+        value,
+        expiresAt: Date.now() + OVERRIDE_CACHE_TTL_MS,
+      });
+      return value;
+    });
+  }
+
+  /**
+   * Deterministic bucket in [0, 1) so a user keeps the same assignment
+   */
+  private static bucket(salt: string, userId: string): number {
+    const digest = createHash('sha256').update(`${salt}:${userId}`).digest();
+    return digest.readUInt32BE(0) / 0x100000000;
+  }
+}
@@ -0,0 +1,162 @@
+/**
+ * @jest-environment node
+ * @description Unit tests for PII field encryption
+ */
+
+import { describe, it, expect, jest, beforeEach } from '@jest/globals';
+import { randomBytes } from 'crypto';
+
+// The extension is tested as the plain object handed to Prisma
+jest.mock('@prisma/client', () => ({
+  Prisma: { defineExtension: (extension: unknown) => extension },
+}));
+
+import {
+  ENCRYPTED_FIELDS,
+  blindIndex,
+  fieldEncryption,
+} from '@/lib/field-encryption';
+
+type Compute = (row: Record<string, unknown>) => unknown;
+
+const extension = fieldEncryption as unknown as {
+  query: {
+    $allModels: {
+      $allOperations(params: {
+        model: string;
+        operation: string;
+        args: Record<string, unknown>;
+        query: (args: unknown) => Promise<unknown>;
+      }): Promise<unknown>;
+    };
+  };
+  result: Record<string, Record<string, { compute: Compute }>>;
+};
+
+const key = () => randomBytes(32).toString('base64');
+
+const resultKey = (model: string) =>
+  model.charAt(0).toLowerCase() + model.slice(1);
+
+const plainValue = (field: string) =>
+  field === 'birthDate' ? '1990-04-02' : 'meet me at the pier';
+
+// The data as it would be written, after the extension has sealed it
+async function write(model: string, data: Record<string, unknown>) {
+  const args = (await extension.query.$allModels.$allOperations({
+    model,
+    operation: 'create',
+    args: { data },
+    query: async args => args,
+  })) as { data: Record<string, unknown> };
+  return args.data;
+}
+
+function read(model: string, field: string, stored: unknown): unknown {
+  return extension.result[resultKey(model)][field].compute({
+    [field]: stored,
+  });
+}
+
+const fields = Object.entries(ENCRYPTED_FIELDS).flatMap(([model, names]) =>
+  names.map(field => [model, field] as const)
+);
+
+describe('fieldEncryption', () => {
+  beforeEach(() => {
+    process.env.PII_MASTER_KEYS = `k2:${key()}`;
+    process.env.PII_INDEX_KEY = key();
+  });
+
+  it.each(fields)(
+    'seals %s.%s on write and opens it on read',
+    async (model, field) => {
+      const plain = plainValue(field);
+      const stored = (await write(model, { [field]: plain }))[field];
+
+      expect(typeof stored).toBe('string');
+      expect(stored).not.toContain(plain);
+      expect((stored as string).startsWith('pii1.k2.')).toBe(true);
+
+      const opened = read(model, field, stored);
+      expect(
+        opened instanceof Date ? opened.toISOString().slice(0, 10) : opened
+      ).toBe(plain);
+    }
+  );
+
+  it('opens values sealed under an older master key', async () => {
+    const oldKey = `k1:${key()}`;
+    process.env.PII_MASTER_KEYS = oldKey;
+    const { body } = await write('DeletedMessage', { body: 'hello' });
+
+    process.env.PII_MASTER_KEYS = `k2:${key()},${oldKey}`;
+    expect(read('DeletedMessage', 'body', body)).toBe('hello');
+  });
+
+  it('seals text that merely looks sealed', async () => {
+    const typed = 'pii1.k2.not-a-key.not-a-payload';
+    const { message } = await write('MessageRequest', { message: typed });
+
+    expect(message).not.toBe(typed);
+    expect(read('MessageRequest', 'message', message)).toBe(typed);
+  });
+
+  it('reads plaintext that looks sealed as it is', () => {
+    const stored = 'pii1.k2.not-a-key.not-a-payload';
+    expect(read('ScheduledMessage', 'body', stored)).toBe(stored);
+  });
+
+  it('does not reseal a value that is already sealed', async () => {
+    const { body } = await write('ScheduledMessage', { body: 'see you' });
+    const again = await write('ScheduledMessage', { body });
+
+    expect(again.body).toBe(body);
+  });
+
+  it('refuses a sealed value moved to another field', async () => {
+    const { body } = await write('DeletedMessage', { body: 'private' });
+
+    // Not authenticated for ScheduledMessage.body, so sealed afresh
+    const moved = await write('ScheduledMessage', { body });
+    expect(read('ScheduledMessage', 'body', moved.body)).toBe(body);
+  });
+
+  it('writes the blind index of a looked-up field', async () => {
+    const data = await write('User', { email: 'ploy@example.com' });
+
+    expect(data.emailHash).toBe(
+      blindIndex('User', 'email', 'ploy@example.com')
+    );
+    // Indexed by its plain value, even when written already sealed
+    const again = await write('User', { email: data.email });
+    expect(again.emailHash).toBe(data.emailHash);
+  });
+
+  it('keys blind indexes with PII_INDEX_KEY', () => {
+    const index = blindIndex('User', 'email', 'ploy@example.com');
+
+    process.env.PII_INDEX_KEY = key();
+    expect(blindIndex('User', 'email', 'ploy@example.com')).not.toBe(index);
+  });
+
+  it('clears the blind index with its field', async () => {
+    expect(await write('User', { email: null })).toEqual({
+      email: null,
+      emailHash: null,
+    });
+  });
+
+  it('stores values as given without master keys', async () => {
+    delete process.env.PII_MASTER_KEYS;
+    jest.spyOn(console, 'warn').mockImplementation(() => {});
+
+    const { transcript } = await write('ConversationExport', {
+      transcript: 'a: hi',
+    });
+    expect(transcript).toBe('a: hi');
+    expect(read('ConversationExport', 'transcript', transcript)).toBe(
+      'a: hi'
+    );
+  });
+});
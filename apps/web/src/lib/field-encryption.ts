@@ -0,0 +1,508 @@
+/**
+ * Field Encryption
+ * Encrypts sensitive fields at rest: date of birth, a safety check-in's
+ * place and trusted contact, conversation transcripts waiting to be
+ * downloaded, moderators' copies of unsent messages, first messages held as
+ * requests, messages scheduled to go out later, data exports from privacy
+ * requests, and account emails (with the address waiting to be confirmed
+ * for one). Each value is sealed
+ * (AES-256-GCM) under its own random data key, and the data key is wrapped
+ * by a master key from PII_MASTER_KEYS, so rotating the master key only
+ * means rewrapping data keys, never touching the values. The Prisma client
+ * seals these fields on writes and opens them on reads, so code using it
+ * sees plain values. Filters on an encrypted field can only test for null;
+ * a field that's looked up by value also gets a blind index, an HMAC under
+ * PII_INDEX_KEY written alongside it, to filter on with `blindIndex`
+ * (the account email's is unique). Contact exchange details already have
+ * their own key (see lib/contact-exchange). Without master keys, values
+ * are stored as given.
+ */
+
+import {
+  createCipheriv,
+  createDecipheriv,
+  createHmac,
+  randomBytes,
+} from 'crypto';
+import { Prisma } from '@prisma/client';
+import type { Database } from './prisma';
+
+// Encrypted fields by model (also the table Prisma keeps them in)
+export const ENCRYPTED_FIELDS = {
+  User: ['birthDate', 'email'],
+  SafetyCheckIn: ['place', 'contactName', 'contactEmail'],
+  ConversationExport: ['transcript'],
+  DeletedMessage: ['body'],
+  MessageRequest: ['message'],
+  ScheduledMessage: ['body'],
+  PrivacyRequest: ['exportData'],
+  EmailVerification: ['email'],
+} as const;
+
+type EncryptedModel = keyof typeof ENCRYPTED_FIELDS;
+
+// The column holding each looked-up field's blind index
+export const BLIND_INDEXES: Partial<
+  Record<EncryptedModel, Record<string, string>>
+> = {
+  User: { email: 'emailHash' },
+};
+
+const PREFIX = 'pii1';
+
+const CIPHER = 'aes-256-gcm';
+const IV_BYTES = 12;
+const TAG_BYTES = 16;
+
+const WRITE_OPERATIONS = new Set([
+  'create',
+  'createMany',
+  'createManyAndReturn',
+  'update',
+  'updateMany',
+  'updateManyAndReturn',
+  'upsert',
+]);
+
+const REWRAP_BATCH_SIZE = 500;
+
+interface MasterKey {
+  id: string;
+  key: Buffer;
+}
+
+export interface RewrapResult {
+  // Plaintext values encrypted for the first time
+  encrypted: number;
+  // Values whose data key was wrapped with an older master key
+  rewrapped: number;
+  // Values already under the current master key
+  unchanged: number;
+  // Blind indexes missing or computed under another PII_INDEX_KEY
+  indexed: number;
+}
+
+let warnedPlaintext = false;
+let warnedIndexKey = false;
+
+/**
+ * PII_MASTER_KEYS: comma-separated `id:base64` pairs of 32-byte keys. The
+ * first wraps new data keys; the rest only unwrap, until a rewrap has
+ * moved everything off them.
+ */
+function masterKeys(): MasterKey[] {
+  return (process.env.PII_MASTER_KEYS || '')
+    .split(',')
+    .map(entry => entry.trim())
+    .filter(Boolean)
+    .map(entry => {
+      const separator = entry.indexOf(':');
+      const id = entry.slice(0, separator);
+      const key = Buffer.from(entry.slice(separator + 1), 'base64');
+      if (separator <= 0 || key.length !== 32) {
+        throw new Error('PII_MASTER_KEYS must be id:base64 32-byte keys');
+      }
+      return { id, key };
+    });
+}
+
+function masterKey(id: string): Buffer | null {
+  return masterKeys().find(key => key.id === id)?.key ?? null;
+}
+
+/**
+ * PII_INDEX_KEY: a base64 32-byte key. Unlike the master keys it can't be
+ * rotated in place, as every blind index has to be computed again.
+ */
+function indexKey(): Buffer {
+  const encoded = process.env.PII_INDEX_KEY;
+  if (!encoded) {
+    if (!warnedIndexKey) {
+      console.warn('PII_INDEX_KEY is not set; blind indexes are unkeyed');
+      warnedIndexKey = true;
+    }
+    return Buffer.alloc(0);
+  }
+  const key = Buffer.from(encoded, 'base64');
+  if (key.length !== 32) {
+    throw new Error('PII_INDEX_KEY must be a base64 32-byte key');
+  }
+  return key;
+}
+
+/**
+ * The blind index of a plain value of `model.field`, to look it up by
+ */
+export function blindIndex(
+  model: EncryptedModel,
+  field: string,
+  value: string
+): string {
+  return createHmac('sha256', indexKey())
+    .update(`${model}.${field}:${value}`)
+    .digest('base64url');
+}
+
+function encrypt(plaintext: Buffer, key: Buffer, aad: string): Buffer {
+  const iv = randomBytes(IV_BYTES);
+  const cipher = createCipheriv(CIPHER, key, iv);
+  cipher.setAAD(Buffer.from(aad, 'utf8'));
+  const ciphertext = Buffer.concat([cipher.update(plaintext), cipher.final()]);
+  return Buffer.concat([iv, cipher.getAuthTag(), ciphertext]);
+}
+
+function decrypt(sealed: Buffer, key: Buffer, aad: string): Buffer {
+  const decipher = createDecipheriv(CIPHER, key, sealed.subarray(0, IV_BYTES));
+  decipher.setAAD(Buffer.from(aad, 'utf8'));
+  decipher.setAuthTag(sealed.subarray(IV_BYTES, IV_BYTES + TAG_BYTES));
+  return Buffer.concat([
+    decipher.update(sealed.subarray(IV_BYTES + TAG_BYTES)),
+    decipher.final(),
+  ]);
+}
+
+/**
+ * The parts of a sealed value (master key id, wrapped data key, payload),
+ * or null if it isn't shaped like one
+ */
+function parse(value: string): [string, Buffer, Buffer] | null {
+  const [prefix, keyId, wrapped, payload, ...rest] = value.split('.');
+  if (prefix !== PREFIX || !keyId || !wrapped || !payload || rest.length) {
+    return null;
+  }
+  return [
+    keyId,
+    Buffer.from(wrapped, 'base64url'),
+    Buffer.from(payload, 'base64url'),
+  ];
+}
+
+/**
+ * The plain value of a value sealed for `model.field`, or null if it isn't
+ * one. Anyone can type our prefix, so only a value that authenticates
+ * under one of our master keys counts as sealed.
+ */
+function unseal(model: string, field: string, value: string): string | null {
+  const parts = parse(value);
+  const master = parts && masterKey(parts[0]);
+  if (!parts || !master) {
+    return null;
+  }
+  const [keyId, wrapped, payload] = parts;
+  try {
+    const dataKey = decrypt(wrapped, master, keyId);
+    return decrypt(payload, dataKey, `${model}.${field}`).toString('utf8');
+  } catch {
+    return null;
+  }
+}
+
+function wrap(dataKey: Buffer, master: MasterKey): string {
+  return encrypt(dataKey, master.key, master.id).toString('base64url');
+}
+
+/**
+ * Seal a value of `model.field` (the field name is authenticated, so a
+ * value can't be moved to another field)
+ */
+function seal(model: string, field: string, value: string): string {
+  const [current] = masterKeys();
+  if (!current) {
+    if (!warnedPlaintext) {
+      console.warn('PII_MASTER_KEYS is not set; storing PII unencrypted');
+      warnedPlaintext = true;
+    }
+    return value;
+  }
+  if (unseal(model, field, value) !== null) {
+    return value;
+  }
+  const dataKey = randomBytes(32);
+  const payload = encrypt(
+    Buffer.from(value, 'utf8'),
+    dataKey,
+    `${model}.${field}`
+  );
+  return [
+    PREFIX,
+    current.id,
+    wrap(dataKey, current),
+    payload.toString('base64url'),
+  ].join('.');
+}
+
+/**
+ * The plain value of `model.field`; values stored before encryption was
+ * turned on, or that only look sealed, are returned as they are
+ */
+function open(model: string, field: string, value: string): string {
+  return unseal(model, field, value) ?? value;
+}
+
+/**
+ * A sealed value with its data key wrapped by `master` instead
+ */
+function rewrapValue(value: string, master: MasterKey): string {
+  const [keyId, wrapped, payload] = parse(value)!;
+  const dataKey = decrypt(wrapped, masterKey(keyId)!, keyId);
+  return [
+    PREFIX,
+    master.id,
+    wrap(dataKey, master),
+    payload.toString('base64url'),
+  ].join('.');
+}
+
+function sealData(model: EncryptedModel, data: unknown): unknown {
+  if (Array.isArray(data)) {
+    return data.map(item => sealData(model, item));
+  }
+  if (!data || typeof data !== 'object') {
+    return data;
+  }
+  const sealed: Record<string, unknown> = { ...data };
+  for (const field of ENCRYPTED_FIELDS[model]) {
+    if (!(field in sealed)) {
+      continue;
+    }
+    let value = sealed[field];
+    // Updates may wrap the value in { set }
+    if (value && typeof value === 'object' && 'set' in value) {
+      value = (value as { set: unknown }).set;
+    }
+    const indexColumn = BLIND_INDEXES[model]?.[field];
+    if (typeof value === 'string') {
+      const plain = open(model, field, value);
+      sealed[field] = seal(model, field, value);
+      if (indexColumn) {
+        sealed[indexColumn] = blindIndex(model, field, plain);
+      }
+    } else if (value === null && indexColumn) {
+      sealed[indexColumn] = null;
+    }
+  }
+  return sealed;
+}
+
+function sealArgs(model: EncryptedModel, args: Record<string, unknown>) {
+  const sealed = { ...args };
+  for (const key of ['data', 'create', 'update']) {
+    if (key in sealed) {
+      sealed[key] = sealData(model, sealed[key]);
+    }
+  }
+  return sealed;
+}
+
+function openNullable(
+  model: EncryptedModel,
+  field: string,
+  value: string | null
+): string | null {
+  return value === null ? null : open(model, field, value);
+}
+
+/**
+ * How dates are kept in encrypted fields
+ */
+export function toStoredDate(date: Date): string {
+  return date.toISOString().slice(0, 10);
+}
+
+/**
+ * Seals encrypted fields on their way into the database and opens them
+ * on the way out, nested includes too. Writes through a nested relation
+ * (e.g. creating a check-in from a user update) aren't sealed.
+ */
+export const fieldEncryption = Prisma.defineExtension({
+  name: 'field-encryption',
+  query: {
+    $allModels: {
+      async $allOperations({ model, operation, args, query }) {
+        if (model in ENCRYPTED_FIELDS && WRITE_OPERATIONS.has(operation)) {
+          return query(
+            sealArgs(
+              model as EncryptedModel,
+              args as Record<string, unknown>
+            ) as typeof args
+          );
+        }
+        return query(args);
+      },
+    },
+  },
+  result: {
+    user: {
+      birthDate: {
+        needs: { birthDate: true },
+        compute(user): Date | null {
+          const value = openNullable('User', 'birthDate', user.birthDate);
+          return value === null ? null : new Date(value);
+        },
+      },
+      email: {
+        needs: { email: true },
+        compute: user => openNullable('User', 'email', user.email),
+      },
+    },
+    safetyCheckIn: {
+      place: {
+        needs: { place: true },
+        compute: checkIn =>
+          openNullable('SafetyCheckIn', 'place', checkIn.place),
+      },
+      contactName: {
+        needs: { contactName: true },
+        compute: checkIn =>
+          open('SafetyCheckIn', 'contactName', checkIn.contactName),
+      },
+      contactEmail: {
+        needs: { contactEmail: true },
+        compute: checkIn =>
+          openNullable('SafetyCheckIn', 'contactEmail', checkIn.contactEmail),
+      },
+    },
+    conversationExport: {
+      transcript: {
+        needs: { transcript: true },
+        compute: exported =>
+          openNullable('ConversationExport', 'transcript', exported.transcript),
+      },
+    },
+    deletedMessage: {
+      body: {
+        needs: { body: true },
+        compute: deleted => open('DeletedMessage', 'body', deleted.body),
+      },
+    },
+    messageRequest: {
+      message: {
+        needs: { message: true },
+        compute: request =>
+          openNullable('MessageRequest', 'message', request.message),
+      },
+    },
+    scheduledMessage: {
+      body: {
+        needs: { body: true },
+        compute: scheduled =>
+          openNullable('ScheduledMessage', 'body', scheduled.body),
+      },
+    },
+    privacyRequest: {
+      exportData: {
+        needs: { exportData: true },
+        compute: request =>
+          openNullable('PrivacyRequest', 'exportData', request.exportData),
+      },
+    },
+    emailVerification: {
+      email: {
+        needs: { email: true },
+        compute: verification =>
+          open('EmailVerification', 'email', verification.email),
+      },
+    },
+  },
+});
+
+export class FieldEncryption {
+  /**
+   * Whether master keys are configured, so new values are encrypted
+   */
+  static enabled(): boolean {
+    return masterKeys().length > 0;
+  }
+
+  /**
+   * Bring every encrypted field under the current master key: wrap data
+   * keys wrapped by older master keys again, and encrypt values stored
+   * before encryption was turned on. Run after putting a new key first in
+   * PII_MASTER_KEYS; the old key can be dropped once this finishes. Reads
+   * and writes raw columns, decrypting only to check a value is really
+   * sealed, and a row changed meanwhile is left for the next run rather
+   * than overwritten. Blind indexes are (re)written on the way, so this
+   * also backfills a newly indexed field and moves to a new PII_INDEX_KEY.
+   * Takes the client as the client itself is built with this module.
+   */
+  static async rewrap(
+    db: Database,
+    batchSize = REWRAP_BATCH_SIZE
+  ): Promise<Record<EncryptedModel, RewrapResult>> {
+    const [current] = masterKeys();
+    if (!current) {
+      throw new Error('PII_MASTER_KEYS is not configured');
+    }
+
+    const results = {} as Record<EncryptedModel, RewrapResult>;
+    for (const model of Object.keys(ENCRYPTED_FIELDS) as EncryptedModel[]) {
+      const fields: readonly string[] = ENCRYPTED_FIELDS[model];
+      const indexes = BLIND_INDEXES[model] ?? {};
+      const result = { encrypted: 0, rewrapped: 0, unchanged: 0, indexed: 0 };
+      const columns = [...fields, ...Object.values(indexes)]
+        .map(column => `"${column}"`)
+        .join(', ');
+      let cursor = '';
+
+      for (;;) {
+        const rows = await db.$queryRawUnsafe<
+          Array<Record<string, string | null>>
+        >(
+          `SELECT "id", ${columns} FROM "${model}" ` +
+            `WHERE "id" > ? ORDER BY "id" LIMIT ?`,
+          cursor,
+          batchSize
+        );
+        if (rows.length === 0) {
+          break;
+        }
+
+        for (const row of rows) {
+          for (const field of fields) {
+            const value = row[field];
+            if (value === null || value === undefined) {
+              continue;
+            }
+            const indexColumn = indexes[field];
+            const index =
+              indexColumn &&
+              blindIndex(model, field, open(model, field, value));
+            if (index && row[indexColumn] !== index) {
+              await db.$executeRawUnsafe(
+                `UPDATE "${model}" SET "${indexColumn}" = ? ` +
+                  `WHERE "id" = ? AND "${field}" = ?`,
+                index,
+                row.id,
+                value
+              );
+              result.indexed++;
+            }
+            let next: string;
+            if (unseal(model, field, value) === null) {
+              next = seal(model, field, value);
+              result.encrypted++;
+            } else if (parse(value)![0] !== current.id) {
+              next = rewrapValue(value, current);
+              result.rewrapped++;
+            } else {
+              result.unchanged++;
+              continue;
+            }
+            await db.$executeRawUnsafe(
+              `UPDATE "${model}" SET "${field}" = ? ` +
+                `WHERE "id" = ? AND "${field}" = ?`,
+              next,
+              row.id,
+              value
+            );
+          }
+        }
+        cursor = rows[rows.length - 1].id as string;
+      }
+      results[model] = result;
+    }
+
+    console.log('🔐 Rewrapped encrypted fields:', results);
+    return results;
+  }
+}
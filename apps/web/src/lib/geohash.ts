@@ -0,0 +1,131 @@
+/**
+ * Geohash
+ * Encoding, decoding and neighbor lookup for geohashes, plus great-circle
+ * distance. A geohash's length is its precision: each character narrows
+ * the cell (5 chars ≈ 5 km, 6 ≈ 1.2 km, 7 ≈ 150 m).
+ */
+
+const BASE32 = '0123456789bcdefghjkmnpqrstuvwxyz';
+
+const EARTH_RADIUS_KM = 6371;
+
+export const GEOHASH_PATTERN = /^[0-9bcdefghjkmnpqrstuvwxyz]{1,12}$/;
+
+export interface GeohashCell {
+  lat: number;
+  lng: number;
+  // Half the cell's height and width, in degrees
+  latError: number;
+  lngError: number;
+}
+
+export function encodeGeohash(
+  lat: number,
+  lng: number,
+  precision: number
+): string {
+  let latRange = [-90, 90];
+  let lngRange = [-180, 180];
+  let hash = '';
+  let bits = 0;
+  let value = 0;
+  let evenBit = true;
+
+  while (hash.length < precision) {
+    const range = evenBit ? lngRange : latRange;
+    const coordinate = evenBit ? lng : lat;
+    const mid = (range[0] + range[1]) / 2;
+    value <<= 1;
+    if (coordinate >= mid) {
+      value |= 1;
+      range[0] = mid;
+    } else {
+      range[1] = mid;
+    }
+    if (evenBit) {
+      lngRange = range;
+    } else {
+      latRange = range;
+    }
+    evenBit = !evenBit;
+
+    if (++bits === 5) {
+      hash += BASE32[value];
+      bits = 0;
+      value = 0;
+    }
+  }
+  return hash;
+}
+
+/**
+ * The center and size of a geohash cell
+ */
+export function decodeGeohash(hash: string): GeohashCell {
+  const latRange = [-90, 90];
+  const lngRange = [-180, 180];
+  let evenBit = true;
+
+  for (const char of hash) {
+    const value = BASE32.indexOf(char);
+    if (value === -1) {
+      throw new Error(`Invalid geohash: ${hash}`);
+    }
+    for (let bit = 4; bit >= 0; bit--) {
+      const range = evenBit ? lngRange : latRange;
+      const mid = (range[0] + range[1]) / 2;
+      if ((value >> bit) & 1) {
+        range[0] = mid;
+      } else {
+        range[1] = mid;
+      }
+      evenBit = !evenBit;
+    }
+  }
+
+  return {
+    lat: (latRange[0] + latRange[1]) / 2,
+    lng: (lngRange[0] + lngRange[1]) / 2,
+    latError: (latRange[1] - latRange[0]) / 2,
+    lngError: (lngRange[1] - lngRange[0]) / 2,
+  };
+}
+
+/**
+ * The cell itself and the up to eight cells around it
+ */
+export function geohashNeighborhood(hash: string): string[] {
+  const cell = decodeGeohash(hash);
+  const cells = new Set<string>();
+  for (const dLat of [-1, 0, 1]) {
+    for (const dLng of [-1, 0, 1]) {
+      const lat = cell.lat + dLat * cell.latError * 2;
+      if (lat < -90 || lat > 90) {
+        continue;
+      }
+      // Wrap around the antimeridian
+      const lng =
+        ((cell.lng + dLng * cell.lngError * 2 + 540) % 360) - 180;
+      cells.add(encodeGeohash(lat, lng, hash.length));
+    }
+  }
+  return Array.from(cells);
+}
+
+/**
+ * Great-circle distance between two points, in kilometers
+ */
+export function distanceKm(
+  a: { lat: number; lng: number },
+  b: { lat: number; lng: number }
+): number {
+  const toRadians = (degrees: number) => (degrees * Math.PI) / 180;
+  const dLat = toRadians(b.lat - a.lat);
+  const dLng = toRadians(b.lng - a.lng);
+  const h =
+    Math.sin(dLat / 2) ** 2 +
+    Math.cos(toRadians(a.lat)) *
+      Math.cos(toRadians(b.lat)) *
+      Math.sin(dLng / 2) ** 2;
+  return 2 * EARTH_RADIUS_KM * Math.asin(Math.sqrt(h));
+}
@@ -0,0 +1,134 @@
+/**
+ * Harassment Detection
+ * Scores message text for harassment: threats, telling someone to hurt
+ * themselves, unsolicited sexual demands and personal insults. Rules
+ * combine the same way as scam detection's (noisy-OR). Recipients get a
+ * safety notice on flagged messages (see lib/conversation-safety), and
+ * high-confidence hits open a moderation case against the sender.
+ */
+
+import { Reports } from './reports';
+import { ConversationSafety } from './conversation-safety';
+import { counter } from './metrics';
+
+export const HARASSMENT_CATEGORIES = [
+  'threat',
+  'self_harm',
+  'sexual',
+  'insult',
+] as const;
+
+export type HarassmentCategory = (typeof HARASSMENT_CATEGORIES)[number];
+
+interface HarassmentRule {
+  category: HarassmentCategory;
+  weight: number;
+  regex: RegExp;
+}
+
+const RULES: HarassmentRule[] = [
+  {
+    category: 'threat',
+    weight: 0.7,
+    regex: /\b(i('| wi)ll|i'm gonna|i am going to|gonna)\s+(kill|hurt|beat|stab|find)\s+(you|u)\b/i,
+  },
+  {
+    category: 'threat',
+    weight: 0.5,
+    regex: /\b(i know where you (live|work|study)|watch your back)\b/i,
+  },
+  {
+    category: 'self_harm',
+    weight: 0.8,
+    regex: /\b(kill (yo)?urself|kys|go die)\b/i,
+  },
+  {
+    category: 'sexual',
+    weight: 0.5,
+    regex: /\b(send (me )?(nudes|naked pics)|show me your (body|boobs|tits))\b/i,
+  },
+  {
+    category: 'insult',
+    weight: 0.4,
+    regex: /\b(you('re| are)|ur|u r) (so )?(ugly|fat|worthless|pathetic|disgusting|a (bitch|slut|whore|loser))\b/i,
+  },
+  {
+    category: 'insult',
+    weight: 0.3,
+    regex: /\b(no one|nobody) (will ever )?(loves?|wants?) you\b/i,
+  },
+];
+
+// Recipients get a safety notice at or above this score
+export const HARASSMENT_WARN_THRESHOLD = parseFloat(
+  process.env.HARASSMENT_WARN_THRESHOLD || '0.5'
+);
+
+// A moderation case is opened at or above this score
+export const HARASSMENT_REPORT_THRESHOLD = parseFloat(
+  process.env.HARASSMENT_REPORT_THRESHOLD || '0.85'
+);
+
+const flaggedCounter = counter(
+  'aurum_harassment_flags_total',
+  'Messages flagged by harassment detection, by outcome'
+);
+
+export interface HarassmentAssessment {
+  score: number;
+  categories: HarassmentCategory[];
+  warn: boolean;
+}
+
+/**
+ * Score one message, 0-1
+ */
+export function assessHarassment(text: string): HarassmentAssessment {
+  const hits = RULES.filter(rule => rule.regex.test(text));
+  const score = 1 - hits.reduce((clean, rule) => clean * (1 - rule.weight), 1);
+  return {
+    score: Math.round(score * 100) / 100,
+    categories: Array.from(new Set(hits.map(rule => rule.category))),
+    warn: score >= HARASSMENT_WARN_THRESHOLD,
+  };
+}
+
+export class HarassmentDetection {
+  /**
+   * Screen a message on its way to the recipient: show them a safety
+   * notice, and open a case on a confident hit. The message is still
+   * delivered either way.
+   */
+  static async screen(message: {
+    senderId: string;
+    recipientId: string;
+    text: string;
+    signalId?: string;
+  }): Promise<HarassmentAssessment> {
+    const assessment = assessHarassment(message.text);
+    if (!assessment.warn) {
+      return assessment;
+    }
+
+    await ConversationSafety.notice({
+      kind: 'harassment',
+      senderId: message.senderId,
+      recipientId: message.recipientId,
+      signalId: message.signalId,
+    });
+
+    const confident = assessment.score >= HARASSMENT_REPORT_THRESHOLD;
+    flaggedCounter.inc({ outcome: confident ? 'reported' : 'warned' });
+    if (confident) {
+      await Reports.createSystemReport(message.senderId, 'harassment', {
+        source: 'harassment_detection',
+        score: assessment.score,
+        categories: assessment.categories,
+        recipientId: message.recipientId,
+        signalId: message.signalId ?? null,
+        sample: message.text.slice(0, 500),
+      });
+    }
+    return assessment;
+  }
+}
@@ -0,0 +1,303 @@
+/**
+ * HTTP Client
+ * Instrumented fetch for calls to upstream services (the ML API, chain
+ * RPC, World ID and the like). Every call is logged as one JSON line with
+ * its upstream, target, latency, status and retries, and timed into
+ * Prometheus, so a failing upstream can be diagnosed from the gateway's
+ * logs alone. Failed calls also log their request and response bodies,
+ * with proofs, signatures, tokens and other secrets redacted first.
+ * Network errors, timeouts, 429s and 5xx responses are retried when the
+ * caller allows it.
+ */
+
+import { counter, histogram } from './metrics';
+
+export const REDACTED = '[REDACTED]';
+
+// Body fields and query parameters never logged, matched case-insensitively
+// and ignoring - and _. UPSTREAM_LOG_REDACT_KEYS adds more.
+const DEFAULT_REDACT_KEYS = [
+  'proof',
+  'merkle_root',
+  'nullifier_hash',
+  'signature',
+  'signed_message',
+  'token',
+  'access_token',
+  'refresh_token',
+  'id_token',
+  'authorization',
+  'api_key',
+  'secret',
+  'client_secret',
+  'secret_id',
+  'client_token',
+  'password',
+  'private_key',
+  // Biometric, and too large to log anyway
+  'image',
+  'embedding',
+  // Message, transcript and email contents
+  'text',
+  'input',
+  'content',
+];
+
+const REDACT_KEYS = new Set(
+  [
+    ...DEFAULT_REDACT_KEYS,
+    ...(process.env.UPSTREAM_LOG_REDACT_KEYS || '').split(','),
+  ]
+    .map(normalizeKey)
+    .filter(Boolean)
+);
+
+// How much of a failed call's bodies is logged; 0 logs none
+const LOG_BODY_CHARS = parseInt(process.env.UPSTREAM_LOG_BODY_CHARS || '1000');
+
+const DEFAULT_TIMEOUT_MS = parseInt(
+  process.env.UPSTREAM_TIMEOUT_MS || '30000'
+);
+
+const upstreamDuration = histogram(
+  'aurum_upstream_request_duration_seconds',
+  'Outbound call latency by upstream, method and status'
+);
+
+const upstreamRetries = counter(
+  'aurum_upstream_retries_total',
+  'Retried outbound calls by upstream'
+);
+
+export interface UpstreamOptions {
+  // Attempts after the first. Only use for calls that are safe to repeat.
+  retries?: number;
+  timeoutMs?: number;
+  // false logs only the origin, for URLs with a key in the path (RPC
+  // providers)
+  logPath?: boolean;
+}
+
+export type UpstreamFetch = (
+  input: string | URL | Request,
+  init?: RequestInit
+) => Promise<Response>;
+
+function normalizeKey(key: string): string {
+  return key.trim().toLowerCase().replace(/[-_]/g, '');
+}
+
+/**
+ * A copy of a JSON value with secret fields replaced
+ */
+export function redact(value: unknown): unknown {
+  if (Array.isArray(value)) {
+    return value.map(redact);
+  }
+  if (value && typeof value === 'object') {
+    return Object.fromEntries(
+      Object.entries(value).map(([key, field]) => [
+        key,
+        REDACT_KEYS.has(normalizeKey(key)) ? REDACTED : redact(field),
+      ])
+    );
+  }
+  return value;
+}
+
+/**
+ * The URL to log: no credentials, secret query parameters replaced, and
+ * optionally no path
+ */
+export function redactUrl(url: string, logPath = true): string {
+  let parsed: URL;
+  try {
+    parsed = new URL(url);
+  } catch {
+    return REDACTED;
+  }
+  if (!logPath) {
+    return parsed.origin;
+  }
+  parsed.username = '';
+  parsed.password = '';
+  parsed.searchParams.forEach((_, key) => {
+    if (REDACT_KEYS.has(normalizeKey(key))) {
+      parsed.searchParams.set(key, REDACTED);
+    }
+  });
+  return parsed.toString();
+}
+
+/**
+ * A body as it can be logged: redacted if it's JSON, truncated, and
+ * nothing if it isn't text
+ */
+function loggableBody(body: unknown): unknown {
+  if (LOG_BODY_CHARS <= 0 || typeof body !== 'string' || !body) {
+    return undefined;
+  }
+  try {
+    const text = JSON.stringify(redact(JSON.parse(body)));
+    return text.length > LOG_BODY_CHARS
+      ? `${text.slice(0, LOG_BODY_CHARS)}…`
+      : text;
+  } catch {
+    // Not JSON, so there's no telling which parts are secret
+    return `<${body.length} chars>`;
+  }
+}
+
+/**
+ * The JSON-RPC method of a request body, if it's a JSON-RPC call
+ */
+function rpcMethod(body: unknown): string | undefined {
+  if (typeof body !== 'string' || !body.includes('jsonrpc')) {
+    return undefined;
+  }
+  try {
+    const parsed = JSON.parse(body);
+    const calls = Array.isArray(parsed) ? parsed : [parsed];
+    return calls.map(call => call?.method).join(',') || undefined;
+  } catch {
+    return undefined;
+  }
+}
+
+function shouldRetry(status: number): boolean {
+  return status === 429 || status >= 500;
+}
+
+function backoff(attempt: number): Promise<void> {
+  const delay = Math.min(1000 * Math.pow(2, attempt - 1), 5000);
+  return new Promise(resolve => setTimeout(resolve, delay));
+}
+
+/**
+ * Make an outbound call to `upstream` and log it. Resolves with the last
+ * response, including error statuses; rejects if no response came back.
+ */
+export async function upstreamFetch(
+  upstream: string,
+  input: string | URL | Request,
+  init: RequestInit = {},
+  options: UpstreamOptions = {}
+): Promise<Response> {
+  const url =
+    input instanceof Request
+      ? input.url
+      : input instanceof URL
+        ? input.href
+        : input;
+  const method = (
+    init.method ?? (input instanceof Request ? input.method : 'GET')
+  ).toUpperCase();
+  const retries = options.retries ?? 0;
+  const timeoutMs = options.timeoutMs ?? DEFAULT_TIMEOUT_MS;
+
+  const started = Date.now();
+  const stopTimer = upstreamDuration.startTimer({ upstream, method });
+  // What went wrong on each attempt that was retried
+  const failures: string[] = [];
+  let response: Response | null = null;
+  let lastError: unknown = null;
+  let timedOut = false;
+
+  for (let attempt = 1; attempt <= retries + 1; attempt++) {
+    const controller = new AbortController();
+    timedOut = false;
+    const timeoutId = setTimeout(() => {
+      timedOut = true;
+      controller.abort();
+    }, timeoutMs);
+    // Abort on the caller's signal as well as the timeout
+    init.signal?.addEventListener('abort', () => controller.abort(), {
+      once: true,
+    });
+
+    try {
+      response = await fetch(input, { ...init, signal: controller.signal });
+      lastError = null;
+    } catch (error) {
+      response = null;
+      lastError = error;
+    } finally {
+      clearTimeout(timeoutId);
+    }
+
+    const retryable = response
+      ? shouldRetry(response.status)
+      : !init.signal?.aborted;
+    if (!retryable || attempt > retries) {
+      break;
+    }
+    failures.push(
+      response
+        ? `HTTP ${response.status}`
+        : timedOut
+          ? 'timeout'
+          : String(lastError)
+    );
+    upstreamRetries.inc({ upstream });
+    await backoff(attempt);
+  }
+
+  const status = response?.status ?? 0;
+  stopTimer({ status: String(status) });
+
+  const failed = !response || !response.ok;
+  const entry: Record<string, unknown> = {
+    level: !response || status >= 500 ? 'error' : failed ? 'warn' : 'info',
+    msg: 'upstream_call',
+    upstream,
+    method,
+    target: redactUrl(url, options.logPath ?? true),
+    rpcMethod: rpcMethod(init.body),
+    status: response ? status : null,
+    latencyMs: Date.now() - started,
+    retries: failures.length,
+  };
+  if (failures.length > 0) {
+    entry.retriedAfter = failures;
+  }
+  if (failed) {
+    entry.error = response
+      ? response.statusText || `HTTP ${status}`
+      : timedOut
+        ? 'timeout'
+        : String(lastError);
+    entry.requestBody = loggableBody(init.body);
+    if (response) {
+      entry.responseBody = loggableBody(
+        await response
+          .clone()
+          .text()
+          .catch(() => undefined)
+      );
+    }
+  }
+
+  const line = JSON.stringify(entry);
+  if (entry.level === 'error') {
+    console.error(line);
+  } else if (entry.level === 'warn') {
+    console.warn(line);
+  } else {
+    console.log(line);
+  }
+
+  if (!response) {
+    throw lastError;
+  }
+  return response;
+}
+
+/**
+ * A fetch for one upstream, e.g. for a viem transport's fetchFn
+ */
+export function httpClient(
+  upstream: string,
+  options: UpstreamOptions = {}
+): UpstreamFetch {
+  return (input, init) => upstreamFetch(upstream, input, init, options);
+}
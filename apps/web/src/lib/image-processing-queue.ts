@@ -1,19 +1,15 @@
 /**
  * Image Processing Queue
- * Queue for handling image preprocessing tasks to prevent CPU blocking
+ * Queue for handling image preprocessing tasks to prevent CPU blocking.
+ * Jobs are processed by the image processing worker (workers/image-processing).
  */
 
-import { Queue, Worker, Job } from "bullmq";
-import Redis from "ioredis";
+import { Queue, Job } from "bullmq";
+import redis from "@/lib/redis";
 import { ProcessedFace } from "@/lib/face-embeddings";
+import { upstreamFetch } from "@/lib/http-client";
 
-// Initialize Redis connection
-const redisConnection = new Redis(
-  process.env.REDIS_URL || "redis://redis:6379",
-  {
-    maxRetriesPerRequest: null,
-  }
-);
+export const IMAGE_PROCESSING_QUEUE_NAME = "imageProcessing";
 
 // ML API configuration
 const ML_API_URL = process.env.ML_API_URL || "http://localhost:3001";
@@ -33,8 +29,8 @@ interface MLAPIResponse {
 }
 
 // Create the image processing queue
-export const imageProcessingQueue = new Queue("imageProcessing", {
-  connection: redisConnection,
+export const imageProcessingQueue = new Queue(IMAGE_PROCESSING_QUEUE_NAME, {
+  connection: redis,
   defaultJobOptions: {
     attempts: 3,
     backoff: {
@@ -52,13 +48,17 @@ async function processImageWithMLAPI(
 ): Promise<MLAPIResponse> {
   try {
     // Send image to ML API
-    const response = await fetch(`${ML_API_URL}/api/score`, {
-      method: "POST",
-      headers: {
-        "Content-Type": "application/json",
-      },
-      body: JSON.stringify({ image: imageBase64 }),
-    });
+    const response = await upstreamFetch(
+      "ml-api",
+      `${ML_API_URL}/api/score`,
+      {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify({ image: imageBase64 }),
+      }
+    );
 
     if (!response.ok) {
       throw new Error(`ML API request failed with status ${response.status}`);
@@ -72,7 +72,10 @@ async function processImageWithMLAPI(
     let retries = 0;
 
     while (retries < maxRetries) {
-      const resultResponse = await fetch(`${ML_API_URL}/api/result/${jobId}`);
+      const resultResponse = await upstreamFetch(
+        "ml-api",
+        `${ML_API_URL}/api/result/${jobId}`
+      );
       if (resultResponse.ok) {
         const resultData = await resultResponse.json();
         if (resultData.status === "completed") {
@@ -94,56 +97,36 @@ async function processImageWithMLAPI(
   }
 }
 
-// Create the worker that processes image preprocessing jobs
-export const imageProcessingWorker = new Worker(
-  "imageProcessing",
-  async (job: Job) => {
-    const { imageBase64, jobId } = job.data;
+// Process an image preprocessing job (run by the image processing worker)
+export async function processImageJob(job: Job) {
+  const { imageBase64, jobId } = job.data;
 
-    try {
-      // Process the image with ML API
-      const result = await processImageWithMLAPI(imageBase64);
-
-      if (!result) {
-        throw new Error("No face detected in image");
-      }
+  try {
+    // Process the image with ML API
+    const result = await processImageWithMLAPI(imageBase64);
 
-      // Convert result to expected format
-      const processedResult: ProcessedFace = {
-        embedding: Array.from(result.embedding || new Float32Array(512)),
-        quality: result.quality || 0.8,
-        frontality: result.frontality || 0.8,
-        symmetry: result.symmetry || 0.8,
-        resolution: result.resolution || 0.8,
-      };
-
-      return {
-        success: true,
-        jobId,
-        result: processedResult,
-      };
-    } catch (error) {
-      console.error("Image processing failed:", error);
-      throw error;
+    if (!result) {
+      throw new Error("No face detected in image");
     }
-  },
-  { connection: redisConnection }
-);
-
-// Event listeners for worker
-imageProcessingWorker.on("completed", (job: Job) => {
-  console.log(`Job ${job.id} completed successfully`);
-});
 
-imageProcessingWorker.on("failed", (job: Job | undefined, err: Error) => {
-  console.error(`Job ${job?.id} failed with error:`, err);
-});
-
-// Graceful shutdown
-process.on("SIGTERM", async () => {
-  await imageProcessingWorker.close();
-  await imageProcessingQueue.close();
-  await redisConnection.quit();
-});
+    // Convert result to expected format
+    const processedResult: ProcessedFace = {
+      embedding: Array.from(result.embedding || new Float32Array(512)),
+      quality: result.quality || 0.8,
+      frontality: result.frontality || 0.8,
+      symmetry: result.symmetry || 0.8,
+      resolution: result.resolution || 0.8,
+    };
+
+    return {
+      success: true,
+      jobId,
+      result: processedResult,
+    };
+  } catch (error) {
+    console.error("Image processing failed:", error);
+    throw error;
+  }
+}
 
 export default imageProcessingQueue;
@@ -0,0 +1,106 @@
+/**
+ * Image Sanitizer
+ * Re-encodes uploaded photos before they're stored or passed on. The EXIF
+ * orientation is applied to the pixels, so the photo shows upright without
+ * it, and all metadata is dropped: EXIF (GPS position, camera and device,
+ * time taken), XMP, IPTC and colour profiles (pixels are converted to
+ * sRGB). Photos straight from a camera roll otherwise tell anyone who
+ * downloads them where they were taken. Output is JPEG, at most
+ * MAX_DIMENSION on its longest side.
+ */
+
+import sharp from 'sharp';
+import { counter } from './metrics';
+
+const MAX_DIMENSION = 2048;
+
+// Decompression bombs are rejected before decoding
+const MAX_INPUT_PIXELS = 50_000_000;
+
+// Formats photos may come in; vector and animated formats aren't photos
+const ACCEPTED_FORMATS = new Set(['jpeg', 'png', 'webp', 'heif', 'avif']);
+
+const metadataStripped = counter(
+  'aurum_image_metadata_stripped_total',
+  'Uploaded images sanitized, by the kind of metadata removed'
+);
+
+export interface SanitizedImage {
+  body: Buffer;
+  contentType: 'image/jpeg';
+  width: number;
+  height: number;
+  // Metadata the upload carried ("exif", "xmp", "iptc", "icc"), and
+  // "orientation" when it had to be rotated upright
+  stripped: string[];
+}
+
+export class ImageSanitizer {
+  /**
+   * The photo upright, resized if need be, and without metadata; null if
+   * it isn't a readable photo
+   */
+  static async sanitize(input: Buffer): Promise<SanitizedImage | null> {
+    let metadata: sharp.Metadata;
+    try {
+      metadata = await sharp(input, {
+        limitInputPixels: MAX_INPUT_PIXELS,
+      }).metadata();
+    } catch {
+      return null;
+    }
+    if (!metadata.format || !ACCEPTED_FORMATS.has(metadata.format)) {
+      return null;
+    }
+
+    const stripped = [
+      metadata.exif && 'exif',
+      metadata.xmp && 'xmp',
+      metadata.iptc && 'iptc',
+      metadata.icc && 'icc',
+      (metadata.orientation ?? 1) > 1 && 'orientation',
+    ].filter((kind): kind is string => Boolean(kind));
+
+    let output: { data: Buffer; info: sharp.OutputInfo };
+    try {
+      // sharp only writes metadata when asked to, so none carries over
+      output = await sharp(input, {
+        limitInputPixels: MAX_INPUT_PIXELS,
+        failOn: 'error',
+      })
+        .rotate()
+        .resize(MAX_DIMENSION, MAX_DIMENSION, {
+          fit: 'inside',
+          withoutEnlargement: true,
+        })
+        .flatten({ background: '#ffffff' })
+        .toColourspace('srgb')
+        .jpeg({ quality: 88, mozjpeg: true })
+        .toBuffer({ resolveWithObject: true });
+    } catch {
+      return null;
+    }
+
+    for (const kind of stripped) {
+      metadataStripped.inc({ kind });
+    }
+    return {
+      body: output.data,
+      contentType: 'image/jpeg',
+      width: output.info.width,
+      height: output.info.height,
+      stripped,
+    };
+  }
+
+  /**
+   * `sanitize` for base64 images; an unreadable image is returned as it
+   * came, for the caller's own checks to turn down
+   */
+  static async sanitizeBase64(image: string): Promise<string> {
+    const sanitized = await ImageSanitizer.sanitize(
+      Buffer.from(image, 'base64')
+    );
+    return sanitized ? sanitized.body.toString('base64') : image;
+  }
+}
@@ -0,0 +1,118 @@
+/**
+ * @description Unit tests for admin impersonation sessions
+ */
+
+import { describe, it, expect, jest, beforeEach } from '@jest/globals';
+import {
+  ImpersonationClaims,
+  MAX_IMPERSONATION_MINUTES,
+  impersonationRestriction,
+  mintImpersonationToken,
+} from '@/lib/impersonation';
+
+const mockSign = jest.fn(
+  async (_claims: Record<string, unknown>, _expiresAt: number) => 'token'
+);
+const mockRecord = jest.fn(async (_entry: Record<string, unknown>) => {});
+
+jest.mock('@/lib/jwt-keys', () => ({
+  JwtKeys: {
+    sign: (claims: Record<string, unknown>, expiresAt: number) =>
+      mockSign(claims, expiresAt),
+  },
+}));
+
+jest.mock('@/lib/audit-log', () => ({
+  AuditLog: {
+    record: (entry: Record<string, unknown>) => mockRecord(entry),
+  },
+}));
+
+const claims = (scope: 'read' | 'write'): ImpersonationClaims => ({
+  id: 'imp-1',
+  adminId: 'admin-1',
+  scope,
+});
+
+const user = {
+  id: 'user-1',
+  worldId: 'world-1',
+  walletAddress: '0xabc',
+  nftVerified: true,
+  tenantId: null,
+};
+
+describe('impersonationRestriction', () => {
+  it('lets a read session read', () => {
+    expect(
+      impersonationRestriction(claims('read'), 'GET', '/api/users/me')
+    ).toBeNull();
+  });
+
+  it('keeps a read session from writing', () => {
+    expect(
+      impersonationRestriction(claims('read'), 'PUT', '/api/users/me/prompts')
+    ).toBe('Impersonation session is read-only');
+  });
+
+  it('lets a write session write', () => {
+    expect(
+      impersonationRestriction(claims('write'), 'PUT', '/api/users/me/prompts')
+    ).toBeNull();
+  });
+
+  it.each([
+    ['POST', '/api/signals/send'],
+    ['POST', '/api/discovery/action'],
+    ['POST', '/api/payments/worldapp/confirm'],
+    ['GET', '/api/admin/users'],
+  ])('never allows %s %s, whatever the scope', (method, pathname) => {
+    expect(
+      impersonationRestriction(claims('write'), method, pathname)
+    ).not.toBeNull();
+  });
+});
+
+describe('mintImpersonationToken', () => {
+  beforeEach(() => {
+    mockSign.mockClear();
+    mockRecord.mockClear();
+  });
+
+  it('caps the session length', async () => {
+    const before = Date.now();
+    const { expiresAt } = await mintImpersonationToken(user, 'admin-1', {
+      scope: 'read',
+      ttlMinutes: 24 * 60,
+      reason: 'support ticket',
+    });
+
+    expect(expiresAt.getTime() - before).toBeLessThanOrEqual(
+      MAX_IMPERSONATION_MINUTES * 60 * 1000 + 1000
+    );
+  });
+
+  it('marks the token and audits who started it', async () => {
+    const { impersonationId } = await mintImpersonationToken(
+      user,
+      'admin-1',
+      { scope: 'write', ttlMinutes: 15, reason: 'support ticket' }
+    );
+
+    expect(mockSign.mock.calls[0][0]).toMatchObject({
+      profileId: 'user-1',
+      impersonation: {
+        id: impersonationId,
+        adminId: 'admin-1',
+        scope: 'write',
+      },
+    });
+    expect(mockRecord).toHaveBeenCalledWith(
+      expect.objectContaining({
+        action: 'admin.impersonation_started',
+        actorId: 'admin-1',
+        targetId: 'user-1',
+      })
+    );
+  });
+});
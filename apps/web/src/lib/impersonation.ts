@@ -0,0 +1,99 @@
+/**
+ * Impersonation
+ * Time-limited support sessions that let an admin see the app as a user.
+ * Impersonated sessions are scope-restricted, can never message, signal or
+ * pay, and every request they make is logged and audited.
+ */
+
+import { randomUUID } from 'crypto';
+import { JwtKeys } from './jwt-keys';
+import { User } from '@prisma/client';
+import { AuditLog } from './audit-log';
+
+export const IMPERSONATION_SCOPES = ['read', 'write'] as const;
+
+export type ImpersonationScope = (typeof IMPERSONATION_SCOPES)[number];
+
+export interface ImpersonationClaims {
+  id: string;
+  adminId: string;
+  scope: ImpersonationScope;
+}
+
+export const MAX_IMPERSONATION_MINUTES = 60;
+
+// Never allowed while impersonating, whatever the scope
+const BLOCKED_PATH_PREFIXES = [
+  '/api/signals',
+  '/api/discovery/action',
+  '/api/payments',
+  '/api/boost',
+  '/api/reports',
+  '/api/admin',
+];
+
+/**
+ * Mint a session token for `user` on behalf of an admin
+ */
+export async function mintImpersonationToken(
+  user: Pick<
+    User,
+    'id' | 'worldId' | 'walletAddress' | 'nftVerified' | 'tenantId'
+  >,
+  adminId: string,
+  options: { scope: ImpersonationScope; ttlMinutes: number; reason: string }
+): Promise<{ token: string; impersonationId: string; expiresAt: Date }> {
+  const ttlMinutes = Math.min(options.ttlMinutes, MAX_IMPERSONATION_MINUTES);
+  const expiresAt = new Date(Date.now() + ttlMinutes * 60 * 1000);
+  const impersonation: ImpersonationClaims = {
+    id: randomUUID(),
+    adminId,
+    scope: options.scope,
+  };
+
+  const token = await JwtKeys.sign(
+    {
+      worldId: user.worldId,
+      profileId: user.id,
+      profileCompleted: true,
+      walletAddress: user.walletAddress,
+      nftVerified: user.nftVerified,
+      ...(user.tenantId && { tenantId: user.tenantId }),
+      impersonation,
+    },
+    Math.floor(expiresAt.getTime() / 1000)
+  );
+
+  await AuditLog.record({
+    action: 'admin.impersonation_started',
+    actorType: 'admin',
+    actorId: adminId,
+    targetType: 'user',
+    targetId: user.id,
+    details: {
+      impersonationId: impersonation.id,
+      scope: options.scope,
+      reason: options.reason,
+      expiresAt: expiresAt.toISOString(),
+    },
+  });
+
+  return { token, impersonationId: impersonation.id, expiresAt };
+}
+
+/**
+ * Why an impersonated session may not make this request, or null if it may
+ */
+export function impersonationRestriction(
+  impersonation: ImpersonationClaims,
+  method: string,
+  pathname: string
+): string | null {
+  if (BLOCKED_PATH_PREFIXES.some(prefix => pathname.startsWith(prefix))) {
+    return 'This action is disabled while impersonating';
+  }
+  if (impersonation.scope === 'read' && !['GET', 'HEAD'].includes(method)) {
+    return 'Impersonation session is read-only';
+  }
+  return null;
+}
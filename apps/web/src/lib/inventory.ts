@@ -0,0 +1,152 @@
+/**
+ * Inventory
+ * Per-user balances of purchased consumables (boosts, super-interest packs).
+ * Grants are keyed by source so a payment can never credit twice; spends
+ * are conditional decrements so concurrent requests can't overdraw.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+
+export const INVENTORY_ITEMS = ['boost', 'super_interest'] as const;
+
+export type InventoryItemType = (typeof INVENTORY_ITEMS)[number];
+
+export class Inventory {
+  /**
+   * Current balance of every item (zero for items never granted)
+   */
+  static async getBalances(
+    userId: string
+  ): Promise<Record<InventoryItemType, number>> {
+    const rows = await prisma.inventoryItem.findMany({ where: { userId } });
+
+    const balances = Object.fromEntries(
+      INVENTORY_ITEMS.map(item => [item, 0])
+    ) as Record<InventoryItemType, number>;
+    rows.forEach(row => {
+      if (row.item in balances) {
+        balances[row.item as InventoryItemType] = row.quantity;
+      }
+    });
+    return balances;
+  }
+
+  /**
+   * Credit items once per source. Returns false if the source was already
+   * granted.
+   */
+  static async grant(
+    userId: string,
+    item: InventoryItemType,
+    quantity: number,
+    source: string
+  ): Promise<boolean> {
+    try {
+      await prisma.$transaction(
+        Inventory.grantWrites(userId, item, quantity, source)
+      );
+      return true;
+    } catch (error) {
+      if (
+        error instanceof Prisma.PrismaClientKnownRequestError &&
+        error.code === 'P2002'
+      ) {
+        return false; // Duplicate grant for this source
+      }
+      throw error;
+    }
+  }
+
+  /**
+   * The writes behind `grant`, for callers that must commit them together
+   * with their own. They fail with P2002 if the source was already granted.
+   */
+  static grantWrites(
+    userId: string,
+    item: InventoryItemType,
+    quantity: number,
+    source: string
+  ): Prisma.PrismaPromise<unknown>[] {
+    return [
+      prisma.inventoryGrant.create({
+        data: { userId, item, quantity, source },
+      }),
+      prisma.inventoryItem.upsert({
+        where: { userId_item: { userId, item } },
+        create: { userId, item, quantity },
+        update: { quantity: { increment: quantity } },
+      }),
+    ];
+  }
+
+  /**
+   * Spend items if the balance allows. Returns false when it doesn't.
+   */
+  static async consume(
+    userId: string,
+    item: InventoryItemType,
+    quantity = 1
+  ): Promise<boolean> {
+    const result = await prisma.inventoryItem.updateMany({
+      where: { userId, item, quantity: { gte: quantity } },
+      data: { quantity: { decrement: quantity } },
+    });
+    return result.count === 1;
+  }
+
+  /**
+   * Claw back up to `quantity` items once per source (e.g. after a refund).
+   * Items already spent can't be recovered. Returns how many were taken.
+   */
+  static async revoke(
+    userId: string,
+    item: InventoryItemType,
+    quantity: number,
+    source: string
+  ): Promise<number> {
+    try {
+      return await prisma.$transaction(async tx => {
+        const current = await tx.inventoryItem.findUnique({
+          where: { userId_item: { userId, item } },
+        });
+        const taken = Math.min(current?.quantity ?? 0, quantity);
+
+        // Negative grant keeps the ledger complete and the revoke idempotent
+        await tx.inventoryGrant.create({
+          data: { userId, item, quantity: -taken, source },
+        });
+        if (taken > 0) {
+          await tx.inventoryItem.update({
+            where: { userId_item: { userId, item } },
+            data: { quantity: { decrement: taken } },
+          });
+        }
+        return taken;
+      });
+    } catch (error) {
+      if (
+        error instanceof Prisma.PrismaClientKnownRequestError &&
+        error.code === 'P2002'
+      ) {
+        return 0; // Already revoked for this source
+      }
+      throw error;
+    }
+  }
+
+  /**
+   * Return spent items (e.g. when the action they paid for fails)
+   */
+  static async refund(
+    userId: string,
+    item: InventoryItemType,
+    quantity = 1
+  ): Promise<void> {
+    await prisma.inventoryItem.upsert({
+      where: { userId_item: { userId, item } },
+      create: { userId, item, quantity },
+      update: { quantity: { increment: quantity } },
+    });
+  }
+}
@@ -0,0 +1,64 @@
+/**
+ * Invites
+ * Invite codes and claiming them. A claim and the inviter's reward (a
+ * fresh code to pass on) commit together, so a claimed invite is never
+ * left unrewarded.
+ */
+
+import { Invite } from '@prisma/client';
+import { customAlphabet } from 'nanoid';
+import { InviteStore, prismaUnitOfWork, UnitOfWork } from './store';
+
+const nanoid = customAlphabet('0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ', 6);
+
+export type InviteClaim =
+  | { status: 'claimed'; invite: Invite; reward: Invite }
+  | { status: 'not_found' }
+  | { status: 'already_claimed' }
+  | { status: 'own_invite' };
+
+/**
+ * Create an invite under a code no other invite uses
+ */
+export async function createInvite(
+  invites: InviteStore,
+  userId: string
+): Promise<Invite> {
+  let code: string;
+  do {
+    code = `AURUM-${nanoid()}`;
+  } while (await invites.codeExists(code));
+  return invites.create(userId, code);
+}
+
+export class Invites {
+  static async generate(
+    userId: string,
+    unitOfWork: UnitOfWork = prismaUnitOfWork
+  ): Promise<Invite> {
+    return unitOfWork.run(store => createInvite(store.invites, userId));
+  }
+
+  static async claim(
+    code: string,
+    claimingUserId: string,
+    unitOfWork: UnitOfWork = prismaUnitOfWork
+  ): Promise<InviteClaim> {
+    return unitOfWork.run<InviteClaim>(async store => {
+      const invite = await store.invites.findByCode(code.toUpperCase());
+      if (!invite) {
+        return { status: 'not_found' };
+      }
+      if (invite.userId === claimingUserId) {
+        return { status: 'own_invite' };
+      }
+      const claimed = await store.invites.claim(invite.id, claimingUserId);
+      if (!claimed) {
+        return { status: 'already_claimed' };
+      }
+
+      const reward = await createInvite(store.invites, invite.userId);
+      return { status: 'claimed', invite: claimed, reward };
+    });
+  }
+}
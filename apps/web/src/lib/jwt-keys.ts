@@ -0,0 +1,352 @@
+/**
+ * JWT Keys
+ * Signing keys for session tokens. Tokens are signed ES256 with the
+ * newest active key and carry its kid; any key the JWKS still lists
+ * verifies them. A scheduled task rotates keys: a new key is published
+ * (pending) at least one JWKS cache lifetime before it starts signing, so
+ * anything verifying from a cached JWKS knows it in time, and the keys it
+ * replaces stay published, and valid, through a grace window longer than
+ * any token lives. Private keys are stored sealed with
+ * JWT_KEY_ENCRYPTION_KEY. Without that key configured, tokens are signed
+ * HS256 with JWT_SECRET as before; tokens without a kid are verified with
+ * JWT_SECRET for as long as it's set, so sessions survive the switch.
+ */
+
+import {
+  decodeProtectedHeader,
+  exportJWK,
+  generateKeyPair,
+  importJWK,
+  JWK,
+  JWTPayload,
+  jwtVerify,
+  SignJWT,
+} from 'jose';
+import { createCipheriv, createDecipheriv, randomBytes } from 'crypto';
+import { Prisma, SigningKey } from '@prisma/client';
+import prisma from './prisma';
+import { ScheduledTask } from './scheduler';
+import { singleflight } from './singleflight';
+
+const ALGORITHM = 'ES256';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// How long a key signs before it's replaced
+const ROTATION_MS =
+  parseFloat(process.env.JWT_KEY_ROTATION_DAYS || '30') * DAY_MS;
+
+// How long retired keys keep verifying; longer than any token lives (7d)
+const GRACE_MS = parseFloat(process.env.JWT_KEY_GRACE_DAYS || '8') * DAY_MS;
+
+// How long JWKS responses may be cached, and so how long a new key is
+// published before it signs
+export const JWKS_MAX_AGE_SECONDS = parseInt(
+  process.env.JWKS_MAX_AGE_SECONDS || '3600'
+);
+
+// Keys change rarely and are needed on every request
+const CACHE_MS = 60 * 1000;
+// Tokens with an unknown kid reload keys at most this often, so forged
+// kids can't send every request to the database
+const UNKNOWN_KID_RELOAD_MS = 5 * 1000;
+
+const CIPHER = 'aes-256-gcm';
+const IV_BYTES = 12;
+const TAG_BYTES = 16;
+
+let cache: { keys: SigningKey[]; loadedAt: number } | null = null;
+
+// Imported keys by kid; a kid's key material never changes
+const imported = new Map<string, CryptoKey>();
+
+let warnedLegacy = false;
+
+export interface RotationResult {
+  published: string | null;
+  activated: string | null;
+  deleted: number;
+}
+
+function encryptionKey(): Buffer | null {
+  const key = process.env.JWT_KEY_ENCRYPTION_KEY;
+  if (!key) {
+    return null;
+  }
+  const bytes = Buffer.from(key, 'base64');
+  return bytes.length === 32 ? bytes : null;
+}
+
+function legacySecret(): Uint8Array | null {
+  const secret = process.env.JWT_SECRET;
+  return secret ? new TextEncoder().encode(secret) : null;
+}
+
+function seal(jwk: JWK, key: Buffer): string {
+  const iv = randomBytes(IV_BYTES);
+  const cipher = createCipheriv(CIPHER, key, iv);
+  const ciphertext = Buffer.concat([
+    cipher.update(JSON.stringify(jwk), 'utf8'),
+    cipher.final(),
+  ]);
+  return Buffer.concat([iv, cipher.getAuthTag(), ciphertext]).toString(
+    'base64'
+  );
+}
+
+function unseal(sealed: string, key: Buffer): JWK {
+  const bytes = Buffer.from(sealed, 'base64');
+  const decipher = createDecipheriv(CIPHER, key, bytes.subarray(0, IV_BYTES));
+  decipher.setAuthTag(bytes.subarray(IV_BYTES, IV_BYTES + TAG_BYTES));
+  const plaintext = Buffer.concat([
+    decipher.update(bytes.subarray(IV_BYTES + TAG_BYTES)),
+    decipher.final(),
+  ]);
+  return JSON.parse(plaintext.toString('utf8'));
+}
+
+async function loadKeys(maxAgeMs = CACHE_MS): Promise<SigningKey[]> {
+  if (cache && Date.now() - cache.loadedAt < maxAgeMs) {
+    return cache.keys;
+  }
+  return singleflight('jwt-keys', 'all', async () => {
+    const keys = await prisma.signingKey.findMany({
+      orderBy: { createdAt: 'asc' },
+    });
+    cache = { keys, loadedAt: Date.now() };
+    return keys;
+  });
+}
+
+/**
+ * Generate a key pair and store it with the given status
+ */
+async function createKey(
+  status: 'pending' | 'active',
+  sealingKey: Buffer
+): Promise<SigningKey> {
+  const { publicKey, privateKey } = await generateKeyPair(ALGORITHM, {
+    extractable: true,
+  });
+  const kid = randomBytes(12).toString('base64url');
+  const key = await prisma.signingKey.create({
+    data: {
+      kid,
+      algorithm: ALGORITHM,
+      publicJwk: {
+        ...(await exportJWK(publicKey)),
+        kid,
+      } as Prisma.InputJsonValue,
+      privateKey: seal(await exportJWK(privateKey), sealingKey),
+      status,
+      activatedAt: status === 'active' ? new Date() : null,
+    },
+  });
+  cache = null;
+  return key;
+}
+
+/**
+ * The key to sign with: the newest active one, creating the first if
+ * there are none yet
+ */
+async function signingKey(
+  sealingKey: Buffer
+): Promise<{ kid: string; key: CryptoKey }> {
+  const keys = await loadKeys();
+  let current = [...keys].reverse().find(key => key.status === 'active');
+  if (!current) {
+    // Nothing has been signed with a key yet, so nobody needs warning
+    current = await singleflight('jwt-keys', 'bootstrap', () =>
+      createKey('active', sealingKey)
+    );
+  }
+
+  const cacheKey = `${current.kid}:private`;
+  let key = imported.get(cacheKey);
+  if (!key) {
+    key = (await importJWK(
+      unseal(current.privateKey, sealingKey),
+      ALGORITHM
+    )) as CryptoKey;
+    imported.set(cacheKey, key);
+  }
+  return { kid: current.kid, key };
+}
+
+/**
+ * The public key for a kid, reloading once for keys made elsewhere since
+ */
+async function verificationKey(kid: string): Promise<CryptoKey> {
+  let stored = (await loadKeys()).find(key => key.kid === kid);
+  if (!stored) {
+    stored = (await loadKeys(UNKNOWN_KID_RELOAD_MS)).find(
+      key => key.kid === kid
+    );
+  }
+  if (!stored) {
+    throw new Error(`Unknown signing key: ${kid}`);
+  }
+
+  let key = imported.get(kid);
+  if (!key) {
+    key = (await importJWK(
+      stored.publicJwk as JWK,
+      stored.algorithm
+    )) as CryptoKey;
+    imported.set(kid, key);
+  }
+  return key;
+}
+
+export class JwtKeys {
+  /**
+   * Sign claims into a token expiring at `expiresIn` (e.g. "24h", or a
+   * timestamp in seconds)
+   */
+  static async sign(
+    claims: JWTPayload,
+    expiresIn: string | number
+  ): Promise<string> {
+    const token = new SignJWT(claims)
+      .setIssuedAt()
+      .setExpirationTime(expiresIn);
+
+    const sealingKey = encryptionKey();
+    if (!sealingKey) {
+      if (!warnedLegacy) {
+        console.warn(
+          'JWT_KEY_ENCRYPTION_KEY is not set; signing with JWT_SECRET'
+        );
+        warnedLegacy = true;
+      }
+      return token.setProtectedHeader({ alg: 'HS256' }).sign(legacySecret()!);
+    }
+
+    const { kid, key } = await signingKey(sealingKey);
+    return token.setProtectedHeader({ alg: ALGORITHM, kid }).sign(key);
+  }
+
+  /**
+   * Sign a session's claims again with `claims` added, for routes that
+   * update the session. The new token expires no later than the old one
+   * (nor `maxAgeSeconds` from now), and impersonation tokens, which are
+   * only ever minted by an admin, are never reissued.
+   */
+  static async reissue(
+    payload: JWTPayload,
+    claims: JWTPayload,
+    maxAgeSeconds: number
+  ): Promise<string> {
+    if (payload.impersonation) {
+      throw new Error('Impersonation tokens are not reissued');
+    }
+    const latest = Math.floor(Date.now() / 1000) + maxAgeSeconds;
+    const { iat: _iat, exp, ...session } = payload;
+    return JwtKeys.sign(
+      { ...session, ...claims },
+      Math.min(exp ?? latest, latest)
+    );
+  }
+
+  /**
+   * Verify a token signed by any published key, or by JWT_SECRET if it
+   * has no kid. Throws if it isn't valid.
+   */
+  static async verify(token: string): Promise<{ payload: JWTPayload }> {
+    const { kid } = decodeProtectedHeader(token);
+    if (!kid) {
+      const secret = legacySecret();
+      if (!secret) {
+        throw new Error('Token has no kid');
+      }
+      return jwtVerify(token, secret, { algorithms: ['HS256'] });
+    }
+    return jwtVerify(token, await verificationKey(kid), {
+      algorithms: [ALGORITHM],
+    });
+  }
+
+  /**
+   * The public keys tokens may be signed with, as a JWK set
+   */
+  static async jwks(): Promise<{ keys: JWK[] }> {
+    const keys = await loadKeys();
+    return {
+      keys: keys.map(key => ({
+        ...(key.publicJwk as JWK),
+        kid: key.kid,
+        alg: key.algorithm,
+        use: 'sig',
+      })),
+    };
+  }
+
+  /**
+   * Publish the next key ahead of time, switch to it once it's due and
+   * published for long enough, and drop keys past their grace window
+   */
+  static async rotate(): Promise<RotationResult> {
+    const result: RotationResult = {
+      published: null,
+      activated: null,
+      deleted: 0,
+    };
+    const sealingKey = encryptionKey();
+    if (!sealingKey) {
+      return result;
+    }
+
+    const now = Date.now();
+    const keys = await loadKeys(0);
+    const current = [...keys].reverse().find(key => key.status === 'active');
+    const age = current?.activatedAt
+      ? now - current.activatedAt.getTime()
+      : Infinity;
+    const publishLeadMs = JWKS_MAX_AGE_SECONDS * 1000;
+
+    let pending = keys.find(key => key.status === 'pending');
+    if (!pending && age >= ROTATION_MS - publishLeadMs) {
+      pending = await createKey('pending', sealingKey);
+      result.published = pending.kid;
+    }
+
+    if (
+      pending &&
+      age >= ROTATION_MS &&
+      (!current || now - pending.createdAt.getTime() >= publishLeadMs)
+    ) {
+      await prisma.$transaction([
+        prisma.signingKey.updateMany({
+          where: { status: 'active' },
+          data: { status: 'retired', retiredAt: new Date(now) },
+        }),
+        prisma.signingKey.update({
+          where: { kid: pending.kid },
+          data: { status: 'active', activatedAt: new Date(now) },
+        }),
+      ]);
+      result.activated = pending.kid;
+    }
+
+    const deleted = await prisma.signingKey.deleteMany({
+      where: {
+        status: 'retired',
+        retiredAt: { lt: new Date(now - GRACE_MS) },
+      },
+    });
+    result.deleted = deleted.count;
+
+    if (result.published || result.activated || result.deleted) {
+      cache = null;
+      console.log('🔑 Rotated JWT signing keys:', result);
+    }
+    return result;
+  }
+}
+
+export const jwtKeyRotation: ScheduledTask = {
+  name: 'jwt-key-rotation',
+  everyMs: parseInt(process.env.JWT_KEY_ROTATION_INTERVAL_MS || '3600000'),
+  run: () => JwtKeys.rotate(),
+};
@@ -0,0 +1,411 @@
+/**
+ * Link Previews
+ * When a message contains a link, the server fetches the page's OpenGraph
+ * title, description, image and site name so the message can show a
+ * preview card. The chat service calls forMessage for each message and
+ * attaches the result to its payload as `preview`, as do messages sent
+ * from the app, scheduled messages and message requests. Previews are
+ * cached in Redis for LINK_PREVIEW_TTL_HOURS, and failures briefly, so a
+ * link shared many times is fetched once.
+ * Fetching arbitrary URLs from inside our network is guarded against
+ * SSRF: only http(s) on the default ports, every address the host
+ * resolves to must be public, the connection goes to the address that was
+ * checked (so a second DNS answer can't swap it), redirects are checked
+ * hop by hop, and the whole fetch (lookups, redirects and the body) is
+ * capped in time, and the response in size. Domains on
+ * LINK_PREVIEW_DENYLIST (and their subdomains) are never fetched.
+ */
+
+import { createHash } from 'crypto';
+import { lookup } from 'dns/promises';
+import { IncomingMessage, request as httpRequest } from 'http';
+import { RequestOptions, request as httpsRequest } from 'https';
+import { BlockList, isIP } from 'net';
+import redis from './redis';
+import { counter } from './metrics';
+
+export interface LinkPreview {
+  url: string;
+  title: string;
+  description: string | null;
+  image: string | null;
+  siteName: string | null;
+}
+
+const CACHE_TTL_SECONDS =
+  parseInt(process.env.LINK_PREVIEW_TTL_HOURS || '24') * 60 * 60;
+// Failed or empty previews are retried after this long
+const FAILURE_TTL_SECONDS = 60 * 60;
+
+// For the whole fetch, redirects included
+const FETCH_TIMEOUT_MS = parseInt(
+  process.env.LINK_PREVIEW_TIMEOUT_MS || '5000'
+);
+const MAX_BYTES = 512 * 1024;
+const MAX_REDIRECTS = 3;
+const MAX_URL_LENGTH = 2048;
+
+const DENYLIST = (process.env.LINK_PREVIEW_DENYLIST || '')
+  .split(',')
+  .map(domain => domain.trim().toLowerCase().replace(/^\.+|\.+$/g, ''))
+  .filter(Boolean);
+
+// Anything that isn't the public internet: private, loopback, link-local
+// (cloud metadata), shared, multicast, reserved and documentation ranges
+const NON_PUBLIC = new BlockList();
+for (const [network, prefix] of [
+  ['0.0.0.0', 8],
+  ['10.0.0.0', 8],
+  ['100.64.0.0', 10],
+  ['127.0.0.0', 8],
+  ['169.254.0.0', 16],
+  ['172.16.0.0', 12],
+  ['192.0.0.0', 24],
+  ['192.0.2.0', 24],
+  ['192.168.0.0', 16],
+  ['198.18.0.0', 15],
+  ['198.51.100.0', 24],
+  ['203.0.113.0', 24],
+  ['224.0.0.0', 3],
+] as const) {
+  NON_PUBLIC.addSubnet(network, prefix, 'ipv4');
+}
+for (const [network, prefix] of [
+  ['::', 127],
+  // IPv4-mapped and NAT64 addresses can reach IPv4 ranges
+  ['::ffff:0:0', 96],
+  ['64:ff9b::', 96],
+  ['100::', 64],
+  ['2001:db8::', 32],
+  ['fc00::', 7],
+  ['fe80::', 10],
+  ['ff00::', 8],
+] as const) {
+  NON_PUBLIC.addSubnet(network, prefix, 'ipv6');
+}
+
+const URL_PATTERN = /\bhttps?:\/\/[^\s<>"']+/i;
+
+const cacheKey = (url: string) =>
+  `link_preview:${createHash('sha256').update(url).digest('hex')}`;
+
+const previewCounter = counter(
+  'aurum_link_previews_total',
+  'Link preview lookups, by result'
+);
+
+class PreviewBlocked extends Error {}
+
+export function isPublicAddress(address: string): boolean {
+  const version = isIP(address);
+  return (
+    version !== 0 &&
+    !NON_PUBLIC.check(address, version === 4 ? 'ipv4' : 'ipv6')
+  );
+}
+
+// IPv6 literals come bracketed in URLs
+const bareHostname = (url: URL) => url.hostname.replace(/^\[|\]$/g, '');
+
+function isDenied(hostname: string): boolean {
+  const host = hostname.toLowerCase().replace(/\.$/, '');
+  return DENYLIST.some(
+    domain => host === domain || host.endsWith(`.${domain}`)
+  );
+}
+
+/**
+ * The URL if it's one we'd fetch, else null
+ */
+function checkUrl(raw: string): URL | null {
+  let url: URL;
+  try {
+    url = new URL(raw);
+  } catch {
+    return null;
+  }
+  if (
+    (url.protocol !== 'http:' && url.protocol !== 'https:') ||
+    url.port !== '' ||
+    url.username ||
+    url.password ||
+    isDenied(url.hostname)
+  ) {
+    return null;
+  }
+  url.hash = '';
+  return url;
+}
+
+/**
+ * One public address for the host; throws if any it resolves to isn't
+ * public, as the resolver could hand out either
+ */
+async function resolvePublic(url: URL): Promise<string> {
+  const host = bareHostname(url);
+  const addresses = isIP(host)
+    ? [host]
+    : (await lookup(host, { all: true })).map(entry => entry.address);
+  if (addresses.length === 0 || !addresses.every(isPublicAddress)) {
+    throw new PreviewBlocked(`${url.hostname} is not a public host`);
+  }
+  return addresses[0];
+}
+
+/**
+ * Settle as `promise` does, or reject once `signal` aborts
+ */
+function withDeadline<T>(promise: Promise<T>, signal: AbortSignal): Promise<T> {
+  return new Promise((resolve, reject) => {
+    const abort = () => reject(signal.reason);
+    if (signal.aborted) {
+      abort();
+      return;
+    }
+    signal.addEventListener('abort', abort, { once: true });
+    promise.then(
+      value => {
+        signal.removeEventListener('abort', abort);
+        resolve(value);
+      },
+      error => {
+        signal.removeEventListener('abort', abort);
+        reject(error);
+      }
+    );
+  });
+}
+
+/**
+ * GET the URL from the checked address, without following redirects
+ */
+function get(
+  url: URL,
+  address: string,
+  signal: AbortSignal
+): Promise<IncomingMessage> {
+  const hostname = bareHostname(url);
+  const options: RequestOptions = {
+    host: address,
+    // TLS still verifies the certificate against the real hostname
+    servername: isIP(hostname) ? undefined : hostname,
+    path: `${url.pathname}${url.search}`,
+    method: 'GET',
+    headers: {
+      Host: url.host,
+      'User-Agent': 'AurumLinkPreview/1.0',
+      Accept: 'text/html,application/xhtml+xml',
+    },
+    // Aborting also ends the response body mid-read
+    signal,
+  };
+  return new Promise((resolve, reject) => {
+    const req =
+      url.protocol === 'https:'
+        ? httpsRequest(options, resolve)
+        : httpRequest(options, resolve);
+    req.on('error', reject);
+    req.end();
+  });
+}
+
+async function readHtml(response: IncomingMessage): Promise<string> {
+  const chunks: Buffer[] = [];
+  let size = 0;
+  for await (const chunk of response) {
+    chunks.push(chunk);
+    size += chunk.length;
+    // The head is all we need; stop rather than download a huge page
+    if (size >= MAX_BYTES) {
+      response.destroy();
+      break;
+    }
+  }
+  return Buffer.concat(chunks).subarray(0, MAX_BYTES).toString('utf8');
+}
+
+/**
+ * The page's HTML, following checked redirects, or null if it isn't HTML.
+ * Everything must finish before `signal` aborts.
+ */
+async function fetchHtml(
+  start: URL,
+  signal: AbortSignal
+): Promise<{ url: URL; html: string } | null> {
+  let url = start;
+  for (let hop = 0; hop <= MAX_REDIRECTS; hop++) {
+    // Lookups can't be canceled, only stopped waiting for
+    const address = await withDeadline(resolvePublic(url), signal);
+    const response = await get(url, address, signal);
+    const status = response.statusCode ?? 0;
+
+    if (status >= 300 && status < 400 && response.headers.location) {
+      response.resume();
+      const next = checkUrl(new URL(response.headers.location, url).href);
+      if (!next) {
+        throw new PreviewBlocked('Redirected somewhere we would not fetch');
+      }
+      url = next;
+      continue;
+    }
+    if (
+      status !== 200 ||
+      !/^(text\/html|application\/xhtml\+xml)/i.test(
+        response.headers['content-type'] || ''
+      )
+    ) {
+      response.resume();
+      return null;
+    }
+    return { url, html: await withDeadline(readHtml(response), signal) };
+  }
+  throw new PreviewBlocked('Too many redirects');
+}
+
+// Out-of-range numeric entities decode to nothing rather than throwing
+const codePoint = (code: number) =>
+  code <= 0x10ffff ? String.fromCodePoint(code) : '';
+
+function decodeEntities(text: string): string {
+  return text
+    .replace(/&#(\d+);/g, (_, code) => codePoint(Number(code)))
+    .replace(/&#x([0-9a-f]+);/gi, (_, code) => codePoint(parseInt(code, 16)))
+    .replace(/&quot;/g, '"')
+    .replace(/&#39;|&apos;/g, "'")
+    .replace(/&lt;/g, '<')
+    .replace(/&gt;/g, '>')
+    .replace(/&amp;/g, '&');
+}
+
+function clean(value: string | undefined, maxLength: number): string | null {
+  if (!value) {
+    return null;
+  }
+  const text = decodeEntities(value).replace(/\s+/g, ' ').trim();
+  return text ? text.slice(0, maxLength) : null;
+}
+
+/**
+ * OpenGraph (and plain meta) tags by property or name
+ */
+function metaTags(html: string): Map<string, string> {
+  const tags = new Map<string, string>();
+  for (const [tag] of html.matchAll(/<meta\b[^>]*>/gi)) {
+    const attribute = (name: string) =>
+      new RegExp(`\\b${name}\\s*=\\s*(?:"([^"]*)"|'([^']*)')`, 'i')
+        .exec(tag)
+        ?.slice(1)
+        .find(value => value !== undefined);
+    const key = (attribute('property') || attribute('name'))?.toLowerCase();
+    const content = attribute('content');
+    if (key && content !== undefined && !tags.has(key)) {
+      tags.set(key, content);
+    }
+  }
+  return tags;
+}
+
+function parsePreview(url: URL, html: string): LinkPreview | null {
+  const tags = metaTags(html);
+  const title = clean(
+    tags.get('og:title') ||
+      tags.get('twitter:title') ||
+      /<title[^>]*>([^<]*)<\/title>/i.exec(html)?.[1],
+    200
+  );
+  if (!title) {
+    return null;
+  }
+
+  // Only https images, so clients never load them over plain http
+  let image: string | null = null;
+  const imageUrl = tags.get('og:image') || tags.get('twitter:image');
+  if (imageUrl) {
+    try {
+      const resolved = new URL(decodeEntities(imageUrl), url);
+      image =
+        resolved.protocol === 'https:' && !isDenied(resolved.hostname)
+          ? resolved.href
+          : null;
+    } catch {
+      image = null;
+    }
+  }
+
+  return {
+    url: url.href,
+    title,
+    description: clean(
+      tags.get('og:description') || tags.get('description'),
+      500
+    ),
+    image,
+    siteName: clean(tags.get('og:site_name'), 100) || url.hostname,
+  };
+}
+
+export class LinkPreviews {
+  /**
+   * The first link in a message's text, if any
+   */
+  static extractUrl(text: string): string | null {
+    const match = URL_PATTERN.exec(text);
+    // Trailing punctuation belongs to the sentence, not the link
+    return match ? match[0].replace(/[.,;:!?)\]]+$/, '') : null;
+  }
+
+  /**
+   * The preview for a URL, from the cache or fetched; null if the page has
+   * none or can't be fetched
+   */
+  static async preview(raw: string): Promise<LinkPreview | null> {
+    const url = raw.length <= MAX_URL_LENGTH ? checkUrl(raw) : null;
+    if (!url) {
+      previewCounter.inc({ result: 'blocked' });
+      return null;
+    }
+
+    const key = cacheKey(url.href);
+    const cached = await redis.get(key);
+    if (cached !== null) {
+      previewCounter.inc({ result: 'cached' });
+      return JSON.parse(cached) as LinkPreview | null;
+    }
+
+    let preview: LinkPreview | null = null;
+    let result = 'fetched';
+    try {
+      const page = await fetchHtml(
+        url,
+        AbortSignal.timeout(FETCH_TIMEOUT_MS)
+      );
+      preview = page && parsePreview(page.url, page.html);
+      if (!preview) {
+        result = 'empty';
+      }
+    } catch (error) {
+      if (error instanceof PreviewBlocked) {
+        result = 'blocked';
+      } else {
+        result = 'failed';
+        console.error(`Link preview failed for ${url.host}:`, error);
+      }
+    }
+
+    previewCounter.inc({ result });
+    await redis.setex(
+      key,
+      preview ? CACHE_TTL_SECONDS : FAILURE_TTL_SECONDS,
+      JSON.stringify(preview)
+    );
+    return preview;
+  }
+
+  /**
+   * The preview for the first link in a message, to attach to its payload
+   */
+  static async forMessage(text: string): Promise<LinkPreview | null> {
+    const url = LinkPreviews.extractUrl(text);
+    return url ? LinkPreviews.preview(url) : null;
+  }
+}
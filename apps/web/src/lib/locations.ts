@@ -0,0 +1,216 @@
+/**
+ * Locations
+ * Stores where users are for geo discovery and distance display. Exact
+ * coordinates are never kept: they're reduced to a geohash cell no finer
+ * than the user allows, and clients that don't want to send coordinates at
+ * all can send a coarse geohash instead. A user in travel mode is placed at
+ * their travel city instead.
+ */
+
+import prisma from './prisma';
+import {
+  decodeGeohash,
+  distanceKm,
+  encodeGeohash,
+  geohashNeighborhood,
+} from './geohash';
+
+// Coarsest cell a location may be stored at (~160 km)
+export const MIN_LOCATION_PRECISION = 3;
+
+export const MAX_LOCATION_PRECISION = Math.max(
+  MIN_LOCATION_PRECISION,
+  parseInt(process.env.LOCATION_MAX_PRECISION || '6')
+);
+
+const DEFAULT_LOCATION_PRECISION = parseInt(
+  process.env.LOCATION_DEFAULT_PRECISION || '5'
+);
+
+// Cell size discovery searches around the viewer
+const DISCOVERY_PRECISION = parseInt(
+  process.env.DISCOVERY_GEOHASH_PRECISION || '4'
+);
+
+// Upper bounds (km) and labels of the distances shown to other users
+const DISTANCE_BUCKETS: [number, string][] = [
+  [1, '<1 km'],
+  [5, '1–5 km'],
+  [10, '5–10 km'],
+  [25, '10–25 km'],
+  [50, '25–50 km'],
+  [100, '50–100 km'],
+];
+
+const FAR_BUCKET = '100+ km';
+
+const KM_PER_DEGREE = 111.32;
+
+export type LocationUpdate =
+  | { lat: number; lng: number; precision?: number }
+  | { geohash: string };
+
+export interface StoredLocation {
+  geohash: string;
+  precision: number;
+  source: string;
+  // Center of the stored cell
+  lat: number;
+  lng: number;
+  updatedAt: Date;
+}
+
+const clampPrecision = (precision: number) =>
+  Math.min(
+    MAX_LOCATION_PRECISION,
+    Math.max(MIN_LOCATION_PRECISION, Math.floor(precision))
+  );
+
+function toStored(location: {
+  geohash: string;
+  precision: number;
+  source: string;
+  updatedAt: Date;
+}): StoredLocation {
+  const { lat, lng } = decodeGeohash(location.geohash);
+  return { ...location, lat, lng };
+}
+
+/**
+ * The label for a distance. Locations are cells, so a distance is never
+ * reported as closer than `floorKm`, the size of the coarser cell.
+ */
+export function distanceBucket(km: number, floorKm = 0): string {
+  const distance = Math.max(km, floorKm);
+  const bucket = DISTANCE_BUCKETS.find(([limit]) => distance < limit);
+  return bucket ? bucket[1] : FAR_BUCKET;
+}
+
+// Height of a geohash cell in km (cells are at most twice as wide)
+const cellSizeKm = (geohash: string) =>
+  decodeGeohash(geohash).latError * 2 * KM_PER_DEGREE;
+
+/**
+ * Where each user is for discovery and distances: their travel city while
+ * travel mode is on, their own location otherwise
+ */
+async function effectiveGeohashes(
+  userIds: string[]
+): Promise<Map<string, string>> {
+  const [locations, trips] = await Promise.all([
+    prisma.userLocation.findMany({
+      where: { userId: { in: userIds } },
+      select: { userId: true, geohash: true },
+    }),
+    prisma.travelLocation.findMany({
+      where: { userId: { in: userIds }, expiresAt: { gt: new Date() } },
+      select: { userId: true, geohash: true },
+    }),
+  ]);
+  return new Map(
+    [...locations, ...trips].map(entry => [entry.userId, entry.geohash])
+  );
+}
+
+export class Locations {
+  /**
+   * Save the user's location, reduced to the precision they asked for
+   * (or the default) and never finer than the configured maximum
+   */
+  static async update(
+    userId: string,
+    update: LocationUpdate
+  ): Promise<StoredLocation> {
+    let geohash: string;
+    let source: string;
+    if ('geohash' in update) {
+      const hash = update.geohash.toLowerCase();
+      geohash = hash.slice(0, clampPrecision(hash.length));
+      source = 'geohash';
+    } else {
+      const precision = clampPrecision(
+        update.precision ?? DEFAULT_LOCATION_PRECISION
+      );
+      geohash = encodeGeohash(update.lat, update.lng, precision);
+      source = 'coordinates';
+    }
+
+    const location = await prisma.userLocation.upsert({
+      where: { userId },
+      create: { userId, geohash, precision: geohash.length, source },
+      update: { geohash, precision: geohash.length, source },
+    });
+    return toStored(location);
+  }
+
+  static async get(userId: string): Promise<StoredLocation | null> {
+    const location = await prisma.userLocation.findUnique({
+      where: { userId },
+    });
+    return location ? toStored(location) : null;
+  }
+
+  static async clear(userId: string): Promise<boolean> {
+    const removed = await prisma.userLocation.deleteMany({ where: { userId } });
+    return removed.count > 0;
+  }
+
+  /**
+   * Bucketed distances from the viewer to each of `userIds`. Users are
+   * left out when either side has no location or hides their distance
+   * (hiding is mutual: you don't see others' distances either).
+   */
+  static async distancesFrom(
+    viewerId: string,
+    userIds: string[]
+  ): Promise<Map<string, string>> {
+    const distances = new Map<string, string>();
+    const viewer = await prisma.user.findUnique({
+      where: { id: viewerId },
+      select: { hideDistance: true },
+    });
+    if (!viewer || viewer.hideDistance || userIds.length === 0) {
+      return distances;
+    }
+
+    const visible = await prisma.user.findMany({
+      where: { id: { in: userIds }, hideDistance: false },
+      select: { id: true },
+    });
+    const geohashes = await effectiveGeohashes([
+      viewerId,
+      ...visible.map(user => user.id),
+    ]);
+    const viewerGeohash = geohashes.get(viewerId);
+    if (!viewerGeohash) {
+      return distances;
+    }
+
+    const from = decodeGeohash(viewerGeohash);
+    for (const { id } of visible) {
+      const geohash = geohashes.get(id);
+      if (!geohash) {
+        continue;
+      }
+      const floorKm = Math.max(cellSizeKm(viewerGeohash), cellSizeKm(geohash));
+      distances.set(
+        id,
+        distanceBucket(distanceKm(from, decodeGeohash(geohash)), floorKm)
+      );
+    }
+    return distances;
+  }
+
+  /**
+   * Geohash prefixes covering the area around the user that discovery
+   * searches, or null if we don't know where they are
+   */
+  static async discoveryCells(userId: string): Promise<string[] | null> {
+    const geohash = (await effectiveGeohashes([userId])).get(userId);
+    if (!geohash) {
+      return null;
+    }
+    const precision = Math.min(DISCOVERY_PRECISION, geohash.length);
+    return geohashNeighborhood(geohash.slice(0, precision));
+  }
+}
@@ -0,0 +1,188 @@
+/**
+ * @description Unit tests for sending match messages
+ */
+
+import { describe, it, expect, jest, beforeEach } from '@jest/globals';
+import { MatchMessages } from '@/lib/match-messages';
+
+let mockGate: { status: string; requestId?: string } = { status: 'deliver' };
+// Channel each published message went to
+const mockPublished: string[] = [];
+const mockEvents = jest.fn(
+  async (_type: string, _payload: Record<string, unknown>) => {}
+);
+const mockNotice = jest.fn(async (_notice: Record<string, unknown>) => true);
+const mockSystemReport = jest.fn(
+  async (_userId: string, _reason: string, _evidence: unknown) => {}
+);
+
+jest.mock('@/lib/prisma', () => ({
+  __esModule: true,
+  default: {
+    match: {
+      findFirst: async ({ where }: { where: { id: string } }) =>
+        where.id === 'match-1'
+          ? {
+              id: 'match-1',
+              user1Id: 'user-1',
+              user2Id: 'user-2',
+              user1: { id: 'user-1', displayName: 'Ploy' },
+              user2: { id: 'user-2', displayName: 'Niran' },
+            }
+          : null,
+    },
+  },
+}));
+
+jest.mock('@/lib/redis', () => ({
+  __esModule: true,
+  default: {
+    multi: () => {
+      const pipeline = {
+        publish: (channel: string) => {
+          mockPublished.push(channel);
+          return pipeline;
+        },
+        exec: async () => [],
+      };
+      return pipeline;
+    },
+  },
+}));
+
+jest.mock('@/lib/event-bus', () => ({
+  EventBus: {
+    publish: (type: string, payload: Record<string, unknown>) =>
+      mockEvents(type, payload),
+  },
+}));
+
+jest.mock('@/lib/message-requests', () => ({
+  MessageRequests: { gate: async () => mockGate },
+}));
+
+jest.mock('@/lib/link-previews', () => ({
+  LinkPreviews: { forMessage: async () => null },
+}));
+
+jest.mock('@/lib/conversation-mutes', () => ({
+  ConversationMutes: { isMuted: async () => false },
+}));
+
+jest.mock('@/lib/notification-push', () => ({
+  NotificationPush: { send: async () => {} },
+}));
+
+jest.mock('@/lib/conversation-safety', () => ({
+  ConversationSafety: {
+    notice: (notice: Record<string, unknown>) => mockNotice(notice),
+  },
+}));
+
+jest.mock('@/lib/reports', () => ({
+  Reports: {
+    createSystemReport: (userId: string, reason: string, evidence: unknown) =>
+      mockSystemReport(userId, reason, evidence),
+  },
+}));
+
+jest.mock('@/lib/metrics', () => ({
+  counter: () => ({ inc: () => {} }),
+}));
+
+const SCAM =
+  'I am stuck at the airport, please send money by western union, ' +
+  'paypal or gift cards urgently';
+
+describe('MatchMessages.send', () => {
+  beforeEach(() => {
+    mockGate = { status: 'deliver' };
+    mockPublished.length = 0;
+    mockEvents.mockClear();
+    mockNotice.mockClear();
+    mockSystemReport.mockClear();
+  });
+
+  it('delivers to both people and announces the message', async () => {
+    const result = await MatchMessages.send('match-1', 'user-1', 'hi!');
+
+    expect(result).toMatchObject({ status: 'sent', held: false });
+    expect(mockPublished).toEqual([
+      'messages:user:user-1',
+      'messages:user:user-2',
+    ]);
+    expect(mockEvents).toHaveBeenCalledWith('message.sent', {
+      matchId: 'match-1',
+      senderId: 'user-1',
+      recipientId: 'user-2',
+    });
+  });
+
+  it('only echoes a held request to the sender', async () => {
+    mockGate = { status: 'held', requestId: 'req-1' };
+
+    const result = await MatchMessages.send('match-1', 'user-1', SCAM);
+
+    expect(result).toMatchObject({ status: 'sent', held: true });
+    expect(mockPublished).toEqual(['messages:user:user-1']);
+    expect(mockEvents).not.toHaveBeenCalled();
+    expect(mockNotice).not.toHaveBeenCalled();
+  });
+
+  it('refuses while the sender waits on their request', async () => {
+    mockGate = { status: 'pending', requestId: 'req-1' };
+
+    expect(await MatchMessages.send('match-1', 'user-1', 'hello?')).toEqual({
+      status: 'pending',
+    });
+    expect(mockPublished).toEqual([]);
+  });
+
+  it('does not find a match the sender is not in', async () => {
+    expect(await MatchMessages.send('match-2', 'user-1', 'hi')).toEqual({
+      status: 'not_found',
+    });
+  });
+
+  it('warns the recipient about a likely scam and opens a case', async () => {
+    await MatchMessages.send('match-1', 'user-1', SCAM);
+
+    expect(mockNotice).toHaveBeenCalledWith(
+      expect.objectContaining({
+        kind: 'scam',
+        senderId: 'user-1',
+        recipientId: 'user-2',
+      })
+    );
+    expect(mockSystemReport).toHaveBeenCalledWith(
+      'user-1',
+      'scam',
+      expect.objectContaining({ source: 'scam_detection' })
+    );
+  });
+
+  it('warns the recipient about harassment', async () => {
+    await MatchMessages.send('match-1', 'user-1', 'watch your back');
+
+    expect(mockNotice).toHaveBeenCalledWith(
+      expect.objectContaining({ kind: 'harassment', recipientId: 'user-2' })
+    );
+    expect(mockSystemReport).not.toHaveBeenCalled();
+  });
+
+  it('leaves ordinary messages alone', async () => {
+    await MatchMessages.send('match-1', 'user-1', 'dinner on friday?');
+
+    expect(mockNotice).not.toHaveBeenCalled();
+    expect(mockSystemReport).not.toHaveBeenCalled();
+  });
+
+  it('still delivers when screening fails', async () => {
+    mockNotice.mockRejectedValueOnce(new Error('Redis unavailable'));
+
+    const result = await MatchMessages.send('match-1', 'user-1', SCAM);
+
+    expect(result).toMatchObject({ status: 'sent' });
+    expect(mockEvents).toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,140 @@
+/**
+ * Match Messages
+ * Sending a message in a match's conversation from the app. Every message
+ * goes through message requests first (see lib/message-requests): a held
+ * first message only reaches the sender's own channel, and the recipient
+ * gets it if they accept. Delivered messages are handed to the chat
+ * service on both people's message channels, with a preview of any link
+ * in them, pushed unless the recipient muted the conversation, and
+ * announced as message.sent on the domain event stream. Delivered text is
+ * screened for scams and harassment on the way (see lib/scam-detection
+ * and lib/harassment-detection).
+ */
+
+import { randomUUID } from 'crypto';
+import prisma from './prisma';
+import redis from './redis';
+import { EventBus } from './event-bus';
+import { HarassmentDetection } from './harassment-detection';
+import { ConversationMutes } from './conversation-mutes';
+import { messageChannel } from './message-deletion';
+import { LinkPreview, LinkPreviews } from './link-previews';
+import { MessageRequests } from './message-requests';
+import { NotificationPush } from './notification-push';
+import { ScamDetection } from './scam-detection';
+
+export type SendResult =
+  | { status: 'sent'; messageId: string; held: boolean; sentAt: Date }
+  // The sender's first message is still waiting as a request
+  | { status: 'pending' }
+  // The recipient declined the sender's request
+  | { status: 'declined' }
+  | { status: 'not_found' };
+
+export interface MatchMessageEvent {
+  type: 'message';
+  messageId: string;
+  matchId: string;
+  fromUserId: string;
+  toUserId: string;
+  text: string;
+  // For the first link in the text, if it has one (see lib/link-previews)
+  preview: LinkPreview | null;
+  sentAt: string;
+  // Held as a message request: only the sender's side gets it
+  held: boolean;
+}
+
+/**
+ * Screen a delivered message. It has already gone out, so a failure here
+ * is only logged.
+ */
+async function screen(senderId: string, recipientId: string, text: string) {
+  const message = { senderId, recipientId, text };
+  const results = await Promise.allSettled([
+    ScamDetection.screen(message),
+    HarassmentDetection.screen(message),
+  ]);
+  for (const result of results) {
+    if (result.status === 'rejected') {
+      console.error('Error screening a message:', result.reason);
+    }
+  }
+}
+
+export class MatchMessages {
+  /**
+   * Send a message to the other person in a match
+   */
+  static async send(
+    matchId: string,
+    senderId: string,
+    text: string
+  ): Promise<SendResult> {
+    const match = await prisma.match.findFirst({
+      where: {
+        id: matchId,
+        deletedAt: null,
+        OR: [{ user1Id: senderId }, { user2Id: senderId }],
+      },
+      include: {
+        user1: { select: { id: true, displayName: true } },
+        user2: { select: { id: true, displayName: true } },
+      },
+    });
+    if (!match) {
+      return { status: 'not_found' };
+    }
+    const [sender, recipient] =
+      match.user1Id === senderId
+        ? [match.user1, match.user2]
+        : [match.user2, match.user1];
+
+    const gate = await MessageRequests.gate(matchId, senderId, text);
+    if (gate.status !== 'deliver' && gate.status !== 'held') {
+      return { status: gate.status };
+    }
+    const held = gate.status === 'held';
+
+    const sentAt = new Date();
+    const event: MatchMessageEvent = {
+      type: 'message',
+      messageId: randomUUID(),
+      matchId,
+      fromUserId: senderId,
+      toUserId: recipient.id,
+      text,
+      // A preview is a nicety; the message goes out without one
+      preview: await LinkPreviews.forMessage(text).catch(error => {
+        console.error('Error previewing a message:', error);
+        return null;
+      }),
+      sentAt: sentAt.toISOString(),
+      held,
+    };
+    const data = JSON.stringify(event);
+    const publish = redis.multi().publish(messageChannel(senderId), data);
+    if (!held) {
+      publish.publish(messageChannel(recipient.id), data);
+    }
+    await publish.exec();
+
+    // A held request notifies the recipient itself
+    if (!held) {
+      await screen(senderId, recipient.id, text);
+      await EventBus.publish('message.sent', {
+        matchId,
+        senderId,
+        recipientId: recipient.id,
+      });
+      if (!(await ConversationMutes.isMuted(matchId, recipient.id))) {
+        await NotificationPush.send(recipient.id, {
+          type: 'new_message',
+          variables: { name: sender.displayName },
+          path: `/matches/${matchId}`,
+        });
+      }
+    }
+    return { status: 'sent', messageId: event.messageId, held, sentAt };
+  }
+}
@@ -0,0 +1,336 @@
+/**
+ * Media GC
+ * Finds objects in media storage nothing uses any more, and deletes them:
+ * uploads a client never finished, voice intro clips and profile photos
+ * that were replaced or turned down, blurred variants of photos the user
+ * has since changed, purged quarantine copies, and everything belonging
+ * to erased accounts. Each tracked object is checked against the records
+ * that would use it; the bucket itself can also be scanned for objects
+ * stored before tracking began, which are adopted if still in use.
+ * Objects under a prefix the app doesn't know are never touched. A dry
+ * run only reports; every run is recorded so admins can review what a
+ * real run would remove first.
+ */
+
+import { MediaGcRun, MediaObject, Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { MediaKind, MediaStorage, mediaStorageEnabled } from './media-storage';
+import { PhotoReveal } from './photo-reveal';
+import { ProfilePhotos } from './profile-photos';
+import { Quarantine, QUARANTINE_PREFIX } from './quarantine';
+import { ScheduledTask } from './scheduler';
+
+export type OrphanReason =
+  | 'abandoned_upload'
+  | 'owner_deleted'
+  | 'unreferenced';
+
+// Younger objects may be mid-upload or mid-processing, so are left alone
+const GRACE_MS =
+  parseFloat(process.env.MEDIA_GC_GRACE_HOURS || '24') * 60 * 60 * 1000;
+
+const BATCH_SIZE = 500;
+
+// Orphan keys kept in a run's details, for spot checks
+const SAMPLE_SIZE = 100;
+
+// Key layouts, and which segment holds the owner's ID
+const KEY_PREFIXES: Record<MediaKind, { prefix: string; owner: number }> = {
+  voice_intro_upload: { prefix: 'uploads/voice-intros/', owner: 2 },
+  voice_intro: { prefix: 'voice-intros/', owner: 1 },
+  photo_variant: { prefix: 'photo-variants/', owner: 1 },
+  profile_photo: { prefix: 'profile-photos/', owner: 1 },
+  quarantined: { prefix: QUARANTINE_PREFIX, owner: 1 },
+};
+
+export interface GcOptions {
+  // Report orphans without deleting them
+  dryRun: boolean;
+  // Also list the bucket for objects that aren't tracked
+  scanBucket: boolean;
+}
+
+export interface GcResult {
+  dryRun: boolean;
+  scanned: number;
+  orphaned: number;
+  deleted: number;
+  bytes: number;
+  details: {
+    byKind: Record<string, number>;
+    byReason: Record<string, number>;
+    adopted: number;
+    sample: Array<{ key: string; reason: OrphanReason }>;
+  };
+}
+
+type Candidate = Pick<MediaObject, 'key' | 'kind' | 'ownerId' | 'createdAt'>;
+
+function kindOf(key: string): { kind: MediaKind; ownerId: string } | null {
+  for (const kind of Object.keys(KEY_PREFIXES) as MediaKind[]) {
+    const { prefix, owner } = KEY_PREFIXES[kind];
+    const ownerId = key.split('/')[owner];
+    if (key.startsWith(prefix) && ownerId) {
+      return { kind, ownerId };
+    }
+  }
+  return null;
+}
+
+/**
+ * Why each of `objects` is no longer needed; objects still in use are
+ * left out
+ */
+async function orphans(
+  objects: Candidate[]
+): Promise<Map<string, OrphanReason>> {
+  const result = new Map<string, OrphanReason>();
+  if (objects.length === 0) {
+    return result;
+  }
+  const keys = objects.map(object => object.key);
+
+  const [owners, intros, held] = await Promise.all([
+    prisma.user.findMany({
+      where: { id: { in: [...new Set(objects.map(o => o.ownerId))] } },
+      select: {
+        id: true,
+        status: true,
+        profileImage: true,
+        photoRevealMode: true,
+      },
+    }),
+    prisma.voiceIntro.findMany({
+      where: {
+        OR: [{ uploadKey: { in: keys } }, { mediaKey: { in: keys } }],
+      },
+      select: {
+        uploadKey: true,
+        mediaKey: true,
+        status: true,
+        createdAt: true,
+      },
+    }),
+    Quarantine.heldKeys(keys),
+  ]);
+  const ownersById = new Map(owners.map(owner => [owner.id, owner]));
+  const abandonedBefore = Date.now() - GRACE_MS;
+
+  for (const object of objects) {
+    const owner = ownersById.get(object.ownerId);
+    if (!owner || owner.status === 'deleted') {
+      result.set(object.key, 'owner_deleted');
+      continue;
+    }
+
+    if (object.kind === 'voice_intro_upload') {
+      const intro = intros.find(intro => intro.uploadKey === object.key);
+      if (
+        intro?.status === 'pending_upload' &&
+        intro.createdAt.getTime() < abandonedBefore
+      ) {
+        result.set(object.key, 'abandoned_upload');
+      } else if (
+        intro?.status !== 'pending_upload' &&
+        intro?.status !== 'processing'
+      ) {
+        // Processed uploads are deleted; these are left from a failure
+        result.set(object.key, 'unreferenced');
+      }
+    } else if (object.kind === 'voice_intro') {
+      const intro = intros.find(intro => intro.mediaKey === object.key);
+      if (intro?.status !== 'ready') {
+        result.set(object.key, 'unreferenced');
+      }
+    } else if (object.kind === 'photo_variant') {
+      const current =
+        owner.photoRevealMode &&
+        owner.profileImage &&
+        object.key.startsWith(
+          PhotoReveal.variantPrefix(owner.id, owner.profileImage)
+        );
+      if (!current) {
+        result.set(object.key, 'unreferenced');
+      }
+    } else if (object.kind === 'profile_photo') {
+      if (ProfilePhotos.storedKey(owner.profileImage) !== object.key) {
+        result.set(object.key, 'unreferenced');
+      }
+    } else if (object.kind === 'quarantined') {
+      if (!held.has(object.key)) {
+        result.set(object.key, 'unreferenced');
+      }
+    }
+  }
+  return result;
+}
+
+export class MediaGc {
+  /**
+   * Find orphaned objects and, unless it's a dry run, delete them
+   */
+  static async run({ dryRun, scanBucket }: GcOptions): Promise<GcResult> {
+    const result: GcResult = {
+      dryRun,
+      scanned: 0,
+      orphaned: 0,
+      deleted: 0,
+      bytes: 0,
+      details: { byKind: {}, byReason: {}, adopted: 0, sample: [] },
+    };
+    if (!mediaStorageEnabled()) {
+      return result;
+    }
+    const olderThan = new Date(Date.now() - GRACE_MS);
+
+    const collect = async (
+      batch: Array<Candidate & { sizeBytes: number | null }>
+    ) => {
+      result.scanned += batch.length;
+      const found = await orphans(batch);
+      for (const object of batch) {
+        const reason = found.get(object.key);
+        if (!reason) {
+          continue;
+        }
+        result.orphaned++;
+        result.bytes += object.sizeBytes ?? 0;
+        result.details.byKind[object.kind] =
+          (result.details.byKind[object.kind] ?? 0) + 1;
+        result.details.byReason[reason] =
+          (result.details.byReason[reason] ?? 0) + 1;
+        if (result.details.sample.length < SAMPLE_SIZE) {
+          result.details.sample.push({ key: object.key, reason });
+        }
+        if (!dryRun) {
+          await MediaStorage.remove(object.key);
+          result.deleted++;
+        }
+      }
+      return found;
+    };
+
+    // Tracked objects; deleting them as we go, so paged by key
+    let cursor = '';
+    for (;;) {
+      const batch = await prisma.mediaObject.findMany({
+        where: { createdAt: { lt: olderThan }, key: { gt: cursor } },
+        orderBy: { key: 'asc' },
+        take: BATCH_SIZE,
+      });
+      if (batch.length === 0) {
+        break;
+      }
+      await collect(batch);
+      cursor = batch[batch.length - 1].key;
+    }
+
+    // Objects the database doesn't know about
+    if (scanBucket) {
+      let token: string | undefined;
+      do {
+        const page = await MediaStorage.list(token);
+        const listed = page.objects.filter(
+          object => object.lastModified < olderThan
+        );
+        const tracked = new Set(
+          (
+            await prisma.mediaObject.findMany({
+              where: { key: { in: listed.map(object => object.key) } },
+              select: { key: true },
+            })
+          ).map(object => object.key)
+        );
+
+        const untracked = listed.flatMap(object => {
+          const known = kindOf(object.key);
+          return known && !tracked.has(object.key)
+            ? [
+                {
+                  key: object.key,
+                  ...known,
+                  sizeBytes: object.sizeBytes,
+                  createdAt: object.lastModified,
+                },
+              ]
+            : [];
+        });
+        const found = await collect(untracked);
+
+        // Still in use, so tracked from now on
+        const adopt = untracked.filter(object => !found.has(object.key));
+        if (!dryRun && adopt.length > 0) {
+          await prisma.mediaObject.createMany({
+            data: adopt.map(({ key, kind, ownerId, sizeBytes }) => ({
+              key,
+              kind,
+              ownerId,
+              sizeBytes,
+            })),
+          });
+        }
+        result.details.adopted += adopt.length;
+        token = page.nextToken ?? undefined;
+      } while (token);
+    }
+
+    await prisma.mediaGcRun.create({
+      data: {
+        dryRun,
+        scanned: result.scanned,
+        orphaned: result.orphaned,
+        deleted: result.deleted,
+        bytes: result.bytes,
+        details: result.details as unknown as Prisma.InputJsonValue,
+      },
+    });
+    if (result.orphaned > 0) {
+      console.log(
+        `🗑️ ${dryRun ? 'Found' : 'Deleted'} orphaned media:`,
+        { orphaned: result.orphaned, byReason: result.details.byReason }
+      );
+    }
+    return result;
+  }
+
+  /**
+   * Recent runs, and what's tracked now by kind
+   */
+  static async report(limit = 20): Promise<{
+    tracked: Array<{ kind: string; objects: number; bytes: number }>;
+    runs: MediaGcRun[];
+  }> {
+    const [grouped, runs] = await Promise.all([
+      prisma.mediaObject.groupBy({
+        by: ['kind'],
+        _count: { _all: true },
+        _sum: { sizeBytes: true },
+      }),
+      prisma.mediaGcRun.findMany({
+        orderBy: { ranAt: 'desc' },
+        take: limit,
+      }),
+    ]);
+    return {
+      tracked: grouped.map(row => ({
+        kind: row.kind,
+        objects: row._count._all,
+        bytes: row._sum.sizeBytes ?? 0,
+      })),
+      runs,
+    };
+  }
+}
+
+export const mediaGc: ScheduledTask<Partial<GcOptions>> = {
+  name: 'media-gc',
+  everyMs: parseInt(process.env.MEDIA_GC_INTERVAL_MS || '86400000'),
+  // Scheduled runs take their mode from the environment; runs an admin
+  // starts pass their own
+  run: data =>
+    MediaGc.run({
+      dryRun: data.dryRun ?? process.env.MEDIA_GC_DRY_RUN === 'true',
+      scanBucket:
+        data.scanBucket ?? process.env.MEDIA_GC_SCAN_BUCKET === 'true',
+    }),
+};
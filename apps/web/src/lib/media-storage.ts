@@ -0,0 +1,328 @@
+/**
+ * Media Storage
+ * User-uploaded media in an S3-compatible bucket. Clients upload straight
+ * to the bucket with pre-signed URLs; the app and workers read and write
+ * with SigV4-signed requests. Served through MEDIA_CDN_BASE_URL when set,
+ * otherwise with short-lived pre-signed download URLs; user media shown
+ * in the app goes through the signed proxy instead (see lib/signed-media).
+ * Every object the app stores is tracked in the database with what it's
+ * for and whose it is, so objects nothing uses any more can be found (see
+ * lib/media-gc).
+ */
+
+import { createHash } from 'crypto';
+import prisma from './prisma';
+import { presignUrl, signHeaders } from './aws-sigv4';
+import { upstreamFetch } from './http-client';
+
+const REQUEST_TIMEOUT_MS = 30_000;
+
+// How long pre-signed download URLs last when there's no CDN
+const DOWNLOAD_URL_TTL_SECONDS = 60 * 60;
+
+// Raw client uploads live under this prefix until they're processed
+export const UPLOADS_PREFIX = 'uploads/';
+
+// What an object is for; lib/media-gc knows when each kind is still used
+export type MediaKind =
+  | 'voice_intro_upload'
+  | 'voice_intro'
+  | 'photo_variant'
+  | 'profile_photo'
+  // Infected uploads held for review (see lib/quarantine)
+  | 'quarantined';
+
+export interface MediaRef {
+  kind: MediaKind;
+  ownerId: string;
+}
+
+export interface ListedObject {
+  key: string;
+  sizeBytes: number;
+  lastModified: Date;
+}
+
+/**
+ * Whether a bucket is configured for this deployment
+ */
+export function mediaStorageEnabled(): boolean {
+  return Boolean(
+    process.env.MEDIA_BUCKET &&
+      process.env.AWS_REGION &&
+      process.env.AWS_ACCESS_KEY_ID &&
+      process.env.AWS_SECRET_ACCESS_KEY
+  );
+}
+
+// Path-style URLs, so custom endpoints (R2, MinIO) work the same as S3
+function objectUrl(key: string): string {
+  const endpoint =
+    process.env.MEDIA_S3_ENDPOINT ||
+    `https://s3.${process.env.AWS_REGION}.amazonaws.com`;
+  return `${endpoint.replace(/\/$/, '')}/${process.env.MEDIA_BUCKET}/${key}`;
+}
+
+function bucketUrl(query: Record<string, string>): string {
+  const url = new URL(objectUrl('').replace(/\/$/, ''));
+  for (const [name, value] of Object.entries(query)) {
+    url.searchParams.set(name, value);
+  }
+  return url.toString();
+}
+
+async function track(key: string, ref: MediaRef, sizeBytes: number | null) {
+  await prisma.mediaObject.upsert({
+    where: { key },
+    create: { key, kind: ref.kind, ownerId: ref.ownerId, sizeBytes },
+    update: { sizeBytes },
+  });
+}
+
+function xmlValue(xml: string, tag: string): string | null {
+  const match = xml.match(new RegExp(`<${tag}>([\\s\\S]*?)</${tag}>`));
+  return match
+    ? match[1]
+        .replace(/&lt;/g, '<')
+        .replace(/&gt;/g, '>')
+        .replace(/&quot;/g, '"')
+        .replace(/&apos;/g, "'")
+        .replace(/&amp;/g, '&')
+    : null;
+}
+
+export class MediaStorage {
+  /**
+   * A URL the client can PUT the object to, with this exact Content-Type.
+   * The object is tracked from now, so an abandoned upload is found too.
+   */
+  static async presignUpload(
+    key: string,
+    contentType: string,
+    expiresSeconds: number,
+    ref: MediaRef
+  ): Promise<string> {
+    await track(key, ref, null);
+    return presignUrl({
+      method: 'PUT',
+      url: objectUrl(key),
+      service: 's3',
+      headers: { 'content-type': contentType },
+      expiresSeconds,
+    });
+  }
+
+  /**
+   * Where clients can fetch the object from
+   */
+  static publicUrl(key: string): string {
+    const cdn = process.env.MEDIA_CDN_BASE_URL;
+    if (cdn) {
+      return `${cdn.replace(/\/$/, '')}/${key}`;
+    }
+    return MediaStorage.presignedUrl(key);
+  }
+
+  /**
+   * A pre-signed download URL that stops working after `expiresSeconds`,
+   * even with a CDN in front, for media that isn't public
+   */
+  static presignedUrl(
+    key: string,
+    expiresSeconds = DOWNLOAD_URL_TTL_SECONDS
+  ): string {
+    return presignUrl({
+      method: 'GET',
+      url: objectUrl(key),
+      service: 's3',
+      expiresSeconds,
+    });
+  }
+
+  /**
+   * The object's bytes, or null if it doesn't exist
+   */
+  static async download(key: string): Promise<Buffer | null> {
+    const url = objectUrl(key);
+    const response = await upstreamFetch(
+      's3',
+      url,
+      {
+        headers: signHeaders({ method: 'GET', url, service: 's3' }),
+      },
+      { retries: 2, timeoutMs: REQUEST_TIMEOUT_MS }
+    );
+    if (response.status === 404) {
+      return null;
+    }
+    if (!response.ok) {
+      throw new Error(`Media download failed: ${response.status}`);
+    }
+    return Buffer.from(await response.arrayBuffer());
+  }
+
+  /**
+   * The object as a streaming response from storage, or null if it
+   * doesn't exist. `range` (an HTTP Range header) is passed through, so
+   * audio can be seeked.
+   */
+  static async stream(key: string, range?: string): Promise<Response | null> {
+    const url = objectUrl(key);
+    const response = await upstreamFetch(
+      's3',
+      url,
+      {
+        headers: {
+          ...signHeaders({ method: 'GET', url, service: 's3' }),
+          ...(range && { Range: range }),
+        },
+      },
+      { retries: 2, timeoutMs: REQUEST_TIMEOUT_MS }
+    );
+    if (response.status === 404) {
+      return null;
+    }
+    if (!response.ok) {
+      throw new Error(`Media download failed: ${response.status}`);
+    }
+    return response;
+  }
+
+  static async upload(
+    key: string,
+    body: Buffer,
+    contentType: string,
+    ref: MediaRef
+  ): Promise<void> {
+    const url = objectUrl(key);
+    const response = await upstreamFetch(
+      's3',
+      url,
+      {
+        method: 'PUT',
+        headers: signHeaders({
+          method: 'PUT',
+          url,
+          service: 's3',
+          body,
+          headers: { 'content-type': contentType },
+        }),
+        body,
+      },
+      { retries: 2, timeoutMs: REQUEST_TIMEOUT_MS }
+    );
+    if (!response.ok) {
+      throw new Error(`Media upload failed: ${response.status}`);
+    }
+    await track(key, ref, body.length);
+  }
+
+  /**
+   * Delete the object (a missing object is not an error)
+   */
+  static async remove(key: string): Promise<void> {
+    const url = objectUrl(key);
+    const response = await upstreamFetch(
+      's3',
+      url,
+      {
+        method: 'DELETE',
+        headers: signHeaders({ method: 'DELETE', url, service: 's3' }),
+      },
+      { retries: 2, timeoutMs: REQUEST_TIMEOUT_MS }
+    );
+    if (!response.ok && response.status !== 404) {
+      throw new Error(`Media delete failed: ${response.status}`);
+    }
+    await prisma.mediaObject.deleteMany({ where: { key } });
+  }
+
+  /**
+   * One page of the bucket's objects, in key order, and the token for the
+   * next page (null on the last)
+   */
+  static async list(
+    continuationToken?: string
+  ): Promise<{ objects: ListedObject[]; nextToken: string | null }> {
+    const url = bucketUrl({
+      'list-type': '2',
+      'max-keys': '1000',
+      ...(continuationToken && { 'continuation-token': continuationToken }),
+    });
+    const response = await upstreamFetch(
+      's3',
+      url,
+      {
+        headers: signHeaders({ method: 'GET', url, service: 's3' }),
+      },
+      { retries: 2, timeoutMs: REQUEST_TIMEOUT_MS }
+    );
+    if (!response.ok) {
+      throw new Error(`Media list failed: ${response.status}`);
+    }
+    const xml = await response.text();
+    const objects = (xml.match(/<Contents>[\s\S]*?<\/Contents>/g) ?? []).map(
+      entry => ({
+        key: xmlValue(entry, 'Key')!,
+        sizeBytes: parseInt(xmlValue(entry, 'Size') ?? '0'),
+        lastModified: new Date(xmlValue(entry, 'LastModified')!),
+      })
+    );
+    return {
+      objects,
+      nextToken:
+        xmlValue(xml, 'IsTruncated') === 'true'
+          ? xmlValue(xml, 'NextContinuationToken')
+          : null,
+    };
+  }
+
+  /**
+   * Set the bucket's lifecycle rules: raw uploads expire after
+   * `uploadExpiryDays` (a backstop for the media GC), and incomplete
+   * multipart uploads are aborted after a day. Replaces any rules set
+   * before.
+   */
+  static async setLifecycle(uploadExpiryDays: number): Promise<void> {
+    const body = Buffer.from(
+      '<?xml version="1.0" encoding="UTF-8"?>' +
+        '<LifecycleConfiguration>' +
+        '<Rule><ID>expire-raw-uploads</ID><Status>Enabled</Status>' +
+        `<Filter><Prefix>${UPLOADS_PREFIX}</Prefix></Filter>` +
+        `<Expiration><Days>${uploadExpiryDays}</Days></Expiration>` +
+        '</Rule>' +
+        '<Rule><ID>abort-incomplete-multipart</ID><Status>Enabled</Status>' +
+        '<Filter><Prefix></Prefix></Filter>' +
+        '<AbortIncompleteMultipartUpload>' +
+        '<DaysAfterInitiation>1</DaysAfterInitiation>' +
+        '</AbortIncompleteMultipartUpload>' +
+        '</Rule>' +
+        '</LifecycleConfiguration>',
+      'utf8'
+    );
+    const url = bucketUrl({ lifecycle: '' });
+    const response = await upstreamFetch(
+      's3',
+      url,
+      {
+        method: 'PUT',
+        headers: signHeaders({
+          method: 'PUT',
+          url,
+          service: 's3',
+          body,
+          headers: {
+            'content-type': 'application/xml',
+            // S3 requires it for lifecycle configuration
+            'content-md5': createHash('md5').update(body).digest('base64'),
+          },
+        }),
+        body,
+      },
+      { retries: 2, timeoutMs: REQUEST_TIMEOUT_MS }
+    );
+    if (!response.ok) {
+      throw new Error(`Media lifecycle update failed: ${response.status}`);
+    }
+  }
+}
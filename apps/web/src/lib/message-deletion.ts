@@ -0,0 +1,119 @@
+/**
+ * Message Deletion
+ * Senders can unsend a message within MESSAGE_UNSEND_WINDOW_MINUTES of
+ * sending it. The text is cleared from the signal, which keeps a tombstone
+ * (messageDeletedAt) so both sides see that something was removed, and
+ * the deletion is published on both people's message channel for a socket
+ * server to push. Unsending can't hide abuse: an encrypted copy is kept
+ * for moderators only, and deleted by the data retention job after
+ * RETENTION_DELETED_MESSAGES_DAYS (see lib/data-retention).
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { EventBus } from './event-bus';
+
+export const UNSEND_WINDOW_MINUTES = parseInt(
+  process.env.MESSAGE_UNSEND_WINDOW_MINUTES || '60'
+);
+
+export const messageChannel = (userId: string) => `messages:user:${userId}`;
+
+export type UnsendResult =
+  | { status: 'deleted'; deletedAt: Date }
+  | { status: 'already_deleted' }
+  | { status: 'window_closed' }
+  | { status: 'not_found' };
+
+export interface MessageDeletedEvent {
+  type: 'message_deleted';
+  signalId: string;
+  fromUserId: string;
+  toUserId: string;
+  deletedAt: string;
+}
+
+export class MessageDeletion {
+  /**
+   * Unsend the message the user sent with a signal
+   */
+  static async unsend(signalId: string, userId: string): Promise<UnsendResult> {
+    const signal = await prisma.signal.findFirst({
+      where: { id: signalId, fromUserId: userId, deletedAt: null },
+    });
+    if (!signal || (signal.message === null && !signal.messageDeletedAt)) {
+      return { status: 'not_found' };
+    }
+    if (signal.messageDeletedAt) {
+      return { status: 'already_deleted' };
+    }
+    if (
+      signal.sentAt.getTime() + UNSEND_WINDOW_MINUTES * 60 * 1000 <
+      Date.now()
+    ) {
+      return { status: 'window_closed' };
+    }
+
+    const deletedAt = new Date();
+    const [cleared] = await prisma.$transaction([
+      // Conditional, so two requests can't both keep a copy
+      prisma.signal.updateMany({
+        where: { id: signalId, messageDeletedAt: null },
+        data: {
+          message: null,
+          messageDeletedAt: deletedAt,
+          scamScore: null,
+          scamFlags: Prisma.DbNull,
+        },
+      }),
+      prisma.deletedMessage.upsert({
+        where: { signalId },
+        create: {
+          signalId,
+          senderId: signal.fromUserId,
+          recipientId: signal.toUserId,
+          body: signal.message!,
+          sentAt: signal.sentAt,
+          deletedAt,
+        },
+        update: {},
+      }),
+    ]);
+    if (cleared.count === 0) {
+      return { status: 'already_deleted' };
+    }
+
+    const event: MessageDeletedEvent = {
+      type: 'message_deleted',
+      signalId,
+      fromUserId: signal.fromUserId,
+      toUserId: signal.toUserId,
+      deletedAt: deletedAt.toISOString(),
+    };
+    const data = JSON.stringify(event);
+    await redis
+      .multi()
+      .publish(messageChannel(signal.fromUserId), data)
+      .publish(messageChannel(signal.toUserId), data)
+      .exec();
+    await EventBus.publish('message.deleted', {
+      signalId,
+      senderId: signal.fromUserId,
+      recipientId: signal.toUserId,
+    });
+    return { status: 'deleted', deletedAt };
+  }
+
+  /**
+   * Moderation copies of what `senderId` unsent to `recipientId` (or to
+   * anyone), newest first
+   */
+  static async copiesFor(senderId: string, recipientId?: string, take = 20) {
+    return prisma.deletedMessage.findMany({
+      where: { senderId, ...(recipientId && { recipientId }) },
+      orderBy: { deletedAt: 'desc' },
+      take,
+    });
+  }
+}
@@ -0,0 +1,298 @@
+/**
+ * Message Requests
+ * Users can opt in (messageRequests) to have the first message from a new
+ * match held as a request: it waits in their requests until they accept
+ * it, and the sender can't write again until then. Every message is
+ * checked before it's delivered (gate), whether sent from the app (see
+ * lib/match-messages) or scheduled; when a request is accepted, the
+ * held message is handed to it on both people's message channels.
+ * Replying counts as accepting. Declines are internal signals: a sender's
+ * share of declined requests lowers their discovery exposure, and a burst
+ * of declines trips abuse detection.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { AbuseDetection } from './abuse-detection';
+import { EventBus } from './event-bus';
+import { LinkPreviews } from './link-previews';
+import { messageChannel } from './message-deletion';
+import { counter } from './metrics';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const LOOKBACK_DAYS = 90;
+// Fewer answered requests than this says nothing
+const MIN_ANSWERED = 5;
+const LIST_LIMIT = 100;
+
+export type GateResult =
+  // Deliver the message as usual
+  | { status: 'deliver' }
+  // Held as a request; don't deliver it
+  | { status: 'held'; requestId: string }
+  // The sender's request is still waiting; refuse the message
+  | { status: 'pending'; requestId: string }
+  // The recipient declined; the conversation stays closed
+  | { status: 'declined' }
+  | { status: 'not_found' };
+
+export type RespondResult =
+  | { status: 'accepted' | 'declined'; matchId: string }
+  | { status: 'already_answered' }
+  | { status: 'not_found' };
+
+export interface MessageRequestEvent {
+  type: 'message_request' | 'message_request_accepted';
+  requestId: string;
+  matchId: string;
+  fromUserId: string;
+  // On acceptance, the held message to deliver, sent by the requester
+  text?: string;
+}
+
+const requestCounter = counter(
+  'aurum_message_requests_total',
+  'First messages held as requests, and how they were answered'
+);
+
+async function publish(userId: string, event: MessageRequestEvent) {
+  await redis.publish(messageChannel(userId), JSON.stringify(event));
+}
+
+export class MessageRequests {
+  /**
+   * Discovery exposure multiplier: 1 when unmeasured or never declined,
+   * down to 0.6 for someone whose requests are always declined
+   */
+  static exposure(declineRate: number | null): number {
+    return declineRate === null ? 1 : 1 - 0.4 * declineRate;
+  }
+
+  /**
+   * Decide what happens to a message the chat service is about to deliver
+   */
+  static async gate(
+    matchId: string,
+    senderId: string,
+    text: string
+  ): Promise<GateResult> {
+    const match = await prisma.match.findFirst({
+      where: {
+        id: matchId,
+        deletedAt: null,
+        OR: [{ user1Id: senderId }, { user2Id: senderId }],
+      },
+      include: {
+        user1: { select: { id: true, messageRequests: true } },
+        user2: { select: { id: true, messageRequests: true } },
+      },
+    });
+    if (!match) {
+      return { status: 'not_found' };
+    }
+    const recipient = match.user1Id === senderId ? match.user2 : match.user1;
+
+    const existing = await prisma.messageRequest.findUnique({
+      where: { matchId },
+    });
+    if (existing?.status === 'pending') {
+      if (existing.senderId === senderId) {
+        return { status: 'pending', requestId: existing.id };
+      }
+      // The recipient wrote back
+      await MessageRequests.respond(existing.id, senderId, true);
+      return { status: 'deliver' };
+    }
+    if (existing?.status === 'declined') {
+      return { status: 'declined' };
+    }
+
+    // Only the very first message of a conversation is held
+    const recipientWrote =
+      match.user1Id === recipient.id
+        ? match.user1FirstMessageAt
+        : match.user2FirstMessageAt;
+    if (
+      existing ||
+      !recipient.messageRequests ||
+      recipientWrote ||
+      match.messageCount > 0
+    ) {
+      return { status: 'deliver' };
+    }
+
+    let request;
+    try {
+      request = await prisma.messageRequest.create({
+        data: {
+          matchId,
+          senderId,
+          recipientId: recipient.id,
+          message: text,
+        },
+      });
+    } catch (error) {
+      // A message from either side got there first; go by its request
+      if (
+        error instanceof Prisma.PrismaClientKnownRequestError &&
+        error.code === 'P2002'
+      ) {
+        return MessageRequests.gate(matchId, senderId, text);
+      }
+      throw error;
+    }
+    requestCounter.inc({ outcome: 'held' });
+    await publish(recipient.id, {
+      type: 'message_request',
+      requestId: request.id,
+      matchId,
+      fromUserId: senderId,
+    });
+    return { status: 'held', requestId: request.id };
+  }
+
+  /**
+   * The user's waiting requests, newest first
+   */
+  static async list(userId: string) {
+    const requests = await prisma.messageRequest.findMany({
+      where: {
+        recipientId: userId,
+        status: 'pending',
+        sender: { deletedAt: null },
+      },
+      include: {
+        sender: {
+          select: {
+            id: true,
+            handle: true,
+            displayName: true,
+            profileImage: true,
+          },
+        },
+      },
+      orderBy: { createdAt: 'desc' },
+      take: LIST_LIMIT,
+    });
+    return Promise.all(
+      requests.map(async request => ({
+        requestId: request.id,
+        matchId: request.matchId,
+        from: request.sender,
+        message: request.message,
+        // One failed preview mustn't fail the whole list
+        preview: request.message
+          ? await LinkPreviews.forMessage(request.message).catch(error => {
+              console.error('Error previewing a message request:', error);
+              return null;
+            })
+          : null,
+        sentAt: request.createdAt,
+      }))
+    );
+  }
+
+  /**
+   * Accept or decline a request sent to the user. An accepted request's
+   * held message is delivered, then dropped; a declined one's is dropped.
+   */
+  static async respond(
+    requestId: string,
+    userId: string,
+    accept: boolean
+  ): Promise<RespondResult> {
+    const request = await prisma.messageRequest.findFirst({
+      where: { id: requestId, recipientId: userId },
+    });
+    if (!request) {
+      return { status: 'not_found' };
+    }
+
+    const status = accept ? 'accepted' : 'declined';
+    const { count } = await prisma.messageRequest.updateMany({
+      where: { id: requestId, status: 'pending' },
+      data: {
+        status,
+        respondedAt: new Date(),
+        ...(!accept && { message: null }),
+      },
+    });
+    if (count === 0) {
+      return { status: 'already_answered' };
+    }
+
+    if (accept) {
+      // Deliver the held message before dropping it. If the publish
+      // fails, the request goes back to pending with its message, so
+      // accepting again retries the delivery.
+      const event: MessageRequestEvent = {
+        type: 'message_request_accepted',
+        requestId,
+        matchId: request.matchId,
+        fromUserId: request.senderId,
+        ...(request.message !== null && { text: request.message }),
+      };
+      try {
+        await redis
+          .multi()
+          .publish(messageChannel(request.senderId), JSON.stringify(event))
+          .publish(messageChannel(userId), JSON.stringify(event))
+          .exec();
+      } catch (error) {
+        await prisma.messageRequest.update({
+          where: { id: requestId },
+          data: { status: 'pending', respondedAt: null },
+        });
+        throw error;
+      }
+      await prisma.messageRequest.update({
+        where: { id: requestId },
+        data: { message: null },
+      });
+    }
+    requestCounter.inc({ outcome: status });
+
+    await EventBus.publish(`message_request.${status}`, {
+      requestId,
+      matchId: request.matchId,
+      senderId: request.senderId,
+      recipientId: userId,
+    });
+    if (!accept) {
+      await AbuseDetection.recordDeclinedRequest(request.senderId);
+    }
+    await MessageRequests.refreshDeclineRate(request.senderId);
+
+    return { status, matchId: request.matchId };
+  }
+
+  /**
+   * Work out the share of the sender's recent answered requests that were
+   * declined, for discovery ranking
+   */
+  static async refreshDeclineRate(senderId: string): Promise<void> {
+    const answered = await prisma.messageRequest.groupBy({
+      by: ['status'],
+      where: {
+        senderId,
+        status: { in: ['accepted', 'declined'] },
+        respondedAt: { gte: new Date(Date.now() - LOOKBACK_DAYS * DAY_MS) },
+      },
+      _count: { _all: true },
+    });
+    const total = answered.reduce((sum, group) => sum + group._count._all, 0);
+    const declined =
+      answered.find(group => group.status === 'declined')?._count._all ?? 0;
+
+    await prisma.user.update({
+      where: { id: senderId },
+      data: {
+        declineRate:
+          total >= MIN_ANSWERED
+            ? Math.round((declined / total) * 100) / 100
+            : null,
+      },
+    });
+  }
+}
@@ -0,0 +1,194 @@
+/**
+ * Prometheus Metrics
+ * Minimal in-process metrics registry with Prometheus text exposition,
+ * scraped from GET /api/metrics
+ */
+
+type Labels = Record<string, string>;
+
+interface Metric {
+  name: string;
+  help: string;
+  type: 'counter' | 'gauge' | 'histogram';
+  render(): string[];
+}
+
+declare global {
+  var metricsRegistry: undefined | Map<string, Metric>;
+}
+
+// Shared across route bundles so every handler reports into one registry
+const registry: Map<string, Metric> =
+  globalThis.metricsRegistry ?? new Map<string, Metric>();
+globalThis.metricsRegistry = registry;
+
+function escapeLabelValue(value: string): string {
+  return value
+    .replace(/\\/g, '\\\\')
+    .replace(/"/g, '\\"')
+    .replace(/\n/g, '\\n');
+}
+
+function labelKey(labels: Labels = {}): string {
+  return Object.keys(labels)
+    .sort()
+    .map(key => `${key}="${escapeLabelValue(String(labels[key]))}"`)
+    .join(',');
+}
+
+function series(name: string, key: string, value: number): string {
+  return key ? `${name}{${key}} ${value}` : `${name} ${value}`;
+}
+
+export class Counter implements Metric {
+  readonly type = 'counter';
+  private values = new Map<string, number>();
+
+  constructor(
+    readonly name: string,
+    readonly help: string
+  ) {}
+
+  inc(labels?: Labels, amount = 1): void {
+    const key = labelKey(labels);
+    this.values.set(key, (this.values.get(key) || 0) + amount);
+  }
+
+  render(): string[] {
+    return Array.from(this.values, ([key, value]) =>
+      series(this.name, key, value)
+    );
+  }
+}
+
+export class Gauge implements Metric {
+  readonly type = 'gauge';
+  private values = new Map<string, number>();
+
+  constructor(
+    readonly name: string,
+    readonly help: string
+  ) {}
+
+  set(value: number, labels?: Labels): void {
+    this.values.set(labelKey(labels), value);
+  }
+
+  inc(labels?: Labels, amount = 1): void {
+    const key = labelKey(labels);
+    this.values.set(key, (this.values.get(key) || 0) + amount);
+  }
+
+  dec(labels?: Labels, amount = 1): void {
+    this.inc(labels, -amount);
+  }
+
+  render(): string[] {
+    return Array.from(this.values, ([key, value]) =>
+      series(this.name, key, value)
+    );
+  }
+}
+
+export class Histogram implements Metric {
+  readonly type = 'histogram';
+  private data = new Map<
+    string,
+    { buckets: number[]; sum: number; count: number }
+  >();
+
+  constructor(
+    readonly name: string,
+    readonly help: string,
+    private readonly bounds: number[] = [
+      0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+    ]
+  ) {}
+
+  observe(value: number, labels?: Labels): void {
+    const key = labelKey(labels);
+    let entry = this.data.get(key);
+    if (!entry) {
+      entry = { buckets: this.bounds.map(() => 0), sum: 0, count: 0 };
+      this.data.set(key, entry);
+    }
+
+    this.bounds.forEach((bound, i) => {
+      if (value <= bound) {
+        entry!.buckets[i]++;
+      }
+    });
+    entry.sum += value;
+    entry.count++;
+  }
+
+  /**
+   * Start a timer; calling the returned function records elapsed seconds
+   */
+  startTimer(labels?: Labels): (extraLabels?: Labels) => number {
+    const start = process.hrtime.bigint();
+    return extraLabels => {
+      const seconds = Number(process.hrtime.bigint() - start) / 1e9;
+      this.observe(seconds, { ...labels, ...extraLabels });
+      return seconds;
+    };
+  }
+
+  render(): string[] {
+    const lines: string[] = [];
+    this.data.forEach((entry, key) => {
+      const prefix = key ? `${key},` : '';
+      this.bounds.forEach((bound, i) => {
+        lines.push(
+          `${this.name}_bucket{${prefix}le="${bound}"} ${entry.buckets[i]}`
+        );
+      });
+      lines.push(`${this.name}_bucket{${prefix}le="+Inf"} ${entry.count}`);
+      lines.push(series(`${this.name}_sum`, key, entry.sum));
+      lines.push(series(`${this.name}_count`, key, entry.count));
+    });
+    return lines;
+  }
+}
+
+/**
+ * Register a metric once; repeated registrations (e.g. hot reload) return
+ * the existing instance
+ */
+function register<T extends Metric>(metric: T): T {
+  const existing = registry.get(metric.name);
+  if (existing) {
+    return existing as T;
+  }
+  registry.set(metric.name, metric);
+  return metric;
+}
+
+export function counter(name: string, help: string): Counter {
+  return register(new Counter(name, help));
+}
+
+export function gauge(name: string, help: string): Gauge {
+  return register(new Gauge(name, help));
+}
+
+export function histogram(
+  name: string,
+  help: string,
+  bounds?: number[]
+): Histogram {
+  return register(new Histogram(name, help, bounds));
+}
+
+/**
+ * Render every registered metric in Prometheus text format
+ */
+export function renderMetrics(): string {
+  const lines: string[] = [];
+  registry.forEach(metric => {
+    lines.push(`# HELP ${metric.name} ${metric.help}`);
+    lines.push(`# TYPE ${metric.name} ${metric.type}`);
+    lines.push(...metric.render());
+  });
+  return lines.join('\n') + '\n';
+}
@@ -0,0 +1,143 @@
+/**
+ * ML API Health Monitor
+ * Probes the ML API's health and queue depth on demand, caches the result
+ * for request-path checks, and publishes it as Prometheus gauges
+ */
+
+import { mlServiceClient } from './ml-service-client';
+import { gauge } from './metrics';
+
+export type MLHealthStatus = 'healthy' | 'degraded' | 'unhealthy';
+
+export interface MLHealthSnapshot {
+  status: MLHealthStatus;
+  latencyMs: number | null;
+  queue: {
+    active: number;
+    waiting: number;
+    failed: number;
+  } | null;
+  checkedAt: string;
+  error?: string;
+}
+
+// How long a probe result is trusted before the next request re-probes
+const PROBE_TTL_MS = parseInt(process.env.ML_HEALTH_PROBE_TTL_MS || '15000');
+
+// Queue depth at which the ML API is considered degraded
+const QUEUE_DEGRADED_THRESHOLD = parseInt(
+  process.env.ML_QUEUE_DEGRADED_THRESHOLD || '100'
+);
+
+const upGauge = gauge(
+  'aurum_ml_api_up',
+  'Whether the ML API health probe succeeded (1) or not (0)'
+);
+const statusGauge = gauge(
+  'aurum_ml_api_status',
+  'ML API health status (1 for the current status label)'
+);
+const latencyGauge = gauge(
+  'aurum_ml_api_probe_latency_seconds',
+  'Latency of the last ML API health probe'
+);
+const queueGauge = gauge(
+  'aurum_ml_api_queue_depth',
+  'ML API scoring queue depth by job state'
+);
+
+let snapshot: MLHealthSnapshot | null = null;
+let inFlight: Promise<MLHealthSnapshot> | null = null;
+
+async function probe(): Promise<MLHealthSnapshot> {
+  const startTime = Date.now();
+
+  try {
+    const response = await mlServiceClient.getHealthDetails();
+    const latencyMs = Date.now() - startTime;
+
+    const queue = response?.queue
+      ? {
+          active: response.queue.active,
+          waiting: response.queue.waiting,
+          failed: response.queue.failed,
+        }
+      : null;
+
+    let status: MLHealthStatus = response ? response.status : 'unhealthy';
+    if (
+      status === 'healthy' &&
+      queue &&
+      queue.waiting >= QUEUE_DEGRADED_THRESHOLD
+    ) {
+      status = 'degraded';
+    }
+
+    return {
+      status,
+      latencyMs,
+      queue,
+      checkedAt: new Date().toISOString(),
+    };
+  } catch (error) {
+    return {
+      status: 'unhealthy',
+      latencyMs: null,
+      queue: null,
+      checkedAt: new Date().toISOString(),
+      error: error instanceof Error ? error.message : String(error),
+    };
+  }
+}
+
+function publish(result: MLHealthSnapshot): void {
+  upGauge.set(result.status === 'unhealthy' ? 0 : 1);
+  (['healthy', 'degraded', 'unhealthy'] as const).forEach(status => {
+    statusGauge.set(result.status === status ? 1 : 0, { status });
+  });
+  if (result.latencyMs !== null) {
+    latencyGauge.set(result.latencyMs / 1000);
+  }
+  if (result.queue) {
+    queueGauge.set(result.queue.active, { state: 'active' });
+    queueGauge.set(result.queue.waiting, { state: 'waiting' });
+    queueGauge.set(result.queue.failed, { state: 'failed' });
+  }
+}
+
+export class MLHealthMonitor {
+  /**
+   * Latest ML API health, re-probing when the cached result is stale.
+   * Concurrent callers share a single in-flight probe.
+   */
+  static async getHealth(force = false): Promise<MLHealthSnapshot> {
+    const fresh =
+      snapshot &&
+      Date.now() - new Date(snapshot.checkedAt).getTime() < PROBE_TTL_MS;
+    if (fresh && !force) {
+      return snapshot!;
+    }
+
+    if (!inFlight) {
+      inFlight = probe()
+        .then(result => {
+          snapshot = result;
+          publish(result);
+          return result;
+        })
+        .finally(() => {
+          inFlight = null;
+        });
+    }
+
+    return inFlight;
+  }
+
+  /**
+   * Whether ML-dependent paths (e.g. ML ranking) should be used right now
+   */
+  static async isAvailable(): Promise<boolean> {
+    const health = await this.getHealth();
+    return health.status !== 'unhealthy';
+  }
+}
@@ -0,0 +1,85 @@
+/**
+ * ML Model Version Routing
+ * Chooses which ML API model version serves a scoring request. A client can
+ * pin a version with the X-ML-Model-Version header; otherwise the
+ * `ml_model_version` feature flag assigns one (weighted for A/B tests).
+ */
+
+import { NextRequest } from 'next/server';
+import { MLServiceClient, mlServiceClient } from './ml-service-client';
+import { FeatureFlags } from './feature-flags';
+
+export const MODEL_VERSION_HEADER = 'x-ml-model-version';
+
+export const DEFAULT_MODEL_VERSION =
+  process.env.ML_DEFAULT_MODEL_VERSION || 'v2';
+
+export interface ModelVersionSelection {
+  version: string;
+  source: 'header' | 'flag' | 'default';
+}
+
+/**
+ * Version -> ML API base URL. Configured as JSON in ML_MODEL_ENDPOINTS, e.g.
+ * {"v2":"http://ml-api:3000/api/ml","v3":"http://ml-api-v3:3000/api/ml"}.
+ * The default version always falls back to ML_API_URL.
+ */
+function loadModelEndpoints(): Record<string, string> {
+  let endpoints: Record<string, string> = {};
+  try {
+    endpoints = JSON.parse(process.env.ML_MODEL_ENDPOINTS || '{}');
+  } catch (error) {
+    console.error('Invalid ML_MODEL_ENDPOINTS configuration:', error);
+  }
+  return endpoints;
+}
+
+const modelEndpoints = loadModelEndpoints();
+const clients = new Map<string, MLServiceClient>();
+
+/**
+ * Versions that can be routed to
+ */
+export function availableModelVersions(): string[] {
+  return Array.from(
+    new Set([DEFAULT_MODEL_VERSION, ...Object.keys(modelEndpoints)])
+  );
+}
+
+/**
+ * Resolve the model version for a request
+ */
+export async function selectModelVersion(
+  request: NextRequest,
+  userId?: string
+): Promise<ModelVersionSelection> {
+  const pinned = request.headers.get(MODEL_VERSION_HEADER);
+  if (pinned && availableModelVersions().includes(pinned)) {
+    return { version: pinned, source: 'header' };
+  }
+
+  const variant = await FeatureFlags.getVariant('ml_model_version', {
+    userId,
+  });
+  if (variant && availableModelVersions().includes(variant)) {
+    return { version: variant, source: 'flag' };
+  }
+
+  return { version: DEFAULT_MODEL_VERSION, source: 'default' };
+}
+
+/**
+ * ML service client for a model version
+ */
+export function getMLClientForVersion(version?: string): MLServiceClient {
+  if (!version || !modelEndpoints[version]) {
+    return mlServiceClient;
+  }
+
+  let client = clients.get(version);
+  if (!client) {
+    client = new MLServiceClient({ baseUrl: modelEndpoints[version] });
+    clients.set(version, client);
+  }
+  return client;
+}
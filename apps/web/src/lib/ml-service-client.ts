@@ -8,6 +8,7 @@ import {
   MLProcessingResult,
   MLValidationResult,
 } from './ml-models/model-integration';
+import { upstreamFetch } from './http-client';
 
 export interface MLServiceConfig {
   baseUrl: string;
@@ -93,6 +94,59 @@ export interface MLServiceProcessResponse {
   message: string;
 }
 
+export interface MLServiceDetectResponse {
+  status: 'success' | 'error';
+  data: {
+    width: number;
+    height: number;
+    format: string;
+    brightness: number;
+    sharpness: number;
+    faceCount: number;
+    faceBoxes: number[][];
+  };
+  message: string;
+}
+
+export interface MLServicePairScoresResponse {
+  status: 'success' | 'error';
+  data: {
+    scores: Array<{
+      id: string;
+      similarity: number;
+      compatibility: number;
+    }>;
+    processingTime: number;
+  };
+  message: string;
+}
+
+export interface MLSuggestionProfile {
+  bio: string | null;
+  vibe: string | null;
+  tags: string[];
+  prompts: { prompt: string; answer: string }[];
+}
+
+export interface MLSuggestionsRequest {
+  kind: 'icebreaker' | 'reply';
+  locale: string;
+  count: number;
+  // "viewer" is who the suggestions are for
+  profiles: { viewer: MLSuggestionProfile; match: MLSuggestionProfile };
+  // Oldest first
+  messages: { from: 'viewer' | 'match'; text: string }[];
+}
+
+export interface MLServiceSuggestionsResponse {
+  status: 'success' | 'error';
+  data: {
+    suggestions: string[];
+    model: string;
+  };
+  message: string;
+}
+
 /**
  * Client for the standalone ML API service
  */
@@ -164,6 +218,20 @@ export class MLServiceClient {
     }
   }
 
+  /**
+   * Raw health payload (including queue depth) from a single fast probe.
+   * Used by the health monitor, so it skips the usual retry/backoff.
+   */
+  async getHealthDetails(): Promise<MLServiceHealthResponse['data'] | null> {
+    const response = await this.makeRequest<MLServiceHealthResponse>(
+      '/health',
+      { method: 'GET' },
+      { timeout: 5000, retries: 1 }
+    );
+
+    return response.status === 'success' ? response.data : null;
+  }
+
   /**
    * Get model information and status
    */
@@ -253,6 +321,81 @@ export class MLServiceClient {
     }
   }
 
+  /**
+   * Run the ML service's face detector and photo inspection (no scoring)
+   */
+  async inspectImage(
+    imageBase64: string
+  ): Promise<MLServiceDetectResponse['data'] | null> {
+    try {
+      const response = await this.makeRequest<MLServiceDetectResponse>(
+        '/detect',
+        {
+          method: 'POST',
+          headers: {
+            'Content-Type': 'application/json',
+          },
+          body: JSON.stringify({ image: imageBase64 }),
+        }
+      );
+
+      return response.status === 'success' ? response.data : null;
+    } catch (error) {
+      console.error('ML image inspection failed:', error);
+      return null;
+    }
+  }
+
+  /**
+   * Score viewer/candidate embedding pairs in a single ML API request
+   */
+  async scorePairs(
+    pairs: Array<{ id: string; viewer: number[]; candidate: number[] }>
+  ): Promise<MLServicePairScoresResponse['data']['scores']> {
+    const response = await this.makeRequest<MLServicePairScoresResponse>(
+      '/score-pairs',
+      {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ pairs }),
+      },
+      { timeout: 5000, retries: 1 }
+    );
+
+    if (response.status !== 'success') {
+      throw new Error(response.message || 'Pair scoring failed');
+    }
+
+    return response.data.scores;
+  }
+
+  /**
+   * Conversation openers or replies from the ML API's LLM endpoint
+   */
+  async generateSuggestions(
+    request: MLSuggestionsRequest
+  ): Promise<MLServiceSuggestionsResponse['data']> {
+    const response = await this.makeRequest<MLServiceSuggestionsResponse>(
+      '/llm/suggestions',
+      {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(request),
+      },
+      { timeout: 15000, retries: 1 }
+    );
+
+    if (response.status !== 'success') {
+      throw new Error(response.message || 'Suggestion generation failed');
+    }
+
+    return response.data;
+  }
+
   /**
    * Batch process multiple images
    */
@@ -428,44 +571,23 @@ export class MLServiceClient {
    */
   private async makeRequest<T>(
     endpoint: string,
-    options: RequestInit
+    options: RequestInit,
+    overrides: Partial<Pick<MLServiceConfig, 'timeout' | 'retries'>> = {}
   ): Promise<T> {
     const url = `${this.config.baseUrl}${endpoint}`;
-    let lastError: Error | null = null;
-
-    for (let attempt = 1; attempt <= this.config.retries; attempt++) {
-      try {
-        const controller = new AbortController();
-        const timeoutId = setTimeout(
-          () => controller.abort(),
-          this.config.timeout
-        );
-
-        const response = await fetch(url, {
-          ...options,
-          signal: controller.signal,
-        });
+    const { timeout, retries } = { ...this.config, ...overrides };
 
-        clearTimeout(timeoutId);
+    // The config counts attempts; the client counts retries after the first
+    const response = await upstreamFetch('ml-api', url, options, {
+      timeoutMs: timeout,
+      retries: Math.max(retries - 1, 0),
+    });
 
-        if (!response.ok) {
-          throw new Error(`HTTP ${response.status}: ${response.statusText}`);
-        }
-
-        return await response.json();
-      } catch (error) {
-        lastError = error as Error;
-        console.warn(`ML service request attempt ${attempt} failed:`, error);
-
-        if (attempt < this.config.retries) {
-          // Exponential backoff
-          const delay = Math.min(1000 * Math.pow(2, attempt - 1), 5000);
-          await new Promise(resolve => setTimeout(resolve, delay));
-        }
-      }
+    if (!response.ok) {
+      throw new Error(`HTTP ${response.status}: ${response.statusText}`);
     }
 
-    throw lastError || new Error('ML service request failed');
+    return await response.json();
   }
 }
 
@@ -0,0 +1,136 @@
+/**
+ * NFT Ownership
+ * Which eligible collection a wallet holds, read from chain. The shared
+ * instance accepts any of the university collections below; a campus
+ * instance can gate on its own collection instead. Answers are cached per
+ * wallet and gate for a while, as holdings change rarely.
+ */
+
+import { Tenant } from '@prisma/client';
+import { createPublicClient, http } from 'viem';
+import { mainnet } from 'viem/chains';
+import { createCache } from './cache';
+import { httpClient } from './http-client';
+
+const publicClient = createPublicClient({
+  chain: mainnet,
+  // Assumes ALCHEMY_URL is in .env; its path holds the API key
+  transport: http(process.env.ALCHEMY_URL, {
+    fetchFn: httpClient('rpc', { logPath: false }),
+  }),
+});
+
+const erc721Abi = [
+  {
+    name: 'balanceOf',
+    type: 'function',
+    inputs: [{ name: 'owner', type: 'address' }],
+    outputs: [{ name: '', type: 'uint256' }],
+    stateMutability: 'view',
+  },
+] as const;
+
+export interface EligibleNft {
+  name: string;
+  contractAddress: string;
+  description: string;
+  requiredAmount: number;
+}
+
+export const ELIGIBLE_NFTS: EligibleNft[] = [
+  {
+    name: 'Bangkok University Student ID',
+    contractAddress: '0x1234567890123456789012345678901234567890', // Replace with actual address
+    description: 'Official Bangkok University NFT Student ID',
+    requiredAmount: 1,
+  },
+  {
+    name: 'Chulalongkorn University Pass',
+    contractAddress: '0x2345678901234567890123456789012345678901', // Replace with actual address
+    description: 'Chulalongkorn University Alumni/Student NFT',
+    requiredAmount: 1,
+  },
+  {
+    name: 'Thammasat Gold Member',
+    contractAddress: '0x3456789012345678901234567890123456789012', // Replace with actual address
+    description: 'Thammasat University Premium Member NFT',
+    requiredAmount: 1,
+  },
+];
+
+// Holdings change rarely; a wallet's answer is reused for this long
+const nftOwnershipCache = createCache<string | null>('nft-ownership', {
+  ttlSeconds: parseInt(process.env.NFT_OWNERSHIP_CACHE_SECONDS || '600'),
+});
+
+/**
+ * Name of the first eligible collection the wallet holds, or null. Throws
+ * when a lookup failed and nothing was found, so an RPC outage isn't
+ * cached as "holds nothing".
+ */
+async function findEligibleNft(
+  wallet: `0x${string}`,
+  eligibleNfts: EligibleNft[]
+): Promise<string | null> {
+  let failure: unknown = null;
+  for (const nft of eligibleNfts) {
+    try {
+      const balance = await publicClient.readContract({
+        address: nft.contractAddress as `0x${string}`,
+        abi: erc721Abi,
+        functionName: 'balanceOf',
+        args: [wallet],
+      });
+
+      if (balance >= nft.requiredAmount) {
+        return nft.name;
+      }
+    } catch (err) {
+      console.warn(`Could not check balance for ${nft.name}:`, err);
+      failure = err; // Continue to the next NFT collection
+    }
+  }
+  if (failure) {
+    throw failure;
+  }
+  return null;
+}
+
+export class NftOwnership {
+  /**
+   * The collections that grant access on `tenant`'s instance
+   */
+  static eligibleFor(tenant: Tenant | null): EligibleNft[] {
+    return tenant?.gateContractAddress
+      ? [
+          {
+            name: tenant.gateCollectionName || tenant.name,
+            contractAddress: tenant.gateContractAddress,
+            description: `${tenant.name} membership NFT`,
+            requiredAmount: 1,
+          },
+        ]
+      : ELIGIBLE_NFTS;
+  }
+
+  /**
+   * The eligible collection `wallet` holds on `tenant`'s instance, or
+   * null. `fresh` skips the cached answer (and replaces it). Throws if
+   * the chain couldn't be read.
+   */
+  static async check(
+    wallet: string,
+    tenant: Tenant | null,
+    { fresh = false }: { fresh?: boolean } = {}
+  ): Promise<EligibleNft | null> {
+    const eligibleNfts = NftOwnership.eligibleFor(tenant);
+    const key = `${tenant?.id ?? 'default'}:${wallet.toLowerCase()}`;
+    const load = () => findEligibleNft(wallet as `0x${string}`, eligibleNfts);
+
+    if (fresh) {
+      await nftOwnershipCache.invalidate(key);
+    }
+    const collection = await nftOwnershipCache.getOrLoad(key, load);
+    return eligibleNfts.find(nft => nft.name === collection) ?? null;
+  }
+}
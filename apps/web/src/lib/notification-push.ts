@@ -0,0 +1,168 @@
+/**
+ * Notification Push
+ * Pushes notifications to a user's phone (World App, registered devices
+ * and linked Telegram/LINE chats). Low-priority notifications such as new
+ * likes are not pushed one by one: they're summed up in a digest on a fixed
+ * schedule, moved into the user's waking hours.
+ */
+
+import prisma from './prisma';
+import { sendPushNotification } from './push-notifications';
+import { Devices } from './devices';
+import { BRIDGED_TYPES, ChatBridge } from './chat-bridge';
+import { Scheduler, ScheduledTask } from './scheduler';
+import { NotificationTemplates } from './notification-templates';
+import { nextWakingTime, WakingHours, WakingPreferences } from './waking-hours';
+
+// Notification types that are only pushed as part of a digest
+export const DIGEST_TYPES = ['new_like'];
+
+export interface PushEvent {
+  type: string;
+  // Template event, when it differs from the type
+  template?: string;
+  variables?: Record<string, string | number>;
+  path?: string;
+}
+
+interface DigestJob {
+  userId: string;
+}
+
+const DIGEST_INTERVAL_HOURS = parseInt(
+  process.env.NOTIFICATION_DIGEST_INTERVAL_HOURS || '4'
+);
+
+const HOUR_MS = 60 * 60 * 1000;
+
+/**
+ * The first digest slot after `from` that falls in the user's waking
+ * hours. Slots sit on fixed boundaries, so everything queued in one window
+ * shares a slot.
+ */
+export function nextDigestAt(
+  from: Date,
+  preferences: WakingPreferences
+): Date {
+  const interval = DIGEST_INTERVAL_HOURS * HOUR_MS;
+  const slot = new Date((Math.floor(from.getTime() / interval) + 1) * interval);
+  return nextWakingTime(slot, preferences);
+}
+
+const digestJobId = (userId: string, runAt: Date) =>
+  `digest-${userId}-${runAt.getTime()}`;
+
+export class NotificationPush {
+  /**
+   * Push to every channel the user can receive on, in their language.
+   * Never throws.
+   */
+  static async send(userId: string, event: PushEvent): Promise<void> {
+    try {
+      const user = await prisma.user.findUnique({
+        where: { id: userId },
+        select: { walletAddress: true, status: true, locale: true },
+      });
+      if (!user || user.status !== 'active') {
+        return;
+      }
+
+      const template = event.template || event.type;
+      const copy = await NotificationTemplates.render(
+        template,
+        'push',
+        user.locale,
+        event.variables
+      );
+      const notification = {
+        title: copy.title,
+        message: copy.body,
+        path: event.path,
+      };
+      await sendPushNotification([user.walletAddress], notification);
+      await Devices.push([userId], { ...notification, type: event.type });
+
+      if (BRIDGED_TYPES.includes(event.type)) {
+        const chatCopy = await NotificationTemplates.render(
+          template,
+          'chat',
+          user.locale,
+          event.variables
+        );
+        await ChatBridge.send(userId, {
+          title: chatCopy.title,
+          message: chatCopy.body,
+          path: event.path,
+        });
+      }
+    } catch (error) {
+      console.error(`Error pushing ${event.type} notification:`, error);
+    }
+  }
+
+  /**
+   * Make sure a digest is scheduled for the user's next slot. Never
+   * throws.
+   */
+  static async queueDigest(userId: string): Promise<void> {
+    try {
+      const preferences = await WakingHours.preferences(userId);
+      const runAt = nextDigestAt(new Date(), preferences);
+      // One job per slot: repeat calls in the same window are no-ops
+      await Scheduler.scheduleAt(
+        notificationDigest.name,
+        { userId },
+        runAt,
+        digestJobId(userId, runAt)
+      );
+    } catch (error) {
+      console.error('Error queueing notification digest:', error);
+    }
+  }
+
+  /**
+   * Push one summary of the unread low-priority notifications that arrived
+   * since the last digest
+   */
+  static async sendDigest(
+    userId: string
+  ): Promise<{ count: number } | { skipped: string }> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { lastDigestAt: true },
+    });
+    if (!user) {
+      return { skipped: 'user_not_found' };
+    }
+
+    const count = await prisma.notification.count({
+      where: {
+        userId,
+        type: { in: DIGEST_TYPES },
+        readAt: null,
+        ...(user.lastDigestAt && { createdAt: { gt: user.lastDigestAt } }),
+      },
+    });
+    if (count === 0) {
+      // Already seen in the app
+      return { skipped: 'nothing_new' };
+    }
+
+    await prisma.user.update({
+      where: { id: userId },
+      data: { lastDigestAt: new Date() },
+    });
+    await NotificationPush.send(userId, {
+      type: 'new_like_digest',
+      template: count === 1 ? 'new_like' : 'new_like_digest',
+      variables: { count },
+      path: '/discover',
+    });
+    return { count };
+  }
+}
+
+export const notificationDigest: ScheduledTask<DigestJob> = {
+  name: 'notification-digest',
+  run: ({ userId }) => NotificationPush.sendDigest(userId),
+};
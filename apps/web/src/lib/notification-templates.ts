@@ -0,0 +1,742 @@
+/**
+ * Notification Templates
+ * Copy for every notification, by event type, channel and locale. The
+ * defaults live here; admins can override any of them, and overrides are
+ * picked up within a minute. Placeholders like {{count}} are filled from
+ * the variables passed when rendering.
+ */
+
+import { NotificationTemplate } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+
+export const TEMPLATE_CHANNELS = ['in_app', 'push', 'chat', 'email'] as const;
+
+export type TemplateChannel = (typeof TEMPLATE_CHANNELS)[number];
+
+export const TEMPLATE_LOCALES = ['en', 'th'] as const;
+
+export type TemplateLocale = (typeof TEMPLATE_LOCALES)[number];
+
+export const DEFAULT_LOCALE: TemplateLocale = 'en';
+
+// Title doubles as the email subject
+export interface TemplateCopy {
+  title: string;
+  body: string;
+}
+
+type TemplateVariables = Record<string, string | number>;
+
+type LocalizedCopy = Record<TemplateLocale, TemplateCopy>;
+
+// Channels without their own copy use the next one along
+const CHANNEL_FALLBACKS: Record<TemplateChannel, TemplateChannel[]> = {
+  in_app: [],
+  push: ['in_app'],
+  chat: ['push', 'in_app'],
+  email: [],
+};
+
+const OVERRIDE_CACHE_MS = 60 * 1000;
+
+const DEFAULT_TEMPLATES: Record<
+  string,
+  Partial<Record<TemplateChannel, LocalizedCopy>>
+> = {
+  match: {
+    in_app: {
+      en: {
+        title: "It's a match!",
+        body: 'You both liked each other. Say hi!',
+      },
+      th: {
+        title: 'แมตช์แล้ว!',
+        body: 'คุณทั้งคู่ถูกใจกันและกัน ทักทายกันเลย!',
+      },
+    },
+  },
+  new_like: {
+    in_app: {
+      en: {
+        title: 'Someone likes you',
+        body: 'Keep swiping to find out who.',
+      },
+      th: {
+        title: 'มีคนถูกใจคุณ',
+        body: 'ปัดต่อไปเพื่อดูว่าเป็นใคร',
+      },
+    },
+  },
+  new_super_like: {
+    in_app: {
+      en: {
+        title: 'Someone super-liked you',
+        body: 'Keep swiping to find out who.',
+      },
+      th: {
+        title: 'มีคนซูเปอร์ไลก์คุณ',
+        body: 'ปัดต่อไปเพื่อดูว่าเป็นใคร',
+      },
+    },
+  },
+  new_like_digest: {
+    push: {
+      en: {
+        title: '{{count}} people like you',
+        body: 'Keep swiping to find out who.',
+      },
+      th: {
+        title: 'มี {{count}} คนถูกใจคุณ',
+        body: 'ปัดต่อไปเพื่อดูว่าเป็นใคร',
+      },
+    },
+  },
+  signal: {
+    in_app: {
+      en: {
+        title: 'You received a secret signal',
+        body: 'Someone sent you a secret signal.',
+      },
+      th: {
+        title: 'คุณได้รับสัญญาณลับ',
+        body: 'มีคนส่งสัญญาณลับถึงคุณ',
+      },
+    },
+  },
+  signal_mutual: {
+    in_app: {
+      en: {
+        title: 'Mutual signal! A profile was revealed',
+        body: 'You both sent each other a signal.',
+      },
+      th: {
+        title: 'สัญญาณตรงกัน! โปรไฟล์ถูกเปิดเผยแล้ว',
+        body: 'คุณทั้งคู่ส่งสัญญาณถึงกัน',
+      },
+    },
+  },
+  content_removed_photo: {
+    in_app: {
+      en: {
+        title: 'Your photo was removed',
+        body: 'It broke our guidelines: {{policy}}.',
+      },
+      th: {
+        title: 'รูปภาพของคุณถูกลบ',
+        body: 'รูปภาพละเมิดแนวทางของเรา: {{policy}}',
+      },
+    },
+  },
+  content_removed_bio: {
+    in_app: {
+      en: {
+        title: 'Your bio was removed',
+        body: 'It broke our guidelines: {{policy}}.',
+      },
+      th: {
+        title: 'คำแนะนำตัวของคุณถูกลบ',
+        body: 'คำแนะนำตัวละเมิดแนวทางของเรา: {{policy}}',
+      },
+    },
+  },
+  content_removed_prompt: {
+    in_app: {
+      en: {
+        title: 'One of your prompt answers was removed',
+        body: 'It broke our guidelines: {{policy}}.',
+      },
+      th: {
+        title: 'คำตอบคำถามในโปรไฟล์ของคุณถูกลบ',
+        body: 'คำตอบละเมิดแนวทางของเรา: {{policy}}',
+      },
+    },
+  },
+  guidelines_warning: {
+    push: {
+      en: {
+        title: 'Community guidelines',
+        body: 'Your account was reported and reviewed. Further violations may lead to a ban.',
+      },
+      th: {
+        title: 'แนวทางชุมชน',
+        body: 'บัญชีของคุณถูกรายงานและได้รับการตรวจสอบแล้ว การละเมิดซ้ำอาจทำให้บัญชีถูกระงับ',
+      },
+    },
+  },
+  score_up: {
+    push: {
+      en: {
+        title: 'Your score went up',
+        body: 'Your score just passed {{threshold}}. Nice!',
+      },
+      th: {
+        title: 'คะแนนของคุณเพิ่มขึ้น',
+        body: 'คะแนนของคุณเพิ่งผ่าน {{threshold}} เยี่ยมมาก!',
+      },
+    },
+  },
+  score_down: {
+    push: {
+      en: {
+        title: 'Time for fresh photos?',
+        body: 'Your score dipped below {{threshold}}. New photos could help.',
+      },
+      th: {
+        title: 'ถึงเวลาเปลี่ยนรูปใหม่หรือยัง?',
+        body: 'คะแนนของคุณลดลงต่ำกว่า {{threshold}} รูปใหม่อาจช่วยได้',
+      },
+    },
+  },
+  dunning_reminder: {
+    push: {
+      en: {
+        title: 'Payment failed',
+        body: 'Update your payment method to keep your Premium benefits.',
+      },
+      th: {
+        title: 'การชำระเงินไม่สำเร็จ',
+        body: 'อัปเดตวิธีการชำระเงินเพื่อรักษาสิทธิประโยชน์ Premium ของคุณ',
+      },
+    },
+  },
+  dunning_downgraded: {
+    push: {
+      en: {
+        title: 'Premium ended',
+        body: "Your subscription couldn't be renewed. You're now on Free.",
+      },
+      th: {
+        title: 'Premium สิ้นสุดแล้ว',
+        body: 'ไม่สามารถต่ออายุการสมัครสมาชิกได้ ตอนนี้คุณใช้แพ็กเกจฟรี',
+      },
+    },
+  },
+  travel_mode_ended: {
+    in_app: {
+      en: {
+        title: 'Travel mode ended',
+        body: "You're back to discovering people near you instead of {{city}}.",
+      },
+      th: {
+        title: 'โหมดเดินทางสิ้นสุดแล้ว',
+        body: 'ตอนนี้คุณกลับมาค้นหาคนใกล้ตัวแทน {{city}} แล้ว',
+      },
+    },
+  },
+  incoming_call: {
+    in_app: {
+      en: {
+        title: 'Incoming call',
+        body: 'Your match is calling you.',
+      },
+      th: {
+        title: 'สายเรียกเข้า',
+        body: 'แมตช์ของคุณกำลังโทรหาคุณ',
+      },
+    },
+  },
+  badge_early_adopter: {
+    in_app: {
+      en: {
+        title: 'New badge: Early adopter',
+        body: 'Thanks for being one of the first on Aurum!',
+      },
+      th: {
+        title: 'เหรียญใหม่: ผู้บุกเบิก',
+        body: 'ขอบคุณที่เป็นหนึ่งในผู้ใช้กลุ่มแรกของ Aurum!',
+      },
+    },
+  },
+  badge_event_attendee: {
+    in_app: {
+      en: {
+        title: 'New badge: Event attendee',
+        body: 'Thanks for coming along! It now shows on your profile.',
+      },
+      th: {
+        title: 'เหรียญใหม่: ผู้เข้าร่วมงาน',
+        body: 'ขอบคุณที่มาร่วมงาน! เหรียญนี้แสดงบนโปรไฟล์ของคุณแล้ว',
+      },
+    },
+  },
+  badge_verified: {
+    in_app: {
+      en: {
+        title: 'New badge: Verified',
+        body: 'Your photo is verified, so matches know it is really you.',
+      },
+      th: {
+        title: 'เหรียญใหม่: ยืนยันตัวตนแล้ว',
+        body: 'รูปของคุณได้รับการยืนยันแล้ว แมตช์จะรู้ว่าเป็นคุณจริงๆ',
+      },
+    },
+  },
+  badge_ten_matches: {
+    in_app: {
+      en: {
+        title: 'New badge: 10 matches',
+        body: "You've made 10 matches. Keep the conversations going!",
+      },
+      th: {
+        title: 'เหรียญใหม่: 10 แมตช์',
+        body: 'คุณแมตช์ครบ 10 คนแล้ว คุยกันต่อเลย!',
+      },
+    },
+  },
+  chat_link_prompt: {
+    chat: {
+      en: {
+        title: 'Aurum',
+        body: 'To get Aurum notifications here, open Settings > Notifications in the app and tap "Link".',
+      },
+      th: {
+        title: 'Aurum',
+        body: 'หากต้องการรับการแจ้งเตือนจาก Aurum ที่นี่ ให้เปิด การตั้งค่า > การแจ้งเตือน ในแอปแล้วแตะ "เชื่อมต่อ"',
+      },
+    },
+  },
+  chat_linked: {
+    chat: {
+      en: {
+        title: 'Aurum',
+        body: "You're all set! Matches and signals will show up here.",
+      },
+      th: {
+        title: 'Aurum',
+        body: 'เรียบร้อยแล้ว! การแมตช์และสัญญาณจะแสดงที่นี่',
+      },
+    },
+  },
+  privacy_export_ready: {
+    in_app: {
+      en: {
+        title: 'Your data export is ready',
+        body: 'Download it within {{days}} days.',
+      },
+      th: {
+        title: 'ข้อมูลของคุณพร้อมให้ดาวน์โหลดแล้ว',
+        body: 'ดาวน์โหลดได้ภายใน {{days}} วัน',
+      },
+    },
+    email: {
+      en: {
+        title: 'Your Aurum data export is ready',
+        body: 'The copy of your data you asked for is ready. Download it from Settings > Privacy within {{days}} days.',
+      },
+      th: {
+        title: 'ข้อมูลของคุณใน Aurum พร้อมให้ดาวน์โหลดแล้ว',
+        body: 'สำเนาข้อมูลที่คุณร้องขอพร้อมแล้ว ดาวน์โหลดได้ที่ การตั้งค่า > ความเป็นส่วนตัว ภายใน {{days}} วัน',
+      },
+    },
+  },
+  verify_email: {
+    email: {
+      en: {
+        title: 'Your Aurum verification code',
+        body: 'Your verification code is {{code}}. It expires in {{minutes}} minutes.\n\nIf you did not ask for this, you can ignore this email.',
+      },
+      th: {
+        title: 'รหัสยืนยันอีเมล Aurum ของคุณ',
+        body: 'รหัสยืนยันของคุณคือ {{code}} รหัสจะหมดอายุใน {{minutes}} นาที\n\nหากคุณไม่ได้ขอรหัสนี้ โปรดเพิกเฉยต่ออีเมลฉบับนี้',
+      },
+    },
+  },
+  security_email_changed: {
+    email: {
+      en: {
+        title: 'Your Aurum email address was changed',
+        body: 'The email address on your Aurum account was changed to {{newEmail}}.\n\nIf this wasn’t you, contact support right away.',
+      },
+      th: {
+        title: 'อีเมลของบัญชี Aurum ของคุณถูกเปลี่ยน',
+        body: 'อีเมลของบัญชี Aurum ของคุณถูกเปลี่ยนเป็น {{newEmail}}\n\nหากคุณไม่ได้ดำเนินการนี้ โปรดติดต่อฝ่ายสนับสนุนทันที',
+      },
+    },
+  },
+  security_erasure_requested: {
+    email: {
+      en: {
+        title: 'Account deletion requested',
+        body: 'We received a request to permanently delete your Aurum account. It will be processed by {{dueDate}}.\n\nIf this wasn’t you, contact support right away.',
+      },
+      th: {
+        title: 'มีคำขอลบบัญชีของคุณ',
+        body: 'เราได้รับคำขอให้ลบบัญชี Aurum ของคุณอย่างถาวร คำขอจะได้รับการดำเนินการภายใน {{dueDate}}\n\nหากคุณไม่ได้ดำเนินการนี้ โปรดติดต่อฝ่ายสนับสนุนทันที',
+      },
+    },
+  },
+  receipt: {
+    email: {
+      en: {
+        title: 'Your Aurum receipt',
+        body: 'Thanks for your purchase.\n\nItem: {{product}}\nAmount: {{amount}} {{currency}}\nDate: {{date}}\nReference: {{reference}}',
+      },
+      th: {
+        title: 'ใบเสร็จ Aurum ของคุณ',
+        body: 'ขอบคุณสำหรับการสั่งซื้อ\n\nรายการ: {{product}}\nจำนวนเงิน: {{amount}} {{currency}}\nวันที่: {{date}}\nหมายเลขอ้างอิง: {{reference}}',
+      },
+    },
+  },
+  event_canceled: {
+    in_app: {
+      en: {
+        title: 'Event canceled',
+        body: '{{title}} has been canceled. Sorry about that!',
+      },
+      th: {
+        title: 'อีเวนต์ถูกยกเลิก',
+        body: '{{title}} ถูกยกเลิกแล้ว ขออภัยด้วย',
+      },
+    },
+  },
+  contact_exchange_requested: {
+    in_app: {
+      en: {
+        title: 'Your match wants to swap contacts',
+        body: 'Share yours too and you will both see each other’s details.',
+      },
+      th: {
+        title: 'แมตช์ของคุณอยากแลกช่องทางติดต่อ',
+        body: 'แชร์ของคุณด้วย แล้วคุณทั้งคู่จะเห็นช่องทางติดต่อของกันและกัน',
+      },
+    },
+  },
+  contact_exchange_released: {
+    in_app: {
+      en: {
+        title: 'Contacts swapped',
+        body: 'You both agreed. Your match’s contact details are ready to view.',
+      },
+      th: {
+        title: 'แลกช่องทางติดต่อแล้ว',
+        body: 'คุณทั้งคู่ตกลงแล้ว ดูช่องทางติดต่อของแมตช์ได้เลย',
+      },
+    },
+  },
+  circle_join_requested: {
+    in_app: {
+      en: {
+        title: 'New request to join {{circle}}',
+        body: 'Someone wants to join your circle. Approve or decline their request.',
+      },
+      th: {
+        title: 'มีคำขอเข้าร่วม {{circle}}',
+        body: 'มีคนอยากเข้าร่วมเซอร์เคิลของคุณ อนุมัติหรือปฏิเสธคำขอได้เลย',
+      },
+    },
+  },
+  circle_join_approved: {
+    in_app: {
+      en: {
+        title: 'Welcome to {{circle}}',
+        body: 'Your request was approved. Meet the other members now.',
+      },
+      th: {
+        title: 'ยินดีต้อนรับสู่ {{circle}}',
+        body: 'คำขอของคุณได้รับการอนุมัติแล้ว ไปทำความรู้จักสมาชิกคนอื่นกันเลย',
+      },
+    },
+  },
+  cohort_admitted: {
+    in_app: {
+      en: {
+        title: 'You are in!',
+        body: 'Your spot on the waitlist came up. Start discovering now.',
+      },
+      th: {
+        title: 'คุณเข้าร่วมได้แล้ว!',
+        body: 'ถึงคิวของคุณแล้ว เริ่มค้นหาคนที่ใช่ได้เลย',
+      },
+    },
+  },
+  safety_check_in_due: {
+    in_app: {
+      en: {
+        title: 'Time to check in',
+        body: 'How is your date going? Check in so {{contactName}} knows you are safe.',
+      },
+      th: {
+        title: 'ถึงเวลาเช็กอินแล้ว',
+        body: 'เดตเป็นอย่างไรบ้าง? เช็กอินเพื่อให้ {{contactName}} รู้ว่าคุณปลอดภัย',
+      },
+    },
+  },
+  safety_check_in_missed: {
+    in_app: {
+      en: {
+        title: 'You missed your check-in',
+        body: 'We let {{contactName}} know. Check in now if you are safe.',
+      },
+      th: {
+        title: 'คุณพลาดการเช็กอิน',
+        body: 'เราแจ้ง {{contactName}} แล้ว เช็กอินตอนนี้หากคุณปลอดภัย',
+      },
+    },
+  },
+  new_message: {
+    in_app: {
+      en: {
+        title: 'New message from {{name}}',
+        body: 'Open the chat to read it.',
+      },
+      th: {
+        title: 'ข้อความใหม่จาก {{name}}',
+        body: 'เปิดแชทเพื่ออ่านข้อความ',
+      },
+    },
+  },
+  reply_reminder: {
+    in_app: {
+      en: {
+        title: 'Time to reply to {{name}}',
+        body: 'You asked us to remind you to write back.',
+      },
+      th: {
+        title: 'ถึงเวลาตอบกลับ {{name}}',
+        body: 'คุณขอให้เราเตือนให้ตอบกลับ',
+      },
+    },
+  },
+  safety_check_in_escalation: {
+    email: {
+      en: {
+        title: '{{name}} missed a date check-in',
+        body: 'Hi {{contactName}},\n\n{{name}} asked us to let you know if they did not check in after their date. They were due to check in at {{checkInAt}} and have not.\n\nSee the date details: {{url}}\n\nTry to reach them. If you think they are in danger, contact local emergency services.',
+      },
+      th: {
+        title: '{{name}} ไม่ได้เช็กอินหลังเดต',
+        body: 'สวัสดี {{contactName}}\n\n{{name}} ขอให้เราแจ้งคุณหากเขาไม่ได้เช็กอินหลังเดต เขาควรเช็กอินภายใน {{checkInAt}} แต่ยังไม่ได้เช็กอิน\n\nดูรายละเอียดเดต: {{url}}\n\nโปรดลองติดต่อเขา หากคุณคิดว่าเขาตกอยู่ในอันตราย โปรดติดต่อหน่วยบริการฉุกเฉินในพื้นที่',
+      },
+    },
+  },
+};
+
+export const TEMPLATE_EVENTS = Object.keys(DEFAULT_TEMPLATES);
+
+/**
+ * The channels an event has its own copy on
+ */
+function eventChannels(event: string): [TemplateChannel, LocalizedCopy][] {
+  return Object.entries(DEFAULT_TEMPLATES[event] || {}) as [
+    TemplateChannel,
+    LocalizedCopy,
+  ][];
+}
+
+let overrideCache: {
+  byKey: Map<string, NotificationTemplate>;
+  loadedAt: number;
+} | null = null;
+
+const templateKey = (
+  event: string,
+  channel: TemplateChannel,
+  locale: TemplateLocale
+) => `${event}:${channel}:${locale}`;
+
+async function loadOverrides(): Promise<Map<string, NotificationTemplate>> {
+  if (
+    overrideCache &&
+    Date.now() - overrideCache.loadedAt < OVERRIDE_CACHE_MS
+  ) {
+    return overrideCache.byKey;
+  }
+
+  try {
+    const overrides = await prisma.notificationTemplate.findMany();
+    overrideCache = {
+      byKey: new Map(
+        overrides.map(override => [
+          templateKey(
+            override.event,
+            override.channel as TemplateChannel,
+            override.locale as TemplateLocale
+          ),
+          override,
+        ])
+      ),
+      loadedAt: Date.now(),
+    };
+  } catch (error) {
+    // Keep serving the defaults (or stale overrides) if the database is down
+    console.error('Error loading notification templates:', error);
+    return overrideCache?.byKey ?? new Map();
+  }
+  return overrideCache.byKey;
+}
+
+/**
+ * Best supported locale for a user's preference (e.g. "th-TH" -> "th")
+ */
+export function resolveLocale(locale?: string | null): TemplateLocale {
+  const language = locale?.split('-')[0].toLowerCase();
+  return (
+    TEMPLATE_LOCALES.find(supported => supported === language) ??
+    DEFAULT_LOCALE
+  );
+}
+
+/**
+ * Whether `event` has its own copy on `channel` (only those can be
+ * overridden)
+ */
+export function hasTemplate(event: string, channel: TemplateChannel) {
+  return Boolean(DEFAULT_TEMPLATES[event]?.[channel]);
+}
+
+/**
+ * Placeholder names used in a piece of copy
+ */
+export function placeholders(text: string): string[] {
+  return [...text.matchAll(/\{\{(\w+)\}\}/g)].map(match => match[1]);
+}
+
+/**
+ * Placeholders an event's copy may use: those in its default copy
+ */
+export function eventPlaceholders(event: string): string[] {
+  const names = new Set<string>();
+  for (const [, byLocale] of eventChannels(event)) {
+    for (const copy of Object.values(byLocale)) {
+      placeholders(copy.title + copy.body).forEach(name => names.add(name));
+    }
+  }
+  return [...names];
+}
+
+/**
+ * Fill in placeholders. Unknown ones are left as they are.
+ */
+function interpolate(text: string, variables: TemplateVariables): string {
+  return text.replace(/\{\{(\w+)\}\}/g, (placeholder, name) =>
+    name in variables ? String(variables[name]) : placeholder
+  );
+}
+
+export class NotificationTemplates {
+  /**
+   * Copy for an event on a channel, in the user's locale. Falls back to
+   * the channel's fallbacks, then to the default locale; throws if the
+   * event has no copy at all.
+   */
+  static async render(
+    event: string,
+    channel: TemplateChannel,
+    locale: string | null | undefined,
+    variables: TemplateVariables = {}
+  ): Promise<TemplateCopy> {
+    const overrides = await loadOverrides();
+    const resolved = resolveLocale(locale);
+    const locales = [...new Set([resolved, DEFAULT_LOCALE])];
+
+    for (const candidateLocale of locales) {
+      for (const candidate of [channel, ...CHANNEL_FALLBACKS[channel]]) {
+        const copy =
+          overrides.get(templateKey(event, candidate, candidateLocale)) ??
+          DEFAULT_TEMPLATES[event]?.[candidate]?.[candidateLocale];
+        if (copy) {
+          return {
+            title: interpolate(copy.title, variables),
+            body: interpolate(copy.body, variables),
+          };
+        }
+      }
+    }
+    throw new Error(`No ${channel} template for ${event}`);
+  }
+
+  /**
+   * Every template with its default copy and any admin override
+   */
+  static async list(event?: string) {
+    const overrides = await prisma.notificationTemplate.findMany({
+      where: event ? { event } : {},
+    });
+    const byKey = new Map(
+      overrides.map(override => [
+        templateKey(
+          override.event,
+          override.channel as TemplateChannel,
+          override.locale as TemplateLocale
+        ),
+        override,
+      ])
+    );
+
+    return (event ? [event] : TEMPLATE_EVENTS).flatMap(name =>
+      eventChannels(name).flatMap(([channel, byLocale]) =>
+        TEMPLATE_LOCALES.map(locale => {
+          const override = byKey.get(templateKey(name, channel, locale));
+          return {
+            event: name,
+            channel,
+            locale,
+            placeholders: eventPlaceholders(name),
+            default: byLocale[locale],
+            override: override
+              ? {
+                  title: override.title,
+                  body: override.body,
+                  updatedBy: override.updatedBy,
+                  updatedAt: override.updatedAt,
+                }
+              : null,
+          };
+        })
+      )
+    );
+  }
+
+  /**
+   * Replace the copy for one event, channel and locale
+   */
+  static async setOverride(
+    key: { event: string; channel: TemplateChannel; locale: TemplateLocale },
+    copy: TemplateCopy,
+    adminId: string
+  ): Promise<NotificationTemplate> {
+    const template = await prisma.notificationTemplate.upsert({
+      where: { event_channel_locale: key },
+      create: { ...key, ...copy, updatedBy: adminId },
+      update: { ...copy, updatedBy: adminId },
+    });
+    overrideCache = null;
+
+    await AuditLog.record({
+      action: 'admin.notification_template_updated',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'notification_template',
+      targetId: templateKey(key.event, key.channel, key.locale),
+      details: { ...copy },
+    });
+    return template;
+  }
+
+  /**
+   * Drop an override and go back to the default copy. Returns false if
+   * there was none.
+   */
+  static async clearOverride(
+    key: { event: string; channel: TemplateChannel; locale: TemplateLocale },
+    adminId: string
+  ): Promise<boolean> {
+    const removed = await prisma.notificationTemplate.deleteMany({
+      where: key,
+    });
+    if (removed.count === 0) {
+      return false;
+    }
+    overrideCache = null;
+
+    await AuditLog.record({
+      action: 'admin.notification_template_reset',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'notification_template',
+      targetId: templateKey(key.event, key.channel, key.locale),
+    });
+    return true;
+  }
+}
@@ -0,0 +1,142 @@
+/**
+ * Notifications
+ * Persisted in-app notifications, so users see them in the notification
+ * center whether or not push is enabled
+ */
+
+import { Notification, Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { DIGEST_TYPES, NotificationPush } from './notification-push';
+import { NotificationTemplates } from './notification-templates';
+
+export interface NotificationInput {
+  type: string;
+  title: string;
+  body: string;
+  path?: string | null;
+  data?: Record<string, unknown>;
+}
+
+/**
+ * A notification whose copy comes from the template registry
+ */
+export interface TemplatedNotification {
+  type: string;
+  // Template event, when it differs from the type (e.g. "new_super_like")
+  template?: string;
+  variables?: Record<string, string | number>;
+  path?: string | null;
+  data?: Record<string, unknown>;
+  // Also push it: right away, or in the next digest for digest types
+  push?: boolean;
+}
+
+export class Notifications {
+  /**
+   * Store a notification for one user, in their language, and push it if
+   * asked. Never throws, so a failed write can't break the flow that
+   * triggered it.
+   */
+  static async notify(
+    userId: string,
+    notification: TemplatedNotification
+  ): Promise<void> {
+    try {
+      const user = await prisma.user.findUnique({
+        where: { id: userId },
+        select: { locale: true },
+      });
+      const copy = await NotificationTemplates.render(
+        notification.template || notification.type,
+        'in_app',
+        user?.locale,
+        notification.variables
+      );
+      await Notifications.createMany([userId], {
+        type: notification.type,
+        title: copy.title,
+        body: copy.body,
+        path: notification.path,
+        data: notification.data,
+      });
+    } catch (error) {
+      console.error(`Error storing ${notification.type} notification:`, error);
+      return;
+    }
+
+    if (!notification.push) {
+      return;
+    }
+    if (DIGEST_TYPES.includes(notification.type)) {
+      await NotificationPush.queueDigest(userId);
+    } else {
+      await NotificationPush.send(userId, {
+        type: notification.type,
+        template: notification.template,
+        variables: notification.variables,
+        path: notification.path || undefined,
+      });
+    }
+  }
+
+  /**
+   * Store the same notification for every user in `userIds`. Returns how
+   * many were written.
+   */
+  static async createMany(
+    userIds: string[],
+    notification: NotificationInput
+  ): Promise<number> {
+    if (userIds.length === 0) {
+      return 0;
+    }
+
+    const result = await prisma.notification.createMany({
+      data: userIds.map(userId => ({
+        userId,
+        type: notification.type,
+        title: notification.title,
+        body: notification.body,
+        path: notification.path ?? null,
+        data: notification.data as Prisma.InputJsonValue | undefined,
+      })),
+    });
+    return result.count;
+  }
+
+  /**
+   * A user's notifications, newest first
+   */
+  static async list(
+    userId: string,
+    limit: number,
+    cursor?: string
+  ): Promise<Notification[]> {
+    return prisma.notification.findMany({
+      where: { userId },
+      orderBy: [{ createdAt: 'desc' }, { id: 'desc' }],
+      take: limit,
+      ...(cursor && { cursor: { id: cursor }, skip: 1 }),
+    });
+  }
+
+  static async unreadCount(userId: string): Promise<number> {
+    return prisma.notification.count({ where: { userId, readAt: null } });
+  }
+
+  /**
+   * Mark notifications read: the given IDs, or all of them. Returns how
+   * many changed.
+   */
+  static async markRead(userId: string, ids?: string[]): Promise<number> {
+    const result = await prisma.notification.updateMany({
+      where: {
+        userId,
+        readAt: null,
+        ...(ids && { id: { in: ids } }),
+      },
+      data: { readAt: new Date() },
+    });
+    return result.count;
+  }
+}
@@ -0,0 +1,248 @@
+/**
+ * On-chain Payments
+ * Direct WLD / USDC.e transfers on World Chain. ERC-20 transfers carry no
+ * memo, so each intent gets a unique amount (price plus a few base units)
+ * that identifies it. A scheduled watcher scans Transfer logs to the payment
+ * address and fulfills matching intents once they reach confirmation depth.
+ * A transfer for an intent that expired meanwhile still fulfills it, if no
+ * other recently expired intent used the amount; transfers that match no
+ * intent are audited so support can refund them.
+ */
+
+import { createPublicClient, http, parseAbiItem, Address } from 'viem';
+import { randomInt } from 'crypto';
+import { Payment } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { Payments, PaymentToken, TOKEN_DECIMALS } from './payments';
+import { ScheduledTask } from './scheduler';
+import { AuditLog } from './audit-log';
+import { httpClient } from './http-client';
+
+export const ONCHAIN_PROVIDER = 'onchain';
+
+// How long after an intent expires a transfer for it is still accepted
+const LATE_TRANSFER_WINDOW_MS = 7 * 24 * 60 * 60 * 1000;
+
+// Blocks a transfer must be buried under before it's accepted
+export const CONFIRMATION_DEPTH = parseInt(
+  process.env.ONCHAIN_CONFIRMATIONS || '5'
+);
+
+// How long an unpaid intent stays open (and its unique amount reserved)
+export const INTENT_TTL_MS = 24 * 60 * 60 * 1000;
+
+// Maximum block range requested per log query
+const MAX_BLOCK_RANGE = 2000n;
+
+// Blocks to look back on the very first scan
+const INITIAL_LOOKBACK = 500n;
+
+const LAST_BLOCK_KEY = 'onchain_payments:last_block';
+
+// World Chain token contracts (overridable for testnets)
+const TOKEN_ADDRESSES: Record<PaymentToken, Address> = {
+  WLD: (process.env.ONCHAIN_WLD_TOKEN_ADDRESS ||
+    '0x2cFc85d8E48F8EAB294be644d9E25C3030863003') as Address,
+  USDCE: (process.env.ONCHAIN_USDCE_TOKEN_ADDRESS ||
+    '0x79A02482A880bCE3F13e09Da970dC34db4CD24d1') as Address,
+};
+
+const transferEvent = parseAbiItem(
+  'event Transfer(address indexed from, address indexed to, uint256 value)'
+);
+
+const client = createPublicClient({
+  // Provider URLs carry their API key in the path
+  transport: http(process.env.ONCHAIN_RPC_URL, {
+    fetchFn: httpClient('rpc', { logPath: false }),
+  }),
+});
+
+function paymentAddress(): Address {
+  const address = process.env.ONCHAIN_PAYMENT_ADDRESS;
+  if (!address) {
+    throw new Error('ONCHAIN_PAYMENT_ADDRESS is not configured');
+  }
+  return address as Address;
+}
+
+/**
+ * Create an on-chain payment intent with an amount no other open intent for
+ * the same token uses
+ */
+export async function createOnchainIntent(
+  userId: string,
+  productId: string,
+  token: PaymentToken
+) {
+  // Dust stays below 0.01 of a token so the price shown is still accurate
+  const maxDust = 10 ** Math.min(TOKEN_DECIMALS[token] - 2, 6);
+
+  for (let attempt = 0; attempt < 5; attempt++) {
+    const payment = await Payments.createIntent(
+      userId,
+      ONCHAIN_PROVIDER,
+      productId,
+      token,
+      BigInt(randomInt(1, maxDust))
+    );
+
+    const collisions = await prisma.payment.count({
+      where: {
+        provider: ONCHAIN_PROVIDER,
+        status: 'pending',
+        token,
+        amount: payment.amount,
+      },
+    });
+    if (collisions === 1) {
+      return {
+        payment,
+        to: paymentAddress(),
+        tokenAddress: TOKEN_ADDRESSES[token],
+        expiresAt: new Date(payment.createdAt.getTime() + INTENT_TTL_MS),
+      };
+    }
+
+    await prisma.payment.delete({ where: { id: payment.id } });
+  }
+
+  throw new Error('Could not allocate a unique payment amount');
+}
+
+/**
+ * The expired intent a late transfer was meant for, if exactly one intent
+ * that expired recently used its amount
+ */
+async function expiredIntent(
+  token: PaymentToken,
+  amount: string
+): Promise<Payment | null> {
+  const candidates = await prisma.payment.findMany({
+    where: {
+      provider: ONCHAIN_PROVIDER,
+      status: 'expired',
+      token,
+      amount,
+      createdAt: {
+        gte: new Date(Date.now() - INTENT_TTL_MS - LATE_TRANSFER_WINDOW_MS),
+      },
+    },
+    take: 2,
+  });
+  return candidates.length === 1 ? candidates[0] : null;
+}
+
+/**
+ * Scan new Transfer logs to the payment address and fulfill matching intents
+ */
+export async function scanOnchainPayments(): Promise<{
+  fromBlock: string;
+  toBlock: string;
+  fulfilled: number;
+}> {
+  const head = await client.getBlockNumber();
+  const safeHead = head - BigInt(CONFIRMATION_DEPTH - 1);
+
+  const stored = await redis.get(LAST_BLOCK_KEY);
+  const fromBlock = stored
+    ? BigInt(stored) + 1n
+    : safeHead - INITIAL_LOOKBACK;
+  if (fromBlock > safeHead) {
+    return {
+      fromBlock: fromBlock.toString(),
+      toBlock: safeHead.toString(),
+      fulfilled: 0,
+    };
+  }
+
+  const toBlock =
+    safeHead - fromBlock > MAX_BLOCK_RANGE
+      ? fromBlock + MAX_BLOCK_RANGE
+      : safeHead;
+
+  const logs = await client.getLogs({
+    address: Object.values(TOKEN_ADDRESSES),
+    event: transferEvent,
+    args: { to: paymentAddress() },
+    fromBlock,
+    toBlock,
+  });
+
+  let fulfilled = 0;
+  for (const log of logs) {
+    const token = (Object.keys(TOKEN_ADDRESSES) as PaymentToken[]).find(
+      key => TOKEN_ADDRESSES[key].toLowerCase() === log.address.toLowerCase()
+    );
+    if (!token || log.args.value === undefined) {
+      continue;
+    }
+
+    // One transaction can carry several transfers, so key on the log too
+    const transactionId = `${log.transactionHash}:${log.logIndex}`;
+    if ((await prisma.payment.count({ where: { transactionId } })) > 0) {
+      continue; // Fulfilled on an earlier scan
+    }
+
+    const amount = log.args.value.toString();
+    const payment =
+      (await prisma.payment.findFirst({
+        where: { provider: ONCHAIN_PROVIDER, status: 'pending', token, amount },
+        orderBy: { createdAt: 'desc' },
+      })) ?? (await expiredIntent(token, amount));
+    if (payment && (await Payments.fulfill(payment, transactionId))) {
+      fulfilled++;
+      continue;
+    }
+
+    console.warn('Unmatched on-chain transfer:', {
+      token,
+      value: amount,
+      transactionHash: log.transactionHash,
+    });
+    await AuditLog.recordSafely({
+      action: 'billing.transfer_unmatched',
+      actorType: 'provider',
+      actorId: ONCHAIN_PROVIDER,
+      targetType: 'transaction',
+      targetId: transactionId,
+      details: {
+        token,
+        amount,
+        from: log.args.from ?? null,
+        // Set when the intent was already paid, e.g. paid twice
+        paymentReference: payment?.reference ?? null,
+      },
+    });
+  }
+
+  await redis.set(LAST_BLOCK_KEY, toBlock.toString());
+
+  // Release amounts held by abandoned intents
+  await prisma.payment.updateMany({
+    where: {
+      provider: ONCHAIN_PROVIDER,
+      status: 'pending',
+      createdAt: { lt: new Date(Date.now() - INTENT_TTL_MS) },
+    },
+    data: { status: 'expired' },
+  });
+
+  return {
+    fromBlock: fromBlock.toString(),
+    toBlock: toBlock.toString(),
+    fulfilled,
+  };
+}
+
+export const onchainPaymentWatcher: ScheduledTask = {
+  name: 'onchain-payment-watcher',
+  everyMs: parseInt(process.env.ONCHAIN_POLL_INTERVAL_MS || '15000'),
+  run: async () => {
+    if (!process.env.ONCHAIN_RPC_URL || !process.env.ONCHAIN_PAYMENT_ADDRESS) {
+      return { skipped: 'not configured' };
+    }
+    return scanOnchainPayments();
+  },
+};
@@ -0,0 +1,120 @@
+/**
+ * Outbox
+ * Relays domain events that were saved in the same transaction as the
+ * change they describe (see Store.outbox) to the event bus. An event only
+ * exists if its change committed, and stays pending until it reaches the
+ * stream, so a crash or Redis outage after commit can't lose it. Delivery
+ * is at least once; consumers dedupe on the event ID.
+ */
+
+import prisma from './prisma';
+import redis from './redis';
+import { DomainEvent, EventBus } from './event-bus';
+import { ScheduledTask } from './scheduler';
+import { counter } from './metrics';
+
+const BATCH_SIZE = 100;
+
+// One relay at a time keeps events in order
+const RELAY_LOCK_KEY = 'outbox:relay:lock';
+const RELAY_LOCK_SECONDS = 60;
+
+// Published events are kept this long for debugging
+const RETENTION_MS = 7 * 24 * 60 * 60 * 1000;
+
+const relayedCounter = counter(
+  'aurum_outbox_events_relayed_total',
+  'Outbox events relayed to the event bus, by outcome'
+);
+
+export class Outbox {
+  /**
+   * Publish pending events oldest first, stopping at the first failure so
+   * later events never overtake it. Does nothing if another relay is
+   * running.
+   */
+  static async relay(): Promise<{ published: number } | { skipped: string }> {
+    const locked = await redis.set(
+      RELAY_LOCK_KEY,
+      '1',
+      'EX',
+      RELAY_LOCK_SECONDS,
+      'NX'
+    );
+    if (!locked) {
+      return { skipped: 'relay_running' };
+    }
+
+    let published = 0;
+    try {
+      for (;;) {
+        const pending = await prisma.outboxEvent.findMany({
+          where: { publishedAt: null },
+          orderBy: { occurredAt: 'asc' },
+          take: BATCH_SIZE,
+        });
+
+        for (const row of pending) {
+          const event: DomainEvent<object> = {
+            id: row.id,
+            type: row.type,
+            occurredAt: row.occurredAt.toISOString(),
+            payload: row.payload as object,
+          };
+          try {
+            await EventBus.deliver(event);
+          } catch (error) {
+            relayedCounter.inc({ outcome: 'failed' });
+            await prisma.outboxEvent.update({
+              where: { id: row.id },
+              data: { attempts: { increment: 1 }, lastError: String(error) },
+            });
+            throw error;
+          }
+          await prisma.outboxEvent.update({
+            where: { id: row.id },
+            data: { publishedAt: new Date(), attempts: { increment: 1 } },
+          });
+          relayedCounter.inc({ outcome: 'published' });
+          published++;
+        }
+
+        if (pending.length < BATCH_SIZE) {
+          break;
+        }
+      }
+    } finally {
+      await redis.del(RELAY_LOCK_KEY);
+    }
+    return { published };
+  }
+
+  /**
+   * Relay right after a commit instead of waiting for the next scheduled
+   * run. Never throws; anything left over goes out on that run.
+   */
+  static relaySoon(): void {
+    Outbox.relay().catch(error => {
+      console.error('Error relaying outbox events:', error);
+    });
+  }
+
+  /**
+   * Drop published events past retention
+   */
+  static async prune(): Promise<number> {
+    const result = await prisma.outboxEvent.deleteMany({
+      where: { publishedAt: { lt: new Date(Date.now() - RETENTION_MS) } },
+    });
+    return result.count;
+  }
+}
+
+export const outboxRelay: ScheduledTask = {
+  name: 'outbox-relay',
+  everyMs: parseInt(process.env.OUTBOX_RELAY_INTERVAL_MS || '5000'),
+  run: async () => {
+    const relayed = await Outbox.relay();
+    return { ...relayed, pruned: await Outbox.prune() };
+  },
+};
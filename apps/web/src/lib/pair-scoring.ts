@@ -0,0 +1,144 @@
+/**
+ * Pair Scoring
+ * Batches viewer/candidate compatibility scoring for discovery ranking.
+ * Requests arriving within a short window (e.g. from concurrent discovery
+ * page loads) are coalesced and deduplicated into as few ML API calls as
+ * possible.
+ */
+
+import { mlServiceClient } from './ml-service-client';
+import { faceVectorStore } from './vector-store';
+import { histogram } from './metrics';
+
+export interface PairScore {
+  candidateId: string;
+  similarity: number;
+  compatibility: number;
+}
+
+// Maximum pairs sent to the ML API in one request
+export const MAX_PAIRS_PER_REQUEST = parseInt(
+  process.env.ML_MAX_PAIR_BATCH_SIZE || '100'
+);
+
+// How long to wait for more requests before flushing a batch
+const COALESCE_WINDOW_MS = 10;
+
+interface PendingPair {
+  viewer: number[];
+  candidate: number[];
+  resolve: (score: { similarity: number; compatibility: number }) => void;
+  reject: (error: Error) => void;
+}
+
+const batchSizeHistogram = histogram(
+  'aurum_pair_scoring_batch_size',
+  'Pairs per ML API pair scoring request',
+  [1, 5, 10, 25, 50, 100, 250]
+);
+
+// Keyed by `${viewerId}:${candidateId}` so duplicate pairs share a result
+let pending = new Map<string, PendingPair[]>();
+let flushTimer: NodeJS.Timeout | null = null;
+
+function schedulePair(
+  key: string,
+  viewer: number[],
+  candidate: number[]
+): Promise<{ similarity: number; compatibility: number }> {
+  return new Promise((resolve, reject) => {
+    const waiters = pending.get(key) || [];
+    waiters.push({ viewer, candidate, resolve, reject });
+    pending.set(key, waiters);
+
+    if (pending.size >= MAX_PAIRS_PER_REQUEST) {
+      flush();
+    } else if (!flushTimer) {
+      flushTimer = setTimeout(flush, COALESCE_WINDOW_MS);
+    }
+  });
+}
+
+function flush(): void {
+  if (flushTimer) {
+    clearTimeout(flushTimer);
+    flushTimer = null;
+  }
+
+  const batch = pending;
+  pending = new Map();
+
+  const keys = Array.from(batch.keys());
+  for (let i = 0; i < keys.length; i += MAX_PAIRS_PER_REQUEST) {
+    sendChunk(batch, keys.slice(i, i + MAX_PAIRS_PER_REQUEST));
+  }
+}
+
+async function sendChunk(
+  batch: Map<string, PendingPair[]>,
+  keys: string[]
+): Promise<void> {
+  batchSizeHistogram.observe(keys.length);
+
+  try {
+    const scores = await mlServiceClient.scorePairs(
+      keys.map(id => ({
+        id,
+        viewer: batch.get(id)![0].viewer,
+        candidate: batch.get(id)![0].candidate,
+      }))
+    );
+    const byId = new Map(scores.map(score => [score.id, score]));
+
+    keys.forEach(key => {
+      const score = byId.get(key);
+      batch.get(key)!.forEach(waiter => {
+        if (score) {
+          waiter.resolve(score);
+        } else {
+          waiter.reject(new Error(`No score returned for pair ${key}`));
+        }
+      });
+    });
+  } catch (error) {
+    const failure = error instanceof Error ? error : new Error(String(error));
+    keys.forEach(key => {
+      batch.get(key)!.forEach(waiter => waiter.reject(failure));
+    });
+  }
+}
+
+/**
+ * Score a viewer against a set of candidates. Candidates without a stored
+ * face embedding are omitted from the result.
+ */
+export async function scoreCandidatePairs(
+  viewerId: string,
+  candidateIds: string[]
+): Promise<PairScore[]> {
+  const viewer = await faceVectorStore.getUserEmbedding(viewerId);
+  if (!viewer) {
+    return [];
+  }
+
+  const candidates = await Promise.all(
+    candidateIds.map(id => faceVectorStore.getUserEmbedding(id))
+  );
+
+  const results = await Promise.all(
+    candidates.map(async (candidate, index) => {
+      if (!candidate) {
+        return null;
+      }
+      const candidateId = candidateIds[index];
+      const score = await schedulePair(
+        `${viewerId}:${candidateId}`,
+        viewer.embedding,
+        candidate.embedding
+      );
+      return { candidateId, ...score };
+    })
+  );
+
+  return results.filter((result): result is PairScore => result !== null);
+}
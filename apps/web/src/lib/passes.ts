@@ -0,0 +1,87 @@
+/**
+ * Passes
+ * The user's pass list. A pass keeps the passed user out of the passer's
+ * discovery for PASS_RESHOW_DAYS, after which they can come round again.
+ * Users can see the people they've passed on and clear any or all of
+ * them to get them back straight away.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+
+// How long a pass keeps someone out of discovery
+export const PASS_RESHOW_DAYS = parseInt(
+  process.env.PASS_RESHOW_DAYS || '30'
+);
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const LIST_LIMIT = 200;
+
+/**
+ * When a pass made now stops applying
+ */
+export function passExpiry(from = new Date()): Date {
+  return new Date(from.getTime() + PASS_RESHOW_DAYS * DAY_MS);
+}
+
+/**
+ * Users the viewer hasn't passed on, or whose pass has run out
+ */
+export function notPassedWhere(viewerId: string): Prisma.UserWhereInput {
+  return {
+    passedBy: {
+      none: { userId: viewerId, expiresAt: { gt: new Date() } },
+    },
+  };
+}
+
+export class Passes {
+  /**
+   * The people the user has passed on and who are still hidden, most
+   * recent first
+   */
+  static async list(userId: string) {
+    const passes = await prisma.pass.findMany({
+      where: { userId, expiresAt: { gt: new Date() } },
+      include: {
+        passedUser: {
+          select: {
+            id: true,
+            handle: true,
+            displayName: true,
+            deletedAt: true,
+          },
+        },
+      },
+      orderBy: { passedAt: 'desc' },
+      take: LIST_LIMIT,
+    });
+    return passes
+      .filter(pass => !pass.passedUser.deletedAt)
+      .map(pass => ({
+        userId: pass.passedUserId,
+        handle: pass.passedUser.handle,
+        displayName: pass.passedUser.displayName,
+        passedAt: pass.passedAt,
+        reshowsAt: pass.expiresAt,
+      }));
+  }
+
+  /**
+   * Take one user off the pass list. Returns false if they weren't on it.
+   */
+  static async remove(userId: string, passedUserId: string): Promise<boolean> {
+    const removed = await prisma.pass.deleteMany({
+      where: { userId, passedUserId },
+    });
+    return removed.count > 0;
+  }
+
+  /**
+   * Clear the whole pass list; returns how many were removed
+   */
+  static async clear(userId: string): Promise<number> {
+    const removed = await prisma.pass.deleteMany({ where: { userId } });
+    return removed.count;
+  }
+}
@@ -0,0 +1,220 @@
+/**
+ * @description Unit tests for payment fulfillment
+ */
+
+import { describe, it, expect, jest, beforeEach } from '@jest/globals';
+import type { Payment } from '@prisma/client';
+import { Payments } from '@/lib/payments';
+
+// A write queued for a batch transaction
+type MockWrite = () => void;
+
+// The database: payment statuses, and what has been granted
+const mockDb = {
+  statuses: new Map<string, string>(),
+  granted: [] as string[],
+};
+let mockGrantFails = false;
+let mockSubscription: { plan: string; expiresAt: Date | null } = {
+  plan: 'free',
+  expiresAt: null,
+};
+
+const mockPlanWrites = jest.fn(
+  (_userId: string, plan: string, _expiresAt: Date | null): MockWrite[] => [
+    () => {
+      mockDb.granted.push(`plan:${plan}`);
+    },
+  ]
+);
+const mockPlanChanged = jest.fn(async () => {});
+
+jest.mock('@prisma/client', () => ({
+  Prisma: {
+    PrismaClientKnownRequestError: class extends Error {
+      code: string;
+      constructor(message: string, { code }: { code: string }) {
+        super(message);
+        this.code = code;
+      }
+    },
+  },
+}));
+
+jest.mock('@/lib/prisma', () => ({
+  __esModule: true,
+  default: {
+    payment: {
+      update:
+        ({
+          where,
+          data,
+        }: {
+          where: { id: string; status: { in: string[] } };
+          data: { status: string };
+        }): MockWrite =>
+        () => {
+          if (!where.status.in.includes(mockDb.statuses.get(where.id)!)) {
+            const { Prisma } = jest.requireMock('@prisma/client') as {
+              Prisma: {
+                PrismaClientKnownRequestError: new (
+                  message: string,
+                  meta: { code: string }
+                ) => Error;
+              };
+            };
+            throw new Prisma.PrismaClientKnownRequestError('No record', {
+              code: 'P2025',
+            });
+          }
+          mockDb.statuses.set(where.id, data.status);
+        },
+    },
+    // All or nothing, like the real thing
+    $transaction: async (writes: MockWrite[]) => {
+      const statuses = new Map(mockDb.statuses);
+      const granted = [...mockDb.granted];
+      try {
+        writes.forEach(write => write());
+      } catch (error) {
+        mockDb.statuses = statuses;
+        mockDb.granted = granted;
+        throw error;
+      }
+    },
+  },
+}));
+
+jest.mock('@/lib/entitlements', () => ({
+  Entitlements: {
+    getSubscription: async () => mockSubscription,
+    planWrites: (userId: string, plan: string, expiresAt: Date | null) =>
+      mockPlanWrites(userId, plan, expiresAt),
+    planChanged: () => mockPlanChanged(),
+  },
+}));
+
+jest.mock('@/lib/inventory', () => ({
+  Inventory: {
+    grantWrites: (_userId: string, item: string, quantity: number) => [
+      () => {
+        if (mockGrantFails) {
+          throw new Error('Database unavailable');
+        }
+        mockDb.granted.push(`${item}:${quantity}`);
+      },
+    ],
+  },
+}));
+
+jest.mock('@/lib/audit-log', () => ({
+  AuditLog: { recordSafely: async () => {} },
+}));
+
+jest.mock('@/lib/email', () => ({
+  Email: { sendToUser: async () => {} },
+}));
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+function payment(product: string, status = 'pending'): Payment {
+  mockDb.statuses.set('pay-1', status);
+  return {
+    id: 'pay-1',
+    reference: 'ref-1',
+    userId: 'user-1',
+    provider: 'worldapp',
+    kind: 'purchase',
+    product,
+    token: 'WLD',
+    amount: '4000000000000000000',
+    status,
+    transactionId: null,
+    createdAt: new Date(),
+    confirmedAt: null,
+  };
+}
+
+describe('Payments.fulfill', () => {
+  beforeEach(() => {
+    mockDb.statuses.clear();
+    mockDb.granted = [];
+    mockGrantFails = false;
+    mockSubscription = { plan: 'free', expiresAt: null };
+    mockPlanWrites.mockClear();
+    mockPlanChanged.mockClear();
+  });
+
+  it('confirms the payment and grants the product', async () => {
+    expect(await Payments.fulfill(payment('boost_5'), 'tx-1')).toBe(true);
+
+    expect(mockDb.statuses.get('pay-1')).toBe('confirmed');
+    expect(mockDb.granted).toEqual(['boost:5']);
+  });
+
+  it('grants once however many times it is confirmed', async () => {
+    const pending = payment('boost_5');
+
+    const results = await Promise.all([
+      Payments.fulfill(pending, 'tx-1'),
+      Payments.fulfill(pending, 'tx-1'),
+    ]);
+    const again = await Payments.fulfill(pending, 'tx-1');
+
+    expect(results.filter(Boolean)).toHaveLength(1);
+    expect(again).toBe(false);
+    expect(mockDb.granted).toEqual(['boost:5']);
+  });
+
+  it('leaves the payment to be retried when the grant fails', async () => {
+    const pending = payment('super_interest_5');
+    mockGrantFails = true;
+
+    await expect(Payments.fulfill(pending, 'tx-1')).rejects.toThrow(
+      'Database unavailable'
+    );
+    expect(mockDb.statuses.get('pay-1')).toBe('pending');
+    expect(mockDb.granted).toEqual([]);
+
+    mockGrantFails = false;
+    expect(await Payments.fulfill(pending, 'tx-1')).toBe(true);
+    expect(mockDb.granted).toEqual(['super_interest:5']);
+  });
+
+  it('fulfills an intent that expired before the money arrived', async () => {
+    expect(await Payments.fulfill(payment('boost_1', 'expired'), 'tx-1')).toBe(
+      true
+    );
+    expect(mockDb.statuses.get('pay-1')).toBe('confirmed');
+  });
+
+  it('never fulfills a failed payment', async () => {
+    expect(await Payments.fulfill(payment('boost_1', 'failed'), 'tx-1')).toBe(
+      false
+    );
+    expect(mockDb.granted).toEqual([]);
+  });
+
+  it('stacks plan time on what is left of the current plan', async () => {
+    const expiresAt = new Date(Date.now() + 10 * DAY_MS);
+    mockSubscription = { plan: 'premium', expiresAt };
+
+    await Payments.fulfill(payment('premium_monthly'), 'tx-1');
+
+    expect(mockPlanWrites).toHaveBeenCalledWith(
+      'user-1',
+      'premium',
+      new Date(expiresAt.getTime() + 30 * DAY_MS)
+    );
+    expect(mockDb.granted).toEqual(['plan:premium']);
+    expect(mockPlanChanged).toHaveBeenCalled();
+  });
+
+  it('does not announce a plan change that was rolled back', async () => {
+    const pending = payment('premium_monthly');
+    mockDb.statuses.set('pay-1', 'confirmed');
+
+    expect(await Payments.fulfill(pending, 'tx-1')).toBe(false);
+    expect(mockPlanChanged).not.toHaveBeenCalled();
+  });
+});
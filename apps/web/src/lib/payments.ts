@@ -0,0 +1,296 @@
+/**
+ * Payments
+ * Product catalog, payment intents and fulfillment. Providers verify a
+ * payment, then call `Payments.fulfill` to grant what was purchased.
+ */
+
+import { randomBytes } from 'crypto';
+import { Payment, Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { Entitlements, Plan } from './entitlements';
+import { Inventory, InventoryItemType } from './inventory';
+import { AuditLog } from './audit-log';
+import { Email } from './email';
+
+export type PaymentToken = 'WLD' | 'USDCE';
+
+export const TOKEN_DECIMALS: Record<PaymentToken, number> = {
+  WLD: 18,
+  USDCE: 6,
+};
+
+export interface ProductDefinition {
+  name: string;
+  // Price per accepted token, in whole token units
+  prices: Partial<Record<PaymentToken, string>>;
+  // Stripe recurring price for card subscriptions
+  stripePriceId?: string;
+  grant:
+    | { type: 'plan'; plan: Plan; durationDays: number }
+    | { type: 'consumable'; item: InventoryItemType; quantity: number };
+}
+
+export const PRODUCTS: Record<string, ProductDefinition> = {
+  premium_monthly: {
+    name: 'Premium (30 days)',
+    prices: { WLD: '5', USDCE: '5' },
+    stripePriceId: process.env.STRIPE_PRICE_PREMIUM_MONTHLY,
+    grant: { type: 'plan', plan: 'premium', durationDays: 30 },
+  },
+  premium_yearly: {
+    name: 'Premium (365 days)',
+    prices: { WLD: '45', USDCE: '45' },
+    stripePriceId: process.env.STRIPE_PRICE_PREMIUM_YEARLY,
+    grant: { type: 'plan', plan: 'premium', durationDays: 365 },
+  },
+  boost_1: {
+    name: '1 Boost',
+    prices: { WLD: '1', USDCE: '1' },
+    grant: { type: 'consumable', item: 'boost', quantity: 1 },
+  },
+  boost_5: {
+    name: '5 Boosts',
+    prices: { WLD: '4', USDCE: '4' },
+    grant: { type: 'consumable', item: 'boost', quantity: 5 },
+  },
+  super_interest_5: {
+    name: '5 Super-Interests',
+    prices: { WLD: '2', USDCE: '2' },
+    grant: { type: 'consumable', item: 'super_interest', quantity: 5 },
+  },
+  super_interest_15: {
+    name: '15 Super-Interests',
+    prices: { WLD: '5', USDCE: '5' },
+    grant: { type: 'consumable', item: 'super_interest', quantity: 15 },
+  },
+};
+
+/**
+ * Convert a decimal token amount (e.g. "1.5") to base units
+ */
+export function toTokenUnits(amount: string, token: PaymentToken): string {
+  const decimals = TOKEN_DECIMALS[token];
+  const [whole, fraction = ''] = amount.split('.');
+  const padded = fraction.padEnd(decimals, '0').slice(0, decimals);
+  return (
+    BigInt(whole || '0') * 10n ** BigInt(decimals) +
+    BigInt(padded || '0')
+  ).toString();
+}
+
+// Decimals for fiat amounts, which providers report in minor units (cents)
+const FIAT_DECIMALS = 2;
+
+/**
+ * Convert base units back to a decimal amount (e.g. "1.5")
+ */
+export function fromTokenUnits(units: string, currency: string): string {
+  const decimals = TOKEN_DECIMALS[currency as PaymentToken] ?? FIAT_DECIMALS;
+  const value = BigInt(units);
+  const scale = 10n ** BigInt(decimals);
+  const fraction = (value % scale)
+    .toString()
+    .padStart(decimals, '0')
+    .replace(/0+$/, '');
+  const whole = (value / scale).toString();
+  return fraction ? `${whole}.${fraction}` : whole;
+}
+
+export interface BillingRecord {
+  reference: string;
+  provider: string;
+  kind: string;
+  product: string;
+  productName: string;
+  status: string;
+  currency: string;
+  amount: string; // Base units
+  displayAmount: string;
+  transactionId: string | null;
+  createdAt: string;
+  confirmedAt: string | null;
+}
+
+// Open intents are not part of a user's billing history
+const BILLED_STATUSES = ['confirmed', 'failed', 'refunded', 'charged_back'];
+
+export class Payments {
+  /**
+   * Create a pending payment with a unique reference for the client to pay.
+   * `extraUnits` is added to the price (used to make on-chain amounts unique).
+   */
+  static async createIntent(
+    userId: string,
+    provider: string,
+    productId: string,
+    token: PaymentToken,
+    extraUnits = 0n
+  ): Promise<Payment> {
+    const product = PRODUCTS[productId];
+    const price = product?.prices[token];
+    if (!product || !price) {
+      throw new Error(`Product ${productId} is not sold for ${token}`);
+    }
+
+    return prisma.payment.create({
+      data: {
+        reference: randomBytes(16).toString('hex'),
+        userId,
+        provider,
+        product: productId,
+        token,
+        amount: (BigInt(toTokenUnits(price, token)) + extraUnits).toString(),
+      },
+    });
+  }
+
+  /**
+   * Mark a verified payment confirmed and grant the product. Expired intents
+   * can still be fulfilled, for money that arrived late. Only the first
+   * caller to move the payment out of "pending" (or "expired") fulfills it,
+   * so duplicate confirmations never grant twice, and the claim commits
+   * together with the grant, so a failed grant leaves the payment to be
+   * retried. Returns whether this call fulfilled it.
+   */
+  static async fulfill(
+    payment: Payment,
+    transactionId: string
+  ): Promise<boolean> {
+    const product = PRODUCTS[payment.product];
+    let grant: Prisma.PrismaPromise<unknown>[];
+    let expiresAt: Date | null = null;
+    if (product.grant.type === 'plan') {
+      // Stack on top of any remaining subscription time
+      const current = await Entitlements.getSubscription(payment.userId);
+      const base =
+        current.plan === product.grant.plan && current.expiresAt
+          ? new Date(current.expiresAt)
+          : new Date();
+      expiresAt = new Date(
+        base.getTime() + product.grant.durationDays * 24 * 60 * 60 * 1000
+      );
+      grant = Entitlements.planWrites(
+        payment.userId,
+        product.grant.plan,
+        expiresAt
+      );
+    } else {
+      grant = Inventory.grantWrites(
+        payment.userId,
+        product.grant.item,
+        product.grant.quantity,
+        `payment:${payment.reference}`
+      );
+    }
+
+    // The claim fails if the payment was already fulfilled (or failed),
+    // which rolls the grant back with it
+    try {
+      await prisma.$transaction([
+        prisma.payment.update({
+          where: { id: payment.id, status: { in: ['pending', 'expired'] } },
+          data: { status: 'confirmed', transactionId, confirmedAt: new Date() },
+        }),
+        ...grant,
+      ]);
+    } catch (error) {
+      if (
+        error instanceof Prisma.PrismaClientKnownRequestError &&
+        error.code === 'P2025'
+      ) {
+        return false; // Already fulfilled by another call
+      }
+      throw error;
+    }
+
+    if (product.grant.type === 'plan') {
+      await Entitlements.planChanged(
+        payment.userId,
+        product.grant.plan,
+        expiresAt
+      );
+    }
+
+    await AuditLog.recordSafely({
+      action: 'billing.payment_confirmed',
+      actorType: 'provider',
+      actorId: payment.provider,
+      targetType: 'payment',
+      targetId: payment.reference,
+      details: {
+        userId: payment.userId,
+        product: payment.product,
+        token: payment.token,
+        amount: payment.amount,
+        transactionId,
+        late: payment.status === 'expired',
+      },
+    });
+    await Payments.sendReceipt({ ...payment, confirmedAt: new Date() });
+    return true;
+  }
+
+  /**
+   * Email a receipt for a confirmed payment, once per payment
+   */
+  static async sendReceipt(payment: Payment): Promise<void> {
+    await Email.sendToUser(
+      payment.userId,
+      'receipt',
+      {
+        product: PRODUCTS[payment.product]?.name || payment.product,
+        amount: fromTokenUnits(payment.amount, payment.token),
+        currency: payment.token,
+        date: (payment.confirmedAt || payment.createdAt)
+          .toISOString()
+          .slice(0, 10),
+        reference: payment.reference,
+      },
+      `receipt:${payment.reference}`
+    );
+  }
+
+  /**
+   * A user's purchases, renewals and refunds across all providers, newest
+   * first. Pass the last record's createdAt as `before` for the next page.
+   */
+  static async getHistory(
+    userId: string,
+    options: { limit: number; before?: Date }
+  ): Promise<BillingRecord[]> {
+    const payments = await prisma.payment.findMany({
+      where: {
+        userId,
+        status: { in: BILLED_STATUSES },
+        ...(options.before && { createdAt: { lt: options.before } }),
+      },
+      orderBy: { createdAt: 'desc' },
+      take: options.limit,
+    });
+
+    return payments.map(payment => ({
+      reference: payment.reference,
+      provider: payment.provider,
+      kind: payment.kind,
+      product: payment.product,
+      productName: PRODUCTS[payment.product]?.name || payment.product,
+      status: payment.status,
+      currency: payment.token,
+      amount: payment.amount,
+      displayAmount: fromTokenUnits(payment.amount, payment.token),
+      transactionId: payment.transactionId,
+      createdAt: payment.createdAt.toISOString(),
+      confirmedAt: payment.confirmedAt?.toISOString() || null,
+    }));
+  }
+
+  /**
+   * Mark a pending payment failed
+   */
+  static async fail(payment: Payment, transactionId?: string): Promise<void> {
+    await prisma.payment.updateMany({
+      where: { id: payment.id, status: 'pending' },
+      data: { status: 'failed', transactionId },
+    });
+  }
+}
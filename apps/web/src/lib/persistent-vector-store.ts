@@ -62,6 +62,8 @@ export class PersistentVectorStore {
         metadata: userEmbedding.metadata,
         score: userEmbedding.score,
         vibeTags: userEmbedding.vibeTags,
+        // Top-level so scores can be filtered and compared by model version
+        modelVersion: userEmbedding.metadata.modelVersion,
       };
 
       // Add point to Qdrant
@@ -127,6 +129,20 @@ export class PersistentVectorStore {
     }
   }
 
+  /**
+   * Remove a user's embedding (e.g. on account erasure)
+   */
+  static async removeEmbedding(userId: string): Promise<boolean> {
+    try {
+      await qdrantClient.delete(COLLECTION_NAME, { points: [userId] });
+      await this.recalculateAllScores();
+      return true;
+    } catch (error) {
+      console.error('Error removing user embedding:', error);
+      return false;
+    }
+  }
+
   /**
    * Find most similar users to a given embedding
    */
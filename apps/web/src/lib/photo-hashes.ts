@@ -0,0 +1,211 @@
+/**
+ * Photo Hashes
+ * Perceptual hashes (pHash) of profile photos, for spotting the same photo
+ * on more than one account: a catfish reusing someone else's pictures, or
+ * one person running several accounts. Unlike a checksum, a pHash barely
+ * changes when a photo is resized, recompressed or lightly edited, so two
+ * copies of a photo are within a few bits of each other. Only each user's
+ * current photo is kept. Hashes are stored in bands so lookups stay on an
+ * index: photos within MAX_DISTANCE bits always share at least one band.
+ */
+
+import sharp from 'sharp';
+import prisma from './prisma';
+
+// Side of the downscaled image the DCT runs on
+const SAMPLE_SIZE = 32;
+
+// Side of the low-frequency block the hash is taken from (64 bits)
+const HASH_SIZE = 8;
+
+// Differing bits at which two photos count as the same. Four bands of 16
+// bits: within 3 bits, at least one band is identical.
+export const MAX_DISTANCE = 3;
+
+const BAND_COUNT = 4;
+
+// Set bits in each hex digit
+const NIBBLE_BITS = [0, 1, 1, 2, 1, 2, 2, 3, 1, 2, 2, 3, 2, 3, 3, 4];
+
+// cos((2x + 1)uπ / 2N) for the frequencies kept
+const COSINES = Array.from({ length: HASH_SIZE }, (_, u) =>
+  Array.from({ length: SAMPLE_SIZE }, (_, x) =>
+    Math.cos(((2 * x + 1) * u * Math.PI) / (2 * SAMPLE_SIZE))
+  )
+);
+
+export interface PhotoMatch {
+  userId: string;
+  distance: number;
+}
+
+function bands(hash: string) {
+  const width = hash.length / BAND_COUNT;
+  const [band0, band1, band2, band3] = Array.from(
+    { length: BAND_COUNT },
+    (_, i) => hash.slice(i * width, (i + 1) * width)
+  );
+  return { band0, band1, band2, band3 };
+}
+
+export class PhotoHashes {
+  /**
+   * The photo's 64-bit pHash, as 16 hex digits: the signs of its lowest
+   * frequencies relative to their median
+   */
+  static async compute(image: Buffer): Promise<string> {
+    const { data, info } = await sharp(image)
+      .greyscale()
+      .resize(SAMPLE_SIZE, SAMPLE_SIZE, { fit: 'fill' })
+      .raw()
+      .toBuffer({ resolveWithObject: true });
+    const pixel = (x: number, y: number) =>
+      data[(y * SAMPLE_SIZE + x) * info.channels];
+
+    // Separable 2D DCT, only for the HASH_SIZE lowest frequencies
+    const rows = Array.from({ length: SAMPLE_SIZE }, (_, y) =>
+      COSINES.map(cosine =>
+        cosine.reduce((sum, c, x) => sum + pixel(x, y) * c, 0)
+      )
+    );
+    const coefficients: number[] = [];
+    for (let v = 0; v < HASH_SIZE; v++) {
+      for (let u = 0; u < HASH_SIZE; u++) {
+        coefficients.push(
+          rows.reduce((sum, row, y) => sum + row[u] * COSINES[v][y], 0)
+        );
+      }
+    }
+
+    // The DC term is the average brightness, so it's left out of the median
+    const sorted = coefficients.slice(1).sort((a, b) => a - b);
+    const median = (sorted[31] + sorted[32]) / 2;
+
+    let hash = '';
+    for (let i = 0; i < coefficients.length; i += 4) {
+      let nibble = 0;
+      for (let bit = 0; bit < 4; bit++) {
+        nibble = (nibble << 1) | (coefficients[i + bit] > median ? 1 : 0);
+      }
+      hash += nibble.toString(16);
+    }
+    return hash;
+  }
+
+  /**
+   * Number of bits two hashes differ in
+   */
+  static distance(a: string, b: string): number {
+    let distance = 0;
+    for (let i = 0; i < a.length; i++) {
+      distance += NIBBLE_BITS[parseInt(a[i], 16) ^ parseInt(b[i], 16)];
+    }
+    return distance;
+  }
+
+  /**
+   * Other accounts whose current photo matches `hash`, closest first.
+   * Erased accounts don't count.
+   */
+  static async findMatches(
+    hash: string,
+    excludeUserId: string
+  ): Promise<PhotoMatch[]> {
+    const { band0, band1, band2, band3 } = bands(hash);
+    const candidates = await prisma.photoHash.findMany({
+      where: {
+        OR: [{ band0 }, { band1 }, { band2 }, { band3 }],
+        userId: { not: excludeUserId },
+        user: { status: { not: 'deleted' } },
+      },
+      select: { userId: true, hash: true },
+    });
+    return candidates
+      .map(candidate => ({
+        userId: candidate.userId,
+        distance: PhotoHashes.distance(hash, candidate.hash),
+      }))
+      .filter(match => match.distance <= MAX_DISTANCE)
+      .sort((a, b) => a.distance - b.distance);
+  }
+
+  /**
+   * Store the hash of the user's new photo. `matched` marks it as
+   * already in use elsewhere when it was uploaded, for the admin view.
+   */
+  static async record(
+    userId: string,
+    hash: string,
+    matched: boolean
+  ): Promise<void> {
+    const data = {
+      hash,
+      ...bands(hash),
+      matchedAt: matched ? new Date() : null,
+    };
+    await prisma.photoHash.upsert({
+      where: { userId },
+      create: { userId, ...data },
+      update: data,
+    });
+  }
+
+  /**
+   * Drop the user's hash once their photo is gone
+   */
+  static async forget(userId: string): Promise<void> {
+    await prisma.photoHash.deleteMany({ where: { userId } });
+  }
+
+  /**
+   * Photos found in use on another account when they were uploaded, most
+   * recent first, with the accounts they still match. Photos that no
+   * longer match anything (the other account changed its photo) are left
+   * out of the page.
+   */
+  static async listCollisions(limit: number, cursor?: string) {
+    const userSummary = {
+      id: true,
+      handle: true,
+      displayName: true,
+      status: true,
+      shadowbanned: true,
+      createdAt: true,
+    } as const;
+
+    const hashes = await prisma.photoHash.findMany({
+      where: { matchedAt: { not: null } },
+      include: { user: { select: userSummary } },
+      orderBy: [{ matchedAt: 'desc' }, { userId: 'desc' }],
+      take: limit,
+      ...(cursor && { cursor: { userId: cursor }, skip: 1 }),
+    });
+
+    const collisions = [];
+    for (const photo of hashes) {
+      const matches = await PhotoHashes.findMatches(photo.hash, photo.userId);
+      if (matches.length === 0) {
+        continue;
+      }
+      const users = await prisma.user.findMany({
+        where: { id: { in: matches.map(match => match.userId) } },
+        select: userSummary,
+      });
+      collisions.push({
+        user: photo.user,
+        hash: photo.hash,
+        matchedAt: photo.matchedAt,
+        matches: matches.flatMap(match => {
+          const user = users.find(u => u.id === match.userId);
+          return user ? [{ user, distance: match.distance }] : [];
+        }),
+      });
+    }
+
+    return {
+      collisions,
+      nextCursor:
+        hashes.length === limit ? hashes[hashes.length - 1].userId : null,
+    };
+  }
+}
@@ -0,0 +1,185 @@
+/**
+ * Photo Quality Gate
+ * Quick pre-scoring checks (resolution, lighting, blur, single face) so users
+ * get actionable feedback instead of generic scoring failures
+ */
+
+import { mlServiceClient } from './ml-service-client';
+
+export type PhotoQualityIssueCode =
+  | 'unreadable_image'
+  | 'resolution_too_low'
+  | 'photo_too_dark'
+  | 'photo_too_bright'
+  | 'photo_blurry'
+  | 'no_face_detected'
+  | 'multiple_faces';
+
+export interface PhotoQualityIssue {
+  code: PhotoQualityIssueCode;
+  message: string;
+}
+
+export interface PhotoQualityResult {
+  passed: boolean;
+  issues: PhotoQualityIssue[];
+  dimensions?: { width: number; height: number };
+  // False when the ML detector was unreachable and only local checks ran
+  detectorChecked: boolean;
+}
+
+// Thresholds for the gate; tuned to reject clearly unusable photos only
+const THRESHOLDS = {
+  minShortSide: 320, // px
+  minBrightness: 50, // mean luminance, 0-255
+  maxBrightness: 225,
+  minSharpness: 60, // variance of the Laplacian
+};
+
+const ISSUE_MESSAGES: Record<PhotoQualityIssueCode, string> = {
+  unreadable_image:
+    'We could not read this photo. Please upload a JPEG, PNG, or WebP image.',
+  resolution_too_low: `Photo resolution is too low. Please use a photo at least ${THRESHOLDS.minShortSide}px on its shortest side.`,
+  photo_too_dark:
+    'Photo is too dark. Try again in better lighting or facing a window.',
+  photo_too_bright:
+    'Photo is overexposed. Avoid direct flash or strong backlight.',
+  photo_blurry:
+    'Photo is blurry. Hold the camera steady and make sure your face is in focus.',
+  no_face_detected:
+    'No face detected. Please upload a clear, front-facing portrait.',
+  multiple_faces:
+    'More than one face detected. Please upload a photo of just yourself.',
+};
+
+function issue(code: PhotoQualityIssueCode): PhotoQualityIssue {
+  return { code, message: ISSUE_MESSAGES[code] };
+}
+
+/**
+ * Read image dimensions from PNG, JPEG, or WebP headers without decoding
+ */
+export function readImageDimensions(
+  buffer: Buffer
+): { width: number; height: number } | null {
+  // PNG: IHDR chunk immediately follows the 8-byte signature
+  if (buffer.length >= 24 && buffer.readUInt32BE(0) === 0x89504e47) {
+    return { width: buffer.readUInt32BE(16), height: buffer.readUInt32BE(20) };
+  }
+
+  // JPEG: walk markers until a start-of-frame segment
+  if (buffer.length >= 4 && buffer[0] === 0xff && buffer[1] === 0xd8) {
+    let offset = 2;
+    while (offset + 9 < buffer.length) {
+      if (buffer[offset] !== 0xff) {
+        return null;
+      }
+      const marker = buffer[offset + 1];
+      const length = buffer.readUInt16BE(offset + 2);
+      const isStartOfFrame =
+        marker >= 0xc0 &&
+        marker <= 0xcf &&
+        marker !== 0xc4 &&
+        marker !== 0xc8 &&
+        marker !== 0xcc;
+
+      if (isStartOfFrame) {
+        return {
+          height: buffer.readUInt16BE(offset + 5),
+          width: buffer.readUInt16BE(offset + 7),
+        };
+      }
+      offset += 2 + length;
+    }
+    return null;
+  }
+
+  // WebP: RIFF container with VP8, VP8L, or VP8X chunk
+  if (
+    buffer.length >= 30 &&
+    buffer.toString('ascii', 0, 4) === 'RIFF' &&
+    buffer.toString('ascii', 8, 12) === 'WEBP'
+  ) {
+    const chunk = buffer.toString('ascii', 12, 16);
+    if (chunk === 'VP8 ') {
+      return {
+        width: buffer.readUInt16LE(26) & 0x3fff,
+        height: buffer.readUInt16LE(28) & 0x3fff,
+      };
+    }
+    if (chunk === 'VP8L') {
+      const bits = buffer.readUInt32LE(21);
+      return {
+        width: (bits & 0x3fff) + 1,
+        height: ((bits >> 14) & 0x3fff) + 1,
+      };
+    }
+    if (chunk === 'VP8X') {
+      return {
+        width: buffer.readUIntLE(24, 3) + 1,
+        height: buffer.readUIntLE(27, 3) + 1,
+      };
+    }
+  }
+
+  return null;
+}
+
+/**
+ * Run the quality gate against a base64 encoded photo
+ */
+export async function checkPhotoQuality(
+  imageBase64: string
+): Promise<PhotoQualityResult> {
+  const buffer = Buffer.from(
+    imageBase64.replace(/^data:image\/\w+;base64,/, ''),
+    'base64'
+  );
+
+  // Local checks first: these don't need the ML API
+  const dimensions = readImageDimensions(buffer);
+  if (!dimensions) {
+    return {
+      passed: false,
+      issues: [issue('unreadable_image')],
+      detectorChecked: false,
+    };
+  }
+
+  const issues: PhotoQualityIssue[] = [];
+  if (
+    Math.min(dimensions.width, dimensions.height) < THRESHOLDS.minShortSide
+  ) {
+    issues.push(issue('resolution_too_low'));
+    return { passed: false, issues, dimensions, detectorChecked: false };
+  }
+
+  // Detector checks; if the ML API is unavailable let scoring handle it
+  const inspection = await mlServiceClient.inspectImage(imageBase64);
+  if (!inspection) {
+    return { passed: true, issues, dimensions, detectorChecked: false };
+  }
+
+  if (inspection.brightness < THRESHOLDS.minBrightness) {
+    issues.push(issue('photo_too_dark'));
+  } else if (inspection.brightness > THRESHOLDS.maxBrightness) {
+    issues.push(issue('photo_too_bright'));
+  }
+
+  if (inspection.sharpness < THRESHOLDS.minSharpness) {
+    issues.push(issue('photo_blurry'));
+  }
+
+  if (inspection.faceCount === 0) {
+    issues.push(issue('no_face_detected'));
+  } else if (inspection.faceCount > 1) {
+    issues.push(issue('multiple_faces'));
+  }
+
+  return {
+    passed: issues.length === 0,
+    issues,
+    dimensions,
+    detectorChecked: true,
+  };
+}
@@ -0,0 +1,283 @@
+/**
+ * Photo Reveal
+ * Optional mode where a user's photo starts heavily blurred for everyone
+ * else and clears up in stages as a match's conversation goes on. The
+ * message count is kept on the match, counted from the chat service's
+ * message.sent events on the domain event stream from its own
+ * checkpoint; profile payloads point every
+ * user's photo at /api/users/[id]/photo, which works out the viewer's
+ * stage itself (the URL's stage is only for client caching) and serves
+ * the matching blurred variant, cached in media storage when configured,
+ * or the original.
+ */
+
+import sharp from 'sharp';
+import prisma from './prisma';
+import redis from './redis';
+import { sha256 } from './aws-sigv4';
+import { MediaStorage, mediaStorageEnabled } from './media-storage';
+import { upstreamFetch } from './http-client';
+import { ImageSanitizer } from './image-sanitizer';
+import { ProfilePhotos } from './profile-photos';
+import { EVENT_STREAM_KEY, DomainEvent } from './event-bus';
+import { ScheduledTask } from './scheduler';
+
+// Messages a match needs to reach each stage, and how blurry the photo is
+// there (Gaussian sigma, on a photo at most VARIANT_MAX_SIZE wide)
+export const REVEAL_STAGES = [
+  { messages: 0, blurSigma: 40 },
+  { messages: 10, blurSigma: 20 },
+  { messages: 25, blurSigma: 8 },
+  { messages: 50, blurSigma: 0 },
+];
+
+const VARIANT_MAX_SIZE = 640;
+const SOURCE_TIMEOUT_MS = 10_000;
+
+const CHECKPOINT_NAME = 'photo-reveal';
+const STREAM_BATCH_SIZE = 500;
+
+export interface PhotoRevealState {
+  stage: number;
+  stages: number;
+  blurred: boolean;
+  // Messages until the next stage; null once fully revealed
+  messagesToNext: number | null;
+}
+
+function toState(messageCount: number): PhotoRevealState {
+  const stage = REVEAL_STAGES.reduce(
+    (reached, { messages }, index) =>
+      messageCount >= messages ? index : reached,
+    0
+  );
+  const next = REVEAL_STAGES[stage + 1];
+  return {
+    stage,
+    stages: REVEAL_STAGES.length,
+    blurred: REVEAL_STAGES[stage].blurSigma > 0,
+    messagesToNext: next ? next.messages - messageCount : null,
+  };
+}
+
+/**
+ * The photo's bytes, from media storage, an http(s) URL or a data URI
+ */
+async function loadSource(
+  image: string
+): Promise<{ body: Buffer; contentType: string }> {
+  const key = ProfilePhotos.storedKey(image);
+  if (key) {
+    const body = await MediaStorage.download(key);
+    if (!body) {
+      throw new Error(`Stored photo is missing: ${key}`);
+    }
+    return { body, contentType: 'image/jpeg' };
+  }
+  const dataUri = image.match(/^data:([^;]+);base64,(.*)$/);
+  if (dataUri) {
+    return { body: Buffer.from(dataUri[2], 'base64'), contentType: dataUri[1] };
+  }
+  const response = await upstreamFetch(
+    'photo-source',
+    image,
+    {},
+    { retries: 1, timeoutMs: SOURCE_TIMEOUT_MS }
+  );
+  if (!response.ok) {
+    throw new Error(`Photo fetch failed: ${response.status}`);
+  }
+  return {
+    body: Buffer.from(await response.arrayBuffer()),
+    contentType: response.headers.get('content-type') || 'image/jpeg',
+  };
+}
+
+export class PhotoReveal {
+  /**
+   * Count a message in the match's conversation
+   */
+  static async recordMessage(matchId: string): Promise<void> {
+    // updateMany, so a match that's gone doesn't stall the stream
+    await prisma.match.updateMany({
+      where: { id: matchId },
+      data: { messageCount: { increment: 1 } },
+    });
+  }
+
+  /**
+   * Count messages from new stream events
+   */
+  static async ingest(): Promise<number> {
+    const checkpoint = await prisma.analyticsCheckpoint.findUnique({
+      where: { name: CHECKPOINT_NAME },
+    });
+    let position = checkpoint?.position || '0';
+    let processed = 0;
+
+    for (;;) {
+      const entries = await redis.xrange(
+        EVENT_STREAM_KEY,
+        `(${position}`,
+        '+',
+        'COUNT',
+        STREAM_BATCH_SIZE
+      );
+      if (entries.length === 0) {
+        break;
+      }
+
+      for (const [, fields] of entries) {
+        const body = fields[fields.indexOf('event') + 1];
+        const event = JSON.parse(body) as DomainEvent<Record<string, unknown>>;
+        if (
+          event.type === 'message.sent' &&
+          typeof event.payload.matchId === 'string'
+        ) {
+          await PhotoReveal.recordMessage(event.payload.matchId);
+        }
+      }
+
+      position = entries[entries.length - 1][0];
+      await prisma.analyticsCheckpoint.upsert({
+        where: { name: CHECKPOINT_NAME },
+        create: { name: CHECKPOINT_NAME, position },
+        update: { position },
+      });
+      processed += entries.length;
+
+      if (entries.length < STREAM_BATCH_SIZE) {
+        break;
+      }
+    }
+    return processed;
+  }
+
+  /**
+   * Where the viewer stands with each opted-in owner's photo. Owners
+   * without reveal mode (and the viewer themself) are left out.
+   */
+  static async statesFor(
+    viewerId: string,
+    owners: { id: string; photoRevealMode: boolean }[]
+  ): Promise<Map<string, PhotoRevealState>> {
+    const states = new Map<string, PhotoRevealState>();
+    const ownerIds = owners
+      .filter(owner => owner.photoRevealMode && owner.id !== viewerId)
+      .map(owner => owner.id);
+    if (ownerIds.length === 0) {
+      return states;
+    }
+
+    const matches = await prisma.match.findMany({
+      where: {
+        deletedAt: null,
+        OR: [
+          { user1Id: viewerId, user2Id: { in: ownerIds } },
+          { user2Id: viewerId, user1Id: { in: ownerIds } },
+        ],
+      },
+      select: { user1Id: true, user2Id: true, messageCount: true },
+    });
+    const counts = new Map(
+      matches.map(match => [
+        match.user1Id === viewerId ? match.user2Id : match.user1Id,
+        match.messageCount,
+      ])
+    );
+    for (const ownerId of ownerIds) {
+      states.set(ownerId, toState(counts.get(ownerId) ?? 0));
+    }
+    return states;
+  }
+
+  /**
+   * The photo URL to show the viewer. Every photo goes through the photo
+   * route, which works out what the viewer may see, so the stored URL is
+   * never handed out; `v` changes with the photo and `stage` with the
+   * viewer's reveal stage, for client caching.
+   */
+  static photoUrl(
+    ownerId: string,
+    profileImage: string | null,
+    state: PhotoRevealState | undefined
+  ): string | null {
+    if (!profileImage) {
+      return null;
+    }
+    const version = sha256(profileImage).slice(0, 12);
+    const stage = state?.blurred ? `&stage=${state.stage}` : '';
+    return `/api/users/${ownerId}/photo?v=${version}${stage}`;
+  }
+
+  /**
+   * Where the stored variants of a photo start. Keyed by the source too,
+   * so a new photo gets new variants.
+   */
+  static variantPrefix(ownerId: string, profileImage: string): string {
+    return `photo-variants/${ownerId}/${sha256(profileImage).slice(0, 16)}-`;
+  }
+
+  /**
+   * The photo blurred for `stage`, as JPEG. `regenerate` makes it afresh
+   * and replaces the stored copy, e.g. after the stages change.
+   */
+  static async variant(
+    ownerId: string,
+    profileImage: string,
+    stage: number,
+    regenerate = false
+  ): Promise<Buffer> {
+    const key =
+      PhotoReveal.variantPrefix(ownerId, profileImage) + `${stage}.jpg`;
+    const cached =
+      mediaStorageEnabled() && !regenerate
+        ? await MediaStorage.download(key)
+        : null;
+    if (cached) {
+      return cached;
+    }
+
+    const source = await loadSource(profileImage);
+    const variant = await sharp(source.body)
+      .rotate()
+      .resize(VARIANT_MAX_SIZE, VARIANT_MAX_SIZE, {
+        fit: 'inside',
+        withoutEnlargement: true,
+      })
+      .blur(REVEAL_STAGES[stage].blurSigma)
+      .jpeg({ quality: 70 })
+      .toBuffer();
+    if (mediaStorageEnabled()) {
+      await MediaStorage.upload(key, variant, 'image/jpeg', {
+        kind: 'photo_variant',
+        ownerId,
+      });
+    }
+    return variant;
+  }
+
+  /**
+   * The original photo, for viewers who've unlocked it. Photos set before
+   * uploads were sanitized have their metadata stripped on the way out.
+   */
+  static async original(
+    profileImage: string
+  ): Promise<{ body: Buffer; contentType: string }> {
+    const source = await loadSource(profileImage);
+    if (ProfilePhotos.storedKey(profileImage)) {
+      return source;
+    }
+    const sanitized = await ImageSanitizer.sanitize(source.body);
+    if (!sanitized) {
+      throw new Error('Profile photo is not a readable image');
+    }
+    return { body: sanitized.body, contentType: sanitized.contentType };
+  }
+}
+
+export const photoRevealIngest: ScheduledTask = {
+  name: 'photo-reveal-ingest',
+  everyMs: parseInt(process.env.PHOTO_REVEAL_INTERVAL_MS || '60000'),
+  run: () => PhotoReveal.ingest(),
+};
@@ -0,0 +1,156 @@
+/**
+ * Places
+ * The directory of cities and university campuses users can pick from.
+ * Profiles, discovery filters, announcement audiences and travel mode all
+ * refer to places by ID. Admins manage the directory; retired places are
+ * deactivated rather than deleted so existing profiles keep them.
+ */
+
+import { Place } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { singleflight } from './singleflight';
+
+export const PLACE_KINDS = ['city', 'campus'] as const;
+
+export type PlaceKind = (typeof PLACE_KINDS)[number];
+
+// The directory changes rarely and is read on every profile write
+const CACHE_MS = 60 * 1000;
+
+let cache: { places: Place[]; loadedAt: number } | null = null;
+
+export interface PlaceInput {
+  id: string;
+  kind: PlaceKind;
+  name: string;
+  nameTh?: string | null;
+  country: string;
+  cityId?: string | null;
+  lat: number;
+  lng: number;
+  timezone?: string | null;
+  active?: boolean;
+  sortOrder?: number;
+}
+
+export type PlaceUpdate = Partial<Omit<PlaceInput, 'id' | 'kind'>>;
+
+export type PlaceResult =
+  | { status: 'saved'; place: Place }
+  | { status: 'already_exists' }
+  | { status: 'not_found' }
+  | { status: 'invalid_city' };
+
+async function loadAll(): Promise<Place[]> {
+  if (cache && Date.now() - cache.loadedAt < CACHE_MS) {
+    return cache.places;
+  }
+  return singleflight('places', 'all', async () => {
+    const places = await prisma.place.findMany({
+      orderBy: [{ kind: 'asc' }, { sortOrder: 'asc' }, { name: 'asc' }],
+    });
+    cache = { places, loadedAt: Date.now() };
+    return places;
+  });
+}
+
+/**
+ * Campuses must sit in an existing city
+ */
+async function validCity(
+  kind: PlaceKind,
+  cityId: string | null | undefined
+): Promise<boolean> {
+  if (kind === 'city') {
+    return !cityId;
+  }
+  if (!cityId) {
+    return false;
+  }
+  const city = await prisma.place.findUnique({ where: { id: cityId } });
+  return city?.kind === 'city';
+}
+
+export class Places {
+  /**
+   * Places of a kind, or all of them. Inactive places are left out unless
+   * asked for.
+   */
+  static async list(
+    kind?: PlaceKind,
+    includeInactive = false
+  ): Promise<Place[]> {
+    const places = await loadAll();
+    return places.filter(
+      place =>
+        (!kind || place.kind === kind) && (includeInactive || place.active)
+    );
+  }
+
+  static async get(id: string): Promise<Place | null> {
+    const places = await loadAll();
+    return places.find(place => place.id === id) ?? null;
+  }
+
+  /**
+   * Whether `id` is an active place of this kind, i.e. one users can pick
+   */
+  static async isSelectable(id: string, kind: PlaceKind): Promise<boolean> {
+    const place = await Places.get(id);
+    return Boolean(place && place.kind === kind && place.active);
+  }
+
+  static async create(
+    input: PlaceInput,
+    adminId: string
+  ): Promise<PlaceResult> {
+    if (!(await validCity(input.kind, input.cityId))) {
+      return { status: 'invalid_city' };
+    }
+    if (await prisma.place.findUnique({ where: { id: input.id } })) {
+      return { status: 'already_exists' };
+    }
+
+    const place = await prisma.place.create({ data: input });
+    cache = null;
+    await AuditLog.record({
+      action: 'admin.place_created',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'place',
+      targetId: place.id,
+      details: { kind: place.kind, name: place.name },
+    });
+    return { status: 'saved', place };
+  }
+
+  static async update(
+    id: string,
+    update: PlaceUpdate,
+    adminId: string
+  ): Promise<PlaceResult> {
+    const existing = await prisma.place.findUnique({ where: { id } });
+    if (!existing) {
+      return { status: 'not_found' };
+    }
+    if (
+      update.cityId !== undefined &&
+      !(await validCity(existing.kind as PlaceKind, update.cityId))
+    ) {
+      return { status: 'invalid_city' };
+    }
+
+    const place = await prisma.place.update({ where: { id }, data: update });
+    cache = null;
+    await AuditLog.record({
+      action: 'admin.place_updated',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'place',
+      targetId: id,
+      details: { changes: Object.keys(update) },
+    });
+    return { status: 'saved', place };
+  }
+}
@@ -0,0 +1,201 @@
+/**
+ * Policies
+ * Versioned terms of service and privacy policy. Admins publish versions
+ * with an effective date; once a version takes effect, `authMiddleware`
+ * turns away users who haven't accepted it (and every other current
+ * policy) until they do. Each acceptance is kept per version, with when
+ * and where it happened.
+ */
+
+import { PolicyVersion } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+
+export const POLICY_KINDS = ['terms', 'privacy'] as const;
+
+export type PolicyKind = (typeof POLICY_KINDS)[number];
+
+// Checked on every authenticated request; new versions are rare
+const CACHE_MS = 60 * 1000;
+
+let cache: { versions: PolicyVersion[]; loadedAt: number } | null = null;
+
+export interface PolicyVersionInput {
+  kind: PolicyKind;
+  version: string;
+  url: string;
+  summary?: string;
+  // Defaults to now
+  effectiveAt?: Date;
+}
+
+export type PublishResult =
+  | { status: 'published'; policy: PolicyVersion }
+  | { status: 'already_exists' };
+
+export type AcceptResult =
+  | { status: 'accepted'; pending: PolicyVersion[] }
+  | { status: 'not_current' };
+
+/**
+ * What clients are shown of a version
+ */
+export function toPolicySummary(policy: PolicyVersion) {
+  return {
+    id: policy.id,
+    kind: policy.kind,
+    version: policy.version,
+    url: policy.url,
+    summary: policy.summary,
+    effectiveAt: policy.effectiveAt,
+  };
+}
+
+async function loadAll(): Promise<PolicyVersion[]> {
+  if (cache && Date.now() - cache.loadedAt < CACHE_MS) {
+    return cache.versions;
+  }
+  const versions = await prisma.policyVersion.findMany({
+    orderBy: { effectiveAt: 'desc' },
+  });
+  cache = { versions, loadedAt: Date.now() };
+  return versions;
+}
+
+export class Policies {
+  /**
+   * The version of each policy in effect now. A kind with nothing
+   * published yet doesn't need accepting.
+   */
+  static async current(): Promise<PolicyVersion[]> {
+    const now = new Date();
+    const versions = await loadAll();
+    return POLICY_KINDS.flatMap(kind => {
+      const version = versions.find(
+        policy => policy.kind === kind && policy.effectiveAt <= now
+      );
+      return version ? [version] : [];
+    });
+  }
+
+  /**
+   * Current versions the user hasn't accepted
+   */
+  static async pendingFor(userId: string): Promise<PolicyVersion[]> {
+    const current = await Policies.current();
+    if (current.length === 0) {
+      return [];
+    }
+    const accepted = await prisma.policyAcceptance.findMany({
+      where: {
+        userId,
+        policyVersionId: { in: current.map(policy => policy.id) },
+      },
+      select: { policyVersionId: true },
+    });
+    const acceptedIds = new Set(accepted.map(a => a.policyVersionId));
+    return current.filter(policy => !acceptedIds.has(policy.id));
+  }
+
+  /**
+   * Record the user accepting policy versions. Only current versions can
+   * be accepted; accepting one twice keeps the first acceptance.
+   */
+  static async accept(
+    userId: string,
+    versionIds: string[],
+    ipAddress: string | null
+  ): Promise<AcceptResult> {
+    const currentIds = new Set((await Policies.current()).map(p => p.id));
+    if (versionIds.some(id => !currentIds.has(id))) {
+      return { status: 'not_current' };
+    }
+
+    await prisma.$transaction(
+      versionIds.map(policyVersionId =>
+        prisma.policyAcceptance.upsert({
+          where: { userId_policyVersionId: { userId, policyVersionId } },
+          create: { userId, policyVersionId, ipAddress },
+          update: {},
+        })
+      )
+    );
+    await AuditLog.recordSafely({
+      action: 'user.policies_accepted',
+      actorType: 'user',
+      actorId: userId,
+      targetType: 'user',
+      targetId: userId,
+      details: { policyVersionIds: versionIds },
+      ipAddress,
+    });
+    return { status: 'accepted', pending: await Policies.pendingFor(userId) };
+  }
+
+  /**
+   * Every version the user has accepted, newest first
+   */
+  static async history(userId: string) {
+    const acceptances = await prisma.policyAcceptance.findMany({
+      where: { userId },
+      include: { policyVersion: true },
+      orderBy: { acceptedAt: 'desc' },
+    });
+    return acceptances.map(acceptance => ({
+      kind: acceptance.policyVersion.kind,
+      version: acceptance.policyVersion.version,
+      url: acceptance.policyVersion.url,
+      acceptedAt: acceptance.acceptedAt,
+    }));
+  }
+
+  /**
+   * All published versions, newest first
+   */
+  static async list(kind?: PolicyKind): Promise<PolicyVersion[]> {
+    return prisma.policyVersion.findMany({
+      where: kind ? { kind } : undefined,
+      orderBy: { effectiveAt: 'desc' },
+    });
+  }
+
+  /**
+   * Publish a new version. Users must accept it once it takes effect.
+   */
+  static async publish(
+    input: PolicyVersionInput,
+    adminId: string
+  ): Promise<PublishResult> {
+    const existing = await prisma.policyVersion.findUnique({
+      where: { kind_version: { kind: input.kind, version: input.version } },
+    });
+    if (existing) {
+      return { status: 'already_exists' };
+    }
+
+    const policy = await prisma.policyVersion.create({
+      data: {
+        kind: input.kind,
+        version: input.version,
+        url: input.url,
+        summary: input.summary,
+        effectiveAt: input.effectiveAt ?? new Date(),
+        createdBy: adminId,
+      },
+    });
+    cache = null;
+    await AuditLog.record({
+      action: 'admin.policy_published',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'policy_version',
+      targetId: policy.id,
+      details: {
+        kind: policy.kind,
+        version: policy.version,
+        effectiveAt: policy.effectiveAt,
+      },
+    });
+    return { status: 'published', policy };
+  }
+}
@@ -0,0 +1,134 @@
+/**
+ * Presence
+ * Tracks who is online and when everyone was last active, in Redis. Any
+ * authenticated request counts as activity; idle clients send heartbeats
+ * (and a socket server would call `touch` on connect). The database's
+ * lastSeen is refreshed at most every few minutes per user, for ranking.
+ * Users can hide their last seen, which also hides everyone else's from
+ * them.
+ */
+
+import prisma from './prisma';
+import redis from './redis';
+
+// Sorted set of user IDs scored by last activity (ms)
+const LAST_SEEN_KEY = 'presence:last_seen';
+
+const persistedKey = (userId: string) => `presence:persisted:${userId}`;
+
+// Active this recently counts as online; clients heartbeat a bit faster
+export const ONLINE_WINDOW_SECONDS = 120;
+
+const RECENTLY_ACTIVE_MS = 24 * 60 * 60 * 1000;
+
+const PERSIST_INTERVAL_SECONDS = 5 * 60;
+
+// Entries this old are dropped from Redis; the database still has them
+const RETENTION_MS = 30 * 24 * 60 * 60 * 1000;
+
+export type PresenceStatus = 'online' | 'recently_active' | 'offline';
+
+export interface UserPresence {
+  status: PresenceStatus;
+  lastSeenAt: string | null;
+}
+
+function toPresence(lastSeenMs: number | null): UserPresence {
+  if (lastSeenMs === null) {
+    return { status: 'offline', lastSeenAt: null };
+  }
+  const age = Date.now() - lastSeenMs;
+  return {
+    status:
+      age < ONLINE_WINDOW_SECONDS * 1000
+        ? 'online'
+        : age < RECENTLY_ACTIVE_MS
+          ? 'recently_active'
+          : 'offline',
+    lastSeenAt: new Date(lastSeenMs).toISOString(),
+  };
+}
+
+export class Presence {
+  /**
+   * Mark the user active now. Never throws.
+   */
+  static async touch(userId: string): Promise<void> {
+    try {
+      const now = Date.now();
+      await redis.zadd(LAST_SEEN_KEY, now, userId);
+
+      const due = await redis.set(
+        persistedKey(userId),
+        '1',
+        'EX',
+        PERSIST_INTERVAL_SECONDS,
+        'NX'
+      );
+      if (due) {
+        await prisma.user.update({
+          where: { id: userId },
+          data: { lastSeen: new Date(now) },
+        });
+        await redis.zremrangebyscore(LAST_SEEN_KEY, 0, now - RETENTION_MS);
+      }
+    } catch (error) {
+      console.error('Error recording presence:', error);
+    }
+  }
+
+  /**
+   * Presence of each of `userIds` as the viewer may see it. Users who hide
+   * their last seen are left out, and a viewer who hides theirs gets
+   * nothing back.
+   */
+  static async lookup(
+    viewerId: string,
+    userIds: string[]
+  ): Promise<Map<string, UserPresence>> {
+    const presence = new Map<string, UserPresence>();
+    const viewer = await prisma.user.findUnique({
+      where: { id: viewerId },
+      select: { hideLastSeen: true },
+    });
+    if (!viewer || viewer.hideLastSeen || userIds.length === 0) {
+      return presence;
+    }
+
+    const visible = await prisma.user.findMany({
+      where: {
+        id: { in: userIds },
+        hideLastSeen: false,
+        shadowbanned: false,
+        status: 'active',
+        deletedAt: null,
+      },
+      select: { id: true, lastSeen: true },
+    });
+    if (visible.length === 0) {
+      return presence;
+    }
+
+    const scores = await redis.zmscore(
+      LAST_SEEN_KEY,
+      ...visible.map(user => user.id)
+    );
+    visible.forEach((user, index) => {
+      const score = scores[index];
+      // Fall back to the database for users Redis has forgotten
+      presence.set(
+        user.id,
+        toPresence(score !== null ? Number(score) : user.lastSeen.getTime())
+      );
+    });
+    return presence;
+  }
+
+  /**
+   * Drop a user from presence tracking (account erasure)
+   */
+  static async forget(userId: string): Promise<void> {
+    await redis.zrem(LAST_SEEN_KEY, userId);
+    await redis.del(persistedKey(userId));
+  }
+}
@@ -1,15 +1,106 @@
 import { PrismaClient } from '@prisma/client'
+import { counter, histogram } from './metrics'
+import { fieldEncryption } from './field-encryption'
+import { Secrets } from './secrets'
 
-const prismaClientSingleton = () => {
-  return new PrismaClient()
+// Pool size and how long a query waits for a free connection; Prisma's
+// defaults (num_cpus * 2 + 1, 10s) apply when unset
+const POOL_SIZE = process.env.DATABASE_POOL_SIZE
+const POOL_TIMEOUT_SECONDS = process.env.DATABASE_POOL_TIMEOUT_SECONDS
+
+// Queries slower than this are logged
+const SLOW_QUERY_MS = parseInt(process.env.DATABASE_SLOW_QUERY_MS || '500')
+
+// How long queries already running on a rotated-out client get to finish
+const ROTATION_DRAIN_MS = 30_000
+
+const queryDuration = histogram(
+  'aurum_db_query_duration_seconds',
+  'Database query latency by model, operation and database'
+)
+
+const queryErrors = counter(
+  'aurum_db_query_errors_total',
+  'Failed database queries by model, operation and database'
+)
+
+// The primary uses DATABASE_URL when it's set, read each time a client is
+// built so a rotated one is picked up; replicas always pass their own URL
+function datasourceUrl(url?: string): string | undefined {
+  const base = url ?? process.env.DATABASE_URL
+  if (!base || (!POOL_SIZE && !POOL_TIMEOUT_SECONDS)) {
+    return base
+  }
+  const params = new URLSearchParams()
+  if (POOL_SIZE) params.set('connection_limit', POOL_SIZE)
+  if (POOL_TIMEOUT_SECONDS) params.set('pool_timeout', POOL_TIMEOUT_SECONDS)
+  return `${base}${base.includes('?') ? '&' : '?'}${params}`
+}
+
+/**
+ * An instrumented client for the primary, or for the database at `url`
+ * (a read replica), encrypting PII fields at rest (see lib/field-encryption)
+ */
+export function createPrismaClient(url?: string) {
+  const database = url ? 'replica' : 'primary'
+  return new PrismaClient({ datasourceUrl: datasourceUrl(url) }).$extends({
+    query: {
+      async $allOperations({ model, operation, args, query }) {
+        const labels = { model: model ?? 'raw', operation, database }
+        const stopTimer = queryDuration.startTimer(labels)
+        try {
+          return await query(args)
+        } catch (error) {
+          queryErrors.inc(labels)
+          throw error
+        } finally {
+          const seconds = stopTimer()
+          if (seconds * 1000 >= SLOW_QUERY_MS) {
+            const ms = Math.round(seconds * 1000)
+            console.warn(
+              `🐢 Slow ${database} query: ${labels.model}.${operation} ` +
+                `took ${ms}ms`
+            )
+          }
+        }
+      },
+    },
+  }).$extends(fieldEncryption)
 }
 
+export type Database = ReturnType<typeof createPrismaClient>
+
 declare global {
-  var prisma: undefined | ReturnType<typeof prismaClientSingleton>
+  var prisma: undefined | Database
 }
 
-const prisma = globalThis.prisma ?? prismaClientSingleton()
+let client = globalThis.prisma ?? createPrismaClient()
 
-export default prisma
+if (process.env.NODE_ENV !== 'production') globalThis.prisma = client
 
-if (process.env.NODE_ENV !== 'production') globalThis.prisma = prisma
+/**
+ * The primary's client. It stays the same object when DATABASE_URL
+ * rotates (see lib/secrets): calls go to a client rebuilt with the new
+ * credentials, and the old one is disconnected once it has drained.
+ */
+const prisma = new Proxy({} as Database, {
+  get(_target, property) {
+    const value = Reflect.get(client, property)
+    return typeof value === 'function' ? value.bind(client) : value
+  },
+})
+
+Secrets.onRotate(rotated => {
+  if (!rotated.includes('DATABASE_URL')) {
+    return
+  }
+  const previous = client
+  client = createPrismaClient()
+  if (process.env.NODE_ENV !== 'production') globalThis.prisma = client
+  console.log('🔐 Database client rebuilt for the rotated DATABASE_URL')
+  setTimeout(() => {
+    void previous.$disconnect()
+  }, ROTATION_DRAIN_MS).unref()
+})
+
+export default prisma
@@ -0,0 +1,283 @@
+/**
+ * Privacy Requests
+ * GDPR/PDPA data subject requests: users ask for a copy of their data or
+ * its erasure, and admins work the queue against a statutory deadline.
+ */
+
+import { PrivacyRequest, Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { AccountData, AccountExport } from './account-data';
+import { Notifications } from './notifications';
+import { Email } from './email';
+import { AuditLog } from './audit-log';
+import { EventBus } from './event-bus';
+
+export const PRIVACY_REQUEST_TYPES = ['access', 'erasure'] as const;
+
+export type PrivacyRequestType = (typeof PRIVACY_REQUEST_TYPES)[number];
+
+export const PRIVACY_REQUEST_STATUSES = [
+  'pending',
+  'processing',
+  'completed',
+  'rejected',
+] as const;
+
+export type PrivacyRequestStatus = (typeof PRIVACY_REQUEST_STATUSES)[number];
+
+const DEADLINE_DAYS = parseInt(
+  process.env.PRIVACY_REQUEST_DEADLINE_DAYS || '30'
+);
+const EXPORT_RETENTION_DAYS = parseInt(
+  process.env.PRIVACY_EXPORT_RETENTION_DAYS || '7'
+);
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+const OPEN_STATUSES = ['pending', 'processing'];
+
+// What users see about their own requests
+const userFields = {
+  id: true,
+  type: true,
+  status: true,
+  dueAt: true,
+  exportExpiresAt: true,
+  note: true,
+  completedAt: true,
+  createdAt: true,
+} as const;
+
+export interface PrivacyRequestFilters {
+  status?: PrivacyRequestStatus;
+  type?: PrivacyRequestType;
+  // Open requests past their deadline
+  overdue?: boolean;
+  limit: number;
+  cursor?: string;
+}
+
+export class PrivacyRequests {
+  /**
+   * File a request. Returns the already-open request of the same type
+   * instead of filing a duplicate.
+   */
+  static async file(
+    userId: string,
+    type: PrivacyRequestType
+  ): Promise<{ request: PrivacyRequest; created: boolean }> {
+    const open = await prisma.privacyRequest.findFirst({
+      where: { userId, type, status: { in: OPEN_STATUSES } },
+    });
+    if (open) {
+      return { request: open, created: false };
+    }
+
+    const request = await prisma.privacyRequest.create({
+      data: {
+        userId,
+        type,
+        dueAt: new Date(Date.now() + DEADLINE_DAYS * DAY_MS),
+      },
+    });
+
+    await AuditLog.record({
+      action: `privacy.${type}_requested`,
+      actorType: 'user',
+      actorId: userId,
+      targetType: 'privacy_request',
+      targetId: request.id,
+    });
+    if (type === 'erasure') {
+      // In case someone else is signed in as them
+      await Email.sendToUser(userId, 'security_erasure_requested', {
+        dueDate: request.dueAt.toISOString().slice(0, 10),
+      });
+    }
+    await EventBus.publish('privacy.request_filed', {
+      requestId: request.id,
+      userId,
+      type,
+    });
+    return { request, created: true };
+  }
+
+  /**
+   * A user's own requests, newest first
+   */
+  static async listForUser(userId: string) {
+    return prisma.privacyRequest.findMany({
+      where: { userId },
+      select: userFields,
+      orderBy: { createdAt: 'desc' },
+    });
+  }
+
+  /**
+   * The export from a user's completed access request, or null if there
+   * isn't one (or it has expired)
+   */
+  static async getExport(
+    userId: string,
+    requestId: string
+  ): Promise<Prisma.JsonValue | null> {
+    const request = await prisma.privacyRequest.findFirst({
+      where: {
+        id: requestId,
+        userId,
+        type: 'access',
+        status: 'completed',
+        exportExpiresAt: { gt: new Date() },
+      },
+      select: { exportData: true },
+    });
+    return request?.exportData ? JSON.parse(request.exportData) : null;
+  }
+
+  /**
+   * Requests for the admin queue, most urgent first
+   */
+  static async list(filters: PrivacyRequestFilters) {
+    const where: Prisma.PrivacyRequestWhereInput = {
+      ...(filters.status && { status: filters.status }),
+      ...(filters.type && { type: filters.type }),
+      ...(filters.overdue && {
+        status: { in: OPEN_STATUSES },
+        dueAt: { lt: new Date() },
+      }),
+    };
+
+    return prisma.privacyRequest.findMany({
+      where,
+      select: { ...userFields, userId: true, handledBy: true },
+      orderBy: [{ dueAt: 'asc' }, { id: 'asc' }],
+      take: filters.limit,
+      ...(filters.cursor && { cursor: { id: filters.cursor }, skip: 1 }),
+    });
+  }
+
+  /**
+   * Mark a pending request as being worked on
+   */
+  static async start(
+    requestId: string,
+    adminId: string
+  ): Promise<PrivacyRequest | null> {
+    const started = await prisma.privacyRequest.updateMany({
+      where: { id: requestId, status: 'pending' },
+      data: { status: 'processing', handledBy: adminId },
+    });
+    if (started.count === 0) {
+      return null;
+    }
+    return prisma.privacyRequest.findUnique({ where: { id: requestId } });
+  }
+
+  /**
+   * Carry out an open request: generate the export, or erase the account
+   */
+  static async fulfill(
+    requestId: string,
+    adminId: string,
+    note?: string
+  ): Promise<PrivacyRequest | null> {
+    const request = await prisma.privacyRequest.findUnique({
+      where: { id: requestId },
+    });
+    if (!request || !OPEN_STATUSES.includes(request.status)) {
+      return null;
+    }
+
+    let exportData: AccountExport | null = null;
+    if (request.type === 'access') {
+      exportData = await AccountData.export(request.userId);
+    } else {
+      await AccountData.erase(request.userId);
+    }
+
+    const completed = await prisma.privacyRequest.update({
+      where: { id: requestId },
+      data: {
+        status: 'completed',
+        handledBy: adminId,
+        note,
+        completedAt: new Date(),
+        ...(exportData && {
+          // Stored as JSON text so it can be encrypted
+          exportData: JSON.stringify(exportData),
+          exportExpiresAt: new Date(
+            Date.now() + EXPORT_RETENTION_DAYS * DAY_MS
+          ),
+        }),
+      },
+    });
+
+    await AuditLog.record({
+      action: `privacy.${request.type}_fulfilled`,
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'user',
+      targetId: request.userId,
+      details: {
+        requestId,
+        overdue: request.dueAt < new Date(),
+        note: note ?? null,
+      },
+    });
+    if (request.type === 'access') {
+      await Notifications.notify(request.userId, {
+        type: 'privacy_export_ready',
+        variables: { days: EXPORT_RETENTION_DAYS },
+        path: '/settings/privacy',
+        data: { requestId },
+      });
+      await Email.sendToUser(
+        request.userId,
+        'privacy_export_ready',
+        { days: EXPORT_RETENTION_DAYS },
+        `export_ready:${requestId}`
+      );
+    }
+    await EventBus.publish('privacy.request_fulfilled', {
+      requestId,
+      userId: request.userId,
+      type: request.type,
+    });
+    return completed;
+  }
+
+  /**
+   * Decline an open request, with the reason the user will see
+   */
+  static async reject(
+    requestId: string,
+    adminId: string,
+    note: string
+  ): Promise<PrivacyRequest | null> {
+    const rejected = await prisma.privacyRequest.updateMany({
+      where: { id: requestId, status: { in: OPEN_STATUSES } },
+      data: {
+        status: 'rejected',
+        handledBy: adminId,
+        note,
+        completedAt: new Date(),
+      },
+    });
+    if (rejected.count === 0) {
+      return null;
+    }
+
+    const request = (await prisma.privacyRequest.findUnique({
+      where: { id: requestId },
+    }))!;
+    await AuditLog.record({
+      action: `privacy.${request.type}_rejected`,
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'user',
+      targetId: request.userId,
+      details: { requestId, note },
+    });
+    return request;
+  }
+}
@@ -0,0 +1,168 @@
+/**
+ * Profile Photos
+ * Photos users upload as their profile photo. Each is sanitized (see
+ * lib/image-sanitizer) before it's stored in media storage, and the
+ * profile points at it as `media:<key>`; older profiles may still hold a
+ * URL or data URI. Profile payloads never expose either: photos are
+ * served through /api/users/[id]/photo (see lib/photo-reveal).
+ *
+ * Uploads are virus-scanned first (see lib/upload-scanner). A photo
+ * already in use on another account is flagged for duplicate review, or
+ * turned down when DUPLICATE_PHOTO_ACTION is "reject".
+ */
+
+import { randomUUID } from 'crypto';
+import prisma from './prisma';
+import { ImageSanitizer } from './image-sanitizer';
+import { MediaStorage } from './media-storage';
+import { PhotoHashes } from './photo-hashes';
+import { Quarantine } from './quarantine';
+import { UploadScanner } from './upload-scanner';
+import { DuplicateAccounts } from './duplicate-accounts';
+import { counter } from './metrics';
+
+const STORED_PREFIX = 'media:';
+
+export const PROFILE_PHOTO_MAX_UPLOAD_BYTES = 10 * 1024 * 1024;
+
+// How long a photo URL handed outside the app works
+const EXTERNAL_URL_TTL_SECONDS = 15 * 60;
+
+const REJECT_DUPLICATES = process.env.DUPLICATE_PHOTO_ACTION === 'reject';
+
+const duplicatePhotos = counter(
+  'aurum_duplicate_photos_total',
+  'Uploaded profile photos already in use on another account, by action'
+);
+
+export type ProfilePhotoResult =
+  | { status: 'saved'; profileImage: string; width: number; height: number }
+  | { status: 'too_large' }
+  | { status: 'unreadable' }
+  | { status: 'duplicate' }
+  | { status: 'infected' }
+  | { status: 'scan_unavailable' };
+
+export class ProfilePhotos {
+  /**
+   * The storage key of a stored profile photo, or null for a URL or data
+   * URI
+   */
+  static storedKey(profileImage: string | null): string | null {
+    return profileImage?.startsWith(STORED_PREFIX)
+      ? profileImage.slice(STORED_PREFIX.length)
+      : null;
+  }
+
+  /**
+   * A URL for the photo that works outside the app (e.g. for a safety
+   * contact). Stored photos get a pre-signed URL that expires, never a
+   * CDN one.
+   */
+  static externalUrl(profileImage: string | null): string | null {
+    const key = ProfilePhotos.storedKey(profileImage);
+    return key
+      ? MediaStorage.presignedUrl(key, EXTERNAL_URL_TTL_SECONDS)
+      : profileImage;
+  }
+
+  /**
+   * Sanitize and store a new profile photo, replacing the current one
+   */
+  static async replace(
+    userId: string,
+    upload: Buffer
+  ): Promise<ProfilePhotoResult> {
+    if (upload.length > PROFILE_PHOTO_MAX_UPLOAD_BYTES) {
+      return { status: 'too_large' };
+    }
+    const scan = await UploadScanner.scan(upload, 'profile_photo');
+    if (scan.status === 'unavailable') {
+      return { status: 'scan_unavailable' };
+    }
+    if (scan.status === 'infected') {
+      await Quarantine.hold(
+        userId,
+        'profile_photo',
+        upload,
+        scan.scanner,
+        scan.signature
+      );
+      return { status: 'infected' };
+    }
+
+    const photo = await ImageSanitizer.sanitize(upload);
+    if (!photo) {
+      return { status: 'unreadable' };
+    }
+    const hash = await PhotoHashes.compute(photo.body);
+    const matches = await PhotoHashes.findMatches(hash, userId);
+    if (matches.length > 0) {
+      duplicatePhotos.inc({
+        action: REJECT_DUPLICATES ? 'rejected' : 'flagged',
+      });
+      if (REJECT_DUPLICATES) {
+        return { status: 'duplicate' };
+      }
+    }
+
+    const key = `profile-photos/${userId}/${randomUUID()}.jpg`;
+    await MediaStorage.upload(key, photo.body, photo.contentType, {
+      kind: 'profile_photo',
+      ownerId: userId,
+    });
+    const previous = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { profileImage: true },
+    });
+    const profileImage = `${STORED_PREFIX}${key}`;
+    await prisma.user.update({
+      where: { id: userId },
+      data: { profileImage, blurredImage: null },
+    });
+
+    // Its blurred variants are left to the media GC
+    const previousKey = ProfilePhotos.storedKey(previous?.profileImage ?? null);
+    if (previousKey) {
+      await MediaStorage.remove(previousKey);
+    }
+
+    await PhotoHashes.record(userId, hash, matches.length > 0);
+    for (const match of matches) {
+      await DuplicateAccounts.recordPhotoMatch(
+        userId,
+        match.userId,
+        match.distance
+      );
+    }
+    return {
+      status: 'saved',
+      profileImage,
+      width: photo.width,
+      height: photo.height,
+    };
+  }
+
+  /**
+   * Take the user's photo off their profile
+   */
+  static async remove(userId: string): Promise<boolean> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { profileImage: true },
+    });
+    if (!user?.profileImage) {
+      return false;
+    }
+    await prisma.user.update({
+      where: { id: userId },
+      data: { profileImage: null, blurredImage: null },
+    });
+    await PhotoHashes.forget(userId);
+    const key = ProfilePhotos.storedKey(user.profileImage);
+    if (key) {
+      await MediaStorage.remove(key);
+    }
+    return true;
+  }
+}
@@ -0,0 +1,161 @@
+/**
+ * Profile Prompts
+ * Users pick a few prompts from a curated bank and answer them on their
+ * profile ("A perfect Sunday is..."), giving discovery cards more to go on
+ * than a bio and tags. Answers with links or contact details are refused
+ * up front; anything else that breaks a content policy is taken down by
+ * moderators (see Takedowns) and hidden from everyone but its author.
+ */
+
+import { ProfilePrompt } from '@prisma/client';
+import prisma from './prisma';
+
+export interface PromptDefinition {
+  id: string;
+  text: string;
+  category: 'about_me' | 'campus' | 'dating' | 'fun';
+}
+
+// Add new prompts freely; retire one by removing it, which hides existing
+// answers to it. Never reword a prompt into a different question.
+export const PROMPT_BANK: PromptDefinition[] = [
+  {
+    id: 'perfect_sunday',
+    text: 'A perfect Sunday is...',
+    category: 'about_me',
+  },
+  { id: 'known_for', text: 'My friends know me for...', category: 'about_me' },
+  { id: 'unpopular_opinion', text: 'My unpopular opinion', category: 'fun' },
+  { id: 'campus_spot', text: 'Best spot on campus', category: 'campus' },
+  { id: 'study_fuel', text: 'My study fuel is...', category: 'campus' },
+  { id: 'late_night_food', text: 'Go-to late night food', category: 'campus' },
+  { id: 'first_date', text: 'Ideal first date', category: 'dating' },
+  { id: 'green_flag', text: 'A green flag I look for', category: 'dating' },
+  { id: 'lets_debate', text: "Let's debate this:", category: 'fun' },
+  { id: 'learning', text: "Something I'm learning right now", category: 'fun' },
+];
+
+export const MAX_PROFILE_PROMPTS = 3;
+export const MAX_PROMPT_ANSWER_LENGTH = 200;
+
+// Contact details and links belong in a match's chat, not on a card
+const CONTACT_PATTERNS = [
+  /https?:\/\/|www\.|\b[a-z0-9-]+\.(com|net|org|io|me|co|th)\b/i,
+  /(?:\+?\d[\s-]?){9,}/,
+  /\b(line|ig|instagram|telegram|tg|wa|whatsapp)\s*(id)?\s*[:@]/i,
+  /@[a-z0-9_.]{3,}/i,
+];
+
+const promptsById = new Map(PROMPT_BANK.map(prompt => [prompt.id, prompt]));
+
+export interface PromptAnswer {
+  promptId: string;
+  prompt: string;
+  answer: string;
+}
+
+// The author's own view also says whether moderators removed it
+export interface OwnPromptAnswer extends PromptAnswer {
+  removed: boolean;
+}
+
+export type PromptUpdate =
+  | { status: 'saved'; prompts: OwnPromptAnswer[] }
+  | { status: 'contact_details'; promptId: string };
+
+export function hasContactDetails(text: string): boolean {
+  return CONTACT_PATTERNS.some(pattern => pattern.test(text));
+}
+
+/**
+ * `text` with links and contact details replaced by "[redacted]"
+ */
+export function redactContactDetails(text: string): string {
+  return CONTACT_PATTERNS.reduce(
+    (redacted, pattern) =>
+      redacted.replace(new RegExp(pattern.source, 'gi'), '[redacted]'),
+    text
+  );
+}
+
+function toAnswer(row: ProfilePrompt): PromptAnswer | null {
+  const prompt = promptsById.get(row.promptId);
+  return prompt
+    ? { promptId: row.promptId, prompt: prompt.text, answer: row.answer }
+    : null;
+}
+
+export class ProfilePrompts {
+  /**
+   * The user's own prompt answers, in profile order, including removed ones
+   */
+  static async getOwn(userId: string): Promise<OwnPromptAnswer[]> {
+    const rows = await prisma.profilePrompt.findMany({
+      where: { userId },
+      orderBy: { position: 'asc' },
+    });
+    return rows.flatMap(row => {
+      const answer = toAnswer(row);
+      return answer ? [{ ...answer, removed: row.removedAt !== null }] : [];
+    });
+  }
+
+  /**
+   * Replace the user's prompt answers. Re-saving a removed answer
+   * unchanged keeps it removed; editing it puts it back up.
+   */
+  static async set(
+    userId: string,
+    answers: { promptId: string; answer: string }[]
+  ): Promise<PromptUpdate> {
+    const flagged = answers.find(answer => hasContactDetails(answer.answer));
+    if (flagged) {
+      return { status: 'contact_details', promptId: flagged.promptId };
+    }
+
+    const rows = await prisma.profilePrompt.findMany({ where: { userId } });
+    const existing = new Map(rows.map(row => [row.promptId, row]));
+    await prisma.$transaction([
+      prisma.profilePrompt.deleteMany({
+        where: {
+          userId,
+          promptId: { notIn: answers.map(answer => answer.promptId) },
+        },
+      }),
+      ...answers.map((answer, position) => {
+        const previous = existing.get(answer.promptId);
+        const removedAt =
+          previous?.answer === answer.answer ? previous.removedAt : null;
+        return prisma.profilePrompt.upsert({
+          where: { userId_promptId: { userId, promptId: answer.promptId } },
+          create: { userId, ...answer, position },
+          update: { answer: answer.answer, position, removedAt },
+        });
+      }),
+    ]);
+    return { status: 'saved', prompts: await ProfilePrompts.getOwn(userId) };
+  }
+
+  /**
+   * Visible prompt answers for each of `userIds`, in profile order
+   */
+  static async visibleFor(
+    userIds: string[]
+  ): Promise<Map<string, PromptAnswer[]>> {
+    const byUser = new Map<string, PromptAnswer[]>();
+    if (userIds.length === 0) {
+      return byUser;
+    }
+    const rows = await prisma.profilePrompt.findMany({
+      where: { userId: { in: userIds }, removedAt: null },
+      orderBy: { position: 'asc' },
+    });
+    for (const row of rows) {
+      const answer = toAnswer(row);
+      if (answer) {
+        byUser.set(row.userId, [...(byUser.get(row.userId) ?? []), answer]);
+      }
+    }
+    return byUser;
+  }
+}
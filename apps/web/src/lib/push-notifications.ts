@@ -0,0 +1,76 @@
+/**
+ * Push Notifications
+ * Sends World App mini app notifications through the Developer Portal API
+ */
+
+import { upstreamFetch } from './http-client';
+
+const NOTIFICATION_API_URL =
+  'https://developer.worldcoin.org/api/v2/minikit/send-notification';
+
+export interface PushNotification {
+  title: string;
+  message: string;
+  // Mini app path opened when the notification is tapped
+  path?: string;
+}
+
+/**
+ * Whether push notifications are configured for this deployment
+ */
+export function pushNotificationsEnabled(): boolean {
+  return Boolean(
+    process.env.WORLD_APP_API_KEY && process.env.NEXT_PUBLIC_WORLDCOIN_APP_ID
+  );
+}
+
+/**
+ * Send a notification to World App users by wallet address.
+ * Returns false (without throwing) if delivery isn't possible.
+ */
+export async function sendPushNotification(
+  walletAddresses: string[],
+  notification: PushNotification
+): Promise<boolean> {
+  if (!pushNotificationsEnabled() || walletAddresses.length === 0) {
+    return false;
+  }
+
+  const appId = process.env.NEXT_PUBLIC_WORLDCOIN_APP_ID!;
+
+  try {
+    const response = await upstreamFetch(
+      'worldcoin',
+      NOTIFICATION_API_URL,
+      {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          Authorization: `Bearer ${process.env.WORLD_APP_API_KEY}`,
+        },
+        body: JSON.stringify({
+          app_id: appId,
+          wallet_addresses: walletAddresses,
+          title: notification.title,
+          message: notification.message,
+          mini_app_path: `worldapp://mini-app?app_id=${appId}&path=${encodeURIComponent(notification.path || '/')}`,
+        }),
+      },
+      { timeoutMs: 5000 }
+    );
+
+    if (!response.ok) {
+      console.error(
+        'Push notification rejected:',
+        response.status,
+        await response.text()
+      );
+      return false;
+    }
+
+    return true;
+  } catch (error) {
+    console.error('Error sending push notification:', error);
+    return false;
+  }
+}
@@ -0,0 +1,125 @@
+/**
+ * Quarantine
+ * Uploads that failed the virus scan (see lib/upload-scanner). The file is
+ * kept under quarantine/ in media storage, which nothing links to, and a
+ * moderation report is filed against the uploader. Admins review held
+ * uploads and purge them; the media GC removes a held copy once its record
+ * is gone or purged.
+ */
+
+import { randomUUID } from 'crypto';
+import { QuarantinedUpload } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { MediaStorage } from './media-storage';
+import { Reports } from './reports';
+import { ScanSource } from './upload-scanner';
+
+export const QUARANTINE_PREFIX = 'quarantine/';
+
+export type PurgeResult =
+  | { status: 'purged'; upload: QuarantinedUpload }
+  | { status: 'already_purged' }
+  | { status: 'not_found' };
+
+export class Quarantine {
+  /**
+   * Hold an infected upload instead of storing it
+   */
+  static async hold(
+    userId: string,
+    source: ScanSource,
+    body: Buffer,
+    scanner: string,
+    signature: string
+  ): Promise<QuarantinedUpload> {
+    const key = `${QUARANTINE_PREFIX}${userId}/${randomUUID()}`;
+    await MediaStorage.upload(key, body, 'application/octet-stream', {
+      kind: 'quarantined',
+      ownerId: userId,
+    });
+    const upload = await prisma.quarantinedUpload.create({
+      data: {
+        userId,
+        source,
+        key,
+        scanner,
+        signature,
+        sizeBytes: body.length,
+      },
+    });
+
+    console.warn('☣️ Quarantined infected upload:', {
+      userId,
+      source,
+      signature,
+    });
+    await AuditLog.record({
+      action: 'media.quarantined',
+      actorType: 'system',
+      targetType: 'user',
+      targetId: userId,
+      details: { quarantineId: upload.id, source, scanner, signature },
+    });
+    await Reports.createSystemReport(userId, 'other', {
+      source: 'upload_scan',
+      quarantineId: upload.id,
+      upload: source,
+      signature,
+    });
+    return upload;
+  }
+
+  /**
+   * Quarantined uploads with the given status, newest first
+   */
+  static async list(status: string, limit: number, cursor?: string) {
+    return prisma.quarantinedUpload.findMany({
+      where: { status },
+      orderBy: [{ createdAt: 'desc' }, { id: 'desc' }],
+      take: limit,
+      ...(cursor && { cursor: { id: cursor }, skip: 1 }),
+    });
+  }
+
+  /**
+   * Delete a held upload's file, keeping the record
+   */
+  static async purge(id: string, adminId: string): Promise<PurgeResult> {
+    const upload = await prisma.quarantinedUpload.findUnique({
+      where: { id },
+    });
+    if (!upload) {
+      return { status: 'not_found' };
+    }
+    if (upload.status === 'purged') {
+      return { status: 'already_purged' };
+    }
+
+    await MediaStorage.remove(upload.key);
+    const purged = await prisma.quarantinedUpload.update({
+      where: { id },
+      data: { status: 'purged', purgedBy: adminId, purgedAt: new Date() },
+    });
+    await AuditLog.record({
+      action: 'admin.quarantine_purged',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'user',
+      targetId: upload.userId,
+      details: { quarantineId: id, signature: upload.signature },
+    });
+    return { status: 'purged', upload: purged };
+  }
+
+  /**
+   * Of `keys`, those still held
+   */
+  static async heldKeys(keys: string[]): Promise<Set<string>> {
+    const held = await prisma.quarantinedUpload.findMany({
+      where: { key: { in: keys }, status: 'held' },
+      select: { key: true },
+    });
+    return new Set(held.map(upload => upload.key));
+  }
+}
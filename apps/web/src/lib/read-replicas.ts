@@ -0,0 +1,167 @@
+/**
+ * Read Replicas
+ * Sends heavy reads that tolerate a little lag (discovery decks, likes
+ * lists) to the replicas in DATABASE_REPLICA_URLS; all writes stay on the
+ * primary. Two guards keep stale data away: a user who just wrote reads
+ * from the primary for a few seconds, and a replica whose heartbeat falls
+ * too far behind is skipped until it catches up.
+ */
+
+import prisma, { createPrismaClient, Database } from './prisma';
+import redis from './redis';
+import { gauge } from './metrics';
+import { Secrets } from './secrets';
+
+function replicaUrls(): string[] {
+  return (process.env.DATABASE_REPLICA_URLS || '')
+    .split(',')
+    .map(url => url.trim())
+    .filter(Boolean);
+}
+
+// Replicas further behind than this are skipped
+const MAX_LAG_MS = parseInt(process.env.DATABASE_REPLICA_MAX_LAG_MS || '5000');
+
+// How long a user's reads stay on the primary after they write
+const STICKY_SECONDS = parseInt(
+  process.env.DATABASE_REPLICA_STICKY_SECONDS || '10'
+);
+
+const HEARTBEAT_INTERVAL_MS = 5 * 1000;
+
+const ROTATION_DRAIN_MS = 30 * 1000;
+
+// Every app instance beats on the same row
+const HEARTBEAT_ID = 'primary';
+
+const recentWriteKey = (userId: string) => `db:recent_write:${userId}`;
+
+const replicaLag = gauge(
+  'aurum_db_replica_lag_seconds',
+  'Replication lag of each read replica, from the heartbeat'
+);
+
+interface Replica {
+  client: Database;
+  // Unhealthy until the first heartbeat check says otherwise
+  healthy: boolean;
+}
+
+function connect(urls: string[]): Replica[] {
+  return urls.map(url => ({ client: createPrismaClient(url), healthy: false }));
+}
+
+const replicas: Replica[] = connect(replicaUrls());
+
+// Rebuilt with the new credentials when they rotate; the old clients are
+// disconnected once the reads already sent to them are done
+Secrets.onRotate(rotated => {
+  if (!rotated.includes('DATABASE_REPLICA_URLS')) {
+    return;
+  }
+  const previous = replicas.splice(
+    0,
+    replicas.length,
+    ...connect(replicaUrls())
+  );
+  lastCheck = 0;
+  setTimeout(() => {
+    for (const replica of previous) {
+      void replica.client.$disconnect();
+    }
+  }, ROTATION_DRAIN_MS).unref();
+});
+
+let lastCheck = 0;
+let next = 0;
+
+/**
+ * Beat on the primary, then see how far behind it each replica's copy of
+ * the beat is. Replicas always trail by up to one beat interval, so a
+ * replica is stale only when it lags more than MAX_LAG_MS beyond that.
+ */
+async function checkReplicas(): Promise<void> {
+  const now = new Date();
+  await prisma.replicationHeartbeat.upsert({
+    where: { id: HEARTBEAT_ID },
+    create: { id: HEARTBEAT_ID, beatAt: now },
+    update: { beatAt: now },
+  });
+
+  await Promise.all(
+    replicas.map(async (replica, index) => {
+      try {
+        const beat = await replica.client.replicationHeartbeat.findUnique({
+          where: { id: HEARTBEAT_ID },
+        });
+        const lagMs = beat ? now.getTime() - beat.beatAt.getTime() : Infinity;
+        replica.healthy = lagMs <= MAX_LAG_MS + HEARTBEAT_INTERVAL_MS;
+        replicaLag.set(
+          Number.isFinite(lagMs) ? Math.max(lagMs, 0) / 1000 : -1,
+          { replica: String(index) }
+        );
+      } catch (error) {
+        console.error(`Read replica ${index} check failed:`, error);
+        replica.healthy = false;
+      }
+    })
+  );
+}
+
+function scheduleCheck() {
+  if (Date.now() - lastCheck < HEARTBEAT_INTERVAL_MS) {
+    return;
+  }
+  lastCheck = Date.now();
+  void checkReplicas().catch(error => {
+    console.error('Read replica heartbeat failed:', error);
+  });
+}
+
+export class ReadReplicas {
+  /**
+   * The client to read from on behalf of `userId`: a healthy replica in
+   * turn, or the primary when there is none or the user wrote recently
+   */
+  static async client(userId?: string): Promise<Database> {
+    if (replicas.length === 0) {
+      return prisma;
+    }
+    scheduleCheck();
+
+    const healthy = replicas.filter(replica => replica.healthy);
+    if (healthy.length === 0) {
+      return prisma;
+    }
+    if (userId) {
+      const recentWrite = await redis
+        .exists(recentWriteKey(userId))
+        .catch(() => 1); // Unsure, so stay on the primary
+      if (recentWrite) {
+        return prisma;
+      }
+    }
+
+    next = (next + 1) % healthy.length;
+    return healthy[next].client;
+  }
+
+  /**
+   * Keep the user's reads on the primary while their write replicates.
+   * Never throws.
+   */
+  static async markWrite(...userIds: string[]): Promise<void> {
+    if (replicas.length === 0) {
+      return;
+    }
+    try {
+      await Promise.all(
+        userIds.map(userId =>
+          redis.set(recentWriteKey(userId), '1', 'EX', STICKY_SECONDS)
+        )
+      );
+    } catch (error) {
+      console.error('Error recording recent write:', error);
+    }
+  }
+}
@@ -3,12 +3,7 @@
  * Provides caching functionality for facial scores and leaderboard data
  */
 
-import Redis from "ioredis";
-
-// Initialize Redis client
-const redis = new Redis(process.env.REDIS_URL || "redis://redis:6379", {
-  maxRetriesPerRequest: null,
-});
+import redis from "@/lib/redis";
 
 // Cache TTL in seconds (24 hours)
 const CACHE_TTL = 24 * 60 * 60;
@@ -94,11 +89,4 @@ export class RedisCache {
       console.error("Error invalidating leaderboard cache:", error);
     }
   }
-
-  /**
-   * Close Redis connection
-   */
-  static async close(): Promise<void> {
-    await redis.quit();
-  }
 }
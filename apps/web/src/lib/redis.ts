@@ -0,0 +1,55 @@
+/**
+ * Shared Redis Client
+ * Single ioredis connection reused by gateway modules that need Redis.
+ * ioredis pipelines commands over one connection, so there's no pool to
+ * size; connection state is exported as metrics instead. When REDIS_URL
+ * rotates (see lib/secrets), the connection reconnects with the new one.
+ */
+
+import Redis from 'ioredis';
+import { counter, gauge } from './metrics';
+import { Secrets } from './secrets';
+
+const connectedGauge = gauge(
+  'aurum_redis_connected',
+  'Whether the shared Redis connection is ready (1) or not (0)'
+);
+
+const reconnectCounter = counter(
+  'aurum_redis_reconnects_total',
+  'Reconnection attempts of the shared Redis connection'
+);
+
+const redis = new Redis(process.env.REDIS_URL || 'redis://redis:6379', {
+  // Required by BullMQ, which blocks on the same connection
+  maxRetriesPerRequest: null,
+  connectTimeout: parseInt(process.env.REDIS_CONNECT_TIMEOUT_MS || '10000'),
+  keepAlive: 30000,
+});
+
+redis.on('ready', () => connectedGauge.set(1));
+redis.on('close', () => connectedGauge.set(0));
+redis.on('reconnecting', () => reconnectCounter.inc());
+
+redis.on('error', error => {
+  console.error('Redis client error:', error);
+});
+
+Secrets.onRotate(rotated => {
+  if (!rotated.includes('REDIS_URL') || !process.env.REDIS_URL) {
+    return;
+  }
+  const url = new URL(process.env.REDIS_URL);
+  // ioredis reads its options again on every reconnect
+  Object.assign(redis.options, {
+    host: url.hostname,
+    port: parseInt(url.port || '6379'),
+    username: decodeURIComponent(url.username) || undefined,
+    password: decodeURIComponent(url.password) || undefined,
+    db: parseInt(url.pathname.slice(1) || '0'),
+  });
+  redis.disconnect(true);
+  console.log('🔐 Redis reconnecting for the rotated REDIS_URL');
+});
+
+export default redis;
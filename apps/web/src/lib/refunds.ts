@@ -0,0 +1,190 @@
+/**
+ * Refunds and Chargebacks
+ * Reverses a payment: records the reversal in billing history, takes back
+ * what the payment granted, and flags accounts with repeated chargebacks.
+ */
+
+import { Payment, Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { Entitlements } from './entitlements';
+import { Inventory } from './inventory';
+import { PRODUCTS } from './payments';
+import { EventBus } from './event-bus';
+import { AuditLog } from './audit-log';
+
+export type ReversalKind = 'refund' | 'chargeback';
+
+export interface Reversal {
+  kind: ReversalKind;
+  // Provider's refund/dispute ID; makes processing idempotent
+  providerReference: string;
+  amount: string; // Base units
+  // Whether the whole payment was reversed (partial refunds keep the grant)
+  full: boolean;
+  reason?: string;
+}
+
+const CHARGEBACK_ABUSE_THRESHOLD = parseInt(
+  process.env.CHARGEBACK_ABUSE_THRESHOLD || '2'
+);
+const CHARGEBACK_WINDOW_DAYS = parseInt(
+  process.env.CHARGEBACK_WINDOW_DAYS || '180'
+);
+
+const REVERSED_STATUS: Record<ReversalKind, string> = {
+  refund: 'refunded',
+  chargeback: 'charged_back',
+};
+
+/**
+ * Take back a plan purchase by removing the time it paid for
+ */
+async function revokePlanTime(userId: string, durationDays: number) {
+  const subscription = await prisma.subscription.findUnique({
+    where: { userId },
+  });
+  if (!subscription || subscription.plan === 'free') {
+    return;
+  }
+
+  const expiresAt = subscription.expiresAt
+    ? new Date(
+        subscription.expiresAt.getTime() - durationDays * 24 * 60 * 60 * 1000
+      )
+    : null;
+  if (!expiresAt || expiresAt <= new Date()) {
+    await Entitlements.setPlan(userId, 'free');
+    await Entitlements.setSubscriptionStatus(userId, 'canceled');
+    return;
+  }
+
+  await prisma.$transaction([
+    prisma.subscription.update({ where: { userId }, data: { expiresAt } }),
+    prisma.entitlement.updateMany({
+      where: { userId, source: { startsWith: 'plan:' } },
+      data: { expiresAt },
+    }),
+  ]);
+  await Entitlements.invalidate(userId);
+}
+
+/**
+ * Flag the account once its recent chargebacks reach the threshold
+ */
+async function checkChargebackAbuse(userId: string): Promise<boolean> {
+  const since = new Date(
+    Date.now() - CHARGEBACK_WINDOW_DAYS * 24 * 60 * 60 * 1000
+  );
+  const chargebacks = await prisma.payment.count({
+    where: { userId, kind: 'chargeback', createdAt: { gte: since } },
+  });
+  if (chargebacks < CHARGEBACK_ABUSE_THRESHOLD) {
+    return false;
+  }
+
+  const flagged = await prisma.user.updateMany({
+    where: { id: userId, billingFlaggedAt: null },
+    data: { billingFlaggedAt: new Date() },
+  });
+  if (flagged.count === 0) {
+    return true; // Already flagged
+  }
+
+  await AuditLog.record({
+    action: 'billing.abuse_flagged',
+    actorType: 'system',
+    targetType: 'user',
+    targetId: userId,
+    details: { chargebacks, windowDays: CHARGEBACK_WINDOW_DAYS },
+  });
+  await EventBus.publish('billing.abuse_flagged', { userId, chargebacks });
+  return true;
+}
+
+export class Refunds {
+  /**
+   * Apply a provider refund or chargeback to a payment. Returns false if
+   * this reversal was already processed.
+   */
+  static async reverse(payment: Payment, reversal: Reversal): Promise<boolean> {
+    const reference = `${reversal.kind}:${reversal.providerReference}`;
+
+    // The reversal row is the idempotency claim
+    try {
+      await prisma.payment.create({
+        data: {
+          reference,
+          userId: payment.userId,
+          provider: payment.provider,
+          kind: reversal.kind,
+          product: payment.product,
+          token: payment.token,
+          amount: reversal.amount,
+          status: 'confirmed',
+          confirmedAt: new Date(),
+        },
+      });
+    } catch (error) {
+      if (
+        error instanceof Prisma.PrismaClientKnownRequestError &&
+        error.code === 'P2002'
+      ) {
+        return false;
+      }
+      throw error;
+    }
+
+    try {
+      if (reversal.full) {
+        await prisma.payment.update({
+          where: { id: payment.id },
+          data: { status: REVERSED_STATUS[reversal.kind] },
+        });
+
+        const grant = PRODUCTS[payment.product]?.grant;
+        if (grant?.type === 'plan') {
+          await revokePlanTime(payment.userId, grant.durationDays);
+        } else if (grant?.type === 'consumable') {
+          await Inventory.revoke(
+            payment.userId,
+            grant.item,
+            grant.quantity,
+            `revoke:${reference}`
+          );
+        }
+      }
+
+      await AuditLog.record({
+        action: `billing.${reversal.kind}`,
+        actorType: 'provider',
+        actorId: payment.provider,
+        targetType: 'payment',
+        targetId: payment.reference,
+        details: {
+          userId: payment.userId,
+          providerReference: reversal.providerReference,
+          amount: reversal.amount,
+          token: payment.token,
+          full: reversal.full,
+          reason: reversal.reason ?? null,
+        },
+      });
+    } catch (error) {
+      // Release the claim so a provider retry can finish the reversal
+      await prisma.payment.delete({ where: { reference } });
+      throw error;
+    }
+
+    await EventBus.publish(`billing.${reversal.kind}`, {
+      userId: payment.userId,
+      paymentReference: payment.reference,
+      provider: payment.provider,
+      full: reversal.full,
+    });
+
+    if (reversal.kind === 'chargeback') {
+      await checkChargebackAbuse(payment.userId);
+    }
+    return true;
+  }
+}
@@ -0,0 +1,396 @@
+/**
+ * Reports
+ * User-filed abuse reports and the moderation queue that resolves them.
+ * Every moderation decision is written to the audit log.
+ */
+
+import { Report } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { EventBus } from './event-bus';
+import { MessageDeletion } from './message-deletion';
+import { Bans, BanReason } from './bans';
+import { NotificationPush } from './notification-push';
+import { TrustScore } from './trust-score';
+
+export const REPORT_REASONS = [
+  'spam',
+  'harassment',
+  'fake_profile',
+  'underage',
+  'scam',
+  'inappropriate_content',
+  'other',
+] as const;
+
+export type ReportReason = (typeof REPORT_REASONS)[number];
+
+export const MODERATION_ACTIONS = [
+  'dismiss',
+  'warn',
+  'hide_photo',
+  'temp_ban',
+  'permaban',
+] as const;
+
+export type ModerationAction = (typeof MODERATION_ACTIONS)[number];
+
+const DEFAULT_TEMP_BAN_HOURS = 72;
+
+const userSummary = {
+  id: true,
+  handle: true,
+  displayName: true,
+  profileImage: true,
+  blurredImage: true,
+  bio: true,
+  status: true,
+  bannedUntil: true,
+  createdAt: true,
+} as const;
+
+async function warnUser(userId: string, reason: string) {
+  await EventBus.publish('user.warned', { userId, reason });
+
+  await NotificationPush.send(userId, { type: 'guidelines_warning' });
+}
+
+export interface Resolution {
+  action: ModerationAction;
+  // temp_ban only
+  durationHours?: number;
+  note?: string;
+}
+
+export class Reports {
+  /**
+   * File a report, capturing the reported message and current photo
+   */
+  static async create(
+    reporterId: string,
+    input: {
+      reportedUserId: string;
+      reason: ReportReason;
+      details?: string;
+      signalId?: string;
+      // Reported from a conversation (see lib/conversation-safety)
+      matchId?: string;
+    }
+  ): Promise<Report | null> {
+    const reported = await prisma.user.findUnique({
+      where: { id: input.reportedUserId },
+      select: { profileImage: true },
+    });
+    if (!reported) {
+      return null;
+    }
+
+    // Only messages the reported user sent to the reporter count as evidence
+    let signalId: string | null = null;
+    if (input.signalId) {
+      const signal = await prisma.signal.findFirst({
+        where: {
+          id: input.signalId,
+          fromUserId: input.reportedUserId,
+          toUserId: reporterId,
+        },
+        select: { id: true },
+      });
+      signalId = signal?.id || null;
+    }
+
+    const report = await prisma.report.create({
+      data: {
+        reporterId,
+        reportedUserId: input.reportedUserId,
+        reason: input.reason,
+        details: input.details,
+        signalId,
+        matchId: input.matchId,
+        photoUrl: reported.profileImage,
+      },
+    });
+
+    await EventBus.publish('moderation.report_filed', {
+      reportId: report.id,
+      reportedUserId: report.reportedUserId,
+      reason: report.reason,
+    });
+    return report;
+  }
+
+  /**
+   * File a report on behalf of the system (e.g. abuse detection). Returns
+   * null if the user already has an open system report.
+   */
+  static async createSystemReport(
+    reportedUserId: string,
+    reason: ReportReason,
+    details: Record<string, unknown>
+  ): Promise<Report | null> {
+    const [reported, existing] = await Promise.all([
+      prisma.user.findUnique({
+        where: { id: reportedUserId },
+        select: { profileImage: true },
+      }),
+      prisma.report.findFirst({
+        where: { reportedUserId, reporterId: null, status: 'open' },
+        select: { id: true },
+      }),
+    ]);
+    if (!reported || existing) {
+      return null;
+    }
+
+    const report = await prisma.report.create({
+      data: {
+        reporterId: null,
+        reportedUserId,
+        reason,
+        details: JSON.stringify(details),
+        photoUrl: reported.profileImage,
+      },
+    });
+
+    await EventBus.publish('moderation.report_filed', {
+      reportId: report.id,
+      reportedUserId,
+      reason,
+      system: true,
+    });
+    return report;
+  }
+
+  /**
+   * Open reports, oldest first
+   */
+  static async listOpen(limit: number, cursor?: string) {
+    return prisma.report.findMany({
+      where: { status: 'open' },
+      include: {
+        reportedUser: { select: userSummary },
+      },
+      orderBy: [{ createdAt: 'asc' }, { id: 'asc' }],
+      take: limit,
+      ...(cursor && { cursor: { id: cursor }, skip: 1 }),
+    });
+  }
+
+  /**
+   * A report with everything a moderator needs to decide on it
+   */
+  static async getEvidence(reportId: string) {
+    const report = await prisma.report.findUnique({
+      where: { id: reportId },
+      include: {
+        reporter: { select: userSummary },
+        reportedUser: { select: userSummary },
+      },
+    });
+    if (!report) {
+      return null;
+    }
+
+    const [
+      reportedMessage,
+      recentMessages,
+      unsentMessages,
+      conversation,
+      prompts,
+      voiceIntros,
+      otherReports,
+      history,
+    ] = await Promise.all([
+      report.signalId
+        ? prisma.signal.findUnique({ where: { id: report.signalId } })
+        : null,
+      // Everything else the reported user sent the reporter
+      report.reporterId
+        ? prisma.signal.findMany({
+            where: {
+              fromUserId: report.reportedUserId,
+              toUserId: report.reporterId,
+              message: { not: null },
+            },
+            orderBy: { sentAt: 'desc' },
+            take: 20,
+          })
+        : [],
+      // Messages the reported user unsent, while the copies are kept
+      report.reporterId
+        ? MessageDeletion.copiesFor(report.reportedUserId, report.reporterId)
+        : [],
+      // Reported from a conversation: both sides of it, unsent messages
+      // showing as tombstones
+      report.matchId && report.reporterId
+        ? prisma.signal.findMany({
+            where: {
+              OR: [
+                {
+                  fromUserId: report.reportedUserId,
+                  toUserId: report.reporterId,
+                },
+                {
+                  fromUserId: report.reporterId,
+                  toUserId: report.reportedUserId,
+                },
+              ],
+              NOT: { message: null, messageDeletedAt: null },
+            },
+            orderBy: { sentAt: 'asc' },
+          })
+        : [],
+      // Prompt answers, including ones already taken down
+      prisma.profilePrompt.findMany({
+        where: { userId: report.reportedUserId },
+        select: { promptId: true, answer: true, removedAt: true },
+        orderBy: { position: 'asc' },
+      }),
+      // Voice intro transcripts, including turned-down submissions
+      prisma.voiceIntro.findMany({
+        where: {
+          userId: report.reportedUserId,
+          status: { in: ['ready', 'rejected', 'replaced', 'removed'] },
+        },
+        select: {
+          status: true,
+          transcript: true,
+          rejectionReason: true,
+          createdAt: true,
+        },
+        orderBy: { createdAt: 'desc' },
+        take: 5,
+      }),
+      prisma.report.findMany({
+        where: {
+          reportedUserId: report.reportedUserId,
+          NOT: { id: report.id },
+        },
+        select: {
+          id: true,
+          reason: true,
+          status: true,
+          action: true,
+          createdAt: true,
+        },
+        orderBy: { createdAt: 'desc' },
+        take: 20,
+      }),
+      prisma.auditLog.findMany({
+        where: { targetType: 'user', targetId: report.reportedUserId },
+        orderBy: { createdAt: 'desc' },
+        take: 20,
+      }),
+    ]);
+
+    return {
+      report,
+      evidence: {
+        reportedMessage,
+        recentMessages,
+        unsentMessages,
+        conversation,
+        photos: {
+          atReport: report.photoUrl,
+          current: report.reportedUser.profileImage,
+          blurred: report.reportedUser.blurredImage,
+        },
+        prompts,
+        voiceIntros,
+      },
+      otherReports,
+      moderationHistory: history,
+    };
+  }
+
+  /**
+   * Resolve an open report with a moderation action. Returns null if the
+   * report doesn't exist or was already resolved.
+   */
+  static async resolve(
+    reportId: string,
+    resolution: Resolution,
+    adminId: string
+  ): Promise<Report | null> {
+    const report = await prisma.report.findUnique({ where: { id: reportId } });
+    if (!report || report.status !== 'open') {
+      return null;
+    }
+
+    // Claim the report so two moderators can't act on it twice
+    const claimed = await prisma.report.updateMany({
+      where: { id: reportId, status: 'open' },
+      data: {
+        status: 'resolved',
+        action: resolution.action,
+        resolvedBy: adminId,
+        resolutionNote: resolution.note,
+        resolvedAt: new Date(),
+      },
+    });
+    if (claimed.count === 0) {
+      return null;
+    }
+
+    const userId = report.reportedUserId;
+    const banReason = report.reason as BanReason;
+    const details: Record<string, unknown> = {
+      reportId,
+      reason: report.reason,
+      note: resolution.note ?? null,
+    };
+
+    switch (resolution.action) {
+      case 'warn':
+        await warnUser(userId, report.reason);
+        break;
+      case 'hide_photo':
+        await prisma.user.update({
+          where: { id: userId },
+          data: { profileImage: null, blurredImage: null },
+        });
+        details.hiddenPhoto = report.photoUrl;
+        break;
+      case 'temp_ban': {
+        const durationHours =
+          resolution.durationHours ?? DEFAULT_TEMP_BAN_HOURS;
+        details.durationHours = durationHours;
+        await Bans.ban(
+          userId,
+          { reason: banReason, durationHours, note: `Report ${reportId}` },
+          adminId
+        );
+        break;
+      }
+      case 'permaban':
+        await Bans.ban(
+          userId,
+          { reason: banReason, note: `Report ${reportId}` },
+          adminId
+        );
+        break;
+    }
+
+    await AuditLog.record({
+      action: `moderation.${resolution.action}`,
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'user',
+      targetId: userId,
+      details,
+    });
+    await EventBus.publish('moderation.report_resolved', {
+      reportId,
+      reportedUserId: userId,
+      action: resolution.action,
+    });
+    // The outcome counts toward the reported user's trust, and a dismissal
+    // toward the reporter's
+    await TrustScore.refresh(userId);
+    if (report.reporterId && resolution.action === 'dismiss') {
+      await TrustScore.refresh(report.reporterId);
+    }
+
+    return prisma.report.findUnique({ where: { id: reportId } });
+  }
+}
@@ -0,0 +1,286 @@
+/**
+ * Response Stats
+ * How users get conversations going: their response rate (of the matches
+ * who wrote first, how many they answered within RESPONSE_WINDOW_HOURS)
+ * and first-message latency (median time from matching to their first
+ * message). First messages are picked up from the chat service's
+ * message.sent events on the domain event stream, from their own
+ * checkpoint; stats are then worked out daily for active users, like
+ * trust scores. They're internal: discovery scales exposure by the
+ * response rate and leaves the least responsive out of decks, and admins
+ * only ever see aggregates.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { EVENT_STREAM_KEY, DomainEvent } from './event-bus';
+import { ScheduledTask } from './scheduler';
+
+// Below this response rate, a user is left out of other people's decks
+export const RESPONSE_RATE_DISCOVERY_MIN = parseFloat(
+  process.env.RESPONSE_RATE_DISCOVERY_MIN || '0.1'
+);
+
+// A reply later than this counts as no reply
+const RESPONSE_WINDOW_HOURS = parseInt(
+  process.env.RESPONSE_WINDOW_HOURS || '48'
+);
+
+const HOUR_MS = 60 * 60 * 1000;
+const DAY_MS = 24 * HOUR_MS;
+const LOOKBACK_DAYS = 90;
+// Fewer conversations than this started by matches says nothing
+const MIN_OPENERS = 5;
+const REFRESH_BATCH_SIZE = 500;
+const ACTIVE_WITHIN_DAYS = 30;
+
+const CHECKPOINT_NAME = 'response-stats';
+const STREAM_BATCH_SIZE = 500;
+
+export interface ResponseStatsSummary {
+  // Users with enough conversations for a response rate
+  measuredUsers: number;
+  averageResponseRate: number | null;
+  medianFirstMessageMs: number | null;
+  // Measured users currently left out of discovery for not responding
+  suppressedUsers: number;
+}
+
+function median(values: number[]): number | null {
+  if (values.length === 0) {
+    return null;
+  }
+  const sorted = [...values].sort((a, b) => a - b);
+  const middle = Math.floor(sorted.length / 2);
+  return sorted.length % 2
+    ? sorted[middle]
+    : Math.round((sorted[middle - 1] + sorted[middle]) / 2);
+}
+
+/**
+ * Users responsive enough for discovery, or not measured yet
+ */
+export function responsiveWhere(): Prisma.UserWhereInput {
+  return {
+    OR: [
+      { responseRate: null },
+      { responseRate: { gte: RESPONSE_RATE_DISCOVERY_MIN } },
+    ],
+  };
+}
+
+/**
+ * Note the sender's first message in a match, if this is it
+ */
+async function recordMessage(matchId: string, senderId: string, at: Date) {
+  const match = await prisma.match.findUnique({
+    where: { id: matchId },
+    select: { user1Id: true, user2Id: true },
+  });
+  if (match?.user1Id === senderId) {
+    await prisma.match.updateMany({
+      where: { id: matchId, user1FirstMessageAt: null },
+      data: { user1FirstMessageAt: at },
+    });
+  } else if (match?.user2Id === senderId) {
+    await prisma.match.updateMany({
+      where: { id: matchId, user2FirstMessageAt: null },
+      data: { user2FirstMessageAt: at },
+    });
+  }
+}
+
+export class ResponseStats {
+  /**
+   * Discovery exposure multiplier: 0.8 for someone who never answers, 1
+   * when unmeasured or at one half, 1.2 for someone who always does
+   */
+  static exposure(responseRate: number | null): number {
+    return responseRate === null ? 1 : 0.8 + 0.4 * responseRate;
+  }
+
+  /**
+   * Work the user's stats out from their recent matches and store them
+   */
+  static async refresh(userId: string) {
+    const now = Date.now();
+    const matches = await prisma.match.findMany({
+      where: {
+        deletedAt: null,
+        matchedAt: { gte: new Date(now - LOOKBACK_DAYS * DAY_MS) },
+        OR: [{ user1Id: userId }, { user2Id: userId }],
+      },
+      select: {
+        user1Id: true,
+        matchedAt: true,
+        user1FirstMessageAt: true,
+        user2FirstMessageAt: true,
+      },
+    });
+
+    let openers = 0;
+    let answered = 0;
+    const latencies: number[] = [];
+    for (const match of matches) {
+      const isUser1 = match.user1Id === userId;
+      const own = isUser1
+        ? match.user1FirstMessageAt
+        : match.user2FirstMessageAt;
+      const theirs = isUser1
+        ? match.user2FirstMessageAt
+        : match.user1FirstMessageAt;
+      if (own) {
+        latencies.push(own.getTime() - match.matchedAt.getTime());
+      }
+      if (!theirs || (own && own < theirs)) {
+        continue; // The user wrote first, or nobody has
+      }
+      const deadline = theirs.getTime() + RESPONSE_WINDOW_HOURS * HOUR_MS;
+      if (own && own.getTime() <= deadline) {
+        openers++;
+        answered++;
+      } else if (deadline <= now) {
+        openers++;
+      }
+    }
+
+    const stats = {
+      responseRate: openers >= MIN_OPENERS ? answered / openers : null,
+      firstMessageMs: median(latencies),
+      responseStatsAt: new Date(),
+    };
+    await prisma.user.update({ where: { id: userId }, data: stats });
+    return stats;
+  }
+
+  /**
+   * Pick up first messages from new stream events
+   */
+  static async ingest(): Promise<number> {
+    const checkpoint = await prisma.analyticsCheckpoint.findUnique({
+      where: { name: CHECKPOINT_NAME },
+    });
+    let position = checkpoint?.position || '0';
+    let processed = 0;
+
+    for (;;) {
+      const entries = await redis.xrange(
+        EVENT_STREAM_KEY,
+        `(${position}`,
+        '+',
+        'COUNT',
+        STREAM_BATCH_SIZE
+      );
+      if (entries.length === 0) {
+        break;
+      }
+
+      for (const [, fields] of entries) {
+        const body = fields[fields.indexOf('event') + 1];
+        const event = JSON.parse(body) as DomainEvent<Record<string, unknown>>;
+        if (
+          event.type === 'message.sent' &&
+          typeof event.payload.matchId === 'string' &&
+          typeof event.payload.senderId === 'string'
+        ) {
+          await recordMessage(
+            event.payload.matchId,
+            event.payload.senderId,
+            new Date(event.occurredAt)
+          );
+        }
+      }
+
+      position = entries[entries.length - 1][0];
+      await prisma.analyticsCheckpoint.upsert({
+        where: { name: CHECKPOINT_NAME },
+        create: { name: CHECKPOINT_NAME, position },
+        update: { position },
+      });
+      processed += entries.length;
+
+      if (entries.length < STREAM_BATCH_SIZE) {
+        break;
+      }
+    }
+    return processed;
+  }
+
+  /**
+   * Ingest new messages, then refresh recently active users whose stats
+   * are a day old or older
+   */
+  static async refreshStale(): Promise<{ events: number; refreshed: number }> {
+    const events = await ResponseStats.ingest();
+    const users = await prisma.user.findMany({
+      where: {
+        status: 'active',
+        deletedAt: null,
+        lastSeen: { gte: new Date(Date.now() - ACTIVE_WITHIN_DAYS * DAY_MS) },
+        OR: [
+          { responseStatsAt: null },
+          { responseStatsAt: { lt: new Date(Date.now() - DAY_MS) } },
+        ],
+      },
+      select: { id: true },
+      orderBy: { responseStatsAt: 'asc' },
+      take: REFRESH_BATCH_SIZE,
+    });
+    for (const user of users) {
+      await ResponseStats.refresh(user.id);
+    }
+    return { events, refreshed: users.length };
+  }
+
+  /**
+   * Stats across all current users, for admin analytics. Never per user.
+   */
+  static async summary(): Promise<ResponseStatsSummary> {
+    const where: Prisma.UserWhereInput = {
+      status: { not: 'deleted' },
+      deletedAt: null,
+    };
+    const timed: Prisma.UserWhereInput = {
+      ...where,
+      firstMessageMs: { not: null },
+    };
+    const [rates, timedUsers, suppressedUsers] = await Promise.all([
+      prisma.user.aggregate({
+        where: { ...where, responseRate: { not: null } },
+        _avg: { responseRate: true },
+        _count: { responseRate: true },
+      }),
+      prisma.user.count({ where: timed }),
+      prisma.user.count({
+        where: {
+          ...where,
+          responseRate: { lt: RESPONSE_RATE_DISCOVERY_MIN },
+        },
+      }),
+    ]);
+    // The middle row rather than every user's latency
+    const middle =
+      timedUsers > 0
+        ? await prisma.user.findFirst({
+            where: timed,
+            select: { firstMessageMs: true },
+            orderBy: { firstMessageMs: 'asc' },
+            skip: Math.floor(timedUsers / 2),
+          })
+        : null;
+
+    return {
+      measuredUsers: rates._count.responseRate,
+      averageResponseRate: rates._avg.responseRate,
+      medianFirstMessageMs: middle?.firstMessageMs ?? null,
+      suppressedUsers,
+    };
+  }
+}
+
+export const responseStatsRefresh: ScheduledTask = {
+  name: 'response-stats-refresh',
+  everyMs: parseInt(process.env.RESPONSE_STATS_INTERVAL_MS || '600000'),
+  run: () => ResponseStats.refreshStale(),
+};
@@ -0,0 +1,352 @@
+/**
+ * Safety Check-ins
+ * Before meeting a match, a user can schedule a check-in: who they're
+ * meeting, where and when, and a trusted contact to share that with
+ * through a private link. At the check-in time we remind them to check
+ * in; if they still haven't after a grace period, they're notified again
+ * and the contact is emailed (when we have an address) with the link.
+ */
+
+import { randomBytes } from 'crypto';
+import { SafetyCheckIn } from '@prisma/client';
+import prisma from './prisma';
+import { ScheduledTask } from './scheduler';
+import { Notifications } from './notifications';
+import { Email } from './email';
+import { PhotoReveal } from './photo-reveal';
+import { ProfilePhotos } from './profile-photos';
+import { AuditLog } from './audit-log';
+import { counter } from './metrics';
+
+export const CHECK_IN_STATUSES = [
+  'scheduled',
+  'due',
+  'checked_in',
+  'escalated',
+  'canceled',
+] as const;
+
+export type CheckInStatus = (typeof CHECK_IN_STATUSES)[number];
+
+// Still waiting on the user to check in
+const OPEN_STATUSES: CheckInStatus[] = ['scheduled', 'due'];
+
+// How long after the check-in time before the contact is alerted
+const GRACE_MINUTES = parseInt(
+  process.env.SAFETY_CHECKIN_GRACE_MINUTES || '30'
+);
+
+const HOUR_MS = 60 * 60 * 1000;
+// Latest a check-in can be scheduled for, from now
+const MAX_AHEAD_MS = 14 * 24 * HOUR_MS;
+// Longest a check-in can be after the date starts
+const MAX_DATE_LENGTH_MS = 24 * HOUR_MS;
+// The share link stops working this long after the check-in time
+const SHARE_TTL_MS = 24 * HOUR_MS;
+const MAX_OPEN_PER_USER = 5;
+const DUE_BATCH_SIZE = 200;
+
+const escalationCounter = counter(
+  'aurum_safety_check_in_escalations_total',
+  'Missed date check-ins escalated to the trusted contact'
+);
+
+export interface CheckInInput {
+  matchId: string;
+  place?: string;
+  dateAt: Date;
+  checkInAt: Date;
+  contactName: string;
+  contactEmail?: string;
+}
+
+export type CreateResult =
+  | { status: 'created'; checkIn: ReturnType<typeof toCheckInSummary> }
+  | { status: 'not_found' }
+  | { status: 'invalid_time' }
+  | { status: 'too_many' };
+
+export type UpdateResult =
+  | { status: 'updated'; checkIn: ReturnType<typeof toCheckInSummary> }
+  | { status: 'not_found' }
+  | { status: 'closed' };
+
+function shareUrl(token: string): string {
+  const base = (process.env.APP_PUBLIC_URL || '').replace(/\/$/, '');
+  return `${base}/safety/${token}`;
+}
+
+/**
+ * What the user who made a check-in is shown of it
+ */
+export function toCheckInSummary(checkIn: SafetyCheckIn) {
+  return {
+    id: checkIn.id,
+    matchId: checkIn.matchId,
+    place: checkIn.place,
+    dateAt: checkIn.dateAt,
+    checkInAt: checkIn.checkInAt,
+    contactName: checkIn.contactName,
+    contactEmail: checkIn.contactEmail,
+    status: checkIn.status,
+    shareUrl: shareUrl(checkIn.shareToken),
+    checkedInAt: checkIn.checkedInAt,
+    escalatedAt: checkIn.escalatedAt,
+    createdAt: checkIn.createdAt,
+  };
+}
+
+export class SafetyCheckIns {
+  /**
+   * Schedule a check-in for a date with one of the user's matches
+   */
+  static async create(
+    userId: string,
+    input: CheckInInput
+  ): Promise<CreateResult> {
+    const now = Date.now();
+    if (
+      input.checkInAt.getTime() <= now ||
+      input.checkInAt.getTime() > now + MAX_AHEAD_MS ||
+      input.checkInAt < input.dateAt ||
+      input.checkInAt.getTime() - input.dateAt.getTime() > MAX_DATE_LENGTH_MS
+    ) {
+      return { status: 'invalid_time' };
+    }
+
+    const match = await prisma.match.findFirst({
+      where: {
+        id: input.matchId,
+        deletedAt: null,
+        OR: [{ user1Id: userId }, { user2Id: userId }],
+      },
+      select: { id: true },
+    });
+    if (!match) {
+      return { status: 'not_found' };
+    }
+
+    const open = await prisma.safetyCheckIn.count({
+      where: { userId, status: { in: OPEN_STATUSES } },
+    });
+    if (open >= MAX_OPEN_PER_USER) {
+      return { status: 'too_many' };
+    }
+
+    const checkIn = await prisma.safetyCheckIn.create({
+      data: {
+        userId,
+        matchId: match.id,
+        place: input.place,
+        dateAt: input.dateAt,
+        checkInAt: input.checkInAt,
+        contactName: input.contactName,
+        contactEmail: input.contactEmail,
+        shareToken: randomBytes(24).toString('base64url'),
+      },
+    });
+    return { status: 'created', checkIn: toCheckInSummary(checkIn) };
+  }
+
+  /**
+   * The user's check-ins, soonest first, open ones before the rest
+   */
+  static async list(userId: string) {
+    const checkIns = await prisma.safetyCheckIn.findMany({
+      where: { userId },
+      orderBy: { checkInAt: 'desc' },
+      take: 50,
+    });
+    const open = checkIns
+      .filter(c => OPEN_STATUSES.includes(c.status as CheckInStatus))
+      .reverse();
+    const closed = checkIns.filter(
+      c => !OPEN_STATUSES.includes(c.status as CheckInStatus)
+    );
+    return [...open, ...closed].map(toCheckInSummary);
+  }
+
+  /**
+   * The user is safe. Works after an escalation too, so the contact's
+   * link shows they checked in.
+   */
+  static async checkIn(userId: string, id: string): Promise<UpdateResult> {
+    const checkIn = await prisma.safetyCheckIn.findFirst({
+      where: { id, userId },
+    });
+    if (!checkIn) {
+      return { status: 'not_found' };
+    }
+    if (checkIn.status === 'checked_in' || checkIn.status === 'canceled') {
+      return { status: 'closed' };
+    }
+
+    const updated = await prisma.safetyCheckIn.update({
+      where: { id },
+      data: { status: 'checked_in', checkedInAt: new Date() },
+    });
+    if (checkIn.status === 'escalated') {
+      await AuditLog.recordSafely({
+        action: 'safety.check_in_after_escalation',
+        actorType: 'user',
+        actorId: userId,
+        targetType: 'safety_check_in',
+        targetId: id,
+      });
+    }
+    return { status: 'updated', checkIn: toCheckInSummary(updated) };
+  }
+
+  /**
+   * Call off a check-in that's still open; the share link stops working
+   */
+  static async cancel(userId: string, id: string): Promise<UpdateResult> {
+    const checkIn = await prisma.safetyCheckIn.findFirst({
+      where: { id, userId },
+    });
+    if (!checkIn) {
+      return { status: 'not_found' };
+    }
+    if (!OPEN_STATUSES.includes(checkIn.status as CheckInStatus)) {
+      return { status: 'closed' };
+    }
+
+    const updated = await prisma.safetyCheckIn.update({
+      where: { id },
+      data: { status: 'canceled' },
+    });
+    return { status: 'updated', checkIn: toCheckInSummary(updated) };
+  }
+
+  /**
+   * What the trusted contact sees through the share link: who the user is
+   * meeting, where and when, and whether they've checked in. Null once
+   * the link is canceled or expired.
+   */
+  static async shared(token: string) {
+    const checkIn = await prisma.safetyCheckIn.findUnique({
+      where: { shareToken: token },
+      include: {
+        user: { select: { displayName: true } },
+        match: { include: { user1: true, user2: true } },
+      },
+    });
+    if (
+      !checkIn ||
+      checkIn.status === 'canceled' ||
+      checkIn.checkInAt.getTime() + SHARE_TTL_MS < Date.now()
+    ) {
+      return null;
+    }
+
+    const { match } = checkIn;
+    const other = match.user1Id === checkIn.userId ? match.user2 : match.user1;
+    // The contact sees no more of the photo than the user can
+    const reveals = await PhotoReveal.statesFor(checkIn.userId, [other]);
+    const photo = reveals.get(other.id)?.blurred
+      ? null
+      : ProfilePhotos.externalUrl(other.profileImage);
+
+    return {
+      name: checkIn.user.displayName,
+      meeting: {
+        displayName: other.displayName,
+        handle: other.handle,
+        profileImage: photo,
+      },
+      place: checkIn.place,
+      dateAt: checkIn.dateAt,
+      checkInAt: checkIn.checkInAt,
+      status: checkIn.status,
+      checkedInAt: checkIn.checkedInAt,
+    };
+  }
+
+  /**
+   * Remind users whose check-in time has come, and escalate those still
+   * not checked in after the grace period
+   */
+  static async processDue(): Promise<{ reminded: number; escalated: number }> {
+    const now = new Date();
+    const reminders = await prisma.safetyCheckIn.findMany({
+      where: { status: 'scheduled', checkInAt: { lte: now } },
+      orderBy: { checkInAt: 'asc' },
+      take: DUE_BATCH_SIZE,
+    });
+    for (const checkIn of reminders) {
+      await prisma.safetyCheckIn.update({
+        where: { id: checkIn.id },
+        data: { status: 'due', remindedAt: now },
+      });
+      await Notifications.notify(checkIn.userId, {
+        type: 'safety_check_in_due',
+        variables: { contactName: checkIn.contactName },
+        path: '/safety',
+        data: { checkInId: checkIn.id },
+        push: true,
+      });
+    }
+
+    const missed = await prisma.safetyCheckIn.findMany({
+      where: {
+        status: 'due',
+        checkInAt: { lte: new Date(now.getTime() - GRACE_MINUTES * 60_000) },
+      },
+      include: { user: { select: { displayName: true } } },
+      orderBy: { checkInAt: 'asc' },
+      take: DUE_BATCH_SIZE,
+    });
+    for (const checkIn of missed) {
+      await SafetyCheckIns.escalate(checkIn, checkIn.user.displayName);
+    }
+
+    return { reminded: reminders.length, escalated: missed.length };
+  }
+
+  private static async escalate(
+    checkIn: SafetyCheckIn,
+    name: string
+  ): Promise<void> {
+    await prisma.safetyCheckIn.update({
+      where: { id: checkIn.id },
+      data: { status: 'escalated', escalatedAt: new Date() },
+    });
+    escalationCounter.inc();
+
+    let emailed = false;
+    if (checkIn.contactEmail) {
+      emailed = await Email.sendToContact(
+        checkIn.userId,
+        checkIn.contactEmail,
+        'safety_check_in_escalation',
+        {
+          name,
+          contactName: checkIn.contactName,
+          checkInAt: checkIn.checkInAt.toUTCString(),
+          url: shareUrl(checkIn.shareToken),
+        },
+        `safety-escalation:${checkIn.id}`
+      );
+    }
+    await Notifications.notify(checkIn.userId, {
+      type: 'safety_check_in_missed',
+      variables: { contactName: checkIn.contactName },
+      path: '/safety',
+      data: { checkInId: checkIn.id },
+      push: true,
+    });
+    await AuditLog.recordSafely({
+      action: 'safety.check_in_escalated',
+      actorType: 'system',
+      targetType: 'safety_check_in',
+      targetId: checkIn.id,
+      details: { userId: checkIn.userId, emailed },
+    });
+  }
+}
+
+export const safetyCheckInEscalation: ScheduledTask = {
+  name: 'safety-check-in-escalation',
+  everyMs: parseInt(process.env.SAFETY_CHECKIN_INTERVAL_MS || '60000'),
+  run: () => SafetyCheckIns.processDue(),
+};
@@ -0,0 +1,172 @@
+/**
+ * Scam Detection
+ * Scores message text for romance-scam patterns: requests for money or
+ * gift cards, pushes to move off-platform, and crypto "investment" pitches.
+ * Matching rules combine like independent evidence (noisy-OR), so one weak
+ * hint stays below the warning line but several together don't. Recipients
+ * are warned inline on flagged messages and get a safety notice in the
+ * conversation (see lib/conversation-safety), and high-confidence hits open
+ * a moderation case against the sender.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { Reports } from './reports';
+import { ConversationSafety } from './conversation-safety';
+import { hasContactDetails } from './profile-prompts';
+import { counter } from './metrics';
+
+export const SCAM_CATEGORIES = [
+  'payment_request',
+  'off_platform',
+  'crypto_investment',
+  'urgency',
+] as const;
+
+export type ScamCategory = (typeof SCAM_CATEGORIES)[number];
+
+interface ScamRule {
+  category: ScamCategory;
+  weight: number;
+  test: (text: string) => boolean;
+}
+
+const pattern = (regex: RegExp) => (text: string) => regex.test(text);
+
+const RULES: ScamRule[] = [
+  {
+    category: 'payment_request',
+    weight: 0.5,
+    test: pattern(
+      /\b(send|transfer|lend|loan|borrow)\b.{0,40}(money|cash|\$|usd|baht|฿|thb|wld|usdt)/i
+    ),
+  },
+  {
+    category: 'payment_request',
+    weight: 0.6,
+    test: pattern(
+      /\b(gift ?cards?|western union|moneygram|wire transfer|promptpay)\b/i
+    ),
+  },
+  {
+    category: 'payment_request',
+    weight: 0.4,
+    test: pattern(/\b(paypal|venmo|cash ?app|bank account|account number)\b/i),
+  },
+  {
+    category: 'off_platform',
+    weight: 0.3,
+    test: hasContactDetails,
+  },
+  {
+    category: 'off_platform',
+    weight: 0.4,
+    test: pattern(/\b(bit\.ly|tinyurl\.com|t\.me|wa\.me|lin\.ee)\b/i),
+  },
+  {
+    category: 'crypto_investment',
+    weight: 0.6,
+    test: text =>
+      /\b(invest(ing|ment)?|trading|trader|forex|mining|airdrop)\b/i.test(
+        text
+      ) && /\b(crypto|bitcoin|btc|eth|usdt|tokens?|coins?)\b/i.test(text),
+  },
+  {
+    category: 'crypto_investment',
+    weight: 0.5,
+    test: pattern(
+      /\b(guaranteed (profit|returns?)|double your|passive income)\b|\d+\s?% (daily|weekly|profit|returns?)/i
+    ),
+  },
+  {
+    category: 'urgency',
+    weight: 0.2,
+    test: pattern(
+      /\b(urgent(ly)?|emergency|hospital bill|stuck at (the )?airport|visa fee|customs fee)\b/i
+    ),
+  },
+];
+
+// Recipients see a warning at or above this score
+export const SCAM_WARN_THRESHOLD = parseFloat(
+  process.env.SCAM_WARN_THRESHOLD || '0.5'
+);
+
+// A moderation case is opened at or above this score
+export const SCAM_REPORT_THRESHOLD = parseFloat(
+  process.env.SCAM_REPORT_THRESHOLD || '0.85'
+);
+
+const flaggedCounter = counter(
+  'aurum_scam_flags_total',
+  'Messages flagged by scam detection, by outcome'
+);
+
+export interface ScamAssessment {
+  score: number;
+  categories: ScamCategory[];
+  warn: boolean;
+}
+
+/**
+ * Score one message, 0-1
+ */
+export function assessMessage(text: string): ScamAssessment {
+  const hits = RULES.filter(rule => rule.test(text));
+  const score = 1 - hits.reduce((clean, rule) => clean * (1 - rule.weight), 1);
+  return {
+    score: Math.round(score * 100) / 100,
+    categories: Array.from(new Set(hits.map(rule => rule.category))),
+    warn: score >= SCAM_WARN_THRESHOLD,
+  };
+}
+
+export class ScamDetection {
+  /**
+   * Screen a message on its way to the recipient: flag the stored signal
+   * so the recipient sees a warning, and open a case on a confident hit.
+   * The message is still delivered either way.
+   */
+  static async screen(message: {
+    senderId: string;
+    recipientId: string;
+    text: string;
+    signalId?: string;
+  }): Promise<ScamAssessment> {
+    const assessment = assessMessage(message.text);
+    if (!assessment.warn) {
+      return assessment;
+    }
+
+    if (message.signalId) {
+      await prisma.signal.update({
+        where: { id: message.signalId },
+        data: {
+          scamScore: assessment.score,
+          scamFlags: assessment.categories as Prisma.InputJsonValue,
+        },
+      });
+    }
+
+    await ConversationSafety.notice({
+      kind: 'scam',
+      senderId: message.senderId,
+      recipientId: message.recipientId,
+      signalId: message.signalId,
+    });
+
+    const confident = assessment.score >= SCAM_REPORT_THRESHOLD;
+    flaggedCounter.inc({ outcome: confident ? 'reported' : 'warned' });
+    if (confident) {
+      await Reports.createSystemReport(message.senderId, 'scam', {
+        source: 'scam_detection',
+        score: assessment.score,
+        categories: assessment.categories,
+        recipientId: message.recipientId,
+        signalId: message.signalId ?? null,
+        sample: message.text.slice(0, 500),
+      });
+    }
+    return assessment;
+  }
+}
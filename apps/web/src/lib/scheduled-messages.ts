@@ -0,0 +1,300 @@
+/**
+ * Scheduled Messages
+ * From a conversation, a user can write a message to go out later, or ask
+ * to be nudged to reply at a given time. A scheduler task sends whatever
+ * is due. Nothing goes out in the quiet hours of the person it reaches
+ * (the recipient of a message, the user themself for a nudge); it waits
+ * until they're over. In a muted conversation (see lib/conversation-mutes)
+ * it still arrives, just without a push. Scheduled messages go through
+ * message requests like any other (see lib/message-requests), and are
+ * handed to the chat service on both people's message channels, with a
+ * preview of any link in them. The text is encrypted while it waits and
+ * dropped once it's sent.
+ */
+
+import { ScheduledMessage } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { ScheduledTask } from './scheduler';
+import { ConversationMutes } from './conversation-mutes';
+import { messageChannel } from './message-deletion';
+import { LinkPreview, LinkPreviews } from './link-previews';
+import { MessageRequests } from './message-requests';
+import { Notifications } from './notifications';
+import { NotificationPush } from './notification-push';
+import { WakingHours } from './waking-hours';
+import { counter } from './metrics';
+
+export const SCHEDULED_KINDS = ['message', 'reminder'] as const;
+
+export type ScheduledKind = (typeof SCHEDULED_KINDS)[number];
+
+// Latest a message or nudge can be scheduled for, from now
+export const MAX_SCHEDULE_DAYS = parseInt(
+  process.env.SCHEDULED_MESSAGE_MAX_DAYS || '30'
+);
+
+const MAX_AHEAD_MS = MAX_SCHEDULE_DAYS * 24 * 60 * 60 * 1000;
+const MAX_PENDING_PER_MATCH = 5;
+const DUE_BATCH_SIZE = 200;
+
+export interface ScheduleInput {
+  kind: ScheduledKind;
+  // Messages only
+  text?: string;
+  sendAt: Date;
+}
+
+export type ScheduleResult =
+  | { status: 'scheduled'; scheduled: ScheduledMessage }
+  | { status: 'invalid_time' }
+  | { status: 'too_many' }
+  | { status: 'not_found' };
+
+export interface ScheduledMessageEvent {
+  type: 'scheduled_message';
+  scheduledMessageId: string;
+  matchId: string;
+  fromUserId: string;
+  toUserId: string;
+  text: string;
+  // For the first link in the text, if it has one (see lib/link-previews)
+  preview: LinkPreview | null;
+  sentAt: string;
+  // Held as a message request: only the sender's side gets it, and the
+  // chat service delivers it if the request is accepted
+  held: boolean;
+}
+
+type DeliveryOutcome = 'sent' | 'deferred' | 'failed' | 'skipped';
+
+const deliveredCounter = counter(
+  'aurum_scheduled_messages_total',
+  'Scheduled messages and reminders processed, by kind and outcome'
+);
+
+async function findMatch(matchId: string, userId: string) {
+  return prisma.match.findFirst({
+    where: {
+      id: matchId,
+      deletedAt: null,
+      OR: [{ user1Id: userId }, { user2Id: userId }],
+    },
+    include: {
+      user1: { select: { id: true, displayName: true } },
+      user2: { select: { id: true, displayName: true } },
+    },
+  });
+}
+
+async function fail(id: string, reason: string) {
+  await prisma.scheduledMessage.update({
+    where: { id },
+    data: { status: 'failed', failureReason: reason, body: null },
+  });
+}
+
+/**
+ * Send one due message or nudge, unless it's now the quiet hours of the
+ * person it reaches
+ */
+async function deliver(scheduled: ScheduledMessage): Promise<DeliveryOutcome> {
+  const match = await findMatch(scheduled.matchId, scheduled.senderId);
+  if (!match) {
+    await fail(scheduled.id, 'match_gone');
+    return 'failed';
+  }
+  const [sender, other] =
+    match.user1Id === scheduled.senderId
+      ? [match.user1, match.user2]
+      : [match.user2, match.user1];
+  const targetId = scheduled.kind === 'message' ? other.id : sender.id;
+
+  const now = new Date();
+  const wakesAt = await WakingHours.nextWakingTime(targetId, now);
+  if (wakesAt > now) {
+    await prisma.scheduledMessage.update({
+      where: { id: scheduled.id },
+      data: { deliverAt: wakesAt },
+    });
+    return 'deferred';
+  }
+
+  // Claim it, so a cancel or a second worker can't race the send
+  const { count } = await prisma.scheduledMessage.updateMany({
+    where: { id: scheduled.id, status: 'scheduled' },
+    data: { status: 'sent', sentAt: now, body: null },
+  });
+  if (count === 0) {
+    return 'skipped';
+  }
+  const muted = await ConversationMutes.isMuted(match.id, targetId);
+
+  if (scheduled.kind === 'reminder') {
+    await Notifications.notify(sender.id, {
+      type: 'reply_reminder',
+      variables: { name: other.displayName },
+      path: `/matches/${match.id}`,
+      data: { matchId: match.id },
+      push: !muted,
+    });
+    return 'sent';
+  }
+
+  const text = scheduled.body!;
+  const gate = await MessageRequests.gate(match.id, sender.id, text);
+  if (gate.status === 'pending' || gate.status === 'declined') {
+    await fail(scheduled.id, `request_${gate.status}`);
+    return 'failed';
+  }
+  if (gate.status === 'not_found') {
+    await fail(scheduled.id, 'match_gone');
+    return 'failed';
+  }
+  const held = gate.status === 'held';
+  const event: ScheduledMessageEvent = {
+    type: 'scheduled_message',
+    scheduledMessageId: scheduled.id,
+    matchId: match.id,
+    fromUserId: sender.id,
+    toUserId: other.id,
+    text,
+    // A preview is a nicety; the message goes out without one
+    preview: await LinkPreviews.forMessage(text).catch(error => {
+      console.error('Error previewing a scheduled message:', error);
+      return null;
+    }),
+    sentAt: now.toISOString(),
+    held,
+  };
+  const data = JSON.stringify(event);
+  const publish = redis.multi().publish(messageChannel(sender.id), data);
+  if (!held) {
+    publish.publish(messageChannel(other.id), data);
+  }
+  await publish.exec();
+  // A held request notifies the recipient itself
+  if (!held && !muted) {
+    await NotificationPush.send(other.id, {
+      type: 'new_message',
+      variables: { name: sender.displayName },
+      path: `/matches/${match.id}`,
+    });
+  }
+  return 'sent';
+}
+
+export class ScheduledMessages {
+  /**
+   * Schedule a message to the other person in a match, or a nudge for the
+   * user to reply
+   */
+  static async schedule(
+    matchId: string,
+    userId: string,
+    input: ScheduleInput
+  ): Promise<ScheduleResult> {
+    const now = Date.now();
+    if (
+      input.sendAt.getTime() <= now ||
+      input.sendAt.getTime() > now + MAX_AHEAD_MS
+    ) {
+      return { status: 'invalid_time' };
+    }
+
+    const match = await findMatch(matchId, userId);
+    if (!match) {
+      return { status: 'not_found' };
+    }
+
+    const pending = await prisma.scheduledMessage.count({
+      where: { matchId, senderId: userId, status: 'scheduled' },
+    });
+    if (pending >= MAX_PENDING_PER_MATCH) {
+      return { status: 'too_many' };
+    }
+
+    const scheduled = await prisma.scheduledMessage.create({
+      data: {
+        matchId,
+        senderId: userId,
+        recipientId: match.user1Id === userId ? match.user2Id : match.user1Id,
+        kind: input.kind,
+        body: input.kind === 'message' ? input.text : null,
+        sendAt: input.sendAt,
+        deliverAt: input.sendAt,
+      },
+    });
+    return { status: 'scheduled', scheduled };
+  }
+
+  /**
+   * What the user has waiting to go out in the match, and what couldn't be
+   * sent, soonest first
+   */
+  static async list(matchId: string, userId: string) {
+    return prisma.scheduledMessage.findMany({
+      where: {
+        matchId,
+        senderId: userId,
+        status: { in: ['scheduled', 'failed'] },
+      },
+      select: {
+        id: true,
+        kind: true,
+        body: true,
+        sendAt: true,
+        deliverAt: true,
+        status: true,
+        failureReason: true,
+      },
+      orderBy: { deliverAt: 'asc' },
+    });
+  }
+
+  /**
+   * Cancel something still waiting to go out, or dismiss one that couldn't
+   * be sent. Returns false if there's nothing to cancel.
+   */
+  static async cancel(
+    id: string,
+    matchId: string,
+    userId: string
+  ): Promise<boolean> {
+    const { count } = await prisma.scheduledMessage.updateMany({
+      where: {
+        id,
+        matchId,
+        senderId: userId,
+        status: { in: ['scheduled', 'failed'] },
+      },
+      data: { status: 'canceled', body: null },
+    });
+    return count > 0;
+  }
+
+  /**
+   * Send everything that's due
+   */
+  static async deliverDue(): Promise<Record<DeliveryOutcome, number>> {
+    const due = await prisma.scheduledMessage.findMany({
+      where: { status: 'scheduled', deliverAt: { lte: new Date() } },
+      orderBy: { deliverAt: 'asc' },
+      take: DUE_BATCH_SIZE,
+    });
+
+    const outcomes = { sent: 0, deferred: 0, failed: 0, skipped: 0 };
+    for (const scheduled of due) {
+      const outcome = await deliver(scheduled);
+      outcomes[outcome]++;
+      deliveredCounter.inc({ kind: scheduled.kind, outcome });
+    }
+    return outcomes;
+  }
+}
+
+export const scheduledMessageDelivery: ScheduledTask = {
+  name: 'scheduled-message-delivery',
+  everyMs: parseInt(process.env.SCHEDULED_MESSAGE_INTERVAL_MS || '60000'),
+  run: () => ScheduledMessages.deliverDue(),
+};
@@ -0,0 +1,58 @@
+/**
+ * Scheduled Tasks
+ * Every task the scheduler worker runs. Register new tasks here.
+ */
+
+import { ScheduledTask } from './scheduler';
+import { onchainPaymentWatcher } from './onchain-payments';
+import { dunningReminder, dunningDowngrade } from './dunning';
+import { analyticsRollup } from './analytics';
+import { announcementDelivery } from './announcements';
+import { notificationDigest } from './notification-push';
+import { travelModeExpiry } from './travel-mode';
+import { outboxRelay } from './outbox';
+import { accountDeletionPurge } from './account-deletion';
+import { badgeGrants } from './badges';
+import { trustScoreRefresh } from './trust-score';
+import { safetyCheckInEscalation } from './safety-check-ins';
+import { speedDatingPairing } from './speed-dating';
+import { topPicksRefresh } from './top-picks';
+import { socialGraphIngest } from './social-graph';
+import { responseStatsRefresh } from './response-stats';
+import { photoRevealIngest } from './photo-reveal';
+import { jwtKeyRotation } from './jwt-keys';
+import { dataRetention } from './data-retention';
+import { backfillSlice } from './backfills';
+import { mediaGc } from './media-gc';
+import {
+  conversationExportBuild,
+  conversationExportExpiry,
+} from './conversation-exports';
+import { scheduledMessageDelivery } from './scheduled-messages';
+
+export const SCHEDULED_TASKS: ScheduledTask[] = [
+  onchainPaymentWatcher,
+  dunningReminder,
+  dunningDowngrade,
+  analyticsRollup,
+  announcementDelivery,
+  notificationDigest,
+  travelModeExpiry,
+  outboxRelay,
+  accountDeletionPurge,
+  badgeGrants,
+  trustScoreRefresh,
+  safetyCheckInEscalation,
+  speedDatingPairing,
+  topPicksRefresh,
+  socialGraphIngest,
+  responseStatsRefresh,
+  photoRevealIngest,
+  jwtKeyRotation,
+  dataRetention,
+  backfillSlice,
+  mediaGc,
+  conversationExportBuild,
+  conversationExportExpiry,
+  scheduledMessageDelivery,
+];
@@ -0,0 +1,95 @@
+/**
+ * Scheduler
+ * Recurring and one-off background tasks backed by BullMQ. Recurring tasks
+ * are registered as job schedulers (so only one run fires per interval no
+ * matter how many workers are up); one-off tasks are delayed jobs.
+ */
+
+import { Queue, Worker, Job } from 'bullmq';
+import redis from './redis';
+
+export const SCHEDULER_QUEUE_NAME = 'scheduler';
+
+export interface ScheduledTask<T = any> {
+  name: string;
+  // Run every N milliseconds; omit for tasks that are only scheduled ad hoc
+  everyMs?: number;
+  run: (data: T, job: Job<T>) => Promise<unknown>;
+}
+
+export const schedulerQueue = new Queue(SCHEDULER_QUEUE_NAME, {
+  connection: redis,
+  defaultJobOptions: {
+    attempts: 3,
+    backoff: {
+      type: 'exponential',
+      delay: 5000,
+    },
+    removeOnComplete: { count: 1000 },
+    removeOnFail: { age: 7 * 24 * 60 * 60 },
+  },
+});
+
+export class Scheduler {
+  /**
+   * Run a task once at `runAt`. Passing a jobId makes scheduling idempotent
+   * and lets the caller cancel it later.
+   */
+  static async scheduleAt<T>(
+    taskName: string,
+    data: T,
+    runAt: Date,
+    jobId?: string
+  ): Promise<string | undefined> {
+    const job = await schedulerQueue.add(taskName, data, {
+      delay: Math.max(0, runAt.getTime() - Date.now()),
+      jobId,
+    });
+    return job.id;
+  }
+
+  /**
+   * Cancel a pending one-off task
+   */
+  static async cancel(jobId: string): Promise<boolean> {
+    const job = await schedulerQueue.getJob(jobId);
+    if (!job) {
+      return false;
+    }
+    await job.remove();
+    return true;
+  }
+
+  /**
+   * Register recurring tasks and start processing. Called from the scheduler
+   * worker process only.
+   */
+  static async start(
+    tasks: ScheduledTask[],
+    concurrency = 4
+  ): Promise<Worker> {
+    const registry = new Map(tasks.map(task => [task.name, task]));
+
+    for (const task of tasks) {
+      if (task.everyMs) {
+        await schedulerQueue.upsertJobScheduler(
+          task.name,
+          { every: task.everyMs },
+          { name: task.name, data: {} }
+        );
+      }
+    }
+
+    return new Worker(
+      SCHEDULER_QUEUE_NAME,
+      async (job: Job) => {
+        const task = registry.get(job.name);
+        if (!task) {
+          throw new Error(`No scheduled task registered for ${job.name}`);
+        }
+        return task.run(job.data, job);
+      },
+      { connection: redis, concurrency }
+    );
+  }
+}
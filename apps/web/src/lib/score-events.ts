@@ -0,0 +1,149 @@
+/**
+ * Score Change Events
+ * Tracks each user's last computed score on each scale (see
+ * lib/score-percentiles) and emits `score.threshold_crossed` when a
+ * recomputed score crosses a configured threshold, so clients can prompt
+ * users to refresh their photos at the right moment
+ */
+
+import redis from './redis';
+import { EventBus } from './event-bus';
+import { NotificationPush } from './notification-push';
+import { ScoreScale } from './score-percentiles';
+
+export const SCORE_THRESHOLD_CROSSED = 'score.threshold_crossed';
+
+// Score thresholds (0-100) that trigger an event when crossed
+export const SCORE_EVENT_THRESHOLDS = (
+  process.env.SCORE_EVENT_THRESHOLDS || '50,60,70,80,90'
+)
+  .split(',')
+  .map(value => parseFloat(value))
+  .filter(value => !isNaN(value))
+  .sort((a, b) => a - b);
+
+// Send a push notification alongside the event when enabled
+const PUSH_ON_CROSSING = process.env.SCORE_EVENT_PUSH_ENABLED === 'true';
+
+// Recent events kept per user for clients to poll
+const MAX_EVENTS_PER_USER = 20;
+
+export interface ScoreThresholdCrossing {
+  userId: string;
+  scale: ScoreScale;
+  threshold: number;
+  direction: 'up' | 'down';
+  previousScore: number;
+  score: number;
+}
+
+export interface ScoreEventRecord extends ScoreThresholdCrossing {
+  eventId: string;
+  occurredAt: string;
+}
+
+/**
+ * Thresholds crossed moving from `previous` to `current`, in the order they
+ * were crossed
+ */
+export function detectThresholdCrossings(
+  previous: number,
+  current: number,
+  thresholds: number[] = SCORE_EVENT_THRESHOLDS
+): { threshold: number; direction: 'up' | 'down' }[] {
+  if (current > previous) {
+    return thresholds
+      .filter(t => previous < t && current >= t)
+      .map(threshold => ({ threshold, direction: 'up' as const }));
+  }
+  if (current < previous) {
+    return thresholds
+      .filter(t => current < t && previous >= t)
+      .reverse()
+      .map(threshold => ({ threshold, direction: 'down' as const }));
+  }
+  return [];
+}
+
+export class ScoreEvents {
+  /**
+   * Record a freshly computed score and emit events for any thresholds it
+   * crossed relative to the user's previous score on the same scale
+   */
+  static async recordScore(
+    userId: string,
+    scale: ScoreScale,
+    score: number
+  ): Promise<ScoreEventRecord[]> {
+    try {
+      // Stored without a TTL so crossings survive score cache expiry
+      const previousValue = await redis.getset(
+        `score_last:${scale}:${userId}`,
+        score.toString()
+      );
+      if (previousValue === null) {
+        return [];
+      }
+
+      const previousScore = parseFloat(previousValue);
+      const crossings = detectThresholdCrossings(previousScore, score);
+
+      const records: ScoreEventRecord[] = [];
+      for (const crossing of crossings) {
+        const event = await EventBus.publish<ScoreThresholdCrossing>(
+          SCORE_THRESHOLD_CROSSED,
+          { userId, scale, previousScore, score, ...crossing }
+        );
+        const record: ScoreEventRecord = {
+          ...event.payload,
+          eventId: event.id,
+          occurredAt: event.occurredAt,
+        };
+        await redis
+          .multi()
+          .lpush(`score_events:${userId}`, JSON.stringify(record))
+          .ltrim(`score_events:${userId}`, 0, MAX_EVENTS_PER_USER - 1)
+          .exec();
+        records.push(record);
+      }
+
+      if (PUSH_ON_CROSSING && records.length > 0) {
+        await this.notify(records[records.length - 1]);
+      }
+
+      return records;
+    } catch (error) {
+      // Events are best effort; never fail the scoring request over them
+      console.error('Error recording score change events:', error);
+      return [];
+    }
+  }
+
+  /**
+   * Most recent score events for a user, newest first
+   */
+  static async getRecent(
+    userId: string,
+    limit = MAX_EVENTS_PER_USER
+  ): Promise<ScoreEventRecord[]> {
+    const entries = await redis.lrange(`score_events:${userId}`, 0, limit - 1);
+    return entries.map(entry => JSON.parse(entry));
+  }
+
+  private static async notify(record: ScoreEventRecord): Promise<void> {
+    await NotificationPush.send(
+      record.userId,
+      record.direction === 'up'
+        ? {
+            type: 'score_up',
+            variables: { threshold: record.threshold },
+            path: '/discover',
+          }
+        : {
+            type: 'score_down',
+            variables: { threshold: record.threshold },
+            path: '/onboarding',
+          }
+    );
+  }
+}
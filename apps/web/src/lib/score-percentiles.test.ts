@@ -0,0 +1,100 @@
+/**
+ * @description Unit tests for the t-digest percentile sketch
+ */
+
+import { describe, it, expect, jest, beforeEach } from '@jest/globals';
+import { ScorePercentiles, TDigest } from '@/lib/score-percentiles';
+
+// In-memory Redis with the compare-and-set script's semantics
+const mockStore = new Map<string, string>();
+
+jest.mock('@/lib/redis', () => ({
+  __esModule: true,
+  default: {
+    get: async (key: string) => mockStore.get(key) ?? null,
+    eval: async (
+      _script: string,
+      _keys: number,
+      key: string,
+      expected: string,
+      next: string
+    ) => {
+      if ((mockStore.get(key) ?? '') !== expected) {
+        return 0;
+      }
+      mockStore.set(key, next);
+      return 1;
+    },
+  },
+}));
+
+describe('TDigest', () => {
+  it('returns 0 for an empty digest', () => {
+    const digest = new TDigest();
+    expect(digest.count).toBe(0);
+    expect(digest.cdf(50)).toBe(0);
+  });
+
+  it('estimates percentiles of a uniform distribution', () => {
+    const digest = new TDigest();
+    for (let i = 1; i <= 10000; i++) {
+      digest.add(i / 100);
+    }
+
+    expect(digest.count).toBe(10000);
+    expect(digest.cdf(25)).toBeCloseTo(0.25, 2);
+    expect(digest.cdf(88)).toBeCloseTo(0.88, 2);
+    expect(digest.quantile(0.5)).toBeCloseTo(50, 0);
+  });
+
+  it('clamps values outside the observed range', () => {
+    const digest = new TDigest();
+    [10, 20, 30].forEach(v => digest.add(v));
+
+    expect(digest.cdf(5)).toBe(0);
+    expect(digest.cdf(30)).toBe(1);
+    expect(digest.cdf(100)).toBe(1);
+  });
+
+  it('survives a serialization round trip', () => {
+    const digest = new TDigest();
+    for (let i = 0; i < 500; i++) {
+      digest.add(i);
+    }
+
+    const restored = TDigest.fromJSON(
+      JSON.parse(JSON.stringify(digest.toJSON()))
+    );
+
+    expect(restored.count).toBe(500);
+    expect(restored.cdf(250)).toBeCloseTo(digest.cdf(250), 5);
+  });
+});
+
+describe('ScorePercentiles', () => {
+  beforeEach(() => {
+    mockStore.clear();
+  });
+
+  it('keeps every score recorded concurrently', async () => {
+    // Every read lands before any write, so all but one must retry
+    const ranks = await Promise.all(
+      [40, 50, 60, 70, 80].map(score =>
+        ScorePercentiles.record('final', 'female', score)
+      )
+    );
+
+    expect(ranks.every(rank => rank !== null)).toBe(true);
+    const rank = await ScorePercentiles.rank('final', 'female', 60);
+    expect(rank?.cohortSize).toBe(5);
+  });
+
+  it('keeps each scale separate', async () => {
+    await ScorePercentiles.record('final', 'male', 90);
+
+    expect(await ScorePercentiles.rank('facial', 'male', 90)).toBeNull();
+    expect((await ScorePercentiles.rank('final', 'male', 90))?.cohortSize).toBe(
+      1
+    );
+  });
+});
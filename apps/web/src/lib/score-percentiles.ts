@@ -0,0 +1,321 @@
+/**
+ * Score Percentiles
+ * Maintains a t-digest sketch of scores per scale and cohort so score
+ * responses can report where a user sits within their cohort ("top 12%")
+ */
+
+import redis from './redis';
+
+// Facial scores and final scores (facial plus university and NFT bonuses)
+// aren't comparable, so each scale keeps its own distributions
+export const SCORE_SCALES = ['facial', 'final'] as const;
+
+export type ScoreScale = (typeof SCORE_SCALES)[number];
+
+// Replace a digest only if it's unchanged since it was read, so concurrent
+// scores can't overwrite each other's updates. WATCH would need its own
+// connection; the shared client interleaves every caller's commands.
+const COMPARE_AND_SET = `
+local current = redis.call('GET', KEYS[1])
+if (current or '') ~= ARGV[1] then
+  return 0
+end
+redis.call('SET', KEYS[1], ARGV[2])
+return 1
+`;
+
+const MAX_RECORD_ATTEMPTS = 10;
+
+export interface Centroid {
+  mean: number;
+  count: number;
+}
+
+export interface SerializedTDigest {
+  compression: number;
+  min: number;
+  max: number;
+  centroids: Array<[number, number]>;
+}
+
+export interface PercentileRank {
+  cohort: string;
+  percentile: number; // Fraction of the cohort scoring below this score (0-1)
+  topPercent: number; // Rounded "top N%" figure for display (1-100)
+  cohortSize: number;
+}
+
+/**
+ * Merging t-digest (Dunning) for streaming quantile estimation
+ */
+export class TDigest {
+  private centroids: Centroid[] = [];
+  private buffer: number[] = [];
+  private min = Infinity;
+  private max = -Infinity;
+
+  constructor(private readonly compression: number = 100) {}
+
+  /**
+   * Total weight held by the digest, including unmerged values
+   */
+  get count(): number {
+    return (
+      this.centroids.reduce((sum, c) => sum + c.count, 0) + this.buffer.length
+    );
+  }
+
+  /**
+   * Add a single observation
+   */
+  add(value: number): void {
+    if (!Number.isFinite(value)) {
+      return;
+    }
+
+    this.buffer.push(value);
+    this.min = Math.min(this.min, value);
+    this.max = Math.max(this.max, value);
+
+    if (this.buffer.length >= this.compression * 5) {
+      this.compress();
+    }
+  }
+
+  /**
+   * Fraction of observations less than or equal to the given value
+   */
+  cdf(value: number): number {
+    this.compress();
+
+    const total = this.count;
+    if (total === 0) {
+      return 0;
+    }
+    if (value < this.min) {
+      return 0;
+    }
+    if (value >= this.max) {
+      return 1;
+    }
+
+    // Interpolate linearly between centroid midpoints
+    let cumulative = 0;
+    let prevMean = this.min;
+    let prevWeight = 0;
+
+    for (const centroid of this.centroids) {
+      const midpoint = cumulative + centroid.count / 2;
+
+      if (value < centroid.mean) {
+        const span = centroid.mean - prevMean;
+        const fraction = span > 0 ? (value - prevMean) / span : 1;
+        return (prevWeight + fraction * (midpoint - prevWeight)) / total;
+      }
+
+      cumulative += centroid.count;
+      prevMean = centroid.mean;
+      prevWeight = midpoint;
+    }
+
+    const span = this.max - prevMean;
+    const fraction = span > 0 ? (value - prevMean) / span : 1;
+    return (prevWeight + fraction * (total - prevWeight)) / total;
+  }
+
+  /**
+   * Estimated value at quantile q (0-1)
+   */
+  quantile(q: number): number {
+    this.compress();
+
+    const total = this.count;
+    if (total === 0) {
+      return NaN;
+    }
+    if (q <= 0) {
+      return this.min;
+    }
+    if (q >= 1) {
+      return this.max;
+    }
+
+    const target = q * total;
+    let cumulative = 0;
+    let prevMean = this.min;
+    let prevWeight = 0;
+
+    for (const centroid of this.centroids) {
+      const midpoint = cumulative + centroid.count / 2;
+
+      if (target < midpoint) {
+        const span = midpoint - prevWeight;
+        const fraction = span > 0 ? (target - prevWeight) / span : 1;
+        return prevMean + fraction * (centroid.mean - prevMean);
+      }
+
+      cumulative += centroid.count;
+      prevMean = centroid.mean;
+      prevWeight = midpoint;
+    }
+
+    const span = total - prevWeight;
+    const fraction = span > 0 ? (target - prevWeight) / span : 1;
+    return prevMean + fraction * (this.max - prevMean);
+  }
+
+  /**
+   * Merge buffered values into the centroid list, respecting the size bound
+   */
+  private compress(): void {
+    if (this.buffer.length === 0) {
+      return;
+    }
+
+    const incoming: Centroid[] = [
+      ...this.centroids,
+      ...this.buffer.map(value => ({ mean: value, count: 1 })),
+    ].sort((a, b) => a.mean - b.mean);
+
+    this.buffer = [];
+
+    const total = incoming.reduce((sum, c) => sum + c.count, 0);
+    const merged: Centroid[] = [];
+    let current = { ...incoming[0] };
+    let weightSoFar = 0;
+
+    for (let i = 1; i < incoming.length; i++) {
+      const next = incoming[i];
+      const combined = current.count + next.count;
+      const q = (weightSoFar + combined / 2) / total;
+      const bound = Math.max(1, (4 * total * q * (1 - q)) / this.compression);
+
+      if (combined <= bound) {
+        current.mean += ((next.mean - current.mean) * next.count) / combined;
+        current.count = combined;
+      } else {
+        weightSoFar += current.count;
+        merged.push(current);
+        current = { ...next };
+      }
+    }
+
+    merged.push(current);
+    this.centroids = merged;
+  }
+
+  toJSON(): SerializedTDigest {
+    this.compress();
+    return {
+      compression: this.compression,
+      min: this.min,
+      max: this.max,
+      centroids: this.centroids.map(c => [c.mean, c.count]),
+    };
+  }
+
+  static fromJSON(data: SerializedTDigest): TDigest {
+    const digest = new TDigest(data.compression);
+    digest.centroids = data.centroids.map(([mean, count]) => ({
+      mean,
+      count,
+    }));
+    digest.min = data.centroids.length > 0 ? data.min : Infinity;
+    digest.max = data.centroids.length > 0 ? data.max : -Infinity;
+    return digest;
+  }
+}
+
+/**
+ * Redis-backed cohort percentile tracking
+ */
+export class ScorePercentiles {
+  /**
+   * Cohort key for a scored user. Final scores are computed differently per
+   * gender (NFT tier only applies to males), so cohorts are split on it.
+   */
+  static cohortFor(profile: { gender: string }): string {
+    return profile.gender;
+  }
+
+  /**
+   * Record a score in its cohort's distribution and return the resulting rank
+   */
+  static async record(
+    scale: ScoreScale,
+    cohort: string,
+    score: number
+  ): Promise<PercentileRank | null> {
+    try {
+      const key = this.key(scale, cohort);
+      for (let attempt = 0; attempt < MAX_RECORD_ATTEMPTS; attempt++) {
+        const data = await redis.get(key);
+        const digest = this.parse(data);
+        digest.add(score);
+        const saved = await redis.eval(
+          COMPARE_AND_SET,
+          1,
+          key,
+          data ?? '',
+          JSON.stringify(digest.toJSON())
+        );
+        if (saved === 1) {
+          return this.toRank(cohort, digest, score);
+        }
+      }
+      throw new Error(`Score digest ${key} kept changing; score not recorded`);
+    } catch (error) {
+      console.error('Error recording score percentile:', error);
+      return null;
+    }
+  }
+
+  /**
+   * Rank a score against its cohort's distribution without recording it
+   */
+  static async rank(
+    scale: ScoreScale,
+    cohort: string,
+    score: number
+  ): Promise<PercentileRank | null> {
+    try {
+      const digest = await this.load(scale, cohort);
+      if (digest.count === 0) {
+        return null;
+      }
+      return this.toRank(cohort, digest, score);
+    } catch (error) {
+      console.error('Error ranking score percentile:', error);
+      return null;
+    }
+  }
+
+  private static async load(
+    scale: ScoreScale,
+    cohort: string
+  ): Promise<TDigest> {
+    return this.parse(await redis.get(this.key(scale, cohort)));
+  }
+
+  private static parse(data: string | null): TDigest {
+    return data ? TDigest.fromJSON(JSON.parse(data)) : new TDigest();
+  }
+
+  private static toRank(
+    cohort: string,
+    digest: TDigest,
+    score: number
+  ): PercentileRank {
+    const percentile = Math.round(digest.cdf(score) * 1000) / 1000;
+    return {
+      cohort,
+      percentile,
+      topPercent: Math.min(100, Math.max(1, Math.ceil((1 - percentile) * 100))),
+      cohortSize: digest.count,
+    };
+  }
+
+  private static key(scale: ScoreScale, cohort: string): string {
+    return `score_digest:${scale}:${cohort}`;
+  }
+}
@@ -0,0 +1,255 @@
+/**
+ * Scoring Jobs
+ * Queue-backed asynchronous scoring so expensive multi-photo ML work doesn't
+ * hold HTTP connections open against the ML API
+ */
+
+import { Queue, Job, UnrecoverableError } from 'bullmq';
+import redis from './redis';
+import {
+  attractivenessEngineV2 as attractivenessEngine,
+  ScoringResult,
+} from './attractiveness-engine-v2';
+import { RedisCache } from './redis-cache';
+import { checkPhotoQuality, PhotoQualityIssue } from './photo-quality';
+import { getMLClientForVersion } from './ml-model-routing';
+import { ScoreEvents } from './score-events';
+import { DuplicateAccounts } from './duplicate-accounts';
+
+export const SCORING_QUEUE_NAME = 'scoringJobs';
+
+// Maximum number of photos accepted in a single job
+export const MAX_PHOTOS_PER_JOB = 6;
+
+export interface ScoringJobData {
+  userId: string;
+  images: string[];
+  metadata: {
+    nftVerified: boolean;
+    wldVerified: boolean;
+    submittedAt: string;
+    modelVersion?: string;
+    // Profile of the signed-in user who queued the job
+    submittedBy?: string;
+  };
+}
+
+export interface PhotoAssessment {
+  index: number;
+  faceDetected: boolean;
+  valid: boolean;
+  reason?: string;
+  quality?: number;
+  issues?: PhotoQualityIssue[];
+}
+
+export interface ScoringJobResult {
+  selectedPhoto: number;
+  photos: PhotoAssessment[];
+  score: ScoringResult;
+}
+
+export type ScoringJobState =
+  | 'queued'
+  | 'processing'
+  | 'completed'
+  | 'failed'
+  | 'unknown';
+
+export interface ScoringJobStatus {
+  jobId: string;
+  userId: string;
+  state: ScoringJobState;
+  progress: number;
+  submittedAt: string;
+  finishedAt?: string;
+  result?: ScoringJobResult;
+  error?: string;
+}
+
+export const scoringJobQueue = new Queue<ScoringJobData, ScoringJobResult>(
+  SCORING_QUEUE_NAME,
+  {
+    connection: redis,
+    defaultJobOptions: {
+      attempts: 2,
+      backoff: {
+        type: 'exponential',
+        delay: 2000,
+      },
+      // Keep finished jobs around long enough for clients to poll them
+      removeOnComplete: { age: 60 * 60 },
+      removeOnFail: { age: 24 * 60 * 60 },
+    },
+  }
+);
+
+/**
+ * Enqueue a scoring job and return its ID
+ */
+export async function enqueueScoringJob(data: ScoringJobData): Promise<string> {
+  const jobId = crypto.randomUUID();
+  await scoringJobQueue.add('score', data, { jobId });
+  return jobId;
+}
+
+/**
+ * Look up a scoring job's status and result
+ */
+export async function getScoringJobStatus(
+  jobId: string
+): Promise<ScoringJobStatus | null> {
+  const job = await scoringJobQueue.getJob(jobId);
+  if (!job) {
+    return null;
+  }
+
+  const state = await job.getState();
+
+  return {
+    jobId,
+    userId: job.data.userId,
+    state: mapJobState(state),
+    progress: typeof job.progress === 'number' ? job.progress : 0,
+    submittedAt: job.data.metadata.submittedAt,
+    finishedAt: job.finishedOn
+      ? new Date(job.finishedOn).toISOString()
+      : undefined,
+    result: state === 'completed' ? job.returnvalue : undefined,
+    error: state === 'failed' ? job.failedReason : undefined,
+  };
+}
+
+function mapJobState(state: string): ScoringJobState {
+  switch (state) {
+    case 'waiting':
+    case 'waiting-children':
+    case 'delayed':
+    case 'prioritized':
+      return 'queued';
+    case 'active':
+      return 'processing';
+    case 'completed':
+      return 'completed';
+    case 'failed':
+      return 'failed';
+    default:
+      return 'unknown';
+  }
+}
+
+/**
+ * Worker processor: assess every photo, then score the best one. The raw
+ * photos are dropped as soon as the job is done with them: on success, and
+ * on a failure that won't be retried.
+ */
+export async function processScoringJob(
+  job: Job<ScoringJobData, ScoringJobResult>
+): Promise<ScoringJobResult> {
+  try {
+    return await scorePhotos(job);
+  } catch (error) {
+    const lastAttempt = job.attemptsMade + 1 >= (job.opts.attempts ?? 1);
+    if (lastAttempt || error instanceof UnrecoverableError) {
+      await job.updateData({ ...job.data, images: [] });
+    }
+    throw error;
+  }
+}
+
+async function scorePhotos(
+  job: Job<ScoringJobData, ScoringJobResult>
+): Promise<ScoringJobResult> {
+  const { userId, images, metadata } = job.data;
+  const mlClient = getMLClientForVersion(metadata.modelVersion);
+
+  // Step 1: Gate each photo before spending ML capacity on it
+  const gates = await Promise.all(
+    images.map(image => checkPhotoQuality(image))
+  );
+  const accepted = images
+    .map((image, index) => ({ image, index }))
+    .filter(({ index }) => gates[index].passed);
+  await job.updateProgress(20);
+
+  // Step 2: Run accepted photos through the ML API in one batch
+  const processed =
+    accepted.length > 0
+      ? await mlClient.processImageBatch(accepted.map(p => p.image))
+      : [];
+  await job.updateProgress(50);
+
+  const photos: PhotoAssessment[] = images.map((_, index) => {
+    const gate = gates[index];
+    if (!gate.passed) {
+      return {
+        index,
+        faceDetected: !gate.issues.some(i => i.code === 'no_face_detected'),
+        valid: false,
+        reason: gate.issues[0].message,
+        issues: gate.issues,
+      };
+    }
+
+    const result = processed[accepted.findIndex(p => p.index === index)];
+    if (!result) {
+      return {
+        index,
+        faceDetected: false,
+        valid: false,
+        reason: 'No face detected',
+      };
+    }
+
+    const validation = mlClient.validateResult(result);
+    return {
+      index,
+      faceDetected: true,
+      valid: validation.isValid,
+      reason: validation.reason,
+      quality: validation.quality.overall,
+    };
+  });
+
+  // Step 3: Pick the highest quality valid photo
+  const best = photos
+    .filter(photo => photo.valid)
+    .sort((a, b) => (b.quality || 0) - (a.quality || 0))[0];
+
+  // Retrying the same photos can't change this
+  if (!best) {
+    throw new UnrecoverableError(
+      'No photo met the quality requirements for scoring'
+    );
+  }
+
+  // Step 4: Score the selected photo
+  const score = await attractivenessEngine.scoreUser({
+    userId,
+    imageBase64: images[best.index],
+    metadata: {
+      nftVerified: metadata.nftVerified,
+      wldVerified: metadata.wldVerified,
+      timestamp: metadata.submittedAt,
+      modelVersion: metadata.modelVersion,
+    },
+  });
+  await job.updateProgress(100);
+
+  await RedisCache.cacheFacialScore(userId, score.score);
+  // Score history and duplicate checks only follow the owner's own uploads
+  const ownPhotos = metadata.submittedBy === userId;
+  if (ownPhotos) {
+    await ScoreEvents.recordScore(userId, 'facial', score.score);
+    await DuplicateAccounts.checkPhoto(userId);
+  }
+
+  // Drop the raw photos so they don't linger in Redis while the job is polled
+  await job.updateData({ ...job.data, images: [] });
+
+  return {
+    selectedPhoto: best.index,
+    photos,
+    score,
+  };
+}
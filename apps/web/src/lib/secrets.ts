@@ -0,0 +1,268 @@
+/**
+ * Secrets
+ * Loads secrets (JWT keys, database credentials, provider API keys) into
+ * process.env at startup from HashiCorp Vault or a SOPS-encrypted file,
+ * so they don't have to sit in plain text in docker-compose env files,
+ * and re-reads them every SECRETS_REFRESH_MS to pick up rotations.
+ * SECRETS_PROVIDER picks the source; "env", the default, leaves the
+ * environment as it is. Code that reads process.env when it needs a value
+ * sees a rotated secret after the next refresh. Values captured once at
+ * startup, like the database URL the Prisma client was created with, need
+ * a restart or a listener registered with `Secrets.onRotate`.
+ */
+
+import { execFile } from 'child_process';
+import { promisify } from 'util';
+import { upstreamFetch } from './http-client';
+
+export const SECRETS_PROVIDERS = ['env', 'vault', 'sops'] as const;
+
+export type SecretsProvider = (typeof SECRETS_PROVIDERS)[number];
+
+const REFRESH_MS = parseInt(process.env.SECRETS_REFRESH_MS || '300000');
+
+// Only keys shaped like environment variables are loaded
+const SECRET_NAME = /^[A-Z][A-Z0-9_]*$/;
+
+type SecretValues = Record<string, string>;
+
+export type RotationListener = (names: string[]) => void | Promise<void>;
+
+const execFileAsync = promisify(execFile);
+
+const listeners: RotationListener[] = [];
+let refreshTimer: NodeJS.Timeout | null = null;
+// Token from an AppRole login, reused until Vault stops accepting it
+let vaultToken: string | null = null;
+
+function provider(): SecretsProvider {
+  const name = process.env.SECRETS_PROVIDER || 'env';
+  if (!(SECRETS_PROVIDERS as readonly string[]).includes(name)) {
+    throw new Error(`Unknown SECRETS_PROVIDER: ${name}`);
+  }
+  return name as SecretsProvider;
+}
+
+/**
+ * The string, number and boolean entries of a secrets document
+ */
+function toSecretValues(data: Record<string, unknown>): SecretValues {
+  const values: SecretValues = {};
+  for (const [name, value] of Object.entries(data)) {
+    if (
+      SECRET_NAME.test(name) &&
+      ['string', 'number', 'boolean'].includes(typeof value)
+    ) {
+      values[name] = String(value);
+    }
+  }
+  return values;
+}
+
+function vaultUrl(path: string): string {
+  const address = process.env.VAULT_ADDR;
+  if (!address) {
+    throw new Error('VAULT_ADDR is not configured');
+  }
+  return `${address.replace(/\/$/, '')}/v1/${path.replace(/^\//, '')}`;
+}
+
+function vaultHeaders(token?: string): Record<string, string> {
+  return {
+    'Content-Type': 'application/json',
+    ...(token && { 'X-Vault-Token': token }),
+    ...(process.env.VAULT_NAMESPACE && {
+      'X-Vault-Namespace': process.env.VAULT_NAMESPACE,
+    }),
+  };
+}
+
+/**
+ * A Vault token: VAULT_TOKEN, or one from logging in with AppRole
+ */
+async function vaultLogin(): Promise<string> {
+  if (process.env.VAULT_TOKEN) {
+    return process.env.VAULT_TOKEN;
+  }
+  if (vaultToken) {
+    return vaultToken;
+  }
+
+  const response = await upstreamFetch(
+    'vault',
+    vaultUrl('auth/approle/login'),
+    {
+      method: 'POST',
+      headers: vaultHeaders(),
+      body: JSON.stringify({
+        role_id: process.env.VAULT_ROLE_ID,
+        secret_id: process.env.VAULT_SECRET_ID,
+      }),
+    },
+    { retries: 2 }
+  );
+  if (!response.ok) {
+    throw new Error(`Vault login failed with status ${response.status}`);
+  }
+  const body = (await response.json()) as { auth: { client_token: string } };
+  vaultToken = body.auth.client_token;
+  return vaultToken;
+}
+
+/**
+ * Every secret under VAULT_SECRET_PATHS, later paths winning. KV v2 paths
+ * include "data/", e.g. secret/data/aurum/web.
+ */
+async function readVault(): Promise<SecretValues> {
+  const paths = (process.env.VAULT_SECRET_PATHS || '')
+    .split(',')
+    .map(path => path.trim())
+    .filter(Boolean);
+  if (paths.length === 0) {
+    throw new Error('VAULT_SECRET_PATHS is not configured');
+  }
+
+  const values: SecretValues = {};
+  for (const path of paths) {
+    let response = await upstreamFetch(
+      'vault',
+      vaultUrl(path),
+      { headers: vaultHeaders(await vaultLogin()) },
+      { retries: 2 }
+    );
+    if (response.status === 403 && vaultToken) {
+      // The AppRole token expired; log in again once
+      vaultToken = null;
+      response = await upstreamFetch(
+        'vault',
+        vaultUrl(path),
+        { headers: vaultHeaders(await vaultLogin()) },
+        { retries: 2 }
+      );
+    }
+    if (!response.ok) {
+      throw new Error(`Vault read of ${path} failed: ${response.status}`);
+    }
+
+    const body = (await response.json()) as {
+      data: { data?: Record<string, unknown> } & Record<string, unknown>;
+    };
+    // KV v2 nests the secret one level deeper than KV v1
+    const data =
+      body.data.data && typeof body.data.data === 'object'
+        ? body.data.data
+        : body.data;
+    Object.assign(values, toSecretValues(data));
+  }
+  return values;
+}
+
+/**
+ * The decrypted contents of SOPS_SECRETS_FILE. sops finds its keys (age,
+ * KMS, PGP) the usual way, e.g. from SOPS_AGE_KEY_FILE.
+ */
+async function readSops(): Promise<SecretValues> {
+  const file = process.env.SOPS_SECRETS_FILE;
+  if (!file) {
+    throw new Error('SOPS_SECRETS_FILE is not configured');
+  }
+  const { stdout } = await execFileAsync(
+    process.env.SOPS_PATH || 'sops',
+    ['--decrypt', '--output-type', 'json', file],
+    { maxBuffer: 1024 * 1024 }
+  );
+  return toSecretValues(JSON.parse(stdout));
+}
+
+async function read(source: SecretsProvider): Promise<SecretValues> {
+  return source === 'vault' ? readVault() : readSops();
+}
+
+/**
+ * Copy secrets into the environment; returns the names that changed
+ */
+function apply(values: SecretValues): string[] {
+  const changed: string[] = [];
+  for (const [name, value] of Object.entries(values)) {
+    if (process.env[name] !== value) {
+      process.env[name] = value;
+      changed.push(name);
+    }
+  }
+  return changed;
+}
+
+export class Secrets {
+  /**
+   * Load secrets into the environment. Call before anything reads them;
+   * throws if the secret store can't be read, so a misconfigured instance
+   * doesn't start with missing credentials.
+   */
+  static async load(): Promise<string[]> {
+    const source = provider();
+    if (source === 'env') {
+      return [];
+    }
+
+    const values = await read(source);
+    apply(values);
+    const names = Object.keys(values);
+    console.log(`🔐 Loaded ${names.length} secrets from ${source}`);
+    return names;
+  }
+
+  /**
+   * Re-read the secret store now, telling listeners which secrets rotated.
+   * A failed read keeps the current values.
+   */
+  static async refresh(): Promise<string[]> {
+    const source = provider();
+    if (source === 'env') {
+      return [];
+    }
+
+    let rotated: string[];
+    try {
+      rotated = apply(await read(source));
+    } catch (error) {
+      console.error('Error refreshing secrets:', error);
+      return [];
+    }
+    if (rotated.length === 0) {
+      return [];
+    }
+
+    // Names only; values never reach the logs
+    console.log(`🔐 Secrets rotated: ${rotated.join(', ')}`);
+    for (const listener of listeners) {
+      try {
+        await listener(rotated);
+      } catch (error) {
+        console.error('Error handling secret rotation:', error);
+      }
+    }
+    return rotated;
+  }
+
+  /**
+   * Refresh every SECRETS_REFRESH_MS for the life of the process
+   */
+  static startRotation() {
+    if (refreshTimer || provider() === 'env' || REFRESH_MS <= 0) {
+      return;
+    }
+    refreshTimer = setInterval(() => {
+      void Secrets.refresh();
+    }, REFRESH_MS);
+    // Don't keep a process alive just to refresh secrets
+    refreshTimer.unref();
+  }
+
+  /**
+   * Run `listener` with the names of rotated secrets after each refresh
+   * that changed any
+   */
+  static onRotate(listener: RotationListener) {
+    listeners.push(listener);
+  }
+}
@@ -0,0 +1,45 @@
+/**
+ * Shadowbans
+ * A shadowbanned user's app behaves normally for them, but they are left out
+ * of everyone else's discovery and their signals are never delivered.
+ */
+
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+
+export class Shadowbans {
+  static async isShadowbanned(userId: string): Promise<boolean> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { shadowbanned: true },
+    });
+    return Boolean(user?.shadowbanned);
+  }
+
+  /**
+   * Turn a shadowban on or off. Deliberately publishes no event so nothing
+   * user-facing can react to it.
+   */
+  static async set(
+    userId: string,
+    shadowbanned: boolean,
+    adminId: string,
+    note?: string
+  ): Promise<void> {
+    await prisma.user.update({
+      where: { id: userId },
+      data: { shadowbanned },
+    });
+
+    await AuditLog.record({
+      action: shadowbanned
+        ? 'admin.user_shadowbanned'
+        : 'admin.user_unshadowbanned',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'user',
+      targetId: userId,
+      details: { note: note ?? null },
+    });
+  }
+}
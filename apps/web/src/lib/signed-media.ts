@@ -0,0 +1,92 @@
+/**
+ * Signed Media
+ * Short-lived links to stored media, served by the /api/media proxy
+ * instead of straight from the bucket. A link is signed for one viewer
+ * (HMAC with MEDIA_URL_SECRET over the object, expiry and viewer), and the
+ * proxy only serves it to that viewer's session before it expires, so
+ * links can't be scraped, or shared outside the app. Without the secret
+ * configured there are no links at all, rather than unsigned ones.
+ */
+
+import { createHmac, timingSafeEqual } from 'crypto';
+
+export const MEDIA_PROXY_PATH = '/api/media';
+
+// How long a link works. Payloads are refetched more often than this.
+const URL_TTL_SECONDS = parseInt(process.env.MEDIA_URL_TTL_SECONDS || '900');
+
+// Expiries are rounded up to this, so a viewer gets the same link (and
+// their browser's cached copy) across requests for a while
+const EXPIRY_STEP_SECONDS = 300;
+
+let warnedUnsigned = false;
+
+function secret(): string | null {
+  return process.env.MEDIA_URL_SECRET || null;
+}
+
+function signature(
+  key: string,
+  expiresAt: number,
+  viewerId: string,
+  secret: string
+): string {
+  return createHmac('sha256', secret)
+    .update(`${key}\n${expiresAt}\n${viewerId}`)
+    .digest('base64url');
+}
+
+export type MediaAccess =
+  | { status: 'ok' }
+  | { status: 'expired' }
+  | { status: 'invalid' };
+
+export class SignedMedia {
+  /**
+   * A link to `key` that only `viewerId` can open, for the next while, or
+   * null if links can't be signed
+   */
+  static url(key: string, viewerId: string): string | null {
+    const signingSecret = secret();
+    if (!signingSecret) {
+      if (!warnedUnsigned) {
+        console.warn('MEDIA_URL_SECRET is not set; not serving user media');
+        warnedUnsigned = true;
+      }
+      return null;
+    }
+
+    const now = Math.floor(Date.now() / 1000);
+    const expiresAt =
+      Math.ceil((now + URL_TTL_SECONDS) / EXPIRY_STEP_SECONDS) *
+      EXPIRY_STEP_SECONDS;
+    const path = key.split('/').map(encodeURIComponent).join('/');
+    const sig = signature(key, expiresAt, viewerId, signingSecret);
+    return `${MEDIA_PROXY_PATH}/${path}?exp=${expiresAt}&sig=${sig}`;
+  }
+
+  /**
+   * Whether a link's signature holds for this viewer and it hasn't expired
+   */
+  static verify(
+    key: string,
+    expiresAt: number,
+    sig: string,
+    viewerId: string
+  ): MediaAccess {
+    const signingSecret = secret();
+    if (!signingSecret || !Number.isFinite(expiresAt)) {
+      return { status: 'invalid' };
+    }
+    const expected = Buffer.from(
+      signature(key, expiresAt, viewerId, signingSecret)
+    );
+    const given = Buffer.from(sig);
+    if (expected.length !== given.length || !timingSafeEqual(expected, given)) {
+      return { status: 'invalid' };
+    }
+    return expiresAt * 1000 < Date.now()
+      ? { status: 'expired' }
+      : { status: 'ok' };
+  }
+}
@@ -0,0 +1,49 @@
+/**
+ * Singleflight
+ * Request coalescing for hot identical reads. While a load for a key is
+ * in flight, everyone else asking for the same key waits on it instead of
+ * starting their own, so a burst of requests after a cache expires costs
+ * one upstream call rather than one each. Nothing is cached: once the
+ * load settles, the next caller starts a fresh one. Errors are shared
+ * too, so callers must not rely on retrying through it.
+ */
+
+import { counter } from './metrics';
+
+const callCounter = counter(
+  'aurum_singleflight_calls_total',
+  'Coalesced reads by group and whether the call loaded or shared a load'
+);
+
+declare global {
+  var singleflightCalls: undefined | Map<string, Promise<unknown>>;
+}
+
+// Shared across route bundles so concurrent requests find each other
+const inFlight: Map<string, Promise<unknown>> =
+  globalThis.singleflightCalls ?? new Map();
+globalThis.singleflightCalls = inFlight;
+
+/**
+ * The result of `load` for `key` in `group` (e.g. "feature-flags"),
+ * joining a load already in flight rather than starting another
+ */
+export function singleflight<T>(
+  group: string,
+  key: string,
+  load: () => Promise<T>
+): Promise<T> {
+  const flightKey = `${group}:${key}`;
+  const existing = inFlight.get(flightKey);
+  if (existing) {
+    callCounter.inc({ group, result: 'shared' });
+    return existing as Promise<T>;
+  }
+
+  callCounter.inc({ group, result: 'loaded' });
+  const promise = load().finally(() => {
+    inFlight.delete(flightKey);
+  });
+  inFlight.set(flightKey, promise);
+  return promise;
+}
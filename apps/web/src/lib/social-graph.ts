@@ -0,0 +1,222 @@
+/**
+ * Social Graph
+ * Lightweight shared context between users, for hints on discovery cards
+ * like "you both went to Freshers Night" or "you both hold CU Pass".
+ * Edges link a user to an event they checked in at or an NFT collection
+ * verification found in their wallet; they're built from the domain
+ * event stream, from their own checkpoint, the same way badges are.
+ * Shared circles are read straight from circle memberships.
+ */
+
+import prisma from './prisma';
+import redis from './redis';
+import { EVENT_STREAM_KEY, DomainEvent } from './event-bus';
+import { ScheduledTask } from './scheduler';
+
+export const EDGE_KINDS = ['event', 'collection'] as const;
+
+export type EdgeKind = (typeof EDGE_KINDS)[number];
+
+export type HintKind = EdgeKind | 'circle';
+
+export interface SharedHint {
+  kind: HintKind;
+  // Event ID, collection contract address or circle ID
+  id: string;
+  label: string;
+}
+
+// Hints shown per card, circles first, then events, then collections
+const MAX_HINTS = 3;
+const HINT_ORDER: HintKind[] = ['circle', 'event', 'collection'];
+
+const CHECKPOINT_NAME = 'social-graph';
+const STREAM_BATCH_SIZE = 500;
+
+interface EdgeInput {
+  userId: string;
+  kind: EdgeKind;
+  key: string;
+  label: string;
+}
+
+type EdgeRule = (
+  event: DomainEvent<Record<string, unknown>>
+) => Promise<EdgeInput[]>;
+
+// Event type -> the edges it adds
+const EDGE_RULES: Record<string, EdgeRule> = {
+  'event.attended': async event => {
+    const eventId = event.payload.eventId as string;
+    const attended = await prisma.event.findUnique({
+      where: { id: eventId },
+      select: { title: true },
+    });
+    return attended
+      ? [
+          {
+            userId: event.payload.userId as string,
+            kind: 'event',
+            key: eventId,
+            label: attended.title,
+          },
+        ]
+      : [];
+  },
+
+  'user.collection_verified': async event => [
+    {
+      userId: event.payload.userId as string,
+      kind: 'collection',
+      key: (event.payload.contractAddress as string).toLowerCase(),
+      label: event.payload.name as string,
+    },
+  ],
+};
+
+async function addEdge(edge: EdgeInput): Promise<void> {
+  await prisma.socialEdge.upsert({
+    where: {
+      userId_kind_key: { userId: edge.userId, kind: edge.kind, key: edge.key },
+    },
+    create: edge,
+    update: { label: edge.label },
+  });
+}
+
+export class SocialGraph {
+  /**
+   * What the viewer shares with each of `userIds`, at most a few hints
+   * per user
+   */
+  static async hintsFor(
+    viewerId: string,
+    userIds: string[]
+  ): Promise<Map<string, SharedHint[]>> {
+    const hints = new Map<string, SharedHint[]>();
+    if (userIds.length === 0) {
+      return hints;
+    }
+
+    const [viewerEdges, viewerCircles] = await Promise.all([
+      prisma.socialEdge.findMany({ where: { userId: viewerId } }),
+      prisma.circleMember.findMany({
+        where: { userId: viewerId, status: 'active' },
+        include: { circle: { select: { id: true, name: true } } },
+      }),
+    ]);
+    if (viewerEdges.length === 0 && viewerCircles.length === 0) {
+      return hints;
+    }
+
+    const [edges, memberships] = await Promise.all([
+      viewerEdges.length > 0
+        ? prisma.socialEdge.findMany({
+            where: {
+              userId: { in: userIds },
+              OR: viewerEdges.map(edge => ({ kind: edge.kind, key: edge.key })),
+            },
+          })
+        : Promise.resolve([]),
+      viewerCircles.length > 0
+        ? prisma.circleMember.findMany({
+            where: {
+              userId: { in: userIds },
+              status: 'active',
+              circleId: { in: viewerCircles.map(m => m.circleId) },
+            },
+          })
+        : Promise.resolve([]),
+    ]);
+
+    const shared: { userId: string; hint: SharedHint }[] = [
+      ...memberships.map(membership => {
+        const circle = viewerCircles.find(
+          m => m.circleId === membership.circleId
+        )!.circle;
+        return {
+          userId: membership.userId,
+          hint: { kind: 'circle' as const, id: circle.id, label: circle.name },
+        };
+      }),
+      ...edges.map(edge => ({
+        userId: edge.userId,
+        hint: {
+          kind: edge.kind as EdgeKind,
+          id: edge.key,
+          label: edge.label,
+        },
+      })),
+    ];
+    shared.sort(
+      (a, b) =>
+        HINT_ORDER.indexOf(a.hint.kind) - HINT_ORDER.indexOf(b.hint.kind)
+    );
+    for (const { userId, hint } of shared) {
+      const list = hints.get(userId) ?? [];
+      if (list.length < MAX_HINTS) {
+        list.push(hint);
+        hints.set(userId, list);
+      }
+    }
+    return hints;
+  }
+
+  /**
+   * Add edges for new stream events
+   */
+  static async ingest(): Promise<{ events: number; edges: number }> {
+    const checkpoint = await prisma.analyticsCheckpoint.findUnique({
+      where: { name: CHECKPOINT_NAME },
+    });
+    let position = checkpoint?.position || '0';
+    let processed = 0;
+    let added = 0;
+
+    for (;;) {
+      const entries = await redis.xrange(
+        EVENT_STREAM_KEY,
+        `(${position}`,
+        '+',
+        'COUNT',
+        STREAM_BATCH_SIZE
+      );
+      if (entries.length === 0) {
+        break;
+      }
+
+      for (const [, fields] of entries) {
+        const body = fields[fields.indexOf('event') + 1];
+        const event = JSON.parse(body) as DomainEvent<Record<string, unknown>>;
+        const rule = EDGE_RULES[event.type];
+        if (!rule) {
+          continue;
+        }
+        for (const edge of await rule(event)) {
+          await addEdge(edge);
+          added++;
+        }
+      }
+
+      position = entries[entries.length - 1][0];
+      await prisma.analyticsCheckpoint.upsert({
+        where: { name: CHECKPOINT_NAME },
+        create: { name: CHECKPOINT_NAME, position },
+        update: { position },
+      });
+      processed += entries.length;
+
+      if (entries.length < STREAM_BATCH_SIZE) {
+        break;
+      }
+    }
+
+    return { events: processed, edges: added };
+  }
+}
+
+export const socialGraphIngest: ScheduledTask = {
+  name: 'social-graph-ingest',
+  everyMs: parseInt(process.env.SOCIAL_GRAPH_INTERVAL_MS || '60000'),
+  run: () => SocialGraph.ingest(),
+};
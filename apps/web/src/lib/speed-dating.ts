@@ -0,0 +1,514 @@
+/**
+ * Speed Dating
+ * Scheduled live sessions where users join a queue and are paired for
+ * short timed chats. Pairing prefers the waiting user with the best quiz
+ * compatibility, skipping anyone the user has already dated this session
+ * or is already matched with. Chat messages and pairing updates are
+ * relayed like call signals: published on the user's channel for a
+ * socket server to push, and kept briefly in a mailbox clients can poll.
+ * When both people vote to keep each other, they become a regular match.
+ */
+
+import { SpeedDate, SpeedDatingSession } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { prismaUnitOfWork } from './store';
+import { Outbox } from './outbox';
+import { CompatibilityQuiz } from './compatibility-quiz';
+import { TRUST_DISCOVERY_MIN } from './trust-score';
+import { Notifications } from './notifications';
+import { ScheduledTask } from './scheduler';
+import { AuditLog } from './audit-log';
+import { Places } from './places';
+
+export const SPEED_DATE_VOTES = ['keep', 'pass'] as const;
+
+export type SpeedDateVote = (typeof SPEED_DATE_VOTES)[number];
+
+export type SpeedDatingUpdateType = 'paired' | 'message' | 'ended' | 'matched';
+
+// How long after a date ends its two people can still vote
+const VOTE_WINDOW_MS = 10 * 60 * 1000;
+// Waiting users considered for each pairing, oldest first
+const PAIRING_POOL_SIZE = 50;
+// Updates the recipient hasn't collected by then are stale anyway
+const MAILBOX_TTL_SECONDS = 120;
+const MAX_MAILBOX_UPDATES = 200;
+
+const queueKey = (sessionId: string) => `speed-dating:queue:${sessionId}`;
+const mailboxKey = (userId: string) => `speed-dating:updates:${userId}`;
+export const speedDatingChannel = (userId: string) =>
+  `speed-dating:user:${userId}`;
+
+export interface SpeedDatingUpdate {
+  type: SpeedDatingUpdateType;
+  dateId: string;
+  fromUserId: string | null;
+  // Message text, or the date / match for the other types
+  payload: Record<string, unknown>;
+  sentAt: string;
+}
+
+export interface SessionInput {
+  title: string;
+  cityId?: string | null;
+  startsAt: Date;
+  endsAt: Date;
+  roundSeconds?: number;
+}
+
+export type SessionResult =
+  | { status: 'saved'; session: SpeedDatingSession }
+  | { status: 'invalid_city' };
+
+export type JoinResult =
+  | { status: 'waiting' }
+  | { status: 'paired'; date: SpeedDate }
+  | { status: 'not_found' }
+  | { status: 'not_live' }
+  | { status: 'not_eligible' }
+  | { status: 'in_date' };
+
+export type MessageResult =
+  | { status: 'sent' }
+  | { status: 'not_found' }
+  | { status: 'ended' };
+
+export type VoteResult =
+  | { status: 'voted'; matchId: string | null }
+  | { status: 'not_found' }
+  | { status: 'closed' };
+
+function isLive(session: SpeedDatingSession, now = new Date()): boolean {
+  return (
+    session.status === 'scheduled' &&
+    session.startsAt <= now &&
+    session.endsAt > now
+  );
+}
+
+function otherUser(date: SpeedDate, userId: string): string {
+  return date.user1Id === userId ? date.user2Id : date.user1Id;
+}
+
+async function relay(toUserId: string, update: SpeedDatingUpdate) {
+  const key = mailboxKey(toUserId);
+  const data = JSON.stringify(update);
+  await redis
+    .multi()
+    .rpush(key, data)
+    .ltrim(key, -MAX_MAILBOX_UPDATES, -1)
+    .expire(key, MAILBOX_TTL_SECONDS)
+    .publish(speedDatingChannel(toUserId), data)
+    .exec();
+}
+
+/**
+ * The date the user is in right now, if any
+ */
+async function currentDate(userId: string): Promise<SpeedDate | null> {
+  return prisma.speedDate.findFirst({
+    where: {
+      endsAt: { gt: new Date() },
+      OR: [
+        { user1Id: userId, vote1: null },
+        { user2Id: userId, vote2: null },
+      ],
+    },
+    orderBy: { startedAt: 'desc' },
+  });
+}
+
+/**
+ * Pair a waiting user with the most compatible eligible person in the
+ * queue. Both are taken off the queue; null if nobody suitable is there.
+ */
+async function pair(
+  session: SpeedDatingSession,
+  userId: string
+): Promise<SpeedDate | null> {
+  const key = queueKey(session.id);
+  const waiting = (await redis.zrange(key, 0, PAIRING_POOL_SIZE - 1)).filter(
+    id => id !== userId
+  );
+  if (waiting.length === 0) {
+    return null;
+  }
+
+  const [eligible, dated, matched] = await Promise.all([
+    prisma.user.findMany({
+      where: {
+        id: { in: [userId, ...waiting] },
+        shadowbanned: false,
+        trustScore: { gte: TRUST_DISCOVERY_MIN },
+        deletedAt: null,
+      },
+      select: { id: true },
+    }),
+    prisma.speedDate.findMany({
+      where: {
+        sessionId: session.id,
+        OR: [{ user1Id: userId }, { user2Id: userId }],
+      },
+    }),
+    prisma.match.findMany({
+      where: {
+        deletedAt: null,
+        OR: [
+          { user1Id: userId, user2Id: { in: waiting } },
+          { user2Id: userId, user1Id: { in: waiting } },
+        ],
+      },
+      select: { user1Id: true, user2Id: true },
+    }),
+  ]);
+  const excluded = new Set([
+    ...dated.map(date => otherUser(date, userId)),
+    ...matched.map(m => (m.user1Id === userId ? m.user2Id : m.user1Id)),
+  ]);
+  const eligibleIds = new Set(eligible.map(user => user.id));
+  // Hidden users wait forever rather than being told why
+  if (!eligibleIds.has(userId)) {
+    return null;
+  }
+  const candidates = waiting.filter(
+    id => eligibleIds.has(id) && !excluded.has(id)
+  );
+  if (candidates.length === 0) {
+    return null;
+  }
+
+  // Best compatibility first; unscored keep queue order after them
+  const scores = await CompatibilityQuiz.scores(userId, candidates);
+  const ordered = candidates
+    .map((id, index) => ({ id, index, score: scores.get(id) ?? -1 }))
+    .sort((a, b) => b.score - a.score || a.index - b.index);
+
+  for (const { id } of ordered) {
+    // Whoever takes someone off the queue first gets them
+    if ((await redis.zrem(key, id)) === 0) {
+      continue;
+    }
+    if ((await redis.zrem(key, userId)) === 0) {
+      // The user was paired elsewhere meanwhile; put the candidate back,
+      // at the front
+      await redis.zadd(key, 'NX', 0, id);
+      return null;
+    }
+
+    const [user1Id, user2Id] = [userId, id].sort();
+    const endsAt = new Date(
+      Math.min(
+        Date.now() + session.roundSeconds * 1000,
+        session.endsAt.getTime()
+      )
+    );
+    const date = await prisma.speedDate.create({
+      data: { sessionId: session.id, user1Id, user2Id, endsAt },
+    });
+    for (const participant of [user1Id, user2Id]) {
+      await relay(participant, {
+        type: 'paired',
+        dateId: date.id,
+        fromUserId: null,
+        payload: {
+          withUserId: otherUser(date, participant),
+          endsAt: date.endsAt.toISOString(),
+        },
+        sentAt: new Date().toISOString(),
+      });
+    }
+    return date;
+  }
+  return null;
+}
+
+export class SpeedDating {
+  /**
+   * Sessions that haven't ended, soonest first. Sessions for a city only
+   * show up for people in it.
+   */
+  static async upcoming(cityId: string | null) {
+    return prisma.speedDatingSession.findMany({
+      where: {
+        status: 'scheduled',
+        endsAt: { gt: new Date() },
+        OR: [{ cityId: null }, ...(cityId ? [{ cityId }] : [])],
+      },
+      select: {
+        id: true,
+        title: true,
+        cityId: true,
+        startsAt: true,
+        endsAt: true,
+        roundSeconds: true,
+      },
+      orderBy: { startsAt: 'asc' },
+      take: 20,
+    });
+  }
+
+  static async list() {
+    return prisma.speedDatingSession.findMany({
+      orderBy: { startsAt: 'desc' },
+      take: 100,
+    });
+  }
+
+  static async create(
+    input: SessionInput,
+    adminId: string
+  ): Promise<SessionResult> {
+    if (input.cityId && !(await Places.isSelectable(input.cityId, 'city'))) {
+      return { status: 'invalid_city' };
+    }
+    const session = await prisma.speedDatingSession.create({
+      data: { ...input, createdBy: adminId },
+    });
+    await AuditLog.record({
+      action: 'admin.speed_dating_session_created',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'speed_dating_session',
+      targetId: session.id,
+      details: { startsAt: session.startsAt, endsAt: session.endsAt },
+    });
+    return { status: 'saved', session };
+  }
+
+  /**
+   * Call a session off. Anyone still queued is dropped; dates already
+   * running finish.
+   */
+  static async cancel(
+    id: string,
+    adminId: string
+  ): Promise<SpeedDatingSession | null> {
+    const existing = await prisma.speedDatingSession.findUnique({
+      where: { id },
+    });
+    if (!existing) {
+      return null;
+    }
+    const session = await prisma.speedDatingSession.update({
+      where: { id },
+      data: { status: 'canceled' },
+    });
+    await redis.del(queueKey(id));
+    await AuditLog.record({
+      action: 'admin.speed_dating_session_canceled',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'speed_dating_session',
+      targetId: id,
+    });
+    return session;
+  }
+
+  /**
+   * Join a live session's queue, getting paired straight away if someone
+   * suitable is waiting
+   */
+  static async join(sessionId: string, userId: string): Promise<JoinResult> {
+    const session = await prisma.speedDatingSession.findUnique({
+      where: { id: sessionId },
+    });
+    if (!session || session.status === 'canceled') {
+      return { status: 'not_found' };
+    }
+    if (!isLive(session)) {
+      return { status: 'not_live' };
+    }
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { cityId: true },
+    });
+    if (session.cityId && user?.cityId !== session.cityId) {
+      return { status: 'not_eligible' };
+    }
+    if (await currentDate(userId)) {
+      return { status: 'in_date' };
+    }
+
+    await redis.zadd(queueKey(sessionId), 'NX', Date.now(), userId);
+    await redis.expireat(
+      queueKey(sessionId),
+      Math.ceil(session.endsAt.getTime() / 1000)
+    );
+    const date = await pair(session, userId);
+    return date ? { status: 'paired', date } : { status: 'waiting' };
+  }
+
+  static async leave(sessionId: string, userId: string): Promise<boolean> {
+    return (await redis.zrem(queueKey(sessionId), userId)) > 0;
+  }
+
+  /**
+   * Whether the user is queued, the date they're in, and the updates
+   * waiting for them (removed as they're returned)
+   */
+  static async state(sessionId: string, userId: string) {
+    const key = mailboxKey(userId);
+    const [queued, date, results] = await Promise.all([
+      redis.zscore(queueKey(sessionId), userId),
+      currentDate(userId),
+      redis.multi().lrange(key, 0, -1).del(key).exec(),
+    ]);
+    const pending = (results?.[0]?.[1] as string[] | undefined) ?? [];
+    return {
+      queued: queued !== null,
+      date:
+        date && date.sessionId === sessionId
+          ? {
+              id: date.id,
+              withUserId: otherUser(date, userId),
+              startedAt: date.startedAt,
+              endsAt: date.endsAt,
+            }
+          : null,
+      updates: pending.map(data => JSON.parse(data) as SpeedDatingUpdate),
+    };
+  }
+
+  /**
+   * Relay a chat message to the other person while the date is running
+   */
+  static async message(
+    dateId: string,
+    userId: string,
+    text: string
+  ): Promise<MessageResult> {
+    const date = await prisma.speedDate.findFirst({
+      where: { id: dateId, OR: [{ user1Id: userId }, { user2Id: userId }] },
+    });
+    if (!date) {
+      return { status: 'not_found' };
+    }
+    if (date.endsAt <= new Date() || date.vote1 || date.vote2) {
+      return { status: 'ended' };
+    }
+    await relay(otherUser(date, userId), {
+      type: 'message',
+      dateId,
+      fromUserId: userId,
+      payload: { text },
+      sentAt: new Date().toISOString(),
+    });
+    return { status: 'sent' };
+  }
+
+  /**
+   * Vote to keep or pass on the other person. Voting during the date ends
+   * it for both. Two keeps make a regular match.
+   */
+  static async vote(
+    dateId: string,
+    userId: string,
+    vote: SpeedDateVote
+  ): Promise<VoteResult> {
+    const date = await prisma.speedDate.findFirst({
+      where: { id: dateId, OR: [{ user1Id: userId }, { user2Id: userId }] },
+    });
+    if (!date) {
+      return { status: 'not_found' };
+    }
+    const isUser1 = date.user1Id === userId;
+    if (
+      (isUser1 ? date.vote1 : date.vote2) ||
+      date.endsAt.getTime() + VOTE_WINDOW_MS < Date.now()
+    ) {
+      return { status: 'closed' };
+    }
+
+    const updated = await prisma.speedDate.update({
+      where: { id: dateId },
+      data: isUser1 ? { vote1: vote } : { vote2: vote },
+    });
+    const otherId = otherUser(date, userId);
+    if (date.endsAt > new Date() && !date.vote1 && !date.vote2) {
+      await relay(otherId, {
+        type: 'ended',
+        dateId,
+        fromUserId: userId,
+        payload: {},
+        sentAt: new Date().toISOString(),
+      });
+    }
+    if (updated.vote1 !== 'keep' || updated.vote2 !== 'keep') {
+      return { status: 'voted', matchId: null };
+    }
+
+    const match = await prismaUnitOfWork.run(async store => {
+      const existing = await store.matches.findBetween(
+        date.user1Id,
+        date.user2Id
+      );
+      if (existing) {
+        return existing;
+      }
+      const created = await store.matches.create(date.user1Id, date.user2Id);
+      await store.outbox.add('match.created', {
+        matchId: created.id,
+        user1Id: created.user1Id,
+        user2Id: created.user2Id,
+      });
+      return created;
+    });
+    Outbox.relaySoon();
+    await prisma.speedDate.update({
+      where: { id: dateId },
+      data: { matchId: match.id },
+    });
+
+    for (const participant of [date.user1Id, date.user2Id]) {
+      await relay(participant, {
+        type: 'matched',
+        dateId,
+        fromUserId: null,
+        payload: { matchId: match.id },
+        sentAt: new Date().toISOString(),
+      });
+      await Notifications.notify(participant, {
+        type: 'match',
+        path: `/matches/${match.id}`,
+        data: { matchId: match.id },
+        push: true,
+      });
+    }
+    return { status: 'voted', matchId: match.id };
+  }
+
+  /**
+   * Pair whoever is still waiting in live sessions, oldest first (joins
+   * pair on the spot, but not everyone is there yet when they join)
+   */
+  static async pairWaiting(): Promise<{ paired: number }> {
+    const now = new Date();
+    const sessions = await prisma.speedDatingSession.findMany({
+      where: {
+        status: 'scheduled',
+        startsAt: { lte: now },
+        endsAt: { gt: now },
+      },
+    });
+    let paired = 0;
+    for (const session of sessions) {
+      const waiting = await redis.zrange(queueKey(session.id), 0, -1);
+      for (const userId of waiting) {
+        if ((await redis.zscore(queueKey(session.id), userId)) === null) {
+          continue; // Paired earlier in this pass
+        }
+        if (await pair(session, userId)) {
+          paired++;
+        }
+      }
+    }
+    return { paired };
+  }
+}
+
+export const speedDatingPairing: ScheduledTask = {
+  name: 'speed-dating-pairing',
+  everyMs: parseInt(process.env.SPEED_DATING_PAIRING_INTERVAL_MS || '10000'),
+  run: () => SpeedDating.pairWaiting(),
+};
@@ -0,0 +1,365 @@
+/**
+ * @description Unit tests for the unit of work, and the flows built on it,
+ * against an in-memory store
+ */
+
+import { describe, it, expect, jest, beforeEach } from '@jest/globals';
+import type { Invite, Match, Pass, Signal } from '@prisma/client';
+import { Store, UnitOfWork, prismaUnitOfWork } from '@/lib/store';
+import { Invites } from '@/lib/invites';
+import { Swipes } from '@/lib/swipes';
+
+// The transaction client handed to prismaUnitOfWork's repositories
+const mockTx = {
+  invite: {
+    updateMany: jest.fn(async (_args: unknown) => ({ count: 1 })),
+    findUnique: jest.fn(async (_args: unknown) => null),
+  },
+  signal: {
+    findFirst: jest.fn(async (_args: unknown) => null),
+  },
+};
+
+jest.mock('@/lib/prisma', () => ({
+  __esModule: true,
+  default: {
+    $transaction: (work: (tx: unknown) => Promise<unknown>) => work(mockTx),
+  },
+}));
+
+jest.mock('@/lib/outbox', () => ({
+  Outbox: { relaySoon: () => {} },
+}));
+
+jest.mock('@/lib/read-replicas', () => ({
+  ReadReplicas: { markWrite: async () => {} },
+}));
+
+interface MemoryDb {
+  signals: Signal[];
+  passes: Pass[];
+  matches: Match[];
+  invites: Invite[];
+  outbox: Array<{ type: string; payload: object }>;
+}
+
+const emptyDb = (): MemoryDb => ({
+  signals: [],
+  passes: [],
+  matches: [],
+  invites: [],
+  outbox: [],
+});
+
+let nextId = 0;
+const id = (prefix: string) => `${prefix}-${++nextId}`;
+
+/**
+ * Repositories over `db`. Writes named in `failing` throw, to stand in for
+ * a database error partway through a flow.
+ */
+function memoryStore(db: MemoryDb, failing: Set<string>): Store {
+  const fail = (write: string) => {
+    if (failing.has(write)) {
+      throw new Error(`${write} failed`);
+    }
+  };
+  return {
+    signals: {
+      create: async input => {
+        fail('signals.create');
+        const signal: Signal = {
+          id: id('signal'),
+          ...input,
+          message: null,
+          messageDeletedAt: null,
+          scamScore: null,
+          scamFlags: null,
+          sentAt: new Date(),
+          deletedAt: null,
+        };
+        db.signals.push(signal);
+        return signal;
+      },
+      findDeliveredLike: async (fromUserId, toUserId) =>
+        db.signals.find(
+          signal =>
+            signal.fromUserId === fromUserId &&
+            signal.toUserId === toUserId &&
+            ['like', 'super_like'].includes(signal.type) &&
+            !signal.suppressed &&
+            !signal.deletedAt
+        ) ?? null,
+    },
+    passes: {
+      record: async (userId, passedUserId, expiresAt) => {
+        fail('passes.record');
+        const pass: Pass = {
+          id: id('pass'),
+          userId,
+          passedUserId,
+          passedAt: new Date(),
+          expiresAt,
+        };
+        db.passes.push(pass);
+        return pass;
+      },
+    },
+    matches: {
+      findBetween: async (userA, userB) =>
+        db.matches.find(
+          match =>
+            [match.user1Id, match.user2Id].sort().join() ===
+              [userA, userB].sort().join() && !match.deletedAt
+        ) ?? null,
+      create: async (user1Id, user2Id) => {
+        fail('matches.create');
+        const match: Match = {
+          id: id('match'),
+          user1Id,
+          user2Id,
+          matchedAt: new Date(),
+          status: 'matched',
+          messageCount: 0,
+          user1FirstMessageAt: null,
+          user2FirstMessageAt: null,
+          deletedAt: null,
+        };
+        db.matches.push(match);
+        return match;
+      },
+    },
+    invites: {
+      findByCode: async code =>
+        db.invites.find(invite => invite.code === code) ?? null,
+      codeExists: async code =>
+        db.invites.some(invite => invite.code === code),
+      claim: async (inviteId, claimedBy) => {
+        fail('invites.claim');
+        const invite = db.invites.find(
+          candidate => candidate.id === inviteId && !candidate.claimedAt
+        );
+        if (!invite) {
+          return null;
+        }
+        invite.claimedBy = claimedBy;
+        invite.claimedAt = new Date();
+        return invite;
+      },
+      create: async (userId, code) => {
+        fail('invites.create');
+        const invite: Invite = {
+          id: id('invite'),
+          code,
+          userId,
+          claimedBy: null,
+          claimedAt: null,
+          createdAt: new Date(),
+        };
+        db.invites.push(invite);
+        return invite;
+      },
+    },
+    outbox: {
+      add: async (type, payload) => {
+        fail('outbox.add');
+        db.outbox.push({ type, payload });
+      },
+    },
+  };
+}
+
+/**
+ * A unit of work over `db` that works on a copy and only keeps it if the
+ * work finishes, like a transaction
+ */
+function memoryUnitOfWork(
+  db: MemoryDb,
+  failing = new Set<string>()
+): UnitOfWork {
+  return {
+    run: async work => {
+      const draft = structuredClone(db);
+      const result = await work(memoryStore(draft, failing));
+      Object.assign(db, draft);
+      return result;
+    },
+  };
+}
+
+function invite(code: string, userId: string): Invite {
+  return {
+    id: id('invite'),
+    code,
+    userId,
+    claimedBy: null,
+    claimedAt: null,
+    createdAt: new Date(),
+  };
+}
+
+describe('Invites.claim', () => {
+  let db: MemoryDb;
+
+  beforeEach(() => {
+    db = emptyDb();
+    db.invites.push(invite('AURUM-ABC123', 'inviter'));
+  });
+
+  it('claims the invite and rewards the inviter together', async () => {
+    const claim = await Invites.claim(
+      'aurum-abc123',
+      'invitee',
+      memoryUnitOfWork(db)
+    );
+
+    expect(claim.status).toBe('claimed');
+    expect(db.invites).toHaveLength(2);
+    expect(db.invites[0].claimedBy).toBe('invitee');
+    expect(db.invites[1]).toMatchObject({ userId: 'inviter', claimedAt: null });
+  });
+
+  it('rolls the claim back when the reward fails', async () => {
+    await expect(
+      Invites.claim(
+        'AURUM-ABC123',
+        'invitee',
+        memoryUnitOfWork(db, new Set(['invites.create']))
+      )
+    ).rejects.toThrow('invites.create failed');
+
+    expect(db.invites).toHaveLength(1);
+    expect(db.invites[0].claimedAt).toBeNull();
+  });
+
+  it('claims each invite once', async () => {
+    const unitOfWork = memoryUnitOfWork(db);
+    await Invites.claim('AURUM-ABC123', 'invitee', unitOfWork);
+
+    expect(
+      await Invites.claim('AURUM-ABC123', 'someone-else', unitOfWork)
+    ).toEqual({ status: 'already_claimed' });
+  });
+
+  it('refuses the inviter their own code', async () => {
+    expect(
+      await Invites.claim('AURUM-ABC123', 'inviter', memoryUnitOfWork(db))
+    ).toEqual({ status: 'own_invite' });
+    expect(db.invites[0].claimedAt).toBeNull();
+  });
+});
+
+describe('Swipes.record', () => {
+  let db: MemoryDb;
+
+  beforeEach(() => {
+    db = emptyDb();
+  });
+
+  const like = (fromUserId: string, toUserId: string, unitOfWork: UnitOfWork) =>
+    Swipes.record(
+      { fromUserId, toUserId, action: 'like', suppressed: false },
+      unitOfWork
+    );
+
+  it('matches a mutual like, with its events', async () => {
+    await like('user-1', 'user-2', memoryUnitOfWork(db));
+    const result = await like('user-2', 'user-1', memoryUnitOfWork(db));
+
+    expect(result.match).toMatchObject({
+      user1Id: 'user-2',
+      user2Id: 'user-1',
+    });
+    expect(db.outbox.map(event => event.type)).toEqual([
+      'signal.sent',
+      'signal.sent',
+      'match.created',
+    ]);
+  });
+
+  it('keeps no signal when the match write fails', async () => {
+    await like('user-1', 'user-2', memoryUnitOfWork(db));
+
+    await expect(
+      like(
+        'user-2',
+        'user-1',
+        memoryUnitOfWork(db, new Set(['matches.create']))
+      )
+    ).rejects.toThrow('matches.create failed');
+
+    expect(db.signals).toHaveLength(1);
+    expect(db.matches).toHaveLength(0);
+    expect(db.outbox).toHaveLength(1);
+  });
+
+  it('never matches on a suppressed like', async () => {
+    await Swipes.record(
+      {
+        fromUserId: 'user-1',
+        toUserId: 'user-2',
+        action: 'like',
+        suppressed: true,
+      },
+      memoryUnitOfWork(db)
+    );
+    const result = await like('user-2', 'user-1', memoryUnitOfWork(db));
+
+    expect(result.match).toBeNull();
+    expect(db.outbox).toHaveLength(1);
+  });
+
+  it('records a pass without a signal', async () => {
+    const result = await Swipes.record(
+      {
+        fromUserId: 'user-1',
+        toUserId: 'user-2',
+        action: 'pass',
+        suppressed: false,
+      },
+      memoryUnitOfWork(db)
+    );
+
+    expect(result).toEqual({ signal: null, match: null });
+    expect(db.passes).toHaveLength(1);
+    expect(db.signals).toHaveLength(0);
+  });
+});
+
+describe('prismaUnitOfWork', () => {
+  beforeEach(() => {
+    mockTx.invite.updateMany.mockClear().mockResolvedValue({ count: 1 });
+    mockTx.invite.findUnique.mockClear();
+    mockTx.signal.findFirst.mockClear();
+  });
+
+  it('binds the repositories to the transaction', async () => {
+    await prismaUnitOfWork.run(store =>
+      store.signals.findDeliveredLike('user-1', 'user-2')
+    );
+
+    expect(mockTx.signal.findFirst).toHaveBeenCalledWith({
+      where: expect.objectContaining({
+        fromUserId: 'user-1',
+        toUserId: 'user-2',
+        suppressed: false,
+        deletedAt: null,
+      }),
+    });
+  });
+
+  it('only claims an invite nobody has claimed', async () => {
+    mockTx.invite.updateMany.mockResolvedValue({ count: 0 });
+
+    const claimed = await prismaUnitOfWork.run(store =>
+      store.invites.claim('invite-1', 'invitee')
+    );
+
+    expect(claimed).toBeNull();
+    expect(mockTx.invite.updateMany).toHaveBeenCalledWith({
+      where: { id: 'invite-1', claimedAt: null },
+      data: { claimedBy: 'invitee', claimedAt: expect.any(Date) },
+    });
+    expect(mockTx.invite.findUnique).not.toHaveBeenCalled();
+  });
+});
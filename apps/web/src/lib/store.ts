@@ -0,0 +1,153 @@
+/**
+ * Store
+ * Repository interfaces for the multi-step write flows, and a unit of work
+ * that hands out repositories bound to one database transaction. Flows
+ * take a `UnitOfWork` so every step commits or rolls back together, and so
+ * they can run against an in-memory store in tests. Reads skip soft-deleted
+ * rows.
+ */
+
+import { Invite, Match, Pass, Prisma, Signal } from '@prisma/client';
+import prisma from './prisma';
+
+export interface SignalStore {
+  create(input: {
+    fromUserId: string;
+    toUserId: string;
+    type: string;
+    suppressed: boolean;
+  }): Promise<Signal>;
+  /**
+   * A delivered like or super-like from `fromUserId` to `toUserId`
+   */
+  findDeliveredLike(
+    fromUserId: string,
+    toUserId: string
+  ): Promise<Signal | null>;
+}
+
+export interface PassStore {
+  /**
+   * Record `userId`'s pass on `passedUserId`, restarting its window if
+   * they'd passed before
+   */
+  record(userId: string, passedUserId: string, expiresAt: Date): Promise<Pass>;
+}
+
+export interface MatchStore {
+  /**
+   * The pair's match, whichever of them liked first
+   */
+  findBetween(userA: string, userB: string): Promise<Match | null>;
+  create(user1Id: string, user2Id: string): Promise<Match>;
+}
+
+export interface InviteStore {
+  findByCode(code: string): Promise<Invite | null>;
+  codeExists(code: string): Promise<boolean>;
+  /**
+   * Mark an unclaimed invite claimed. Returns null if someone got there
+   * first.
+   */
+  claim(id: string, claimedBy: string): Promise<Invite | null>;
+  create(userId: string, code: string): Promise<Invite>;
+}
+
+export interface OutboxStore {
+  /**
+   * Queue an event to be published once the transaction commits
+   */
+  add(type: string, payload: object): Promise<void>;
+}
+
+export interface Store {
+  signals: SignalStore;
+  passes: PassStore;
+  matches: MatchStore;
+  invites: InviteStore;
+  outbox: OutboxStore;
+}
+
+export interface UnitOfWork {
+  /**
+   * Run `work` in one transaction; anything it throws rolls it all back
+   */
+  run<T>(work: (store: Store) => Promise<T>): Promise<T>;
+}
+
+// The subset of the client (or a transaction) the repositories use
+type Db = Pick<
+  typeof prisma,
+  'signal' | 'pass' | 'match' | 'invite' | 'outboxEvent'
+>;
+
+function prismaStore(db: Db): Store {
+  return {
+    signals: {
+      create: data => db.signal.create({ data }),
+      findDeliveredLike: (fromUserId, toUserId) =>
+        db.signal.findFirst({
+          where: {
+            fromUserId,
+            toUserId,
+            type: { in: ['like', 'super_like'] },
+            suppressed: false,
+            deletedAt: null,
+          },
+        }),
+    },
+    passes: {
+      record: (userId, passedUserId, expiresAt) =>
+        db.pass.upsert({
+          where: { userId_passedUserId: { userId, passedUserId } },
+          create: { userId, passedUserId, expiresAt },
+          update: { passedAt: new Date(), expiresAt },
+        }),
+    },
+    matches: {
+      findBetween: (userA, userB) =>
+        db.match.findFirst({
+          where: {
+            OR: [
+              { user1Id: userA, user2Id: userB },
+              { user1Id: userB, user2Id: userA },
+            ],
+            deletedAt: null,
+          },
+        }),
+      create: (user1Id, user2Id) =>
+        db.match.create({ data: { user1Id, user2Id } }),
+    },
+    invites: {
+      findByCode: code => db.invite.findUnique({ where: { code } }),
+      codeExists: async code =>
+        (await db.invite.count({ where: { code } })) > 0,
+      claim: async (id, claimedBy) => {
+        // Conditional on claimedAt so two claims can't both succeed
+        const result = await db.invite.updateMany({
+          where: { id, claimedAt: null },
+          data: { claimedBy, claimedAt: new Date() },
+        });
+        return result.count === 1
+          ? db.invite.findUnique({ where: { id } })
+          : null;
+      },
+      create: (userId, code) => db.invite.create({ data: { userId, code } }),
+    },
+    outbox: {
+      add: async (type, payload) => {
+        await db.outboxEvent.create({
+          data: {
+            id: crypto.randomUUID(),
+            type,
+            payload: payload as Prisma.InputJsonValue,
+          },
+        });
+      },
+    },
+  };
+}
+
+export const prismaUnitOfWork: UnitOfWork = {
+  run: work => prisma.$transaction(tx => work(prismaStore(tx))),
+};
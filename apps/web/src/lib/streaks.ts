@@ -0,0 +1,158 @@
+/**
+ * Streaks
+ * Daily active streaks. Once a day the app claims the day for the user;
+ * days are local dates in the user's timezone (see WakingHours), so the
+ * streak rolls over at their midnight. Claiming again the same day is a
+ * no-op, as is a day that's already behind the last claim (after moving
+ * to a timezone further west). Milestones grant super-interests.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { WakingHours, localDate } from './waking-hours';
+import { Inventory } from './inventory';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+export interface StreakMilestone {
+  days: number;
+  superInterests: number;
+}
+
+export const STREAK_MILESTONES: StreakMilestone[] = [
+  { days: 3, superInterests: 1 },
+  { days: 7, superInterests: 2 },
+  { days: 14, superInterests: 3 },
+  { days: 30, superInterests: 5 },
+];
+
+// Past the last milestone, every this many days repeats its reward
+const REPEAT_EVERY_DAYS = 30;
+
+export type ClaimResult =
+  | { status: 'claimed'; streak: number; reward: StreakMilestone | null }
+  | { status: 'already_claimed'; streak: number };
+
+function daysBetween(from: string, to: string): number {
+  return Math.round((Date.parse(to) - Date.parse(from)) / DAY_MS);
+}
+
+/**
+ * The reward for reaching `days` in a row, if it's a milestone
+ */
+export function milestoneReward(days: number): StreakMilestone | null {
+  const milestone = STREAK_MILESTONES.find(m => m.days === days);
+  if (milestone) {
+    return milestone;
+  }
+  const last = STREAK_MILESTONES[STREAK_MILESTONES.length - 1];
+  if (days > last.days && (days - last.days) % REPEAT_EVERY_DAYS === 0) {
+    return { days, superInterests: last.superInterests };
+  }
+  return null;
+}
+
+function nextMilestone(days: number): StreakMilestone {
+  const upcoming = STREAK_MILESTONES.find(m => m.days > days);
+  if (upcoming) {
+    return upcoming;
+  }
+  const last = STREAK_MILESTONES[STREAK_MILESTONES.length - 1];
+  const repeats = Math.floor((days - last.days) / REPEAT_EVERY_DAYS) + 1;
+  return {
+    days: last.days + repeats * REPEAT_EVERY_DAYS,
+    superInterests: last.superInterests,
+  };
+}
+
+export class Streaks {
+  /**
+   * Where the user's streak stands today. A streak whose last claim was
+   * before yesterday has lapsed and counts as zero.
+   */
+  static async status(userId: string) {
+    const { timezone } = await WakingHours.preferences(userId);
+    const today = localDate(new Date(), timezone);
+    const streak = await prisma.streak.findUnique({ where: { userId } });
+
+    const lastDay = streak?.lastDay ?? null;
+    const claimedToday = lastDay !== null && lastDay >= today;
+    const alive =
+      claimedToday || (lastDay !== null && daysBetween(lastDay, today) === 1);
+    const current = alive && streak ? streak.current : 0;
+
+    return {
+      current,
+      longest: streak?.longest ?? 0,
+      today,
+      timezone,
+      claimedToday,
+      // What claiming each day from here leads to
+      nextMilestone: nextMilestone(current),
+    };
+  }
+
+  /**
+   * Claim today for the user and grant any milestone reward. Safe to call
+   * as often as the app likes.
+   */
+  static async claim(userId: string): Promise<ClaimResult> {
+    const { timezone } = await WakingHours.preferences(userId);
+    const today = localDate(new Date(), timezone);
+
+    let streak: number | null;
+    try {
+      streak = await prisma.$transaction(async tx => {
+        const existing = await tx.streak.findUnique({ where: { userId } });
+        if (existing?.lastDay && existing.lastDay >= today) {
+          return null;
+        }
+        const current =
+          existing?.lastDay && daysBetween(existing.lastDay, today) === 1
+            ? existing.current + 1
+            : 1;
+
+        // The unique day stops two concurrent claims both counting
+        await tx.streakClaim.create({
+          data: { userId, day: today, streak: current, timezone },
+        });
+        await tx.streak.upsert({
+          where: { userId },
+          create: { userId, current, longest: current, lastDay: today },
+          update: {
+            current,
+            longest: Math.max(existing?.longest ?? 0, current),
+            lastDay: today,
+          },
+        });
+        return current;
+      });
+    } catch (error) {
+      if (
+        error instanceof Prisma.PrismaClientKnownRequestError &&
+        error.code === 'P2002'
+      ) {
+        streak = null; // Claimed concurrently
+      } else {
+        throw error;
+      }
+    }
+
+    if (streak === null) {
+      const existing = await prisma.streak.findUnique({ where: { userId } });
+      return { status: 'already_claimed', streak: existing?.current ?? 0 };
+    }
+
+    const reward = milestoneReward(streak);
+    if (reward) {
+      // Keyed by day so a reward is never granted twice
+      await Inventory.grant(
+        userId,
+        'super_interest',
+        reward.superInterests,
+        `streak:${userId}:${today}`
+      );
+    }
+    return { status: 'claimed', streak, reward };
+  }
+}
@@ -0,0 +1,300 @@
+/**
+ * Stripe Billing
+ * Maps Stripe subscription lifecycle, refund and dispute webhooks onto plans
+ * and entitlements
+ */
+
+import prisma from './prisma';
+import redis from './redis';
+import { Entitlements, Plan } from './entitlements';
+import { Payments, PRODUCTS } from './payments';
+import { EventBus } from './event-bus';
+import { Dunning } from './dunning';
+import { Refunds } from './refunds';
+import { StripeEvent } from './stripe';
+
+export const STRIPE_PROVIDER = 'stripe';
+
+// Processed event IDs are remembered this long to drop redeliveries
+const EVENT_DEDUPE_TTL = 7 * 24 * 60 * 60;
+
+export type StripeEventOutcome = 'processed' | 'duplicate' | 'ignored';
+
+type StripeObjectHandler = (object: Record<string, any>) => Promise<void>;
+
+function planFor(productId?: string): Plan {
+  const grant = productId ? PRODUCTS[productId]?.grant : undefined;
+  return grant?.type === 'plan' ? grant.plan : 'premium';
+}
+
+// Newer API versions moved the billing period onto subscription items
+function subscriptionPeriodEnd(subscription: Record<string, any>): Date {
+  const end =
+    subscription.current_period_end ??
+    subscription.items?.data?.[0]?.current_period_end;
+  return new Date(end * 1000);
+}
+
+function invoiceSubscriptionId(invoice: Record<string, any>): string | null {
+  return (
+    invoice.subscription ??
+    invoice.parent?.subscription_details?.subscription ??
+    null
+  );
+}
+
+async function userForSubscription(
+  subscriptionId: string | null
+): Promise<string | null> {
+  if (!subscriptionId) {
+    return null;
+  }
+  const subscription = await prisma.subscription.findUnique({
+    where: { providerSubscriptionId: subscriptionId },
+    select: { userId: true },
+  });
+  return subscription?.userId || null;
+}
+
+async function recordInvoice(
+  userId: string,
+  invoice: Record<string, any>,
+  status: 'confirmed' | 'failed'
+): Promise<void> {
+  const productId =
+    invoice.parent?.subscription_details?.metadata?.product ??
+    invoice.subscription_details?.metadata?.product ??
+    'premium_monthly';
+  const kind =
+    invoice.billing_reason === 'subscription_cycle' ? 'renewal' : 'purchase';
+
+  const payment = await prisma.payment.upsert({
+    where: { reference: invoice.id },
+    create: {
+      reference: invoice.id,
+      userId,
+      provider: STRIPE_PROVIDER,
+      kind,
+      product: productId,
+      token: String(invoice.currency || 'usd').toUpperCase(),
+      amount: String(
+        status === 'confirmed' ? invoice.amount_paid : invoice.amount_due
+      ),
+      status,
+      transactionId: invoice.payment_intent ?? null,
+      confirmedAt: status === 'confirmed' ? new Date() : null,
+    },
+    update: {
+      status,
+      confirmedAt: status === 'confirmed' ? new Date() : undefined,
+    },
+  });
+  if (status === 'confirmed') {
+    await Payments.sendReceipt(payment);
+  }
+}
+
+async function handleSubscriptionCreated(subscription: Record<string, any>) {
+  const userId = subscription.metadata?.userId;
+  if (!userId || !['active', 'trialing'].includes(subscription.status)) {
+    return;
+  }
+
+  await Entitlements.setPlan(
+    userId,
+    planFor(subscription.metadata?.product),
+    subscriptionPeriodEnd(subscription),
+    { provider: STRIPE_PROVIDER, providerSubscriptionId: subscription.id }
+  );
+  await EventBus.publish('billing.subscription_created', {
+    userId,
+    provider: STRIPE_PROVIDER,
+    subscriptionId: subscription.id,
+  });
+}
+
+async function handleInvoicePaid(invoice: Record<string, any>) {
+  const subscriptionId = invoiceSubscriptionId(invoice);
+  const userId = await userForSubscription(subscriptionId);
+  if (!userId) {
+    // subscription.created hasn't been processed yet; Stripe will retry
+    throw new Error(`No subscription on file for ${subscriptionId}`);
+  }
+
+  await recordInvoice(userId, invoice, 'confirmed');
+
+  const periodEnd = invoice.lines?.data?.[0]?.period?.end;
+  const productId =
+    invoice.parent?.subscription_details?.metadata?.product ??
+    invoice.subscription_details?.metadata?.product;
+  if (periodEnd) {
+    await Dunning.resolve(userId);
+    // Keep any plan time stacked past the period with crypto payments
+    // (see lib/payments)
+    const plan = planFor(productId);
+    const current = await Entitlements.getSubscription(userId);
+    const stackedUntil =
+      current.plan === plan && current.expiresAt
+        ? new Date(current.expiresAt).getTime()
+        : 0;
+    await Entitlements.setPlan(
+      userId,
+      plan,
+      new Date(Math.max(periodEnd * 1000, stackedUntil)),
+      { provider: STRIPE_PROVIDER, providerSubscriptionId: subscriptionId! }
+    );
+  }
+
+  if (invoice.billing_reason === 'subscription_cycle') {
+    await EventBus.publish('billing.subscription_renewed', {
+      userId,
+      provider: STRIPE_PROVIDER,
+      subscriptionId,
+      invoiceId: invoice.id,
+    });
+  }
+}
+
+async function handlePaymentFailed(invoice: Record<string, any>) {
+  const subscriptionId = invoiceSubscriptionId(invoice);
+  const userId = await userForSubscription(subscriptionId);
+  if (!userId) {
+    return;
+  }
+
+  // Entitlements stay until the grace window closes
+  await recordInvoice(userId, invoice, 'failed');
+  const graceEndsAt = await Dunning.startGracePeriod(userId);
+  await EventBus.publish('billing.payment_failed', {
+    userId,
+    provider: STRIPE_PROVIDER,
+    subscriptionId,
+    invoiceId: invoice.id,
+    attemptCount: invoice.attempt_count,
+    nextAttemptAt: invoice.next_payment_attempt
+      ? new Date(invoice.next_payment_attempt * 1000).toISOString()
+      : null,
+    graceEndsAt: graceEndsAt?.toISOString() || null,
+  });
+}
+
+async function handleSubscriptionDeleted(subscription: Record<string, any>) {
+  const current = await prisma.subscription.findUnique({
+    where: { providerSubscriptionId: subscription.id },
+  });
+  const userId = current?.userId ?? subscription.metadata?.userId;
+  if (!userId) {
+    return;
+  }
+
+  await Dunning.resolve(userId);
+  // Nothing to take back if the plan no longer comes from this subscription
+  if (current) {
+    // Time bought with other providers was stacked on the end of the
+    // subscription's period; only the subscription's own time goes
+    const stacked = current.expiresAt
+      ? current.expiresAt.getTime() -
+        subscriptionPeriodEnd(subscription).getTime()
+      : 0;
+    if (stacked > 0) {
+      await Entitlements.setPlan(
+        userId,
+        current.plan as Plan,
+        new Date(Date.now() + stacked),
+        { provider: null, providerSubscriptionId: null }
+      );
+    } else {
+      await Entitlements.setPlan(userId, 'free');
+      await Entitlements.setSubscriptionStatus(userId, 'canceled');
+    }
+  }
+  await EventBus.publish('billing.subscription_canceled', {
+    userId,
+    provider: STRIPE_PROVIDER,
+    subscriptionId: subscription.id,
+  });
+}
+
+// Charges are matched to invoices by their payment intent
+async function paymentForCharge(paymentIntentId?: string | null) {
+  if (!paymentIntentId) {
+    return null;
+  }
+  return prisma.payment.findUnique({
+    where: { transactionId: paymentIntentId },
+  });
+}
+
+async function handleChargeRefunded(charge: Record<string, any>) {
+  const payment = await paymentForCharge(charge.payment_intent);
+  if (!payment) {
+    return;
+  }
+
+  const refund = charge.refunds?.data?.[0];
+  await Refunds.reverse(payment, {
+    kind: 'refund',
+    providerReference: refund?.id ?? charge.id,
+    amount: String(refund?.amount ?? charge.amount_refunded),
+    full: charge.refunded === true,
+    reason: refund?.reason ?? undefined,
+  });
+}
+
+async function handleDisputeCreated(dispute: Record<string, any>) {
+  const payment = await paymentForCharge(dispute.payment_intent);
+  if (!payment) {
+    return;
+  }
+
+  await Refunds.reverse(payment, {
+    kind: 'chargeback',
+    providerReference: dispute.id,
+    amount: String(dispute.amount),
+    full: true,
+    reason: dispute.reason,
+  });
+}
+
+/**
+ * Apply a verified Stripe event. Redelivered events are acknowledged
+ * without being applied twice.
+ */
+export async function handleStripeEvent(
+  event: StripeEvent
+): Promise<StripeEventOutcome> {
+  const handlers: Record<string, StripeObjectHandler> = {
+    'customer.subscription.created': handleSubscriptionCreated,
+    'invoice.paid': handleInvoicePaid,
+    'invoice.payment_failed': handlePaymentFailed,
+    'customer.subscription.deleted': handleSubscriptionDeleted,
+    'charge.refunded': handleChargeRefunded,
+    'charge.dispute.created': handleDisputeCreated,
+  };
+
+  const handler = handlers[event.type];
+  if (!handler) {
+    return 'ignored';
+  }
+
+  const dedupeKey = `stripe_event:${event.id}`;
+  const claimed = await redis.set(
+    dedupeKey,
+    '1',
+    'EX',
+    EVENT_DEDUPE_TTL,
+    'NX'
+  );
+  if (!claimed) {
+    return 'duplicate';
+  }
+
+  try {
+    await handler(event.data.object);
+    return 'processed';
+  } catch (error) {
+    // Let Stripe's retry re-run the handler
+    await redis.del(dedupeKey);
+    throw error;
+  }
+}
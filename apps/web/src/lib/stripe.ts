@@ -0,0 +1,147 @@
+/**
+ * Stripe
+ * Minimal Stripe REST client (checkout sessions) and webhook signature
+ * verification
+ */
+
+import { createHmac, timingSafeEqual } from 'crypto';
+import { upstreamFetch } from './http-client';
+
+const STRIPE_API_URL = 'https://api.stripe.com/v1';
+
+// Maximum age of a webhook signature timestamp
+const SIGNATURE_TOLERANCE_SECONDS = 300;
+
+export interface StripeEvent {
+  id: string;
+  type: string;
+  created: number;
+  data: { object: Record<string, any> };
+}
+
+/**
+ * Flatten nested params into Stripe's form encoding (a[b][0][c]=...)
+ */
+function encodeForm(params: Record<string, unknown>): URLSearchParams {
+  const form = new URLSearchParams();
+  const append = (key: string, value: unknown) => {
+    if (value === undefined || value === null) {
+      return;
+    }
+    if (typeof value === 'object') {
+      Object.entries(value as Record<string, unknown>).forEach(([k, v]) =>
+        append(`${key}[${k}]`, v)
+      );
+      return;
+    }
+    form.append(key, String(value));
+  };
+  Object.entries(params).forEach(([key, value]) => append(key, value));
+  return form;
+}
+
+async function stripeRequest<T>(
+  path: string,
+  params: Record<string, unknown>
+): Promise<T> {
+  const secretKey = process.env.STRIPE_SECRET_KEY;
+  if (!secretKey) {
+    throw new Error('STRIPE_SECRET_KEY is not configured');
+  }
+
+  const response = await upstreamFetch(
+    'stripe',
+    `${STRIPE_API_URL}${path}`,
+    {
+      method: 'POST',
+      headers: {
+        Authorization: `Bearer ${secretKey}`,
+        'Content-Type': 'application/x-www-form-urlencoded',
+      },
+      body: encodeForm(params),
+    },
+    { timeoutMs: 10000 }
+  );
+
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(
+      `Stripe ${path} failed: ${data?.error?.message || response.status}`
+    );
+  }
+  return data as T;
+}
+
+/**
+ * Create a subscription checkout session for a user
+ */
+export async function createCheckoutSession(options: {
+  userId: string;
+  productId: string;
+  priceId: string;
+  successUrl: string;
+  cancelUrl: string;
+}): Promise<{ id: string; url: string }> {
+  // userId/product ride along on the subscription so webhooks can map back
+  const metadata = { userId: options.userId, product: options.productId };
+
+  return stripeRequest('/checkout/sessions', {
+    mode: 'subscription',
+    line_items: [{ price: options.priceId, quantity: 1 }],
+    client_reference_id: options.userId,
+    success_url: options.successUrl,
+    cancel_url: options.cancelUrl,
+    metadata,
+    subscription_data: { metadata },
+  });
+}
+
+/**
+ * Verify a Stripe-Signature header and parse the event. Throws on an
+ * invalid or stale signature.
+ */
+export function verifyWebhookEvent(
+  payload: string,
+  signatureHeader: string | null
+): StripeEvent {
+  const secret = process.env.STRIPE_WEBHOOK_SECRET;
+  if (!secret) {
+    throw new Error('STRIPE_WEBHOOK_SECRET is not configured');
+  }
+  if (!signatureHeader) {
+    throw new Error('Missing Stripe-Signature header');
+  }
+
+  const parts = signatureHeader.split(',').map(part => part.split('='));
+  const timestamp = parts.find(([key]) => key === 't')?.[1];
+  const signatures = parts
+    .filter(([key]) => key === 'v1')
+    .map(([, value]) => value);
+
+  if (!timestamp || signatures.length === 0) {
+    throw new Error('Malformed Stripe-Signature header');
+  }
+
+  const age = Math.abs(Date.now() / 1000 - parseInt(timestamp));
+  if (isNaN(age) || age > SIGNATURE_TOLERANCE_SECONDS) {
+    throw new Error('Stripe signature timestamp outside tolerance');
+  }
+
+  const expected = Buffer.from(
+    createHmac('sha256', secret)
+      .update(`${timestamp}.${payload}`)
+      .digest('hex')
+  );
+  const valid = signatures.some(signature => {
+    const candidate = Buffer.from(signature);
+    return (
+      candidate.length === expected.length &&
+      timingSafeEqual(candidate, expected)
+    );
+  });
+  if (!valid) {
+    throw new Error('Invalid Stripe signature');
+  }
+
+  return JSON.parse(payload) as StripeEvent;
+}
@@ -0,0 +1,95 @@
+/**
+ * Swipes
+ * Records a swipe and, when it completes a mutual like, the match, in one
+ * transaction so a match never exists without the signal behind it (and a
+ * failed match write doesn't leave a half-recorded swipe). The signal.sent
+ * and match.created events go through the outbox in that transaction.
+ * Passes go to the pass list rather than signals (see lib/passes).
+ */
+
+import { Match, Signal } from '@prisma/client';
+import { prismaUnitOfWork, UnitOfWork } from './store';
+import { ReadReplicas } from './read-replicas';
+import { Outbox } from './outbox';
+import { passExpiry } from './passes';
+
+export const SWIPE_ACTIONS = ['like', 'pass', 'super_like'] as const;
+
+export type SwipeAction = (typeof SWIPE_ACTIONS)[number];
+
+export interface SwipeResult {
+  // Null for passes
+  signal: Signal | null;
+  // Set when this swipe completed a mutual like
+  match: Match | null;
+}
+
+export class Swipes {
+  /**
+   * Store `fromUserId`'s swipe on `toUserId`. Suppressed swipes (from
+   * shadowbanned users) are kept but never match.
+   */
+  static async record(
+    input: {
+      fromUserId: string;
+      toUserId: string;
+      action: SwipeAction;
+      suppressed: boolean;
+    },
+    unitOfWork: UnitOfWork = prismaUnitOfWork
+  ): Promise<SwipeResult> {
+    const { fromUserId, toUserId, action, suppressed } = input;
+    const result = await unitOfWork.run<SwipeResult>(async store => {
+      if (action === 'pass') {
+        await store.passes.record(fromUserId, toUserId, passExpiry());
+        if (!suppressed) {
+          // Still counted alongside likes in analytics
+          await store.outbox.add('signal.sent', {
+            fromUserId,
+            toUserId,
+            type: action,
+          });
+        }
+        return { signal: null, match: null };
+      }
+
+      const signal = await store.signals.create({
+        fromUserId,
+        toUserId,
+        type: action,
+        suppressed,
+      });
+      if (suppressed) {
+        return { signal, match: null };
+      }
+      await store.outbox.add('signal.sent', {
+        fromUserId,
+        toUserId,
+        type: action,
+      });
+      const mutual = await store.signals.findDeliveredLike(
+        toUserId,
+        fromUserId
+      );
+      if (!mutual) {
+        return { signal, match: null };
+      }
+      const existing = await store.matches.findBetween(fromUserId, toUserId);
+      if (existing) {
+        return { signal, match: existing };
+      }
+      const match = await store.matches.create(fromUserId, toUserId);
+      await store.outbox.add('match.created', {
+        matchId: match.id,
+        user1Id: match.user1Id,
+        user2Id: match.user2Id,
+      });
+      return { signal, match };
+    });
+    Outbox.relaySoon();
+
+    // Both sides' likes and matches change, so read them from the primary
+    await ReadReplicas.markWrite(fromUserId, toUserId);
+    return result;
+  }
+}
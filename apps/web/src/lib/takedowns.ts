@@ -0,0 +1,154 @@
+/**
+ * Takedowns
+ * Removes a single piece of profile content (the photo, the bio or a prompt
+ * answer) that breaks a content policy, short of banning the account. The
+ * photo and bio are replaced with a placeholder and a prompt answer is
+ * hidden from others, and the user is told which policy it broke.
+ */
+
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { EventBus } from './event-bus';
+import { Notifications } from './notifications';
+
+export const TAKEDOWN_FIELDS = ['photo', 'bio', 'prompt'] as const;
+
+export type TakedownField = (typeof TAKEDOWN_FIELDS)[number];
+
+export const CONTENT_POLICIES = {
+  nudity: 'Nudity or sexual content',
+  violence: 'Violent or graphic content',
+  hate: 'Hate speech or symbols',
+  harassment: 'Harassment or bullying',
+  impersonation: 'Impersonating someone else',
+  personal_info: "Sharing someone's personal information",
+  spam: 'Spam or advertising',
+  not_a_person: "Photo doesn't clearly show you",
+} as const;
+
+export type ContentPolicy = keyof typeof CONTENT_POLICIES;
+
+export const PHOTO_PLACEHOLDER =
+  process.env.TAKEDOWN_PHOTO_PLACEHOLDER || '/images/content-removed.svg';
+
+export const BIO_PLACEHOLDER =
+  'This bio was removed for breaking our community guidelines.';
+
+export interface TakedownOptions {
+  note?: string;
+  // Which prompt answer, for field "prompt"
+  promptId?: string;
+}
+
+/**
+ * Hide one of the user's prompt answers, returning the removed text
+ */
+async function removePrompt(
+  userId: string,
+  promptId: string | undefined
+): Promise<string | null> {
+  const prompt = promptId
+    ? await prisma.profilePrompt.findUnique({
+        where: { userId_promptId: { userId, promptId } },
+      })
+    : null;
+  if (!prompt || prompt.removedAt) {
+    return null;
+  }
+  await prisma.profilePrompt.update({
+    where: { id: prompt.id },
+    data: { removedAt: new Date() },
+  });
+  return prompt.answer;
+}
+
+/**
+ * Audit, notify the user and publish a takedown. The removed content is
+ * kept in the audit trail as evidence.
+ */
+async function recordTakedown(
+  userId: string,
+  field: TakedownField,
+  policy: ContentPolicy,
+  adminId: string,
+  details: Record<string, unknown>
+) {
+  await AuditLog.record({
+    action: `moderation.${field}_removed`,
+    actorType: 'admin',
+    actorId: adminId,
+    targetType: 'user',
+    targetId: userId,
+    details: { policy, ...details },
+  });
+
+  await Notifications.notify(userId, {
+    type: 'content_removed',
+    template: `content_removed_${field}`,
+    variables: { policy: CONTENT_POLICIES[policy] },
+    path: '/profile/edit',
+    data: { field, policy },
+    push: true,
+  });
+  await EventBus.publish('moderation.content_removed', {
+    userId,
+    field,
+    policy,
+  });
+}
+
+export class Takedowns {
+  /**
+   * Replace a user's photo or bio with a placeholder, or hide a prompt
+   * answer, and notify them. Returns false if the user doesn't exist or
+   * has nothing to take down.
+   */
+  static async remove(
+    userId: string,
+    field: TakedownField,
+    policy: ContentPolicy,
+    adminId: string,
+    options: TakedownOptions = {}
+  ): Promise<boolean> {
+    const { note, promptId } = options;
+    if (field === 'prompt') {
+      const removed = await removePrompt(userId, promptId);
+      if (removed === null) {
+        return false;
+      }
+      await recordTakedown(userId, field, policy, adminId, {
+        removed,
+        promptId,
+        note: note ?? null,
+      });
+      return true;
+    }
+
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { profileImage: true, blurredImage: true, bio: true },
+    });
+    const [removed, placeholder] =
+      field === 'photo'
+        ? [user?.profileImage, PHOTO_PLACEHOLDER]
+        : [user?.bio, BIO_PLACEHOLDER];
+    if (!user || !removed || removed === placeholder) {
+      return false;
+    }
+
+    await prisma.user.update({
+      where: { id: userId },
+      data:
+        field === 'photo'
+          ? { profileImage: placeholder, blurredImage: null }
+          : { bio: placeholder },
+    });
+
+    await recordTakedown(userId, field, policy, adminId, {
+      removed,
+      ...(field === 'photo' && { removedBlurred: user.blurredImage }),
+      note: note ?? null,
+    });
+    return true;
+  }
+}
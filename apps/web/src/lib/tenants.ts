@@ -0,0 +1,214 @@
+/**
+ * Tenants
+ * Campus instances. A tenant partitions users by campus: members only
+ * discover, swipe on and match with members of the same tenant, and each
+ * tenant can have its own host, NFT gate and quotas, so a new campus
+ * launches as a row rather than a deployment. Users outside any tenant
+ * share the default instance. Requests are resolved to a tenant from the
+ * session token, or for signed-out requests (and signup) from the host
+ * they were made to.
+ */
+
+import { NextRequest } from 'next/server';
+import { Tenant } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { singleflight } from './singleflight';
+
+// Tenants change rarely and are read on every discovery request
+const CACHE_MS = 60 * 1000;
+
+let cache: { tenants: Tenant[]; loadedAt: number } | null = null;
+
+export interface TenantInput {
+  id: string;
+  name: string;
+  campusId: string;
+  host?: string | null;
+  gateContractAddress?: string | null;
+  gateCollectionName?: string | null;
+  discoveryDeckSize?: number | null;
+  freeDailySuperInterests?: number | null;
+  freeDailyTopPicks?: number | null;
+  active?: boolean;
+}
+
+export type TenantUpdate = Partial<Omit<TenantInput, 'id' | 'campusId'>>;
+
+export type TenantResult =
+  | { status: 'saved'; tenant: Tenant }
+  | { status: 'already_exists' }
+  | { status: 'not_found' }
+  | { status: 'invalid_campus' }
+  | { status: 'host_taken' };
+
+async function loadAll(): Promise<Tenant[]> {
+  if (cache && Date.now() - cache.loadedAt < CACHE_MS) {
+    return cache.tenants;
+  }
+  return singleflight('tenants', 'all', async () => {
+    const tenants = await prisma.tenant.findMany({ orderBy: { name: 'asc' } });
+    cache = { tenants, loadedAt: Date.now() };
+    return tenants;
+  });
+}
+
+/**
+ * The hostname a request was made to, without the port
+ */
+function requestHost(request: NextRequest): string | null {
+  const host =
+    request.headers.get('x-forwarded-host') || request.headers.get('host');
+  return host ? host.split(',')[0].trim().split(':')[0].toLowerCase() : null;
+}
+
+async function hostTaken(host: string, exceptId?: string): Promise<boolean> {
+  const tenants = await loadAll();
+  return tenants.some(tenant => tenant.host === host && tenant.id !== exceptId);
+}
+
+export class Tenants {
+  static async list(): Promise<Tenant[]> {
+    return loadAll();
+  }
+
+  /**
+   * An active tenant by ID
+   */
+  static async get(id: string | null | undefined): Promise<Tenant | null> {
+    if (!id) {
+      return null;
+    }
+    const tenants = await loadAll();
+    return tenants.find(tenant => tenant.id === id && tenant.active) ?? null;
+  }
+
+  /**
+   * The active tenant served from a host
+   */
+  static async forHost(host: string | null): Promise<Tenant | null> {
+    if (!host) {
+      return null;
+    }
+    const tenants = await loadAll();
+    return (
+      tenants.find(tenant => tenant.host === host && tenant.active) ?? null
+    );
+  }
+
+  /**
+   * The active tenant for a campus's students
+   */
+  static async forCampus(campusId: string): Promise<Tenant | null> {
+    const tenants = await loadAll();
+    return (
+      tenants.find(tenant => tenant.campusId === campusId && tenant.active) ??
+      null
+    );
+  }
+
+  /**
+   * The tenant a request belongs to: the session token's, else the one
+   * served from the request's host, else none
+   */
+  static async resolve(
+    request: NextRequest,
+    tokenTenantId?: string | null
+  ): Promise<Tenant | null> {
+    if (tokenTenantId) {
+      return Tenants.get(tokenTenantId);
+    }
+    return Tenants.forHost(requestHost(request));
+  }
+
+  /**
+   * The tenant a user belongs to, or null for the default instance
+   */
+  static async ofUser(userId: string): Promise<string | null> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: { tenantId: true },
+    });
+    return user?.tenantId ?? null;
+  }
+
+  /**
+   * Whether two users are in the same tenant (or both in none)
+   */
+  static async sameTenant(userId: string, otherId: string): Promise<boolean> {
+    const users = await prisma.user.findMany({
+      where: { id: { in: [userId, otherId] } },
+      select: { tenantId: true },
+    });
+    return users.length === 2 && users[0].tenantId === users[1].tenantId;
+  }
+
+  /**
+   * Set up a campus instance. The campus's existing students move into
+   * it.
+   */
+  static async create(
+    input: TenantInput,
+    adminId: string
+  ): Promise<TenantResult> {
+    const campus = await prisma.place.findUnique({
+      where: { id: input.campusId },
+    });
+    if (campus?.kind !== 'campus') {
+      return { status: 'invalid_campus' };
+    }
+    const existing = await prisma.tenant.findFirst({
+      where: { OR: [{ id: input.id }, { campusId: input.campusId }] },
+    });
+    if (existing) {
+      return { status: 'already_exists' };
+    }
+    if (input.host && (await hostTaken(input.host))) {
+      return { status: 'host_taken' };
+    }
+
+    const [tenant, moved] = await prisma.$transaction([
+      prisma.tenant.create({ data: input }),
+      prisma.user.updateMany({
+        where: { campusId: input.campusId, tenantId: null },
+        data: { tenantId: input.id },
+      }),
+    ]);
+    cache = null;
+    await AuditLog.record({
+      action: 'admin.tenant_created',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'tenant',
+      targetId: tenant.id,
+      details: { campusId: tenant.campusId, usersMoved: moved.count },
+    });
+    return { status: 'saved', tenant };
+  }
+
+  static async update(
+    id: string,
+    update: TenantUpdate,
+    adminId: string
+  ): Promise<TenantResult> {
+    const existing = await prisma.tenant.findUnique({ where: { id } });
+    if (!existing) {
+      return { status: 'not_found' };
+    }
+    if (update.host && (await hostTaken(update.host, id))) {
+      return { status: 'host_taken' };
+    }
+
+    const tenant = await prisma.tenant.update({ where: { id }, data: update });
+    cache = null;
+    await AuditLog.record({
+      action: 'admin.tenant_updated',
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'tenant',
+      targetId: id,
+      details: { changes: Object.keys(update) },
+    });
+    return { status: 'saved', tenant };
+  }
+}
@@ -0,0 +1,172 @@
+/**
+ * Top Picks
+ * A small daily set of the viewer's best candidates: complete profiles
+ * (photo and bio) ranked like discovery, without anyone the viewer has
+ * already swiped on. The background job regenerates each active user's
+ * picks once a day and stores them, so serving them is a cached read.
+ * Everyone sees FREE_DAILY_TOP_PICKS (or their tenant's allowance); the
+ * extra_top_picks entitlement unlocks more of the same set.
+ */
+
+import { User } from '@prisma/client';
+import prisma from './prisma';
+import { ScheduledTask } from './scheduler';
+import { createCache } from './cache';
+import { rankDiscoveryProfiles, RankingMode } from './discovery-ranking';
+import { Entitlements } from './entitlements';
+import { discoveryQuotas } from './client-config';
+import { Tenants } from './tenants';
+import { TRUST_DISCOVERY_MIN } from './trust-score';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+// Picks stored per user; the most anyone can unlock in a day
+const STORED_PICKS = 20;
+// Ranked candidates the picks are chosen from
+const RANKING_POOL = 50;
+const REFRESH_BATCH_SIZE = 200;
+const ACTIVE_WITHIN_DAYS = 7;
+
+export interface TopPicksSet {
+  userIds: string[];
+  ranking: RankingMode;
+  generatedAt: string;
+}
+
+const picksCache = createCache<TopPicksSet | null>('top-picks', {
+  ttlSeconds: 60 * 60,
+});
+
+function isComplete(user: User): boolean {
+  return Boolean(user.profileImage && user.bio);
+}
+
+async function loadPicks(userId: string): Promise<TopPicksSet | null> {
+  const picks = await prisma.topPicks.findUnique({ where: { userId } });
+  if (!picks) {
+    return null;
+  }
+  return {
+    userIds: picks.userIds as string[],
+    ranking: picks.ranking as RankingMode,
+    generatedAt: picks.generatedAt.toISOString(),
+  };
+}
+
+export class TopPicks {
+  /**
+   * Rank and store a fresh set of picks for the user
+   */
+  static async generate(userId: string): Promise<TopPicksSet> {
+    const [{ users, ranking }, swiped] = await Promise.all([
+      rankDiscoveryProfiles(userId, RANKING_POOL),
+      prisma.signal.findMany({
+        where: { fromUserId: userId },
+        select: { toUserId: true },
+      }),
+    ]);
+    const seen = new Set(swiped.map(signal => signal.toUserId));
+    const userIds = users
+      .filter(user => isComplete(user) && !seen.has(user.id))
+      .slice(0, STORED_PICKS)
+      .map(user => user.id);
+
+    const generatedAt = new Date();
+    await prisma.topPicks.upsert({
+      where: { userId },
+      create: { userId, userIds, ranking, generatedAt },
+      update: { userIds, ranking, generatedAt },
+    });
+    await picksCache.invalidate(userId);
+    return { userIds, ranking, generatedAt: generatedAt.toISOString() };
+  }
+
+  /**
+   * How many picks a day the user gets
+   */
+  static async allowance(userId: string): Promise<number> {
+    const [extra, quotas] = await Promise.all([
+      Entitlements.getLimit(userId, 'extra_top_picks'),
+      Tenants.ofUser(userId).then(discoveryQuotas),
+    ]);
+    return Math.min(quotas.freeDailyTopPicks + extra, STORED_PICKS);
+  }
+
+  /**
+   * The viewer's picks as far as their allowance goes, generating them on
+   * the spot if the job hasn't got to the user yet. Picks who have since
+   * been swiped on, or left, drop out until the next set.
+   */
+  static async forViewer(userId: string) {
+    const [stored, allowance] = await Promise.all([
+      picksCache.getOrLoad(userId, () => loadPicks(userId)),
+      TopPicks.allowance(userId),
+    ]);
+    const picks = stored ?? (await TopPicks.generate(userId));
+    const unlockedIds = picks.userIds.slice(0, allowance);
+
+    const [users, swiped] = await Promise.all([
+      prisma.user.findMany({
+        where: {
+          id: { in: unlockedIds },
+          shadowbanned: false,
+          trustScore: { gte: TRUST_DISCOVERY_MIN },
+          status: { not: 'deleted' },
+          deletedAt: null,
+        },
+      }),
+      prisma.signal.findMany({
+        where: { fromUserId: userId, toUserId: { in: unlockedIds } },
+        select: { toUserId: true },
+      }),
+    ]);
+    const seen = new Set(swiped.map(signal => signal.toUserId));
+    const byId = new Map(users.map(user => [user.id, user]));
+
+    return {
+      users: unlockedIds.flatMap(id => {
+        const user = byId.get(id);
+        return user && !seen.has(id) ? [user] : [];
+      }),
+      ranking: picks.ranking,
+      // More picks in today's set that extra_top_picks would unlock
+      locked: Math.max(0, picks.userIds.length - allowance),
+      refreshesAt: new Date(Date.parse(picks.generatedAt) + DAY_MS),
+    };
+  }
+
+  /**
+   * Regenerate recently active users' picks that are a day old or older
+   */
+  static async refreshStale(): Promise<{ refreshed: number }> {
+    const staleBefore = new Date(Date.now() - DAY_MS);
+    const users = await prisma.user.findMany({
+      where: {
+        status: 'active',
+        deletedAt: null,
+        lastSeen: { gte: new Date(Date.now() - ACTIVE_WITHIN_DAYS * DAY_MS) },
+        OR: [
+          { topPicks: { is: null } },
+          { topPicks: { is: { generatedAt: { lt: staleBefore } } } },
+        ],
+      },
+      select: { id: true },
+      take: REFRESH_BATCH_SIZE,
+    });
+    let refreshed = 0;
+    for (const user of users) {
+      try {
+        await TopPicks.generate(user.id);
+        refreshed++;
+      } catch (error) {
+        console.error(`Failed to refresh top picks for ${user.id}:`, error);
+      }
+    }
+    return { refreshed };
+  }
+}
+
+export const topPicksRefresh: ScheduledTask = {
+  name: 'top-picks-refresh',
+  everyMs: parseInt(process.env.TOP_PICKS_INTERVAL_MS || '600000'),
+  run: () => TopPicks.refreshStale(),
+};
@@ -0,0 +1,114 @@
+/**
+ * Travel Mode
+ * Premium users can pick a directory city and be placed there for
+ * discovery and distances, e.g. to line up dates before a trip. The city
+ * stands in for their own location until it expires; a scheduled job ends
+ * it on time.
+ */
+
+import { TravelLocation } from '@prisma/client';
+import prisma from './prisma';
+import { encodeGeohash } from './geohash';
+import { Scheduler, ScheduledTask } from './scheduler';
+import { Notifications } from './notifications';
+import { Places } from './places';
+
+// City centers are stored at ~5 km cells
+const CITY_PRECISION = 5;
+
+export const MAX_TRAVEL_DAYS = parseInt(
+  process.env.TRAVEL_MODE_MAX_DAYS || '7'
+);
+
+interface TravelExpiryJob {
+  userId: string;
+}
+
+const expiryJobId = (userId: string) => `travel-expiry-${userId}`;
+
+export type TravelActivation =
+  | { status: 'activated'; travel: TravelLocation }
+  | { status: 'unknown_city' };
+
+export class TravelMode {
+  /**
+   * The user's active travel location, if any
+   */
+  static async get(userId: string): Promise<TravelLocation | null> {
+    return prisma.travelLocation.findFirst({
+      where: { userId, expiresAt: { gt: new Date() } },
+    });
+  }
+
+  /**
+   * Move the user to a city for `days` days, replacing any current trip
+   */
+  static async start(
+    userId: string,
+    cityId: string,
+    days: number
+  ): Promise<TravelActivation> {
+    const city = await Places.get(cityId);
+    if (!city || city.kind !== 'city' || !city.active) {
+      return { status: 'unknown_city' };
+    }
+
+    const geohash = encodeGeohash(city.lat, city.lng, CITY_PRECISION);
+    const expiresAt = new Date(Date.now() + days * 24 * 60 * 60 * 1000);
+    const travel = await prisma.travelLocation.upsert({
+      where: { userId },
+      create: { userId, city: city.id, geohash, expiresAt },
+      update: { city: city.id, geohash, startedAt: new Date(), expiresAt },
+    });
+
+    // Replaces the previous trip's job, which would otherwise keep its time
+    await Scheduler.cancel(expiryJobId(userId));
+    await Scheduler.scheduleAt(
+      travelModeExpiry.name,
+      { userId },
+      expiresAt,
+      expiryJobId(userId)
+    );
+    return { status: 'activated', travel };
+  }
+
+  static async stop(userId: string): Promise<boolean> {
+    const removed = await prisma.travelLocation.deleteMany({
+      where: { userId },
+    });
+    await Scheduler.cancel(expiryJobId(userId));
+    return removed.count > 0;
+  }
+
+  /**
+   * End a trip that has run out and let the user know
+   */
+  static async expire(
+    userId: string
+  ): Promise<{ ended: string } | { skipped: string }> {
+    const travel = await prisma.travelLocation.findUnique({
+      where: { userId },
+    });
+    if (!travel) {
+      return { skipped: 'not_traveling' };
+    }
+    if (travel.expiresAt > new Date()) {
+      // Extended since this job was scheduled
+      return { skipped: 'still_active' };
+    }
+
+    await prisma.travelLocation.delete({ where: { userId } });
+    const city = await Places.get(travel.city);
+    await Notifications.notify(userId, {
+      type: 'travel_mode_ended',
+      variables: { city: city?.name ?? travel.city },
+      path: '/discover',
+    });
+    return { ended: travel.city };
+  }
+}
+
+export const travelModeExpiry: ScheduledTask<TravelExpiryJob> = {
+  name: 'travel-mode-expiry',
+  run: ({ userId }) => TravelMode.expire(userId),
+};
@@ -0,0 +1,216 @@
+/**
+ * Trust Score
+ * An internal 0-1 score of how much we trust an account, from moderation
+ * outcomes (upheld reports against it, dismissed reports it filed), how
+ * often it answers people who like it, verification, account age and
+ * abuse clamps. It's never shown to users, the account holder included.
+ * Discovery uses it to scale exposure and keep the least trusted out of
+ * decks, and abuse detection scales velocity limits by it. Scores are
+ * refreshed daily for active users and right after a report on them is
+ * resolved.
+ */
+
+import prisma from './prisma';
+import { ScheduledTask } from './scheduler';
+import { AbuseDetection } from './abuse-detection';
+import { createCache } from './cache';
+
+// New accounts start here
+export const NEUTRAL_TRUST = 0.5;
+
+// Below this, an account is left out of other people's discovery decks
+export const TRUST_DISCOVERY_MIN = parseFloat(
+  process.env.TRUST_DISCOVERY_MIN || '0.2'
+);
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const REPORT_LOOKBACK_DAYS = 180;
+const RESPONSE_LOOKBACK_DAYS = 90;
+// Fewer received likes than this says nothing about response rate
+const MIN_LIKES_FOR_RESPONSE_RATE = 5;
+const REFRESH_BATCH_SIZE = 500;
+const ACTIVE_WITHIN_DAYS = 30;
+
+const LIKE_TYPES = ['like', 'super_like', 'interest', 'super_interest'];
+
+// How far each input moves the score from neutral
+const WEIGHTS = {
+  upheldReport: -0.15,
+  dismissedReportFiled: -0.03,
+  // Scaled by how far the response rate is from one half
+  responseRate: 0.2,
+  photoVerified: 0.1,
+  nftVerified: 0.05,
+  ageVerified: 0.1,
+  // Per month of account age, capped
+  accountMonth: 0.01,
+  maxAccountAge: 0.06,
+  clamped: -0.2,
+};
+
+const scoreCache = createCache<number>('trust-score', { ttlSeconds: 60 * 60 });
+
+const clamp = (value: number) => Math.min(1, Math.max(0, value));
+
+export class TrustScore {
+  /**
+   * The account's current score
+   */
+  static async get(userId: string): Promise<number> {
+    return scoreCache.getOrLoad(userId, async () => {
+      const user = await prisma.user.findUnique({
+        where: { id: userId },
+        select: { trustScore: true },
+      });
+      return user?.trustScore ?? NEUTRAL_TRUST;
+    });
+  }
+
+  /**
+   * How much of a normal velocity limit the account gets: less for
+   * accounts we trust little, more for ones we trust a lot
+   */
+  static async rateLimitFactor(userId: string): Promise<number> {
+    const score = await TrustScore.get(userId);
+    if (score < 0.3) {
+      return 0.5;
+    }
+    return score > 0.7 ? 1.5 : 1;
+  }
+
+  /**
+   * Discovery exposure multiplier: 0.5 at zero trust, 1 at neutral, 1.5
+   * at full trust
+   */
+  static exposure(score: number): number {
+    return 0.5 + score;
+  }
+
+  /**
+   * Work the score out from scratch and store it
+   */
+  static async refresh(userId: string): Promise<number | null> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: {
+        createdAt: true,
+        photoVerified: true,
+        photoOverride: true,
+        nftVerified: true,
+        nftOverride: true,
+        ageVerifiedAt: true,
+      },
+    });
+    if (!user) {
+      return null;
+    }
+
+    const reportsSince = new Date(Date.now() - REPORT_LOOKBACK_DAYS * DAY_MS);
+    const likesSince = new Date(Date.now() - RESPONSE_LOOKBACK_DAYS * DAY_MS);
+    const [upheldReports, dismissedReportsFiled, likes, clamped] =
+      await Promise.all([
+        prisma.report.count({
+          where: {
+            reportedUserId: userId,
+            status: 'resolved',
+            action: { not: 'dismiss' },
+            resolvedAt: { gte: reportsSince },
+          },
+        }),
+        prisma.report.count({
+          where: {
+            reporterId: userId,
+            status: 'resolved',
+            action: 'dismiss',
+            resolvedAt: { gte: reportsSince },
+          },
+        }),
+        prisma.signal.findMany({
+          where: {
+            toUserId: userId,
+            type: { in: LIKE_TYPES },
+            suppressed: false,
+            deletedAt: null,
+            sentAt: { gte: likesSince },
+          },
+          select: { fromUserId: true },
+        }),
+        AbuseDetection.isClamped(userId),
+      ]);
+
+    let score = NEUTRAL_TRUST;
+    score += WEIGHTS.upheldReport * upheldReports;
+    score += WEIGHTS.dismissedReportFiled * dismissedReportsFiled;
+
+    if (likes.length >= MIN_LIKES_FOR_RESPONSE_RATE) {
+      // Answered either way: a like back or a pass
+      const likerIds = likes.map(like => like.fromUserId);
+      const [likedBack, passed] = await Promise.all([
+        prisma.signal.count({
+          where: { fromUserId: userId, toUserId: { in: likerIds } },
+        }),
+        prisma.pass.count({
+          where: { userId, passedUserId: { in: likerIds } },
+        }),
+      ]);
+      const rate = Math.min(1, (likedBack + passed) / likes.length);
+      score += WEIGHTS.responseRate * (rate - 0.5) * 2;
+    }
+
+    if (user.photoOverride ?? user.photoVerified) {
+      score += WEIGHTS.photoVerified;
+    }
+    if (user.nftOverride ?? user.nftVerified) {
+      score += WEIGHTS.nftVerified;
+    }
+    if (user.ageVerifiedAt) {
+      score += WEIGHTS.ageVerified;
+    }
+    const months = (Date.now() - user.createdAt.getTime()) / (30 * DAY_MS);
+    score += Math.min(
+      WEIGHTS.maxAccountAge,
+      WEIGHTS.accountMonth * Math.floor(months)
+    );
+    if (clamped) {
+      score += WEIGHTS.clamped;
+    }
+
+    const trustScore = Math.round(clamp(score) * 1000) / 1000;
+    await prisma.user.update({
+      where: { id: userId },
+      data: { trustScore, trustScoredAt: new Date() },
+    });
+    await scoreCache.invalidate(userId);
+    return trustScore;
+  }
+
+  /**
+   * Refresh recently active users whose score is a day old or older
+   */
+  static async refreshStale(): Promise<{ refreshed: number }> {
+    const users = await prisma.user.findMany({
+      where: {
+        status: 'active',
+        deletedAt: null,
+        lastSeen: { gte: new Date(Date.now() - ACTIVE_WITHIN_DAYS * DAY_MS) },
+        OR: [
+          { trustScoredAt: null },
+          { trustScoredAt: { lt: new Date(Date.now() - DAY_MS) } },
+        ],
+      },
+      select: { id: true },
+      orderBy: { trustScoredAt: 'asc' },
+      take: REFRESH_BATCH_SIZE,
+    });
+    for (const user of users) {
+      await TrustScore.refresh(user.id);
+    }
+    return { refreshed: users.length };
+  }
+}
+
+export const trustScoreRefresh: ScheduledTask = {
+  name: 'trust-score-refresh',
+  everyMs: parseInt(process.env.TRUST_REFRESH_INTERVAL_MS || '600000'),
+  run: () => TrustScore.refreshStale(),
+};
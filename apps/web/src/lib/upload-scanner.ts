@@ -0,0 +1,168 @@
+/**
+ * Upload Scanner
+ * Virus scanning for user uploads, run before an upload is stored where
+ * anyone can reach it. The scanner is chosen with UPLOAD_SCANNER: "clamav"
+ * streams the file to a clamd daemon, "http" posts it to a scanning
+ * service, and "none" (the default, for local development) passes
+ * everything. Infected uploads are held in quarantine (see lib/quarantine)
+ * instead of being stored. When the scanner can't be reached uploads are
+ * turned away, unless UPLOAD_SCAN_FAIL_OPEN lets them through unscanned.
+ */
+
+import { Socket } from 'net';
+import { upstreamFetch } from './http-client';
+import { counter, histogram } from './metrics';
+
+export type ScanVerdict =
+  | { status: 'clean' }
+  | { status: 'infected'; scanner: string; signature: string }
+  // The scanner failed and uploads fail closed
+  | { status: 'unavailable' };
+
+// Where an upload came from, for metrics
+export type ScanSource = 'profile_photo' | 'voice_intro';
+
+export interface ScanBackend {
+  name: string;
+  // The signature found, or null if clean; throws if the scan failed
+  scan(body: Buffer): Promise<string | null>;
+}
+
+const SCAN_TIMEOUT_MS = parseInt(process.env.UPLOAD_SCAN_TIMEOUT_MS || '30000');
+
+const FAIL_OPEN = process.env.UPLOAD_SCAN_FAIL_OPEN === 'true';
+
+// clamd's StreamMaxLength is checked per chunk as well as in total
+const CLAMD_CHUNK_BYTES = 64 * 1024;
+
+const scanDuration = histogram(
+  'aurum_upload_scan_duration_seconds',
+  'Time taken to virus-scan an upload, by scanner and result',
+  [0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30]
+);
+
+const scanResults = counter(
+  'aurum_upload_scans_total',
+  'Uploads virus-scanned, by scanner, source and result'
+);
+
+/**
+ * clamd's INSTREAM command: length-prefixed chunks, then a zero length
+ */
+const clamav: ScanBackend = {
+  name: 'clamav',
+  scan: body =>
+    new Promise((resolve, reject) => {
+      const socket = new Socket();
+      let reply = '';
+      socket.setTimeout(SCAN_TIMEOUT_MS, () =>
+        socket.destroy(new Error('clamd timed out'))
+      );
+      socket.on('data', chunk => (reply += chunk.toString()));
+      socket.on('error', reject);
+      socket.on('close', () => {
+        // "stream: OK", "stream: <signature> FOUND" or "... ERROR"
+        const result = reply.replace(/\0/g, '').trim();
+        const found = /^stream: (.+) FOUND$/.exec(result);
+        if (result === 'stream: OK') {
+          resolve(null);
+        } else if (found) {
+          resolve(found[1]);
+        } else {
+          reject(new Error(`clamd replied: ${result || 'nothing'}`));
+        }
+      });
+
+      socket.connect(
+        parseInt(process.env.CLAMAV_PORT || '3310'),
+        process.env.CLAMAV_HOST || '127.0.0.1',
+        () => {
+          socket.write('zINSTREAM\0');
+          for (let i = 0; i < body.length; i += CLAMD_CHUNK_BYTES) {
+            const chunk = body.subarray(i, i + CLAMD_CHUNK_BYTES);
+            const length = Buffer.alloc(4);
+            length.writeUInt32BE(chunk.length);
+            socket.write(length);
+            socket.write(chunk);
+          }
+          socket.end(Buffer.alloc(4));
+        }
+      );
+    }),
+};
+
+/**
+ * A scanning service that takes the file as the request body and answers
+ * { infected, signature }
+ */
+const http: ScanBackend = {
+  name: 'http',
+  scan: async body => {
+    const response = await upstreamFetch(
+      'upload-scanner',
+      process.env.UPLOAD_SCANNER_URL!,
+      {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/octet-stream',
+          ...(process.env.UPLOAD_SCANNER_TOKEN && {
+            Authorization: `Bearer ${process.env.UPLOAD_SCANNER_TOKEN}`,
+          }),
+        },
+        body,
+      },
+      { retries: 1, timeoutMs: SCAN_TIMEOUT_MS }
+    );
+    if (!response.ok) {
+      throw new Error(`Scanning service failed: ${response.status}`);
+    }
+    const result = (await response.json()) as {
+      infected: boolean;
+      signature?: string;
+    };
+    return result.infected ? result.signature || 'unknown' : null;
+  },
+};
+
+const none: ScanBackend = {
+  name: 'none',
+  scan: async () => null,
+};
+
+const BACKENDS: Record<string, ScanBackend> = { clamav, http, none };
+
+function backend(): ScanBackend {
+  const name = process.env.UPLOAD_SCANNER || 'none';
+  const chosen = BACKENDS[name];
+  if (!chosen) {
+    throw new Error(`Unknown UPLOAD_SCANNER: ${name}`);
+  }
+  return chosen;
+}
+
+export class UploadScanner {
+  /**
+   * Scan an upload from `source`
+   */
+  static async scan(body: Buffer, source: ScanSource): Promise<ScanVerdict> {
+    const scanner = backend();
+    const stopTimer = scanDuration.startTimer({ scanner: scanner.name });
+    let verdict: ScanVerdict;
+    let result: string;
+    try {
+      const signature = await scanner.scan(body);
+      verdict = signature
+        ? { status: 'infected', scanner: scanner.name, signature }
+        : { status: 'clean' };
+      result = verdict.status;
+    } catch (error) {
+      console.error(`Upload scan failed (${scanner.name}):`, error);
+      verdict = FAIL_OPEN ? { status: 'clean' } : { status: 'unavailable' };
+      result = 'error';
+    }
+
+    stopTimer({ result });
+    scanResults.inc({ scanner: scanner.name, source, result });
+    return verdict;
+  }
+}
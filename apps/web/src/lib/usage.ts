@@ -0,0 +1,82 @@
+/**
+ * Usage
+ * Everything that limits the user in one place, so the client can show
+ * what's left instead of finding out from 403s and 429s: today's
+ * super-interests, discovery deck and Top Picks allowances, boosts, and
+ * where the user stands against the abuse velocity limits.
+ */
+
+import prisma from './prisma';
+import { Entitlements } from './entitlements';
+import { Inventory } from './inventory';
+import { Boosts, BoostStatus } from './boosts';
+import { AbuseDetection, RateLimitStatus } from './abuse-detection';
+import { TopPicks } from './top-picks';
+import { Tenants } from './tenants';
+import { discoveryQuotas } from './client-config';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+export interface UsageSummary {
+  superInterests: {
+    // Free plus extra_super_interests; super-likes past it spend inventory
+    dailyAllowance: number;
+    usedToday: number;
+    remainingToday: number;
+    inventory: number;
+    // Daily allowances reset at midnight UTC
+    resetsAt: string;
+  };
+  discovery: {
+    deckSize: number;
+    topPicksPerDay: number;
+  };
+  boosts: BoostStatus;
+  rateLimits: RateLimitStatus;
+}
+
+export class Usage {
+  /**
+   * The user's quotas and how much of them is left
+   */
+  static async forUser(userId: string): Promise<UsageSummary> {
+    const startOfDay = new Date();
+    startOfDay.setUTCHours(0, 0, 0, 0);
+
+    const [sentToday, extraSuperInterests, topPicksPerDay, balances, quotas] =
+      await Promise.all([
+        prisma.signal.count({
+          where: {
+            fromUserId: userId,
+            type: 'super_like',
+            sentAt: { gte: startOfDay },
+          },
+        }),
+        Entitlements.getLimit(userId, 'extra_super_interests'),
+        TopPicks.allowance(userId),
+        Inventory.getBalances(userId),
+        Tenants.ofUser(userId).then(discoveryQuotas),
+      ]);
+    const [boosts, rateLimits] = await Promise.all([
+      Boosts.getStatus(userId),
+      AbuseDetection.status(userId),
+    ]);
+
+    const dailyAllowance = quotas.freeDailySuperInterests + extraSuperInterests;
+    return {
+      superInterests: {
+        dailyAllowance,
+        usedToday: sentToday,
+        remainingToday: Math.max(dailyAllowance - sentToday, 0),
+        inventory: balances.super_interest,
+        resetsAt: new Date(startOfDay.getTime() + DAY_MS).toISOString(),
+      },
+      discovery: {
+        deckSize: quotas.deckSize,
+        topPicksPerDay,
+      },
+      boosts,
+      rateLimits,
+    };
+  }
+}
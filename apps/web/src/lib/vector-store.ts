@@ -12,6 +12,7 @@ export interface UserEmbedding {
     frontality: number
     symmetry: number
     resolution: number
+    modelVersion?: string // ML API model version that produced the embedding
   }
   score?: number // Percentile score (calculated dynamically)
   vibeTags?: string[]
@@ -98,6 +99,17 @@ export class FaceVectorStore {
   async hasUser(userId: string): Promise<boolean> {
     return this.embeddings.has(userId)
   }
+
+  /**
+   * Remove a user's embedding (e.g. on account erasure)
+   */
+  async removeEmbedding(userId: string): Promise<boolean> {
+    const removed = this.embeddings.delete(userId)
+    if (removed) {
+      await this.recalculateAllScores()
+    }
+    return removed
+  }
   
   /**
    * Find most similar users to a given embedding
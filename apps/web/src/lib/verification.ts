@@ -0,0 +1,160 @@
+/**
+ * Verification
+ * Effective NFT and photo verification badges. Automated checks set the
+ * badges; an admin override (e.g. during an RPC outage at an event) takes
+ * precedence until it is cleared. Badges are read on every session check,
+ * so they're cached.
+ */
+
+import { Prisma } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { EventBus } from './event-bus';
+import { createCache } from './cache';
+
+export const VERIFICATION_BADGES = ['nft', 'photo'] as const;
+
+export type VerificationBadge = (typeof VERIFICATION_BADGES)[number];
+
+export interface VerificationStatus {
+  nft: boolean;
+  photo: boolean;
+  // Admin overrides in effect; null where the automated check decides
+  overrides: Record<VerificationBadge, boolean | null>;
+}
+
+/**
+ * Users whose effective badge is `verified`
+ */
+export function verifiedWhere(
+  badge: VerificationBadge,
+  verified = true
+): Prisma.UserWhereInput {
+  return badge === 'nft'
+    ? {
+        OR: [
+          { nftOverride: verified },
+          { nftOverride: null, nftVerified: verified },
+        ],
+      }
+    : {
+        OR: [
+          { photoOverride: verified },
+          { photoOverride: null, photoVerified: verified },
+        ],
+      };
+}
+
+async function loadStatus(
+  userId: string
+): Promise<VerificationStatus | null> {
+  const user = await prisma.user.findUnique({
+    where: { id: userId },
+    select: {
+      nftVerified: true,
+      photoVerified: true,
+      nftOverride: true,
+      photoOverride: true,
+    },
+  });
+  if (!user) {
+    return null;
+  }
+
+  return {
+    nft: user.nftOverride ?? user.nftVerified,
+    photo: user.photoOverride ?? user.photoVerified,
+    overrides: { nft: user.nftOverride, photo: user.photoOverride },
+  };
+}
+
+const statusCache = createCache<VerificationStatus | null>('verification', {
+  ttlSeconds: 10 * 60,
+});
+
+export class Verification {
+  /**
+   * Effective badges for a user, or null if the user doesn't exist
+   */
+  static async getStatus(userId: string): Promise<VerificationStatus | null> {
+    return statusCache.getOrLoad(userId, () => loadStatus(userId));
+  }
+
+  static async isVerified(
+    userId: string,
+    badge: VerificationBadge
+  ): Promise<boolean> {
+    const status = await Verification.getStatus(userId);
+    return Boolean(status?.[badge]);
+  }
+
+  /**
+   * Record the outcome of an automated check
+   */
+  static async setAutomated(
+    userId: string,
+    badge: VerificationBadge,
+    verified: boolean
+  ): Promise<void> {
+    const before = await Verification.isVerified(userId, badge);
+    await prisma.user.update({
+      where: { id: userId },
+      data:
+        badge === 'nft'
+          ? { nftVerified: verified }
+          : { photoVerified: verified },
+    });
+    await statusCache.invalidate(userId);
+
+    // An override in effect keeps the badge where it was
+    const after = await Verification.isVerified(userId, badge);
+    if (after !== before) {
+      await EventBus.publish('user.verification_changed', {
+        userId,
+        badge,
+        verified: after,
+      });
+    }
+  }
+
+  /**
+   * Grant (true) or revoke (false) a badge regardless of the automated
+   * check, or clear the override (null) to hand back to it
+   */
+  static async setOverride(
+    userId: string,
+    badge: VerificationBadge,
+    granted: boolean | null,
+    adminId: string,
+    reason: string
+  ): Promise<VerificationStatus> {
+    await prisma.user.update({
+      where: { id: userId },
+      data:
+        badge === 'nft' ? { nftOverride: granted } : { photoOverride: granted },
+    });
+    await statusCache.invalidate(userId);
+    const status = (await Verification.getStatus(userId))!;
+
+    const action =
+      granted === null
+        ? 'admin.verification_override_cleared'
+        : granted
+          ? 'admin.verification_granted'
+          : 'admin.verification_revoked';
+    await AuditLog.record({
+      action,
+      actorType: 'admin',
+      actorId: adminId,
+      targetType: 'user',
+      targetId: userId,
+      details: { badge, reason, verified: status[badge] },
+    });
+    await EventBus.publish('user.verification_changed', {
+      userId,
+      badge,
+      verified: status[badge],
+    });
+    return status;
+  }
+}
@@ -0,0 +1,504 @@
+/**
+ * Voice Intros
+ * Short audio clips on profiles. The client asks for a pre-signed URL,
+ * uploads the raw recording straight to media storage and reports it
+ * complete; a media worker then virus-scans it (see lib/upload-scanner),
+ * checks the format and duration, transcodes it to mono AAC (faststart, so
+ * playback starts before the download ends), and moderates the transcript
+ * before the clip goes live. A new clip replaces the old one only once
+ * it's been approved.
+ */
+
+import { spawn } from 'child_process';
+import { randomUUID } from 'crypto';
+import { mkdtemp, readFile, rm, writeFile } from 'fs/promises';
+import { tmpdir } from 'os';
+import { join } from 'path';
+import { Job, Queue } from 'bullmq';
+import { VoiceIntro } from '@prisma/client';
+import prisma from './prisma';
+import redis from './redis';
+import { MediaStorage, UPLOADS_PREFIX } from './media-storage';
+import { SignedMedia } from './signed-media';
+import { hasContactDetails } from './profile-prompts';
+import { upstreamFetch } from './http-client';
+import { Reports } from './reports';
+import { Quarantine } from './quarantine';
+import { UploadScanner } from './upload-scanner';
+
+export const MEDIA_QUEUE_NAME = 'mediaJobs';
+
+export const VOICE_INTRO_CONTENT_TYPES = [
+  'audio/mp4',
+  'audio/x-m4a',
+  'audio/aac',
+  'audio/mpeg',
+  'audio/webm',
+  'audio/ogg',
+  'audio/wav',
+] as const;
+
+export type VoiceIntroContentType = (typeof VOICE_INTRO_CONTENT_TYPES)[number];
+
+export const VOICE_INTRO_MIN_SECONDS = 3;
+export const VOICE_INTRO_MAX_SECONDS = parseInt(
+  process.env.VOICE_INTRO_MAX_SECONDS || '30'
+);
+export const VOICE_INTRO_MAX_UPLOAD_BYTES = 5 * 1024 * 1024;
+
+const UPLOAD_URL_TTL_SECONDS = 15 * 60;
+const FFMPEG_TIMEOUT_MS = 60_000;
+const OPENAI_TIMEOUT_MS = 30_000;
+
+export const VOICE_INTRO_REJECTION_REASONS = [
+  'upload_missing',
+  'too_large',
+  'failed_scan',
+  'invalid_audio',
+  'too_short',
+  'too_long',
+  'contact_details',
+  'inappropriate_content',
+] as const;
+
+export type VoiceIntroRejectionReason =
+  (typeof VOICE_INTRO_REJECTION_REASONS)[number];
+
+export interface VoiceIntroJobData {
+  voiceIntroId: string;
+}
+
+export interface VoiceIntroView {
+  id: string;
+  status: string;
+  url: string | null;
+  durationMs: number | null;
+  rejectionReason: string | null;
+  createdAt: Date;
+}
+
+export interface PublicVoiceIntro {
+  url: string;
+  durationMs: number;
+}
+
+export type VoiceIntroUpload =
+  | {
+      status: 'created';
+      id: string;
+      uploadUrl: string;
+      // Headers the client must send with the PUT
+      uploadHeaders: Record<string, string>;
+      expiresAt: Date;
+    }
+  | { status: 'too_large' };
+
+export type VoiceIntroCompletion =
+  | { status: 'processing'; intro: VoiceIntroView }
+  | { status: 'not_found' };
+
+export const mediaJobQueue = new Queue<VoiceIntroJobData>(MEDIA_QUEUE_NAME, {
+  connection: redis,
+  defaultJobOptions: {
+    attempts: 2,
+    backoff: {
+      type: 'exponential',
+      delay: 5000,
+    },
+    removeOnComplete: { age: 60 * 60 },
+    removeOnFail: { age: 24 * 60 * 60 },
+  },
+});
+
+function toView(intro: VoiceIntro): VoiceIntroView {
+  return {
+    id: intro.id,
+    status: intro.status,
+    // Only the owner sees this view of an intro
+    url:
+      intro.status === 'ready' && intro.mediaKey
+        ? SignedMedia.url(intro.mediaKey, intro.userId)
+        : null,
+    durationMs: intro.durationMs,
+    rejectionReason: intro.rejectionReason,
+    createdAt: intro.createdAt,
+  };
+}
+
+async function retire(intro: VoiceIntro, status: 'removed' | 'replaced') {
+  await prisma.voiceIntro.update({
+    where: { id: intro.id },
+    data: { status },
+  });
+  if (intro.mediaKey) {
+    await MediaStorage.remove(intro.mediaKey);
+  }
+}
+
+export class VoiceIntros {
+  /**
+   * Reserve an intro and a pre-signed URL to upload its recording to
+   */
+  static async startUpload(
+    userId: string,
+    contentType: VoiceIntroContentType,
+    sizeBytes: number
+  ): Promise<VoiceIntroUpload> {
+    if (sizeBytes > VOICE_INTRO_MAX_UPLOAD_BYTES) {
+      return { status: 'too_large' };
+    }
+
+    const uploadKey = `${UPLOADS_PREFIX}voice-intros/${userId}/${randomUUID()}`;
+    const intro = await prisma.voiceIntro.create({
+      data: { userId, uploadKey },
+    });
+    return {
+      status: 'created',
+      id: intro.id,
+      uploadUrl: await MediaStorage.presignUpload(
+        uploadKey,
+        contentType,
+        UPLOAD_URL_TTL_SECONDS,
+        { kind: 'voice_intro_upload', ownerId: userId }
+      ),
+      uploadHeaders: { 'Content-Type': contentType },
+      expiresAt: new Date(Date.now() + UPLOAD_URL_TTL_SECONDS * 1000),
+    };
+  }
+
+  /**
+   * The client finished uploading: queue the recording for processing
+   */
+  static async completeUpload(
+    userId: string,
+    voiceIntroId: string
+  ): Promise<VoiceIntroCompletion> {
+    const { count } = await prisma.voiceIntro.updateMany({
+      where: { id: voiceIntroId, userId, status: 'pending_upload' },
+      data: { status: 'processing' },
+    });
+    if (count === 0) {
+      return { status: 'not_found' };
+    }
+    await mediaJobQueue.add(
+      'voice_intro',
+      { voiceIntroId },
+      { jobId: `voice_intro:${voiceIntroId}` }
+    );
+    const intro = await prisma.voiceIntro.findUniqueOrThrow({
+      where: { id: voiceIntroId },
+    });
+    return { status: 'processing', intro: toView(intro) };
+  }
+
+  /**
+   * The user's live intro, and their latest submission if it's newer (still
+   * processing, or turned down)
+   */
+  static async getOwn(
+    userId: string
+  ): Promise<{ live: VoiceIntroView | null; latest: VoiceIntroView | null }> {
+    const [live, latest] = await Promise.all([
+      prisma.voiceIntro.findFirst({
+        where: { userId, status: 'ready' },
+        orderBy: { createdAt: 'desc' },
+      }),
+      prisma.voiceIntro.findFirst({
+        where: {
+          userId,
+          status: { in: ['processing', 'ready', 'rejected', 'failed'] },
+        },
+        orderBy: { createdAt: 'desc' },
+      }),
+    ]);
+    return {
+      live: live && toView(live),
+      latest: latest && latest.id !== live?.id ? toView(latest) : null,
+    };
+  }
+
+  /**
+   * Take the user's intro off their profile and delete the clip
+   */
+  static async remove(userId: string): Promise<boolean> {
+    const live = await prisma.voiceIntro.findMany({
+      where: { userId, status: 'ready' },
+    });
+    for (const intro of live) {
+      await retire(intro, 'removed');
+    }
+    return live.length > 0;
+  }
+
+  /**
+   * Live intros for each of `userIds`, with links for `viewerId` only
+   */
+  static async readyFor(
+    viewerId: string,
+    userIds: string[]
+  ): Promise<Map<string, PublicVoiceIntro>> {
+    const byUser = new Map<string, PublicVoiceIntro>();
+    if (userIds.length === 0) {
+      return byUser;
+    }
+    const intros = await prisma.voiceIntro.findMany({
+      where: { userId: { in: userIds }, status: 'ready' },
+      orderBy: { createdAt: 'asc' },
+    });
+    for (const intro of intros) {
+      const url = SignedMedia.url(intro.mediaKey!, viewerId);
+      if (url) {
+        byUser.set(intro.userId, { url, durationMs: intro.durationMs! });
+      }
+    }
+    return byUser;
+  }
+}
+
+/**
+ * Run a command to completion, resolving with its stdout
+ */
+function run(command: string, args: string[]): Promise<string> {
+  return new Promise((resolve, reject) => {
+    const child = spawn(command, args, { timeout: FFMPEG_TIMEOUT_MS });
+    let stdout = '';
+    let stderr = '';
+    child.stdout.on('data', chunk => (stdout += chunk));
+    child.stderr.on('data', chunk => (stderr += chunk));
+    child.on('error', reject);
+    child.on('close', code =>
+      code === 0
+        ? resolve(stdout)
+        : reject(new Error(`${command} exited ${code}: ${stderr.slice(-500)}`))
+    );
+  });
+}
+
+/**
+ * Duration of the recording's audio, or null if it has no audio stream
+ */
+async function probeDuration(path: string): Promise<number | null> {
+  try {
+    const output = await run(process.env.FFPROBE_PATH || 'ffprobe', [
+      '-v',
+      'error',
+      '-show_entries',
+      'format=duration:stream=codec_type',
+      '-of',
+      'json',
+      path,
+    ]);
+    const probe = JSON.parse(output) as {
+      format?: { duration?: string };
+      streams?: { codec_type?: string }[];
+    };
+    const duration = parseFloat(probe.format?.duration ?? '');
+    const hasAudio = probe.streams?.some(s => s.codec_type === 'audio');
+    return hasAudio && Number.isFinite(duration) ? duration : null;
+  } catch {
+    // ffprobe fails on anything it can't parse as media
+    return null;
+  }
+}
+
+async function transcribe(audio: Buffer): Promise<string> {
+  const form = new FormData();
+  form.append('model', 'whisper-1');
+  form.append('file', new Blob([audio], { type: 'audio/mp4' }), 'intro.m4a');
+  const response = await upstreamFetch(
+    'openai',
+    'https://api.openai.com/v1/audio/transcriptions',
+    {
+      method: 'POST',
+      headers: { Authorization: `Bearer ${process.env.OPENAI_API_KEY}` },
+      body: form,
+    },
+    { retries: 1, timeoutMs: OPENAI_TIMEOUT_MS }
+  );
+  if (!response.ok) {
+    throw new Error(`Transcription failed: ${response.status}`);
+  }
+  const { text } = (await response.json()) as { text: string };
+  return text;
+}
+
+async function flaggedCategories(text: string): Promise<string[]> {
+  const response = await upstreamFetch(
+    'openai',
+    'https://api.openai.com/v1/moderations',
+    {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        Authorization: `Bearer ${process.env.OPENAI_API_KEY}`,
+      },
+      body: JSON.stringify({ model: 'omni-moderation-latest', input: text }),
+    },
+    { retries: 1, timeoutMs: OPENAI_TIMEOUT_MS }
+  );
+  if (!response.ok) {
+    throw new Error(`Moderation failed: ${response.status}`);
+  }
+  const { results } = (await response.json()) as {
+    results: { flagged: boolean; categories: Record<string, boolean> }[];
+  };
+  const [result] = results;
+  return result.flagged
+    ? Object.keys(result.categories).filter(name => result.categories[name])
+    : [];
+}
+
+async function reject(
+  intro: VoiceIntro,
+  reason: VoiceIntroRejectionReason,
+  transcript?: string
+) {
+  await prisma.voiceIntro.update({
+    where: { id: intro.id },
+    data: { status: 'rejected', rejectionReason: reason, transcript },
+  });
+  await MediaStorage.remove(intro.uploadKey);
+}
+
+/**
+ * Validate, transcode and moderate the recording, then publish it
+ */
+async function processIntro(intro: VoiceIntro): Promise<void> {
+  const upload = await MediaStorage.download(intro.uploadKey);
+  if (!upload) {
+    return reject(intro, 'upload_missing');
+  }
+  if (upload.length > VOICE_INTRO_MAX_UPLOAD_BYTES) {
+    return reject(intro, 'too_large');
+  }
+  const scan = await UploadScanner.scan(upload, 'voice_intro');
+  if (scan.status === 'unavailable') {
+    // Retried; the raw upload can't be reached meanwhile
+    throw new Error('Upload scanner unavailable');
+  }
+  if (scan.status === 'infected') {
+    await Quarantine.hold(
+      intro.userId,
+      'voice_intro',
+      upload,
+      scan.scanner,
+      scan.signature
+    );
+    return reject(intro, 'failed_scan');
+  }
+
+  const dir = await mkdtemp(join(tmpdir(), 'voice-intro-'));
+  try {
+    const input = join(dir, 'input');
+    const output = join(dir, 'output.m4a');
+    await writeFile(input, upload);
+
+    const duration = await probeDuration(input);
+    if (duration === null) {
+      return reject(intro, 'invalid_audio');
+    }
+    if (duration < VOICE_INTRO_MIN_SECONDS) {
+      return reject(intro, 'too_short');
+    }
+    // A little slack for encoder padding
+    if (duration > VOICE_INTRO_MAX_SECONDS + 0.5) {
+      return reject(intro, 'too_long');
+    }
+
+    await run(process.env.FFMPEG_PATH || 'ffmpeg', [
+      '-v',
+      'error',
+      '-y',
+      '-i',
+      input,
+      '-vn',
+      '-ac',
+      '1',
+      '-ar',
+      '44100',
+      '-c:a',
+      'aac',
+      '-b:a',
+      '64k',
+      '-movflags',
+      '+faststart',
+      output,
+    ]);
+    const clip = await readFile(output);
+
+    // Without an OpenAI key (local development) clips go live unmoderated
+    let transcript: string | null = null;
+    if (process.env.OPENAI_API_KEY) {
+      transcript = await transcribe(clip);
+      if (hasContactDetails(transcript)) {
+        return reject(intro, 'contact_details', transcript);
+      }
+      const categories = await flaggedCategories(transcript);
+      if (categories.length > 0) {
+        await reject(intro, 'inappropriate_content', transcript);
+        await Reports.createSystemReport(
+          intro.userId,
+          'inappropriate_content',
+          {
+            source: 'voice_intro',
+            voiceIntroId: intro.id,
+            transcript,
+            categories,
+          }
+        );
+        return;
+      }
+    }
+
+    const mediaKey = `voice-intros/${intro.userId}/${intro.id}.m4a`;
+    await MediaStorage.upload(mediaKey, clip, 'audio/mp4', {
+      kind: 'voice_intro',
+      ownerId: intro.userId,
+    });
+    const previous = await prisma.voiceIntro.findMany({
+      where: { userId: intro.userId, status: 'ready' },
+    });
+    await prisma.voiceIntro.update({
+      where: { id: intro.id },
+      data: {
+        status: 'ready',
+        mediaKey,
+        durationMs: Math.round(duration * 1000),
+        transcript,
+      },
+    });
+    for (const old of previous) {
+      await retire(old, 'replaced');
+    }
+    await MediaStorage.remove(intro.uploadKey);
+  } finally {
+    await rm(dir, { recursive: true, force: true });
+  }
+}
+
+/**
+ * Worker processor for voice intro jobs
+ */
+export async function processVoiceIntroJob(
+  job: Job<VoiceIntroJobData>
+): Promise<void> {
+  const intro = await prisma.voiceIntro.findUnique({
+    where: { id: job.data.voiceIntroId },
+  });
+  if (intro?.status !== 'processing') {
+    return;
+  }
+
+  try {
+    await processIntro(intro);
+  } catch (error) {
+    // Out of retries: tell the user instead of leaving it processing
+    if (job.attemptsMade + 1 >= (job.opts.attempts ?? 1)) {
+      await prisma.voiceIntro.update({
+        where: { id: intro.id },
+        data: { status: 'failed' },
+      });
+    }
+    throw error;
+  }
+}
@@ -0,0 +1,186 @@
+/**
+ * Waitlist
+ * Sign-ups for the soft launch from people who haven't made an account
+ * yet. An entry is tied to a verified World ID, so each person gets one
+ * place in line however often they sign up; signing up again only
+ * updates their campus and email. Admins export entries and admit them in
+ * batches, oldest first. An admitted entry signs up straight into the
+ * invited cohort (see Cohorts), and anyone who already made an account in
+ * the meantime is admitted with it.
+ */
+
+import { Prisma, WaitlistEntry } from '@prisma/client';
+import prisma from './prisma';
+import { AuditLog } from './audit-log';
+import { Cohorts, MAX_ADMISSION_BATCH } from './cohorts';
+
+export const WAITLIST_STATUSES = ['waiting', 'admitted'] as const;
+
+export type WaitlistStatus = (typeof WAITLIST_STATUSES)[number];
+
+export interface WaitlistSignup {
+  campusId: string;
+  email?: string;
+  verificationLevel?: string;
+}
+
+export interface WaitlistPlace {
+  status: WaitlistStatus;
+  // 1-based place in line while waiting
+  position: number | null;
+  waitlistSize: number;
+  joinedAt: string;
+  admittedAt: string | null;
+}
+
+export interface WaitlistFilters {
+  status?: WaitlistStatus;
+  campusId?: string;
+  limit: number;
+  cursor?: string;
+}
+
+export interface WaitlistAdmission {
+  // Entries admitted
+  admitted: number;
+  // Of those, existing accounts let in off the waitlist
+  accountsAdmitted: number;
+}
+
+async function placeOf(entry: WaitlistEntry): Promise<WaitlistPlace> {
+  const waiting = entry.status === 'waiting';
+  const [waitlistSize, ahead] = await Promise.all([
+    prisma.waitlistEntry.count({ where: { status: 'waiting' } }),
+    waiting
+      ? prisma.waitlistEntry.count({
+          where: { status: 'waiting', createdAt: { lt: entry.createdAt } },
+        })
+      : Promise.resolve(null),
+  ]);
+  return {
+    status: entry.status as WaitlistStatus,
+    position: ahead === null ? null : ahead + 1,
+    waitlistSize,
+    joinedAt: entry.createdAt.toISOString(),
+    admittedAt: entry.admittedAt?.toISOString() ?? null,
+  };
+}
+
+export class Waitlist {
+  /**
+   * Put a World ID on the waitlist, or update its details if it's on
+   * already
+   */
+  static async join(
+    worldId: string,
+    signup: WaitlistSignup
+  ): Promise<{ created: boolean; place: WaitlistPlace }> {
+    const details = {
+      campusId: signup.campusId,
+      email: signup.email?.toLowerCase() ?? null,
+    };
+
+    let created = true;
+    let entry: WaitlistEntry;
+    try {
+      entry = await prisma.waitlistEntry.create({
+        data: {
+          worldId,
+          verificationLevel: signup.verificationLevel ?? null,
+          ...details,
+        },
+      });
+    } catch (error) {
+      if (
+        !(error instanceof Prisma.PrismaClientKnownRequestError) ||
+        error.code !== 'P2002'
+      ) {
+        throw error;
+      }
+      // Already on the list: keep their place
+      created = false;
+      entry = await prisma.waitlistEntry.update({
+        where: { worldId },
+        data: details,
+      });
+    }
+    return { created, place: await placeOf(entry) };
+  }
+
+  /**
+   * Where a World ID is on the waitlist, or null if it isn't on it
+   */
+  static async place(worldId: string): Promise<WaitlistPlace | null> {
+    const entry = await prisma.waitlistEntry.findUnique({
+      where: { worldId },
+    });
+    return entry ? placeOf(entry) : null;
+  }
+
+  /**
+   * A page of entries in queue order, for export
+   */
+  static async list(filters: WaitlistFilters) {
+    return prisma.waitlistEntry.findMany({
+      where: {
+        ...(filters.status && { status: filters.status }),
+        ...(filters.campusId && { campusId: filters.campusId }),
+      },
+      orderBy: [{ createdAt: 'asc' }, { id: 'asc' }],
+      take: filters.limit,
+      ...(filters.cursor && { cursor: { id: filters.cursor }, skip: 1 }),
+    });
+  }
+
+  /**
+   * Admit the next `count` waiting entries, optionally from one campus,
+   * along with any accounts they've already made
+   */
+  static async admit(
+    count: number,
+    adminId: string,
+    campusId?: string
+  ): Promise<WaitlistAdmission> {
+    const entries = await prisma.waitlistEntry.findMany({
+      where: { status: 'waiting', ...(campusId && { campusId }) },
+      select: { id: true, worldId: true },
+      orderBy: { createdAt: 'asc' },
+      take: Math.min(count, MAX_ADMISSION_BATCH),
+    });
+    if (entries.length === 0) {
+      return { admitted: 0, accountsAdmitted: 0 };
+    }
+
+    await prisma.waitlistEntry.updateMany({
+      where: { id: { in: entries.map(entry => entry.id) }, status: 'waiting' },
+      data: { status: 'admitted', admittedAt: new Date() },
+    });
+
+    await AuditLog.record({
+      action: 'admin.waitlist_admitted',
+      actorType: 'admin',
+      actorId: adminId,
+      details: { count: entries.length, campusId: campusId ?? null },
+    });
+
+    const accounts = await prisma.user.findMany({
+      where: {
+        worldId: { in: entries.map(entry => entry.worldId) },
+        cohort: 'waitlist',
+      },
+      select: { id: true },
+    });
+    const accountsAdmitted =
+      accounts.length > 0
+        ? await Cohorts.admit(
+            { userIds: accounts.map(account => account.id) },
+            adminId
+          )
+        : [];
+
+    return {
+      admitted: entries.length,
+      accountsAdmitted: accountsAdmitted.length,
+    };
+  }
+}
@@ -0,0 +1,164 @@
+/**
+ * Waking Hours
+ * Keeps scheduled, non-urgent notifications (digests, reminders) out of
+ * the night in the user's own timezone. The timezone is the one the user
+ * picked, else the one their app last reported, else their city's. Quiet
+ * hours default to 22:00-08:00 until the user sets their own.
+ */
+
+import prisma from './prisma';
+
+const DEFAULT_TIMEZONE = process.env.DEFAULT_TIMEZONE || 'UTC';
+
+const DEFAULT_QUIET_HOURS_START = parseInt(
+  process.env.DEFAULT_QUIET_HOURS_START || '22'
+);
+const DEFAULT_QUIET_HOURS_END = parseInt(
+  process.env.DEFAULT_QUIET_HOURS_END || '8'
+);
+
+const HOUR_MS = 60 * 60 * 1000;
+
+export interface WakingPreferences {
+  timezone: string;
+  quietHoursStart: number;
+  quietHoursEnd: number;
+}
+
+/**
+ * Whether `timezone` is an IANA zone this runtime knows
+ */
+export function isValidTimezone(timezone: string): boolean {
+  try {
+    new Intl.DateTimeFormat('en-US', { timeZone: timezone });
+    return true;
+  } catch {
+    return false;
+  }
+}
+
+export function localHour(date: Date, timezone: string): number {
+  return parseInt(
+    new Intl.DateTimeFormat('en-US', {
+      timeZone: timezone,
+      hour: 'numeric',
+      hourCycle: 'h23',
+    }).format(date)
+  );
+}
+
+/**
+ * The calendar date (YYYY-MM-DD) at `date` in `timezone`
+ */
+export function localDate(date: Date, timezone: string): string {
+  return new Intl.DateTimeFormat('en-CA', {
+    timeZone: timezone,
+    year: 'numeric',
+    month: '2-digit',
+    day: '2-digit',
+  }).format(date);
+}
+
+/**
+ * Whether `hour` falls in quiet hours running from `start` up to `end`,
+ * which may wrap past midnight (e.g. 22 to 8). Equal bounds mean none.
+ */
+export function inQuietHours(hour: number, start: number, end: number) {
+  if (start === end) {
+    return false;
+  }
+  return start < end
+    ? hour >= start && hour < end
+    : hour >= start || hour < end;
+}
+
+/**
+ * `from` if it's outside quiet hours, otherwise the first hour after them
+ */
+export function nextWakingTime(
+  from: Date,
+  preferences: WakingPreferences
+): Date {
+  const { timezone, quietHoursStart: start, quietHoursEnd: end } = preferences;
+  if (!inQuietHours(localHour(from, timezone), start, end)) {
+    return from;
+  }
+
+  let slot = new Date(Math.ceil(from.getTime() / HOUR_MS) * HOUR_MS);
+  // Quiet hours never cover the whole day, so this ends within 24 steps
+  for (
+    let step = 0;
+    step < 24 && inQuietHours(localHour(slot, timezone), start, end);
+    step++
+  ) {
+    slot = new Date(slot.getTime() + HOUR_MS);
+  }
+  return slot;
+}
+
+export class WakingHours {
+  /**
+   * The user's timezone and quiet hours, filling in what they haven't set
+   */
+  static async preferences(userId: string): Promise<WakingPreferences> {
+    const user = await prisma.user.findUnique({
+      where: { id: userId },
+      select: {
+        timezone: true,
+        detectedTimezone: true,
+        quietHoursStart: true,
+        quietHoursEnd: true,
+        city: { select: { timezone: true } },
+      },
+    });
+
+    const timezone =
+      user?.timezone ||
+      user?.detectedTimezone ||
+      user?.city?.timezone ||
+      DEFAULT_TIMEZONE;
+    if (user?.quietHoursStart == null || user.quietHoursEnd == null) {
+      return {
+        timezone,
+        quietHoursStart: DEFAULT_QUIET_HOURS_START,
+        quietHoursEnd: DEFAULT_QUIET_HOURS_END,
+      };
+    }
+    return {
+      timezone,
+      quietHoursStart: user.quietHoursStart,
+      quietHoursEnd: user.quietHoursEnd,
+    };
+  }
+
+  /**
+   * The first time at or after `from` the user is awake
+   */
+  static async nextWakingTime(userId: string, from: Date): Promise<Date> {
+    return nextWakingTime(from, await WakingHours.preferences(userId));
+  }
+
+  /**
+   * Remember the timezone the user's device reports. Used until the user
+   * picks one in settings.
+   */
+  static async recordDetectedTimezone(
+    userId: string,
+    timezone: string
+  ): Promise<boolean> {
+    if (!isValidTimezone(timezone)) {
+      return false;
+    }
+    await prisma.user.updateMany({
+      where: {
+        id: userId,
+        OR: [
+          { detectedTimezone: null },
+          { detectedTimezone: { not: timezone } },
+        ],
+      },
+      data: { detectedTimezone: timezone },
+    });
+    return true;
+  }
+}
@@ -0,0 +1,60 @@
+/**
+ * World App Payments
+ * Verifies MiniKit pay transactions against the Developer Portal API
+ */
+
+import { upstreamFetch } from './http-client';
+
+const TRANSACTION_API_URL =
+  'https://developer.worldcoin.org/api/v2/minikit/transaction';
+
+export interface WorldAppTransaction {
+  transactionId: string;
+  reference: string;
+  status: 'pending' | 'mined' | 'failed';
+  to: string;
+  token: string;
+  tokenAmount: string;
+  transactionHash?: string;
+}
+
+/**
+ * Look up a MiniKit transaction. Throws if the Developer Portal can't be
+ * reached or rejects the lookup.
+ */
+export async function fetchWorldAppTransaction(
+  transactionId: string
+): Promise<WorldAppTransaction> {
+  const appId = process.env.NEXT_PUBLIC_WORLDCOIN_APP_ID;
+  const apiKey = process.env.WORLD_APP_API_KEY;
+  if (!appId || !apiKey) {
+    throw new Error('World App payments are not configured');
+  }
+
+  const response = await upstreamFetch(
+    'worldcoin',
+    `${TRANSACTION_API_URL}/${encodeURIComponent(transactionId)}?app_id=${appId}&type=payment`,
+    {
+      method: 'GET',
+      headers: { Authorization: `Bearer ${apiKey}` },
+    },
+    { retries: 2, timeoutMs: 10000 }
+  );
+
+  if (!response.ok) {
+    throw new Error(
+      `Transaction lookup failed with HTTP ${response.status}: ${await response.text()}`
+    );
+  }
+
+  const data = await response.json();
+  return {
+    transactionId: data.transaction_id || transactionId,
+    reference: data.reference,
+    status: data.transaction_status,
+    to: data.to,
+    token: data.token,
+    tokenAmount: data.token_amount,
+    transactionHash: data.transaction_hash,
+  };
+}
@@ -0,0 +1,43 @@
+/**
+ * Edge Middleware
+ * Refuses API requests from app builds older than APP_MIN_VERSION (see
+ * lib/app-version) before they reach a route handler
+ */
+
+import { NextRequest, NextResponse } from 'next/server';
+import {
+  APP_VERSION_HEADER,
+  AppVersion,
+  minimumVersion,
+} from '@/lib/app-version';
+
+// Reachable by outdated builds so they can find out they must upgrade
+const UNGATED_PATHS = ['/api/meta/config', '/api/health'];
+
+export function middleware(request: NextRequest) {
+  const { pathname } = request.nextUrl;
+  if (UNGATED_PATHS.some(path => pathname.startsWith(path))) {
+    return NextResponse.next();
+  }
+
+  const version = request.headers.get(APP_VERSION_HEADER);
+  if (AppVersion.status(version) !== 'upgrade_required') {
+    return NextResponse.next();
+  }
+
+  return NextResponse.json(
+    {
+      success: false,
+      message: 'This version of the app is no longer supported',
+      error_type: 'upgrade_required',
+      currentVersion: version,
+      minimumVersion: minimumVersion(),
+      upgradeUrl: process.env.APP_UPGRADE_URL || null,
+    },
+    { status: 426 }
+  );
+}
+
+export const config = {
+  matcher: '/api/:path*',
+};
@@ -0,0 +1,25 @@
+/**
+ * Admin Middleware
+ * Restricts admin routes to the World IDs listed in ADMIN_WORLD_IDS (the
+ * admin role; see lib/access-policy)
+ */
+
+import { NextRequest } from 'next/server';
+import { getSession } from './auth';
+import { checkPolicy, subjectOf } from './policy';
+import { AccessPolicy } from '@/lib/access-policy';
+
+/**
+ * The signed-in admin's World ID, or null if the caller isn't an admin
+ */
+export async function getAdminId(request: NextRequest): Promise<string | null> {
+  const session = await getSession(request);
+  if (!session || AccessPolicy.roleOf(subjectOf(session)) !== 'admin') {
+    return null;
+  }
+  return session.worldId;
+}
+
+export async function requireAdmin(request: NextRequest) {
+  return checkPolicy(request, [{ role: 'admin' }]);
+}
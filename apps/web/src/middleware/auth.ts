@@ -0,0 +1,239 @@
+/**
+ * Auth Middleware
+ * Verifies the World ID session cookie and exposes its claims to handlers
+ */
+
+import { NextRequest, NextResponse } from 'next/server';
+import { JwtKeys } from '@/lib/jwt-keys';
+import { Bans } from '@/lib/bans';
+import { AccountData } from '@/lib/account-data';
+import { AccountDeletion, restorableUntil } from '@/lib/account-deletion';
+import { Analytics } from '@/lib/analytics';
+import { Presence } from '@/lib/presence';
+import { AuditLog } from '@/lib/audit-log';
+import { ClientIp } from '@/lib/client-ip';
+import { Policies, toPolicySummary } from '@/lib/policies';
+import { Cohorts } from '@/lib/cohorts';
+import {
+  ImpersonationClaims,
+  impersonationRestriction,
+} from '@/lib/impersonation';
+
+export const SESSION_COOKIE = 'worldid-session';
+
+// The one route a deleted (but not yet purged) account may call
+const RESTORE_PATH = '/api/users/me/restore';
+
+// Routes that work before the current policies are accepted: accepting
+// them, and leaving (deleting the account or asking for its data)
+const POLICY_EXEMPT_PATHS = new Set([
+  '/api/users/me/policies',
+  '/api/users/me',
+  RESTORE_PATH,
+  '/api/users/me/privacy-requests',
+]);
+
+// Routes waitlisted users may call during the soft launch: the above, and
+// finding out where they are in the queue
+const WAITLIST_EXEMPT_PATHS = new Set([
+  ...POLICY_EXEMPT_PATHS,
+  '/api/users/me/cohort',
+]);
+
+export interface Session {
+  worldId: string;
+  // World ID verification level ("orb" or "device")
+  verificationLevel?: string;
+  profileId?: string;
+  // Campus instance (see lib/tenants), once the profile exists
+  tenantId?: string;
+  profileCompleted: boolean;
+  walletAddress?: string;
+  nftVerified: boolean;
+  // Present when an admin is impersonating the user
+  impersonation?: ImpersonationClaims;
+}
+
+/**
+ * Decode the session cookie, or null if it's missing or invalid
+ */
+export async function getSession(
+  request: NextRequest
+): Promise<Session | null> {
+  const sessionCookie = request.cookies.get(SESSION_COOKIE);
+  if (!sessionCookie) {
+    return null;
+  }
+
+  try {
+    const { payload } = await JwtKeys.verify(sessionCookie.value);
+    return {
+      worldId: payload.worldId as string,
+      verificationLevel: payload.verificationLevel as string | undefined,
+      profileId: payload.profileId as string | undefined,
+      tenantId: payload.tenantId as string | undefined,
+      profileCompleted: Boolean(payload.profileCompleted),
+      walletAddress: payload.walletAddress as string | undefined,
+      nftVerified: Boolean(payload.nftVerified),
+      impersonation: payload.impersonation as ImpersonationClaims | undefined,
+    };
+  } catch {
+    return null;
+  }
+}
+
+/**
+ * Watermark and audit an impersonated request, or reject it if it's out of
+ * the impersonation's scope
+ */
+async function checkImpersonation(
+  request: NextRequest,
+  session: Session,
+  impersonation: ImpersonationClaims
+) {
+  const { pathname } = request.nextUrl;
+  const restriction = impersonationRestriction(
+    impersonation,
+    request.method,
+    pathname
+  );
+
+  console.warn('🎭 Impersonated request:', {
+    impersonationId: impersonation.id,
+    adminId: impersonation.adminId,
+    userId: session.profileId,
+    method: request.method,
+    path: pathname,
+    allowed: !restriction,
+  });
+  await AuditLog.recordSafely({
+    action: 'admin.impersonated_request',
+    actorType: 'admin',
+    actorId: impersonation.adminId,
+    targetType: 'user',
+    targetId: session.profileId,
+    details: {
+      impersonationId: impersonation.id,
+      method: request.method,
+      path: pathname,
+      allowed: !restriction,
+    },
+    ipAddress: ClientIp.of(request),
+  });
+
+  if (restriction) {
+    return NextResponse.json(
+      {
+        success: false,
+        message: restriction,
+        error_type: 'impersonation_restricted',
+      },
+      { status: 403 }
+    );
+  }
+  return null;
+}
+
+/**
+ * Reject requests without a valid session and completed profile,
+ * requests from banned or deleted accounts, requests from users who
+ * haven't accepted the current terms and privacy policy, and requests
+ * from users still on the soft-launch waitlist
+ */
+export async function authMiddleware(request: NextRequest) {
+  const session = await getSession(request);
+  if (!session) {
+    return NextResponse.json(
+      { success: false, message: 'Session required' },
+      { status: 401 }
+    );
+  }
+
+  if (!session.profileCompleted || !session.profileId) {
+    return NextResponse.json(
+      { success: false, message: 'Profile setup required' },
+      { status: 400 }
+    );
+  }
+
+  const ban = await Bans.getActiveBan(session.profileId);
+  if (ban) {
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'This account has been suspended',
+        error_type: 'account_banned',
+        reason: ban.reason,
+        bannedUntil: ban.until,
+      },
+      { status: 403 }
+    );
+  }
+
+  // Sessions issued before an erasure must stop working
+  if (await AccountData.isErased(session.profileId)) {
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'This account has been deleted',
+        error_type: 'account_deleted',
+      },
+      { status: 401 }
+    );
+  }
+
+  // Deleted accounts can only be restored until they're purged
+  const deletedAt = await AccountDeletion.deletedAt(session.profileId);
+  if (deletedAt && request.nextUrl.pathname !== RESTORE_PATH) {
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'This account has been deleted',
+        error_type: 'account_pending_deletion',
+        restorableUntil: restorableUntil(deletedAt),
+      },
+      { status: 403 }
+    );
+  }
+
+  // Impersonating admins can't accept policies for the user, so the
+  // policy check doesn't apply to them
+  if (session.impersonation) {
+    return checkImpersonation(request, session, session.impersonation);
+  }
+
+  if (!POLICY_EXEMPT_PATHS.has(request.nextUrl.pathname)) {
+    const pending = await Policies.pendingFor(session.profileId);
+    if (pending.length > 0) {
+      return NextResponse.json(
+        {
+          success: false,
+          message: 'Please accept the updated terms to continue',
+          error_type: 'policy_acceptance_required',
+          pending: pending.map(toPolicySummary),
+        },
+        { status: 403 }
+      );
+    }
+  }
+
+  if (
+    !WAITLIST_EXEMPT_PATHS.has(request.nextUrl.pathname) &&
+    (await Cohorts.isWaitlisted(session.profileId))
+  ) {
+    return NextResponse.json(
+      {
+        success: false,
+        message: "You're on the waitlist. We'll let you know when you're in",
+        error_type: 'waitlisted',
+      },
+      { status: 403 }
+    );
+  }
+
+  // Fire-and-forget; feeds DAU/WAU and online status
+  void Analytics.recordActive(session.profileId);
+  void Presence.touch(session.profileId);
+
+  return null; // Continue with the request
+}
@@ -0,0 +1,78 @@
+/**
+ * Policy Middleware
+ * Enforces the route's access policy (see lib/access-policy) for the
+ * signed-in caller
+ */
+
+import { NextRequest, NextResponse } from 'next/server';
+import { authMiddleware, getSession, Session } from './auth';
+import { AccessPolicy, Rule, Subject } from '@/lib/access-policy';
+
+export function subjectOf(session: Session): Subject {
+  return {
+    worldId: session.worldId,
+    userId: session.profileId ?? null,
+    verificationLevel: session.verificationLevel,
+    tenantId: session.tenantId ?? null,
+    impersonating: Boolean(session.impersonation),
+  };
+}
+
+/**
+ * Check `rules` (by default, the route's policy) against the session,
+ * without the account checks authMiddleware makes. For routes that
+ * authenticate the caller themselves.
+ */
+export async function checkPolicy(
+  request: NextRequest,
+  rules?: Rule[]
+): Promise<NextResponse | null> {
+  const session = await getSession(request);
+  if (!session) {
+    return NextResponse.json(
+      { success: false, message: 'Session required' },
+      { status: 401 }
+    );
+  }
+
+  try {
+    const decision = await AccessPolicy.evaluate(
+      subjectOf(session),
+      rules ??
+        AccessPolicy.forRoute(request.method, request.nextUrl.pathname)
+    );
+    if (!decision.allowed) {
+      const { status, message, errorType, details } = decision.denial;
+      return NextResponse.json(
+        { success: false, message, error_type: errorType, ...details },
+        { status }
+      );
+    }
+  } catch (error) {
+    console.error('Access policy error:', error);
+    return NextResponse.json(
+      {
+        success: false,
+        message: 'Failed to check access',
+        error: 'SERVER_ERROR',
+      },
+      { status: 500 }
+    );
+  }
+
+  return null; // Continue with the request
+}
+
+/**
+ * Authenticate the caller (see authMiddleware), then enforce the route's
+ * policy
+ */
+export async function authorize(
+  request: NextRequest
+): Promise<NextResponse | null> {
+  const authResponse = await authMiddleware(request);
+  if (authResponse) {
+    return authResponse;
+  }
+  return checkPolicy(request);
+}
@@ -4,12 +4,8 @@
  */
 
 import { NextRequest, NextResponse } from "next/server";
-import Redis from "ioredis";
-
-// Initialize Redis client
-const redis = new Redis(process.env.REDIS_URL || "redis://redis:6379", {
-  maxRetriesPerRequest: null,
-});
+import redis from "@/lib/redis";
+import { ClientIp } from "@/lib/client-ip";
 
 // Rate limit configuration
 const RATE_LIMITS = {
@@ -17,6 +13,22 @@ const RATE_LIMITS = {
     limit: 10, // 10 requests
     window: 60, // per 60 seconds (1 minute)
   },
+  "/api/score/jobs": {
+    limit: 5, // 5 jobs
+    window: 60, // per 60 seconds (1 minute)
+  },
+  "/api/analytics/events": {
+    limit: 60, // 60 batches
+    window: 60, // per 60 seconds (1 minute)
+  },
+  "/api/qr": {
+    limit: 30, // 30 codes
+    window: 60, // per 60 seconds (1 minute)
+  },
+  "/api/waitlist": {
+    limit: 10, // 10 sign-ups
+    window: 60, // per 60 seconds (1 minute)
+  },
 };
 
 export async function rateLimitMiddleware(request: NextRequest) {
@@ -28,12 +40,8 @@ export async function rateLimitMiddleware(request: NextRequest) {
     return null; // No rate limiting for this path
   }
 
-  // Get IP address
-  const ip =
-    request.headers.get("x-forwarded-for") ||
-    request.headers.get("x-real-ip") ||
-    (request as unknown as { ip?: string }).ip ||
-    "unknown";
+  // Get IP address (the client's, not the proxy's)
+  const ip = ClientIp.of(request) || "unknown";
 
   // Create key for this IP and path
   const key = `rate_limit:${pathname}:${ip}`;
@@ -0,0 +1,42 @@
+/**
+ * Image Processing Worker
+ * Preprocesses uploaded images with the ML API outside the request path
+ */
+
+import { Worker, Job } from 'bullmq';
+import { Secrets } from '@/lib/secrets';
+
+async function main() {
+  // Loaded first: the modules below read their config as they load
+  await Secrets.load();
+  Secrets.startRotation();
+  const { default: redis } = await import('@/lib/redis');
+  const { IMAGE_PROCESSING_QUEUE_NAME, processImageJob } = await import(
+    '@/lib/image-processing-queue'
+  );
+
+  const imageProcessingWorker = new Worker(
+    IMAGE_PROCESSING_QUEUE_NAME,
+    processImageJob,
+    { connection: redis }
+  );
+
+  imageProcessingWorker.on('completed', (job: Job) => {
+    console.log(`Image processing job ${job.id} completed`);
+  });
+
+  imageProcessingWorker.on('failed', (job: Job | undefined, err: Error) => {
+    console.error(`Image processing job ${job?.id} failed:`, err);
+  });
+
+  // Graceful shutdown
+  process.on('SIGTERM', async () => {
+    await imageProcessingWorker.close();
+    await redis.quit();
+  });
+}
+
+main().catch(error => {
+  console.error('Image processing worker failed to start:', error);
+  process.exit(1);
+});
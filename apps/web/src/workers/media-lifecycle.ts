@@ -0,0 +1,34 @@
+/**
+ * Media Lifecycle
+ * One-off run when setting up a bucket (or changing
+ * MEDIA_UPLOAD_EXPIRY_DAYS): sets its lifecycle rules so raw uploads and
+ * incomplete multipart uploads expire on their own, even if the media GC
+ * isn't running (see lib/media-storage). Safe to run again.
+ */
+
+import { Secrets } from '@/lib/secrets';
+
+async function main() {
+  // Loaded first: the modules below read their config as they load
+  await Secrets.load();
+  const { MediaStorage, mediaStorageEnabled } = await import(
+    '@/lib/media-storage'
+  );
+  const { default: prisma } = await import('@/lib/prisma');
+
+  try {
+    if (!mediaStorageEnabled()) {
+      throw new Error('Media storage is not configured');
+    }
+    const days = parseInt(process.env.MEDIA_UPLOAD_EXPIRY_DAYS || '7');
+    await MediaStorage.setLifecycle(days);
+    console.log(`🗑️ Raw uploads now expire after ${days} days`);
+  } finally {
+    await prisma.$disconnect();
+  }
+}
+
+main().catch(error => {
+  console.error('Media lifecycle update failed:', error);
+  process.exit(1);
+});
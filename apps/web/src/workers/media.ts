@@ -0,0 +1,47 @@
+/**
+ * Media Worker
+ * Transcodes and moderates uploaded media (voice intros) outside the
+ * request path. Needs ffmpeg and ffprobe on the PATH (or FFMPEG_PATH and
+ * FFPROBE_PATH).
+ */
+
+import { Worker, Job } from 'bullmq';
+import { Secrets } from '@/lib/secrets';
+import type { VoiceIntroJobData } from '@/lib/voice-intros';
+
+const concurrency = parseInt(process.env.MEDIA_WORKER_CONCURRENCY || '2');
+
+async function main() {
+  // Loaded first: the modules below read their config as they load
+  await Secrets.load();
+  Secrets.startRotation();
+  const { default: redis } = await import('@/lib/redis');
+  const { MEDIA_QUEUE_NAME, processVoiceIntroJob } = await import(
+    '@/lib/voice-intros'
+  );
+
+  const mediaWorker = new Worker<VoiceIntroJobData>(
+    MEDIA_QUEUE_NAME,
+    processVoiceIntroJob,
+    { connection: redis, concurrency }
+  );
+
+  mediaWorker.on('completed', (job: Job) => {
+    console.log(`Media job ${job.id} completed`);
+  });
+
+  mediaWorker.on('failed', (job: Job | undefined, err: Error) => {
+    console.error(`Media job ${job?.id} failed:`, err);
+  });
+
+  // Graceful shutdown
+  process.on('SIGTERM', async () => {
+    await mediaWorker.close();
+    await redis.quit();
+  });
+}
+
+main().catch(error => {
+  console.error('Media worker failed to start:', error);
+  process.exit(1);
+});
@@ -0,0 +1,27 @@
+/**
+ * PII Rewrap
+ * One-off run after rotating PII_MASTER_KEYS: moves every encrypted field
+ * onto the current master key, encrypts any values still stored in
+ * plaintext and writes their blind indexes (see lib/field-encryption).
+ * Also run after changing PII_INDEX_KEY. Safe to run again.
+ */
+
+import { Secrets } from '@/lib/secrets';
+
+async function main() {
+  // Loaded first: the modules below read their config as they load
+  await Secrets.load();
+  const { default: prisma } = await import('@/lib/prisma');
+  const { FieldEncryption } = await import('@/lib/field-encryption');
+
+  try {
+    await FieldEncryption.rewrap(prisma);
+  } finally {
+    await prisma.$disconnect();
+  }
+}
+
+main().catch(error => {
+  console.error('PII rewrap failed:', error);
+  process.exit(1);
+});
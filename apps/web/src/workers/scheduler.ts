@@ -0,0 +1,36 @@
+/**
+ * Scheduler Worker
+ * Runs recurring and delayed background tasks
+ */
+
+import { Job } from 'bullmq';
+import { Secrets } from '@/lib/secrets';
+
+async function main() {
+  // Loaded first: the modules below read their config as they load
+  await Secrets.load();
+  Secrets.startRotation();
+  const { default: redis } = await import('@/lib/redis');
+  const { Scheduler } = await import('@/lib/scheduler');
+  const { SCHEDULED_TASKS } = await import('@/lib/scheduled-tasks');
+
+  const worker = await Scheduler.start(SCHEDULED_TASKS);
+
+  worker.on('failed', (job: Job | undefined, err: Error) => {
+    console.error(`Scheduled task ${job?.name} (${job?.id}) failed:`, err);
+  });
+
+  const taskNames = SCHEDULED_TASKS.map(task => task.name).join(', ');
+  console.log(`Scheduler started with tasks: ${taskNames}`);
+
+  // Graceful shutdown
+  process.on('SIGTERM', async () => {
+    await worker.close();
+    await redis.quit();
+  });
+}
+
+main().catch(error => {
+  console.error('Scheduler failed to start:', error);
+  process.exit(1);
+});
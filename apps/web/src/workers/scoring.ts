@@ -0,0 +1,45 @@
+/**
+ * Scoring Worker
+ * Processes queued scoring jobs outside the request path
+ */
+
+import { Worker, Job } from 'bullmq';
+import { Secrets } from '@/lib/secrets';
+import type { ScoringJobData, ScoringJobResult } from '@/lib/scoring-jobs';
+
+const concurrency = parseInt(process.env.SCORING_WORKER_CONCURRENCY || '2');
+
+async function main() {
+  // Loaded first: the modules below read their config as they load
+  await Secrets.load();
+  Secrets.startRotation();
+  const { default: redis } = await import('@/lib/redis');
+  const { SCORING_QUEUE_NAME, processScoringJob } = await import(
+    '@/lib/scoring-jobs'
+  );
+
+  const scoringWorker = new Worker<ScoringJobData, ScoringJobResult>(
+    SCORING_QUEUE_NAME,
+    processScoringJob,
+    { connection: redis, concurrency }
+  );
+
+  scoringWorker.on('completed', (job: Job) => {
+    console.log(`Scoring job ${job.id} completed`);
+  });
+
+  scoringWorker.on('failed', (job: Job | undefined, err: Error) => {
+    console.error(`Scoring job ${job?.id} failed:`, err);
+  });
+
+  // Graceful shutdown
+  process.on('SIGTERM', async () => {
+    await scoringWorker.close();
+    await redis.quit();
+  });
+}
+
+main().catch(error => {
+  console.error('Scoring worker failed to start:', error);
+  process.exit(1);
+});
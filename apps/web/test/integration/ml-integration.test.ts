@@ -10,6 +10,9 @@ import {
   attractivenessEngineV2Simulated,
 } from '@/lib/attractiveness-engine-v2';
 
+// The ML service can be slow to warm up
+jest.setTimeout(60000);
+
 // Test constants
 const TEST_USER_ID = 'test-user-ml-integration';
 const TEST_IMAGE_BASE64 =
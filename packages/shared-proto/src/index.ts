@@ -0,0 +1,10 @@
+/**
+ * @description Protobuf messages shared between Aurum services, generated
+ * from proto/ by `npm run generate`
+ * @version 1.0.0
+ */
+
+export * from './gen/aurum/v1/user';
+export * from './gen/aurum/v1/signal';
+export * from './gen/aurum/v1/match';
+export * from './gen/aurum/v1/events';